@@ -63,11 +63,12 @@ func TestConstructStorage(t *testing.T) {
 	constructStorage()
 }
 
-func constructAndUseAppender() {
+func constructAndUseAppender(dir string) {
 	ctx := context.Background()
 	data := []byte("hello")
 
-	driver, _ := posix.New(ctx, "/tmp/mylog")
+	// Each run uses a new signer, so a fresh log directory is needed to avoid tripping the log identity check.
+	driver, _ := posix.New(ctx, dir)
 	signer := createSigner()
 
 	// #region use_appender_example
@@ -86,7 +87,7 @@ func constructAndUseAppender() {
 }
 
 func TestConstructAndUseAppender(t *testing.T) {
-	constructAndUseAppender()
+	constructAndUseAppender(t.TempDir())
 }
 
 func createSigner() note.Signer {
@@ -0,0 +1,251 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultAddHandlerMaxBodySize is the default limit on the size of /add request bodies.
+	DefaultAddHandlerMaxBodySize = 1 << 20
+
+	addJSONContType = "application/json"
+)
+
+// AddRequest is the body of a JSON /add request.
+type AddRequest struct {
+	// Data is the entry to add to the log.
+	Data []byte `json:"data"`
+	// Metadata is optional information about the entry supplied by the submitter, which is made
+	// available to AddHandlerOptions.NewEntry. It isn't stored in the log unless NewEntry does so.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// InclusionProof requests that the response is delayed until a checkpoint committing to the entry
+	// has been published, and includes a proof of the entry's inclusion in that checkpoint.
+	InclusionProof bool `json:"inclusion_proof,omitempty"`
+}
+
+// AddResponse is the body of the response to a successful JSON /add request.
+type AddResponse struct {
+	// Index is the index assigned to the entry.
+	Index uint64 `json:"index"`
+	// Duplicate is true if the entry was already present in the log, in which case Index is the index
+	// it was previously assigned.
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Checkpoint is a hint as to the state of the log.
+	//
+	// If an inclusion proof was requested, this is the checkpoint which InclusionProof is relative to.
+	// Otherwise it is the latest checkpoint at the time of the response, which may not yet commit to
+	// the entry; clients can compare its size with Index to find out whether it does.
+	// It is omitted if the handler has no AddHandlerOptions.Reader.
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// InclusionProof is the proof of inclusion of the entry in Checkpoint, if one was requested.
+	InclusionProof [][]byte `json:"inclusion_proof,omitempty"`
+}
+
+// AddHandlerOptions configures the handler returned by NewAddHandler.
+type AddHandlerOptions struct {
+	// Reader is used to read the log's checkpoint and tiles for JSON responses.
+	//
+	// This is optional; if unset, JSON responses don't include a checkpoint, and requests for inclusion
+	// proofs fail with 501 Not Implemented.
+	Reader LogReader
+
+	// Awaiter is used to wait for entries to be published when an inclusion proof is requested.
+	// It should be created with the same LogReader as Reader.
+	//
+	// This is optional; if unset, requests for inclusion proofs fail with 501 Not Implemented.
+	Awaiter *PublicationAwaiter
+
+	// NewEntry creates the entry to be added from the data and metadata in a request. Requests with a
+	// raw body pass the body as data, and nil metadata.
+	//
+	// Errors wrapping ErrInvalidEntry result in a 400 response.
+	// If unset, NewEntry(data) is used and metadata is ignored.
+	NewEntry func(data []byte, metadata map[string]string) (*Entry, error)
+
+	// MaxBodySize is the maximum size of a request body. If zero, DefaultAddHandlerMaxBodySize is used.
+	MaxBodySize int64
+
+	// InclusionProof returns the inclusion proof for the entry at index in the tree of the given size,
+	// e.g. ProofCache.InclusionProof.
+	//
+	// If unset, inclusion proofs are built from the tiles read from Reader for each request.
+	InclusionProof func(ctx context.Context, index, size uint64) ([][]byte, error)
+}
+
+// NewAddHandler returns an http.Handler which serves POST /add requests by adding entries to the log
+// using the provided Appender.
+//
+// The request and response formats are negotiated by the request's Content-Type:
+//   - application/json requests have an AddRequest body, and receive an AddResponse, which allows
+//     integrators to submit metadata alongside the entry, and to obtain a checkpoint and inclusion proof
+//     without any further round trips.
+//   - Requests with any other Content-Type are treated as the raw entry, and the response is the decimal
+//     index assigned to it, as served by Tessera's conformance personalities.
+//
+// Errors are reported using the status codes returned by HTTPStatusCode, and 429 and 503 responses carry
+// a Retry-After header.
+func NewAddHandler(a *Appender, opts AddHandlerOptions) (http.Handler, error) {
+	if a == nil {
+		return nil, errors.New("appender must be set")
+	}
+	if opts.NewEntry == nil {
+		opts.NewEntry = func(data []byte, _ map[string]string) (*Entry, error) {
+			return NewEntry(data), nil
+		}
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = DefaultAddHandlerMaxBodySize
+	}
+	if opts.InclusionProof == nil && opts.Reader != nil {
+		opts.InclusionProof = func(ctx context.Context, index, size uint64) ([][]byte, error) {
+			return buildInclusionProof(ctx, opts.Reader, index, size)
+		}
+	}
+	h := &addHandler{a: a, opts: opts}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /add", h.add)
+	return mux, nil
+}
+
+type addHandler struct {
+	a    *Appender
+	opts AddHandlerOptions
+}
+
+func (h *addHandler) add(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxBodySize))
+	if err != nil {
+		if mbe := (*http.MaxBytesError)(nil); errors.As(err, &mbe) {
+			http.Error(w, fmt.Sprintf("request body is larger than %d bytes", mbe.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			// The server's read deadline passed before a slow client finished sending the body.
+			http.Error(w, "timed out reading request body", http.StatusRequestTimeout)
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == addJSONContType {
+		h.addJSON(w, r, body)
+		return
+	}
+
+	e, err := h.opts.NewEntry(body, nil)
+	if err != nil {
+		writeAddError(w, err)
+		return
+	}
+	idx, err := h.a.Add(r.Context(), e)()
+	if err != nil {
+		writeAddError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := fmt.Fprintf(w, "%d", idx.Index); err != nil {
+		klog.Errorf("/add: %v", err)
+	}
+}
+
+func (h *addHandler) addJSON(w http.ResponseWriter, r *http.Request, body []byte) {
+	req := AddRequest{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.InclusionProof && (h.opts.Reader == nil || h.opts.Awaiter == nil) {
+		http.Error(w, "inclusion proofs are not supported by this log", http.StatusNotImplemented)
+		return
+	}
+	e, err := h.opts.NewEntry(req.Data, req.Metadata)
+	if err != nil {
+		writeAddError(w, err)
+		return
+	}
+	f := h.a.Add(r.Context(), e)
+
+	var resp AddResponse
+	if req.InclusionProof {
+		idx, cp, err := h.opts.Awaiter.Await(r.Context(), f)
+		if err != nil {
+			writeAddError(w, err)
+			return
+		}
+		// The awaiter has already checked that cp commits to the entry, so its size is larger than the index.
+		_, size, _, err := parse.CheckpointUnsafe(cp)
+		if err != nil {
+			writeAddError(w, fmt.Errorf("failed to parse checkpoint: %v", err))
+			return
+		}
+		p, err := h.opts.InclusionProof(r.Context(), idx.Index, size)
+		if err != nil {
+			writeAddError(w, err)
+			return
+		}
+		resp = AddResponse{Index: idx.Index, Duplicate: idx.IsDup, Checkpoint: string(cp), InclusionProof: p}
+	} else {
+		idx, err := f()
+		if err != nil {
+			writeAddError(w, err)
+			return
+		}
+		resp = AddResponse{Index: idx.Index, Duplicate: idx.IsDup}
+		if h.opts.Reader != nil {
+			// The checkpoint is only a hint, so failing to read it shouldn't fail a request which has
+			// already been sequenced.
+			cp, err := h.opts.Reader.ReadCheckpoint(r.Context())
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				klog.Warningf("/add: failed to read checkpoint: %v", err)
+			}
+			resp.Checkpoint = string(cp)
+		}
+	}
+	writeJSON(w, "/add", resp)
+}
+
+// SetRetryAfter sets the Retry-After header in h to the delay suggested by RetryAfter(err), rounded up
+// to whole seconds since that's the header's granularity.
+func SetRetryAfter(h http.Header, err error) {
+	h.Set("Retry-After", strconv.Itoa(int(math.Ceil(RetryAfter(err).Seconds()))))
+}
+
+// writeAddError writes the status code and message corresponding to err, asking clients to retry
+// later if the log is pushing back or their quota is exhausted.
+func writeAddError(w http.ResponseWriter, err error) {
+	code := HTTPStatusCode(err)
+	switch code {
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		SetRetryAfter(w.Header(), err)
+	case http.StatusInternalServerError:
+		klog.Errorf("/add: %v", err)
+	}
+	http.Error(w, err.Error(), code)
+}
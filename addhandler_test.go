@@ -0,0 +1,151 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestAddHandler(t *testing.T) {
+	ctx := t.Context()
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, shutdown, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	newEntry := func(data []byte, md map[string]string) (*tessera.Entry, error) {
+		if md["reject"] != "" {
+			return nil, fmt.Errorf("%w: rejected", tessera.ErrInvalidEntry)
+		}
+		return tessera.NewEntry(data), nil
+	}
+	full, err := tessera.NewAddHandler(a, tessera.AddHandlerOptions{
+		Reader:      r,
+		Awaiter:     tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond),
+		NewEntry:    newEntry,
+		MaxBodySize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("NewAddHandler: %v", err)
+	}
+	fullSrv := httptest.NewServer(full)
+	defer fullSrv.Close()
+	bare, err := tessera.NewAddHandler(a, tessera.AddHandlerOptions{})
+	if err != nil {
+		t.Fatalf("NewAddHandler: %v", err)
+	}
+	bareSrv := httptest.NewServer(bare)
+	defer bareSrv.Close()
+
+	post := func(srv *httptest.Server, contType string, body []byte, wantCode int) []byte {
+		t.Helper()
+		rsp, err := http.Post(srv.URL+"/add", contType, bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST /add: %v", err)
+		}
+		defer func() { _ = rsp.Body.Close() }()
+		b, err := io.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		if rsp.StatusCode != wantCode {
+			t.Fatalf("POST /add: got status %d (%q), want %d", rsp.StatusCode, b, wantCode)
+		}
+		return b
+	}
+	postJSON := func(srv *httptest.Server, req tessera.AddRequest, wantCode int) tessera.AddResponse {
+		t.Helper()
+		b, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		rsp := tessera.AddResponse{}
+		if b := post(srv, "application/json; charset=utf-8", b, wantCode); wantCode == http.StatusOK {
+			if err := json.Unmarshal(b, &rsp); err != nil {
+				t.Fatalf("failed to decode response %q: %v", b, err)
+			}
+		}
+		return rsp
+	}
+
+	if got := post(fullSrv, "application/octet-stream", []byte("raw"), http.StatusOK); string(got) != "0" {
+		t.Errorf("raw response: got %q, want %q", got, "0")
+	}
+	if got := postJSON(bareSrv, tessera.AddRequest{Data: []byte("json")}, http.StatusOK); got.Index != 1 || got.Checkpoint != "" {
+		t.Errorf("JSON response without reader: got %+v, want index 1 and no checkpoint", got)
+	}
+
+	got := postJSON(fullSrv, tessera.AddRequest{Data: []byte("proof"), Metadata: map[string]string{"k": "v"}, InclusionProof: true}, http.StatusOK)
+	if got.Index != 2 {
+		t.Errorf("JSON response: got index %d, want 2", got.Index)
+	}
+	cp, _, _, err := client.OpenCheckpoint([]byte(got.Checkpoint), v.Name(), v)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, got.Index, cp.Size, rfc6962.DefaultHasher.HashLeaf([]byte("proof")), got.InclusionProof, cp.Hash); err != nil {
+		t.Errorf("VerifyInclusion: %v", err)
+	}
+	// Without a proof, the checkpoint is only a hint, but it's already large enough here.
+	if got := postJSON(fullSrv, tessera.AddRequest{Data: []byte("hint")}, http.StatusOK); got.Index != 3 || !strings.HasPrefix(got.Checkpoint, v.Name()+"\n") {
+		t.Errorf("JSON response: got %+v, want index 3 and a checkpoint", got)
+	}
+
+	postJSON(fullSrv, tessera.AddRequest{Data: []byte("bad"), Metadata: map[string]string{"reject": "yes"}}, http.StatusBadRequest)
+	postJSON(bareSrv, tessera.AddRequest{Data: []byte("proof"), InclusionProof: true}, http.StatusNotImplemented)
+	post(fullSrv, "application/json", []byte("{not json"), http.StatusBadRequest)
+	post(fullSrv, "application/octet-stream", make([]byte, 1025), http.StatusRequestEntityTooLarge)
+}
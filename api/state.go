@@ -21,11 +21,16 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 
 	"github.com/transparency-dev/tessera/api/layout"
 )
 
+// MaxEntrySize is the size, in bytes, of the largest entry which can be stored in an EntryBundle.
+const MaxEntrySize = math.MaxUint16
+
 // HashTile represents a tile within the Merkle hash tree.
 // Leaf HashTiles will have a corresponding EntryBundle, where each
 // entry in the EntryBundle slice hashes to the value at the same
@@ -39,13 +44,16 @@ type HashTile struct {
 // MarshalText implements encoding/TextMarshaller and writes out an HashTile
 // instance as sequences of concatenated hashes as specified by the tlog-tiles spec.
 func (t HashTile) MarshalText() ([]byte, error) {
-	r := &bytes.Buffer{}
+	// Size the output up-front so that only a single allocation is needed.
+	l := 0
 	for _, n := range t.Nodes {
-		if _, err := r.Write(n); err != nil {
-			return nil, err
-		}
+		l += len(n)
 	}
-	return r.Bytes(), nil
+	r := make([]byte, 0, l)
+	for _, n := range t.Nodes {
+		r = append(r, n...)
+	}
+	return r, nil
 }
 
 // UnmarshalText implements encoding/TextUnmarshaler and reads HashTiles
@@ -63,6 +71,35 @@ func (t *HashTile) UnmarshalText(raw []byte) error {
 	return nil
 }
 
+// UnmarshalPartial reads a HashTile which is encoded using the tlog-tiles spec, and checks that it holds
+// the number of hashes implied by the partial tile size p, where zero means a full tile.
+func (t *HashTile) UnmarshalPartial(raw []byte, p uint8) error {
+	if err := t.UnmarshalText(raw); err != nil {
+		return err
+	}
+	return checkPartial(len(t.Nodes), p, layout.TileWidth)
+}
+
+// Append adds a hash to the right of the tile.
+// An error is returned if the hash is not a SHA256 hash, or if the tile is already full.
+func (t *HashTile) Append(h []byte) error {
+	if len(h) != sha256.Size {
+		return fmt.Errorf("hash is %d bytes, want %d", len(h), sha256.Size)
+	}
+	if len(t.Nodes) >= layout.TileWidth {
+		return errors.New("tile is full")
+	}
+	t.Nodes = append(t.Nodes, h)
+	return nil
+}
+
+// Partial returns the partial tile size to use when addressing this tile, i.e. the number of hashes it holds,
+// or zero if it is full.
+// An error is returned if the tile is empty or holds more hashes than fit in a tile.
+func (t HashTile) Partial() (uint8, error) {
+	return partialSize(len(t.Nodes), layout.TileWidth)
+}
+
 // EntryBundle represents a sequence of entries in the log.
 // These entries correspond to a leaf tile in the hash tree.
 type EntryBundle struct {
@@ -91,3 +128,201 @@ func (t *EntryBundle) UnmarshalText(raw []byte) error {
 	t.Entries = nodes
 	return nil
 }
+
+// MarshalText implements encoding/TextMarshaler and writes out an EntryBundle
+// instance as a sequence of length-prefixed entries as specified by the tlog-tiles spec.
+func (t EntryBundle) MarshalText() ([]byte, error) {
+	l := 0
+	for i, e := range t.Entries {
+		if len(e) > MaxEntrySize {
+			return nil, fmt.Errorf("entry %d is %d bytes, larger than the maximum of %d", i, len(e), MaxEntrySize)
+		}
+		l += 2 + len(e)
+	}
+	r := make([]byte, 0, l)
+	for _, e := range t.Entries {
+		r = AppendEntry(r, e)
+	}
+	return r, nil
+}
+
+// UnmarshalPartial reads an EntryBundle which is encoded using the tlog-tiles spec, and checks that it holds
+// the number of entries implied by the partial bundle size p, where zero means a full bundle.
+func (t *EntryBundle) UnmarshalPartial(raw []byte, p uint8) error {
+	if err := t.UnmarshalText(raw); err != nil {
+		return err
+	}
+	return checkPartial(len(t.Entries), p, layout.EntryBundleWidth)
+}
+
+// Append adds an entry to the end of the bundle.
+// An error is returned if the entry is larger than MaxEntrySize, or if the bundle is already full.
+func (t *EntryBundle) Append(e []byte) error {
+	if len(e) > MaxEntrySize {
+		return fmt.Errorf("entry is %d bytes, larger than the maximum of %d", len(e), MaxEntrySize)
+	}
+	if len(t.Entries) >= layout.EntryBundleWidth {
+		return errors.New("entry bundle is full")
+	}
+	t.Entries = append(t.Entries, e)
+	return nil
+}
+
+// Partial returns the partial bundle size to use when addressing this bundle, i.e. the number of entries it holds,
+// or zero if it is full.
+// An error is returned if the bundle is empty or holds more entries than fit in a bundle.
+func (t EntryBundle) Partial() (uint8, error) {
+	return partialSize(len(t.Entries), layout.EntryBundleWidth)
+}
+
+// EntryBundleBuilder incrementally builds the serialised entry bundles which hold a contiguous run of log entries,
+// taking care of growing a partial bundle at the start of the run, and of moving on to a new bundle each time one
+// is filled.
+//
+// A typical use, when integrating entries from index from onwards, is:
+//
+//	b, err := NewEntryBundleBuilder(from, partial, nil)
+//	for _, e := range entries {
+//		if err := b.Append(e); err != nil { ... }
+//		if b.Full() {
+//			write(b.Index(), 0, b.Bytes())
+//			b.Next(nil)
+//		}
+//	}
+//	if p, err := b.Partial(); err == nil {
+//		write(b.Index(), p, b.Bytes())
+//	}
+type EntryBundleBuilder struct {
+	index uint64
+	n     int
+	buf   *bytes.Buffer
+}
+
+// NewEntryBundleBuilder returns a builder for the bundle which will hold the entry at index from.
+//
+// If from doesn't fall at the start of a bundle, partial must be the serialised contents of the existing partial
+// bundle, i.e. the from%EntryBundleWidth entries which precede it. Otherwise, partial is ignored.
+//
+// Bundles are built in buf, or in a new buffer if buf is nil, which allows callers to supply pooled buffers.
+// Any existing contents of buf are discarded.
+func NewEntryBundleBuilder(from uint64, partial []byte, buf *bytes.Buffer) (*EntryBundleBuilder, error) {
+	b := &EntryBundleBuilder{
+		index: from / layout.EntryBundleWidth,
+		n:     int(from % layout.EntryBundleWidth),
+	}
+	b.reset(buf)
+	if b.n > 0 {
+		if len(partial) == 0 {
+			return nil, fmt.Errorf("entry %d is part way through bundle %d, but no partial bundle was provided", from, b.index)
+		}
+		b.buf.Write(partial)
+	}
+	return b, nil
+}
+
+// Append adds an entry to the end of the bundle, serialised as specified by the tlog-tiles spec.
+// An error is returned if the entry is larger than MaxEntrySize, or if the bundle is already full.
+func (b *EntryBundleBuilder) Append(e []byte) error {
+	if len(e) > MaxEntrySize {
+		return fmt.Errorf("entry is %d bytes, larger than the maximum of %d", len(e), MaxEntrySize)
+	}
+	return b.AppendSerialised(AppendEntry(nil, e))
+}
+
+// AppendSerialised adds the already serialised form of a single entry to the end of the bundle.
+// This allows logs whose bundles aren't in the tlog-tiles format, e.g. static-ct-api logs, to use the builder too.
+// An error is returned if the bundle is already full.
+func (b *EntryBundleBuilder) AppendSerialised(d []byte) error {
+	if b.Full() {
+		return errors.New("entry bundle is full")
+	}
+	b.buf.Write(d)
+	b.n++
+	return nil
+}
+
+// Index returns the index of the bundle currently being built.
+func (b *EntryBundleBuilder) Index() uint64 {
+	return b.index
+}
+
+// Size returns the number of entries in the bundle currently being built, including any from a partial bundle it
+// was started with.
+func (b *EntryBundleBuilder) Size() int {
+	return b.n
+}
+
+// Full returns true if the bundle currently being built can't hold any more entries.
+func (b *EntryBundleBuilder) Full() bool {
+	return b.n >= layout.EntryBundleWidth
+}
+
+// Partial returns the partial bundle size to use when addressing the bundle currently being built, or zero if it is
+// full. An error is returned if the bundle is empty.
+func (b *EntryBundleBuilder) Partial() (uint8, error) {
+	return partialSize(b.n, layout.EntryBundleWidth)
+}
+
+// Bytes returns the serialised bundle built so far. The returned slice aliases the builder's buffer, so is only
+// valid until the next call to Append, AppendSerialised, or Next which reuses that buffer.
+func (b *EntryBundleBuilder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// Next moves the builder on to the bundle following the current, full, one, building it in buf, or in a new
+// buffer if buf is nil. The previous buffer is left untouched, so the full bundle may still be being written
+// out from it.
+// An error is returned if the current bundle isn't yet full.
+func (b *EntryBundleBuilder) Next(buf *bytes.Buffer) error {
+	if !b.Full() {
+		return fmt.Errorf("entry bundle %d holds %d entries, and isn't yet full", b.index, b.n)
+	}
+	b.index++
+	b.n = 0
+	b.reset(buf)
+	return nil
+}
+
+func (b *EntryBundleBuilder) reset(buf *bytes.Buffer) {
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	buf.Reset()
+	b.buf = buf
+}
+
+// AppendEntry appends the tlog-tiles serialisation of a single entry to a serialised entry bundle, and returns
+// the extended buffer.
+//
+// This allows bundles to be built up incrementally without first parsing them. The entry must be no larger
+// than MaxEntrySize, which callers are responsible for checking.
+func AppendEntry(bundle []byte, e []byte) []byte {
+	bundle = binary.BigEndian.AppendUint16(bundle, uint16(len(e)))
+	return append(bundle, e...)
+}
+
+// partialSize returns the partial size for a tile or bundle holding n of a possible width items.
+func partialSize(n, width int) (uint8, error) {
+	switch {
+	case n == 0:
+		return 0, errors.New("empty")
+	case n > width:
+		return 0, fmt.Errorf("holds %d items, more than the width of %d", n, width)
+	case n == width:
+		return 0, nil
+	default:
+		return uint8(n), nil
+	}
+}
+
+// checkPartial returns an error if n is not the number of items implied by the partial size p.
+func checkPartial(n int, p uint8, width int) error {
+	want := int(p)
+	if p == 0 {
+		want = width
+	}
+	if n != want {
+		return fmt.Errorf("holds %d items, but partial size %d implies %d", n, p, want)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
 )
 
 func TestHashTile_MarshalTileRoundtrip(t *testing.T) {
@@ -146,6 +147,103 @@ func TestLeafBundle_UnmarshalText(t *testing.T) {
 	}
 }
 
+func TestEntryBundle_MarshalText(t *testing.T) {
+	b := api.EntryBundle{}
+	var want []byte
+	for i := range 3 {
+		e := fmt.Appendf(nil, "entry %d", i)
+		if err := b.Append(e); err != nil {
+			t.Fatalf("Append(%d): %v", i, err)
+		}
+		want = append(want, tessera.NewEntry(e).MarshalBundleData(uint64(i))...)
+	}
+	got, err := b.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("MarshalText: got %x, want %x", got, want)
+	}
+	if got := api.AppendEntry(api.AppendEntry(api.AppendEntry(nil, b.Entries[0]), b.Entries[1]), b.Entries[2]); !bytes.Equal(got, want) {
+		t.Errorf("AppendEntry: got %x, want %x", got, want)
+	}
+
+	if err := b.Append(make([]byte, api.MaxEntrySize+1)); err == nil {
+		t.Error("Append: got nil error for oversized entry")
+	}
+	if _, err := (api.EntryBundle{Entries: [][]byte{make([]byte, api.MaxEntrySize+1)}}).MarshalText(); err == nil {
+		t.Error("MarshalText: got nil error for oversized entry")
+	}
+}
+
+func TestPartial(t *testing.T) {
+	for _, test := range []struct {
+		n       int
+		want    uint8
+		wantErr bool
+	}{
+		{n: 0, wantErr: true},
+		{n: 1, want: 1},
+		{n: 255, want: 255},
+		{n: 256, want: 0},
+		{n: 257, wantErr: true},
+	} {
+		t.Run(fmt.Sprintf("%d", test.n), func(t *testing.T) {
+			tile := api.HashTile{}
+			bundle := api.EntryBundle{}
+			for i := range test.n {
+				h := sha256.Sum256(fmt.Appendf(nil, "%d", i))
+				// Append refuses to overfill, so build the oversized case directly.
+				tile.Nodes = append(tile.Nodes, h[:])
+				bundle.Entries = append(bundle.Entries, h[:])
+			}
+			for name, partial := range map[string]func() (uint8, error){"HashTile": tile.Partial, "EntryBundle": bundle.Partial} {
+				got, err := partial()
+				if gotErr := err != nil; gotErr != test.wantErr {
+					t.Fatalf("%s.Partial: got err %v, want err %t", name, err, test.wantErr)
+				}
+				if got != test.want {
+					t.Errorf("%s.Partial: got %d, want %d", name, got, test.want)
+				}
+			}
+			if test.n > 0 && test.n <= layout.TileWidth {
+				raw, err := tile.MarshalText()
+				if err != nil {
+					t.Fatalf("MarshalText: %v", err)
+				}
+				if err := (&api.HashTile{}).UnmarshalPartial(raw, test.want); err != nil {
+					t.Errorf("HashTile.UnmarshalPartial(%d): %v", test.want, err)
+				}
+				if err := (&api.HashTile{}).UnmarshalPartial(raw, test.want+1); err == nil {
+					t.Errorf("HashTile.UnmarshalPartial(%d): got nil error", test.want+1)
+				}
+				raw, err = bundle.MarshalText()
+				if err != nil {
+					t.Fatalf("MarshalText: %v", err)
+				}
+				if err := (&api.EntryBundle{}).UnmarshalPartial(raw, test.want); err != nil {
+					t.Errorf("EntryBundle.UnmarshalPartial(%d): %v", test.want, err)
+				}
+				if err := (&api.EntryBundle{}).UnmarshalPartial(raw, test.want+1); err == nil {
+					t.Errorf("EntryBundle.UnmarshalPartial(%d): got nil error", test.want+1)
+				}
+			}
+		})
+	}
+
+	full := api.HashTile{Nodes: make([][]byte, layout.TileWidth)}
+	if err := full.Append(make([]byte, sha256.Size)); err == nil {
+		t.Error("HashTile.Append: got nil error for full tile")
+	}
+	if err := (&api.HashTile{}).Append([]byte("short")); err == nil {
+		t.Error("HashTile.Append: got nil error for short hash")
+	}
+	fullBundle := api.EntryBundle{Entries: make([][]byte, layout.EntryBundleWidth)}
+	if err := fullBundle.Append([]byte("one more")); err == nil {
+		t.Error("EntryBundle.Append: got nil error for full bundle")
+	}
+}
+
 func BenchmarkLeafBundle_UnmarshalText(b *testing.B) {
 	bs := bytes.Buffer{}
 	for i := range 222 {
@@ -161,3 +259,114 @@ func BenchmarkLeafBundle_UnmarshalText(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkHashTile_MarshalText(b *testing.B) {
+	tile := api.HashTile{Nodes: make([][]byte, layout.TileWidth)}
+	for i := range tile.Nodes {
+		h := sha256.Sum256(fmt.Appendf(nil, "node %d", i))
+		tile.Nodes[i] = h[:]
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := tile.MarshalText(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEntryBundleBuilder(t *testing.T) {
+	entry := func(i uint64) []byte { return fmt.Appendf(nil, "entry %d", i) }
+	for _, test := range []struct {
+		name  string
+		from  uint64
+		count uint64
+	}{
+		{name: "empty log", from: 0, count: 10},
+		{name: "grow partial", from: 10, count: 5},
+		{name: "fill partial", from: 250, count: 6},
+		{name: "span bundles", from: 250, count: 2*layout.EntryBundleWidth + 20},
+		{name: "start of bundle", from: layout.EntryBundleWidth, count: 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// want holds the expected contents of each bundle, keyed by bundle index.
+			want := map[uint64]*api.EntryBundle{}
+			for i := test.from - test.from%layout.EntryBundleWidth; i < test.from+test.count; i++ {
+				bi := i / layout.EntryBundleWidth
+				if want[bi] == nil {
+					want[bi] = &api.EntryBundle{}
+				}
+				want[bi].Entries = append(want[bi].Entries, entry(i))
+			}
+			var partial []byte
+			if n := test.from % layout.EntryBundleWidth; n > 0 {
+				var err error
+				if partial, err = (api.EntryBundle{Entries: want[test.from/layout.EntryBundleWidth].Entries[:n]}).MarshalText(); err != nil {
+					t.Fatalf("MarshalText: %v", err)
+				}
+			}
+
+			got := map[uint64][]byte{}
+			write := func(b *api.EntryBundleBuilder) {
+				t.Helper()
+				p, err := b.Partial()
+				if err != nil {
+					t.Fatalf("Partial: %v", err)
+				}
+				if n := int(p); p == 0 && b.Size() != layout.EntryBundleWidth || p != 0 && n != b.Size() {
+					t.Errorf("Bundle %d: got partial size %d for %d entries", b.Index(), p, b.Size())
+				}
+				got[b.Index()] = bytes.Clone(b.Bytes())
+			}
+			b, err := api.NewEntryBundleBuilder(test.from, partial, nil)
+			if err != nil {
+				t.Fatalf("NewEntryBundleBuilder: %v", err)
+			}
+			for i := test.from; i < test.from+test.count; i++ {
+				if err := b.Append(entry(i)); err != nil {
+					t.Fatalf("Append(%d): %v", i, err)
+				}
+				if b.Full() {
+					write(b)
+					if err := b.Append(entry(i)); err == nil {
+						t.Fatal("Append to full bundle succeeded")
+					}
+					if err := b.Next(nil); err != nil {
+						t.Fatalf("Next: %v", err)
+					}
+				}
+			}
+			if b.Size() > 0 {
+				write(b)
+			} else if err := b.Next(nil); err == nil {
+				t.Error("Next on empty bundle succeeded")
+			}
+
+			if len(got) != len(want) {
+				t.Errorf("Got %d bundles, want %d", len(got), len(want))
+			}
+			for bi, w := range want {
+				wantRaw, err := w.MarshalText()
+				if err != nil {
+					t.Fatalf("MarshalText: %v", err)
+				}
+				if !bytes.Equal(got[bi], wantRaw) {
+					t.Errorf("Bundle %d: got %d bytes, want %d bytes", bi, len(got[bi]), len(wantRaw))
+				}
+			}
+		})
+	}
+
+	if _, err := api.NewEntryBundleBuilder(10, nil, nil); err == nil {
+		t.Error("NewEntryBundleBuilder without partial bundle succeeded")
+	}
+	b, err := api.NewEntryBundleBuilder(0, nil, bytes.NewBufferString("stale"))
+	if err != nil {
+		t.Fatalf("NewEntryBundleBuilder: %v", err)
+	}
+	if err := b.Append(make([]byte, api.MaxEntrySize+1)); err == nil {
+		t.Error("Append of oversized entry succeeded")
+	}
+	if len(b.Bytes()) != 0 {
+		t.Errorf("Got %d bytes in new bundle, want 0", len(b.Bytes()))
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	f_log "github.com/transparency-dev/formats/log"
 	"github.com/transparency-dev/merkle/rfc6962"
@@ -33,6 +34,7 @@ import (
 	"github.com/transparency-dev/tessera/internal/witness"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/mod/sumdb/note"
 	"k8s.io/klog/v2"
 )
@@ -44,8 +46,12 @@ const (
 	DefaultBatchMaxAge = 250 * time.Millisecond
 	// DefaultCheckpointInterval is used by storage implementations if no WithCheckpointInterval option is provided when instantiating it.
 	DefaultCheckpointInterval = 10 * time.Second
-	// DefaultPushbackMaxOutstanding is used by storage implementations if no WithPushback option is provided when instantiating it.
+	// DefaultPushbackMaxOutstanding is used by storage implementations if no WithPushbackMaxOutstanding option is provided when instantiating it.
 	DefaultPushbackMaxOutstanding = 4096
+	// DefaultIntegrationParallelism is used by storage implementations if no WithIntegrationParallelism option is provided when instantiating it.
+	DefaultIntegrationParallelism = 64
+	// DefaultIntegrationBatchSize is used by storage implementations if no WithIntegrationBatchSize option is provided when instantiating it.
+	DefaultIntegrationBatchSize = 5 * 4096
 )
 
 var (
@@ -172,6 +178,15 @@ func init() {
 // can use the PublicationAwaiter to wrap the call to this method.
 type AddFn func(ctx context.Context, entry *Entry) IndexFuture
 
+// AddBatchFn adds several new entries to be sequenced, and returns an IndexFuture for each of them,
+// in the same order.
+//
+// The entries in the batch which are sequenced are assigned contiguous indices, in the order given.
+// Entries which are rejected, e.g. for being too large, or which antispam finds to be duplicates, don't
+// take up an index, and their futures resolve as they would have done had they been passed to AddFn.
+// Otherwise, the futures behave just like those returned by AddFn.
+type AddBatchFn func(ctx context.Context, entries []*Entry) []IndexFuture
+
 // IndexFuture is the signature of a function which can return an assigned index or error.
 //
 // Implementations of this func are likely to be "futures", or a promise to return this data at
@@ -191,6 +206,28 @@ type Index struct {
 // such as a Shutdown method for #341.
 type Appender struct {
 	Add AddFn
+
+	// AddBatch adds several entries at once, guaranteeing that they're sequenced contiguously.
+	// Personalities which have many entries to hand at once should prefer this to repeated calls
+	// to Add, as it's cheaper too.
+	//
+	// Storage drivers set this if they can sequence entries contiguously, in which case it must be
+	// safe for NewAppender to call it and Add concurrently.
+	AddBatch AddBatchFn
+
+	// Queued, if set by the storage driver, returns the number of entries which have been added via
+	// this Appender but not yet sequenced. Personalities should use Status rather than calling this.
+	Queued func() uint64
+
+	// Flush, if set by the storage driver, sequences any entries which have been added via this
+	// Appender without waiting for their batch to fill or age out, and returns once each of them has
+	// either been sequenced or has failed. It's called by the shutdown function returned by NewAppender.
+	Flush func(ctx context.Context) error
+
+	// reader is used by Status to measure the progress of integration.
+	reader LogReader
+	// published delivers checkpoints published by the storage driver to SubscribeCheckpoints.
+	published *checkpointBroadcaster
 }
 
 // NewAppender returns an Appender, which allows a personality to incrementally append new
@@ -202,13 +239,16 @@ type Appender struct {
 // Shutdown ensures that all calls to Add that have returned a value will be resolved. Any
 // futures returned by _this appender_ which resolve to an index will be integrated and have
 // a checkpoint that commits to them published if this returns successfully. After this returns,
-// any calls to Add will fail.
+// any calls to Add will fail. Entries queued by the storage driver are flushed by shutdown rather
+// than waiting for their batch, so this holds even for futures which callers never resolve.
 //
 // The context passed into this function will be referenced by any background tasks that are started
 // in the Appender. The correct process for shutting down an Appender cleanly is to first call the
 // shutdown function that is returned, and then cancel the context. Cancelling the context without calling
 // shutdown first may mean that some entries added by this appender aren't in the log when the process
 // exits.
+// In that case, the futures for any entries which hadn't been sequenced fail with an error wrapping
+// the context's error, rather than never resolving.
 func NewAppender(ctx context.Context, d Driver, opts *AppendOptions) (*Appender, func(ctx context.Context) error, LogReader, error) {
 	type appendLifecycle interface {
 		Appender(context.Context, *AppendOptions) (*Appender, LogReader, error)
@@ -220,31 +260,129 @@ func NewAppender(ctx context.Context, d Driver, opts *AppendOptions) (*Appender,
 	if err := opts.valid(); err != nil {
 		return nil, nil, nil, err
 	}
+	if opts.published == nil {
+		opts.published = &checkpointBroadcaster{}
+	}
+	// Background tasks started by the driver and below inherit ctx, so this is how their spans reach
+	// the configured provider.
+	if opts.tracerProvider != nil {
+		ctx = otel.ContextWithTracerProvider(ctx, opts.tracerProvider)
+	}
+	// The driver starts its background tasks before startup verification can read the log, so they're
+	// stopped again if the appender isn't returned.
+	ctx, cancel := context.WithCancel(ctx)
+	started := false
+	defer func() {
+		if !started {
+			cancel()
+		}
+	}()
 	a, r, err := lc.Appender(ctx, opts)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to init appender lifecycle: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to init appender lifecycle: %w", err)
+	}
+	// Entries added via AddBatch pass through the same decorators as those passed to Add, so it's
+	// here, beneath them, that they're gathered back together to be handed to the driver.
+	b := newBatcher(a.Add, a.AddBatch, d)
+	a.Add = b.add
+	if opts.startupVerifier != nil {
+		if err := verifyStartupState(ctx, r, opts.checkpointOrigin(), opts.startupVerifier, opts.startupAuditSamples); err != nil {
+			return nil, nil, nil, fmt.Errorf("startup verification of stored log state failed: %w", err)
+		}
+	}
+	// The callback wraps the driver directly, so that it only sees entries which are really sequenced
+	// rather than those which antispam resolved to an existing index.
+	if opts.sequencedCallback != nil {
+		a.Add = sequencedCallbackDecorator(opts.sequencedCallback)(a.Add)
 	}
 	for i := len(opts.addDecorators) - 1; i >= 0; i-- {
 		a.Add = opts.addDecorators[i](a.Add)
 	}
-	sd := &integrationStats{}
+	if opts.readOnly {
+		a.Add = func(_ context.Context, _ *Entry) IndexFuture {
+			return func() (Index, error) { return Index{}, ErrReadOnly }
+		}
+	}
+	// Validation wraps everything above so that rejected entries never reach antispam or the driver,
+	// but the size check comes first so validators never see oversized entries.
+	if opts.entryValidator != nil {
+		a.Add = entryValidatorDecorator(opts.entryValidator)(a.Add)
+	}
+	if opts.maxEntrySize > 0 {
+		a.Add = maxEntrySizeDecorator(opts.maxEntrySize)(a.Add)
+	}
+	if len(opts.pendingLimits) > 0 {
+		a.Add = pendingLimitDecorator(opts.pendingLimits)(a.Add)
+	}
+	// Quotas are checked before anything else, so that a submitter who has exhausted theirs can't
+	// consume resources in the process, even for validation.
+	if len(opts.writeQuotas) > 0 {
+		a.Add = writeQuotaDecorator(opts.writeQuotas)(a.Add)
+	}
+	mp := opts.MetricsProvider()
+	sd := &integrationStats{
+		addLatency:       mp.Histogram("tessera_add_duration_seconds", "Time taken for calls to Add to be assigned an index", LatencyBuckets),
+		integrationDelay: mp.Histogram("tessera_integration_delay_seconds", "Time between an entry being assigned an index and it being integrated into the tree", LatencyBuckets),
+	}
 	a.Add = sd.statsDecorator(a.Add)
-	for _, f := range opts.followers {
-		go f.Follow(ctx, r)
-		go followerStats(ctx, f, r.IntegratedSize)
+	a.Add = traceDecorator(opts.tracer())(a.Add)
+	a.AddBatch = b.addBatch(a.Add)
+	if !opts.readOnly {
+		// A read-only appender leaves the log, and anything which follows it, exactly as it found them.
+		startBackgroundTasks(ctx, opts, r, sd)
 	}
-	go sd.updateStats(ctx, r)
+	a.reader = r
+	a.published = opts.published
 	t := terminator{
 		delegate:       a.Add,
+		delegateBatch:  a.AddBatch,
+		flush:          a.Flush,
+		nextIndex:      r.NextIndex,
 		readCheckpoint: r.ReadCheckpoint,
 	}
 	// TODO(mhutchinson): move this into the decorators
 	a.Add = func(ctx context.Context, entry *Entry) IndexFuture {
 		return t.Add(ctx, entry)
 	}
+	a.AddBatch = func(ctx context.Context, entries []*Entry) []IndexFuture {
+		return t.AddBatch(ctx, entries)
+	}
+	started = true
 	return a, t.Shutdown, r, nil
 }
 
+// startBackgroundTasks starts the tasks which run alongside the appender for as long as ctx is live.
+func startBackgroundTasks(ctx context.Context, opts *AppendOptions, r LogReader, sd *integrationStats) {
+	for _, f := range opts.followers {
+		go f.Follow(ctx, r)
+		go followerStats(ctx, f, r.IntegratedSize)
+	}
+	go sd.updateStats(ctx, r)
+	if len(opts.distributors) > 0 {
+		// Polling is still needed to pick up checkpoints published by other processes sharing the log.
+		go distributeCheckpoints(ctx, r.ReadCheckpoint, opts.published.subscribe(ctx), opts.distributorPollPeriod, opts.distributors)
+	}
+	if opts.stallAlert != nil {
+		w := &integrationWatcher{
+			readCheckpoint: r.ReadCheckpoint,
+			nextIndex:      r.NextIndex,
+			maxAge:         opts.stallMaxAge,
+			maxSize:        opts.stallMaxSize,
+			alert:          opts.stallAlert,
+		}
+		go w.run(ctx, time.Second)
+	}
+	if opts.auditVerifier != nil {
+		ia := &integrityAuditor{
+			lr:     r,
+			origin: opts.checkpointOrigin(),
+			v:      opts.auditVerifier,
+			alert:  opts.auditAlert,
+		}
+		go ia.run(ctx, opts.auditInterval)
+	}
+}
+
 func followerStats(ctx context.Context, f Follower, size func(context.Context) (uint64, error)) {
 	name := f.Name()
 	t := time.NewTicker(200 * time.Millisecond)
@@ -288,6 +426,9 @@ type idxAt struct {
 type integrationStats struct {
 	// indexSample points to a sampled indexAt, or nil if there has been no sample made _or_ the sample was consumed.
 	indexSample atomic.Pointer[idxAt]
+
+	addLatency       MetricHistogram
+	integrationDelay MetricHistogram
 }
 
 // sample creates a new sample with the provided index if no sample is already held.
@@ -338,6 +479,7 @@ func (i *integrationStats) updateStats(ctx context.Context, r LogReader) {
 		appenderIntegratedSize.Record(ctx, otel.Clamp64(s))
 		if d, ok := i.latency(s); ok {
 			appenderIntegrateLatency.Record(ctx, d.Milliseconds())
+			i.integrationDelay.Record(ctx, d.Seconds())
 		}
 		i, err := r.NextIndex(ctx)
 		if err != nil {
@@ -347,6 +489,22 @@ func (i *integrationStats) updateStats(ctx context.Context, r LogReader) {
 	}
 }
 
+// traceDecorator wraps a delegate AddFn so that each call is made within a new span started with t.
+//
+// This span is the root of the entry's journey through Tessera: the driver's queue links the span
+// which flushes a batch to those of the entries in it, and sequencing, integration, and tile writes are
+// traced beneath that.
+func traceDecorator(t trace.Tracer) func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			ctx, span := t.Start(ctx, "tessera.Appender.Add")
+			defer span.End()
+
+			return delegate(ctx, entry)
+		}
+	}
+}
+
 // statsDecorator wraps a delegate AddFn with code to calculate/update
 // metric stats.
 func (i *integrationStats) statsDecorator(delegate AddFn) AddFn {
@@ -376,6 +534,9 @@ func (i *integrationStats) statsDecorator(delegate AddFn) AddFn {
 			appenderAddsTotal.Add(ctx, 1, metric.WithAttributes(attr...))
 			d := time.Since(start)
 			appenderAddHistogram.Record(ctx, d.Milliseconds(), metric.WithAttributes(attr...))
+			if err == nil {
+				i.addLatency.Record(ctx, d.Seconds())
+			}
 
 			if !idx.IsDup {
 				i.sample(idx.Index)
@@ -387,7 +548,11 @@ func (i *integrationStats) statsDecorator(delegate AddFn) AddFn {
 }
 
 type terminator struct {
-	delegate       AddFn
+	delegate      AddFn
+	delegateBatch AddBatchFn
+	// flush, if not nil, sequences any entries which are queued in the driver.
+	flush          func(ctx context.Context) error
+	nextIndex      func(ctx context.Context) (uint64, error)
 	readCheckpoint func(ctx context.Context) ([]byte, error)
 	// This mutex guards the stopped state. We use this instead of an atomic.Boolean
 	// to get the property that no readers of this state can have the lock when the
@@ -396,8 +561,12 @@ type terminator struct {
 	mu      sync.RWMutex
 	stopped bool
 
-	// largestIssued tracks the largest index allocated by this appender.
+	// largestIssued tracks the largest index allocated by this appender, and issued whether any
+	// index has been allocated at all.
 	largestIssued atomic.Uint64
+	issued        atomic.Bool
+	// added is set once any entry has been passed to the delegate.
+	added atomic.Bool
 }
 
 func (t *terminator) Add(ctx context.Context, entry *Entry) IndexFuture {
@@ -405,41 +574,94 @@ func (t *terminator) Add(ctx context.Context, entry *Entry) IndexFuture {
 	defer t.mu.RUnlock()
 	if t.stopped {
 		return func() (Index, error) {
-			return Index{}, errors.New("appender has been shut down")
+			return Index{}, fmt.Errorf("%w: appender has been shut down", ErrSealed)
 		}
 	}
+	t.added.Store(true)
 	res := t.delegate(ctx, entry)
 	return func() (Index, error) {
 		i, err := res()
 		if err != nil {
 			return i, err
 		}
-
-		// https://github.com/golang/go/issues/63999 - atomically set largest issued index
-		old := t.largestIssued.Load()
-		for old < i.Index && !t.largestIssued.CompareAndSwap(old, i.Index) {
-			old = t.largestIssued.Load()
-		}
+		t.recordIssued(i.Index)
 		appenderHighestIndex.Record(ctx, otel.Clamp64(t.largestIssued.Load()))
 
 		return i, err
 	}
 }
 
+// AddBatch is the AddBatchFn counterpart of Add.
+func (t *terminator) AddBatch(ctx context.Context, entries []*Entry) []IndexFuture {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	fs := make([]IndexFuture, len(entries))
+	if t.stopped {
+		for i := range fs {
+			fs[i] = func() (Index, error) {
+				return Index{}, fmt.Errorf("%w: appender has been shut down", ErrSealed)
+			}
+		}
+		return fs
+	}
+	t.added.Store(true)
+	for i, res := range t.delegateBatch(ctx, entries) {
+		fs[i] = func() (Index, error) {
+			i, err := res()
+			if err != nil {
+				return i, err
+			}
+			t.recordIssued(i.Index)
+			appenderHighestIndex.Record(ctx, otel.Clamp64(t.largestIssued.Load()))
+			return i, err
+		}
+	}
+	return fs
+}
+
+// recordIssued records that idx has been allocated by this appender.
+func (t *terminator) recordIssued(idx uint64) {
+	// https://github.com/golang/go/issues/63999 - atomically set largest issued index
+	old := t.largestIssued.Load()
+	for old < idx && !t.largestIssued.CompareAndSwap(old, idx) {
+		old = t.largestIssued.Load()
+	}
+	t.issued.Store(true)
+}
+
 // Shutdown ensures that all calls to Add that have returned a value will be resolved. Any
 // futures returned by _this appender_ which resolve to an index will be integrated and have
 // a checkpoint that commits to them published if this returns successfully.
 //
+// Entries still queued in the driver are flushed straight away rather than waiting for their
+// batch, and this holds whether or not the futures for them have been resolved by their callers.
+//
 // After this returns, any calls to Add will fail.
 func (t *terminator) Shutdown(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.stopped = true
-	maxIndex := t.largestIssued.Load()
-	if maxIndex == 0 {
+	if t.flush != nil && t.added.Load() {
+		if err := t.flush(ctx); err != nil {
+			return fmt.Errorf("failed to flush queued entries: %w", err)
+		}
+		// Callers may not have resolved the futures for all of the entries which have now been
+		// sequenced, so their indices aren't known. They're all below the next index, though, so
+		// waiting for that covers them, at the cost of possibly also waiting for entries added via
+		// other appenders for the same log.
+		next, err := t.nextIndex(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read next index: %w", err)
+		}
+		if next > 0 {
+			t.recordIssued(next - 1)
+		}
+	}
+	if !t.issued.Load() {
 		// special case no work done
 		return nil
 	}
+	maxIndex := t.largestIssued.Load()
 	sleepTime := 0 * time.Millisecond
 	for {
 		select {
@@ -477,6 +699,15 @@ func (o *AppendOptions) WithAntispam(inMemEntries uint, as Antispam) *AppendOpti
 	return o
 }
 
+// WithFollower configures the appender to run the provided Follower for as long as the appender's
+// context is live, e.g. to feed integrated entries to an external system.
+//
+// Followers are passed the appender's LogReader, and are responsible for tracking their own progress.
+func (o *AppendOptions) WithFollower(f Follower) *AppendOptions {
+	o.followers = append(o.followers, f)
+	return o
+}
+
 func NewAppendOptions() *AppendOptions {
 	return &AppendOptions{
 		batchMaxSize:           DefaultBatchMaxSize,
@@ -486,6 +717,9 @@ func NewAppendOptions() *AppendOptions {
 		checkpointInterval:     DefaultCheckpointInterval,
 		addDecorators:          make([]func(AddFn) AddFn, 0),
 		pushbackMaxOutstanding: DefaultPushbackMaxOutstanding,
+		integrationParallelism: DefaultIntegrationParallelism,
+		integrationBatchSize:   DefaultIntegrationBatchSize,
+		published:              &checkpointBroadcaster{},
 	}
 }
 
@@ -493,11 +727,34 @@ func NewAppendOptions() *AppendOptions {
 type AppendOptions struct {
 	// newCP knows how to format and sign checkpoints.
 	newCP func(ctx context.Context, size uint64, hash []byte) ([]byte, error)
+	// signerName is the name of the checkpoint signing key(s).
+	signerName string
+	// origin, if originSet, is the origin line used in new checkpoints. Otherwise, signerName is used.
+	origin    string
+	originSet bool
+	// identity describes the origin and all of the keys which may be used to sign checkpoints.
+	identity LogIdentity
+	// timestampSigner, if set, is used to add a timestamped signature to new checkpoints.
+	timestampSigner note.Signer
+	// cpExtensions returns any extension lines to be added to the body of new checkpoints.
+	cpExtensions func(ctx context.Context, size uint64, hash []byte) ([]string, error)
 
 	batchMaxAge  time.Duration
 	batchMaxSize uint
+	// isPriority, if set, selects the entries which are sequenced via the storage's priority lane.
+	isPriority func(context.Context, *Entry) bool
+	// metrics, if set, creates the instruments reported to by the appender and storage driver.
+	metrics MetricsProvider
+	// tracerProvider, if set, creates the spans for the appender and storage driver instead of the
+	// global otel TracerProvider.
+	tracerProvider trace.TracerProvider
 
 	pushbackMaxOutstanding uint
+	integrationParallelism uint
+	integrationBatchSize   uint
+
+	// pushbackMaxOutstandingSet is true if pushbackMaxOutstanding was set with WithPushbackMaxOutstanding.
+	pushbackMaxOutstandingSet bool
 
 	// EntriesPath knows how to format entry bundle paths.
 	entriesPath func(n uint64, p uint8) string
@@ -510,17 +767,100 @@ type AppendOptions struct {
 
 	addDecorators []func(AddFn) AddFn
 	followers     []Follower
+
+	distributors          []CheckpointDistributor
+	distributorPollPeriod time.Duration
+
+	stallMaxAge  time.Duration
+	stallMaxSize uint64
+	stallAlert   func(context.Context, IntegrationStall)
+
+	// startupVerifier, if set, is used to verify the stored log state before accepting writes.
+	startupVerifier     note.Verifier
+	startupAuditSamples uint
+
+	// opTimeouts holds the configured timeout for each class of storage operation.
+	opTimeouts map[Operation]time.Duration
+
+	// auditVerifier, if set, enables the background integrity auditor.
+	auditVerifier note.Verifier
+	auditInterval time.Duration
+	auditAlert    func(context.Context, error)
+
+	// readOnly, if set, prevents any writes to the log.
+	readOnly bool
+
+	// maxEntrySize, if non-zero, is the largest entry, in bytes, which will be accepted by Add.
+	maxEntrySize uint
+
+	// entryValidator, if set, is called with each entry passed to Add before it is queued.
+	entryValidator func(*Entry) error
+
+	// writeQuotas, if set, limit the rate at which Add accepts entries.
+	writeQuotas []*WriteQuota
+	// pendingLimits, if set, limit the number of entries which may be pending at once.
+	pendingLimits []*PendingLimit
+
+	// sequencedCallback, if set, is called with each entry once it has been durably assigned an index.
+	sequencedCallback func(index uint64, e *Entry)
+
+	// published is notified of each checkpoint published by the storage implementation.
+	published *checkpointBroadcaster
 }
 
 // valid returns an error if an invalid combination of options has been set, or nil otherwise.
 func (o AppendOptions) valid() error {
+	// Collect all problems rather than returning on the first one, so that a misconfigured
+	// personality can be fixed in a single pass.
+	var errs []error
 	if o.newCP == nil {
-		return errors.New("invalid AppendOptions: WithCheckpointSigner must be set")
+		errs = append(errs, errors.New("WithCheckpointSigner must be set"))
+	}
+	if o.originSet {
+		if err := validOrigin(o.origin); err != nil {
+			errs = append(errs, fmt.Errorf("WithCheckpointOrigin: %v", err))
+		}
+	}
+	if o.timestampSigner != nil && o.timestampSigner.Name() != o.signerName {
+		errs = append(errs, fmt.Errorf("WithCheckpointTimestamps signer name (%q) does not match checkpoint signer name (%q)", o.timestampSigner.Name(), o.signerName))
+	}
+	if o.startupVerifier != nil && o.newCP != nil && o.startupVerifier.Name() != o.signerName {
+		errs = append(errs, fmt.Errorf("WithStartupVerification verifier name (%q) does not match checkpoint signer name (%q)", o.startupVerifier.Name(), o.signerName))
+	}
+	if o.auditVerifier != nil && o.newCP != nil && o.auditVerifier.Name() != o.signerName {
+		errs = append(errs, fmt.Errorf("WithIntegrityAudit verifier name (%q) does not match checkpoint signer name (%q)", o.auditVerifier.Name(), o.signerName))
+	}
+	if o.stallAlert != nil && o.stallMaxAge <= 0 && o.stallMaxSize == 0 {
+		errs = append(errs, errors.New("WithIntegrationStallAlert requires at least one of maxAge or maxSize"))
+	}
+	if o.integrationParallelism == 0 {
+		errs = append(errs, errors.New("WithIntegrationParallelism must be positive"))
+	}
+	if o.integrationBatchSize == 0 {
+		errs = append(errs, errors.New("WithIntegrationBatchSize must be positive"))
+	}
+	for _, op := range []Operation{OperationSequence, OperationIntegrate, OperationRead} {
+		if o.opTimeouts[op] < 0 {
+			errs = append(errs, fmt.Errorf("WithOperationTimeout for %v must not be negative", op))
+		}
+	}
+	if o.auditVerifier != nil && o.auditInterval <= 0 {
+		errs = append(errs, errors.New("WithIntegrityAudit interval must be positive"))
+	}
+	if len(o.distributors) > 0 && o.distributorPollPeriod <= 0 {
+		errs = append(errs, errors.New("WithCheckpointDistribution pollPeriod must be positive"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid AppendOptions:\n%w", errors.Join(errs...))
 	}
 	return nil
 }
 
 // CheckpointPublisher returns a function which should be used to create, sign, and potentially witness a new checkpoint.
+//
+// Signing and witnessing may be slow (e.g. when using a remote KMS or HSM), so storage implementations must call the
+// returned function from a separate publishing task, using the latest integrated tree state, and must not hold any
+// locks which integration requires while doing so.
 func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client) func(context.Context, uint64, []byte) ([]byte, error) {
 	wg := witness.NewWitnessGateway(o.witnesses, httpClient, lr.ReadTile)
 	return func(ctx context.Context, size uint64, root []byte) ([]byte, error) {
@@ -534,7 +874,13 @@ func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client
 		appenderSignedSize.Record(ctx, otel.Clamp64(size))
 
 		witAttr := []attribute.KeyValue{}
-		cp, err = wg.Witness(ctx, cp)
+		wCtx := ctx
+		if o.witnessOpts.Timeout > 0 {
+			var cancel context.CancelFunc
+			wCtx, cancel = context.WithTimeout(ctx, o.witnessOpts.Timeout)
+			defer cancel()
+		}
+		wCP, err := wg.Witness(wCtx, cp)
 		if err != nil {
 			if !o.witnessOpts.FailOpen {
 				appenderWitnessRequests.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "failed")))
@@ -543,6 +889,11 @@ func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client
 			klog.Warningf("WitnessGateway: failing-open despite error: %v", err)
 			witAttr = append(witAttr, attribute.String("error.type", "failed_open"))
 		}
+		// When failing open, wCP holds whatever cosignatures were collected, but may be nil if
+		// the witnessing process couldn't even be started. Fall back to the log-signed checkpoint.
+		if wCP != nil {
+			cp = wCP
+		}
 
 		appenderWitnessRequests.Add(ctx, 1, metric.WithAttributes(witAttr...))
 		appenderWitnessedSize.Record(ctx, otel.Clamp64(size))
@@ -551,6 +902,14 @@ func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client
 	}
 }
 
+// CheckpointNotifier returns a function which storage implementations must call each time they have successfully
+// published a checkpoint, so that it can be passed on to the Appender's subscribers.
+//
+// The returned function doesn't block, so may be called while holding locks.
+func (o AppendOptions) CheckpointNotifier() func(PublishedCheckpoint) {
+	return o.published.publish
+}
+
 func (o AppendOptions) BatchMaxAge() time.Duration {
 	return o.batchMaxAge
 }
@@ -563,10 +922,77 @@ func (o AppendOptions) PushbackMaxOutstanding() uint {
 	return o.pushbackMaxOutstanding
 }
 
+// QueuePushbackMaxOutstanding returns the limit set with WithPushbackMaxOutstanding, or zero if it wasn't set.
+//
+// Storage implementations which integrate each batch of entries as it's sequenced use this, rather than
+// PushbackMaxOutstanding, so that they only push back on queued entries if the personality asked them to.
+func (o AppendOptions) QueuePushbackMaxOutstanding() uint {
+	if !o.pushbackMaxOutstandingSet {
+		return 0
+	}
+	return o.pushbackMaxOutstanding
+}
+
+// PriorityFunc returns the function set with WithPriorityLane, or nil if there isn't one.
+func (o AppendOptions) PriorityFunc() func(context.Context, *Entry) bool {
+	return o.isPriority
+}
+
+// MetricsProvider returns the MetricsProvider set with WithMetricsProvider, or one which discards all
+// values if there isn't one.
+func (o AppendOptions) MetricsProvider() MetricsProvider {
+	if o.metrics == nil {
+		return noopMetrics{}
+	}
+	return o.metrics
+}
+
+// tracer returns a Tracer from the TracerProvider set with WithTracing, or the global otel TracerProvider
+// if there isn't one.
+func (o AppendOptions) tracer() trace.Tracer {
+	if o.tracerProvider == nil {
+		return tracer
+	}
+	return o.tracerProvider.Tracer(name)
+}
+
+func (o AppendOptions) IntegrationParallelism() uint {
+	return o.integrationParallelism
+}
+
+func (o AppendOptions) IntegrationBatchSize() uint {
+	return o.integrationBatchSize
+}
+
+// OperationTimeout returns the timeout configured for the given class of storage operation, or zero if
+// the storage implementation's default should be used.
+func (o AppendOptions) OperationTimeout(op Operation) time.Duration {
+	return o.opTimeouts[op]
+}
+
 func (o AppendOptions) EntriesPath() func(uint64, uint8) string {
 	return o.entriesPath
 }
 
+// LogIdentity returns the checkpoint origin and signing keys which the appender is configured with.
+//
+// Storage drivers should use this to ensure that the storage isn't used with a different
+// origin or key to that which it was first used with. See LogIdentity.Reconcile.
+func (o AppendOptions) LogIdentity() LogIdentity {
+	if o.identity.Origin == "" {
+		return o.identity
+	}
+	return LogIdentity{Origin: o.checkpointOrigin(), KeyIDs: o.identity.KeyIDs}
+}
+
+// checkpointOrigin returns the origin line used in new checkpoints.
+func (o AppendOptions) checkpointOrigin() string {
+	if o.originSet {
+		return o.origin
+	}
+	return o.signerName
+}
+
 func (o AppendOptions) CheckpointInterval() time.Duration {
 	return o.checkpointInterval
 }
@@ -581,11 +1007,12 @@ func (o AppendOptions) CheckpointInterval() time.Duration {
 //
 // Zero or more dditional signers may also be provided.
 // This enables cases like:
-//   - a rolling key rotation, where checkpoints are signed by both the old and new keys for some period of time,
+//   - a rolling key rotation, where checkpoints are signed by both the old and new keys for some period of time
+//     (see also WithKeyRotation),
 //   - using different signature schemes for different audiences, etc.
 //
 // When providing additional signers, their names MUST be identical to the primary signer name, and this name will be used
-// as the checkpoint Origin line.
+// as the checkpoint Origin line unless WithCheckpointOrigin is also used.
 //
 // Checkpoints signed by these signer(s) will be standard checkpoints as defined by https://c2sp.org/tlog-checkpoint.
 func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ...note.Signer) *AppendOptions {
@@ -595,6 +1022,32 @@ func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ..
 			klog.Exitf("WithCheckpointSigner: additional signer name (%q) does not match primary signer name (%q)", signer.Name(), origin)
 		}
 	}
+	signers := append([]note.Signer{s}, additionalSigners...)
+	o.identity = newLogIdentity(origin, signers...)
+	return o.withCheckpointSigners(origin, func(context.Context) []note.Signer { return signers })
+}
+
+// WithKeyRotation is an alternative to WithCheckpointSigner which supports rotating the checkpoint
+// signing key, as described by the provided KeyRotation.
+//
+// The phase of the rotation is exported via the tessera.appender.key_rotation.phase metric, and the
+// KeyRotation itself may be served as an admin endpoint to expose the rotation state.
+func (o *AppendOptions) WithKeyRotation(r *KeyRotation) *AppendOptions {
+	if err := r.valid(); err != nil {
+		klog.Exitf("WithKeyRotation: %v", err)
+	}
+	o.identity = newLogIdentity(r.Old.Name(), r.Old, r.New)
+	return o.withCheckpointSigners(r.Old.Name(), func(ctx context.Context) []note.Signer {
+		now := time.Now()
+		keyRotationPhase.Record(ctx, int64(r.Phase(now)))
+		return r.signersAt(now)
+	})
+}
+
+// withCheckpointSigners configures the log to create checkpoints signed by the signers returned by the
+// provided function at the time of signing, all of which have the given name.
+func (o *AppendOptions) withCheckpointSigners(signerName string, signersFn func(context.Context) []note.Signer) *AppendOptions {
+	o.signerName = signerName
 	o.newCP = func(ctx context.Context, size uint64, hash []byte) ([]byte, error) {
 		_, span := tracer.Start(ctx, "tessera.SignCheckpoint")
 		defer span.End()
@@ -606,12 +1059,29 @@ func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ..
 			hash = emptyRoot[:]
 		}
 		cpRaw := f_log.Checkpoint{
-			Origin: origin,
+			Origin: o.checkpointOrigin(),
 			Size:   size,
 			Hash:   hash,
 		}.Marshal()
+		if o.cpExtensions != nil {
+			ext, err := o.cpExtensions(ctx, size, hash)
+			if err != nil {
+				return nil, fmt.Errorf("checkpoint extensions: %v", err)
+			}
+			for _, l := range ext {
+				if l == "" || strings.ContainsRune(l, '\n') {
+					return nil, fmt.Errorf("invalid checkpoint extension line %q", l)
+				}
+				cpRaw = append(cpRaw, l...)
+				cpRaw = append(cpRaw, '\n')
+			}
+		}
 
-		n, err := note.Sign(&note.Note{Text: string(cpRaw)}, append([]note.Signer{s}, additionalSigners...)...)
+		signers := signersFn(ctx)
+		if o.timestampSigner != nil {
+			signers = append(signers[:len(signers):len(signers)], o.timestampSigner)
+		}
+		n, err := note.Sign(&note.Note{Text: string(cpRaw)}, signers...)
 		if err != nil {
 			return nil, fmt.Errorf("note.Sign: %w", err)
 		}
@@ -621,6 +1091,80 @@ func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ..
 	return o
 }
 
+// WithCheckpointTimestamps configures the log to add a timestamped signature to each new checkpoint, in
+// addition to the signature(s) from the signers provided via WithCheckpointSigner. This allows consumers
+// of the log's checkpoints to enforce freshness policies.
+//
+// The provided signer must produce signatures which commit to a timestamp, such as one created via
+// NewSignerForCosignatureV1 in github.com/transparency-dev/formats/note, which follows the conventions
+// of https://c2sp.org/tlog-cosignature. Its name must match the name of the checkpoint signer.
+//
+// Clients can verify and retrieve the timestamp from checkpoints using client.CheckpointTimestamp.
+func (o *AppendOptions) WithCheckpointTimestamps(s note.Signer) *AppendOptions {
+	o.timestampSigner = s
+	return o
+}
+
+// WithCheckpointOrigin sets the origin line of new checkpoints, which otherwise defaults to the name of
+// the checkpoint signer. This allows a log to follow a naming scheme such as "example.com/logs/2025h1"
+// while being signed by a key whose name describes the operator, e.g. "example.com".
+//
+// Verifiers of the log's checkpoints must then be told the origin separately from the verifier key,
+// e.g. via the origin argument of client.NewLogStateTracker. The origin must be non-empty and must not
+// contain newlines or leading or trailing whitespace.
+//
+// Changing the origin of an existing log is prevented by the log identity check (see LogIdentity).
+func (o *AppendOptions) WithCheckpointOrigin(origin string) *AppendOptions {
+	o.origin = origin
+	o.originSet = true
+	return o
+}
+
+// validOrigin returns an error if origin can't be used as a checkpoint origin line.
+func validOrigin(origin string) error {
+	switch {
+	case origin == "":
+		return errors.New("origin must not be empty")
+	case !utf8.ValidString(origin):
+		return errors.New("origin must be valid UTF-8")
+	case strings.ContainsAny(origin, "\n\r"):
+		return fmt.Errorf("origin %q must not contain newlines", origin)
+	case strings.TrimSpace(origin) != origin:
+		return fmt.Errorf("origin %q must not have leading or trailing whitespace", origin)
+	}
+	return nil
+}
+
+// WithIntegrationStallAlert configures a hook which is called when the log's published checkpoint is
+// failing to keep up with the entries accepted by the log, so that operators can be alerted before
+// any guarantees about the time taken to publish entries (e.g. an MMD) are violated.
+//
+// The log is considered to be stalled when either the oldest accepted entry not yet covered by the
+// published checkpoint has been waiting for longer than maxAge, or the number of such entries exceeds
+// maxSize. Either threshold may be disabled by setting it to zero, but not both.
+//
+// The alert function is called whenever the log transitions into, or out of, the stalled state.
+func (o *AppendOptions) WithIntegrationStallAlert(maxAge time.Duration, maxSize uint64, alert func(ctx context.Context, s IntegrationStall)) *AppendOptions {
+	o.stallMaxAge = maxAge
+	o.stallMaxSize = maxSize
+	o.stallAlert = alert
+	return o
+}
+
+// WithCheckpointExtensions configures a function which provides extension lines to be added to the body
+// of new checkpoints, following the origin, tree size, and root hash lines, as permitted by
+// https://c2sp.org/tlog-checkpoint. This allows personalities to attach metadata, e.g. shard hints, to
+// their checkpoints.
+//
+// The function is called with the size and root hash of each checkpoint to be signed. Returned lines
+// must be non-empty and must not contain newlines, and will be added to the checkpoint in order.
+//
+// Clients can retrieve extension lines from checkpoints using client.CheckpointExtensions.
+func (o *AppendOptions) WithCheckpointExtensions(f func(ctx context.Context, size uint64, hash []byte) ([]string, error)) *AppendOptions {
+	o.cpExtensions = f
+	return o
+}
+
 // WithBatching configures the batching behaviour of leaves being sequenced.
 // A batch will be allowed to grow in memory until either:
 //   - the number of entries in the batch reach maxSize
@@ -633,6 +1177,8 @@ func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ..
 // lower cost of operation, where more frequent batches reduce the amount of time
 // required for entries to be included in the log.
 //
+// All storage implementations honour this option.
+//
 // If this option isn't provided, storage implementations with use the DefaultBatchMaxSize and DefaultBatchMaxAge consts above.
 func (o *AppendOptions) WithBatching(maxSize uint, maxAge time.Duration) *AppendOptions {
 	o.batchMaxSize = maxSize
@@ -640,12 +1186,97 @@ func (o *AppendOptions) WithBatching(maxSize uint, maxAge time.Duration) *Append
 	return o
 }
 
+// WithPriorityLane sets a function which classifies entries as high priority, e.g. an operator's heartbeat
+// entries, or resubmissions of entries whose clients are about to time out. Priority entries skip the
+// bulk batching queue, and are instead placed in a small lane which is flushed within a few milliseconds,
+// and ahead of any bulk batches waiting to be sequenced. This bounds their sequencing latency even when the
+// log is heavily loaded.
+//
+// isPriority is passed the context given to Add, so personalities can mark requests for prioritisation
+// with a context value. Priority entries are not subject to the WithPushbackMaxOutstanding limit for
+// drivers which sequence and integrate together, so isPriority should only select a small minority of
+// entries. Entries added with AddBatch are never prioritised.
+func (o *AppendOptions) WithPriorityLane(isPriority func(ctx context.Context, e *Entry) bool) *AppendOptions {
+	o.isPriority = isPriority
+	return o
+}
+
+// WithMetricsProvider sets the MetricsProvider with which the appender and storage driver create
+// instruments describing their operation, e.g. to export them to Prometheus.
+//
+// This is independent of the OpenTelemetry instruments which Tessera always reports to via the global
+// otel MeterProvider. Without this option, the instruments created via MetricsProvider discard their values.
+func (o *AppendOptions) WithMetricsProvider(p MetricsProvider) *AppendOptions {
+	o.metrics = p
+	return o
+}
+
+// WithTracing sets the OpenTelemetry TracerProvider with which the appender and storage driver create
+// their spans, rather than the global one.
+//
+// Each call to Add starts a new trace, or joins the one in its context, and the span for the batch in
+// which the storage driver sequences the entry is linked to it. Where the driver sequences and integrates
+// entries together (e.g. POSIX and MySQL), integration and tile writes are traced beneath that batch.
+// Where integration happens later in a separate task (e.g. GCP and AWS), it's traced in its own spans,
+// along with checkpoint publication, all created with tp.
+func (o *AppendOptions) WithTracing(tp trace.TracerProvider) *AppendOptions {
+	o.tracerProvider = tp
+	return o
+}
+
+// WithPushbackMaxOutstanding sets the number of entries which may be "in-flight" before the storage starts
+// pushing back on add requests, by failing them with a PushbackError.
+//
+// For storage implementations which sequence and integrate entries separately (GCP and AWS), an entry is
+// in-flight once it has been assigned a sequence number, until it's integrated into the log.
+// Others integrate each batch of entries as it's sequenced, so for them an entry is in-flight from when it's
+// added until its batch has been sequenced.
+//
+// If this option isn't provided, storage implementations which sequence and integrate entries separately
+// will use the DefaultPushbackMaxOutstanding const above, and others won't push back at all.
+func (o *AppendOptions) WithPushbackMaxOutstanding(n uint) *AppendOptions {
+	o.pushbackMaxOutstanding = n
+	o.pushbackMaxOutstandingSet = true
+	return o
+}
+
 // WithPushback allows configuration of when the storage should start pushing back on add requests.
 //
-// maxOutstanding is the number of "in-flight" add requests - i.e. the number of entries with sequence numbers
-// assigned, but which are not yet integrated into the log.
+// Deprecated: use WithPushbackMaxOutstanding, which this is equivalent to.
 func (o *AppendOptions) WithPushback(maxOutstanding uint) *AppendOptions {
-	o.pushbackMaxOutstanding = maxOutstanding
+	return o.WithPushbackMaxOutstanding(maxOutstanding)
+}
+
+// WithIntegrationParallelism configures the maximum number of concurrent writes of entry bundles and
+// tiles which storage implementations will perform while integrating a batch of entries into the log.
+//
+// Higher values allow for greater integration throughput, particularly with object-store based storage
+// where the latency of each individual write is relatively high, at the cost of more concurrent requests
+// to the storage infrastructure.
+//
+// This controls how concurrently newly integrated resources are published, and is honoured by storage
+// implementations which write entry bundles and tiles to files or objects (POSIX, GCP, and AWS).
+//
+// If this option isn't provided, storage implementations will use the DefaultIntegrationParallelism const above.
+func (o *AppendOptions) WithIntegrationParallelism(n uint) *AppendOptions {
+	o.integrationParallelism = n
+	return o
+}
+
+// WithIntegrationBatchSize configures the maximum number of sequenced entries which storage implementations
+// will integrate into the log in a single pass.
+//
+// Larger values allow the cost of updating the tree and publishing the resulting tiles to be amortised across
+// more entries, at the cost of larger, longer running, integration passes. This is independent of, and
+// generally much larger than, the batch size set by WithBatching, since integration can pick up many
+// sequenced batches at once.
+//
+// This option is honoured by storage implementations which sequence and integrate entries separately (GCP and AWS).
+// Others integrate each batch of entries as it's sequenced, so their integration batch size is set by WithBatching.
+//
+// If this option isn't provided, storage implementations will use the DefaultIntegrationBatchSize const above.
+func (o *AppendOptions) WithIntegrationBatchSize(n uint) *AppendOptions {
+	o.integrationBatchSize = n
 	return o
 }
 
@@ -668,6 +1299,185 @@ func (o *AppendOptions) WithCheckpointInterval(interval time.Duration) *AppendOp
 	return o
 }
 
+// WithStartupVerification configures NewAppender to verify the state of the log held in storage before
+// any writes are accepted.
+//
+// The signature on the stored checkpoint is verified using v, and the root hash of the tree held in the
+// stored tiles is checked against it. If auditSamples is non-zero, the inclusion of that many randomly
+// selected entries from the stored entry bundles is additionally verified; this is a deeper check, but
+// requires more reads from storage.
+//
+// If verification fails, NewAppender fails closed and returns an error describing the problem, which
+// wraps ErrDiscrepancy if the stored state is invalid.
+func (o *AppendOptions) WithStartupVerification(v note.Verifier, auditSamples uint) *AppendOptions {
+	o.startupVerifier = v
+	o.startupAuditSamples = auditSamples
+	return o
+}
+
+// WithMaxEntrySize sets the size, in bytes, of the largest entry which the appender will accept.
+//
+// Larger entries are rejected by Add with an error wrapping ErrEntryTooLarge before any storage work is
+// done, protecting the size of entry bundles, database row limits, and the memory used on the read path.
+// Zero, the default, means that entry sizes are not limited by Tessera.
+//
+// Note that entries in tlog-tiles entry bundles are length-prefixed with 16 bits, so logs using the
+// default layout must not accept entries larger than api.MaxEntrySize bytes.
+func (o *AppendOptions) WithMaxEntrySize(bytes uint) *AppendOptions {
+	o.maxEntrySize = bytes
+	return o
+}
+
+// MaxEntrySize returns the size, in bytes, of the largest entry the appender will accept, or zero if unlimited.
+func (o AppendOptions) MaxEntrySize() uint {
+	return o.maxEntrySize
+}
+
+// maxEntrySizeDecorator returns a decorator which rejects entries whose data is larger than max bytes.
+func maxEntrySizeDecorator(max uint) func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			if n := len(entry.Data()); uint(n) > max {
+				return func() (Index, error) {
+					return Index{}, fmt.Errorf("%w: entry of %d bytes exceeds limit of %d bytes", ErrEntryTooLarge, n, max)
+				}
+			}
+			return delegate(ctx, entry)
+		}
+	}
+}
+
+// WithEntryValidator configures a function which is called with each entry passed to Add before it
+// enters the queue.
+//
+// This allows personalities to centralise checks on the content of entries, e.g. format, signatures,
+// or admission policy. If the validator returns an error, Add fails with an error wrapping both
+// ErrInvalidEntry and the validator's error, and no storage work is done for the entry.
+//
+// The validator may be called concurrently, and should be fast since it blocks the caller of Add.
+func (o *AppendOptions) WithEntryValidator(v func(*Entry) error) *AppendOptions {
+	o.entryValidator = v
+	return o
+}
+
+// entryValidatorDecorator returns a decorator which only passes on entries accepted by v.
+func entryValidatorDecorator(v func(*Entry) error) func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			if err := v(entry); err != nil {
+				return func() (Index, error) { return Index{}, fmt.Errorf("%w: %w", ErrInvalidEntry, err) }
+			}
+			return delegate(ctx, entry)
+		}
+	}
+}
+
+// WithSequencedCallback configures a function which is called with each new entry, and the index assigned
+// to it, as soon as the entry has been durably sequenced, which is before it has been integrated into
+// the tree and committed to by a checkpoint.
+//
+// This allows personalities to act at the durability point rather than waiting for integration, e.g. a
+// CT log may issue an SCT for the entry. The callback is not called for entries which antispam found to
+// be duplicates, nor for entries which failed to be sequenced.
+//
+// The callback is called from a goroutine which is waiting on the entry's future, independently of
+// whether or when the caller of Add waits on it. It may be called concurrently, and in any order.
+func (o *AppendOptions) WithSequencedCallback(f func(index uint64, e *Entry)) *AppendOptions {
+	o.sequencedCallback = f
+	return o
+}
+
+// sequencedCallbackDecorator returns a decorator which calls f with each entry, once it's been sequenced.
+func sequencedCallbackDecorator(f func(index uint64, e *Entry)) func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			future := delegate(ctx, entry)
+			go func() {
+				if idx, err := future(); err == nil && !idx.IsDup {
+					f(idx.Index, entry)
+				}
+			}()
+			return future
+		}
+	}
+}
+
+// WithReadOnly configures the appender to serve reads without ever writing to the log.
+//
+// Add will always return ErrReadOnly, and storage drivers will neither initialise the log nor run
+// integration or checkpoint publishing tasks, while the returned LogReader works as normal. None of the
+// other background tasks, such as followers, distributors, and auditors, are started either. This is
+// intended for maintenance windows, serving forensic copies of a log, and serve-only replicas.
+func (o *AppendOptions) WithReadOnly() *AppendOptions {
+	o.readOnly = true
+	return o
+}
+
+// ReadOnly returns true if the log must not be written to, as requested via WithReadOnly.
+func (o AppendOptions) ReadOnly() bool {
+	return o.readOnly
+}
+
+// Operation identifies a class of storage operation whose duration may be bounded using WithOperationTimeout.
+type Operation int
+
+const (
+	// OperationSequence is the assignment of indices to a batch of entries.
+	OperationSequence Operation = iota
+	// OperationIntegrate is the integration of a batch of sequenced entries into the tree.
+	OperationIntegrate
+	// OperationRead is a read of a checkpoint, tile, or entry bundle via the LogReader.
+	OperationRead
+)
+
+func (op Operation) String() string {
+	switch op {
+	case OperationSequence:
+		return "sequence"
+	case OperationIntegrate:
+		return "integrate"
+	case OperationRead:
+		return "read"
+	default:
+		return fmt.Sprintf("Operation(%d)", int(op))
+	}
+}
+
+// WithOperationTimeout bounds the time which storage implementations will spend on each call to their
+// backend for the given class of operation, so that a wedged backend call cannot stall the log indefinitely.
+// Operations which time out fail, and are retried where the storage implementation would retry any other
+// failure.
+//
+// Storage implementations which perform sequencing and integration as a single operation apply the
+// smaller of the two timeouts to it. Streaming reads are not subject to the read timeout, since their
+// duration depends on the consumer.
+//
+// If this option isn't provided for a class of operation, or d is zero, the storage implementation's
+// default is used; see the documentation for each implementation.
+func (o *AppendOptions) WithOperationTimeout(op Operation, d time.Duration) *AppendOptions {
+	if o.opTimeouts == nil {
+		o.opTimeouts = make(map[Operation]time.Duration)
+	}
+	o.opTimeouts[op] = d
+	return o
+}
+
+// WithIntegrityAudit enables a background auditor which continuously re-verifies randomly selected parts
+// of the log's stored state, in order to detect corruption of the underlying storage.
+//
+// Once per interval, the auditor verifies the stored checkpoint's signature using v and checks its root
+// hash against the stored tiles, then checks that a randomly selected full tile hashes to the corresponding
+// node in its parent tile, and that a randomly selected entry bundle matches the leaves of its tile.
+//
+// Detected corruption is counted by the tessera.auditor.corruption.detected metric, and is passed to alert,
+// if non-nil, as an error wrapping ErrDiscrepancy.
+func (o *AppendOptions) WithIntegrityAudit(v note.Verifier, interval time.Duration, alert func(ctx context.Context, err error)) *AppendOptions {
+	o.auditVerifier = v
+	o.auditInterval = interval
+	o.auditAlert = alert
+	return o
+}
+
 // WithWitnesses configures the set of witnesses that Tessera will contact in order to counter-sign
 // a checkpoint before publishing it. A request will be sent to every witness referenced by the group
 // using the URLs method. The checkpoint will be accepted for publishing when a sufficient number of
@@ -685,13 +1495,42 @@ func (o *AppendOptions) WithWitnesses(witnesses WitnessGroup, opts *WitnessOptio
 	return o
 }
 
+// WithCheckpointDistribution configures Tessera to push each newly published checkpoint, along with
+// any witness cosignatures it carries, to the provided destinations. This helps ecosystem consumers
+// of the log obtain fresh checkpoints quickly.
+//
+// The published checkpoint is checked for changes every pollPeriod. Each destination is updated
+// independently, and failed pushes are retried on the next poll. Per-destination status is reported
+// via the tessera.distributor.* metrics.
+func (o *AppendOptions) WithCheckpointDistribution(pollPeriod time.Duration, dests ...CheckpointDistributor) *AppendOptions {
+	o.distributors = append(o.distributors, dests...)
+	o.distributorPollPeriod = pollPeriod
+	return o
+}
+
 // WitnessOptions contains extra optional configuration for how Tessera should use/interact with
 // a user-provided WitnessGroup policy.
+//
+// Together with the WitnessGroup, these options form the policy which decides when a checkpoint
+// with collected cosignatures is publishable:
+//   - the threshold and any required witnesses are expressed by the structure of the WitnessGroup,
+//     e.g. NewWitnessGroup(2, requiredWitness, NewWitnessGroup(3, w1, w2, w3, w4)),
+//   - Timeout bounds how long to wait for the group to become satisfied,
+//   - FailOpen is the fallback behaviour if the group is not satisfied in time.
 type WitnessOptions struct {
 	// FailOpen controls whether a checkpoint, for which the witness policy was unable to be met,
-	// should still be published.
+	// should still be published. If so, the checkpoint is published with whichever cosignatures
+	// were successfully collected.
 	//
 	// This setting is intended only for facilitating early "non-blocking" adoption of witnessing,
 	// and will be disabled and/or removed in the future.
 	FailOpen bool
+
+	// Timeout is the maximum amount of time to wait for witnesses to satisfy the policy when
+	// publishing a checkpoint. If zero, the only bound is that of the context used by the storage
+	// implementation for publishing.
+	//
+	// If the policy is not satisfied within this time, the checkpoint is not published unless
+	// FailOpen is set.
+	Timeout time.Duration
 }
@@ -0,0 +1,531 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	f_log "github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/client"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestCheckpointPublisherWitnessTimeout(t *testing.T) {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	_, wvkey, err := note.GenerateKey(rand.Reader, "witness")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	// The witness never responds in time.
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	wit, err := NewWitness(wvkey, u)
+	if err != nil {
+		t.Fatalf("NewWitness: %v", err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		failOpen bool
+		wantErr  bool
+	}{
+		{name: "fail closed", failOpen: false, wantErr: true},
+		{name: "fail open", failOpen: true, wantErr: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := NewAppendOptions().
+				WithCheckpointSigner(s).
+				WithWitnesses(NewWitnessGroup(1, wit), &WitnessOptions{FailOpen: test.failOpen, Timeout: 50 * time.Millisecond})
+			pub := opts.CheckpointPublisher(stubLogReader{}, srv.Client())
+
+			root := sha256.Sum256([]byte("root"))
+			start := time.Now()
+			cp, err := pub(t.Context(), 10, root[:])
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("CheckpointPublisher took %s, timeout was not respected", d)
+			}
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("CheckpointPublisher: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := note.Open(cp, note.VerifierList(v)); err != nil {
+				t.Errorf("Published checkpoint isn't signed by the log: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckpointExtensions(t *testing.T) {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		ext     []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "ok", ext: []string{"shard 2025h1", "kid 1234"}},
+		{name: "empty line", ext: []string{"shard 2025h1", ""}, wantErr: true},
+		{name: "embedded newline", ext: []string{"shard\n2025h1"}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := NewAppendOptions().
+				WithCheckpointSigner(s).
+				WithCheckpointExtensions(func(_ context.Context, _ uint64, _ []byte) ([]string, error) {
+					return test.ext, nil
+				})
+			root := sha256.Sum256([]byte("root"))
+			cp, err := opts.newCP(t.Context(), 10, root[:])
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("newCP: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			_, rest, _, err := f_log.ParseCheckpoint(cp, v.Name(), v)
+			if err != nil {
+				t.Fatalf("ParseCheckpoint: %v", err)
+			}
+			want := ""
+			if len(test.ext) > 0 {
+				want = strings.Join(test.ext, "\n") + "\n"
+			}
+			if got := string(rest); got != want {
+				t.Errorf("Got extension lines %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckpointTimestamps(t *testing.T) {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	tsS, err := f_note.NewSignerForCosignatureV1(skey)
+	if err != nil {
+		t.Fatalf("NewSignerForCosignatureV1: %v", err)
+	}
+
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointTimestamps(tsS)
+	if err := opts.valid(); err != nil {
+		t.Fatalf("valid: %v", err)
+	}
+	root := sha256.Sum256([]byte("root"))
+	start := time.Now().Truncate(time.Second)
+	cp, err := opts.newCP(t.Context(), 10, root[:])
+	if err != nil {
+		t.Fatalf("newCP: %v", err)
+	}
+	_, verified, n, err := client.OpenCheckpoint(cp, v.Name(), v, tsS.Verifier())
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	if len(verified) != 1 {
+		t.Fatalf("Timestamped signature did not verify")
+	}
+	ts, err := client.CheckpointTimestamp(n, tsS.Verifier())
+	if err != nil {
+		t.Fatalf("CheckpointTimestamp: %v", err)
+	}
+	if ts.Before(start) || ts.After(time.Now()) {
+		t.Errorf("Got timestamp %v, want between %v and now", ts, start)
+	}
+
+	// The timestamp signer must share the checkpoint origin.
+	otherKey, _, err := note.GenerateKey(rand.Reader, "other")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherS, err := f_note.NewSignerForCosignatureV1(otherKey)
+	if err != nil {
+		t.Fatalf("NewSignerForCosignatureV1: %v", err)
+	}
+	if err := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointTimestamps(otherS).valid(); err == nil {
+		t.Error("valid: got nil error for mismatched timestamp signer name")
+	}
+}
+
+func TestAppendOptionsValidAggregatesErrors(t *testing.T) {
+	skey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	_, otherVKey, err := note.GenerateKey(rand.Reader, "other.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherV, err := note.NewVerifier(otherVKey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	err = NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithStartupVerification(otherV, 0).
+		WithIntegrationBatchSize(0).
+		WithOperationTimeout(OperationRead, -time.Second).
+		valid()
+	if err == nil {
+		t.Fatal("valid: got nil error for invalid options")
+	}
+	for _, want := range []string{"WithStartupVerification", "WithIntegrationBatchSize", "WithOperationTimeout"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("valid() = %q, want mention of %s", err, want)
+		}
+	}
+}
+
+func TestCheckpointOrigin(t *testing.T) {
+	const origin = "example.com/logs/2025h1"
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	// The origin may be set before or after the signer.
+	for _, opts := range []*AppendOptions{
+		NewAppendOptions().WithCheckpointSigner(s).WithCheckpointOrigin(origin),
+		NewAppendOptions().WithCheckpointOrigin(origin).WithCheckpointSigner(s),
+	} {
+		if err := opts.valid(); err != nil {
+			t.Fatalf("valid: %v", err)
+		}
+		if got := opts.LogIdentity().Origin; got != origin {
+			t.Errorf("LogIdentity().Origin = %q, want %q", got, origin)
+		}
+		root := sha256.Sum256([]byte("root"))
+		cpRaw, err := opts.newCP(t.Context(), 10, root[:])
+		if err != nil {
+			t.Fatalf("newCP: %v", err)
+		}
+		if _, _, _, err := f_log.ParseCheckpoint(cpRaw, origin, v); err != nil {
+			t.Errorf("ParseCheckpoint: %v", err)
+		}
+	}
+
+	for _, bad := range []string{"", " example.com/log", "example.com/log\nsize"} {
+		if err := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointOrigin(bad).valid(); err == nil {
+			t.Errorf("valid: got nil error for origin %q", bad)
+		}
+	}
+}
+
+// stubLogReader is a LogReader which has no data.
+type stubLogReader struct {
+	LogReader
+}
+
+func (stubLogReader) ReadTile(_ context.Context, _, _ uint64, _ uint8) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+func TestTuningOptions(t *testing.T) {
+	skey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	opts := NewAppendOptions().WithCheckpointSigner(s)
+	if err := opts.valid(); err != nil {
+		t.Fatalf("valid: %v", err)
+	}
+	if got, want := opts.IntegrationBatchSize(), uint(DefaultIntegrationBatchSize); got != want {
+		t.Errorf("IntegrationBatchSize() = %d, want default %d", got, want)
+	}
+	if got := opts.WithOperationTimeout(OperationIntegrate, time.Minute).OperationTimeout(OperationIntegrate); got != time.Minute {
+		t.Errorf("OperationTimeout(OperationIntegrate) = %v, want %v", got, time.Minute)
+	}
+	if got := opts.OperationTimeout(OperationRead); got != 0 {
+		t.Errorf("OperationTimeout(OperationRead) = %v, want 0 for driver default", got)
+	}
+	// Drivers which queue entries only push back if the personality asks them to.
+	if got := opts.QueuePushbackMaxOutstanding(); got != 0 {
+		t.Errorf("QueuePushbackMaxOutstanding() = %d, want 0 when unset", got)
+	}
+	if got, want := opts.PushbackMaxOutstanding(), uint(DefaultPushbackMaxOutstanding); got != want {
+		t.Errorf("PushbackMaxOutstanding() = %d, want default %d", got, want)
+	}
+	if got := opts.WithPushbackMaxOutstanding(10).QueuePushbackMaxOutstanding(); got != 10 {
+		t.Errorf("QueuePushbackMaxOutstanding() = %d, want 10", got)
+	}
+
+	for _, tC := range []struct {
+		desc    string
+		opts    *AppendOptions
+		wantErr bool
+	}{
+		{desc: "tuned", opts: NewAppendOptions().WithCheckpointSigner(s).WithBatching(1024, time.Second).WithPushbackMaxOutstanding(1 << 16).WithIntegrationParallelism(8).WithIntegrationBatchSize(1 << 16)},
+		{desc: "zero integration batch size", opts: NewAppendOptions().WithCheckpointSigner(s).WithIntegrationBatchSize(0), wantErr: true},
+		{desc: "zero integration parallelism", opts: NewAppendOptions().WithCheckpointSigner(s).WithIntegrationParallelism(0), wantErr: true},
+		{desc: "operation timeouts", opts: NewAppendOptions().WithCheckpointSigner(s).WithOperationTimeout(OperationSequence, time.Second).WithOperationTimeout(OperationRead, 0)},
+		{desc: "negative operation timeout", opts: NewAppendOptions().WithCheckpointSigner(s).WithOperationTimeout(OperationIntegrate, -time.Second), wantErr: true},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := tC.opts.valid(); (err != nil) != tC.wantErr {
+				t.Errorf("valid() = %v, want error %t", err, tC.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxEntrySize(t *testing.T) {
+	var added int
+	add := maxEntrySizeDecorator(4)(func(_ context.Context, _ *Entry) IndexFuture {
+		added++
+		return func() (Index, error) { return Index{Index: uint64(added)}, nil }
+	})
+
+	for _, tC := range []struct {
+		data    string
+		wantErr bool
+	}{
+		{data: ""},
+		{data: "four"},
+		{data: "fives", wantErr: true},
+	} {
+		_, err := add(t.Context(), NewEntry([]byte(tC.data)))()
+		if gotErr := errors.Is(err, ErrEntryTooLarge); gotErr != tC.wantErr {
+			t.Errorf("Add(%q): got err %v, want ErrEntryTooLarge %t", tC.data, err, tC.wantErr)
+		}
+	}
+	if added != 2 {
+		t.Errorf("delegate called %d times, want 2", added)
+	}
+	if got := NewAppendOptions().WithMaxEntrySize(4).MaxEntrySize(); got != 4 {
+		t.Errorf("MaxEntrySize() = %d, want 4", got)
+	}
+}
+
+func TestEntryValidator(t *testing.T) {
+	errNotJSON := errors.New("not JSON")
+	var added int
+	add := entryValidatorDecorator(func(e *Entry) error {
+		if !strings.HasPrefix(string(e.Data()), "{") {
+			return errNotJSON
+		}
+		return nil
+	})(func(_ context.Context, _ *Entry) IndexFuture {
+		added++
+		return func() (Index, error) { return Index{}, nil }
+	})
+
+	if _, err := add(t.Context(), NewEntry([]byte("{}")))(); err != nil {
+		t.Errorf("Add(valid): %v", err)
+	}
+	_, err := add(t.Context(), NewEntry([]byte("nope")))()
+	if !errors.Is(err, ErrInvalidEntry) || !errors.Is(err, errNotJSON) {
+		t.Errorf("Add(invalid): got err %v, want wrapping %v and %v", err, ErrInvalidEntry, errNotJSON)
+	}
+	if added != 1 {
+		t.Errorf("delegate called %d times, want 1", added)
+	}
+}
+
+func TestSequencedCallback(t *testing.T) {
+	type call struct {
+		index uint64
+		data  string
+	}
+	calls := make(chan call, 10)
+	sequence := make(chan struct{})
+	var next uint64
+	add := sequencedCallbackDecorator(func(index uint64, e *Entry) {
+		calls <- call{index: index, data: string(e.Data())}
+	})(func(_ context.Context, e *Entry) IndexFuture {
+		i := next
+		next++
+		return sync.OnceValues(func() (Index, error) {
+			<-sequence
+			switch string(e.Data()) {
+			case "bad":
+				return Index{}, errors.New("bad")
+			case "dup":
+				return Index{Index: 0, IsDup: true}, nil
+			}
+			return Index{Index: i}, nil
+		})
+	})
+
+	// None of these futures are waited on by the caller.
+	for _, d := range []string{"bad", "dup", "new"} {
+		add(t.Context(), NewEntry([]byte(d)))
+	}
+	close(sequence)
+	select {
+	case got := <-calls:
+		if want := (call{index: 2, data: "new"}); got != want {
+			t.Errorf("got callback %+v, want %+v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for callback")
+	}
+	select {
+	case got := <-calls:
+		t.Errorf("got unexpected callback %+v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestShutdownFlushesQueuedEntries(t *testing.T) {
+	ctx := t.Context()
+	// Entries are only sequenced when flushed, and the checkpoint takes a couple of reads to catch up.
+	sequenced := make(chan struct{})
+	var next uint64
+	add := func(_ context.Context, _ *Entry) IndexFuture {
+		i := next
+		next++
+		return func() (Index, error) {
+			<-sequenced
+			return Index{Index: i}, nil
+		}
+	}
+	flushed := false
+	cpReads := 0
+	term := terminator{
+		delegate: add,
+		flush: func(context.Context) error {
+			flushed = true
+			close(sequenced)
+			return nil
+		},
+		nextIndex: func(context.Context) (uint64, error) { return next, nil },
+		readCheckpoint: func(context.Context) ([]byte, error) {
+			cpReads++
+			size := min(cpReads, 3)
+			return fmt.Appendf(nil, "example.com/log\n%d\n%s\n", size, base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))), nil
+		},
+	}
+
+	// None of the futures are resolved by their callers, as with fire-and-forget submissions.
+	for range 3 {
+		_ = term.Add(ctx, NewEntry([]byte("entry")))
+	}
+	sctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := term.Shutdown(sctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !flushed {
+		t.Error("Shutdown didn't flush queued entries")
+	}
+	if cpReads < 3 {
+		t.Errorf("Shutdown returned after %d checkpoint reads, want it to wait for a checkpoint committing to all 3 entries", cpReads)
+	}
+	if _, err := term.Add(ctx, NewEntry([]byte("late")))(); !errors.Is(err, ErrSealed) {
+		t.Errorf("Add after Shutdown: got err %v, want %v", err, ErrSealed)
+	}
+}
+
+func TestTraceDecorator(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	opts := NewAppendOptions().WithTracing(tp)
+
+	add := traceDecorator(opts.tracer())(func(ctx context.Context, _ *Entry) IndexFuture {
+		// Spans started with the package tracer beneath the Add span should use the same provider.
+		_, span := tracer.Start(ctx, "child")
+		span.End()
+		return func() (Index, error) { return Index{Index: 1}, nil }
+	})
+	if _, err := add(t.Context(), NewEntry([]byte("entry")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child, parent := spans[0], spans[1]
+	if got, want := parent.Name(), "tessera.Appender.Add"; got != want {
+		t.Errorf("got span %q, want %q", got, want)
+	}
+	if got, want := child.Parent().SpanID(), parent.SpanContext().SpanID(); got != want {
+		t.Errorf("child span has parent %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	auditorChecks     metric.Int64Counter
+	auditorCorruption metric.Int64Counter
+)
+
+func init() {
+	var err error
+
+	auditorChecks, err = meter.Int64Counter(
+		"tessera.auditor.checks",
+		metric.WithDescription("Number of background integrity audits of the log's stored state"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		klog.Exitf("Failed to create auditorChecks metric: %v", err)
+	}
+
+	auditorCorruption, err = meter.Int64Counter(
+		"tessera.auditor.corruption.detected",
+		metric.WithDescription("Number of background integrity audits which detected corruption of the log's stored state"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		klog.Exitf("Failed to create auditorCorruption metric: %v", err)
+	}
+}
+
+// integrityAuditor continuously re-verifies randomly selected parts of the log's stored state.
+//
+// Each audit verifies the stored checkpoint's signature and root hash, that a randomly selected full
+// tile hashes to the corresponding node in its parent tile, and that a randomly selected entry bundle
+// hashes to the corresponding leaves of the level 0 tile.
+type integrityAuditor struct {
+	lr     LogReader
+	origin string
+	v      note.Verifier
+	alert  func(ctx context.Context, err error)
+}
+
+func (a *integrityAuditor) run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := a.audit(ctx); err != nil {
+			klog.Warningf("Integrity audit: %v", err)
+		}
+	}
+}
+
+// audit performs a single round of auditing.
+//
+// Errors wrapping ErrDiscrepancy indicate that corruption was detected, and are also passed to the
+// alert hook, if set. Other errors are likely to be transient.
+func (a *integrityAuditor) audit(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "tessera.integrityAuditor.audit")
+	defer span.End()
+
+	err := a.check(ctx)
+	result := "ok"
+	switch {
+	case errors.Is(err, ErrDiscrepancy):
+		result = "corruption"
+		auditorCorruption.Add(ctx, 1)
+		if a.alert != nil {
+			a.alert(ctx, err)
+		}
+	case err != nil:
+		result = "error"
+	}
+	auditorChecks.Add(ctx, 1, metric.WithAttributes(attribute.String("tessera.auditor.result", result)))
+	return err
+}
+
+func (a *integrityAuditor) check(ctx context.Context) error {
+	cpRaw, err := a.lr.ReadCheckpoint(ctx)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	cp, _, _, err := f_log.ParseCheckpoint(cpRaw, a.origin, a.v)
+	if err != nil {
+		return fmt.Errorf("%w: stored checkpoint cannot be verified with key %q: %v", ErrDiscrepancy, a.v.Name(), err)
+	}
+	if err := verifyRootHash(ctx, a.lr.ReadTile, cp); err != nil {
+		return err
+	}
+	if cp.Size == 0 {
+		return nil
+	}
+	idx := rand.Uint64N(cp.Size)
+	if err := a.checkBundle(ctx, cp.Size, idx/layout.EntryBundleWidth); err != nil {
+		return err
+	}
+	return a.checkTile(ctx, cp.Size, idx)
+}
+
+// checkBundle verifies that the leaf hashes of the entries in the given entry bundle match the
+// corresponding nodes in the level 0 tile.
+func (a *integrityAuditor) checkBundle(ctx context.Context, size, bIdx uint64) error {
+	p := layout.PartialTileSize(0, bIdx, size)
+	bundle, err := a.lr.ReadEntryBundle(ctx, bIdx, p)
+	if err != nil {
+		return fmt.Errorf("failed to read entry bundle %d: %v", bIdx, err)
+	}
+	hashes, err := defaultMerkleLeafHasher(bundle)
+	if err != nil {
+		return fmt.Errorf("%w: invalid entry bundle %d: %v", ErrDiscrepancy, bIdx, err)
+	}
+	t, err := a.readTile(ctx, 0, bIdx, p)
+	if err != nil {
+		return err
+	}
+	hashes, nodes := partialWidth(hashes, p), partialWidth(t.Nodes, p)
+	if len(hashes) != len(nodes) {
+		return fmt.Errorf("%w: entry bundle %d has %d entries, but tile has %d leaves", ErrDiscrepancy, bIdx, len(hashes), len(nodes))
+	}
+	for i, h := range hashes {
+		if !bytes.Equal(h, nodes[i]) {
+			return fmt.Errorf("%w: leaf hash of entry %d does not match tile", ErrDiscrepancy, bIdx*layout.EntryBundleWidth+uint64(i))
+		}
+	}
+	return nil
+}
+
+// partialWidth returns s truncated to the width of a tile or entry bundle with partial size p.
+//
+// Some drivers, e.g. MySQL, answer requests for a partial tile or entry bundle with the latest version
+// they hold, which may have grown since, so any elements beyond the requested width are ignored.
+func partialWidth[T any](s []T, p uint8) []T {
+	if p > 0 && len(s) > int(p) {
+		return s[:p]
+	}
+	return s
+}
+
+// checkTile verifies that a full tile covering the entry at index idx, at a randomly selected level,
+// hashes to the corresponding node in its parent tile.
+//
+// Partial tiles are not checked here, since they're covered by verifying the checkpoint's root hash.
+func (a *integrityAuditor) checkTile(ctx context.Context, size, idx uint64) error {
+	levels := []uint64{}
+	for l := uint64(0); idx>>(layout.TileHeight*(l+1)) < size>>(layout.TileHeight*(l+1)); l++ {
+		levels = append(levels, l)
+	}
+	if len(levels) == 0 {
+		return nil
+	}
+	level := levels[rand.IntN(len(levels))]
+	index := idx >> (layout.TileHeight * (level + 1))
+
+	t, err := a.readTile(ctx, level, index, 0)
+	if err != nil {
+		return err
+	}
+	if len(t.Nodes) != layout.TileWidth {
+		return fmt.Errorf("%w: tile %d/%d has %d nodes, want %d", ErrDiscrepancy, level, index, len(t.Nodes), layout.TileWidth)
+	}
+	r := (&compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}).NewEmptyRange(0)
+	for _, n := range t.Nodes {
+		if err := r.Append(n, nil); err != nil {
+			return fmt.Errorf("failed to append to range: %v", err)
+		}
+	}
+	root, err := r.GetRootHash(nil)
+	if err != nil {
+		return fmt.Errorf("failed to compute root hash of tile %d/%d: %v", level, index, err)
+	}
+
+	pIndex := index / layout.TileWidth
+	parent, err := a.readTile(ctx, level+1, pIndex, layout.PartialTileSize(level+1, pIndex, size))
+	if err != nil {
+		return err
+	}
+	n := index % layout.TileWidth
+	if n >= uint64(len(parent.Nodes)) {
+		return fmt.Errorf("%w: tile %d/%d has %d nodes, but should contain node %d", ErrDiscrepancy, level+1, pIndex, len(parent.Nodes), n)
+	}
+	if !bytes.Equal(root, parent.Nodes[n]) {
+		return fmt.Errorf("%w: root hash of tile %d/%d does not match node %d of parent tile %d/%d", ErrDiscrepancy, level, index, n, level+1, pIndex)
+	}
+	return nil
+}
+
+func (a *integrityAuditor) readTile(ctx context.Context, level, index uint64, p uint8) (*api.HashTile, error) {
+	raw, err := a.lr.ReadTile(ctx, level, index, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tile %d/%d: %v", level, index, err)
+	}
+	t := &api.HashTile{}
+	if err := t.UnmarshalText(raw); err != nil {
+		return nil, fmt.Errorf("%w: invalid tile %d/%d: %v", ErrDiscrepancy, level, index, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestIntegrityAudit(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	dir := t.TempDir()
+	driver, err := posix.New(ctx, dir)
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	alerts := make(chan error, 100)
+	opts := tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithIntegrityAudit(v, 10*time.Millisecond, func(_ context.Context, err error) {
+			select {
+			case alerts <- err:
+			default:
+			}
+		})
+	a, _, r, err := tessera.NewAppender(ctx, driver, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, 300)
+	for i := range cap(futures) {
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+
+	select {
+	case err := <-alerts:
+		t.Fatalf("Unexpected alert for valid log: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	corrupt(t, filepath.Join(dir, "tile", "entries"))
+	select {
+	case err := <-alerts:
+		if !errors.Is(err, tessera.ErrDiscrepancy) {
+			t.Errorf("Got alert %v, want %v", err, tessera.ErrDiscrepancy)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No alert raised for corrupt entry bundles")
+	}
+}
+
+func TestIntegrityAuditGrownTiles(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	alerts := make(chan error, 100)
+	opts := tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithIntegrityAudit(v, 10*time.Millisecond, func(_ context.Context, err error) {
+			select {
+			case alerts <- err:
+			default:
+			}
+		})
+	a, _, r, err := tessera.NewAppender(ctx, &grownTileDriver{d: driver}, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	// Every entry is in the partial first bundle, so that each audit reads it.
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, 100)
+	for i := range cap(futures) {
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+
+	select {
+	case err := <-alerts:
+		t.Fatalf("Unexpected alert for valid log: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// grownTileDriver wraps a driver so that requests for partial tiles and entry bundles are answered
+// with full-width ones, as MySQL does once the tree has grown past them.
+type grownTileDriver struct {
+	d tessera.Driver
+}
+
+func (g *grownTileDriver) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
+	a, r, err := g.d.(interface {
+		Appender(context.Context, *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error)
+	}).Appender(ctx, opts)
+	return a, &grownTileReader{LogReader: r}, err
+}
+
+type grownTileReader struct {
+	tessera.LogReader
+}
+
+func (g *grownTileReader) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	t, err := g.LogReader.ReadTile(ctx, level, index, p)
+	if err != nil || p == 0 {
+		return t, err
+	}
+	// The extra nodes stand in for those of leaves added since.
+	return append(t, make([]byte, 32*(layout.TileWidth-int(p)))...), nil
+}
+
+func (g *grownTileReader) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	b, err := g.LogReader.ReadEntryBundle(ctx, index, p)
+	if err != nil || p == 0 {
+		return b, err
+	}
+	for i := uint64(p); i < layout.EntryBundleWidth; i++ {
+		b = append(b, tessera.NewEntry([]byte("added since")).MarshalBundleData(index*layout.EntryBundleWidth+i)...)
+	}
+	return b, nil
+}
@@ -140,10 +140,19 @@ func (a *PublicationAwaiter) await(ctx context.Context, i uint64) ([]byte, error
 		index:  i,
 		result: done,
 	}
-	a.waiters.PushBack(w)
+	e := a.waiters.PushBack(w)
 	a.mu.Unlock()
 	select {
 	case <-ctx.Done():
+		// Stop waiting for an index which may never be reached, so that abandoned waiters don't accumulate.
+		// Waiters are only released with the lock held, so if there's no result yet, it's still in the list.
+		a.mu.Lock()
+		select {
+		case <-done:
+		default:
+			a.waiters.Remove(e)
+		}
+		a.mu.Unlock()
 		return nil, ctx.Err()
 	case cod := <-done:
 		return cod.cp, cod.err
@@ -0,0 +1,99 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+)
+
+// batcher routes the entries of a batch through the same chain of AddFn decorators as individually added
+// entries, and then hands those which make it through to the driver together.
+//
+// The decorators only deal in single entries, so entries from a batch are marked by a batchCollector in
+// their context. Rather than being passed to the driver's AddFn when they reach the bottom of the chain,
+// they're gathered up by the collector, and only passed on to the driver once the whole batch has been
+// through the chain.
+type batcher struct {
+	driverAdd      AddFn
+	driverAddBatch AddBatchFn
+	driver         Driver
+}
+
+func newBatcher(add AddFn, addBatch AddBatchFn, d Driver) *batcher {
+	return &batcher{
+		driverAdd:      add,
+		driverAddBatch: addBatch,
+		driver:         d,
+	}
+}
+
+// batchKey is the context key under which a batchCollector is stored.
+type batchKey struct{}
+
+// add is the AddFn at the bottom of the decorator chain.
+func (b *batcher) add(ctx context.Context, e *Entry) IndexFuture {
+	if c, ok := ctx.Value(batchKey{}).(*batchCollector); ok {
+		return c.add(e)
+	}
+	return b.driverAdd(ctx, e)
+}
+
+// addBatch returns an AddBatchFn which passes each entry through decorated, the AddFn at the top of
+// the decorator chain, and hands the entries which reach the bottom to the driver.
+func (b *batcher) addBatch(decorated AddFn) AddBatchFn {
+	return func(ctx context.Context, entries []*Entry) []IndexFuture {
+		c := &batchCollector{done: make(chan struct{})}
+		bctx := context.WithValue(ctx, batchKey{}, c)
+		fs := make([]IndexFuture, len(entries))
+		for i, e := range entries {
+			fs[i] = decorated(bctx, e)
+		}
+		switch {
+		case len(c.entries) == 0:
+		case b.driverAddBatch == nil:
+			err := fmt.Errorf("storage driver %T does not support adding batches of entries", b.driver)
+			c.futures = make([]IndexFuture, len(c.entries))
+			for i := range c.futures {
+				c.futures[i] = func() (Index, error) { return Index{}, err }
+			}
+		default:
+			c.futures = b.driverAddBatch(ctx, c.entries)
+		}
+		close(c.done)
+		return fs
+	}
+}
+
+// batchCollector gathers the entries of a batch which have made it through the decorator chain.
+//
+// The decorators must call add synchronously, and mustn't wait on the futures it returns before returning
+// themselves, since those futures can't resolve until done is closed once the whole batch has been passed
+// to the driver.
+type batchCollector struct {
+	entries []*Entry
+	// futures holds the driver's futures for entries, and must only be read once done is closed.
+	futures []IndexFuture
+	done    chan struct{}
+}
+
+func (c *batchCollector) add(e *Entry) IndexFuture {
+	i := len(c.entries)
+	c.entries = append(c.entries, e)
+	return func() (Index, error) {
+		<-c.done
+		return c.futures[i]()
+	}
+}
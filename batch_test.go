@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBatcher(t *testing.T) {
+	var next uint64
+	var batches [][]string
+	driverAdd := func(_ context.Context, _ *Entry) IndexFuture {
+		i := next
+		next++
+		return func() (Index, error) { return Index{Index: i}, nil }
+	}
+	driverAddBatch := func(_ context.Context, entries []*Entry) []IndexFuture {
+		var b []string
+		fs := make([]IndexFuture, len(entries))
+		for i, e := range entries {
+			b = append(b, string(e.Data()))
+			idx := next
+			next++
+			fs[i] = func() (Index, error) { return Index{Index: idx}, nil }
+		}
+		batches = append(batches, b)
+		return fs
+	}
+
+	b := newBatcher(driverAdd, driverAddBatch, nil)
+	add := maxEntrySizeDecorator(4)(newInMemoryDedupe(10)(b.add))
+	addBatch := b.addBatch(add)
+
+	if _, err := add(t.Context(), NewEntry([]byte("one")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	fs := addBatch(t.Context(), []*Entry{
+		NewEntry([]byte("two")),
+		NewEntry([]byte("too big")),
+		NewEntry([]byte("one")),
+		NewEntry([]byte("thr")),
+		NewEntry([]byte("two")),
+	})
+	for i, want := range []struct {
+		idx    Index
+		tooBig bool
+	}{
+		{idx: Index{Index: 1}},
+		{tooBig: true},
+		{idx: Index{Index: 0, IsDup: true}},
+		{idx: Index{Index: 2}},
+		{idx: Index{Index: 1, IsDup: true}},
+	} {
+		got, err := fs[i]()
+		if want.tooBig {
+			if !errors.Is(err, ErrEntryTooLarge) {
+				t.Errorf("future %d: got err %v, want %v", i, err, ErrEntryTooLarge)
+			}
+			continue
+		}
+		if err != nil || got != want.idx {
+			t.Errorf("future %d: got (%+v, %v), want %+v", i, got, err, want.idx)
+		}
+	}
+	if got, want := len(batches), 1; got != want {
+		t.Fatalf("driver got %d batches, want %d", got, want)
+	}
+	if got, want := batches[0], []string{"two", "thr"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("driver got batch %q, want %q", got, want)
+	}
+
+	// Batches in which every entry is filtered out never reach the driver.
+	if _, err := addBatch(t.Context(), []*Entry{NewEntry([]byte("one"))})[0](); err != nil {
+		t.Errorf("AddBatch(dup): %v", err)
+	}
+	if got, want := len(batches), 1; got != want {
+		t.Errorf("driver got %d batches, want %d", got, want)
+	}
+}
+
+func TestBatcherUnsupported(t *testing.T) {
+	b := newBatcher(func(context.Context, *Entry) IndexFuture {
+		t.Fatal("driver Add called for batched entry")
+		return nil
+	}, nil, nil)
+	if _, err := b.addBatch(b.add)(t.Context(), []*Entry{NewEntry([]byte("one"))})[0](); err == nil {
+		t.Error("AddBatch with unsupporting driver: want error")
+	}
+}
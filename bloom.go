@@ -0,0 +1,312 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/otel"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultBloomExpectedEntries is the number of distinct entries a Bloom filter is sized for by default.
+	DefaultBloomExpectedEntries = 1 << 24
+	// DefaultBloomFalsePositiveRate is the false positive rate a Bloom filter is sized for by default.
+	DefaultBloomFalsePositiveRate = 0.01
+	// DefaultBloomMaxFollowerLag is the default for BloomAntispamOpts.MaxFollowerLag.
+	DefaultBloomMaxFollowerLag = 1024
+)
+
+var (
+	bloomLookupsSkipped  metric.Int64Counter
+	bloomFalsePositives  metric.Int64Counter
+	bloomFilterPopulated metric.Int64Gauge
+)
+
+func init() {
+	var err error
+
+	bloomLookupsSkipped, err = meter.Int64Counter(
+		"tessera.antispam.bloom.lookups.skipped",
+		metric.WithDescription("Number of entries which the Bloom filter showed to be new, so skipped the persistent antispam lookup"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create bloomLookupsSkipped metric: %v", err)
+	}
+
+	bloomFalsePositives, err = meter.Int64Counter(
+		"tessera.antispam.bloom.false_positives",
+		metric.WithDescription("Number of persistent antispam lookups triggered by the Bloom filter which found no existing entry"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create bloomFalsePositives metric: %v", err)
+	}
+
+	bloomFilterPopulated, err = meter.Int64Gauge(
+		"tessera.antispam.bloom.populated",
+		metric.WithDescription("Number of log entries the Bloom filter has been populated with"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create bloomFilterPopulated metric: %v", err)
+	}
+}
+
+// BloomAntispamOpts allows configuration of the Bloom filter created by NewBloomAntispam.
+type BloomAntispamOpts struct {
+	// ExpectedEntries is the number of distinct entries the filter is sized for.
+	// This should comfortably exceed the size the log is expected to grow to while the process is running, since
+	// the false positive rate rises quickly once the filter holds more entries than it was sized for.
+	//
+	// If unset, DefaultBloomExpectedEntries is used.
+	ExpectedEntries uint
+
+	// FalsePositiveRate is the fraction of new entries which the filter may wrongly report as having been seen.
+	// Each false positive costs a lookup in the persistent antispam storage.
+	//
+	// If unset, DefaultBloomFalsePositiveRate is used.
+	FalsePositiveRate float64
+
+	// MaxFollowerLag is the largest number of integrated entries which the persistent antispam follower may be
+	// behind the log by for the filter to be used to skip lookups.
+	// Beyond this, all entries are passed to the persistent antispam decorator so that its pushback applies, so
+	// this should be no larger than the persistent implementation's own pushback threshold.
+	//
+	// If unset, DefaultBloomMaxFollowerLag is used.
+	MaxFollowerLag uint
+}
+
+// NewBloomAntispam returns an Antispam which puts an in-memory Bloom filter of the identities of entries in the log
+// in front of the provided persistent antispam implementation.
+//
+// Most entries added to a log are new, but the persistent implementation can only discover this by looking the entry
+// up in its storage. When the filter shows that an entry definitely hasn't been seen before, that lookup is skipped and
+// the entry is passed straight on to be sequenced. Entries which the filter may have seen are looked up as usual.
+//
+// The filter is populated by reading the whole log at startup, and by tailing it thereafter, alongside the persistent
+// follower. Until it has caught up with the entries known to the persistent storage, every entry is looked up.
+// The filter uses roughly 1.2 bytes per expected entry at the default false positive rate.
+func NewBloomAntispam(persistent Antispam, opts BloomAntispamOpts) Antispam {
+	if opts.ExpectedEntries == 0 {
+		opts.ExpectedEntries = DefaultBloomExpectedEntries
+	}
+	if opts.FalsePositiveRate <= 0 || opts.FalsePositiveRate >= 1 {
+		opts.FalsePositiveRate = DefaultBloomFalsePositiveRate
+	}
+	if opts.MaxFollowerLag == 0 {
+		opts.MaxFollowerLag = DefaultBloomMaxFollowerLag
+	}
+	return &bloomAntispam{
+		persistent: persistent,
+		opts:       opts,
+		filter:     newBloomFilter(opts.ExpectedEntries, opts.FalsePositiveRate),
+	}
+}
+
+type bloomAntispam struct {
+	persistent Antispam
+	opts       BloomAntispamOpts
+	filter     *bloomFilter
+
+	// trusted is true while the filter contains every entry known to the persistent storage, and the persistent
+	// follower is keeping up with the log.
+	trusted atomic.Bool
+}
+
+// Decorator returns a function which skips the persistent antispam lookup for entries which the filter shows to be
+// new, and otherwise delegates to the persistent antispam decorator.
+//
+// This implements Antispam.
+func (b *bloomAntispam) Decorator() func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		lookup := b.persistent.Decorator()(delegate)
+		return func(ctx context.Context, e *Entry) IndexFuture {
+			trusted := b.trusted.Load()
+			// The identity is always recorded, so that resubmissions of an entry which is still being
+			// integrated are looked up rather than skipped.
+			seen := b.filter.testAndAdd(e.Identity())
+			if !trusted {
+				return lookup(ctx, e)
+			}
+			if !seen {
+				bloomLookupsSkipped.Add(ctx, 1)
+				return delegate(ctx, e)
+			}
+			f := lookup(ctx, e)
+			return func() (Index, error) {
+				idx, err := f()
+				if err == nil && !idx.IsDup {
+					bloomFalsePositives.Add(ctx, 1)
+				}
+				return idx, err
+			}
+		}
+	}
+}
+
+// LookupIndex looks up the identity hash in the persistent antispam storage, if it supports lookups.
+//
+// This implements IndexLookup.
+func (b *bloomAntispam) LookupIndex(ctx context.Context, identityHash []byte) (uint64, error) {
+	l, ok := b.persistent.(IndexLookup)
+	if !ok {
+		return 0, fmt.Errorf("persistent antispam %T does not support index lookups", b.persistent)
+	}
+	return l.LookupIndex(ctx, identityHash)
+}
+
+// Follower returns a follower which runs the persistent antispam follower, and populates the filter.
+//
+// This implements Antispam.
+func (b *bloomAntispam) Follower(bundleHasher func([]byte) ([][]byte, error)) Follower {
+	return &bloomFollower{
+		b:            b,
+		persistent:   b.persistent.Follower(bundleHasher),
+		bundleHasher: bundleHasher,
+	}
+}
+
+// bloomFollower populates the filter with the identities of the entries in the log.
+type bloomFollower struct {
+	b            *bloomAntispam
+	persistent   Follower
+	bundleHasher func([]byte) ([][]byte, error)
+
+	// next is the index of the next entry to be added to the filter.
+	next uint64
+}
+
+// Name returns the name of the persistent follower, whose progress this follower reports.
+func (f *bloomFollower) Name() string {
+	return f.persistent.Name()
+}
+
+// EntriesProcessed returns the progress of the persistent follower, since that's what determines
+// whether entries can be deduplicated.
+func (f *bloomFollower) EntriesProcessed(ctx context.Context) (uint64, error) {
+	return f.persistent.EntriesProcessed(ctx)
+}
+
+// Follow runs the persistent follower, and populates the filter until the context is done.
+func (f *bloomFollower) Follow(ctx context.Context, lr LogReader) {
+	go f.persistent.Follow(ctx, lr)
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := f.catchUp(ctx, lr); err != nil {
+			klog.Warningf("Bloom filter: %v", err)
+		}
+	}
+}
+
+// catchUp adds all integrated entries which aren't yet in the filter, and then decides whether the filter
+// can be used to skip lookups.
+func (f *bloomFollower) catchUp(ctx context.Context, lr LogReader) error {
+	// The persistent progress must be read before the log size, so that the filter is guaranteed
+	// to cover everything the persistent storage knew about at this point.
+	processed, err := f.persistent.EntriesProcessed(ctx)
+	if err != nil {
+		f.b.trusted.Store(false)
+		return fmt.Errorf("EntriesProcessed(): %v", err)
+	}
+	size, err := lr.IntegratedSize(ctx)
+	if err != nil {
+		f.b.trusted.Store(false)
+		return fmt.Errorf("IntegratedSize(): %v", err)
+	}
+
+	for ri := range layout.Range(f.next, size-min(f.next, size), size) {
+		b, err := lr.ReadEntryBundle(ctx, ri.Index, ri.Partial)
+		if err != nil {
+			f.b.trusted.Store(false)
+			return fmt.Errorf("failed to read entry bundle %d: %v", ri.Index, err)
+		}
+		ids, err := f.bundleHasher(b)
+		if err != nil {
+			f.b.trusted.Store(false)
+			return fmt.Errorf("failed to hash entry bundle %d: %v", ri.Index, err)
+		}
+		if uint(len(ids)) < uint(ri.First)+uint(ri.N) {
+			f.b.trusted.Store(false)
+			return fmt.Errorf("entry bundle %d has %d entries, want at least %d", ri.Index, len(ids), uint(ri.First)+uint(ri.N))
+		}
+		for _, id := range ids[ri.First : uint(ri.First)+uint(ri.N)] {
+			f.b.filter.add(id)
+		}
+		f.next += uint64(ri.N)
+	}
+	bloomFilterPopulated.Record(ctx, otel.Clamp64(f.next))
+
+	trusted := f.next >= processed && size-min(processed, size) <= uint64(f.b.opts.MaxFollowerLag)
+	if trusted != f.b.trusted.Load() {
+		klog.Infof("Bloom filter populated with %d entries, persistent antispam has %d: skipping lookups=%t", f.next, processed, trusted)
+	}
+	f.b.trusted.Store(trusted)
+	return nil
+}
+
+// bloomFilter is a fixed size Bloom filter which is safe for concurrent use.
+type bloomFilter struct {
+	words  []atomic.Uint64
+	m      uint64
+	k      uint64
+	s1, s2 maphash.Seed
+}
+
+// newBloomFilter returns a filter sized to hold n entries with a false positive rate of p.
+func newBloomFilter(n uint, p float64) *bloomFilter {
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(64, (m+63)/64*64)
+	k := uint64(max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &bloomFilter{
+		words: make([]atomic.Uint64, m/64),
+		m:     m,
+		k:     k,
+		s1:    maphash.MakeSeed(),
+		s2:    maphash.MakeSeed(),
+	}
+}
+
+// testAndAdd adds id to the filter, and returns whether it may already have been present.
+func (f *bloomFilter) testAndAdd(id []byte) bool {
+	h1, h2 := maphash.Bytes(f.s1, id), maphash.Bytes(f.s2, id)|1
+	seen := true
+	for i := range f.k {
+		b := (h1 + i*h2) % f.m
+		mask := uint64(1) << (b % 64)
+		if f.words[b/64].Or(mask)&mask == 0 {
+			seen = false
+		}
+	}
+	return seen
+}
+
+// add adds id to the filter.
+func (f *bloomFilter) add(id []byte) {
+	_ = f.testAndAdd(id)
+}
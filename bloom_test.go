@@ -0,0 +1,239 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	f := newBloomFilter(n, 0.01)
+	for i := range n {
+		if f.testAndAdd(fmt.Appendf(nil, "in %d", i)) {
+			// A false positive is possible here too, but so unlikely for the first entries that it's worth flagging.
+			if i < 10 {
+				t.Errorf("testAndAdd(%d): got seen for a new entry in a nearly empty filter", i)
+			}
+		}
+	}
+	for i := range n {
+		if !f.testAndAdd(fmt.Appendf(nil, "in %d", i)) {
+			t.Fatalf("testAndAdd(%d): got not seen for an added entry", i)
+		}
+	}
+	// Entries are added as they're tested, so only check a few to avoid filling the filter beyond its size.
+	const m = n / 10
+	fp := 0
+	for i := range m {
+		if f.testAndAdd(fmt.Appendf(nil, "out %d", i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / m; rate > 0.03 {
+		t.Errorf("false positive rate %.3f, want around 0.01", rate)
+	}
+}
+
+// bloomLogReader is a LogReader containing size entries, each with the data "entry <index>".
+type bloomLogReader struct {
+	LogReader
+	size uint64
+}
+
+func (r *bloomLogReader) IntegratedSize(_ context.Context) (uint64, error) {
+	return r.size, nil
+}
+
+func (r *bloomLogReader) ReadEntryBundle(_ context.Context, i uint64, p uint8) ([]byte, error) {
+	n := uint64(p)
+	if n == 0 {
+		n = layout.EntryBundleWidth
+	}
+	var b []byte
+	for j := range n {
+		idx := i*layout.EntryBundleWidth + j
+		b = append(b, NewEntry(fmt.Appendf(nil, "entry %d", idx)).MarshalBundleData(idx)...)
+	}
+	return b, nil
+}
+
+// lookupAntispam is a persistent Antispam which knows about the first processed entries of a bloomLogReader.
+type lookupAntispam struct {
+	processed atomic.Uint64
+	lookups   atomic.Uint64
+}
+
+func (a *lookupAntispam) Decorator() func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, e *Entry) IndexFuture {
+			a.lookups.Add(1)
+			for i := range a.processed.Load() {
+				if string(e.Data()) == fmt.Sprintf("entry %d", i) {
+					return func() (Index, error) { return Index{Index: i, IsDup: true}, nil }
+				}
+			}
+			return delegate(ctx, e)
+		}
+	}
+}
+
+func (a *lookupAntispam) LookupIndex(_ context.Context, h []byte) (uint64, error) {
+	for i := range a.processed.Load() {
+		if bytes.Equal(h, identityHash(fmt.Appendf(nil, "entry %d", i))) {
+			return i, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (a *lookupAntispam) Follower(func([]byte) ([][]byte, error)) Follower {
+	return a
+}
+
+func (a *lookupAntispam) Name() string                      { return "lookup" }
+func (a *lookupAntispam) Follow(context.Context, LogReader) {}
+func (a *lookupAntispam) EntriesProcessed(context.Context) (uint64, error) {
+	return a.processed.Load(), nil
+}
+
+func TestBloomAntispam(t *testing.T) {
+	ctx := t.Context()
+	lr := &bloomLogReader{size: 300}
+	p := &lookupAntispam{}
+	p.processed.Store(lr.size)
+	b := NewBloomAntispam(p, BloomAntispamOpts{ExpectedEntries: 1000, MaxFollowerLag: 10}).(*bloomAntispam)
+	f := b.Follower(defaultIDHasher).(*bloomFollower)
+	next := lr.size
+	add := b.Decorator()(func(_ context.Context, _ *Entry) IndexFuture {
+		i := next
+		next++
+		return func() (Index, error) { return Index{Index: i}, nil }
+	})
+
+	for _, step := range []struct {
+		desc       string
+		before     func()
+		data       string
+		wantIdx    Index
+		wantLookup bool
+	}{
+		{
+			desc:       "new entry before filter is populated",
+			data:       "new 0",
+			wantIdx:    Index{Index: 300},
+			wantLookup: true,
+		}, {
+			desc: "new entry",
+			before: func() {
+				if err := f.catchUp(ctx, lr); err != nil {
+					t.Fatalf("catchUp: %v", err)
+				}
+			},
+			data:    "new 1",
+			wantIdx: Index{Index: 301},
+		}, {
+			desc:       "duplicate entry",
+			data:       "entry 42",
+			wantIdx:    Index{Index: 42, IsDup: true},
+			wantLookup: true,
+		}, {
+			desc:       "resubmitted new entry",
+			data:       "new 1",
+			wantIdx:    Index{Index: 302},
+			wantLookup: true,
+		}, {
+			desc: "persistent follower ahead of filter",
+			before: func() {
+				p.processed.Store(lr.size + 1)
+				if err := f.catchUp(ctx, lr); err != nil {
+					t.Fatalf("catchUp: %v", err)
+				}
+			},
+			data:       "new 2",
+			wantIdx:    Index{Index: 303},
+			wantLookup: true,
+		}, {
+			desc: "log grows",
+			before: func() {
+				lr.size = 320
+				p.processed.Store(315)
+				if err := f.catchUp(ctx, lr); err != nil {
+					t.Fatalf("catchUp: %v", err)
+				}
+			},
+			data:    "new 3",
+			wantIdx: Index{Index: 304},
+		}, {
+			desc: "persistent follower lagging",
+			before: func() {
+				lr.size = 400
+				if err := f.catchUp(ctx, lr); err != nil {
+					t.Fatalf("catchUp: %v", err)
+				}
+			},
+			data:       "new 4",
+			wantIdx:    Index{Index: 305},
+			wantLookup: true,
+		},
+	} {
+		if step.before != nil {
+			step.before()
+		}
+		lookups := p.lookups.Load()
+		got, err := add(ctx, NewEntry([]byte(step.data)))()
+		if err != nil {
+			t.Fatalf("%s: Add: %v", step.desc, err)
+		}
+		if got != step.wantIdx {
+			t.Errorf("%s: got index %+v, want %+v", step.desc, got, step.wantIdx)
+		}
+		if gotLookup := p.lookups.Load() > lookups; gotLookup != step.wantLookup {
+			t.Errorf("%s: got lookup %t, want %t", step.desc, gotLookup, step.wantLookup)
+		}
+	}
+	if f.next != lr.size {
+		t.Errorf("filter populated with %d entries, want %d", f.next, lr.size)
+	}
+}
+
+func TestBloomAntispamLookupIndex(t *testing.T) {
+	ctx := t.Context()
+	p := &lookupAntispam{}
+	p.processed.Store(10)
+	l, ok := NewBloomAntispam(p, BloomAntispamOpts{}).(IndexLookup)
+	if !ok {
+		t.Fatal("Bloom antispam doesn't implement IndexLookup")
+	}
+	if got, err := l.LookupIndex(ctx, identityHash([]byte("entry 7"))); err != nil || got != 7 {
+		t.Errorf("LookupIndex(entry 7) = %d, %v, want 7", got, err)
+	}
+	if _, err := l.LookupIndex(ctx, identityHash([]byte("entry 70"))); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupIndex(entry 70): got err %v, want %v", err, ErrNotFound)
+	}
+
+	// Hide the persistent implementation's LookupIndex method.
+	noLookup := NewBloomAntispam(struct{ Antispam }{p}, BloomAntispamOpts{}).(IndexLookup)
+	if _, err := noLookup.LookupIndex(ctx, identityHash([]byte("entry 7"))); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupIndex without persistent support: got err %v, want non-ErrNotFound error", err)
+	}
+}
@@ -23,9 +23,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
 	"github.com/transparency-dev/merkle/compact"
 	"github.com/transparency-dev/merkle/proof"
 	"github.com/transparency-dev/merkle/rfc6962"
@@ -114,6 +117,71 @@ func FetchCheckpoint(ctx context.Context, f CheckpointFetcherFunc, v note.Verifi
 	return cp, cpRaw, n, nil
 }
 
+// OpenCheckpoint verifies and parses a checkpoint which MUST be signed by the log, and which may
+// additionally carry signatures from any subset of otherVerifiers, e.g. witnesses, or the log's
+// new key during a key rotation.
+//
+// Returns the parsed checkpoint, the subset of otherVerifiers whose signatures were present and
+// verified, and the opened note. An error is returned if the log signature does not verify.
+// Signatures which could not be verified by any of the provided verifiers are left in the
+// note's UnverifiedSigs.
+func OpenCheckpoint(cpRaw []byte, origin string, logSigV note.Verifier, otherVerifiers ...note.Verifier) (*log.Checkpoint, []note.Verifier, *note.Note, error) {
+	cp, _, n, err := log.ParseCheckpoint(cpRaw, origin, logSigV, otherVerifiers...)
+	if err != nil {
+		return nil, nil, n, fmt.Errorf("failed to parse Checkpoint: %v", err)
+	}
+	verified := make([]note.Verifier, 0, len(otherVerifiers))
+	for _, v := range otherVerifiers {
+		for _, s := range n.Sigs {
+			if s.Name == v.Name() && s.Hash == v.KeyHash() {
+				verified = append(verified, v)
+				break
+			}
+		}
+	}
+	return cp, verified, n, nil
+}
+
+// CheckpointTimestamp returns the time committed to by the timestamped signature from tsV on the
+// provided checkpoint note, as added by logs configured with WithCheckpointTimestamps.
+//
+// The note must have been opened with tsV as one of its verifiers, e.g. via OpenCheckpoint, so that
+// only verified signatures are considered. Callers can use the returned time to enforce freshness
+// policies on the checkpoint.
+func CheckpointTimestamp(n *note.Note, tsV note.Verifier) (time.Time, error) {
+	for _, s := range n.Sigs {
+		if s.Name == tsV.Name() && s.Hash == tsV.KeyHash() {
+			return f_note.CoSigV1Timestamp(s)
+		}
+	}
+	return time.Time{}, fmt.Errorf("no verified timestamped signature from %q", tsV.Name())
+}
+
+// CheckpointExtensions returns the extension lines, if any, which follow the origin, tree size,
+// and root hash lines in the body of a checkpoint, as described by https://c2sp.org/tlog-checkpoint.
+//
+// The provided note should have been opened, and its signature(s) verified, e.g. via OpenCheckpoint.
+func CheckpointExtensions(n *note.Note) ([]string, error) {
+	parts := strings.SplitN(n.Text, "\n", 4)
+	if len(parts) < 4 {
+		return nil, errors.New("invalid checkpoint - too few newlines")
+	}
+	rest := parts[3]
+	if rest == "" {
+		return nil, nil
+	}
+	if !strings.HasSuffix(rest, "\n") {
+		return nil, errors.New("invalid checkpoint - extension lines must be newline terminated")
+	}
+	ext := strings.Split(strings.TrimSuffix(rest, "\n"), "\n")
+	for _, l := range ext {
+		if l == "" {
+			return nil, errors.New("invalid checkpoint - empty extension line")
+		}
+	}
+	return ext, nil
+}
+
 // FetchRangeNodes returns the set of nodes representing the compact range covering
 // a log of size s.
 func FetchRangeNodes(ctx context.Context, s uint64, f TileFetcherFunc) ([][]byte, error) {
@@ -22,8 +22,10 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
 	"github.com/transparency-dev/merkle/compact"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
@@ -274,3 +276,158 @@ func TestGetEntryBundleAddressing(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenCheckpoint(t *testing.T) {
+	newKey := func(name string) (note.Signer, note.Verifier) {
+		t.Helper()
+		sk, vk, err := note.GenerateKey(nil, name)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		s, err := note.NewSigner(sk)
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+		v, err := note.NewVerifier(vk)
+		if err != nil {
+			t.Fatalf("NewVerifier: %v", err)
+		}
+		return s, v
+	}
+	logS, logV := newKey("example.com/log")
+	w1S, w1V := newKey("w1")
+	w2S, w2V := newKey("w2")
+	_, w3V := newKey("w3")
+	body := log.Checkpoint{Origin: "example.com/log", Size: 1, Hash: make([]byte, 32)}.Marshal()
+
+	for _, test := range []struct {
+		name         string
+		signers      []note.Signer
+		wantVerified []string
+		wantErr      bool
+	}{
+		{
+			name:    "log only",
+			signers: []note.Signer{logS},
+		}, {
+			name:         "log and some witnesses",
+			signers:      []note.Signer{logS, w1S},
+			wantVerified: []string{"w1"},
+		}, {
+			name:         "log and all witnesses",
+			signers:      []note.Signer{logS, w2S, w1S},
+			wantVerified: []string{"w1", "w2"},
+		}, {
+			name:    "witnesses only",
+			signers: []note.Signer{w1S, w2S},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cpRaw, err := note.Sign(&note.Note{Text: string(body)}, test.signers...)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			cp, verified, _, err := OpenCheckpoint(cpRaw, "example.com/log", logV, w1V, w2V, w3V)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("OpenCheckpoint: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cp.Size != 1 {
+				t.Errorf("Got size %d, want 1", cp.Size)
+			}
+			gotVerified := []string{}
+			for _, v := range verified {
+				gotVerified = append(gotVerified, v.Name())
+			}
+			if fmt.Sprint(gotVerified) != fmt.Sprint(test.wantVerified) && len(gotVerified)+len(test.wantVerified) > 0 {
+				t.Errorf("Got verified %v, want %v", gotVerified, test.wantVerified)
+			}
+		})
+	}
+}
+
+func TestCheckpointExtensions(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "none",
+			text: "example.com/log\n1\nAAAA\n",
+		}, {
+			name: "some",
+			text: "example.com/log\n1\nAAAA\nshard 2025h1\nkid 1234\n",
+			want: []string{"shard 2025h1", "kid 1234"},
+		}, {
+			name:    "empty line",
+			text:    "example.com/log\n1\nAAAA\nshard 2025h1\n\nkid 1234\n",
+			wantErr: true,
+		}, {
+			name:    "too short",
+			text:    "example.com/log\n1\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CheckpointExtensions(&note.Note{Text: test.text})
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("CheckpointExtensions: got err %v, want err %t", err, test.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("Got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckpointTimestamp(t *testing.T) {
+	sk, vk, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	logS, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	logV, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	tsS, err := f_note.NewSignerForCosignatureV1(sk)
+	if err != nil {
+		t.Fatalf("NewSignerForCosignatureV1: %v", err)
+	}
+	body := log.Checkpoint{Origin: "example.com/log", Size: 1, Hash: make([]byte, 32)}.Marshal()
+
+	for _, test := range []struct {
+		name    string
+		signers []note.Signer
+		wantErr bool
+	}{
+		{name: "timestamped", signers: []note.Signer{logS, tsS}},
+		{name: "not timestamped", signers: []note.Signer{logS}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cpRaw, err := note.Sign(&note.Note{Text: string(body)}, test.signers...)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			_, _, n, err := OpenCheckpoint(cpRaw, "example.com/log", logV, tsS.Verifier())
+			if err != nil {
+				t.Fatalf("OpenCheckpoint: %v", err)
+			}
+			ts, err := CheckpointTimestamp(n, tsS.Verifier())
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("CheckpointTimestamp: got err %v, want err %t", err, test.wantErr)
+			}
+			if err == nil && time.Since(ts) > time.Minute {
+				t.Errorf("Got timestamp %v, want roughly now", ts)
+			}
+		})
+	}
+}
@@ -28,6 +28,24 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// Fetcher provides access to the static resources which make up a tlog-tiles log.
+//
+// HTTPFetcher and FileFetcher implement this interface, as does tessera.LogReader, which allows
+// clients of the log to be pointed directly at a log's storage without going via HTTP.
+type Fetcher interface {
+	// ReadCheckpoint returns the latest checkpoint published by the log.
+	ReadCheckpoint(ctx context.Context) ([]byte, error)
+	// ReadTile returns the raw marshalled tile at the given coordinates.
+	ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error)
+	// ReadEntryBundle returns the raw marshalled entry bundle at the given coordinates.
+	ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error)
+}
+
+var (
+	_ Fetcher = HTTPFetcher{}
+	_ Fetcher = FileFetcher{}
+)
+
 // NewHTTPFetcher creates a new HTTPFetcher for the log rooted at the given URL, using
 // the provided HTTP client.
 //
@@ -41,16 +59,24 @@ func NewHTTPFetcher(rootURL *url.URL, c *http.Client) (*HTTPFetcher, error) {
 		c = http.DefaultClient
 	}
 	return &HTTPFetcher{
-		c:       c,
-		rootURL: rootURL,
+		c:           c,
+		rootURL:     rootURL,
+		entriesPath: layout.EntriesPath,
 	}, nil
 }
 
 // HTTPFetcher knows how to fetch log artifacts from a log being served via HTTP.
 type HTTPFetcher struct {
-	c          *http.Client
-	rootURL    *url.URL
-	authHeader string
+	c           *http.Client
+	rootURL     *url.URL
+	authHeader  string
+	entriesPath func(uint64, uint8) string
+}
+
+// SetEntriesPath overrides the function used to build the path of entry bundles, e.g. to fetch
+// the data tiles of a Static CT API log.
+func (h *HTTPFetcher) SetEntriesPath(f func(n uint64, p uint8) string) {
+	h.entriesPath = f
 }
 
 // SetAuthorizationHeader sets the value to be used with an Authorization: header
@@ -102,7 +128,7 @@ func (h HTTPFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte
 }
 
 func (h HTTPFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
-	return h.fetch(ctx, layout.EntriesPath(i, p))
+	return h.fetch(ctx, h.entriesPath(i, p))
 }
 
 // FileFetcher knows how to fetch log artifacts from a filesystem rooted at Root.
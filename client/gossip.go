@@ -0,0 +1,64 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"k8s.io/klog/v2"
+)
+
+// ErrSplitView is returned by GossipCheckpoint when the log reports that the submitted checkpoint
+// is inconsistent with its own history.
+var ErrSplitView = errors.New("log reported split view")
+
+// GossipCheckpoint submits a checkpoint seen for a log to that log's gossip endpoint at u, so that
+// the log can verify it against its own history.
+//
+// An error wrapping ErrSplitView is returned if the log reports that the checkpoint is evidence of a
+// split view. c may be nil, in which case http.DefaultClient will be used.
+func GossipCheckpoint(ctx context.Context, c *http.Client, u *url.URL, cp []byte) error {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(cp))
+	if err != nil {
+		return fmt.Errorf("NewRequestWithContext(%q): %v", u.String(), err)
+	}
+	r, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("post(%q): %v", u.String(), err)
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			klog.Errorf("resp.Body.Close(): %v", err)
+		}
+	}()
+	body, _ := io.ReadAll(r.Body)
+	switch r.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrSplitView, body)
+	default:
+		return fmt.Errorf("post(%q): %d: %s", u.String(), r.StatusCode, body)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var checkpointAgeGauge metric.Float64Gauge
+
+func init() {
+	var err error
+	checkpointAgeGauge, err = meter.Float64Gauge(
+		"tessera.client.checkpoint.age",
+		metric.WithDescription("Time since a monitored log's checkpoint last advanced"),
+		metric.WithUnit("s"))
+	if err != nil {
+		klog.Exitf("Failed to create checkpointAgeGauge metric: %v", err)
+	}
+}
+
+// CheckpointAgeMonitorOpts configures a CheckpointAgeMonitor.
+type CheckpointAgeMonitorOpts struct {
+	// MaxAge is how long the checkpoint may go without advancing before it is considered stale.
+	// This is required.
+	MaxAge time.Duration
+
+	// PollInterval is how often Run fetches the checkpoint.
+	// If unset, a quarter of MaxAge is used.
+	PollInterval time.Duration
+
+	// TimestampVerifier, if set, verifies the timestamped cosignature which the log adds to its checkpoints,
+	// e.g. when configured with WithCheckpointTimestamps.
+	// The checkpoint then also counts as advancing when its timestamp does, even if the tree hasn't grown,
+	// and its age is measured from that timestamp rather than from when this monitor first saw it.
+	TimestampVerifier note.Verifier
+
+	// OnStale, if set, is called once each time the checkpoint becomes stale, and OnFresh is called once
+	// each time it subsequently advances again.
+	OnStale func(CheckpointAge)
+	OnFresh func(CheckpointAge)
+}
+
+// CheckpointAge describes the progress of a monitored log's checkpoint.
+type CheckpointAge struct {
+	// Size is the largest tree size seen.
+	Size uint64
+	// Timestamp is the latest checkpoint timestamp seen, if CheckpointAgeMonitorOpts.TimestampVerifier is set.
+	Timestamp time.Time
+	// AdvancedAt is when the checkpoint last advanced.
+	AdvancedAt time.Time
+	// Age is how long the checkpoint had gone without advancing when it was last checked.
+	Age time.Duration
+	// Stale is true if Age exceeded CheckpointAgeMonitorOpts.MaxAge.
+	Stale bool
+}
+
+// CheckpointAgeMonitor watches a log's checkpoint, and reports when it stops advancing for longer than
+// a configured threshold.
+//
+// This is intended for monitors which enforce freshness, or maximum merge delay, expectations on a log.
+// A failure to fetch or verify the checkpoint doesn't count as progress, so an unreachable log also
+// becomes stale.
+type CheckpointAgeMonitor struct {
+	f      CheckpointFetcherFunc
+	origin string
+	v      note.Verifier
+	opts   CheckpointAgeMonitorOpts
+	now    func() time.Time
+
+	mu  sync.Mutex
+	cur CheckpointAge
+}
+
+// NewCheckpointAgeMonitor returns a monitor of the checkpoint fetched by f, which must be signed by v for origin.
+//
+// The age of the checkpoint is measured from the time the monitor is created until it first fetches a checkpoint.
+func NewCheckpointAgeMonitor(f CheckpointFetcherFunc, v note.Verifier, origin string, opts CheckpointAgeMonitorOpts) (*CheckpointAgeMonitor, error) {
+	if opts.MaxAge <= 0 {
+		return nil, errors.New("MaxAge must be positive")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = opts.MaxAge / 4
+	}
+	m := &CheckpointAgeMonitor{
+		f:      f,
+		origin: origin,
+		v:      v,
+		opts:   opts,
+		now:    time.Now,
+	}
+	m.cur.AdvancedAt = m.now()
+	return m, nil
+}
+
+// Run checks the checkpoint every PollInterval until ctx is done.
+func (m *CheckpointAgeMonitor) Run(ctx context.Context) {
+	t := time.NewTicker(m.opts.PollInterval)
+	defer t.Stop()
+	for {
+		if _, err := m.Check(ctx); err != nil {
+			klog.Warningf("CheckpointAgeMonitor(%q): %v", m.origin, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Check fetches the checkpoint once, and returns the updated state of the monitor.
+//
+// The state is returned, and the age recorded, even if the checkpoint couldn't be fetched or verified,
+// in which case the error is also returned.
+func (m *CheckpointAgeMonitor) Check(ctx context.Context) (CheckpointAge, error) {
+	size, ts, err := m.fetch(ctx)
+
+	m.mu.Lock()
+	now := m.now()
+	wasStale := m.cur.Stale
+	if err == nil {
+		if size > m.cur.Size {
+			m.cur.Size = size
+			m.cur.AdvancedAt = now
+		}
+		if ts.After(m.cur.Timestamp) {
+			m.cur.Timestamp = ts
+			m.cur.AdvancedAt = now
+		}
+	}
+	since := m.cur.AdvancedAt
+	if !m.cur.Timestamp.IsZero() && m.cur.Timestamp.Before(since) {
+		since = m.cur.Timestamp
+	}
+	m.cur.Age = max(0, now.Sub(since))
+	m.cur.Stale = m.cur.Age > m.opts.MaxAge
+	cur := m.cur
+	m.mu.Unlock()
+
+	checkpointAgeGauge.Record(ctx, cur.Age.Seconds(), metric.WithAttributes(originKey.String(m.origin)))
+	switch {
+	case cur.Stale && !wasStale && m.opts.OnStale != nil:
+		m.opts.OnStale(cur)
+	case !cur.Stale && wasStale && m.opts.OnFresh != nil:
+		m.opts.OnFresh(cur)
+	}
+	return cur, err
+}
+
+// Latest returns the state of the monitor as of the last check.
+func (m *CheckpointAgeMonitor) Latest() CheckpointAge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cur
+}
+
+// fetch returns the size, and timestamp if configured, of the log's current checkpoint.
+func (m *CheckpointAgeMonitor) fetch(ctx context.Context) (uint64, time.Time, error) {
+	raw, err := m.f(ctx)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to fetch checkpoint: %v", err)
+	}
+	if m.opts.TimestampVerifier == nil {
+		cp, _, _, err := OpenCheckpoint(raw, m.origin, m.v)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		return cp.Size, time.Time{}, nil
+	}
+	cp, _, n, err := OpenCheckpoint(raw, m.origin, m.v, m.opts.TimestampVerifier)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	ts, err := CheckpointTimestamp(n, m.opts.TimestampVerifier)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return cp.Size, ts, nil
+}
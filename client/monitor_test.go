@@ -0,0 +1,147 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestCheckpointAgeMonitor(t *testing.T) {
+	const origin = "example.com/log"
+	sk, vk, err := note.GenerateKey(nil, origin)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	var cpRaw []byte
+	var fetchErr error
+	setSize := func(size uint64) {
+		cpRaw, err = note.Sign(&note.Note{Text: string(log.Checkpoint{Origin: origin, Size: size, Hash: make([]byte, 32)}.Marshal())}, s)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+	}
+	fetch := func(context.Context) ([]byte, error) { return cpRaw, fetchErr }
+
+	var stale, fresh int
+	m, err := NewCheckpointAgeMonitor(fetch, v, origin, CheckpointAgeMonitorOpts{
+		MaxAge:  time.Minute,
+		OnStale: func(CheckpointAge) { stale++ },
+		OnFresh: func(CheckpointAge) { fresh++ },
+	})
+	if err != nil {
+		t.Fatalf("NewCheckpointAgeMonitor: %v", err)
+	}
+	now := time.Unix(1000, 0)
+	m.now = func() time.Time { return now }
+
+	for _, step := range []struct {
+		desc      string
+		advance   time.Duration
+		size      uint64
+		fetchErr  error
+		wantErr   bool
+		wantSize  uint64
+		wantAge   time.Duration
+		wantStale bool
+		wantCalls [2]int
+	}{
+		{desc: "first checkpoint", size: 1, wantSize: 1},
+		{desc: "growing", advance: 30 * time.Second, size: 5, wantSize: 5},
+		{desc: "not growing", advance: 45 * time.Second, size: 5, wantSize: 5, wantAge: 45 * time.Second},
+		{desc: "stalled", advance: 30 * time.Second, size: 5, wantSize: 5, wantAge: 75 * time.Second, wantStale: true, wantCalls: [2]int{1, 0}},
+		{desc: "still stalled", advance: 30 * time.Second, size: 5, wantSize: 5, wantAge: 105 * time.Second, wantStale: true, wantCalls: [2]int{1, 0}},
+		{desc: "recovered", advance: time.Second, size: 6, wantSize: 6, wantCalls: [2]int{1, 1}},
+		{desc: "unreachable", advance: 2 * time.Minute, size: 7, fetchErr: errors.New("boom"), wantErr: true, wantSize: 6, wantAge: 2 * time.Minute, wantStale: true, wantCalls: [2]int{2, 1}},
+		{desc: "shrinking", advance: time.Second, size: 3, wantSize: 6, wantAge: 121 * time.Second, wantStale: true, wantCalls: [2]int{2, 1}},
+	} {
+		now = now.Add(step.advance)
+		setSize(step.size)
+		fetchErr = step.fetchErr
+		got, err := m.Check(t.Context())
+		if gotErr := err != nil; gotErr != step.wantErr {
+			t.Fatalf("%s: Check: got err %v, want err %t", step.desc, err, step.wantErr)
+		}
+		if got.Size != step.wantSize || got.Age != step.wantAge || got.Stale != step.wantStale {
+			t.Errorf("%s: got %+v, want size %d, age %v, stale %t", step.desc, got, step.wantSize, step.wantAge, step.wantStale)
+		}
+		if gotCalls := [2]int{stale, fresh}; gotCalls != step.wantCalls {
+			t.Errorf("%s: got (OnStale, OnFresh) calls %v, want %v", step.desc, gotCalls, step.wantCalls)
+		}
+		if got != m.Latest() {
+			t.Errorf("%s: Latest() = %+v, want %+v", step.desc, m.Latest(), got)
+		}
+	}
+
+	if _, err := NewCheckpointAgeMonitor(fetch, v, origin, CheckpointAgeMonitorOpts{}); err == nil {
+		t.Error("NewCheckpointAgeMonitor: got nil error without MaxAge")
+	}
+}
+
+func TestCheckpointAgeMonitorTimestamp(t *testing.T) {
+	const origin = "example.com/log"
+	sk, vk, err := note.GenerateKey(nil, origin)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	tsS, err := f_note.NewSignerForCosignatureV1(sk)
+	if err != nil {
+		t.Fatalf("NewSignerForCosignatureV1: %v", err)
+	}
+	cpRaw, err := note.Sign(&note.Note{Text: string(log.Checkpoint{Origin: origin, Size: 1, Hash: make([]byte, 32)}.Marshal())}, s, tsS)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	m, err := NewCheckpointAgeMonitor(func(context.Context) ([]byte, error) { return cpRaw, nil }, v, origin, CheckpointAgeMonitorOpts{
+		MaxAge:            time.Minute,
+		TimestampVerifier: tsS.Verifier(),
+	})
+	if err != nil {
+		t.Fatalf("NewCheckpointAgeMonitor: %v", err)
+	}
+	// Although this is the first time the monitor has seen the checkpoint, its timestamp shows it's old.
+	m.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	got, err := m.Check(t.Context())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got.Timestamp.IsZero() || got.Age < 2*time.Minute || !got.Stale {
+		t.Errorf("Check: got %+v, want a timestamp at least 2m old, and stale", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// DefaultHedgeDelay is how long a MultiFetcher waits for a source to respond before also trying the
+// next one, unless MultiFetcherOpts.HedgeDelay is set.
+const DefaultHedgeDelay = time.Second
+
+// ErrMirrorMismatch is wrapped by the error returned by a MultiFetcher which has been asked to verify
+// its sources, when two of them serve different content for the same immutable resource.
+var ErrMirrorMismatch = errors.New("sources served different content")
+
+// MultiFetcherOpts configures a MultiFetcher.
+type MultiFetcherOpts struct {
+	// HedgeDelay is how long to wait for a source to respond before additionally requesting the same
+	// resource from the next source. A source which fails is moved on from immediately.
+	// If unset, DefaultHedgeDelay is used.
+	HedgeDelay time.Duration
+
+	// Verify causes full tiles and entry bundles to be requested from every source, and an error
+	// wrapping ErrMirrorMismatch to be returned if any two sources serve different content.
+	// Sources which fail, e.g. because they're down or haven't yet mirrored the resource, are ignored
+	// so long as at least one succeeds.
+	//
+	// Partial resources and checkpoints aren't verified, since different sources may legitimately
+	// serve different versions of them, e.g. a larger tile in place of a partial one, or a checkpoint
+	// from a mirror which is lagging behind.
+	Verify bool
+}
+
+// MultiFetcher is a Fetcher which reads from several sources serving the same log, e.g. mirrors or
+// CDN edges, so that the failure of any one of them doesn't prevent the log from being read.
+//
+// Sources are tried in the order given, so the preferred source should be first. If a source fails,
+// or hasn't responded within the hedge delay, the next source is tried too, and the first successful
+// response is returned.
+//
+// Since mirrors may lag behind the log, the checkpoint returned by ReadCheckpoint may be older than
+// the log's latest one; callers must verify checkpoints, and their consistency, as usual.
+type MultiFetcher struct {
+	sources []Fetcher
+	opts    MultiFetcherOpts
+}
+
+var _ Fetcher = &MultiFetcher{}
+
+// NewMultiFetcher returns a MultiFetcher which reads from the provided sources, in order of preference.
+func NewMultiFetcher(sources []Fetcher, opts MultiFetcherOpts) (*MultiFetcher, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("at least one source is required")
+	}
+	if opts.HedgeDelay <= 0 {
+		opts.HedgeDelay = DefaultHedgeDelay
+	}
+	return &MultiFetcher{
+		sources: sources,
+		opts:    opts,
+	}, nil
+}
+
+// readFunc reads a single resource from a source.
+type readFunc func(ctx context.Context, f Fetcher) ([]byte, error)
+
+// ReadCheckpoint returns the checkpoint from the first source to successfully serve one.
+func (m *MultiFetcher) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	return m.race(ctx, layout.CheckpointPath, func(ctx context.Context, f Fetcher) ([]byte, error) {
+		return f.ReadCheckpoint(ctx)
+	})
+}
+
+// ReadTile returns the tile at the given coordinates.
+func (m *MultiFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+	read := func(ctx context.Context, f Fetcher) ([]byte, error) {
+		return f.ReadTile(ctx, l, i, p)
+	}
+	if m.opts.Verify && p == 0 {
+		return m.verified(ctx, layout.TilePath(l, i, p), read)
+	}
+	return m.race(ctx, layout.TilePath(l, i, p), read)
+}
+
+// ReadEntryBundle returns the entry bundle at the given coordinates.
+func (m *MultiFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	read := func(ctx context.Context, f Fetcher) ([]byte, error) {
+		return f.ReadEntryBundle(ctx, i, p)
+	}
+	if m.opts.Verify && p == 0 {
+		return m.verified(ctx, layout.EntriesPath(i, p), read)
+	}
+	return m.race(ctx, layout.EntriesPath(i, p), read)
+}
+
+// result is the outcome of reading a resource from the source at index i.
+type result struct {
+	i   int
+	d   []byte
+	err error
+}
+
+// race reads a resource from the sources in order, starting on the next source whenever the
+// previous one fails or is slower than the hedge delay, and returns the first successful response.
+//
+// If every source fails, the returned error wraps all of their errors, so it will satisfy
+// errors.Is(err, os.ErrNotExist) if any of them didn't have the resource.
+func (m *MultiFetcher) race(ctx context.Context, name string, read readFunc) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// Abandon any requests which are still outstanding once we have an answer.
+	defer cancel()
+
+	results := make(chan result, len(m.sources))
+	next, inflight := 0, 0
+	launch := func() {
+		i := next
+		go func() {
+			d, err := read(ctx, m.sources[i])
+			results <- result{i: i, d: d, err: err}
+		}()
+		next++
+		inflight++
+	}
+
+	launch()
+	t := time.NewTimer(m.opts.HedgeDelay)
+	defer t.Stop()
+	errs := make([]error, 0, len(m.sources))
+	for inflight > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				return r.d, nil
+			}
+			errs = append(errs, fmt.Errorf("source %d: %w", r.i, r.err))
+			if next < len(m.sources) {
+				launch()
+				t.Reset(m.opts.HedgeDelay)
+			}
+		case <-t.C:
+			if next < len(m.sources) {
+				launch()
+				t.Reset(m.opts.HedgeDelay)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: all %d sources failed: %w", name, len(m.sources), errors.Join(errs...))
+}
+
+// verified reads a resource from all sources concurrently, and checks that every source which
+// successfully served it returned identical content.
+func (m *MultiFetcher) verified(ctx context.Context, name string, read readFunc) ([]byte, error) {
+	results := make(chan result, len(m.sources))
+	for i, f := range m.sources {
+		go func() {
+			d, err := read(ctx, f)
+			results <- result{i: i, d: d, err: err}
+		}()
+	}
+
+	var first *result
+	errs := make([]error, 0, len(m.sources))
+	for range m.sources {
+		r := <-results
+		switch {
+		case r.err != nil:
+			errs = append(errs, fmt.Errorf("source %d: %w", r.i, r.err))
+		case first == nil:
+			first = &r
+		case !bytes.Equal(first.d, r.d):
+			return nil, fmt.Errorf("%s: sources %d and %d: %w", name, first.i, r.i, ErrMirrorMismatch)
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("%s: all %d sources failed: %w", name, len(m.sources), errors.Join(errs...))
+	}
+	return first.d, nil
+}
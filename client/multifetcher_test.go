@@ -0,0 +1,171 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSource is a Fetcher which serves the same content for every resource, after an optional delay.
+type fakeSource struct {
+	data  string
+	err   error
+	delay time.Duration
+	reads atomic.Int32
+}
+
+func (f *fakeSource) read(ctx context.Context) ([]byte, error) {
+	f.reads.Add(1)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(f.delay):
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(f.data), nil
+}
+
+func (f *fakeSource) ReadCheckpoint(ctx context.Context) ([]byte, error) { return f.read(ctx) }
+func (f *fakeSource) ReadTile(ctx context.Context, _, _ uint64, _ uint8) ([]byte, error) {
+	return f.read(ctx)
+}
+func (f *fakeSource) ReadEntryBundle(ctx context.Context, _ uint64, _ uint8) ([]byte, error) {
+	return f.read(ctx)
+}
+
+func TestMultiFetcherRace(t *testing.T) {
+	errDown := errors.New("down")
+	for _, test := range []struct {
+		desc      string
+		sources   []*fakeSource
+		want      string
+		wantErr   error
+		wantReads []int32
+	}{
+		{
+			desc:      "first succeeds",
+			sources:   []*fakeSource{{data: "a"}, {data: "b"}},
+			want:      "a",
+			wantReads: []int32{1, 0},
+		}, {
+			desc:      "fail over",
+			sources:   []*fakeSource{{err: errDown}, {data: "b"}},
+			want:      "b",
+			wantReads: []int32{1, 1},
+		}, {
+			desc:      "hedge slow source",
+			sources:   []*fakeSource{{data: "a", delay: time.Minute}, {data: "b"}},
+			want:      "b",
+			wantReads: []int32{1, 1},
+		}, {
+			desc:      "all fail",
+			sources:   []*fakeSource{{err: errDown}, {err: os.ErrNotExist}},
+			wantErr:   os.ErrNotExist,
+			wantReads: []int32{1, 1},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			fs := make([]Fetcher, len(test.sources))
+			for i, s := range test.sources {
+				fs[i] = s
+			}
+			m, err := NewMultiFetcher(fs, MultiFetcherOpts{HedgeDelay: 10 * time.Millisecond})
+			if err != nil {
+				t.Fatalf("NewMultiFetcher: %v", err)
+			}
+			got, err := m.ReadTile(t.Context(), 0, 0, 0)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("ReadTile: got err %v, want %v", err, test.wantErr)
+				}
+			} else if err != nil || string(got) != test.want {
+				t.Fatalf("ReadTile = %q, %v, want %q", got, err, test.want)
+			}
+			for i, s := range test.sources {
+				if got := s.reads.Load(); got != test.wantReads[i] {
+					t.Errorf("source %d read %d times, want %d", i, got, test.wantReads[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMultiFetcherVerify(t *testing.T) {
+	errDown := errors.New("down")
+	for _, test := range []struct {
+		desc    string
+		sources []*fakeSource
+		partial uint8
+		want    string
+		wantErr error
+	}{
+		{
+			desc:    "identical",
+			sources: []*fakeSource{{data: "a"}, {data: "a"}, {data: "a"}},
+			want:    "a",
+		}, {
+			desc:    "one down",
+			sources: []*fakeSource{{err: errDown}, {data: "a"}, {data: "a"}},
+			want:    "a",
+		}, {
+			desc:    "mismatch",
+			sources: []*fakeSource{{data: "a"}, {data: "a"}, {data: "evil"}},
+			wantErr: ErrMirrorMismatch,
+		}, {
+			desc:    "partials aren't verified",
+			sources: []*fakeSource{{data: "a"}, {data: "longer"}},
+			partial: 1,
+			want:    "a",
+		}, {
+			desc:    "all down",
+			sources: []*fakeSource{{err: errDown}, {err: errDown}},
+			wantErr: errDown,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			fs := make([]Fetcher, len(test.sources))
+			for i, s := range test.sources {
+				fs[i] = s
+			}
+			m, err := NewMultiFetcher(fs, MultiFetcherOpts{Verify: true})
+			if err != nil {
+				t.Fatalf("NewMultiFetcher: %v", err)
+			}
+			got, err := m.ReadEntryBundle(t.Context(), 0, test.partial)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("ReadEntryBundle: got err %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != test.want {
+				t.Fatalf("ReadEntryBundle = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestNewMultiFetcherNoSources(t *testing.T) {
+	if _, err := NewMultiFetcher(nil, MultiFetcherOpts{}); err == nil {
+		t.Error("NewMultiFetcher with no sources succeeded, want error")
+	}
+}
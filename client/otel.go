@@ -23,6 +23,7 @@ const name = "github.com/transparency-dev/tessera/client"
 
 var (
 	tracer = otel.Tracer(name)
+	meter  = otel.Meter(name)
 )
 
 var (
@@ -33,4 +34,5 @@ var (
 	levelKey   = attribute.Key("level")
 	smallerKey = attribute.Key("smaller")
 	largerKey  = attribute.Key("larger")
+	originKey  = attribute.Key("tessera.origin")
 )
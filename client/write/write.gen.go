@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by openapigen from write-api.json. DO NOT EDIT.
+
+package write
+
+// responseKind describes how the client should handle a response.
+type responseKind int
+
+const (
+	// responseReject means that the request was rejected, and shouldn't be retried.
+	responseReject responseKind = iota
+	// responseSuccess means that the request succeeded.
+	responseSuccess
+	// responseRetry means that the request should be retried later.
+	responseRetry
+)
+
+// AddRequest is the body of a JSON add request.
+type AddRequest struct {
+	// Data is the base64 encoded entry.
+	Data []byte `json:"data"`
+	// Metadata is optional information about the entry, which the personality may use when constructing or
+	// validating it.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// InclusionProof is whether to delay the response until a checkpoint committing to the entry has been
+	// published, and include a proof of the entry's inclusion in that checkpoint.
+	InclusionProof bool `json:"inclusion_proof,omitempty"`
+}
+
+// AddResponse is the body of the response to a successful JSON add request.
+type AddResponse struct {
+	// Index is the index assigned to the entry.
+	Index uint64 `json:"index"`
+	// Duplicate is whether the entry was already present in the log, in which case index is the index previously
+	// assigned to it.
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Checkpoint is the checkpoint the inclusion proof is relative to if one was requested, otherwise the latest
+	// checkpoint at the time of the response, which may not yet commit to the entry.
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// InclusionProof is the base64 encoded hashes making up the inclusion proof, if one was requested.
+	InclusionProof [][]byte `json:"inclusion_proof,omitempty"`
+}
+
+// addPath is the path of the add operation, relative to the base URL of the API.
+const addPath = "add"
+
+// addResponses maps the status of each response to the add operation to how it should be handled.
+var addResponses = map[int]responseKind{
+	200: responseSuccess,
+	400: responseReject,
+	413: responseReject,
+	429: responseRetry,
+	500: responseReject,
+	501: responseReject,
+	503: responseRetry,
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package write provides a typed client for the personality write API described by the OpenAPI
+// specification in api/openapi/write-api.json.
+//
+// The request and response types, and how the client handles each response status, are generated from
+// the specification by internal/openapigen. Run `go generate` in this directory after changing it.
+package write
+
+//go:generate go run ../../internal/openapigen --spec ../../api/openapi/write-api.json --package write --out write.gen.go
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of attempts made to add an entry before giving up.
+	DefaultMaxAttempts = 10
+	// DefaultRetryAfter is the default delay before retrying, used if a pushback response doesn't
+	// carry a valid Retry-After header.
+	DefaultRetryAfter = time.Second
+	// maxResponseSize limits the size of response bodies read by the client.
+	maxResponseSize = 1 << 16
+)
+
+// StatusError is returned when the log responds with an unexpected HTTP status.
+//
+// It wraps the tessera sentinel error which corresponds to the status, where there is one, so that
+// callers can use e.g. `errors.Is(err, tessera.ErrPushback)`.
+type StatusError struct {
+	// Code is the HTTP status code returned by the log.
+	Code int
+	// Body is the body of the response, which usually describes the error.
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("got status %d: %s", e.Code, e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	if addResponses[e.Code] == responseRetry {
+		return tessera.ErrPushback
+	}
+	switch e.Code {
+	case http.StatusBadRequest:
+		return tessera.ErrInvalidEntry
+	case http.StatusRequestEntityTooLarge:
+		return tessera.ErrEntryTooLarge
+	default:
+		return nil
+	}
+}
+
+// Options configures a Client.
+type Options struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// MaxAttempts is the maximum number of attempts made to add an entry while the log is pushing back,
+	// or the request fails due to transient errors. If zero, DefaultMaxAttempts is used.
+	MaxAttempts uint
+	// MaxRetryAfter, if non-zero, caps the delay requested by the log before a retry.
+	MaxRetryAfter time.Duration
+}
+
+// Client submits entries to a log via its write API.
+type Client struct {
+	addURL string
+	opts   Options
+}
+
+// NewClient returns a client for the write API served under baseURL.
+func NewClient(baseURL *url.URL, opts Options) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	return &Client{
+		addURL: baseURL.JoinPath(addPath).String(),
+		opts:   opts,
+	}
+}
+
+// Add submits data as a new entry, returning the index assigned to it.
+//
+// Pushback responses are retried after the delay requested by the log, as are requests which fail
+// due to transport or server errors, up to the configured number of attempts or until ctx is done.
+// Rejections, e.g. because the entry is too large, are returned immediately as a *StatusError.
+func (c *Client) Add(ctx context.Context, data []byte) (uint64, error) {
+	var lastErr error
+	for attempt := uint(0); attempt < c.opts.MaxAttempts; attempt++ {
+		idx, retryAfter, err := c.add(ctx, data)
+		if err == nil || retryAfter < 0 {
+			return idx, err
+		}
+		lastErr = err
+		if c.opts.MaxRetryAfter > 0 {
+			retryAfter = min(retryAfter, c.opts.MaxRetryAfter)
+		}
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+		case <-time.After(retryAfter):
+		}
+	}
+	return 0, fmt.Errorf("giving up after %d attempts: %w", c.opts.MaxAttempts, lastErr)
+}
+
+// add makes a single attempt to add the entry. If the attempt failed but may be retried, the returned
+// duration is the delay before doing so, otherwise it's negative.
+func (c *Client) add(ctx context.Context, data []byte) (uint64, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addURL, bytes.NewReader(data))
+	if err != nil {
+		return 0, -1, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, -1, err
+		}
+		return 0, DefaultRetryAfter, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	_ = resp.Body.Close()
+	if err != nil {
+		return 0, DefaultRetryAfter, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	switch addResponses[resp.StatusCode] {
+	case responseSuccess:
+		idx, err := strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+		if err != nil {
+			return 0, -1, fmt.Errorf("invalid index in response %q: %v", body, err)
+		}
+		return idx, -1, nil
+	case responseRetry:
+		return 0, retryAfter(resp.Header.Get("Retry-After")), &StatusError{Code: resp.StatusCode, Body: string(body)}
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		// These are usually transient, whether they come from the log or a proxy in front of it.
+		return 0, DefaultRetryAfter, &StatusError{Code: resp.StatusCode, Body: string(body)}
+	default:
+		return 0, -1, &StatusError{Code: resp.StatusCode, Body: string(body)}
+	}
+}
+
+// retryAfter parses the delay-seconds form of a Retry-After header.
+func retryAfter(h string) time.Duration {
+	s, err := strconv.ParseUint(strings.TrimSpace(h), 10, 32)
+	if err != nil {
+		return DefaultRetryAfter
+	}
+	return time.Duration(s) * time.Second
+}
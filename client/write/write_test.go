@@ -0,0 +1,144 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package write
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// newTestServer returns a client for a server which responds to successive requests with the given
+// statuses, and a pointer to the number of requests it has received.
+func newTestServer(t *testing.T, statuses ...int) (*Client, *int) {
+	t.Helper()
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/log/add" {
+			t.Errorf("Got %s %s, want POST /log/add", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		code := statuses[min(calls, len(statuses)-1)]
+		calls++
+		if code == http.StatusServiceUnavailable || code == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			_, _ = w.Write([]byte(strconv.Itoa(len(body))))
+		}
+	}))
+	t.Cleanup(s.Close)
+	u, err := url.Parse(s.URL + "/log/")
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	return NewClient(u, Options{HTTPClient: s.Client(), MaxAttempts: 3}), &calls
+}
+
+func TestAdd(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		statuses  []int
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "ok", statuses: []int{http.StatusOK}, wantCalls: 1},
+		{name: "pushback then ok", statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, wantCalls: 3},
+		{name: "persistent pushback", statuses: []int{http.StatusServiceUnavailable}, wantCalls: 3, wantErr: tessera.ErrPushback},
+		{name: "server error then ok", statuses: []int{http.StatusInternalServerError, http.StatusOK}, wantCalls: 2},
+		{name: "too large", statuses: []int{http.StatusRequestEntityTooLarge, http.StatusOK}, wantCalls: 1, wantErr: tessera.ErrEntryTooLarge},
+		{name: "invalid", statuses: []int{http.StatusBadRequest, http.StatusOK}, wantCalls: 1, wantErr: tessera.ErrInvalidEntry},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, calls := newTestServer(t, test.statuses...)
+			idx, err := c.Add(t.Context(), []byte("12345"))
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("Add: got err %v, want %v", err, test.wantErr)
+				}
+			} else if err != nil || idx != 5 {
+				t.Errorf("Add: got %d, %v, want 5", idx, err)
+			}
+			if *calls != test.wantCalls {
+				t.Errorf("Got %d calls, want %d", *calls, test.wantCalls)
+			}
+		})
+	}
+}
+
+// TestSpec checks that the client handles each response declared by the OpenAPI specification as described.
+func TestSpec(t *testing.T) {
+	b, err := os.ReadFile("../../api/openapi/write-api.json")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Ref string `json:"$ref"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	add, ok := spec.Paths["/add"]["post"]
+	if !ok {
+		t.Fatal("Spec has no POST /add operation")
+	}
+	for code, resp := range add.Responses {
+		t.Run(code, func(t *testing.T) {
+			status, err := strconv.Atoi(code)
+			if err != nil {
+				t.Fatalf("Invalid status %q: %v", code, err)
+			}
+			c, calls := newTestServer(t, status, http.StatusOK)
+			c.opts.MaxRetryAfter = time.Millisecond
+			_, err = c.Add(t.Context(), []byte("entry"))
+			switch resp.Ref {
+			case "":
+				if status != http.StatusOK || err != nil {
+					t.Errorf("Add: got err %v for success response", err)
+				}
+			case "#/components/responses/Pushback":
+				if err != nil || *calls != 2 {
+					t.Errorf("Add: got err %v after %d calls, want pushback to be retried", err, *calls)
+				}
+				if !errors.Is(&StatusError{Code: status}, tessera.ErrPushback) {
+					t.Errorf("StatusError %d doesn't wrap ErrPushback", status)
+				}
+			case "#/components/responses/Error":
+				var se *StatusError
+				if err == nil && status != http.StatusInternalServerError {
+					t.Errorf("Add: got nil error for error response")
+				} else if err != nil && !errors.As(err, &se) {
+					t.Errorf("Add: got err %v, want StatusError", err)
+				}
+			default:
+				t.Errorf("Unknown response %q", resp.Ref)
+			}
+		})
+	}
+}
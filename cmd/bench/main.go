@@ -0,0 +1,135 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// bench drives a Tessera storage driver directly, bypassing any HTTP personality, and reports its
+// sequencing latency and integration throughput.
+//
+// This allows the performance of drivers, or of changes to a driver, to be compared objectively.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/bench"
+	"github.com/transparency-dev/tessera/storage/aws"
+	"github.com/transparency-dev/tessera/storage/gcp"
+	"github.com/transparency-dev/tessera/storage/mysql"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	driverName = flag.String("driver", "posix", "Storage driver to benchmark, one of posix, mysql, gcp, or aws.")
+
+	storageDir = flag.String("storage_dir", "", "posix: Root directory to store log data. If unset, a temporary directory is used.")
+	mysqlURI   = flag.String("mysql_uri", "", "mysql/aws: Connection string for the database. The mysql driver requires the schema to have been applied already.")
+	bucket     = flag.String("bucket", "", "gcp/aws: Bucket to use for storing log data.")
+	spanner    = flag.String("spanner", "", "gcp: Spanner resource URI ('projects/.../...').")
+
+	numEntries         = flag.Int("num_entries", bench.DefaultOptions().NumEntries, "Number of entries to add.")
+	entrySize          = flag.Int("entry_size", bench.DefaultOptions().EntrySize, "Size in bytes of each entry.")
+	concurrency        = flag.Int("concurrency", bench.DefaultOptions().Concurrency, "Number of concurrent writers.")
+	batchMaxSize       = flag.Uint("batch_max_size", tessera.DefaultBatchMaxSize, "Maximum number of entries in a sequencing batch.")
+	batchMaxAge        = flag.Duration("batch_max_age", tessera.DefaultBatchMaxAge, "Maximum age of a sequencing batch.")
+	checkpointInterval = flag.Duration("checkpoint_interval", tessera.DefaultCheckpointInterval, "Interval between checkpoint publications.")
+	runs               = flag.Int("runs", 1, "Number of times to repeat the benchmark.")
+	privKeyFile        = flag.String("private_key", "", "Location of a note private key file to sign checkpoints with, which is needed to benchmark existing storage again. If unset, an ephemeral key is used for all runs.")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	driver := driverOrDie(ctx)
+	opts := bench.Options{
+		NumEntries:         *numEntries,
+		EntrySize:          *entrySize,
+		Concurrency:        *concurrency,
+		BatchMaxSize:       *batchMaxSize,
+		BatchMaxAge:        *batchMaxAge,
+		CheckpointInterval: *checkpointInterval,
+		// All runs share the same storage, so they must all sign with the same key.
+		Signer: signerOrDie(),
+	}
+	for i := range *runs {
+		r, err := bench.Run(ctx, driver, opts)
+		if err != nil {
+			klog.Exitf("Run %d: %v", i, err)
+		}
+		fmt.Printf("run=%d driver=%s entry_size=%d concurrency=%d %s\n", i, *driverName, *entrySize, *concurrency, r)
+	}
+}
+
+func signerOrDie() note.Signer {
+	if *privKeyFile == "" {
+		s, err := bench.NewSigner()
+		if err != nil {
+			klog.Exitf("Failed to create signer: %v", err)
+		}
+		return s
+	}
+	k, err := os.ReadFile(*privKeyFile)
+	if err != nil {
+		klog.Exitf("Failed to read private key: %v", err)
+	}
+	s, err := note.NewSigner(strings.TrimSpace(string(k)))
+	if err != nil {
+		klog.Exitf("Failed to create signer: %v", err)
+	}
+	return s
+}
+
+func driverOrDie(ctx context.Context) tessera.Driver {
+	var d tessera.Driver
+	var err error
+	switch *driverName {
+	case "posix":
+		dir := *storageDir
+		if dir == "" {
+			if dir, err = os.MkdirTemp("", "tessera-bench"); err != nil {
+				klog.Exitf("MkdirTemp: %v", err)
+			}
+			klog.Infof("Using temporary directory %q", dir)
+		}
+		d, err = posix.New(ctx, dir)
+	case "mysql":
+		var db *sql.DB
+		if db, err = sql.Open("mysql", *mysqlURI); err != nil {
+			klog.Exitf("Failed to connect to DB: %v", err)
+		}
+		db.SetConnMaxLifetime(time.Minute)
+		db.SetMaxOpenConns(*concurrency)
+		d, err = mysql.New(ctx, db)
+	case "gcp":
+		d, err = gcp.New(ctx, gcp.Config{Bucket: *bucket, Spanner: *spanner})
+	case "aws":
+		d, err = aws.New(ctx, aws.Config{Bucket: *bucket, DSN: *mysqlURI})
+	default:
+		klog.Exitf("Unknown --driver %q", *driverName)
+	}
+	if err != nil {
+		klog.Exitf("Failed to construct %s storage: %v", *driverName, err)
+	}
+	return d
+}
@@ -17,10 +17,8 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -29,6 +27,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/go-sql-driver/mysql"
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/debug"
+	"github.com/transparency-dev/tessera/internal/httpserve"
 	"github.com/transparency-dev/tessera/storage/aws"
 	aws_as "github.com/transparency-dev/tessera/storage/aws/antispam"
 	"golang.org/x/mod/sumdb/note"
@@ -46,11 +46,14 @@ var (
 	dbPassword        = flag.String("db_password", "", "AuroraDB user")
 	dbMaxConns        = flag.Int("db_max_conns", 0, "Maximum connections to the database, defaults to 0, i.e unlimited")
 	dbMaxIdle         = flag.Int("db_max_idle_conns", 2, "Maximum idle database connections in the connection pool, defaults to 2")
+	dbAdaptiveMin     = flag.Int("db_adaptive_min_conns", 0, "If non-zero, the maximum number of connections to the database is adapted to the load between this value and --db_max_conns")
 	s3Endpoint        = flag.String("s3_endpoint", "", "Endpoint for custom non-AWS S3 service")
 	s3AccessKeyID     = flag.String("s3_access_key", "", "Access key ID for custom non-AWS S3 service")
 	s3SecretAccessKey = flag.String("s3_secret", "", "Secret access key for custom non-AWS S3 service")
 
 	listen            = flag.String("listen", ":2024", "Address:port to listen on")
+	httpOpts          = httpserve.RegisterFlags(flag.CommandLine)
+	debugListen       = flag.String("debug_listen", "", "If set, address:port to serve pprof, runtime metrics, and goroutine dumps on. This must not be publicly reachable.")
 	signer            = flag.String("signer", "", "Note signer to use to sign checkpoints")
 	publishInterval   = flag.Duration("publish_interval", 3*time.Second, "How frequently to publish updated checkpoints")
 	traceFraction     = flag.Float64("trace_fraction", 0, "Fraction of open-telemetry span traces to sample")
@@ -70,6 +73,7 @@ func init() {
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
+	debug.Serve(*debugListen)
 	ctx := context.Background()
 
 	shutdownOTel := initOTel(ctx, *traceFraction)
@@ -95,7 +99,7 @@ func main() {
 		WithCheckpointSigner(s, a...).
 		WithCheckpointInterval(*publishInterval).
 		WithBatching(512, 300*time.Millisecond).
-		WithPushback(10*4096).
+		WithPushbackMaxOutstanding(10*4096).
 		WithAntispam(256<<10, antispam))
 	if err != nil {
 		klog.Exit(err)
@@ -105,20 +109,18 @@ func main() {
 	// This should accept arbitrary bytes POSTed to /add, and return an ascii
 	// decimal representation of the index assigned to the entry.
 	http.HandleFunc("POST /add", func(w http.ResponseWriter, r *http.Request) {
-		b, err := io.ReadAll(r.Body)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		b, ok := httpserve.ReadBody(w, r)
+		if !ok {
 			return
 		}
 
 		idx, err := appender.Add(r.Context(), tessera.NewEntry(b))()
 		if err != nil {
-			if errors.Is(err, tessera.ErrPushback) {
-				w.Header().Add("Retry-After", "1")
-				w.WriteHeader(http.StatusServiceUnavailable)
-				return
+			code := tessera.HTTPStatusCode(err)
+			if code == http.StatusServiceUnavailable {
+				tessera.SetRetryAfter(w.Header(), err)
 			}
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(code)
 			_, _ = w.Write([]byte(err.Error()))
 			return
 		}
@@ -127,10 +129,7 @@ func main() {
 	})
 
 	h2s := &http2.Server{}
-	h1s := &http.Server{
-		Addr:    *listen,
-		Handler: h2c.NewHandler(http.DefaultServeMux, h2s),
-	}
+	h1s := httpserve.NewServer(*listen, h2c.NewHandler(http.DefaultServeMux, h2s), *httpOpts)
 
 	if err := h1s.ListenAndServe(); err != nil {
 		if err := shutdown(ctx); err != nil {
@@ -191,12 +190,14 @@ func storageConfigFromFlags() aws.Config {
 	}
 
 	return aws.Config{
-		Bucket:       *bucket,
-		SDKConfig:    awsConfig,
-		S3Options:    s3Opts,
-		DSN:          c.FormatDSN(),
-		MaxOpenConns: *dbMaxConns,
-		MaxIdleConns: *dbMaxIdle,
+		Bucket:        *bucket,
+		SDKConfig:     awsConfig,
+		S3Options:     s3Opts,
+		DSN:           c.FormatDSN(),
+		MaxOpenConns:  *dbMaxConns,
+		MaxIdleConns:  *dbMaxIdle,
+		AdaptiveConns: *dbAdaptiveMin > 0,
+		MinOpenConns:  *dbAdaptiveMin,
 	}
 }
 
@@ -17,14 +17,14 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/debug"
+	"github.com/transparency-dev/tessera/internal/httpserve"
 	"github.com/transparency-dev/tessera/storage/gcp"
 	gcp_as "github.com/transparency-dev/tessera/storage/gcp/antispam"
 	"golang.org/x/mod/sumdb/note"
@@ -36,9 +36,14 @@ import (
 var (
 	bucket             = flag.String("bucket", "", "Bucket to use for storing log")
 	listen             = flag.String("listen", ":2024", "Address:port to listen on")
-	spanner            = flag.String("spanner", "", "Spanner resource URI ('projects/.../...')")
+	httpOpts           = httpserve.RegisterFlags(flag.CommandLine)
+	debugListen        = flag.String("debug_listen", "", "If set, address:port to serve pprof, runtime metrics, and goroutine dumps on. This must not be publicly reachable.")
+	spanner            = flag.String("spanner", "", "Spanner resource URI ('projects/.../...'). Exactly one of --spanner or --gcs_coordination must be set.")
+	gcsCoordination    = flag.Bool("gcs_coordination", false, "If true, the log's coordination state is kept in GCS rather than Spanner, which is only suitable for a single instance. Exactly one of --spanner or --gcs_coordination must be set.")
+	stateBucket        = flag.String("state_bucket", "", "If set, and --gcs_coordination is, the bucket in which the log's coordination state, including entries awaiting integration, is kept instead of --bucket. Use this if --bucket is publicly readable.")
 	signer             = flag.String("signer", "", "Note signer to use to sign checkpoints")
 	persistentAntispam = flag.Bool("antispam", false, "EXPERIMENTAL: Set to true to enable GCP-based persistent antispam storage")
+	instance           = flag.String("instance", "", "If set, enables running multiple instances against the same log, e.g. a scaled-out Cloud Run service. Must uniquely identify this instance, and requires --spanner.")
 	traceFraction      = flag.Float64("trace_fraction", 0.01, "Fraction of open-telemetry span traces to sample")
 	additionalSigners  = []string{}
 )
@@ -53,6 +58,7 @@ func init() {
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
+	debug.Serve(*debugListen)
 	ctx := context.Background()
 
 	shutdownOTel := initOTel(ctx, *traceFraction)
@@ -62,7 +68,11 @@ func main() {
 
 	// Create our Tessera storage backend:
 	gcpCfg := storageConfigFromFlags()
-	driver, err := gcp.New(ctx, gcpCfg)
+	var gcpOpts []gcp.Option
+	if *instance != "" {
+		gcpOpts = append(gcpOpts, gcp.WithMultiInstance(*instance))
+	}
+	driver, err := gcp.New(ctx, gcpCfg, gcpOpts...)
 	if err != nil {
 		klog.Exitf("Failed to create new GCP storage: %v", err)
 	}
@@ -70,6 +80,9 @@ func main() {
 	var antispam tessera.Antispam
 	// Persistent antispam is currently experimental, so there's no terraform or documentation yet!
 	if *persistentAntispam {
+		if *spanner == "" {
+			klog.Exit("--antispam requires --spanner to be set")
+		}
 		asOpts := gcp_as.AntispamOpts{} // Use defaults
 		antispam, err = gcp_as.NewAntispam(ctx, fmt.Sprintf("%s-antispam", *spanner), asOpts)
 		if err != nil {
@@ -77,11 +90,16 @@ func main() {
 		}
 	}
 
+	// Without Spanner, each batch updates a single GCS object, which GCS limits to around one update per second.
+	batchMaxAge := 300 * time.Millisecond
+	if *gcsCoordination {
+		batchMaxAge = time.Second
+	}
 	appender, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
 		WithCheckpointSigner(s, a...).
 		WithCheckpointInterval(10*time.Second).
-		WithBatching(512, 300*time.Millisecond).
-		WithPushback(10*4096).
+		WithBatching(512, batchMaxAge).
+		WithPushbackMaxOutstanding(10*4096).
 		WithAntispam(256<<10, antispam))
 	if err != nil {
 		klog.Exit(err)
@@ -91,21 +109,19 @@ func main() {
 	// This should accept arbitrary bytes POSTed to /add, and return an ascii
 	// decimal representation of the index assigned to the entry.
 	http.HandleFunc("POST /add", func(w http.ResponseWriter, r *http.Request) {
-		b, err := io.ReadAll(r.Body)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		b, ok := httpserve.ReadBody(w, r)
+		if !ok {
 			return
 		}
 
 		f := appender.Add(r.Context(), tessera.NewEntry(b))
 		idx, err := f()
 		if err != nil {
-			if errors.Is(err, tessera.ErrPushback) {
-				w.Header().Add("Retry-After", "1")
-				w.WriteHeader(http.StatusServiceUnavailable)
-				return
+			code := tessera.HTTPStatusCode(err)
+			if code == http.StatusServiceUnavailable {
+				tessera.SetRetryAfter(w.Header(), err)
 			}
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(code)
 			_, _ = w.Write([]byte(err.Error()))
 			return
 		}
@@ -115,10 +131,7 @@ func main() {
 	})
 
 	h2s := &http2.Server{}
-	h1s := &http.Server{
-		Addr:    *listen,
-		Handler: h2c.NewHandler(http.DefaultServeMux, h2s),
-	}
+	h1s := httpserve.NewServer(*listen, h2c.NewHandler(http.DefaultServeMux, h2s), *httpOpts)
 
 	if err := h1s.ListenAndServe(); err != nil {
 		if err := shutdown(ctx); err != nil {
@@ -134,12 +147,11 @@ func storageConfigFromFlags() gcp.Config {
 	if *bucket == "" {
 		klog.Exit("--bucket must be set")
 	}
-	if *spanner == "" {
-		klog.Exit("--spanner must be set")
-	}
 	return gcp.Config{
-		Bucket:  *bucket,
-		Spanner: *spanner,
+		Bucket:          *bucket,
+		Spanner:         *spanner,
+		GCSCoordination: *gcsCoordination,
+		StateBucket:     *stateBucket,
 	}
 }
 
@@ -18,17 +18,16 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"io/fs"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/transparency-dev/tessera"
-	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/debug"
+	"github.com/transparency-dev/tessera/internal/httpserve"
+	mysql_as "github.com/transparency-dev/tessera/storage/aws/antispam"
 	"github.com/transparency-dev/tessera/storage/mysql"
 	"golang.org/x/mod/sumdb/note"
 	"k8s.io/klog/v2"
@@ -39,10 +38,15 @@ var (
 	dbConnMaxLifetime         = flag.Duration("db_conn_max_lifetime", 3*time.Minute, "")
 	dbMaxOpenConns            = flag.Int("db_max_open_conns", 64, "")
 	dbMaxIdleConns            = flag.Int("db_max_idle_conns", 64, "")
+	dbAdaptiveMinOpenConns    = flag.Int("db_adaptive_min_open_conns", 0, "If non-zero, the maximum number of open connections is adapted to the load between this value and --db_max_open_conns")
 	initSchemaPath            = flag.String("init_schema_path", "", "Location of the schema file if database initialization is needed")
 	listen                    = flag.String("listen", ":2024", "Address:port to listen on")
+	httpOpts                  = httpserve.RegisterFlags(flag.CommandLine)
+	debugListen               = flag.String("debug_listen", "", "If set, address:port to serve pprof, runtime metrics, and goroutine dumps on. This must not be publicly reachable.")
 	privateKeyPath            = flag.String("private_key_path", "", "Location of private key file")
 	publishInterval           = flag.Duration("publish_interval", 3*time.Second, "How frequently to publish updated checkpoints")
+	checkpointCacheMaxAge     = flag.Duration("checkpoint_cache_max_age", 0, "If non-zero, the latest checkpoint and tree size are cached in memory for up to this long")
+	persistentAntispam        = flag.Bool("antispam", false, "EXPERIMENTAL: Set to true to enable persistent antispam, stored in the same database as the log")
 	additionalPrivateKeyPaths = []string{}
 )
 
@@ -56,35 +60,58 @@ func init() {
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
+	debug.Serve(*debugListen)
 	ctx := context.Background()
 
 	db := createDatabaseOrDie(ctx)
 	noteSigner, additionalSigners := createSignersOrDie()
 
 	// Initialise the Tessera MySQL storage
-	driver, err := mysql.New(ctx, db)
+	var opts []mysql.Option
+	if *dbAdaptiveMinOpenConns > 0 {
+		opts = append(opts, mysql.WithAdaptiveConns(*dbAdaptiveMinOpenConns, *dbMaxOpenConns))
+	}
+	if *checkpointCacheMaxAge > 0 {
+		opts = append(opts, mysql.WithCheckpointCache(*checkpointCacheMaxAge))
+	}
+	driver, err := mysql.New(ctx, db, opts...)
 	if err != nil {
 		klog.Exitf("Failed to create new MySQL storage: %v", err)
 	}
 
+	var antispam tessera.Antispam
+	if *persistentAntispam {
+		// The antispam tables live alongside the log's, so they're backed up and restored together.
+		antispam, err = mysql_as.NewAntispamFromDB(ctx, db, mysql_as.AntispamOpts{})
+		if err != nil {
+			klog.Exitf("Failed to create new MySQL antispam storage: %v", err)
+		}
+	}
+
 	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
 		WithCheckpointSigner(noteSigner, additionalSigners...).
 		WithCheckpointInterval(*publishInterval).
-		WithAntispam(256, nil))
+		WithAntispam(256, antispam))
 	if err != nil {
 		klog.Exit(err)
 	}
+	if l, ok := antispam.(tessera.IndexLookup); ok {
+		http.Handle("GET /index/{hash}", tessera.NewIndexHandler(l))
+	}
 	// Set up the handlers for the tlog-tiles GET methods, and a custom handler for HTTP POSTs to /add
-	configureTilesReadAPI(http.DefaultServeMux, reader)
+	configureTilesReadAPI(http.DefaultServeMux, reader, tessera.NewPublicationAwaiter(ctx, reader.ReadCheckpoint, 100*time.Millisecond))
 	http.HandleFunc("POST /add", func(w http.ResponseWriter, r *http.Request) {
-		b, err := io.ReadAll(r.Body)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		b, ok := httpserve.ReadBody(w, r)
+		if !ok {
 			return
 		}
 		idx, err := appender.Add(r.Context(), tessera.NewEntry(b))()
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			code := tessera.HTTPStatusCode(err)
+			if code == http.StatusServiceUnavailable {
+				tessera.SetRetryAfter(w.Header(), err)
+			}
+			w.WriteHeader(code)
 			_, _ = w.Write([]byte(err.Error()))
 			return
 		}
@@ -99,7 +126,7 @@ func main() {
 		"export WRITE_URL=http://localhost%s/ \n"+
 		"export READ_URL=http://localhost%s/ \n", *listen, *listen)
 	// Serve HTTP requests until the process is terminated
-	if err := http.ListenAndServe(*listen, http.DefaultServeMux); err != nil {
+	if err := httpserve.NewServer(*listen, http.DefaultServeMux, *httpOpts).ListenAndServe(); err != nil {
 		if err := shutdown(ctx); err != nil {
 			klog.Exit(err)
 		}
@@ -142,88 +169,11 @@ func createSignerOrDie(s string) note.Signer {
 }
 
 // configureTilesReadAPI adds the API methods from https://c2sp.org/tlog-tiles to the mux,
-// routing the requests to the mysql storage.
-// This method could be moved into the storage API as it's likely this will be
-// the same for any implementation of a personality based on MySQL.
-func configureTilesReadAPI(mux *http.ServeMux, reader tessera.LogReader) {
-	mux.HandleFunc("GET /checkpoint", func(w http.ResponseWriter, r *http.Request) {
-		checkpoint, err := reader.ReadCheckpoint(r.Context())
-		if err != nil {
-			if errors.Is(err, fs.ErrNotExist) {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			klog.Errorf("/checkpoint: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		// Don't cache checkpoints as the endpoint refreshes regularly.
-		// A personality that wanted to _could_ set a small cache time here which was no higher
-		// than the checkpoint publish interval.
-		w.Header().Set("Cache-Control", "no-cache")
-		if _, err := w.Write(checkpoint); err != nil {
-			klog.Errorf("/checkpoint: %v", err)
-			return
-		}
-	})
-
-	mux.HandleFunc("GET /tile/{level}/{index...}", func(w http.ResponseWriter, r *http.Request) {
-		level, index, p, err := layout.ParseTileLevelIndexPartial(r.PathValue("level"), r.PathValue("index"))
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			if _, werr := fmt.Fprintf(w, "Malformed URL: %s", err.Error()); werr != nil {
-				klog.Errorf("/tile/{level}/{index...}: %v", werr)
-			}
-			return
-		}
-		tile, err := reader.ReadTile(r.Context(), level, index, p)
-		if err != nil {
-			if errors.Is(err, fs.ErrNotExist) {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			klog.Errorf("/tile/{level}/{index...}: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Cache-Control", "max-age=31536000, immutable")
-
-		if _, err := w.Write(tile); err != nil {
-			klog.Errorf("/tile/{level}/{index...}: %v", err)
-			return
-		}
-	})
-
-	mux.HandleFunc("GET /tile/entries/{index...}", func(w http.ResponseWriter, r *http.Request) {
-		index, p, err := layout.ParseTileIndexPartial(r.PathValue("index"))
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			if _, werr := fmt.Fprintf(w, "Malformed URL: %s", err.Error()); werr != nil {
-				klog.Errorf("/tile/entries/{index...}: %v", werr)
-			}
-			return
-		}
-
-		entryBundle, err := reader.ReadEntryBundle(r.Context(), index, p)
-		if err != nil {
-			klog.Errorf("/tile/entries/{index...}: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if entryBundle == nil {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-
-		if _, err := w.Write(entryBundle); err != nil {
-			klog.Errorf("/tile/entries/{index...}: %v", err)
-			return
-		}
-	})
+// routing the requests to the mysql storage. Clients may long-poll for new checkpoints.
+func configureTilesReadAPI(mux *http.ServeMux, reader tessera.LogReader, awaiter *tessera.PublicationAwaiter) {
+	h := tessera.NewReadHandler(reader, tessera.WithCheckpointLongPoll(awaiter, 0))
+	mux.Handle("GET /checkpoint", h)
+	mux.Handle("GET /tile/", h)
 }
 
 func initDatabaseSchema(ctx context.Context) {
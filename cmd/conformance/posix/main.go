@@ -20,9 +20,11 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -31,6 +33,9 @@ import (
 	"golang.org/x/mod/sumdb/note"
 
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/bastion"
+	"github.com/transparency-dev/tessera/internal/debug"
+	"github.com/transparency-dev/tessera/internal/httpserve"
 	"github.com/transparency-dev/tessera/storage/posix"
 	badger_as "github.com/transparency-dev/tessera/storage/posix/antispam"
 	"k8s.io/klog/v2"
@@ -39,8 +44,15 @@ import (
 var (
 	storageDir                = flag.String("storage_dir", "", "Root directory to store log data.")
 	listen                    = flag.String("listen", ":2025", "Address:port to listen on")
+	httpOpts                  = httpserve.RegisterFlags(flag.CommandLine)
+	debugListen               = flag.String("debug_listen", "", "If set, address:port to serve pprof, runtime metrics, and goroutine dumps on. This must not be publicly reachable.")
 	privKeyFile               = flag.String("private_key", "", "Location of private key file. If unset, uses the contents of the LOG_PRIVATE_KEY environment variable.")
 	persistentAntispam        = flag.Bool("antispam", false, "EXPERIMENTAL: Set to true to enable Badger-based persistent antispam storage")
+	bastionAddr               = flag.String("bastion_addr", "", "EXPERIMENTAL: If set, the host:port of a https://c2sp.org/https-bastion host to register with in order to serve the read API")
+	bastionKeyFile            = flag.String("bastion_key", "", "Location of a PEM encoded PKCS#8 Ed25519 private key used to authenticate to the bastion")
+	witnessPolicyFile         = flag.String("witness_policy_file", "", "If set, location of a witness policy file, in the Sigsum policy format, which checkpoints must satisfy before being published")
+	witnessTimeout            = flag.Duration("witness_timeout", 5*time.Second, "Maximum time to wait for witnesses to satisfy the policy when publishing a checkpoint")
+	witnessFailOpen           = flag.Bool("witness_fail_open", false, "Set to true to publish checkpoints with whichever cosignatures were collected if the witness policy can't be satisfied")
 	additionalPrivateKeyFiles = []string{}
 )
 
@@ -61,6 +73,7 @@ func addCacheHeaders(value string, fs http.Handler) http.HandlerFunc {
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
+	debug.Serve(*debugListen)
 	ctx := context.Background()
 
 	// Gather the info needed for reading/writing checkpoints
@@ -81,45 +94,60 @@ func main() {
 		}
 	}
 
-	appender, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+	opts := tessera.NewAppendOptions().
 		WithCheckpointSigner(s, a...).
 		WithBatching(256, time.Second).
-		WithAntispam(256, antispam))
+		WithAntispam(256, antispam)
+	if *witnessPolicyFile != "" {
+		opts.WithWitnesses(getWitnessPolicyOrDie(), &tessera.WitnessOptions{Timeout: *witnessTimeout, FailOpen: *witnessFailOpen})
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, opts)
 	if err != nil {
 		klog.Exit(err)
 	}
 
-	// Define a handler for /add that accepts POST requests and adds the POST body to the log
-	http.HandleFunc("POST /add", func(w http.ResponseWriter, r *http.Request) {
-		b, err := io.ReadAll(r.Body)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		idx, err := appender.Add(r.Context(), tessera.NewEntry(b))()
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(err.Error()))
-			return
-		}
-		if _, err := fmt.Fprintf(w, "%d", idx.Index); err != nil {
-			klog.Errorf("/add: %v", err)
-			return
-		}
+	// Define a handler for /add that accepts POST requests and adds the POST body to the log.
+	// JSON requests may also ask for a checkpoint and inclusion proof for the new entry.
+	addHandler, err := tessera.NewAddHandler(appender, tessera.AddHandlerOptions{
+		Reader:  reader,
+		Awaiter: tessera.NewPublicationAwaiter(ctx, reader.ReadCheckpoint, 100*time.Millisecond),
 	})
+	if err != nil {
+		klog.Exitf("Failed to create add handler: %v", err)
+	}
+	http.Handle("POST /add", addHandler)
 	// Proxy all GET requests to the filesystem as a lightweight file server.
 	// This makes it easier to test this implementation from another machine.
 	fs := http.FileServer(http.Dir(*storageDir))
-	http.Handle("GET /checkpoint", addCacheHeaders("no-cache", fs))
-	http.Handle("GET /tile/", addCacheHeaders("max-age=31536000, immutable", fs))
-	http.Handle("GET /entries/", fs)
+	readMux := http.NewServeMux()
+	readMux.Handle("GET /checkpoint", addCacheHeaders("no-cache", fs))
+	readMux.Handle("GET /tile/", addCacheHeaders("max-age=31536000, immutable", fs))
+	readMux.Handle("GET /entries/", fs)
+	http.Handle("GET /", readMux)
+	// Persistent antispam storage also lets clients find an entry's index from its identity hash.
+	if l, ok := antispam.(tessera.IndexLookup); ok {
+		http.Handle("GET /index/{hash}", tessera.NewIndexHandler(l))
+	}
+
+	// Optionally make the read API available to clients of a bastion host too.
+	if *bastionAddr != "" {
+		cfg := bastion.Config{
+			Addr: *bastionAddr,
+			Key:  getBastionKeyOrDie(),
+		}
+		go func() {
+			if err := bastion.Serve(ctx, cfg, readMux); err != nil {
+				klog.Errorf("bastion.Serve: %v", err)
+			}
+		}()
+	}
 
 	// TODO(mhutchinson): Change the listen flag to just a port, or fix up this address formatting
 	klog.Infof("Environment variables useful for accessing this log:\n"+
 		"export WRITE_URL=http://localhost%s/ \n"+
 		"export READ_URL=http://localhost%s/ \n", *listen, *listen)
 	// Run the HTTP server with the single handler and block until this is terminated
-	if err := http.ListenAndServe(*listen, http.DefaultServeMux); err != nil {
+	if err := httpserve.NewServer(*listen, http.DefaultServeMux, *httpOpts).ListenAndServe(); err != nil {
 		if err := shutdown(ctx); err != nil {
 			klog.Exit(err)
 		}
@@ -166,6 +194,28 @@ func getSignerOrDie() note.Signer {
 	return s
 }
 
+// getBastionKeyOrDie reads the Ed25519 key used to authenticate to the bastion from the
+// file specified by the --bastion_key flag.
+func getBastionKeyOrDie() ed25519.PrivateKey {
+	b, err := os.ReadFile(*bastionKeyFile)
+	if err != nil {
+		klog.Exitf("Unable to read bastion key: %v", err)
+	}
+	p, _ := pem.Decode(b)
+	if p == nil {
+		klog.Exitf("No PEM block found in bastion key file %q", *bastionKeyFile)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(p.Bytes)
+	if err != nil {
+		klog.Exitf("Failed to parse bastion key: %v", err)
+	}
+	ek, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		klog.Exitf("Bastion key must be an Ed25519 key, got %T", k)
+	}
+	return ek
+}
+
 func getKeyFile(path string) (string, error) {
 	k, err := os.ReadFile(path)
 	if err != nil {
@@ -173,3 +223,16 @@ func getKeyFile(path string) (string, error) {
 	}
 	return string(k), nil
 }
+
+// getWitnessPolicyOrDie returns the witness policy read from the file specified by the --witness_policy_file flag.
+func getWitnessPolicyOrDie() tessera.WitnessGroup {
+	p, err := os.ReadFile(*witnessPolicyFile)
+	if err != nil {
+		klog.Exitf("Failed to read witness policy file %q: %v", *witnessPolicyFile, err)
+	}
+	wg, err := tessera.NewWitnessGroupFromPolicy(p)
+	if err != nil {
+		klog.Exitf("Failed to parse witness policy: %v", err)
+	}
+	return wg
+}
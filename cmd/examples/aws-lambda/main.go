@@ -0,0 +1,206 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// aws-lambda is an example personality which runs the write path of a Tessera log on AWS Lambda,
+// behind an API Gateway HTTP API, so that a low-traffic log can be run without any always-on servers.
+//
+// Reads are served directly from the S3 bucket, e.g. via CloudFront, and never touch the function.
+// Invocations which aren't from API Gateway, e.g. from an EventBridge schedule, wait for everything
+// sequenced so far to be integrated and committed to by a published checkpoint.
+//
+// The function is configured via environment variables, described in the README.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/aws"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// publishMargin is how long before an invocation's deadline waiting for publication is abandoned,
+	// so that there's time to respond.
+	publishMargin = time.Second
+	// publishPollPeriod is how often the checkpoint is checked while waiting for publication.
+	publishPollPeriod = 250 * time.Millisecond
+)
+
+func main() {
+	klog.InitFlags(nil)
+	ctx := context.Background()
+
+	rt, err := newRuntime()
+	if err != nil {
+		klog.Exit(err)
+	}
+	p, err := newPersonality(ctx)
+	if err != nil {
+		klog.Errorf("Failed to initialise: %v", err)
+		if err := rt.initError(ctx, err); err != nil {
+			klog.Errorf("Failed to report initialisation error: %v", err)
+		}
+		os.Exit(1)
+	}
+	if err := rt.serve(ctx, p.handle); err != nil {
+		klog.Exit(err)
+	}
+}
+
+// personality holds the state which lives for as long as the Lambda execution environment.
+//
+// Lambda freezes the environment between invocations, so the appender's background work, i.e.
+// integrating sequenced entries and publishing checkpoints, only makes progress while an invocation
+// is being handled.
+type personality struct {
+	reader       tessera.LogReader
+	addHandler   http.Handler
+	awaitPublish bool
+}
+
+func newPersonality(ctx context.Context) (*personality, error) {
+	bucket, dsn, key := os.Getenv("LOG_BUCKET"), os.Getenv("LOG_DB_DSN"), os.Getenv("LOG_PRIVATE_KEY")
+	if bucket == "" || dsn == "" || key == "" {
+		return nil, errors.New("LOG_BUCKET, LOG_DB_DSN, and LOG_PRIVATE_KEY must all be set")
+	}
+	s, err := note.NewSigner(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOG_PRIVATE_KEY: %v", err)
+	}
+	publishInterval, err := durationFromEnv("LOG_PUBLISH_INTERVAL", 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	awaitPublish := false
+	if v := os.Getenv("LOG_AWAIT_PUBLICATION"); v != "" {
+		if awaitPublish, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid LOG_AWAIT_PUBLICATION: %v", err)
+		}
+	}
+
+	driver, err := aws.New(ctx, aws.Config{
+		Bucket:       bucket,
+		BucketPrefix: os.Getenv("LOG_BUCKET_PREFIX"),
+		DSN:          dsn,
+		// Each execution environment handles one invocation at a time, so needs very few connections,
+		// but there may be many environments sharing the database.
+		MaxOpenConns: 2,
+		MaxIdleConns: 2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS storage: %v", err)
+	}
+	// An execution environment only ever has one request in flight, so entries can't be batched
+	// together in memory, and waiting for more to arrive would only add latency. Batches of one are
+	// sequenced as soon as they're added, and so are durable before the invocation returns.
+	a, _, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(publishInterval).
+		WithBatching(1, publishPollPeriod))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create appender: %v", err)
+	}
+	h, err := tessera.NewAddHandler(a, tessera.AddHandlerOptions{
+		Reader:  r,
+		Awaiter: tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, publishPollPeriod),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create add handler: %v", err)
+	}
+	return &personality{reader: r, addHandler: h, awaitPublish: awaitPublish}, nil
+}
+
+// handle dispatches an invocation according to its source.
+func (p *personality) handle(ctx context.Context, payload []byte) (any, error) {
+	var e apiGatewayRequest
+	if err := json.Unmarshal(payload, &e); err == nil && e.RequestContext.HTTP.Method != "" {
+		resp, err := serveHTTP(ctx, p.addHandler, e)
+		if err != nil {
+			return nil, err
+		}
+		// Without a schedule to drive integration, it would otherwise only happen while later
+		// requests are being handled.
+		if p.awaitPublish && resp.StatusCode == http.StatusOK {
+			if _, err := p.publish(ctx); err != nil {
+				klog.Warningf("Entry added, but not yet published: %v", err)
+			}
+		}
+		return resp, nil
+	}
+	size, err := p.publish(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]uint64{"size": size}, nil
+}
+
+// publish waits until every entry sequenced so far has been integrated and committed to by a published
+// checkpoint, or until shortly before the invocation's deadline, and returns the size of the checkpoint.
+func (p *personality) publish(ctx context.Context) (uint64, error) {
+	if d, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, d.Add(-publishMargin))
+		defer cancel()
+	}
+	next, err := p.reader.NextIndex(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read next index: %v", err)
+	}
+	for {
+		size, err := p.checkpointSize(ctx)
+		if err != nil {
+			klog.Warningf("Failed to read checkpoint: %v", err)
+		}
+		if err == nil && size >= next {
+			return size, nil
+		}
+		select {
+		case <-ctx.Done():
+			return size, fmt.Errorf("checkpoint size %d has not reached %d: %w", size, next, ctx.Err())
+		case <-time.After(publishPollPeriod):
+		}
+	}
+}
+
+// checkpointSize returns the size of the log's published checkpoint, or zero if there isn't one yet.
+func (p *personality) checkpointSize(ctx context.Context) (uint64, error) {
+	ts, err := tessera.ReadTreeState(ctx, p.reader)
+	if errors.Is(err, tessera.ErrNotFound) {
+		return 0, nil
+	}
+	return ts.Size, err
+}
+
+// durationFromEnv parses the named environment variable as a duration, returning def if it's unset.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", name, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// This file implements just enough of the Lambda custom runtime API to receive invocations and return
+// their results, so that the example doesn't need any dependencies beyond those Tessera already has.
+// See https://docs.aws.amazon.com/lambda/latest/dg/runtimes-api.html.
+
+const runtimeAPIPath = "/2018-06-01/runtime"
+
+// handlerFunc handles a single invocation, returning a value to be serialised as JSON in the response.
+type handlerFunc func(ctx context.Context, payload []byte) (any, error)
+
+// runtime talks to the Lambda runtime API.
+type runtime struct {
+	base string
+	c    *http.Client
+}
+
+// newRuntime returns a runtime for the API advertised by the Lambda environment.
+func newRuntime() (*runtime, error) {
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		return nil, fmt.Errorf("AWS_LAMBDA_RUNTIME_API is not set; this binary must be run as a Lambda function")
+	}
+	// Requests for the next invocation block until there is one, so there's no client timeout.
+	return &runtime{base: "http://" + api + runtimeAPIPath, c: &http.Client{}}, nil
+}
+
+// serve handles invocations with h, one at a time, forever.
+func (r *runtime) serve(ctx context.Context, h handlerFunc) error {
+	for {
+		id, deadline, payload, err := r.next(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch next invocation: %v", err)
+		}
+		ictx, cancel := context.WithDeadline(ctx, deadline)
+		resp, err := h(ictx, payload)
+		cancel()
+		if err != nil {
+			klog.Warningf("Invocation %s failed: %v", id, err)
+			if err := r.post(ctx, "/invocation/"+id+"/error", map[string]string{"errorMessage": err.Error(), "errorType": "HandlerError"}); err != nil {
+				return fmt.Errorf("failed to report error for invocation %s: %v", id, err)
+			}
+			continue
+		}
+		if err := r.post(ctx, "/invocation/"+id+"/response", resp); err != nil {
+			return fmt.Errorf("failed to send response for invocation %s: %v", id, err)
+		}
+	}
+}
+
+// initError reports that the function failed to initialise. The environment is discarded afterwards.
+func (r *runtime) initError(ctx context.Context, err error) error {
+	return r.post(ctx, "/init/error", map[string]string{"errorMessage": err.Error(), "errorType": "InitError"})
+}
+
+// next blocks until there is an invocation to handle, and returns its ID, deadline, and payload.
+func (r *runtime) next(ctx context.Context) (string, time.Time, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/invocation/next", nil)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	resp, err := r.c.Do(req)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, nil, fmt.Errorf("%s: %s", resp.Status, payload)
+	}
+	deadlineMs, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64)
+	if err != nil {
+		return "", time.Time{}, nil, fmt.Errorf("invalid deadline: %v", err)
+	}
+	// Propagate the X-Ray trace ID in the way the AWS SDKs expect.
+	if err := os.Setenv("_X_AMZN_TRACE_ID", resp.Header.Get("Lambda-Runtime-Trace-Id")); err != nil {
+		klog.Warningf("Failed to set trace ID: %v", err)
+	}
+	return resp.Header.Get("Lambda-Runtime-Aws-Request-Id"), time.UnixMilli(deadlineMs), payload, nil
+}
+
+func (r *runtime) post(ctx context.Context, path string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := r.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusAccepted {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, b)
+	}
+	return nil
+}
+
+// apiGatewayRequest is the subset of an API Gateway HTTP API (payload format 2.0) event used here.
+type apiGatewayRequest struct {
+	RawPath         string            `json:"rawPath"`
+	RawQueryString  string            `json:"rawQueryString"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	RequestContext  struct {
+		HTTP struct {
+			Method string `json:"method"`
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+// apiGatewayResponse is the response to an API Gateway HTTP API event.
+type apiGatewayResponse struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// serveHTTP passes the request described by an API Gateway event to h, and returns its response.
+func serveHTTP(ctx context.Context, h http.Handler, e apiGatewayRequest) (apiGatewayResponse, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(e.Body); err != nil {
+			return apiGatewayResponse{}, fmt.Errorf("invalid base64 body: %v", err)
+		}
+	}
+	u := e.RawPath
+	if e.RawQueryString != "" {
+		u += "?" + e.RawQueryString
+	}
+	req, err := http.NewRequestWithContext(ctx, e.RequestContext.HTTP.Method, u, bytes.NewReader(body))
+	if err != nil {
+		return apiGatewayResponse{}, err
+	}
+	for k, v := range e.Headers {
+		// API Gateway joins repeated headers with commas.
+		for _, vv := range strings.Split(v, ",") {
+			req.Header.Add(k, strings.TrimSpace(vv))
+		}
+	}
+	req.ContentLength = int64(len(body))
+
+	w := &responseRecorder{header: http.Header{}}
+	h.ServeHTTP(w, req)
+	resp := apiGatewayResponse{
+		StatusCode:      w.status,
+		Headers:         map[string]string{},
+		Body:            base64.StdEncoding.EncodeToString(w.body.Bytes()),
+		IsBase64Encoded: true,
+	}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	for k, v := range w.header {
+		resp.Headers[k] = strings.Join(v, ",")
+	}
+	return resp, nil
+}
+
+// responseRecorder is a minimal http.ResponseWriter which buffers the response in memory.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// monitor is an example of a simple transparency monitor for tlog-tiles logs.
+// It tails a log, verifying that each new checkpoint is consistent with the
+// previous one and that the entries it serves are those committed to by the
+// checkpoint, and checks every entry against a matcher function.
+// Its position in the log is persisted so that it can pick up where it left off
+// if it's restarted.
+//
+// This is intended to be a template for building monitors with this library;
+// the matchEntry function is the place to start customising.
+// See the README in this package for more detailed usage instructions.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	logURL       = flag.String("log_url", "", "Root URL of the log to monitor, e.g. https://log.server/and/path/ or file:///path/to/log/")
+	logPubKey    = flag.String("log_public_key", os.Getenv("LOG_PUBLIC_KEY"), "Public key for the log. If unset, uses the contents of the LOG_PUBLIC_KEY environment variable.")
+	stateFile    = flag.String("state_file", "", "File used to persist the monitor's position in the log.")
+	match        = flag.String("match", "", "Regular expression which entries are matched against.")
+	pollInterval = flag.Duration("poll_interval", 10*time.Second, "How often to check the log for a new checkpoint.")
+)
+
+// fetcher is the set of functions the monitor needs in order to read the log.
+type fetcher interface {
+	ReadCheckpoint(ctx context.Context) ([]byte, error)
+	ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error)
+	ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error)
+}
+
+// state is the monitor's position in the log, which is persisted between runs.
+type state struct {
+	// Checkpoint is the latest checkpoint that the monitor has verified as consistent.
+	Checkpoint []byte
+	// Next is the index of the next entry to be checked against the matcher.
+	Next uint64
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	if *stateFile == "" {
+		klog.Exit("--state_file must be set")
+	}
+	v, err := note.NewVerifier(*logPubKey)
+	if err != nil {
+		klog.Exitf("Failed to create verifier: %v", err)
+	}
+	matchEntry := newMatcherOrDie(*match)
+	f := newFetcherOrDie(*logURL)
+
+	st, err := readState(*stateFile)
+	if err != nil {
+		klog.Exitf("Failed to read state: %v", err)
+	}
+	// The tracker will verify that every checkpoint it sees is consistent with the previous one,
+	// starting with the one we saw last time we ran, if there was one.
+	tracker, err := client.NewLogStateTracker(ctx, f.ReadTile, st.Checkpoint, v, v.Name(), client.UnilateralConsensus(f.ReadCheckpoint))
+	if err != nil {
+		klog.Exitf("Failed to create LogStateTracker: %v", err)
+	}
+
+	// checked is the checkpoint whose entries have all been checked.
+	checked := log.Checkpoint{Hash: rfc6962.DefaultHasher.EmptyRoot()}
+	if len(st.Checkpoint) > 0 {
+		checked = tracker.Latest()
+	}
+
+	t := time.NewTicker(*pollInterval)
+	defer t.Stop()
+	for {
+		_, _, cpRaw, err := tracker.Update(ctx)
+		if err != nil {
+			if e := (client.ErrInconsistency{}); errors.As(err, &e) {
+				// This is the important case for a monitor: the log has presented a split view!
+				klog.Exitf("Log is inconsistent!\nLast good checkpoint:\n%s\nInconsistent checkpoint:\n%s\nError: %v", e.SmallerRaw, e.LargerRaw, e)
+			}
+			klog.Warningf("Failed to update checkpoint: %v", err)
+		} else if latest := tracker.Latest(); latest.Size > checked.Size {
+			klog.V(1).Infof("Checking entries [%d, %d)", checked.Size, latest.Size)
+			if err := checkEntries(ctx, f, checked, latest, matchEntry); err != nil {
+				klog.Warningf("Failed to check entries: %v", err)
+			} else {
+				checked = latest
+				st = state{Checkpoint: cpRaw, Next: latest.Size}
+				if err := writeState(*stateFile, st); err != nil {
+					klog.Exitf("Failed to persist state: %v", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// matchEntryFunc is the signature of a function which decides whether an entry in the log is interesting.
+//
+// This is the place to start when building a real monitor, e.g. parsing the entry and checking
+// whether it refers to a package, domain, or key you care about.
+type matchEntryFunc func(index uint64, data []byte) bool
+
+// newMatcherOrDie returns a matchEntryFunc which matches entries against the provided regular expression.
+func newMatcherOrDie(expr string) matchEntryFunc {
+	if expr == "" {
+		klog.Exit("--match must be set")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		klog.Exitf("Invalid --match expression: %v", err)
+	}
+	return func(_ uint64, data []byte) bool {
+		return re.Match(data)
+	}
+}
+
+// checkEntries checks the entries added to the log between the verified checkpoints from and to against
+// the matcher.
+//
+// The entries are only trusted once they have been shown to be those committed to by to: the hashes of
+// the entries are appended to the compact range of the tree at from, and the resulting root hash must
+// match to's. Otherwise, a log could present a consistent tree while serving any entries it liked.
+func checkEntries(ctx context.Context, f fetcher, from, to log.Checkpoint, matchEntry matchEntryFunc) error {
+	rf := &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	hashes, err := client.FetchRangeNodes(ctx, from.Size, f.ReadTile)
+	if err != nil {
+		return fmt.Errorf("failed to fetch range nodes for size %d: %v", from.Size, err)
+	}
+	r, err := rf.NewRange(0, from.Size, hashes)
+	if err != nil {
+		return fmt.Errorf("failed to create range: %v", err)
+	}
+	if err := checkRoot(r, from); err != nil {
+		return err
+	}
+
+	type match struct {
+		idx  uint64
+		data []byte
+	}
+	var matches []match
+	for ri := range layout.Range(from.Size, to.Size-from.Size, to.Size) {
+		bundle, err := client.GetEntryBundle(ctx, f.ReadEntryBundle, ri.Index, to.Size)
+		if err != nil {
+			return err
+		}
+		for i := ri.First; i < ri.First+ri.N; i++ {
+			if int(i) >= len(bundle.Entries) {
+				return fmt.Errorf("entry bundle %d has only %d entries", ri.Index, len(bundle.Entries))
+			}
+			if err := r.Append(rfc6962.DefaultHasher.HashLeaf(bundle.Entries[i]), nil); err != nil {
+				return fmt.Errorf("failed to append to range: %v", err)
+			}
+			idx := ri.Index*layout.EntryBundleWidth + uint64(i)
+			if matchEntry(idx, bundle.Entries[i]) {
+				matches = append(matches, match{idx: idx, data: bundle.Entries[i]})
+			}
+		}
+	}
+	if err := checkRoot(r, to); err != nil {
+		return fmt.Errorf("entries don't match checkpoint: %v", err)
+	}
+	for _, m := range matches {
+		// A real monitor would probably want to alert someone here.
+		klog.Infof("Matched entry %d: %q", m.idx, m.data)
+	}
+	return nil
+}
+
+// checkRoot returns an error unless the root hash of the compact range r, which must start at zero,
+// matches that of the checkpoint cp of the same size.
+func checkRoot(r *compact.Range, cp log.Checkpoint) error {
+	root := rfc6962.DefaultHasher.EmptyRoot()
+	if r.End() > 0 {
+		var err error
+		if root, err = r.GetRootHash(nil); err != nil {
+			return fmt.Errorf("failed to calculate root hash: %v", err)
+		}
+	}
+	if r.End() != cp.Size || !bytes.Equal(root, cp.Hash) {
+		return fmt.Errorf("tree of size %d has root hash %x, checkpoint of size %d has %x", r.End(), root, cp.Size, cp.Hash)
+	}
+	return nil
+}
+
+// newFetcherOrDie creates a fetcher for the log at the given root URL.
+func newFetcherOrDie(s string) fetcher {
+	// url must reference a directory, by definition
+	if !strings.HasSuffix(s, "/") {
+		s += "/"
+	}
+	root, err := url.Parse(s)
+	if err != nil {
+		klog.Exitf("Invalid --log_url: %v", err)
+	}
+	switch root.Scheme {
+	case "http", "https":
+		f, err := client.NewHTTPFetcher(root, nil)
+		if err != nil {
+			klog.Exitf("NewHTTPFetcher: %v", err)
+		}
+		return f
+	case "file":
+		return client.FileFetcher{Root: root.Path}
+	}
+	klog.Exitf("Unknown scheme on --log_url: %q", root.Scheme)
+	return nil
+}
+
+// readState reads the monitor's persisted state from path, if it exists.
+func readState(path string) (state, error) {
+	var st state
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			klog.Infof("No state found at %q, starting from the beginning of the log", path)
+			return st, nil
+		}
+		return st, err
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return st, fmt.Errorf("failed to unmarshal state: %v", err)
+	}
+	return st, nil
+}
+
+// writeState atomically persists the monitor's state to path.
+func writeState(path string, st state) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
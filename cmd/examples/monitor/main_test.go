@@ -0,0 +1,110 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+// addEntries adds n entries to the log, and returns its checkpoint once they've all been published.
+func addEntries(t *testing.T, tl *testonly.TestLog, first, n int) log.Checkpoint {
+	t.Helper()
+	ctx := t.Context()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 10*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, n)
+	for i := range n {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", first+i))))
+	}
+	var raw []byte
+	for _, f := range futures {
+		var err error
+		if _, raw, err = awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+	cp, _, _, err := client.OpenCheckpoint(raw, tl.SigVerifier.Name(), tl.SigVerifier)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	return *cp
+}
+
+func TestCheckEntries(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 10*time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	f := client.FileFetcher{Root: tl.Root}
+	matched := 0
+	matchEntry := func(idx uint64, data []byte) bool {
+		if string(data) != fmt.Sprintf("entry %d", idx) {
+			t.Errorf("Entry %d has data %q", idx, data)
+		}
+		matched++
+		return true
+	}
+
+	empty := log.Checkpoint{Hash: rfc6962.DefaultHasher.EmptyRoot()}
+	first := addEntries(t, tl, 0, 300)
+	if err := checkEntries(ctx, f, empty, first, matchEntry); err != nil {
+		t.Fatalf("checkEntries(0, %d): %v", first.Size, err)
+	}
+	second := addEntries(t, tl, int(first.Size), 10)
+	if err := checkEntries(ctx, f, first, second, matchEntry); err != nil {
+		t.Fatalf("checkEntries(%d, %d): %v", first.Size, second.Size, err)
+	}
+	if got, want := matched, int(second.Size); got != want {
+		t.Errorf("Matched %d entries, want %d", got, want)
+	}
+
+	// Replace one of the entries in the partial bundle at the end of the log, as a misbehaving log might.
+	p := layout.PartialTileSize(0, 1, second.Size)
+	path := filepath.Join(tl.Root, layout.EntriesPath(1, p))
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	b := api.EntryBundle{}
+	if err := b.UnmarshalText(raw); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	b.Entries[len(b.Entries)-1] = fmt.Appendf(nil, "entry %d", second.Size+1)
+	if raw, err = b.MarshalText(); err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := checkEntries(ctx, f, first, second, func(uint64, []byte) bool { return false }); err == nil {
+		t.Error("checkEntries with tampered bundle: want error")
+	}
+}
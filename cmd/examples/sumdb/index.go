@@ -0,0 +1,126 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/internal/stream"
+	"k8s.io/klog/v2"
+)
+
+// index maps the hash of each record's key to the index of the record in the log.
+//
+// It's kept up to date by following the log, so it's rebuilt from the log's contents on startup. Keying on
+// the hash, rather than the key itself, bounds the memory used per record regardless of the length of keys.
+type index struct {
+	mu sync.RWMutex
+	m  map[[sha256.Size]byte]uint64
+
+	processed atomic.Uint64
+}
+
+func newIndex() *index {
+	return &index{m: make(map[[sha256.Size]byte]uint64)}
+}
+
+// lookup returns the index of the record with the given key, if it's known.
+func (x *index) lookup(key string) (uint64, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	i, ok := x.m[sha256.Sum256([]byte(key))]
+	return i, ok
+}
+
+// put records that the record with the given key is at index i, unless an earlier record already has
+// that key: as with the Go checksum database, the first record for a key is the one which counts.
+func (x *index) put(key string, i uint64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	h := sha256.Sum256([]byte(key))
+	if prev, ok := x.m[h]; !ok || i < prev {
+		x.m[h] = i
+	}
+}
+
+// Name implements tessera.Follower.
+func (x *index) Name() string {
+	return "sumdb index"
+}
+
+// EntriesProcessed implements tessera.Follower.
+func (x *index) EntriesProcessed(_ context.Context) (uint64, error) {
+	return x.processed.Load(), nil
+}
+
+// Follow implements tessera.Follower, indexing integrated entries until ctx is done.
+func (x *index) Follow(ctx context.Context, lr tessera.LogReader) {
+	for {
+		if err := x.follow(ctx, lr); err != nil && ctx.Err() == nil {
+			klog.Warningf("Index: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// follow indexes entries from where it last left off, returning only on error.
+func (x *index) follow(ctx context.Context, lr tessera.LogReader) error {
+	next, stop := lr.StreamEntries(ctx, x.processed.Load())
+	defer stop()
+	r := stream.NewEntryStreamReader(next, func(b []byte) ([][]byte, error) {
+		eb := &api.EntryBundle{}
+		if err := eb.UnmarshalText(b); err != nil {
+			return nil, fmt.Errorf("failed to parse entry bundle: %v", err)
+		}
+		return eb.Entries, nil
+	})
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		size, err := lr.IntegratedSize(ctx)
+		if err != nil {
+			return fmt.Errorf("IntegratedSize: %v", err)
+		}
+		// Only read entries which have been integrated, so that reading never blocks.
+		for i := x.processed.Load(); i < size; i++ {
+			idx, d, err := r.Next()
+			if err != nil {
+				return fmt.Errorf("failed to read entry %d: %v", i, err)
+			}
+			if key, err := recordKey(d); err != nil {
+				klog.Warningf("Entry %d isn't a valid record: %v", idx, err)
+			} else {
+				x.put(key, idx)
+			}
+			x.processed.Store(idx + 1)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
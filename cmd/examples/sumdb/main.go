@@ -0,0 +1,244 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sumdb is an example personality which serves a key/value transparency log in the style of the
+// Go checksum database (https://go.dev/design/25530-sumdb), on top of Tessera's POSIX storage.
+// See the README in this package for more detailed usage instructions.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	listen      = flag.String("listen", ":2025", "Address:port to listen on")
+	storageDir  = flag.String("storage_dir", "", "Root directory to store log data.")
+	privKeyFile = flag.String("private_key", "", "Location of private key file.")
+)
+
+const (
+	// maxRecordSize bounds the size of records which may be added, go.sum records are much smaller than this.
+	maxRecordSize = 1 << 14
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	b, err := os.ReadFile(*privKeyFile)
+	if err != nil {
+		klog.Exitf("Failed to read private key: %v", err)
+	}
+	s, err := note.NewSigner(strings.TrimSpace(string(b)))
+	if err != nil {
+		klog.Exitf("Invalid private key: %v", err)
+	}
+	driver, err := posix.New(ctx, *storageDir)
+	if err != nil {
+		klog.Exitf("Failed to construct storage: %v", err)
+	}
+	idx := newIndex()
+	appender, shutdown, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(256, 100*time.Millisecond).
+		WithFollower(idx))
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	srv := &server{
+		appender: appender,
+		reader:   r,
+		awaiter:  tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 100*time.Millisecond),
+		index:    idx,
+		pending:  make(map[string]bool),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /add", srv.add)
+	mux.HandleFunc("GET /lookup/{key...}", srv.lookup)
+	mux.HandleFunc("GET /latest", srv.latest)
+	// Clients verify lookups using the log's tiles, which are served via the standard tlog-tiles API.
+	mux.Handle("GET /", tessera.NewReadHandler(r))
+
+	h := &http.Server{Addr: *listen, Handler: mux}
+	klog.Infof("Listening on %s", *listen)
+	if err := h.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.Errorf("ListenAndServe: %v", err)
+	}
+	if err := shutdown(ctx); err != nil {
+		klog.Exitf("Failed to shut down cleanly: %v", err)
+	}
+}
+
+// recordKey returns the key of a record, which is made up of one or more go.sum lines for the same
+// module version, e.g.
+//
+//	golang.org/x/text v0.3.0 h1:g61tztE5qeGQ89tm6NTjjM9VPIm088od1l6aSorWRWg=
+//	golang.org/x/text v0.3.0/go.mod h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=
+//
+// The key of this record is "golang.org/x/text@v0.3.0".
+func recordKey(record []byte) (string, error) {
+	if len(record) == 0 || record[len(record)-1] != '\n' {
+		return "", errors.New("record must be non-empty and end with a newline")
+	}
+	var key string
+	for _, line := range strings.Split(strings.TrimSuffix(string(record), "\n"), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 3 {
+			return "", fmt.Errorf("invalid go.sum line %q", line)
+		}
+		k := f[0] + "@" + strings.TrimSuffix(f[1], "/go.mod")
+		if key != "" && k != key {
+			return "", fmt.Errorf("record contains lines for both %s and %s", key, k)
+		}
+		key = k
+	}
+	path, version, _ := strings.Cut(key, "@")
+	if err := module.Check(path, version); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+type server struct {
+	appender *tessera.Appender
+	reader   tessera.LogReader
+	awaiter  *tessera.PublicationAwaiter
+	index    *index
+
+	// mu guards pending, the keys of records which are being added but haven't yet been published.
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+// add handles requests to add a record, responding with its index once a checkpoint committing to it
+// has been published. Records whose key is already present in the log are rejected.
+func (s *server) add(w http.ResponseWriter, r *http.Request) {
+	record, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRecordSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	key, err := recordKey(record)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.index.lookup(key); ok || s.pending[key] {
+		s.mu.Unlock()
+		http.Error(w, fmt.Sprintf("%s is already present", key), http.StatusConflict)
+		return
+	}
+	s.pending[key] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.mu.Unlock()
+	}()
+
+	i, _, err := s.awaiter.Await(r.Context(), s.appender.Add(r.Context(), tessera.NewEntry(record)))
+	if err != nil {
+		http.Error(w, err.Error(), tessera.HTTPStatusCode(err))
+		return
+	}
+	// Make the record available for lookup straight away, rather than waiting for the index to catch up.
+	s.index.put(key, i.Index)
+	_, _ = fmt.Fprintf(w, "%d\n", i.Index)
+}
+
+// lookup handles requests for /lookup/<module>@<version>, responding in the same format as the Go
+// checksum database: the index of the record, the record itself, and a checkpoint which commits to it.
+func (s *server) lookup(w http.ResponseWriter, r *http.Request) {
+	escPath, escVersion, ok := strings.Cut(r.PathValue("key"), "@")
+	if !ok {
+		http.Error(w, "key must be of the form <module>@<version>", http.StatusBadRequest)
+		return
+	}
+	path, err := module.UnescapePath(escPath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	version, err := module.UnescapeVersion(escVersion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := path + "@" + version
+	i, ok := s.index.lookup(key)
+	if !ok {
+		http.Error(w, fmt.Sprintf("%s not found", key), http.StatusNotFound)
+		return
+	}
+
+	ts, err := tessera.ReadTreeState(r.Context(), s.reader)
+	if err != nil {
+		http.Error(w, err.Error(), tessera.HTTPStatusCode(err))
+		return
+	}
+	if i >= ts.Size {
+		// The record has been integrated, but not yet published.
+		http.Error(w, fmt.Sprintf("%s not found", key), http.StatusNotFound)
+		return
+	}
+	bundleIdx := i / layout.EntryBundleWidth
+	raw, err := s.reader.ReadEntryBundle(r.Context(), bundleIdx, layout.PartialTileSize(0, bundleIdx, ts.Size))
+	if err != nil {
+		http.Error(w, err.Error(), tessera.HTTPStatusCode(err))
+		return
+	}
+	eb := api.EntryBundle{}
+	if err := eb.UnmarshalText(raw); err != nil || uint64(len(eb.Entries)) <= i%layout.EntryBundleWidth {
+		http.Error(w, fmt.Sprintf("invalid entry bundle %d", bundleIdx), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprintf(w, "%d\n%s\n%s", i, eb.Entries[i%layout.EntryBundleWidth], ts.Checkpoint)
+}
+
+// latest handles requests for the latest checkpoint, which is the log's equivalent of the Go checksum
+// database's signed tree head.
+func (s *server) latest(w http.ResponseWriter, r *http.Request) {
+	cp, err := s.reader.ReadCheckpoint(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), tessera.HTTPStatusCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(cp)
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// export is a command-line tool for exporting the entries of a tlog-tiles based log as
+// newline delimited JSON or Parquet, or into a BigQuery table.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"net/url"
+	"os"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/export"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageURL = flag.String("storage_url", "", "Base tlog-tiles URL")
+	N          = flag.Uint("N", 4, "The number of entry bundles to fetch concurrently")
+	origin     = flag.String("origin", "", "Origin of the log to export, if unset, will use the name of the provided public key")
+	pubKey     = flag.String("public_key", "", "Path to a file containing the log's public key")
+	from       = flag.Uint64("from", 0, "Index of the first entry to export")
+	to         = flag.Uint64("to", 0, "Index after the last entry to export, if unset, will use the size of the log's current checkpoint")
+	output     = flag.String("output", "", "File to write the exported entries to, if unset, they're written to stdout")
+	format     = flag.String("format", "jsonl", "Format to export entries in: jsonl, parquet, or bigquery")
+	bqTable    = flag.String("bigquery_table", "", "BigQuery table to insert entries into, as project.dataset.table, when --format=bigquery")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+	switch *format {
+	case "jsonl", "parquet":
+	case "bigquery":
+		if *bqTable == "" {
+			klog.Exit("Must provide the --bigquery_table flag with --format=bigquery")
+		}
+	default:
+		klog.Exitf("Unknown --format %q", *format)
+	}
+	logURL, err := url.Parse(*storageURL)
+	if err != nil {
+		klog.Exitf("Invalid --storage_url %q: %v", *storageURL, err)
+	}
+	src, err := client.NewHTTPFetcher(logURL, nil)
+	if err != nil {
+		klog.Exitf("Failed to create HTTP fetcher: %v", err)
+	}
+	if *pubKey == "" {
+		klog.Exit("Must provide the --public_key flag")
+	}
+	b, err := os.ReadFile(*pubKey)
+	if err != nil {
+		klog.Exitf("Failed to read verifier from %q: %v", *pubKey, err)
+	}
+	v, err := f_note.NewVerifier(string(b))
+	if err != nil {
+		klog.Exitf("Invalid verifier in %q: %v", *pubKey, err)
+	}
+	if *origin == "" {
+		*origin = v.Name()
+	}
+	cp, _, _, err := client.FetchCheckpoint(ctx, src.ReadCheckpoint, v, *origin)
+	if err != nil {
+		klog.Exitf("Failed to fetch checkpoint: %v", err)
+	}
+	if *to == 0 {
+		*to = cp.Size
+	}
+
+	opts := export.Options{From: *from, To: *to, Parallelism: *N}
+	klog.Infof("Exporting entries [%d, %d) from log of size %d", *from, *to, cp.Size)
+	if *format == "bigquery" {
+		w, err := export.NewBigQueryWriter(ctx, *bqTable)
+		if err != nil {
+			klog.Exitf("Failed to create BigQuery writer: %v", err)
+		}
+		if err := export.Export(ctx, src.ReadEntryBundle, cp.Size, w, opts); err != nil {
+			klog.Exitf("Export failed: %v", err)
+		}
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			klog.Exitf("Failed to create output file: %v", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				klog.Errorf("Failed to close output file: %v", err)
+			}
+		}()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	if *format == "parquet" {
+		pw := export.NewParquetWriter(w, 0)
+		if err := export.Export(ctx, src.ReadEntryBundle, cp.Size, pw, opts); err != nil {
+			klog.Exitf("Export failed: %v", err)
+		}
+		if err := pw.Close(); err != nil {
+			klog.Exitf("Failed to finish Parquet file: %v", err)
+		}
+	} else if err := export.Export(ctx, src.ReadEntryBundle, cp.Size, export.NewJSONLinesWriter(w), opts); err != nil {
+		klog.Exitf("Export failed: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		klog.Exitf("Failed to flush output: %v", err)
+	}
+}
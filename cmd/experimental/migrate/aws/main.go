@@ -46,8 +46,9 @@ var (
 	s3AccessKeyID     = flag.String("s3_access_key", "", "Access key ID for custom non-AWS S3 service")
 	s3SecretAccessKey = flag.String("s3_secret", "", "Secret access key for custom non-AWS S3 service")
 
-	sourceURL  = flag.String("source_url", "", "Base URL for the source log.")
-	numWorkers = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	sourceURL   = flag.String("source_url", "", "Base URL for the source log.")
+	numWorkers  = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	verifyTiles = flag.Bool("verify_tiles", false, "If set, each entry bundle is checked against the source log's level-0 hash tiles as it is copied.")
 )
 
 func main() {
@@ -83,6 +84,9 @@ func main() {
 		klog.Exitf("Failed to create new AWS storage: %v", err)
 	}
 	opts := tessera.NewMigrationOptions()
+	if *verifyTiles {
+		opts.WithSourceTileCheck(src.ReadTile)
+	}
 
 	m, err := tessera.NewMigrationTarget(ctx, driver, opts)
 	if err != nil {
@@ -38,6 +38,7 @@ var (
 
 	sourceURL          = flag.String("source_url", "", "Base URL for the source log.")
 	numWorkers         = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	verifyTiles        = flag.Bool("verify_tiles", false, "If set, each entry bundle is checked against the source log's level-0 hash tiles as it is copied.")
 	persistentAntispam = flag.Bool("antispam", false, "EXPERIMENTAL: Set to true to enable GCP-based persistent antispam storage")
 )
 
@@ -76,6 +77,9 @@ func main() {
 	}
 
 	opts := tessera.NewMigrationOptions()
+	if *verifyTiles {
+		opts.WithSourceTileCheck(src.ReadTile)
+	}
 	// Configure antispam storage, if necessary
 	var antispam tessera.Antispam
 	// Persistent antispam is currently experimental, so there's no terraform or documentation yet!
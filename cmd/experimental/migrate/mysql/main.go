@@ -40,8 +40,9 @@ var (
 	dbMaxIdleConns    = flag.Int("db_max_idle_conns", 64, "")
 	initSchemaPath    = flag.String("init_schema_path", "", "Location of the schema file if database initialization is needed")
 
-	sourceURL  = flag.String("source_url", "", "Base URL for the source log.")
-	numWorkers = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	sourceURL   = flag.String("source_url", "", "Base URL for the source log.")
+	numWorkers  = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	verifyTiles = flag.Bool("verify_tiles", false, "If set, each entry bundle is checked against the source log's level-0 hash tiles as it is copied.")
 )
 
 func main() {
@@ -80,6 +81,9 @@ func main() {
 	}
 
 	opts := tessera.NewMigrationOptions()
+	if *verifyTiles {
+		opts.WithSourceTileCheck(src.ReadTile)
+	}
 
 	m, err := tessera.NewMigrationTarget(ctx, driver, opts)
 	if err != nil {
@@ -13,7 +13,7 @@
 // limitations under the License.
 
 // posix-migrate is a command-line tool for migrating data from a tlog-tiles
-// compliant log, into a Tessera log instance.
+// compliant log, or a Sunlight log, into a Tessera log instance.
 package main
 
 import (
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 
+	f_note "github.com/transparency-dev/formats/note"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/client"
 	"github.com/transparency-dev/tessera/storage/posix"
@@ -31,9 +32,12 @@ import (
 )
 
 var (
-	storageDir = flag.String("storage_dir", "", "Root directory to store log data.")
-	sourceURL  = flag.String("source_url", "", "Base URL for the source log.")
-	numWorkers = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	storageDir  = flag.String("storage_dir", "", "Root directory to store log data.")
+	sourceURL   = flag.String("source_url", "", "Base URL for the source log.")
+	numWorkers  = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	verifyTiles = flag.Bool("verify_tiles", false, "If set, each entry bundle is checked against the source log's level-0 hash tiles as it is copied.")
+	dedup       = flag.Bool("dedup", false, "If set, identical tiles and entry bundles are stored once on disk and hard-linked into place.")
+	sunlightV   = flag.String("sunlight_verifier", "", "If set, the source is a Sunlight log, and its checkpoints are verified with this note verifier key, e.g. from RFC6962VerifierString.")
 )
 
 func main() {
@@ -45,35 +49,67 @@ func main() {
 	if err != nil {
 		klog.Exitf("Invalid --source_url %q: %v", *sourceURL, err)
 	}
-	src, err := client.NewHTTPFetcher(srcURL, nil)
-	if err != nil {
-		klog.Exitf("Failed to create HTTP fetcher: %v", err)
-	}
-	sourceCP, err := src.ReadCheckpoint(ctx)
-	if err != nil {
-		klog.Exitf("fetch initial source checkpoint: %v", err)
+	opts := tessera.NewMigrationOptions()
+	var sourceSize uint64
+	var sourceRoot []byte
+	var getEntries client.EntryBundleFetcherFunc
+	var getTiles client.TileFetcherFunc
+	if *sunlightV != "" {
+		v, err := f_note.NewVerifier(*sunlightV)
+		if err != nil {
+			klog.Exitf("Invalid --sunlight_verifier: %v", err)
+		}
+		src, err := tessera.NewSunlightSource(srcURL, nil, v)
+		if err != nil {
+			klog.Exitf("Failed to create Sunlight source: %v", err)
+		}
+		_, sourceSize, sourceRoot, err = src.Checkpoint(ctx)
+		if err != nil {
+			klog.Exitf("fetch initial source checkpoint: %v", err)
+		}
+		getEntries = src.ReadEntryBundle
+		getTiles = src.ReadTile
+		opts.WithCTLayout()
+	} else {
+		src, err := client.NewHTTPFetcher(srcURL, nil)
+		if err != nil {
+			klog.Exitf("Failed to create HTTP fetcher: %v", err)
+		}
+		sourceCP, err := src.ReadCheckpoint(ctx)
+		if err != nil {
+			klog.Exitf("fetch initial source checkpoint: %v", err)
+		}
+		bits := strings.Split(string(sourceCP), "\n")
+		sourceSize, err = strconv.ParseUint(bits[1], 10, 64)
+		if err != nil {
+			klog.Exitf("invalid CP size %q: %v", bits[1], err)
+		}
+		sourceRoot, err = base64.StdEncoding.DecodeString(bits[2])
+		if err != nil {
+			klog.Exitf("invalid checkpoint roothash %q: %v", bits[2], err)
+		}
+		getEntries = src.ReadEntryBundle
+		getTiles = src.ReadTile
 	}
-	bits := strings.Split(string(sourceCP), "\n")
-	sourceSize, err := strconv.ParseUint(bits[1], 10, 64)
-	if err != nil {
-		klog.Exitf("invalid CP size %q: %v", bits[1], err)
-	}
-	sourceRoot, err := base64.StdEncoding.DecodeString(bits[2])
-	if err != nil {
-		klog.Exitf("invalid checkpoint roothash %q: %v", bits[2], err)
+	if *verifyTiles {
+		opts.WithSourceTileCheck(getTiles)
 	}
 
-	driver, err := posix.New(ctx, *storageDir)
+	var posixOpts []posix.Option
+	if *dedup {
+		posixOpts = append(posixOpts, posix.WithHardLinkDedup())
+	}
+	driver, err := posix.New(ctx, *storageDir, posixOpts...)
 	if err != nil {
 		klog.Exitf("Failed to create new POSIX storage driver: %v", err)
 	}
 	// Create our Tessera migration target instance
-	m, err := tessera.NewMigrationTarget(ctx, driver, tessera.NewMigrationOptions())
+	m, err := tessera.NewMigrationTarget(ctx, driver, opts)
 	if err != nil {
 		klog.Exitf("Failed to create new POSIX storage: %v", err)
 	}
 
-	if err := m.Migrate(context.Background(), *numWorkers, sourceSize, sourceRoot, src.ReadEntryBundle); err != nil {
+	if err := m.Migrate(context.Background(), *numWorkers, sourceSize, sourceRoot, getEntries); err != nil {
 		klog.Exitf("Migrate failed: %v", err)
 	}
 }
@@ -0,0 +1,257 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/publish"
+)
+
+func TestSink(t *testing.T) {
+	js := newFakeJetStream(t, "log.entries", "s3cret")
+
+	s, err := New(fmt.Sprintf("nats://s3cret@%s", js.l.Addr()), "log.entries", 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	// The second batch is a replay of part of the first, as happens if the Publisher fails to store its
+	// high-water mark, followed by new entries.
+	for _, batch := range [][2]uint64{{0, 10}, {5, 15}} {
+		if err := s.Publish(t.Context(), records(batch[0], batch[1])); err != nil {
+			t.Fatalf("Publish%v: %v", batch, err)
+		}
+	}
+
+	// The connection is dropped part way through this batch, so the whole batch must be retried.
+	js.dropAfter(2)
+	if err := s.Publish(t.Context(), records(15, 20)); err == nil {
+		t.Fatal("Publish succeeded despite dropped connection")
+	}
+	if err := s.Publish(t.Context(), records(15, 20)); err != nil {
+		t.Fatalf("Publish after dropped connection: %v", err)
+	}
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if got, want := js.connects, 2; got != want {
+		t.Errorf("Got %d connections, want %d", got, want)
+	}
+	if got, want := len(js.stored), 20; got != want {
+		t.Fatalf("Stream has %d messages, want %d", got, want)
+	}
+	for i, m := range js.stored {
+		want := records(uint64(i), uint64(i)+1)[0]
+		if m.hdr["Nats-Msg-Id"] != fmt.Sprintf("log.entries/%d", i) || m.hdr["Tessera-Index"] != strconv.Itoa(i) || m.hdr["Tessera-Leaf-Hash"] != base64.StdEncoding.EncodeToString(want.LeafHash) {
+			t.Errorf("Message %d has headers %v", i, m.hdr)
+		}
+		if !bytes.Equal(m.data, want.Data) {
+			t.Errorf("Message %d has data %q, want %q", i, m.data, want.Data)
+		}
+	}
+}
+
+func TestSinkNoStream(t *testing.T) {
+	js := newFakeJetStream(t, "log.entries", "")
+
+	s, err := New(fmt.Sprintf("nats://%s", js.l.Addr()), "other.subject", 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	if err := s.Publish(t.Context(), records(0, 1)); err == nil || !strings.Contains(err.Error(), "no JetStream stream") {
+		t.Errorf("Publish to subject without a stream: got err %v, want no stream error", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, test := range []struct {
+		url, subject string
+		wantErr      bool
+	}{
+		{url: "nats://localhost:4222", subject: "log.entries"},
+		{url: "nats://localhost", subject: "log.entries"},
+		{url: "http://localhost", subject: "log.entries", wantErr: true},
+		{url: "nats://localhost", subject: "", wantErr: true},
+		{url: "nats://localhost", subject: "log.*", wantErr: true},
+	} {
+		if _, err := New(test.url, test.subject, 0); (err != nil) != test.wantErr {
+			t.Errorf("New(%q, %q): got err %v, want err %t", test.url, test.subject, err, test.wantErr)
+		}
+	}
+}
+
+func records(from, to uint64) []publish.Record {
+	var r []publish.Record
+	for i := from; i < to; i++ {
+		d := fmt.Appendf(nil, "entry %d", i)
+		r = append(r, publish.Record{Index: i, LeafHash: rfc6962.DefaultHasher.HashLeaf(d), Data: d})
+	}
+	return r
+}
+
+type fakeMsg struct {
+	hdr  map[string]string
+	data []byte
+}
+
+// fakeJetStream is a NATS server with a JetStream stream bound to a single subject, which supports
+// just enough of the protocol for Sink.
+type fakeJetStream struct {
+	t       *testing.T
+	l       net.Listener
+	subject string
+	token   string
+
+	mu       sync.Mutex
+	connects int
+	stored   []fakeMsg
+	ids      map[string]bool
+	// drop, if positive, is the number of messages after which the connection is closed.
+	drop int
+}
+
+func newFakeJetStream(t *testing.T, subject, token string) *fakeJetStream {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	js := &fakeJetStream{t: t, l: l, subject: subject, token: token, ids: map[string]bool{}}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go js.serve(c)
+		}
+	}()
+	return js
+}
+
+func (js *fakeJetStream) dropAfter(n int) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.drop = n
+}
+
+func (js *fakeJetStream) serve(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	js.mu.Lock()
+	js.connects++
+	js.mu.Unlock()
+
+	r := bufio.NewReader(c)
+	if _, err := io.WriteString(c, `INFO {"server_id":"fake","headers":true,"jetstream":true}`+"\r\n"); err != nil {
+		return
+	}
+	sid := ""
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		var reply string
+		switch op {
+		case "CONNECT":
+			var opts struct {
+				Headers      bool   `json:"headers"`
+				NoResponders bool   `json:"no_responders"`
+				Token        string `json:"auth_token"`
+			}
+			if err := json.Unmarshal([]byte(args), &opts); err != nil || !opts.Headers || !opts.NoResponders {
+				js.t.Errorf("Unexpected CONNECT %s", args)
+				return
+			}
+			if opts.Token != js.token {
+				reply = "-ERR 'Authorization Violation'\r\n"
+			}
+		case "SUB":
+			f := strings.Fields(args)
+			sid = f[len(f)-1]
+		case "PING":
+			reply = "PONG\r\n"
+		case "HPUB":
+			f := strings.Fields(args)
+			if len(f) != 4 {
+				js.t.Errorf("Unexpected HPUB %s", args)
+				return
+			}
+			hdrLen, _ := strconv.Atoi(f[2])
+			total, _ := strconv.Atoi(f[3])
+			b := make([]byte, total+2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return
+			}
+			if f[0] != js.subject {
+				reply = fmt.Sprintf("HMSG %s %s 16 16\r\nNATS/1.0 503\r\n\r\n\r\n", f[1], sid)
+				break
+			}
+			ack, ok := js.store(b[:hdrLen], b[hdrLen:total])
+			if !ok {
+				return
+			}
+			reply = fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", f[1], sid, len(ack), ack)
+		default:
+			js.t.Errorf("Unexpected operation %q", line)
+			return
+		}
+		if _, err := io.WriteString(c, reply); err != nil {
+			return
+		}
+	}
+}
+
+// store stores the message unless it's a duplicate, and returns the acknowledgement to send, or false
+// if the connection should be dropped instead.
+func (js *fakeJetStream) store(hdr, data []byte) (string, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if js.drop > 0 {
+		js.drop--
+		if js.drop == 0 {
+			return "", false
+		}
+	}
+	m := fakeMsg{hdr: map[string]string{}, data: bytes.Clone(data)}
+	for _, l := range strings.Split(string(hdr), "\r\n")[1:] {
+		if k, v, ok := strings.Cut(l, ": "); ok {
+			m.hdr[k] = v
+		}
+	}
+	id := m.hdr["Nats-Msg-Id"]
+	dup := js.ids[id]
+	if !dup {
+		js.ids[id] = true
+		js.stored = append(js.stored, m)
+	}
+	return fmt.Sprintf(`{"stream":"LOG","seq":%d,"duplicate":%t}`, len(js.stored), dup), true
+}
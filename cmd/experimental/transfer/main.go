@@ -0,0 +1,172 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// transfer is a command-line tool for replicating a log across an air gap.
+//
+// `transfer create` writes a signed bundle of the resources which are new in a log since a given tree size,
+// and `transfer apply` verifies such a bundle and applies it to a replica of the log stored on a POSIX filesystem.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/transfer"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [create|apply] [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	ctx := context.Background()
+
+	var err error
+	switch cmd := flag.Arg(0); cmd {
+	case "create":
+		err = create(ctx, flag.Args()[1:])
+	case "apply":
+		err = apply(ctx, flag.Args()[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		klog.Exit(err)
+	}
+}
+
+func create(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	storageDir := fs.String("storage_dir", "", "Root directory of the log to create a bundle from. Exactly one of --storage_dir or --storage_url must be set.")
+	storageURL := fs.String("storage_url", "", "Base URL of the log to create a bundle from.")
+	replicaCheckpoint := fs.String("replica_checkpoint", "", "Path to a copy of the replica's current checkpoint, the bundle will contain the resources which are new since then. If unset, the bundle contains the whole log.")
+	privKey := fs.String("private_key", "", "Path to a file containing the note signer key used to sign the bundle.")
+	output := fs.String("output", "", "File to write the bundle to.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var f client.Fetcher
+	switch {
+	case *storageDir != "" && *storageURL == "":
+		f = client.FileFetcher{Root: *storageDir}
+	case *storageURL != "" && *storageDir == "":
+		u, err := url.Parse(*storageURL)
+		if err != nil {
+			return fmt.Errorf("invalid --storage_url %q: %v", *storageURL, err)
+		}
+		if f, err = client.NewHTTPFetcher(u, nil); err != nil {
+			return fmt.Errorf("failed to create HTTP fetcher: %v", err)
+		}
+	default:
+		return errors.New("exactly one of --storage_dir or --storage_url must be set")
+	}
+	var from uint64
+	if *replicaCheckpoint != "" {
+		cp, err := os.ReadFile(*replicaCheckpoint)
+		if err != nil {
+			return fmt.Errorf("failed to read replica checkpoint: %v", err)
+		}
+		// The replica checkpoint only determines which resources go into the bundle, the receiver
+		// verifies that the bundle is consistent with the checkpoint it actually has.
+		if _, from, _, err = parse.CheckpointUnsafe(cp); err != nil {
+			return fmt.Errorf("failed to parse replica checkpoint: %v", err)
+		}
+	}
+	b, err := os.ReadFile(*privKey)
+	if err != nil {
+		return fmt.Errorf("failed to read --private_key: %v", err)
+	}
+	s, err := note.NewSigner(strings.TrimSpace(string(b)))
+	if err != nil {
+		return fmt.Errorf("invalid --private_key: %v", err)
+	}
+	if *output == "" {
+		return errors.New("--output must be set")
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	if err := transfer.Create(ctx, out, f, from, s); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to create bundle: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %v", err)
+	}
+	klog.Infof("Wrote bundle from size %d to %q", from, *output)
+	return nil
+}
+
+func apply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	storageDir := fs.String("storage_dir", "", "Root directory of the replica to apply the bundle to.")
+	input := fs.String("input", "", "File to read the bundle from.")
+	bundleKey := fs.String("bundle_public_key", "", "Path to a file containing the note verifier key for the bundle's signature.")
+	logKey := fs.String("log_public_key", "", "Path to a file containing the note verifier key for the log's checkpoints.")
+	origin := fs.String("origin", "", "Origin of the log, if unset, will use the name of the log's public key.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *storageDir == "" {
+		return errors.New("--storage_dir must be set")
+	}
+	bv, err := readVerifier(*bundleKey)
+	if err != nil {
+		return fmt.Errorf("invalid --bundle_public_key: %v", err)
+	}
+	lv, err := readVerifier(*logKey)
+	if err != nil {
+		return fmt.Errorf("invalid --log_public_key: %v", err)
+	}
+
+	in, err := os.Open(*input)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %v", err)
+	}
+	defer func() { _ = in.Close() }()
+	st, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat bundle: %v", err)
+	}
+	opts := transfer.ApplyOptions{BundleVerifier: bv, LogVerifier: lv, Origin: *origin}
+	if err := transfer.Apply(ctx, in, st.Size(), transfer.DirTarget{Root: *storageDir}, opts); err != nil {
+		return fmt.Errorf("failed to apply bundle: %v", err)
+	}
+	klog.Infof("Applied bundle %q", *input)
+	return nil
+}
+
+func readVerifier(p string) (note.Verifier, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return f_note.NewVerifier(strings.TrimSpace(string(b)))
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// witness-verify is a command-line tool for checking that a log's checkpoints are consistent,
+// and witnessed according to a witness policy, without needing to write any code.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	f_log "github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	pubKey        = flag.String("public_key", "", "Path to a file containing the log's public key")
+	origin        = flag.String("origin", "", "Origin of the log, if unset, will use the name of the provided public key")
+	policyFile    = flag.String("policy_file", "", "Path to a file containing the witness policy the checkpoint must satisfy")
+	checkpoint    = flag.String("checkpoint", "", "Path to a file containing the checkpoint to verify")
+	oldCheckpoint = flag.String("old_checkpoint", "", "Optional path to a file containing an older checkpoint, which must be consistent with --checkpoint")
+	proofFile     = flag.String("proof", "", "Optional path to a file containing a consistency proof from --old_checkpoint to --checkpoint, as base64 hashes, one per line")
+	logURL        = flag.String("log_url", "", "Optional root URL of the log, used to build the consistency proof if --proof is not provided")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	v := verifierFromFlags()
+	if *origin == "" {
+		*origin = v.Name()
+	}
+	policy, err := tessera.NewWitnessGroupFromPolicy(readFileOrDie("policy_file", *policyFile))
+	if err != nil {
+		klog.Exitf("Invalid witness policy: %v", err)
+	}
+
+	cpRaw := readFileOrDie("checkpoint", *checkpoint)
+	cp, _, _, err := f_log.ParseCheckpoint(cpRaw, *origin, v)
+	if err != nil {
+		klog.Exitf("Invalid checkpoint: %v", err)
+	}
+	if !policy.Satisfied(cpRaw) {
+		klog.Exitf("Checkpoint of size %d does NOT satisfy the witness policy", cp.Size)
+	}
+	fmt.Printf("Checkpoint of size %d is signed by the log and satisfies the witness policy\n", cp.Size)
+
+	if *oldCheckpoint == "" {
+		return
+	}
+	oldRaw := readFileOrDie("old_checkpoint", *oldCheckpoint)
+	old, _, _, err := f_log.ParseCheckpoint(oldRaw, *origin, v)
+	if err != nil {
+		klog.Exitf("Invalid old checkpoint: %v", err)
+	}
+	p, err := consistencyProof(ctx, old.Size, cp.Size)
+	if err != nil {
+		klog.Exitf("Failed to get consistency proof: %v", err)
+	}
+	if err := verifyConsistency(old, cp, p); err != nil {
+		klog.Exitf("Checkpoints are NOT consistent: %v", err)
+	}
+	fmt.Printf("Checkpoint of size %d is consistent with old checkpoint of size %d\n", cp.Size, old.Size)
+}
+
+// verifyConsistency checks that the larger checkpoint is consistent with the smaller one.
+func verifyConsistency(smaller, larger *f_log.Checkpoint, p [][]byte) error {
+	if smaller.Size > larger.Size {
+		return fmt.Errorf("old checkpoint size %d is larger than checkpoint size %d", smaller.Size, larger.Size)
+	}
+	if smaller.Size == 0 {
+		return nil
+	}
+	return proof.VerifyConsistency(rfc6962.DefaultHasher, smaller.Size, larger.Size, p, smaller.Hash, larger.Hash)
+}
+
+// consistencyProof returns the consistency proof between the two tree sizes, either read from
+// the --proof file, or built from the log at --log_url.
+func consistencyProof(ctx context.Context, smaller, larger uint64) ([][]byte, error) {
+	if *proofFile != "" {
+		return parseProof(readFileOrDie("proof", *proofFile))
+	}
+	if smaller == 0 || smaller == larger {
+		return nil, nil
+	}
+	if *logURL == "" {
+		return nil, errors.New("one of --proof or --log_url must be provided")
+	}
+	u, err := url.Parse(*logURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --log_url %q: %v", *logURL, err)
+	}
+	f, err := client.NewHTTPFetcher(u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP fetcher: %v", err)
+	}
+	pb, err := client.NewProofBuilder(ctx, larger, f.ReadTile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	return pb.ConsistencyProof(ctx, smaller, larger)
+}
+
+// parseProof parses a consistency proof made up of base64 encoded hashes, one per line.
+func parseProof(b []byte) ([][]byte, error) {
+	var p [][]byte
+	for l := range strings.Lines(string(b)) {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		h, err := base64.StdEncoding.DecodeString(l)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof hash %q: %v", l, err)
+		}
+		p = append(p, h)
+	}
+	return p, nil
+}
+
+func readFileOrDie(flagName, path string) []byte {
+	if path == "" {
+		klog.Exitf("Must provide the --%s flag", flagName)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		klog.Exitf("Failed to read --%s %q: %v", flagName, path, err)
+	}
+	return b
+}
+
+func verifierFromFlags() note.Verifier {
+	b := readFileOrDie("public_key", *pubKey)
+	v, err := f_note.NewVerifier(strings.TrimSpace(string(b)))
+	if err != nil {
+		klog.Exitf("Invalid verifier in %q: %v", *pubKey, err)
+	}
+	return v
+}
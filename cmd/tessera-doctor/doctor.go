@@ -0,0 +1,212 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// status is the outcome of a single check.
+type status string
+
+const (
+	statusOK   status = "OK"
+	statusWarn status = "WARN"
+	statusFail status = "FAIL"
+	statusSkip status = "SKIP"
+)
+
+// result is the outcome of a single check, along with a human readable explanation.
+type result struct {
+	name   string
+	status status
+	detail string
+}
+
+// report is the outcome of all of the checks run against a log.
+type report struct {
+	storage string
+	results []result
+}
+
+func (r *report) add(name string, s status, format string, args ...any) {
+	r.results = append(r.results, result{name: name, status: s, detail: fmt.Sprintf(format, args...)})
+}
+
+// ready returns true if none of the checks failed.
+func (r *report) ready() bool {
+	for _, res := range r.results {
+		if res.status == statusFail {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *report) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Readiness report for %s\n\n", r.storage)
+	failed := 0
+	for _, res := range r.results {
+		fmt.Fprintf(b, "  %-4s  %-22s%s\n", res.status, res.name, res.detail)
+		if res.status == statusFail {
+			failed++
+		}
+	}
+	if failed == 0 {
+		b.WriteString("\nReady\n")
+	} else {
+		fmt.Fprintf(b, "\nNOT ready: %d check(s) failed\n", failed)
+	}
+	return b.String()
+}
+
+// doctor runs checks against the storage and keys of a log.
+type doctor struct {
+	storage string
+	v       note.Verifier
+	// signer is the log's note signer key, if known.
+	signer           string
+	origin           string
+	checkWrite       bool
+	maxClockSkew     time.Duration
+	maxCheckpointAge time.Duration
+}
+
+// run runs all of the checks, continuing past failures where possible so that the report describes
+// as many problems as it can.
+func (d *doctor) run(ctx context.Context) *report {
+	r := &report{storage: d.storage}
+	d.checkKeys(r)
+
+	t, err := openTarget(ctx, d.storage)
+	if err != nil {
+		r.add("connectivity", statusFail, "%v", err)
+		return r
+	}
+	defer func() { _ = t.close() }()
+
+	if sc, ok := t.(schemaChecker); ok {
+		if v, err := sc.checkSchema(ctx); err != nil {
+			r.add("schema", statusFail, "%v", err)
+		} else {
+			r.add("schema", statusOK, "compatibility version %d", v)
+		}
+	}
+
+	cp, modTime, cpErr := t.readCheckpoint(ctx)
+	switch {
+	case cpErr == nil:
+		r.add("connectivity", statusOK, "read checkpoint")
+	case errors.Is(cpErr, os.ErrNotExist):
+		r.add("connectivity", statusOK, "connected, but no checkpoint has been published yet")
+	default:
+		r.add("connectivity", statusFail, "failed to read checkpoint: %v", cpErr)
+	}
+
+	d.checkWriteAndClock(ctx, r, t)
+
+	if cpErr != nil {
+		r.add("checkpoint", statusSkip, "no checkpoint to verify")
+		r.add("checkpoint freshness", statusSkip, "no checkpoint to check")
+		return r
+	}
+	if c, _, _, err := client.OpenCheckpoint(cp, d.origin, d.v); err != nil {
+		r.add("checkpoint", statusFail, "failed to verify with the public key for %q: %v", d.origin, err)
+	} else {
+		r.add("checkpoint", statusOK, "verified, tree size %d", c.Size)
+	}
+	d.checkFreshness(r, modTime)
+	return r
+}
+
+// checkKeys checks that the signer, if given, corresponds to the verifier.
+func (d *doctor) checkKeys(r *report) {
+	if d.signer == "" {
+		r.add("keys", statusSkip, "no signer given")
+		return
+	}
+	s, err := note.NewSigner(d.signer)
+	if err != nil {
+		r.add("keys", statusFail, "invalid signer: %v", err)
+		return
+	}
+	if s.Name() != d.v.Name() || s.KeyHash() != d.v.KeyHash() {
+		r.add("keys", statusFail, "signer %q (hash %08x) doesn't match public key %q (hash %08x)", s.Name(), s.KeyHash(), d.v.Name(), d.v.KeyHash())
+		return
+	}
+	// The name and key hash are only declared in the key strings, so check that the keys really are a pair.
+	msg := []byte("tessera-doctor\n")
+	sig, err := s.Sign(msg)
+	if err != nil {
+		r.add("keys", statusFail, "failed to sign with signer: %v", err)
+		return
+	}
+	if !d.v.Verify(msg, sig) {
+		r.add("keys", statusFail, "public key doesn't verify signatures made by the signer")
+		return
+	}
+	r.add("keys", statusOK, "signer matches public key %q", d.v.Name())
+}
+
+// checkWriteAndClock checks that the storage can be written to and, using the storage's record of
+// when that write happened, that its clock agrees with the local one.
+func (d *doctor) checkWriteAndClock(ctx context.Context, r *report, t target) {
+	if !d.checkWrite {
+		r.add("write access", statusSkip, "disabled by --check_write=false")
+		r.add("clock skew", statusSkip, "requires write check")
+		return
+	}
+	before := time.Now()
+	remote, err := t.probeWrite(ctx)
+	after := time.Now()
+	if err != nil {
+		r.add("write access", statusFail, "%v", err)
+		r.add("clock skew", statusSkip, "requires write access")
+		return
+	}
+	r.add("write access", statusOK, "wrote and removed a probe")
+
+	// The remote time could be from any point during the probe, so only complain about skew beyond that.
+	rtt := after.Sub(before)
+	skew := remote.Sub(before.Add(rtt / 2))
+	if skew.Abs() > d.maxClockSkew+rtt/2 {
+		r.add("clock skew", statusFail, "storage clock differs from local clock by %v, more than %v", skew.Round(time.Millisecond), d.maxClockSkew)
+		return
+	}
+	r.add("clock skew", statusOK, "%v (±%v)", skew.Round(time.Millisecond), (rtt / 2).Round(time.Millisecond))
+}
+
+// checkFreshness checks that the checkpoint was written recently enough.
+func (d *doctor) checkFreshness(r *report, modTime time.Time) {
+	if d.maxCheckpointAge == 0 {
+		r.add("checkpoint freshness", statusSkip, "disabled by --max_checkpoint_age=0")
+		return
+	}
+	age := time.Now().Sub(modTime)
+	if age > d.maxCheckpointAge {
+		r.add("checkpoint freshness", statusFail, "checkpoint is %v old, more than %v; is the log's sequencer running?", age.Round(time.Second), d.maxCheckpointAge)
+		return
+	}
+	r.add("checkpoint freshness", statusOK, "checkpoint is %v old", age.Round(time.Second))
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// newPOSIXLog creates a POSIX log holding a few entries, and returns its directory along with the log's
+// signer and verifier keys. The log's sequencer is stopped before returning, so the log isn't modified
+// afterwards.
+func newPOSIXLog(t *testing.T) (string, string, note.Verifier) {
+	t.Helper()
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	root := t.TempDir()
+	driver, err := posix.New(ctx, root)
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, shutdown, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 10*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, 10)
+	for i := range 10 {
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	return root, skey, v
+}
+
+// statuses returns the status of each check in the report, by name.
+func statuses(r *report) map[string]status {
+	s := make(map[string]status, len(r.results))
+	for _, res := range r.results {
+		s[res.name] = res.status
+	}
+	return s
+}
+
+func TestHealthyLog(t *testing.T) {
+	root, skey, v := newPOSIXLog(t)
+	d := &doctor{
+		storage:          "file://" + root,
+		v:                v,
+		signer:           skey,
+		origin:           v.Name(),
+		checkWrite:       true,
+		maxClockSkew:     5 * time.Second,
+		maxCheckpointAge: time.Minute,
+	}
+	r := d.run(t.Context())
+	if !r.ready() {
+		t.Errorf("Healthy log is not ready:\n%s", r)
+	}
+	for _, name := range []string{"keys", "connectivity", "write access", "clock skew", "checkpoint", "checkpoint freshness"} {
+		if got := statuses(r)[name]; got != statusOK {
+			t.Errorf("Check %q: got %q, want %q\n%s", name, got, statusOK, r)
+		}
+	}
+	probes, err := filepath.Glob(filepath.Join(root, probePrefix+"*"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(probes) != 0 {
+		t.Errorf("Write probes were left behind: %v", probes)
+	}
+}
+
+func TestUnhealthyLog(t *testing.T) {
+	otherKey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	for _, test := range []struct {
+		name string
+		// corrupt breaks the log stored in root, or the doctor's configuration for it.
+		corrupt   func(t *testing.T, root string, d *doctor)
+		wantCheck string
+	}{
+		{
+			name: "corrupted checkpoint",
+			corrupt: func(t *testing.T, root string, _ *doctor) {
+				p := filepath.Join(root, layout.CheckpointPath)
+				cp, err := os.ReadFile(p)
+				if err != nil {
+					t.Fatalf("ReadFile: %v", err)
+				}
+				// Change the first character of the root hash, which is on the third line.
+				lines := bytes.SplitN(cp, []byte("\n"), 4)
+				if lines[2][0] == 'A' {
+					lines[2][0] = 'B'
+				} else {
+					lines[2][0] = 'A'
+				}
+				cp = bytes.Join(lines, []byte("\n"))
+				if err := os.WriteFile(p, cp, 0o644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			},
+			wantCheck: "checkpoint",
+		}, {
+			name: "truncated checkpoint",
+			corrupt: func(t *testing.T, root string, _ *doctor) {
+				if err := os.WriteFile(filepath.Join(root, layout.CheckpointPath), []byte("example.com/log\n"), 0o644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			},
+			wantCheck: "checkpoint",
+		}, {
+			name: "stale checkpoint",
+			corrupt: func(t *testing.T, root string, _ *doctor) {
+				old := time.Now().Add(-time.Hour)
+				if err := os.Chtimes(filepath.Join(root, layout.CheckpointPath), old, old); err != nil {
+					t.Fatalf("Chtimes: %v", err)
+				}
+			},
+			wantCheck: "checkpoint freshness",
+		}, {
+			name: "mismatched signer",
+			corrupt: func(_ *testing.T, _ string, d *doctor) {
+				d.signer = otherKey
+			},
+			wantCheck: "keys",
+		}, {
+			name: "missing storage",
+			corrupt: func(t *testing.T, root string, _ *doctor) {
+				if err := os.RemoveAll(root); err != nil {
+					t.Fatalf("RemoveAll: %v", err)
+				}
+			},
+			wantCheck: "connectivity",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			root, skey, v := newPOSIXLog(t)
+			d := &doctor{
+				storage:          "file://" + root,
+				v:                v,
+				signer:           skey,
+				origin:           v.Name(),
+				checkWrite:       true,
+				maxClockSkew:     5 * time.Second,
+				maxCheckpointAge: time.Minute,
+			}
+			test.corrupt(t, root, d)
+			r := d.run(t.Context())
+			if r.ready() {
+				t.Errorf("Unhealthy log is ready:\n%s", r)
+			}
+			if got := statuses(r)[test.wantCheck]; got != statusFail {
+				t.Errorf("Check %q: got %q, want %q\n%s", test.wantCheck, got, statusFail, r)
+			}
+		})
+	}
+}
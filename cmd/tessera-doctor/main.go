@@ -0,0 +1,84 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// tessera-doctor is a command-line tool which checks that the storage and keys configured for a
+// Tessera log are usable, and prints a readiness report, so that misconfigurations can be caught
+// before a personality is deployed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageURI        = flag.String("storage", "", "Storage of the log to check, one of gs://<bucket>[/<prefix>], s3://<bucket>[/<prefix>], file://<dir>, or mysql://<DSN>.")
+	pubKey            = flag.String("public_key", "", "Path to a file containing the log's public key, used to verify checkpoints.")
+	signer            = flag.String("signer", "", "If set, the note signer the log is configured with, which is checked against --public_key.")
+	origin            = flag.String("origin", "", "Origin of the log, if unset, will use the name of the provided public key.")
+	checkWrite        = flag.Bool("check_write", true, "Whether to check that the storage is writable by writing and then removing a probe. Disable this when running with read-only credentials.")
+	maxClockSkew      = flag.Duration("max_clock_skew", 5*time.Second, "Largest difference between the local clock and the storage's clock which is acceptable.")
+	maxCheckpointAge  = flag.Duration("max_checkpoint_age", 5*time.Minute, "Largest age of the latest checkpoint which is acceptable. Zero disables the check.")
+	timeout           = flag.Duration("timeout", time.Minute, "Maximum time to spend running checks.")
+	s3Endpoint        = flag.String("s3_endpoint", "", "Endpoint for custom non-AWS S3 service")
+	s3AccessKeyID     = flag.String("s3_access_key", "", "Access key ID for custom non-AWS S3 service")
+	s3SecretAccessKey = flag.String("s3_secret", "", "Secret access key for custom non-AWS S3 service")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if *storageURI == "" {
+		klog.Exit("Missing parameter: --storage")
+	}
+	if *pubKey == "" {
+		klog.Exit("Missing parameter: --public_key")
+	}
+	b, err := os.ReadFile(*pubKey)
+	if err != nil {
+		klog.Exitf("Failed to read verifier from %q: %v", *pubKey, err)
+	}
+	v, err := f_note.NewVerifier(strings.TrimSpace(string(b)))
+	if err != nil {
+		klog.Exitf("Invalid verifier in %q: %v", *pubKey, err)
+	}
+	if *origin == "" {
+		*origin = v.Name()
+	}
+
+	d := &doctor{
+		storage:          *storageURI,
+		v:                v,
+		signer:           *signer,
+		origin:           *origin,
+		checkWrite:       *checkWrite,
+		maxClockSkew:     *maxClockSkew,
+		maxCheckpointAge: *maxCheckpointAge,
+	}
+	r := d.run(ctx)
+	fmt.Print(r)
+	if !r.ready() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/storage/mysql"
+)
+
+// probePrefix is the name under which probe objects are written. It's outside of the tlog-tiles
+// layout, so can never collide with the log's resources.
+const probePrefix = ".tessera-doctor-probe"
+
+// target is the storage of a log, as seen by the checks.
+type target interface {
+	// readCheckpoint returns the latest checkpoint and the time it was written, or an error wrapping
+	// os.ErrNotExist if there isn't one.
+	readCheckpoint(ctx context.Context) ([]byte, time.Time, error)
+	// probeWrite checks that the storage can be written to without modifying the log, and returns the
+	// time at which the storage recorded the write according to its own clock.
+	probeWrite(ctx context.Context) (time.Time, error)
+	close() error
+}
+
+// schemaChecker is implemented by targets which have a schema which must be compatible with Tessera.
+type schemaChecker interface {
+	checkSchema(ctx context.Context) (uint64, error)
+}
+
+// openTarget returns the target described by uri.
+func openTarget(ctx context.Context, uri string) (target, error) {
+	// MySQL DSNs aren't URLs, so are handled before parsing.
+	if dsn, ok := strings.CutPrefix(uri, "mysql://"); ok {
+		return openMySQL(ctx, dsn)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "gs":
+		c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS client: %v", err)
+		}
+		return gcsTarget{c: c, bkt: c.Bucket(u.Host), prefix: prefix}, nil
+	case "s3":
+		var opts []func(*s3.Options)
+		cfg := aws.Config{}
+		if *s3Endpoint != "" {
+			const defaultRegion = "us-east-1"
+			cfg.Region = defaultRegion
+			opts = append(opts, func(o *s3.Options) {
+				o.BaseEndpoint = aws.String(*s3Endpoint)
+				o.Credentials = credentials.NewStaticCredentialsProvider(*s3AccessKeyID, *s3SecretAccessKey, "")
+				o.UsePathStyle = true
+			})
+		} else if cfg, err = config.LoadDefaultConfig(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %v", err)
+		}
+		return s3Target{c: s3.NewFromConfig(cfg, opts...), bucket: u.Host, prefix: prefix}, nil
+	case "file":
+		if _, err := os.Stat(u.Path); err != nil {
+			return nil, err
+		}
+		return dirTarget{root: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// gcsTarget is a log stored in a GCS bucket.
+type gcsTarget struct {
+	c      *gcs.Client
+	bkt    *gcs.BucketHandle
+	prefix string
+}
+
+func (t gcsTarget) readCheckpoint(ctx context.Context) ([]byte, time.Time, error) {
+	r, err := t.bkt.Object(path.Join(t.prefix, layout.CheckpointPath)).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, time.Time{}, fmt.Errorf("%v: %w", err, os.ErrNotExist)
+		}
+		return nil, time.Time{}, err
+	}
+	defer func() { _ = r.Close() }()
+	b, err := io.ReadAll(r)
+	return b, r.Attrs.LastModified, err
+}
+
+func (t gcsTarget) probeWrite(ctx context.Context) (time.Time, error) {
+	obj := t.bkt.Object(path.Join(t.prefix, fmt.Sprintf("%s-%d", probePrefix, time.Now().UnixNano())))
+	w := obj.NewWriter(ctx)
+	if _, err := w.Write([]byte("probe")); err != nil {
+		return time.Time{}, fmt.Errorf("failed to write probe: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to write probe: %v", err)
+	}
+	if err := obj.Delete(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("wrote probe %q, but failed to delete it: %v", obj.ObjectName(), err)
+	}
+	return w.Attrs().Created, nil
+}
+
+func (t gcsTarget) close() error {
+	return t.c.Close()
+}
+
+// s3Target is a log stored in an S3 bucket.
+type s3Target struct {
+	c      *s3.Client
+	bucket string
+	prefix string
+}
+
+func (t s3Target) readCheckpoint(ctx context.Context) ([]byte, time.Time, error) {
+	r, err := t.c.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(path.Join(t.prefix, layout.CheckpointPath)),
+	})
+	if err != nil {
+		var nske *types.NoSuchKey
+		if errors.As(err, &nske) {
+			return nil, time.Time{}, fmt.Errorf("%v: %w", err, os.ErrNotExist)
+		}
+		return nil, time.Time{}, err
+	}
+	defer func() { _ = r.Body.Close() }()
+	b, err := io.ReadAll(r.Body)
+	return b, aws.ToTime(r.LastModified), err
+}
+
+func (t s3Target) probeWrite(ctx context.Context) (time.Time, error) {
+	key := path.Join(t.prefix, fmt.Sprintf("%s-%d", probePrefix, time.Now().UnixNano()))
+	if _, err := t.c.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader([]byte("probe")),
+	}); err != nil {
+		return time.Time{}, fmt.Errorf("failed to write probe: %v", err)
+	}
+	// S3 only reports the time of a write when the object is read back.
+	h, headErr := t.c.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(t.bucket), Key: aws.String(key)})
+	if _, err := t.c.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(t.bucket), Key: aws.String(key)}); err != nil {
+		return time.Time{}, fmt.Errorf("wrote probe %q, but failed to delete it: %v", key, err)
+	}
+	if headErr != nil {
+		return time.Time{}, fmt.Errorf("failed to read back probe: %v", headErr)
+	}
+	return aws.ToTime(h.LastModified), nil
+}
+
+func (t s3Target) close() error {
+	return nil
+}
+
+// dirTarget is a log stored in a local directory, e.g. by the POSIX storage implementation.
+type dirTarget struct {
+	root string
+}
+
+func (t dirTarget) readCheckpoint(_ context.Context) ([]byte, time.Time, error) {
+	p := filepath.Join(t.root, layout.CheckpointPath)
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	b, err := os.ReadFile(p)
+	return b, fi.ModTime(), err
+}
+
+func (t dirTarget) probeWrite(_ context.Context) (time.Time, error) {
+	f, err := os.CreateTemp(t.root, probePrefix+"-*")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to write probe: %v", err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	// On a network filesystem, the modification time is set by the server's clock.
+	fi, err := f.Stat()
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat probe: %v", err)
+	}
+	return fi.ModTime(), nil
+}
+
+func (t dirTarget) close() error {
+	return nil
+}
+
+// mysqlTarget is a log stored in a MySQL database.
+type mysqlTarget struct {
+	db *sql.DB
+	// s is only set once the schema has been found to be compatible.
+	s *mysql.Storage
+}
+
+func openMySQL(ctx context.Context, dsn string) (*mysqlTarget, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	return &mysqlTarget{db: db}, nil
+}
+
+func (t *mysqlTarget) checkSchema(ctx context.Context) (uint64, error) {
+	var v uint64
+	if err := t.db.QueryRowContext(ctx, "SELECT `compatibilityVersion` FROM `Tessera` WHERE `id` = 0").Scan(&v); err != nil {
+		return 0, fmt.Errorf("failed to read compatibility version, has the schema been created? %v", err)
+	}
+	// The storage implementation knows which version it requires.
+	s, err := mysql.New(ctx, t.db)
+	if err != nil {
+		return v, err
+	}
+	t.s = s
+	return v, nil
+}
+
+func (t *mysqlTarget) readCheckpoint(ctx context.Context) ([]byte, time.Time, error) {
+	if t.s == nil {
+		return nil, time.Time{}, errors.New("schema is incompatible")
+	}
+	cp, err := t.s.ReadCheckpoint(ctx)
+	if err != nil {
+		if errors.Is(err, tessera.ErrNotFound) {
+			return nil, time.Time{}, fmt.Errorf("%v: %w", err, os.ErrNotExist)
+		}
+		return nil, time.Time{}, err
+	}
+	at, err := t.s.ReadCheckpointModTime(ctx)
+	return cp, at, err
+}
+
+func (t *mysqlTarget) probeWrite(ctx context.Context) (time.Time, error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The update is a no-op, and is rolled back anyway, but still requires write privileges.
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, "UPDATE `Tessera` SET `compatibilityVersion` = `compatibilityVersion` WHERE `id` = 0"); err != nil {
+		return time.Time{}, fmt.Errorf("failed to update Tessera table: %v", err)
+	}
+	var ms int64
+	if err := tx.QueryRowContext(ctx, "SELECT CAST(UNIX_TIMESTAMP(NOW(3)) * 1000 AS SIGNED)").Scan(&ms); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read database time: %v", err)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func (t *mysqlTarget) close() error {
+	return t.db.Close()
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// tessera-load is a command-line tool which populates a log with an existing corpus of entries,
+// read from files, a directory, or stdin, either by writing directly to a POSIX log, or by
+// submitting them to a personality's HTTP write endpoint.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/transparency-dev/tessera/api"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageDir       = flag.String("storage_dir", "", "If set, root directory of a POSIX log to add entries to directly. The log must not be in use by any other process.")
+	privKeyFile      = flag.String("private_key", "", "Location of the log's private key file, used with --storage_dir. If unset, uses the contents of the LOG_PRIVATE_KEY environment variable.")
+	writeURL         = flag.String("write_url", "", "If set, URL of a personality's write endpoint, e.g. http://localhost:2025/add, to POST entries to.")
+	format           = flag.String("format", formatFile, "Format of the input, either \"file\" to read each file as one entry, or \"stream\" to read each file, or stdin, as a sequence of entries each prefixed with a big-endian uint16 length.")
+	parallelism      = flag.Uint("parallelism", 64, "Maximum number of entries to be adding at once.")
+	maxEntrySize     = flag.Int("max_entry_size", api.MaxEntrySize, "Size, in bytes, of the largest entry to load. Larger entries stop the load with an error.")
+	stateFile        = flag.String("state_file", "", "If set, file in which to record progress through the input, so that an interrupted load can be resumed by running the tool again with the same input.")
+	progressInterval = flag.Duration("progress_interval", 10*time.Second, "How often to report progress, and update --state_file.")
+	timeout          = flag.Duration("timeout", time.Minute, "Maximum time to spend adding each entry, including retries.")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [path...]\n\nEach path is a file, a directory to read all files from, or - for stdin. If no paths are given, stdin is read.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if (*storageDir == "") == (*writeURL == "") {
+		klog.Exit("Exactly one of --storage_dir or --write_url must be set")
+	}
+	if *format != formatFile && *format != formatStream {
+		klog.Exitf("Invalid --format %q", *format)
+	}
+	if *parallelism == 0 {
+		klog.Exit("--parallelism must be positive")
+	}
+
+	var skip uint64
+	if *stateFile != "" {
+		var err error
+		if skip, err = readState(*stateFile); err != nil {
+			klog.Exitf("Failed to read --state_file: %v", err)
+		}
+		if skip > 0 {
+			klog.Infof("Resuming after the first %d entries of the input", skip)
+		}
+	}
+
+	var s sink
+	if *storageDir != "" {
+		d, err := newDriverSink(ctx, *storageDir, getSignerOrDie())
+		if err != nil {
+			klog.Exitf("Failed to open log: %v", err)
+		}
+		s = d
+	} else {
+		s = &httpSink{url: *writeURL, c: &http.Client{Timeout: *timeout}}
+	}
+
+	t := newTracker(skip)
+	err := load(ctx, source{paths: flag.Args(), format: *format, maxEntrySize: *maxEntrySize}, s, t)
+	if err == nil {
+		klog.Info("All entries added, waiting for them to be integrated")
+	}
+	// Closing waits for a checkpoint including everything added so far, so a load which is stopped
+	// part way through still leaves the log in a consistent state.
+	if cErr := s.close(context.Background()); cErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to close log: %v", cErr))
+	}
+	report(t)
+	if err != nil {
+		klog.Exitf("Load failed: %v", err)
+	}
+}
+
+// load adds the entries from src to the log via s, using up to --parallelism workers.
+func load(ctx context.Context, src source, s sink, t *tracker) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	next, _, _ := t.state()
+	entries := make(chan entry, *parallelism)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		return src.read(ctx, next, entries)
+	})
+	for range *parallelism {
+		eg.Go(func() error {
+			for e := range entries {
+				idx, err := addWithRetry(ctx, s, e.data)
+				if err != nil {
+					return fmt.Errorf("failed to add entry %d of the input: %v", e.seq, err)
+				}
+				t.markDone(e.seq, idx)
+			}
+			return nil
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(*progressInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			report(t)
+		}
+	}()
+	err := eg.Wait()
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// addWithRetry adds an entry, retrying failures which may be transient until --timeout has elapsed.
+func addWithRetry(ctx context.Context, s sink, data []byte) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	return retry.DoWithData(func() (uint64, error) {
+		idx, err := s.add(ctx, data)
+		if err != nil && !errors.Is(err, errRetry) {
+			return 0, retry.Unrecoverable(err)
+		}
+		return idx, err
+	},
+		retry.Context(ctx),
+		retry.Attempts(0),
+		retry.DelayType(retry.BackOffDelay),
+		retry.MaxDelay(5*time.Second),
+		retry.LastErrorOnly(true))
+}
+
+// report logs the progress of the load, and saves it to --state_file, if set.
+func report(t *tracker) {
+	next, added, maxIndex := t.state()
+	klog.Infof("Added %d entries (%d of the input done), largest index assigned: %d", added, next, maxIndex)
+	if *stateFile != "" {
+		if err := writeState(*stateFile, next); err != nil {
+			klog.Warningf("Failed to update --state_file: %v", err)
+		}
+	}
+}
+
+// getSignerOrDie reads the log's private key from --private_key or the LOG_PRIVATE_KEY environment variable.
+func getSignerOrDie() note.Signer {
+	privKey := os.Getenv("LOG_PRIVATE_KEY")
+	if *privKeyFile != "" {
+		k, err := os.ReadFile(*privKeyFile)
+		if err != nil {
+			klog.Exitf("Unable to read private key: %v", err)
+		}
+		privKey = string(k)
+	}
+	if privKey == "" {
+		klog.Exit("Supply private key file path using --private_key or set LOG_PRIVATE_KEY environment variable")
+	}
+	s, err := note.NewSigner(strings.TrimSpace(privKey))
+	if err != nil {
+		klog.Exitf("Failed to instantiate signer: %v", err)
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// TestLoadPOSIX loads entries from a directory and from a stream into a POSIX log, and then checks
+// that the log holds exactly those entries.
+func TestLoadPOSIX(t *testing.T) {
+	ctx := t.Context()
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	want := map[string]bool{}
+	inDir := t.TempDir()
+	for i := range 20 {
+		e := fmt.Sprintf("file entry %d", i)
+		want[e] = true
+		if err := os.WriteFile(filepath.Join(inDir, fmt.Sprintf("%02d", i)), []byte(e), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	var stream []byte
+	for i := range 5 {
+		e := fmt.Sprintf("stream entry %d", i)
+		want[e] = true
+		stream = binary.BigEndian.AppendUint16(stream, uint16(len(e)))
+		stream = append(stream, e...)
+	}
+	streamFile := filepath.Join(t.TempDir(), "stream")
+	if err := os.WriteFile(streamFile, stream, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	logDir := t.TempDir()
+	// run loads src into the log, starting after the first skip entries, and returns the number of
+	// entries added.
+	run := func(src source, skip uint64) uint64 {
+		t.Helper()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		d, err := newDriverSink(ctx, logDir, s)
+		if err != nil {
+			t.Fatalf("newDriverSink: %v", err)
+		}
+		tr := newTracker(skip)
+		if err := load(ctx, src, d, tr); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if err := d.close(ctx); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+		next, added, _ := tr.state()
+		if next != skip+added {
+			t.Errorf("Got watermark %d after adding %d entries, want %d", next, added, skip+added)
+		}
+		return added
+	}
+	files := source{paths: []string{inDir}, format: formatFile, maxEntrySize: api.MaxEntrySize}
+	if got := run(files, 0); got != 20 {
+		t.Errorf("Loading files added %d entries, want 20", got)
+	}
+	if got := run(source{paths: []string{streamFile}, format: formatStream, maxEntrySize: api.MaxEntrySize}, 0); got != 5 {
+		t.Errorf("Loading stream added %d entries, want 5", got)
+	}
+	// Resuming a load which has already completed adds nothing.
+	if got := run(files, 20); got != 0 {
+		t.Errorf("Resuming a completed load added %d entries, want 0", got)
+	}
+
+	f := client.FileFetcher{Root: logDir}
+	raw, err := f.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	cp, _, _, err := client.OpenCheckpoint(raw, v.Name(), v)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	if cp.Size != uint64(len(want)) {
+		t.Fatalf("Got checkpoint size %d, want %d", cp.Size, len(want))
+	}
+	bundle, err := f.ReadEntryBundle(ctx, 0, layout.PartialTileSize(0, 0, cp.Size))
+	if err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	}
+	b := api.EntryBundle{}
+	if err := b.UnmarshalText(bundle); err != nil {
+		t.Fatalf("Invalid entry bundle: %v", err)
+	}
+	for _, e := range b.Entries {
+		if !want[string(e)] {
+			t.Errorf("Log contains unexpected or duplicate entry %q", e)
+		}
+		delete(want, string(e))
+	}
+	for e := range want {
+		t.Errorf("Log doesn't contain entry %q", e)
+	}
+}
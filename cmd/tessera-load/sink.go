@@ -0,0 +1,117 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// errRetry is wrapped by sink errors which are worth retrying, e.g. pushback from the log.
+var errRetry = errors.New("retryable")
+
+// sink adds entries to a log.
+type sink interface {
+	// add adds the entry to the log, and returns its index once it has been durably sequenced.
+	add(ctx context.Context, data []byte) (uint64, error)
+	// close waits for the added entries to be integrated, where the sink is able to, and releases
+	// any resources held by the sink.
+	close(ctx context.Context) error
+}
+
+// driverSink adds entries directly to a POSIX log via a Tessera appender.
+type driverSink struct {
+	a        *tessera.Appender
+	shutdown func(context.Context) error
+}
+
+func newDriverSink(ctx context.Context, dir string, s note.Signer) (*driverSink, error) {
+	driver, err := posix.New(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct storage: %v", err)
+	}
+	a, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(tessera.DefaultBatchMaxSize, 100*time.Millisecond))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create appender: %v", err)
+	}
+	return &driverSink{a: a, shutdown: shutdown}, nil
+}
+
+func (d *driverSink) add(ctx context.Context, data []byte) (uint64, error) {
+	idx, err := d.a.Add(ctx, tessera.NewEntry(data))()
+	if errors.Is(err, tessera.ErrPushback) {
+		return 0, fmt.Errorf("%w: %v", errRetry, err)
+	}
+	return idx.Index, err
+}
+
+func (d *driverSink) close(ctx context.Context) error {
+	return d.shutdown(ctx)
+}
+
+// httpSink adds entries by POSTing them to a personality's write endpoint, which must respond
+// with the decimal index assigned to the entry.
+type httpSink struct {
+	url string
+	c   *http.Client
+}
+
+func (h *httpSink) add(ctx context.Context, data []byte) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	rsp, err := h.c.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errRetry, err)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to read response: %v", errRetry, err)
+	}
+	switch {
+	case rsp.StatusCode == http.StatusOK:
+	case rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500:
+		return 0, fmt.Errorf("%w: %s: %q", errRetry, rsp.Status, body)
+	default:
+		return 0, fmt.Errorf("%s: %q", rsp.Status, body)
+	}
+	idx, err := strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index in response %q: %v", body, err)
+	}
+	return idx, nil
+}
+
+func (h *httpSink) close(context.Context) error {
+	h.c.CloseIdleConnections()
+	return nil
+}
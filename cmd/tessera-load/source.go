@@ -0,0 +1,172 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// formatFile reads each file as a single entry.
+	formatFile = "file"
+	// formatStream reads each file as a sequence of entries, each prefixed with its length as a
+	// big-endian uint16, i.e. the same encoding as a tlog-tiles entry bundle.
+	formatStream = "stream"
+)
+
+// entry is an entry read from the input, along with its position in the input.
+type entry struct {
+	seq  uint64
+	data []byte
+}
+
+// source reads entries, in a stable order, from a set of input paths.
+type source struct {
+	paths        []string
+	format       string
+	maxEntrySize int
+}
+
+// inputs expands the paths given on the command line into the files to read, in the order they will be read.
+//
+// Directories are walked recursively, and the files within them are read in lexical order of their paths,
+// so that the order of entries is the same each time the tool is run against the same input.
+func (s source) inputs() ([]string, error) {
+	if len(s.paths) == 0 {
+		return []string{"-"}, nil
+	}
+	r := []string{}
+	for _, p := range s.paths {
+		if p == "-" {
+			r = append(r, p)
+			continue
+		}
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			r = append(r, p)
+			continue
+		}
+		files := []string{}
+		if err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		r = append(r, files...)
+	}
+	return r, nil
+}
+
+// read sends the entries in the input to out, starting from the entry at position skip, and closes out
+// once they have all been sent.
+func (s source) read(ctx context.Context, skip uint64, out chan<- entry) error {
+	defer close(out)
+
+	inputs, err := s.inputs()
+	if err != nil {
+		return fmt.Errorf("failed to list inputs: %v", err)
+	}
+	var seq uint64
+	send := func(data []byte) error {
+		defer func() { seq++ }()
+		if seq < skip {
+			return nil
+		}
+		if len(data) > s.maxEntrySize {
+			return fmt.Errorf("entry %d is %d bytes, larger than the maximum of %d", seq, len(data), s.maxEntrySize)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- entry{seq: seq, data: data}:
+			return nil
+		}
+	}
+
+	for _, in := range inputs {
+		if s.format == formatFile {
+			if in == "-" {
+				return errors.New("stdin can only be read in the stream format")
+			}
+			// Avoid reading files which have already been loaded.
+			if seq < skip {
+				seq++
+				continue
+			}
+			data, err := os.ReadFile(in)
+			if err != nil {
+				return err
+			}
+			if err := send(data); err != nil {
+				return fmt.Errorf("%s: %v", in, err)
+			}
+			continue
+		}
+
+		if err := s.readStream(in, send); err != nil {
+			return fmt.Errorf("%s: %v", in, err)
+		}
+	}
+	return nil
+}
+
+// readStream calls send with each length-prefixed entry in the named file, or stdin if the name is "-".
+func (s source) readStream(name string, send func([]byte) error) error {
+	var f io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		f = file
+	}
+	r := bufio.NewReader(f)
+	var l [2]byte
+	for {
+		if _, err := io.ReadFull(r, l[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read entry length: %v", err)
+		}
+		data := make([]byte, binary.BigEndian.Uint16(l[:]))
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("failed to read entry of %d bytes: %v", len(data), err)
+		}
+		if err := send(data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tracker keeps track of which entries from the input have been added to the log.
+//
+// Entries are added in parallel, so may complete out of order. The tracker maintains a watermark
+// below which every entry has been added, and it's this which is saved so that a later run can
+// resume from it.
+type tracker struct {
+	mu sync.Mutex
+	// next is the position in the input of the first entry not known to have been added.
+	next uint64
+	// done holds the positions of entries at or beyond next which have been added.
+	done map[uint64]bool
+	// added is the number of entries added by this run.
+	added uint64
+	// maxIndex is the largest log index assigned to an entry by this run.
+	maxIndex uint64
+}
+
+func newTracker(next uint64) *tracker {
+	return &tracker{next: next, done: map[uint64]bool{}}
+}
+
+// markDone records that the entry at position seq in the input has been assigned index idx in the log.
+func (t *tracker) markDone(seq, idx uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.added++
+	t.maxIndex = max(t.maxIndex, idx)
+	t.done[seq] = true
+	for t.done[t.next] {
+		delete(t.done, t.next)
+		t.next++
+	}
+}
+
+// state returns the watermark, the number of entries added by this run, and the largest index assigned.
+func (t *tracker) state() (next, added, maxIndex uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.next, t.added, t.maxIndex
+}
+
+// readState returns the watermark saved in the state file at path, or zero if there isn't one.
+func readState(path string) (uint64, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid state in %q: %v", path, err)
+	}
+	return n, nil
+}
+
+// writeState atomically saves the watermark to the state file at path.
+func writeState(path string, next uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := fmt.Fprintf(tmp, "%d\n", next); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/transparency-dev/tessera/api/layout"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	logContType      = "application/octet-stream"
+	ckptContType     = "text/plain; charset=utf-8"
+	logCacheControl  = "max-age=604800,immutable"
+	ckptCacheControl = "no-cache"
+)
+
+// bucket is a destination for the resources of a log.
+type bucket interface {
+	// readCheckpoint returns the checkpoint currently published in the bucket, or an error wrapping
+	// os.ErrNotExist if there isn't one.
+	readCheckpoint(ctx context.Context) ([]byte, error)
+	// writeCheckpoint overwrites the checkpoint published in the bucket.
+	writeCheckpoint(ctx context.Context, data []byte) error
+	// writeOnce stores an immutable resource. It must fail, rather than overwrite the resource, if different
+	// data is already stored at p, but succeed if identical data is.
+	writeOnce(ctx context.Context, p string, data []byte) error
+}
+
+// gcsBucket publishes to a GCS bucket.
+type gcsBucket struct {
+	bkt    *gcs.BucketHandle
+	prefix string
+}
+
+func (b gcsBucket) readCheckpoint(ctx context.Context) ([]byte, error) {
+	r, err := b.bkt.Object(path.Join(b.prefix, layout.CheckpointPath)).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%v: %w", err, os.ErrNotExist)
+		}
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
+}
+
+func (b gcsBucket) writeCheckpoint(ctx context.Context, data []byte) error {
+	w := b.bkt.Object(path.Join(b.prefix, layout.CheckpointPath)).NewWriter(ctx)
+	w.ContentType = ckptContType
+	w.CacheControl = ckptCacheControl
+	return b.write(w, data)
+}
+
+func (b gcsBucket) writeOnce(ctx context.Context, p string, data []byte) error {
+	obj := b.bkt.Object(path.Join(b.prefix, p))
+	w := obj.If(gcs.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	w.ContentType = logContType
+	w.CacheControl = logCacheControl
+	err := b.write(w, data)
+	var ee *googleapi.Error
+	if errors.As(err, &ee) && ee.Code == http.StatusPreconditionFailed {
+		r, err := obj.NewReader(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read existing object %q: %v", p, err)
+		}
+		defer func() { _ = r.Close() }()
+		existing, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read existing object %q: %v", p, err)
+		}
+		return sameContent(p, existing, data)
+	}
+	return err
+}
+
+func (b gcsBucket) write(w *gcs.Writer, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %q: %w", w.Name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close write on %q: %w", w.Name, err)
+	}
+	return nil
+}
+
+// s3Bucket publishes to an S3 bucket.
+type s3Bucket struct {
+	c      *s3.Client
+	bucket string
+	prefix string
+}
+
+func (b s3Bucket) readCheckpoint(ctx context.Context) ([]byte, error) {
+	return b.get(ctx, path.Join(b.prefix, layout.CheckpointPath))
+}
+
+func (b s3Bucket) get(ctx context.Context, key string) ([]byte, error) {
+	r, err := b.c.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var nske *types.NoSuchKey
+		if errors.As(err, &nske) {
+			return nil, fmt.Errorf("%v: %w", err, os.ErrNotExist)
+		}
+		return nil, err
+	}
+	defer func() { _ = r.Body.Close() }()
+	return io.ReadAll(r.Body)
+}
+
+func (b s3Bucket) writeCheckpoint(ctx context.Context, data []byte) error {
+	_, err := b.c.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(b.bucket),
+		Key:          aws.String(path.Join(b.prefix, layout.CheckpointPath)),
+		Body:         bytes.NewReader(data),
+		ContentType:  aws.String(ckptContType),
+		CacheControl: aws.String(ckptCacheControl),
+	})
+	return err
+}
+
+func (b s3Bucket) writeOnce(ctx context.Context, p string, data []byte) error {
+	key := path.Join(b.prefix, p)
+	_, err := b.c.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(b.bucket),
+		Key:          aws.String(key),
+		Body:         bytes.NewReader(data),
+		ContentType:  aws.String(logContType),
+		CacheControl: aws.String(logCacheControl),
+		IfNoneMatch:  aws.String("*"),
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+		existing, err := b.get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read existing object %q: %v", p, err)
+		}
+		return sameContent(p, existing, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %q: %v", key, err)
+	}
+	return nil
+}
+
+// dirBucket publishes to a local directory, e.g. one which is synced to a CDN by other means, or for
+// staging and testing.
+type dirBucket struct {
+	root string
+}
+
+func (b dirBucket) readCheckpoint(_ context.Context) ([]byte, error) {
+	return os.ReadFile(filepath.Join(b.root, layout.CheckpointPath))
+}
+
+func (b dirBucket) writeCheckpoint(_ context.Context, data []byte) error {
+	p := filepath.Join(b.root, layout.CheckpointPath)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (b dirBucket) writeOnce(_ context.Context, p string, data []byte) error {
+	fp := filepath.Join(b.root, p)
+	if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file and then hard link it into place, which fails if the resource already
+	// exists, so that an interrupted write never leaves a truncated resource behind.
+	f, err := os.CreateTemp(filepath.Dir(fp), ".publish-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Link(f.Name(), fp); errors.Is(err, os.ErrExist) {
+		existing, err := os.ReadFile(fp)
+		if err != nil {
+			return err
+		}
+		return sameContent(p, existing, data)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// sameContent returns an error if the data already stored at p differs from the data to be written,
+// which means that something other than this tool has written to the destination.
+func sameContent(p string, existing, data []byte) error {
+	if !bytes.Equal(existing, data) {
+		return fmt.Errorf("resource %q already exists in destination with different content", p)
+	}
+	return nil
+}
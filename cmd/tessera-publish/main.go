@@ -0,0 +1,136 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// tessera-publish is a command-line tool which incrementally publishes a log stored on a POSIX
+// filesystem to a GCS or S3 bucket, so that a log can be integrated locally and served from a
+// bucket or CDN.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/client"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageDir        = flag.String("storage_dir", "", "Root directory of the POSIX log to publish.")
+	destination       = flag.String("destination", "", "Where to publish the log, one of gs://<bucket>[/<prefix>], s3://<bucket>[/<prefix>], or file://<dir>.")
+	pubKey            = flag.String("public_key", "", "Path to a file containing the log's public key, used to verify checkpoints.")
+	origin            = flag.String("origin", "", "Origin of the log, if unset, will use the name of the provided public key.")
+	numWorkers        = flag.Uint("num_workers", 30, "Number of resources to upload concurrently.")
+	interval          = flag.Duration("interval", 0, "If set, keep publishing new checkpoints with this interval between attempts, rather than exiting after publishing once.")
+	s3Endpoint        = flag.String("s3_endpoint", "", "Endpoint for custom non-AWS S3 service")
+	s3AccessKeyID     = flag.String("s3_access_key", "", "Access key ID for custom non-AWS S3 service")
+	s3SecretAccessKey = flag.String("s3_secret", "", "Secret access key for custom non-AWS S3 service")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	if *storageDir == "" {
+		klog.Exit("Missing parameter: --storage_dir")
+	}
+	if *pubKey == "" {
+		klog.Exit("Missing parameter: --public_key")
+	}
+	b, err := os.ReadFile(*pubKey)
+	if err != nil {
+		klog.Exitf("Failed to read verifier from %q: %v", *pubKey, err)
+	}
+	v, err := f_note.NewVerifier(strings.TrimSpace(string(b)))
+	if err != nil {
+		klog.Exitf("Invalid verifier in %q: %v", *pubKey, err)
+	}
+	if *origin == "" {
+		*origin = v.Name()
+	}
+	dst, err := bucketFromFlags(ctx)
+	if err != nil {
+		klog.Exitf("Invalid --destination %q: %v", *destination, err)
+	}
+
+	s := &syncer{
+		src:        client.FileFetcher{Root: *storageDir},
+		dst:        dst,
+		v:          v,
+		origin:     *origin,
+		numWorkers: *numWorkers,
+	}
+	for {
+		start := time.Now()
+		if err := s.sync(ctx); err != nil {
+			if *interval == 0 {
+				klog.Exitf("Failed to publish log: %v", err)
+			}
+			klog.Errorf("Failed to publish log: %v", err)
+		} else {
+			klog.V(1).Infof("Publish took %s, %d resources uploaded in total", time.Since(start), s.uploaded.Load())
+		}
+		if *interval == 0 {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// bucketFromFlags returns the bucket described by the --destination flag.
+func bucketFromFlags(ctx context.Context) (bucket, error) {
+	u, err := url.Parse(*destination)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "gs":
+		c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS client: %v", err)
+		}
+		return gcsBucket{bkt: c.Bucket(u.Host), prefix: prefix}, nil
+	case "s3":
+		var opts []func(*s3.Options)
+		cfg := aws.Config{}
+		if *s3Endpoint != "" {
+			const defaultRegion = "us-east-1"
+			cfg.Region = defaultRegion
+			opts = append(opts, func(o *s3.Options) {
+				o.BaseEndpoint = aws.String(*s3Endpoint)
+				o.Credentials = credentials.NewStaticCredentialsProvider(*s3AccessKeyID, *s3SecretAccessKey, "")
+				o.UsePathStyle = true
+			})
+		} else if cfg, err = config.LoadDefaultConfig(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %v", err)
+		}
+		return s3Bucket{c: s3.NewFromConfig(cfg, opts...), bucket: u.Host, prefix: prefix}, nil
+	case "file":
+		return dirBucket{root: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
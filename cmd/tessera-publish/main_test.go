@@ -0,0 +1,157 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/verify"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+func TestBucketFromFlags(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		destination string
+		s3Endpoint  string
+		want        bucket
+		wantErr     bool
+	}{
+		{
+			name:        "file",
+			destination: "file:///srv/log",
+			want:        dirBucket{root: "/srv/log"},
+		}, {
+			name:        "s3 with prefix",
+			destination: "s3://my-bucket/some/prefix/",
+			s3Endpoint:  "http://localhost:9000",
+			want:        s3Bucket{bucket: "my-bucket", prefix: "some/prefix"},
+		}, {
+			name:        "s3 without prefix",
+			destination: "s3://my-bucket",
+			s3Endpoint:  "http://localhost:9000",
+			want:        s3Bucket{bucket: "my-bucket"},
+		}, {
+			name:        "unsupported scheme",
+			destination: "ftp://example.com/log",
+			wantErr:     true,
+		}, {
+			name:        "missing scheme",
+			destination: "/srv/log",
+			wantErr:     true,
+		}, {
+			name:        "invalid URL",
+			destination: "s3://my bucket/%zz",
+			wantErr:     true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			setFlag(t, destination, test.destination)
+			setFlag(t, s3Endpoint, test.s3Endpoint)
+			got, err := bucketFromFlags(t.Context())
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("bucketFromFlags: got err %v, want err %t", err, test.wantErr)
+			}
+			if s3, ok := got.(s3Bucket); ok {
+				if s3.c == nil {
+					t.Error("bucketFromFlags: S3 client not set")
+				}
+				s3.c = nil
+				got = s3
+			}
+			if got != test.want {
+				t.Errorf("bucketFromFlags: got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+// setFlag sets the value of a flag for the duration of the test.
+func setFlag(t *testing.T, f *string, v string) {
+	t.Helper()
+	old := *f
+	*f = v
+	t.Cleanup(func() { *f = old })
+}
+
+func TestSync(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 10*time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	dst := t.TempDir()
+	s := &syncer{
+		src:        client.FileFetcher{Root: tl.Root},
+		dst:        dirBucket{root: dst},
+		v:          tl.SigVerifier,
+		origin:     tl.SigVerifier.Name(),
+		numWorkers: 4,
+	}
+
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 10*time.Millisecond)
+	var size uint64
+	for _, n := range []int{300, 10, 0} {
+		t.Run(fmt.Sprintf("add %d", n), func(t *testing.T) {
+			futures := make([]tessera.IndexFuture, 0, n)
+			for i := range n {
+				futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", size+uint64(i)))))
+			}
+			for _, f := range futures {
+				idx, _, err := awaiter.Await(ctx, f)
+				if err != nil {
+					t.Fatalf("Await: %v", err)
+				}
+				size = max(size, idx.Index+1)
+			}
+			before := s.uploaded.Load()
+			if err := s.sync(ctx); err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			if n == 0 && s.uploaded.Load() != before {
+				t.Errorf("sync uploaded %d resources when the destination was up to date", s.uploaded.Load()-before)
+			}
+
+			published := client.FileFetcher{Root: dst}
+			dstCP, err := published.ReadCheckpoint(ctx)
+			if err != nil {
+				t.Fatalf("Failed to read published checkpoint: %v", err)
+			}
+			cp, _, _, err := client.OpenCheckpoint(dstCP, tl.SigVerifier.Name(), tl.SigVerifier)
+			if err != nil {
+				t.Fatalf("OpenCheckpoint: %v", err)
+			}
+			if cp.Size < size {
+				t.Fatalf("Published checkpoint has size %d, want at least %d", cp.Size, size)
+			}
+			if err := verify.Root(ctx, published.ReadTile, cp.Size, cp.Hash); err != nil {
+				t.Errorf("Published tiles don't match the published checkpoint: %v", err)
+			}
+			for c := range verify.NewTiles(0, cp.Size) {
+				if _, _, err := verify.Tile(ctx, published, c.Level, c.Index, c.Partial); err != nil {
+					t.Errorf("Published tile %v: %v", c, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/verify"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+// syncer copies the resources of a local log to a bucket.
+type syncer struct {
+	src        client.Fetcher
+	dst        bucket
+	v          note.Verifier
+	origin     string
+	numWorkers uint
+
+	uploaded atomic.Uint64
+}
+
+// sync copies any resources committed to by the source log's checkpoint which are not yet committed to by
+// the destination's checkpoint.
+//
+// Before anything is uploaded, the source checkpoint's signature is verified, its root hash is checked
+// against the tiles, and the tiles are proven to be consistent with the checkpoint already in the bucket.
+// Each tile and entry bundle is then checked against the resources below it as it's uploaded, and the
+// checkpoint is only uploaded once everything it commits to is in place.
+func (s *syncer) sync(ctx context.Context) error {
+	srcRaw, err := s.src.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read source checkpoint: %v", err)
+	}
+	srcCP, _, _, err := client.OpenCheckpoint(srcRaw, s.origin, s.v)
+	if err != nil {
+		return fmt.Errorf("invalid source checkpoint: %v", err)
+	}
+	dstCP := &log.Checkpoint{}
+	dstRaw, err := s.dst.readCheckpoint(ctx)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		klog.Info("No checkpoint in destination, publishing from scratch")
+	case err != nil:
+		return fmt.Errorf("failed to read destination checkpoint: %v", err)
+	default:
+		if dstCP, _, _, err = client.OpenCheckpoint(dstRaw, s.origin, s.v); err != nil {
+			return fmt.Errorf("invalid destination checkpoint: %v", err)
+		}
+	}
+
+	switch {
+	case dstCP.Size > srcCP.Size:
+		return fmt.Errorf("destination checkpoint size %d is larger than source checkpoint size %d", dstCP.Size, srcCP.Size)
+	case dstCP.Size == srcCP.Size && dstCP.Size > 0:
+		if !bytes.Equal(dstCP.Hash, srcCP.Hash) {
+			return fmt.Errorf("destination and source checkpoints have the same size %d but different root hashes", srcCP.Size)
+		}
+		if bytes.Equal(dstRaw, srcRaw) {
+			klog.V(1).Infof("Destination is up to date at size %d", srcCP.Size)
+			return nil
+		}
+		// Same tree, but e.g. a fresh timestamp or new cosignatures, so there's nothing to do but publish it.
+		return s.dst.writeCheckpoint(ctx, srcRaw)
+	}
+
+	if err := verify.Root(ctx, s.src.ReadTile, srcCP.Size, srcCP.Hash); err != nil {
+		return fmt.Errorf("source checkpoint doesn't match tiles: %v", err)
+	}
+	if err := verify.Consistency(ctx, s.src.ReadTile, dstCP.Size, srcCP.Size, dstCP.Hash, srcCP.Hash); err != nil {
+		return fmt.Errorf("source checkpoint is not consistent with destination checkpoint: %v", err)
+	}
+
+	klog.Infof("Publishing from size %d to %d", dstCP.Size, srcCP.Size)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(int(max(s.numWorkers, 1)))
+	for c := range verify.NewTiles(dstCP.Size, srcCP.Size) {
+		g.Go(func() error {
+			return s.copy(gctx, c)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if err := s.dst.writeCheckpoint(ctx, srcRaw); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	klog.Infof("Published checkpoint for size %d", srcCP.Size)
+	return nil
+}
+
+// copy uploads the tile at the given coordinates, along with its entry bundle if it's a level zero tile,
+// once it has been checked against the resources below it.
+func (s *syncer) copy(ctx context.Context, c verify.TileCoords) error {
+	tile, bundle, err := verify.Tile(ctx, s.src, c.Level, c.Index, c.Partial)
+	if err != nil {
+		return err
+	}
+	if err := s.dst.writeOnce(ctx, layout.TilePath(c.Level, c.Index, c.Partial), tile); err != nil {
+		return err
+	}
+	s.uploaded.Add(1)
+	if bundle != nil {
+		if err := s.dst.writeOnce(ctx, layout.EntriesPath(c.Index, c.Partial), bundle); err != nil {
+			return err
+		}
+		s.uploaded.Add(1)
+	}
+	return nil
+}
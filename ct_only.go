@@ -176,6 +176,19 @@ func copyUint24LengthPrefixed(from *cryptobyte.String, to *cryptobyte.Builder) b
 
 // ctMerkleLeafHasher knows how to calculate RFC6962 Merkle leaf hashes for entries in a Static-CT formatted entry bundle.
 func ctMerkleLeafHasher(bundle []byte) ([][]byte, error) {
+	leaves, err := ctMerkleTreeLeaves(bundle)
+	if err != nil {
+		return nil, err
+	}
+	r := make([][]byte, 0, len(leaves))
+	for _, l := range leaves {
+		r = append(r, rfc6962.DefaultHasher.HashLeaf(l))
+	}
+	return r, nil
+}
+
+// ctMerkleTreeLeaves reconstructs the RFC6962 MerkleTreeLeaf structures for entries in a Static-CT formatted entry bundle.
+func ctMerkleTreeLeaves(bundle []byte) ([][]byte, error) {
 	r := make([][]byte, 0, layout.EntryBundleWidth)
 	b := cryptobyte.String(bundle)
 	for i := 0; i < layout.EntryBundleWidth && !b.Empty(); i++ {
@@ -228,8 +241,7 @@ func ctMerkleLeafHasher(bundle []byte) ([][]byte, error) {
 			return nil, fmt.Errorf("failed to read chain fingerprints at entry index %d of bundle", i)
 		}
 
-		h := rfc6962.DefaultHasher.HashLeaf(preimage.BytesOrPanic())
-		r = append(r, h)
+		r = append(r, preimage.BytesOrPanic())
 	}
 	if !b.Empty() {
 		return nil, fmt.Errorf("unexpected %d bytes of trailing data in entry bundle", len(b))
@@ -0,0 +1,231 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/otel"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+var (
+	distributorPushes metric.Int64Counter
+	distributorSize   metric.Int64Gauge
+)
+
+func init() {
+	var err error
+
+	distributorPushes, err = meter.Int64Counter(
+		"tessera.distributor.pushes",
+		metric.WithDescription("Number of attempts to push a published checkpoint to a distributor"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		klog.Exitf("Failed to create distributorPushes metric: %v", err)
+	}
+
+	distributorSize, err = meter.Int64Gauge(
+		"tessera.distributor.size",
+		metric.WithDescription("Size of the latest checkpoint successfully pushed to a distributor"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create distributorSize metric: %v", err)
+	}
+}
+
+// ErrCheckpointConflict is returned by a CheckpointDistributor when the destination has rejected a
+// checkpoint because it conflicts with, or is older than, a checkpoint it already has.
+//
+// Pushing the same checkpoint again can't succeed, so it isn't retried.
+var ErrCheckpointConflict = errors.New("distributor rejected checkpoint as conflicting")
+
+// CheckpointDistributor knows how to make published checkpoints available at some location
+// other than the log itself, e.g. a checkpoint distributor service, or a static mirror.
+type CheckpointDistributor interface {
+	// Name returns a human readable identifier for this destination, used in logs and metrics.
+	Name() string
+	// Distribute pushes the provided checkpoint, including any cosignatures, to the destination.
+	//
+	// Errors which wrap ErrCheckpointConflict indicate that the destination will never accept the checkpoint.
+	Distribute(ctx context.Context, cp []byte) error
+}
+
+// NewCheckpointDistributor returns a CheckpointDistributor which calls the provided function to push checkpoints.
+//
+// This is a convenient way to plug in destinations such as object storage buckets.
+func NewCheckpointDistributor(name string, f func(ctx context.Context, cp []byte) error) CheckpointDistributor {
+	return funcDistributor{name: name, f: f}
+}
+
+type funcDistributor struct {
+	name string
+	f    func(ctx context.Context, cp []byte) error
+}
+
+func (d funcDistributor) Name() string {
+	return d.name
+}
+
+func (d funcDistributor) Distribute(ctx context.Context, cp []byte) error {
+	return d.f(ctx, cp)
+}
+
+// NewHTTPDistributor returns a CheckpointDistributor which POSTs checkpoints to the provided URL.
+//
+// If client is nil, http.DefaultClient will be used.
+func NewHTTPDistributor(u *url.URL, client *http.Client) CheckpointDistributor {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpDistributor{url: u, client: client}
+}
+
+type httpDistributor struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func (d httpDistributor) Name() string {
+	return d.url.String()
+}
+
+func (d httpDistributor) Distribute(ctx context.Context, cp []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url.String(), bytes.NewReader(cp))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post checkpoint: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read body: %v", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		// The distributor already has a newer or conflicting view, this isn't something we can fix by retrying.
+		return fmt.Errorf("%w: %s", ErrCheckpointConflict, body)
+	default:
+		return fmt.Errorf("got non-OK status %d: %s", resp.StatusCode, body)
+	}
+}
+
+// distributeCheckpoints polls the log's published checkpoint, and pushes any new checkpoint to
+// each of the provided destinations. Checkpoints received from published, which may be nil, are
+// pushed straight away rather than waiting for the next poll.
+//
+// Destinations are updated independently of one another, so a slow or failing destination will
+// not hold up the others. Failed pushes are retried on the next poll, unless the destination
+// rejected the checkpoint as conflicting.
+//
+// This is a long running function, exiting only when the provided context is done.
+func distributeCheckpoints(ctx context.Context, readCheckpoint func(ctx context.Context) ([]byte, error), published <-chan PublishedCheckpoint, pollPeriod time.Duration, dests []CheckpointDistributor) {
+	// Each destination has a worker which is offered the latest checkpoint via a single-slot channel.
+	offers := make([]chan []byte, 0, len(dests))
+	for _, d := range dests {
+		c := make(chan []byte, 1)
+		offers = append(offers, c)
+		go distributeTo(ctx, d, c)
+	}
+
+	t := time.NewTicker(pollPeriod)
+	defer t.Stop()
+	for {
+		var cp []byte
+		select {
+		case <-ctx.Done():
+			return
+		case p, ok := <-published:
+			if !ok {
+				// Stop selecting on the closed channel, and carry on polling.
+				published = nil
+				continue
+			}
+			cp = p.Checkpoint
+		case <-t.C:
+			var err error
+			cp, err = readCheckpoint(ctx)
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					klog.Warningf("distributeCheckpoints: readCheckpoint: %v", err)
+				}
+				continue
+			}
+		}
+		for _, c := range offers {
+			// Replace any checkpoint the worker hasn't yet picked up, it's stale now.
+			select {
+			case <-c:
+			default:
+			}
+			c <- cp
+		}
+	}
+}
+
+// distributeTo pushes checkpoints received on the provided channel to d, skipping any which
+// have already been successfully pushed or rejected as conflicting.
+func distributeTo(ctx context.Context, d CheckpointDistributor, cps <-chan []byte) {
+	attrs := []attribute.KeyValue{distributorNameKey.String(d.Name())}
+	var last []byte
+	for {
+		var cp []byte
+		select {
+		case <-ctx.Done():
+			return
+		case cp = <-cps:
+		}
+		// Checkpoints may change without the size changing, e.g. if a new cosignature is added,
+		// so compare the whole thing.
+		if bytes.Equal(cp, last) {
+			continue
+		}
+		_, size, _, err := parse.CheckpointUnsafe(cp)
+		if err != nil {
+			klog.Warningf("distributeTo: invalid checkpoint: %v", err)
+			continue
+		}
+		if err := d.Distribute(ctx, cp); errors.Is(err, ErrCheckpointConflict) {
+			// Retrying won't help, so remember the checkpoint to avoid pushing it again on every poll.
+			klog.Warningf("Distributor %q rejected checkpoint of size %d, not retrying: %v", d.Name(), size, err)
+			distributorPushes.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("error.type", "conflict"))...))
+			last = cp
+			continue
+		} else if err != nil {
+			klog.Warningf("Failed to distribute checkpoint of size %d to %q: %v", size, d.Name(), err)
+			distributorPushes.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("error.type", "failed"))...))
+			continue
+		}
+		distributorPushes.Add(ctx, 1, metric.WithAttributes(attrs...))
+		distributorSize.Record(ctx, otel.Clamp64(size), metric.WithAttributes(attrs...))
+		last = cp
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDistributeCheckpoints(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var size atomic.Uint64
+	readCP := func(_ context.Context) ([]byte, error) {
+		return fmt.Appendf(nil, "example.com/log\n%d\nAAAA\n\n— example.com/log sig\n", size.Load()), nil
+	}
+
+	var mu sync.Mutex
+	got := map[string][]string{}
+	record := func(name string) func(context.Context, []byte) error {
+		return func(_ context.Context, cp []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[name] = append(got[name], string(cp))
+			return nil
+		}
+	}
+	// flaky fails the first push it sees, so we can check that it's retried.
+	var flakyCalls atomic.Int32
+	flaky := func(ctx context.Context, cp []byte) error {
+		if flakyCalls.Add(1) == 1 {
+			return errors.New("flake")
+		}
+		return record("flaky")(ctx, cp)
+	}
+
+	// conflicting rejects every checkpoint, so we can check that rejected checkpoints aren't retried.
+	conflicting := func(ctx context.Context, cp []byte) error {
+		_ = record("conflicting")(ctx, cp)
+		return fmt.Errorf("%w: have a newer checkpoint", ErrCheckpointConflict)
+	}
+
+	go distributeCheckpoints(ctx, readCP, nil, 5*time.Millisecond, []CheckpointDistributor{
+		NewCheckpointDistributor("good", record("good")),
+		NewCheckpointDistributor("flaky", flaky),
+		NewCheckpointDistributor("conflicting", conflicting),
+	})
+
+	waitFor := func(name string, n int) {
+		t.Helper()
+		for {
+			mu.Lock()
+			l := len(got[name])
+			mu.Unlock()
+			if l >= n {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				t.Fatalf("Timed out waiting for %q", name)
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+	waitFor("good", 1)
+	waitFor("flaky", 1)
+	size.Store(10)
+	waitFor("good", 2)
+	waitFor("flaky", 2)
+	waitFor("conflicting", 2)
+	// Give the loop a chance to (incorrectly) push duplicates.
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, cps := range got {
+		if len(cps) != 2 {
+			t.Errorf("%q: got %d pushes, want 2: %q", name, len(cps), cps)
+		}
+	}
+}
+
+func TestDistributeCheckpointsPublished(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	readCP := func(context.Context) ([]byte, error) {
+		t.Error("Checkpoint was read from storage")
+		return nil, errors.New("unexpected read")
+	}
+	got := make(chan string, 1)
+	published := make(chan PublishedCheckpoint, 1)
+	// The poll period is long enough that checkpoints can only be distributed as they're published.
+	go distributeCheckpoints(ctx, readCP, published, time.Hour, []CheckpointDistributor{
+		NewCheckpointDistributor("good", func(_ context.Context, cp []byte) error {
+			got <- string(cp)
+			return nil
+		}),
+	})
+	for size := range uint64(3) {
+		cp := fmt.Sprintf("example.com/log\n%d\n%s\n", size+1, base64.StdEncoding.EncodeToString(make([]byte, 32)))
+		published <- PublishedCheckpoint{Size: size + 1, Checkpoint: []byte(cp)}
+		select {
+		case g := <-got:
+			if g != cp {
+				t.Errorf("Got checkpoint %q, want %q", g, cp)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for checkpoint %d", size+1)
+		}
+	}
+	close(published)
+}
+
+func TestHTTPDistributor(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		status       int
+		wantErr      bool
+		wantConflict bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "conflict", status: http.StatusConflict, wantErr: true, wantConflict: true},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var gotBody string
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("Got method %q, want POST", r.Method)
+				}
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+				w.WriteHeader(test.status)
+			}))
+			defer s.Close()
+			u, err := url.Parse(s.URL)
+			if err != nil {
+				t.Fatalf("url.Parse: %v", err)
+			}
+
+			err = NewHTTPDistributor(u, s.Client()).Distribute(t.Context(), []byte("checkpoint"))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Distribute: got err %v, want err %t", err, test.wantErr)
+			}
+			if gotConflict := errors.Is(err, ErrCheckpointConflict); gotConflict != test.wantConflict {
+				t.Errorf("Distribute: got err %v, want ErrCheckpointConflict %t", err, test.wantConflict)
+			}
+			if gotBody != "checkpoint" {
+				t.Errorf("Got body %q, want %q", gotBody, "checkpoint")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"net/http"
+)
+
+// DriverOptions holds settings which are common to all storage drivers.
+//
+// Each driver's constructor accepts functional options of a type specific to that driver, and
+// provides a WithDriverOptions option which applies DriverOptions. This allows cross-cutting settings
+// to be configured in the same way regardless of the driver in use, while driver-specific settings
+// live alongside the driver. Only the options are shared: the constructors' required arguments and
+// return types still differ between drivers.
+//
+// The zero value is ready to use, and results in default behaviour.
+type DriverOptions struct {
+	httpClient     *http.Client
+	objectMetadata map[ResourceClass]ObjectMetadata
+}
+
+// DriverOption configures settings which are common to all storage drivers.
+type DriverOption func(*DriverOptions)
+
+// WithHTTPClient sets the HTTP client used by the driver for outbound requests, e.g. to witnesses.
+//
+// If this option isn't provided, http.DefaultClient is used.
+func WithHTTPClient(c *http.Client) DriverOption {
+	return func(o *DriverOptions) {
+		o.httpClient = c
+	}
+}
+
+// ResourceClass identifies a kind of resource which storage drivers write for a log.
+type ResourceClass int
+
+const (
+	// ResourceCheckpoint is the log's checkpoint, which is overwritten each time it's published.
+	ResourceCheckpoint ResourceClass = iota
+	// ResourceTile is a full or partial tile, which is immutable once written.
+	ResourceTile
+	// ResourceEntryBundle is a full or partial entry bundle, which is immutable once written.
+	ResourceEntryBundle
+)
+
+// ObjectMetadata is the metadata attached to a resource when a driver uploads it to an object store.
+//
+// Empty fields are set to the driver's defaults, which are suitable for serving the log directly from
+// the bucket.
+type ObjectMetadata struct {
+	// ContentType is the value of the Content-Type header served with the resource.
+	ContentType string
+	// CacheControl is the value of the Cache-Control header served with the resource.
+	CacheControl string
+	// Custom is additional user-defined metadata to attach to the resource.
+	Custom map[string]string
+}
+
+// WithObjectMetadata sets the metadata attached to resources of the given class when they're uploaded,
+// e.g. to set caching semantics appropriate to a CDN fronting the bucket.
+//
+// This is only supported by drivers which store resources as objects, i.e. the GCP and AWS drivers, and
+// is ignored by others. Changing the metadata doesn't affect resources which have already been written.
+func WithObjectMetadata(class ResourceClass, md ObjectMetadata) DriverOption {
+	return func(o *DriverOptions) {
+		if o.objectMetadata == nil {
+			o.objectMetadata = make(map[ResourceClass]ObjectMetadata)
+		}
+		o.objectMetadata[class] = md
+	}
+}
+
+// Apply applies the provided options.
+func (o *DriverOptions) Apply(opts ...DriverOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// HTTPClient returns the HTTP client which the driver should use for outbound requests.
+func (o DriverOptions) HTTPClient() *http.Client {
+	if o.httpClient == nil {
+		return http.DefaultClient
+	}
+	return o.httpClient
+}
+
+// ObjectMetadata returns the metadata which the driver should attach to resources of the given class,
+// using the values in defaults for any which haven't been configured.
+func (o DriverOptions) ObjectMetadata(class ResourceClass, defaults ObjectMetadata) ObjectMetadata {
+	md := o.objectMetadata[class]
+	if md.ContentType == "" {
+		md.ContentType = defaults.ContentType
+	}
+	if md.CacheControl == "" {
+		md.CacheControl = defaults.CacheControl
+	}
+	if md.Custom == nil {
+		md.Custom = defaults.Custom
+	}
+	return md
+}
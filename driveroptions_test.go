@@ -0,0 +1,55 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"maps"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+func TestDriverOptions(t *testing.T) {
+	var o tessera.DriverOptions
+	if got := o.HTTPClient(); got != http.DefaultClient {
+		t.Errorf("HTTPClient() = %v, want http.DefaultClient", got)
+	}
+
+	c := &http.Client{Timeout: time.Second}
+	o.Apply(tessera.WithHTTPClient(c))
+	if got := o.HTTPClient(); got != c {
+		t.Errorf("HTTPClient() = %v, want %v", got, c)
+	}
+}
+
+func TestDriverOptionsObjectMetadata(t *testing.T) {
+	defaults := tessera.ObjectMetadata{ContentType: "text/plain", CacheControl: "no-cache"}
+	var o tessera.DriverOptions
+	if got := o.ObjectMetadata(tessera.ResourceCheckpoint, defaults); got.ContentType != "text/plain" || got.CacheControl != "no-cache" || got.Custom != nil {
+		t.Errorf("ObjectMetadata() = %+v, want defaults %+v", got, defaults)
+	}
+
+	custom := map[string]string{"log": "example"}
+	o.Apply(tessera.WithObjectMetadata(tessera.ResourceCheckpoint, tessera.ObjectMetadata{CacheControl: "max-age=5", Custom: custom}))
+	got := o.ObjectMetadata(tessera.ResourceCheckpoint, defaults)
+	if got.ContentType != "text/plain" || got.CacheControl != "max-age=5" || !maps.Equal(got.Custom, custom) {
+		t.Errorf("ObjectMetadata(ResourceCheckpoint) = %+v, want configured values over defaults", got)
+	}
+	if got := o.ObjectMetadata(tessera.ResourceTile, defaults); got.CacheControl != "no-cache" {
+		t.Errorf("ObjectMetadata(ResourceTile) = %+v, want defaults", got)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package election provides a lease based leader election helper for running a personality in an
+// active/standby configuration.
+//
+// All instances of the personality share a single Lease, which is stored in the log's coordination
+// database (see the election packages under the storage directory). Each instance runs an Election,
+// and only the instance which currently holds the lease should construct a Tessera Appender and
+// thereby sequence and integrate entries. Standby instances should either hold requests until they
+// are elected, or proxy them to the leader, e.g. by returning a 503 so that a load balancer retries
+// them elsewhere:
+//
+//	e := election.New(lease, hostname)
+//	go e.Run(ctx)
+//	lctx, err := e.Lead(ctx) // Blocks until this instance is elected.
+//	if err != nil { ... }
+//	appender, shutdown, reader, err := tessera.NewAppender(lctx, driver, opts)
+//	...
+//	<-lctx.Done() // Leadership lost, stop accepting writes and shut down.
+//
+// Leadership is time-bounded: an instance considers itself the leader only until the lease it most
+// recently acquired or renewed expires, so the clocks of all instances must be loosely synchronised
+// relative to the lease TTL.
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// DefaultTTL is the lease duration used if WithTTL is not specified.
+const DefaultTTL = 10 * time.Second
+
+// ErrLeadershipLost is the cause of the cancellation of a context returned by Election.Lead when this
+// instance no longer holds the lease.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// Lease is a named lease held in shared storage, which may be held by at most one holder at a time.
+type Lease interface {
+	// TryAcquire attempts to acquire the lease on behalf of holder, or to renew it if holder already
+	// holds it, until ttl from now.
+	//
+	// Returns true if holder holds the lease when the call returns.
+	// An expired lease may be acquired by any holder.
+	TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Release relinquishes the lease if it is currently held by holder, so that another holder may acquire
+	// it without waiting for it to expire.
+	Release(ctx context.Context, holder string) error
+}
+
+// Option configures an Election.
+type Option func(*Election)
+
+// WithTTL sets the duration for which the lease is acquired. The lease is renewed every ttl/3.
+//
+// Shorter TTLs allow a standby to take over more quickly when the leader fails, at the cost of more
+// frequent writes to the coordination database.
+func WithTTL(ttl time.Duration) Option {
+	return func(e *Election) {
+		e.ttl = ttl
+	}
+}
+
+// Election tracks whether this instance holds a shared Lease.
+type Election struct {
+	lease  Lease
+	holder string
+	ttl    time.Duration
+
+	mu sync.Mutex
+	// expiry is the time until which this instance is known to hold the lease, or zero if it does not.
+	expiry time.Time
+	// changed is closed and replaced whenever expiry changes.
+	changed chan struct{}
+}
+
+// New creates an Election for the provided lease.
+//
+// The holder string must uniquely identify this instance amongst all those sharing the lease, e.g.
+// the hostname or pod name.
+func New(lease Lease, holder string, opts ...Option) *Election {
+	e := &Election{
+		lease:   lease,
+		holder:  holder,
+		ttl:     DefaultTTL,
+		changed: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// Run campaigns for, and then renews, the lease until ctx is done, at which point the lease is
+// released if held.
+func (e *Election) Run(ctx context.Context) {
+	t := time.NewTicker(e.ttl / 3)
+	defer t.Stop()
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ctx.Done():
+			e.setExpiry(time.Time{})
+			rctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+			defer cancel()
+			if err := e.lease.Release(rctx, e.holder); err != nil {
+				klog.Warningf("election: failed to release lease held by %q: %v", e.holder, err)
+			}
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// campaign makes a single attempt to acquire or renew the lease.
+func (e *Election) campaign(ctx context.Context) {
+	// The lease expiry is measured from before the attempt so that we never believe we hold it for
+	// longer than the other instances do.
+	start := time.Now()
+	cctx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+	ok, err := e.lease.TryAcquire(cctx, e.holder, e.ttl)
+	switch {
+	case err != nil:
+		// Keep any existing leadership until it expires; the next attempt may succeed.
+		klog.Warningf("election: %q failed to acquire lease: %v", e.holder, err)
+	case ok:
+		if !e.IsLeader() {
+			klog.Infof("election: %q is now the leader", e.holder)
+		}
+		e.setExpiry(start.Add(e.ttl))
+	default:
+		if e.IsLeader() {
+			klog.Warningf("election: %q lost the lease", e.holder)
+		}
+		e.setExpiry(time.Time{})
+	}
+}
+
+func (e *Election) setExpiry(t time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expiry = t
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// state returns the current lease expiry and a channel which will be closed when it next changes.
+func (e *Election) state() (time.Time, <-chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.expiry, e.changed
+}
+
+// IsLeader returns true if this instance currently holds the lease.
+func (e *Election) IsLeader() bool {
+	expiry, _ := e.state()
+	return time.Now().Before(expiry)
+}
+
+// Lead blocks until this instance holds the lease, and then returns a context derived from ctx which
+// is cancelled as soon as it no longer does.
+//
+// Run must be called concurrently for leadership to be acquired.
+func (e *Election) Lead(ctx context.Context) (context.Context, error) {
+	for {
+		expiry, changed := e.state()
+		if time.Now().Before(expiry) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+
+	lctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		for {
+			expiry, changed := e.state()
+			d := time.Until(expiry)
+			if d <= 0 {
+				cancel(ErrLeadershipLost)
+				return
+			}
+			t := time.NewTimer(d)
+			select {
+			case <-lctx.Done():
+				t.Stop()
+				return
+			case <-changed:
+			case <-t.C:
+			}
+			t.Stop()
+		}
+	}()
+	return lctx, nil
+}
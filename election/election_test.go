@@ -0,0 +1,120 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memLease is an in-memory Lease.
+type memLease struct {
+	mu     sync.Mutex
+	holder string
+	expiry time.Time
+	// fail, if set, causes TryAcquire to return an error for the named holder.
+	fail map[string]bool
+}
+
+func (l *memLease) TryAcquire(_ context.Context, holder string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fail[holder] {
+		return false, errors.New("unavailable")
+	}
+	now := time.Now()
+	if l.holder != holder && now.Before(l.expiry) {
+		return false, nil
+	}
+	l.holder, l.expiry = holder, now.Add(ttl)
+	return true, nil
+}
+
+func (l *memLease) Release(_ context.Context, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == holder {
+		l.holder, l.expiry = "", time.Time{}
+	}
+	return nil
+}
+
+func (l *memLease) setFail(holder string, fail bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fail[holder] = fail
+}
+
+func TestElection(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	const ttl = 300 * time.Millisecond
+
+	lease := &memLease{fail: map[string]bool{}}
+	aCtx, aCancel := context.WithCancel(ctx)
+	a := New(lease, "a", WithTTL(ttl))
+	go a.Run(aCtx)
+	aLead, err := a.Lead(ctx)
+	if err != nil {
+		t.Fatalf("a.Lead: %v", err)
+	}
+
+	b := New(lease, "b", WithTTL(ttl))
+	go b.Run(ctx)
+	time.Sleep(2 * ttl)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("got a.IsLeader()=%t b.IsLeader()=%t, want only a", a.IsLeader(), b.IsLeader())
+	}
+
+	// Stopping the leader releases the lease, so the standby takes over.
+	aCancel()
+	bLead, err := b.Lead(ctx)
+	if err != nil {
+		t.Fatalf("b.Lead: %v", err)
+	}
+	if a.IsLeader() {
+		t.Error("a.IsLeader() = true after stopping")
+	}
+	select {
+	case <-aLead.Done():
+	case <-time.After(ttl):
+		t.Error("a's leadership context was not cancelled after stopping")
+	}
+
+	// A leader which can no longer renew the lease loses leadership once it expires.
+	lease.setFail("b", true)
+	select {
+	case <-bLead.Done():
+	case <-time.After(3 * ttl):
+		t.Fatal("b's leadership context was not cancelled after renewal failures")
+	}
+	if got := context.Cause(bLead); !errors.Is(got, ErrLeadershipLost) {
+		t.Errorf("context.Cause() = %v, want %v", got, ErrLeadershipLost)
+	}
+}
+
+func TestLeadCancelled(t *testing.T) {
+	lease := &memLease{holder: "other", expiry: time.Now().Add(time.Hour)}
+	e := New(lease, "me")
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	go e.Run(ctx)
+	if _, err := e.Lead(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lead: got err %v, want %v", err, context.DeadlineExceeded)
+	}
+}
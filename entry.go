@@ -16,9 +16,8 @@
 package tessera
 
 import (
-	"encoding/binary"
-
 	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
 )
 
 // Entry represents an entry in a log.
@@ -71,10 +70,7 @@ func NewEntry(data []byte) *Entry {
 	// By default we will marshal ourselves into a bundle using the mechanism described
 	// by https://c2sp.org/tlog-tiles:
 	e.marshalForBundle = func(_ uint64) []byte {
-		r := make([]byte, 0, 2+len(e.internal.Data))
-		r = binary.BigEndian.AppendUint16(r, uint16(len(e.internal.Data)))
-		r = append(r, e.internal.Data...)
-		return r
+		return api.AppendEntry(make([]byte, 0, 2+len(e.internal.Data)), e.internal.Data)
 	}
 	return e
 }
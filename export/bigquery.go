@@ -0,0 +1,98 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+// bigQueryMaxRows is the most rows sent to BigQuery in a single streaming insert request, as
+// recommended by BigQuery's quotas documentation.
+const bigQueryMaxRows = 500
+
+// BigQueryWriter streams rows into a BigQuery table with the schema index:INTEGER, leaf_hash:BYTES,
+// data:BYTES, which must already exist.
+//
+// Each row is inserted with its index as its insert ID, so that BigQuery can drop duplicate rows
+// when an interrupted export is retried.
+type BigQueryWriter struct {
+	tabledata                 *bigquery.TabledataService
+	project, dataset, tableID string
+}
+
+// NewBigQueryWriter returns a BigQueryWriter which inserts rows into the table identified by
+// "project.dataset.table". Application Default Credentials are used unless opts say otherwise.
+func NewBigQueryWriter(ctx context.Context, table string, opts ...option.ClientOption) (*BigQueryWriter, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("invalid table %q, want project.dataset.table", table)
+	}
+	s, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery client: %v", err)
+	}
+	return &BigQueryWriter{
+		tabledata: bigquery.NewTabledataService(s),
+		project:   parts[0],
+		dataset:   parts[1],
+		tableID:   parts[2],
+	}, nil
+}
+
+// WriteRows implements RowWriter.
+func (b *BigQueryWriter) WriteRows(ctx context.Context, rows []Row) error {
+	for len(rows) > 0 {
+		n := min(len(rows), bigQueryMaxRows)
+		req := &bigquery.TableDataInsertAllRequest{Rows: make([]*bigquery.TableDataInsertAllRequestRows, 0, n)}
+		for _, r := range rows[:n] {
+			idx := strconv.FormatUint(r.Index, 10)
+			req.Rows = append(req.Rows, &bigquery.TableDataInsertAllRequestRows{
+				InsertId: idx,
+				Json: map[string]bigquery.JsonValue{
+					// The index is sent as a string, as JSON numbers can't represent every uint64.
+					"index":     idx,
+					"leaf_hash": base64.StdEncoding.EncodeToString(r.LeafHash),
+					"data":      base64.StdEncoding.EncodeToString(r.Data),
+				},
+			})
+		}
+		resp, err := b.tabledata.InsertAll(b.project, b.dataset, b.tableID, req).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to insert rows [%d, %d): %v", rows[0].Index, rows[n-1].Index+1, err)
+		}
+		if len(resp.InsertErrors) > 0 {
+			e := resp.InsertErrors[0]
+			return fmt.Errorf("failed to insert %d rows, including row %d: %v", len(resp.InsertErrors), rows[0].Index+uint64(e.Index), insertErrorString(e))
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+func insertErrorString(e *bigquery.TableDataInsertAllResponseInsertErrors) string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", err.Reason, err.Message))
+	}
+	return strings.Join(msgs, "; ")
+}
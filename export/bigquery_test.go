@@ -0,0 +1,125 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+// fakeBigQuery serves BigQuery's streaming insert API, recording the rows inserted into a table.
+type fakeBigQuery struct {
+	mu   sync.Mutex
+	rows []Row
+	// failIndex, if set, is the index of a row which is rejected.
+	failIndex *uint64
+}
+
+func (f *fakeBigQuery) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/projects/p/datasets/d/tables/t/insertAll" {
+		http.Error(w, fmt.Sprintf("unexpected request %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Rows []struct {
+			InsertID string            `json:"insertId"`
+			JSON     map[string]string `json:"json"`
+		} `json:"rows"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Rows) > bigQueryMaxRows {
+		http.Error(w, fmt.Sprintf("%d rows in one request", len(req.Rows)), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, row := range req.Rows {
+		idx, err := strconv.ParseUint(row.JSON["index"], 10, 64)
+		if err != nil || row.InsertID != row.JSON["index"] {
+			http.Error(w, fmt.Sprintf("bad row %v", row), http.StatusBadRequest)
+			return
+		}
+		if f.failIndex != nil && *f.failIndex == idx {
+			fmt.Fprintf(w, `{"insertErrors": [{"index": %d, "errors": [{"reason": "invalid", "message": "bad row"}]}]}`, i)
+			return
+		}
+		lh, err := base64.StdEncoding.DecodeString(row.JSON["leaf_hash"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d, err := base64.StdEncoding.DecodeString(row.JSON["data"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.rows = append(f.rows, Row{Index: idx, LeafHash: lh, Data: d})
+	}
+	fmt.Fprint(w, `{}`)
+}
+
+func TestBigQueryWriter(t *testing.T) {
+	ctx := t.Context()
+	fake := &fakeBigQuery{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	newWriter := func(table string) (*BigQueryWriter, error) {
+		return NewBigQueryWriter(ctx, table, option.WithEndpoint(srv.URL), option.WithoutAuthentication(), option.WithHTTPClient(srv.Client()))
+	}
+
+	for _, table := range []string{"", "d.t", "p..t", "p.d.t.x"} {
+		if _, err := newWriter(table); err == nil {
+			t.Errorf("NewBigQueryWriter(%q): want error", table)
+		}
+	}
+	w, err := newWriter("p.d.t")
+	if err != nil {
+		t.Fatalf("NewBigQueryWriter: %v", err)
+	}
+	// Entry bundles are wider than the maximum number of rows in a request, so some must be split.
+	const size = 1100
+	if err := Export(ctx, testBundles(size), size, w, Options{From: 10, To: size}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	c := &collector{}
+	if err := Export(ctx, testBundles(size), size, c, Options{From: 10, To: size}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if got, want := len(fake.rows), len(c.rows); got != want {
+		t.Fatalf("Inserted %d rows, want %d", got, want)
+	}
+	for i, r := range fake.rows {
+		if want := c.rows[i]; r.Index != want.Index || string(r.LeafHash) != string(want.LeafHash) || string(r.Data) != string(want.Data) {
+			t.Errorf("row %d: got %+v, want %+v", i, r, want)
+		}
+	}
+
+	bad := uint64(20)
+	fake.failIndex = &bad
+	if err := Export(ctx, testBundles(size), size, w, Options{From: 0, To: 100}); err == nil {
+		t.Error("Export with rejected row: want error")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export streams the contents of a log's integrated entry bundles out as rows, so that
+// log contents can be loaded into analytics systems without serving ad-hoc queries from the
+// log's read path.
+//
+// RowWriters are provided for newline delimited JSON, Parquet files, and BigQuery tables; other
+// destinations can be supported by implementing RowWriter.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+)
+
+// Row is a single exported log entry.
+type Row struct {
+	// Index is the position of the entry in the log.
+	Index uint64 `json:"index"`
+	// LeafHash is the Merkle leaf hash of the entry.
+	LeafHash []byte `json:"leaf_hash"`
+	// Data is the entry's payload.
+	Data []byte `json:"data"`
+}
+
+// RowWriter is implemented by the destinations of an export, e.g. a file in a particular format or a
+// streaming insert into a data warehouse.
+type RowWriter interface {
+	// WriteRows is called with consecutive rows, in log order, once per entry bundle.
+	WriteRows(ctx context.Context, rows []Row) error
+}
+
+// Options configures an export.
+type Options struct {
+	// From is the index of the first entry to export.
+	From uint64
+	// To is the index of the entry after the last one to export, and must be no larger than the size of
+	// the tree whose entry bundles are being read.
+	To uint64
+	// Parallelism is the number of entry bundles fetched concurrently. If zero, 1 is used.
+	Parallelism uint
+	// Unbundle splits a serialised entry bundle into its entries. If unset, bundles are parsed as
+	// tlog-tiles entry bundles.
+	Unbundle func(bundle []byte) ([][]byte, error)
+	// LeafHash returns the Merkle leaf hash for an entry. If unset, the RFC 6962 leaf hash of the
+	// entry's data is used.
+	LeafHash func(data []byte) []byte
+}
+
+// Export reads the entries in [opts.From, opts.To) from the log of the given tree size, and passes
+// them to w in order.
+//
+// Entry bundles are fetched with f, which will typically be the ReadEntryBundle method of one of the
+// fetchers in the client package. Exports can be resumed by passing the index after the last exported
+// row as opts.From.
+func Export(ctx context.Context, f client.EntryBundleFetcherFunc, treeSize uint64, w RowWriter, opts Options) error {
+	if opts.From > opts.To || opts.To > treeSize {
+		return fmt.Errorf("invalid range [%d, %d) for tree size %d", opts.From, opts.To, treeSize)
+	}
+	if opts.Unbundle == nil {
+		opts.Unbundle = unbundle
+	}
+	if opts.LeafHash == nil {
+		opts.LeafHash = rfc6962.DefaultHasher.HashLeaf
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Bundles are fetched concurrently, but results are queued in order so that rows are
+	// always written in log order.
+	type result struct {
+		ri      layout.RangeInfo
+		entries [][]byte
+		err     error
+	}
+	results := make(chan chan result, max(opts.Parallelism, 1))
+	go func() {
+		defer close(results)
+		for ri := range layout.Range(opts.From, opts.To-opts.From, treeSize) {
+			c := make(chan result, 1)
+			select {
+			case results <- c:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				b, err := f(ctx, ri.Index, ri.Partial)
+				if err != nil {
+					c <- result{err: fmt.Errorf("failed to fetch entry bundle %d: %w", ri.Index, err)}
+					return
+				}
+				entries, err := opts.Unbundle(b)
+				if err != nil {
+					err = fmt.Errorf("failed to parse entry bundle %d: %v", ri.Index, err)
+				}
+				c <- result{ri: ri, entries: entries, err: err}
+			}()
+		}
+	}()
+
+	for c := range results {
+		r := <-c
+		if r.err != nil {
+			return r.err
+		}
+		if got, want := uint64(len(r.entries)), uint64(r.ri.First+r.ri.N); got < want {
+			return fmt.Errorf("entry bundle %d has %d entries, want at least %d", r.ri.Index, got, want)
+		}
+		rows := make([]Row, 0, r.ri.N)
+		for i := uint64(r.ri.First); i < uint64(r.ri.First+r.ri.N); i++ {
+			rows = append(rows, Row{
+				Index:    r.ri.Index*layout.EntryBundleWidth + i,
+				LeafHash: opts.LeafHash(r.entries[i]),
+				Data:     r.entries[i],
+			})
+		}
+		if err := w.WriteRows(ctx, rows); err != nil {
+			return fmt.Errorf("failed to write rows from entry bundle %d: %v", r.ri.Index, err)
+		}
+	}
+	return ctx.Err()
+}
+
+func unbundle(bundle []byte) ([][]byte, error) {
+	b := api.EntryBundle{}
+	if err := b.UnmarshalText(bundle); err != nil {
+		return nil, err
+	}
+	return b.Entries, nil
+}
+
+// JSONLinesWriter writes rows as newline delimited JSON objects, with bytes fields encoded as
+// base64 strings.
+//
+// This is the format expected by BigQuery for NEWLINE_DELIMITED_JSON loads into a table with the
+// schema index:INTEGER, leaf_hash:BYTES, data:BYTES, and can be converted to Parquet or other
+// columnar formats by most data processing tools.
+type JSONLinesWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONLinesWriter returns a JSONLinesWriter which writes to w.
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{enc: json.NewEncoder(w)}
+}
+
+// WriteRows implements RowWriter.
+func (j *JSONLinesWriter) WriteRows(_ context.Context, rows []Row) error {
+	for _, r := range rows {
+		if err := j.enc.Encode(r); err != nil {
+			return fmt.Errorf("row %d: %v", r.Index, err)
+		}
+	}
+	return nil
+}
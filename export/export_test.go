@@ -0,0 +1,117 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// testBundles returns a fetcher for the tlog-tiles entry bundles of a log of the given size.
+func testBundles(size uint64) func(context.Context, uint64, uint8) ([]byte, error) {
+	return func(_ context.Context, idx uint64, p uint8) ([]byte, error) {
+		if p != layout.PartialTileSize(0, idx, size) {
+			return nil, fmt.Errorf("bundle %d.p/%d: %w", idx, p, os.ErrNotExist)
+		}
+		n := uint64(p)
+		if n == 0 {
+			n = layout.EntryBundleWidth
+		}
+		var b []byte
+		for i := range n {
+			d := fmt.Appendf(nil, "entry %d", idx*layout.EntryBundleWidth+i)
+			b = binary.BigEndian.AppendUint16(b, uint16(len(d)))
+			b = append(b, d...)
+		}
+		return b, nil
+	}
+}
+
+type collector struct {
+	rows []Row
+}
+
+func (c *collector) WriteRows(_ context.Context, rows []Row) error {
+	c.rows = append(c.rows, rows...)
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	const size = 600
+	for _, tC := range []struct {
+		desc        string
+		from, to    uint64
+		parallelism uint
+		wantErr     bool
+	}{
+		{desc: "everything", from: 0, to: size},
+		{desc: "middle, parallel", from: 100, to: 550, parallelism: 4},
+		{desc: "within a bundle", from: 300, to: 310},
+		{desc: "empty", from: 42, to: 42},
+		{desc: "beyond tree", from: 0, to: size + 1, wantErr: true},
+		{desc: "backwards", from: 10, to: 5, wantErr: true},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			c := &collector{}
+			err := Export(t.Context(), testBundles(size), size, c, Options{From: tC.from, To: tC.to, Parallelism: tC.parallelism})
+			if gotErr := err != nil; gotErr != tC.wantErr {
+				t.Fatalf("Export: got err %v, want error %t", err, tC.wantErr)
+			}
+			if tC.wantErr {
+				return
+			}
+			if got, want := uint64(len(c.rows)), tC.to-tC.from; got != want {
+				t.Fatalf("Export: got %d rows, want %d", got, want)
+			}
+			for i, r := range c.rows {
+				idx := tC.from + uint64(i)
+				want := fmt.Appendf(nil, "entry %d", idx)
+				if r.Index != idx || !bytes.Equal(r.Data, want) || !bytes.Equal(r.LeafHash, rfc6962.DefaultHasher.HashLeaf(want)) {
+					t.Errorf("row %d: got %+v, want entry %d", i, r, idx)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := Export(t.Context(), testBundles(300), 300, NewJSONLinesWriter(buf), Options{From: 250, To: 300}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	s := bufio.NewScanner(buf)
+	var n uint64
+	for ; s.Scan(); n++ {
+		r := Row{}
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatalf("line %d: %v", n, err)
+		}
+		if r.Index != 250+n {
+			t.Errorf("line %d: got index %d, want %d", n, r.Index, 250+n)
+		}
+	}
+	if n != 50 {
+		t.Errorf("got %d lines, want 50", n)
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// parquetMagic starts and ends every Parquet file.
+const parquetMagic = "PAR1"
+
+// DefaultParquetRowGroupSize is the number of bytes of column data which a ParquetWriter buffers
+// before writing it out as a row group, unless told otherwise.
+const DefaultParquetRowGroupSize = 64 << 20
+
+// Values of the enums in the Parquet format's Thrift definitions which are used by ParquetWriter.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetRepetitionRequired = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetCodecUncompressed  = 0
+	parquetPageTypeData       = 0
+)
+
+// parquetColumn describes one of the columns written by ParquetWriter.
+type parquetColumn struct {
+	name string
+	typ  int32
+}
+
+// parquetColumns are the columns of the files written by ParquetWriter, in the order of Row's fields.
+var parquetColumns = [...]parquetColumn{
+	{name: "index", typ: parquetTypeInt64},
+	{name: "leaf_hash", typ: parquetTypeByteArray},
+	{name: "data", typ: parquetTypeByteArray},
+}
+
+// columnChunk records where a column of a row group was written.
+type columnChunk struct {
+	offset int64
+	size   int64
+}
+
+// rowGroup records where a row group was written.
+type rowGroup struct {
+	numRows int64
+	size    int64
+	columns []columnChunk
+}
+
+// ParquetWriter writes rows as a Parquet file with the required columns index (INT64), leaf_hash
+// (BYTE_ARRAY), and data (BYTE_ARRAY), which BigQuery loads as INTEGER, BYTES, and BYTES columns.
+//
+// Rows are buffered, and written out as an uncompressed, PLAIN encoded row group each time the
+// buffered data reaches the row group size. The file is only complete once Close has been called.
+type ParquetWriter struct {
+	w            io.Writer
+	offset       int64
+	rowGroupSize int
+	// columns buffers the PLAIN encoded values of each column for the next row group.
+	columns   [len(parquetColumns)]bytes.Buffer
+	numRows   int64
+	rowGroups []rowGroup
+	closed    bool
+}
+
+// NewParquetWriter returns a ParquetWriter which writes to w, starting a new row group each time
+// rowGroupSize bytes of data have been buffered. If rowGroupSize is zero,
+// DefaultParquetRowGroupSize is used.
+func NewParquetWriter(w io.Writer, rowGroupSize int) *ParquetWriter {
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultParquetRowGroupSize
+	}
+	return &ParquetWriter{w: w, rowGroupSize: rowGroupSize}
+}
+
+// WriteRows implements RowWriter.
+func (p *ParquetWriter) WriteRows(_ context.Context, rows []Row) error {
+	if p.closed {
+		return errors.New("writer is closed")
+	}
+	for _, r := range rows {
+		p.columns[0].Write(binary.LittleEndian.AppendUint64(nil, r.Index))
+		for i, v := range [][]byte{r.LeafHash, r.Data} {
+			p.columns[i+1].Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			p.columns[i+1].Write(v)
+		}
+		p.numRows++
+		if p.buffered() >= p.rowGroupSize {
+			if err := p.flushRowGroup(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close writes out any buffered rows and the file's footer. It doesn't close the underlying writer.
+func (p *ParquetWriter) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if err := p.flushRowGroup(); err != nil {
+		return err
+	}
+	if err := p.writeMagic(); err != nil {
+		return err
+	}
+	footer := p.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, parquetMagic...)
+	return p.write(footer)
+}
+
+// buffered returns the number of bytes of column data waiting to be written.
+func (p *ParquetWriter) buffered() int {
+	n := 0
+	for i := range p.columns {
+		n += p.columns[i].Len()
+	}
+	return n
+}
+
+// flushRowGroup writes the buffered rows as a row group holding a single data page for each column.
+func (p *ParquetWriter) flushRowGroup() error {
+	if p.numRows == 0 {
+		return nil
+	}
+	if err := p.writeMagic(); err != nil {
+		return err
+	}
+	rg := rowGroup{numRows: p.numRows}
+	for i := range p.columns {
+		data := p.columns[i].Bytes()
+		// Required, non-nested, columns have no repetition or definition levels, so the page holds only
+		// the values themselves.
+		t := &thriftWriter{}
+		t.structBegin()
+		t.i32(1, parquetPageTypeData)
+		t.i32(2, int32(len(data)))
+		t.i32(3, int32(len(data)))
+		t.structField(5)
+		t.i32(1, int32(p.numRows))
+		t.i32(2, parquetEncodingPlain)
+		t.i32(3, parquetEncodingRLE)
+		t.i32(4, parquetEncodingRLE)
+		t.structEnd()
+		t.structEnd()
+		c := columnChunk{offset: p.offset, size: int64(t.b.Len() + len(data))}
+		if err := p.write(t.b.Bytes()); err != nil {
+			return err
+		}
+		if err := p.write(data); err != nil {
+			return err
+		}
+		rg.columns = append(rg.columns, c)
+		rg.size += c.size
+		p.columns[i].Reset()
+	}
+	p.rowGroups = append(p.rowGroups, rg)
+	p.numRows = 0
+	return nil
+}
+
+// footer returns the Thrift compact encoding of the file's FileMetaData.
+func (p *ParquetWriter) footer() []byte {
+	var numRows int64
+	for _, rg := range p.rowGroups {
+		numRows += rg.numRows
+	}
+	t := &thriftWriter{}
+	t.structBegin()
+	t.i32(1, 1)
+	t.listBegin(2, thriftStruct, 1+len(parquetColumns))
+	t.structBegin()
+	t.binary(4, []byte("schema"))
+	t.i32(5, int32(len(parquetColumns)))
+	t.structEnd()
+	for _, c := range parquetColumns {
+		t.structBegin()
+		t.i32(1, c.typ)
+		t.i32(3, parquetRepetitionRequired)
+		t.binary(4, []byte(c.name))
+		t.structEnd()
+	}
+	t.i64(3, numRows)
+	t.listBegin(4, thriftStruct, len(p.rowGroups))
+	for _, rg := range p.rowGroups {
+		t.structBegin()
+		t.listBegin(1, thriftStruct, len(rg.columns))
+		for i, c := range rg.columns {
+			t.structBegin()
+			t.i64(2, c.offset)
+			t.structField(3)
+			t.i32(1, parquetColumns[i].typ)
+			t.listBegin(2, thriftI32, 1)
+			t.varint(zigzag(parquetEncodingPlain))
+			t.listBegin(3, thriftBinary, 1)
+			t.varint(uint64(len(parquetColumns[i].name)))
+			t.b.WriteString(parquetColumns[i].name)
+			t.i32(4, parquetCodecUncompressed)
+			t.i64(5, rg.numRows)
+			t.i64(6, c.size)
+			t.i64(7, c.size)
+			t.i64(9, c.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, rg.size)
+		t.i64(3, rg.numRows)
+		t.structEnd()
+	}
+	t.binary(6, []byte("tessera export"))
+	t.structEnd()
+	return t.b.Bytes()
+}
+
+// writeMagic writes the magic bytes which start the file, if nothing has been written yet.
+func (p *ParquetWriter) writeMagic() error {
+	if p.offset > 0 {
+		return nil
+	}
+	return p.write([]byte(parquetMagic))
+}
+
+func (p *ParquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write Parquet file: %v", err)
+	}
+	return nil
+}
+
+// Types of the Thrift compact protocol which are used in the Parquet metadata.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes structs using the Thrift compact protocol, in which Parquet's metadata is
+// serialised.
+//
+// Fields must be written in increasing order of their IDs within each struct.
+type thriftWriter struct {
+	b bytes.Buffer
+	// lastID holds the ID of the last field written to each of the structs currently being written.
+	lastID []int16
+}
+
+func (t *thriftWriter) structBegin() {
+	t.lastID = append(t.lastID, 0)
+}
+
+func (t *thriftWriter) structEnd() {
+	t.b.WriteByte(0)
+	t.lastID = t.lastID[:len(t.lastID)-1]
+}
+
+// structField begins a field holding a struct, whose fields should then be written, followed by a call
+// to structEnd.
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBegin()
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, v []byte) {
+	t.fieldHeader(id, thriftBinary)
+	t.varint(uint64(len(v)))
+	t.b.Write(v)
+}
+
+// listBegin begins a field holding a list of n elements of type elem, which should then be written
+// without field headers.
+func (t *thriftWriter) listBegin(id int16, elem byte, n int) {
+	t.fieldHeader(id, thriftList)
+	if n < 15 {
+		t.b.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	t.b.WriteByte(0xf0 | elem)
+	t.varint(uint64(n))
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &t.lastID[len(t.lastID)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		t.b.WriteByte(byte(d)<<4 | typ)
+	} else {
+		t.b.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.b.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+func TestParquetWriter(t *testing.T) {
+	for _, tC := range []struct {
+		desc          string
+		from, to      uint64
+		rowGroupSize  int
+		wantRowGroups int
+	}{
+		{desc: "one row group", from: 0, to: 300, wantRowGroups: 1},
+		{desc: "several row groups", from: 10, to: 300, rowGroupSize: 4096, wantRowGroups: 4},
+		{desc: "empty", from: 42, to: 42, wantRowGroups: 0},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewParquetWriter(buf, tC.rowGroupSize)
+			if err := Export(t.Context(), testBundles(300), 300, w, Options{From: tC.from, To: tC.to}); err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			rows, numRowGroups, err := readParquet(buf.Bytes())
+			if err != nil {
+				t.Fatalf("Invalid Parquet file: %v", err)
+			}
+			if numRowGroups != tC.wantRowGroups {
+				t.Errorf("Got %d row groups, want %d", numRowGroups, tC.wantRowGroups)
+			}
+			if got, want := uint64(len(rows)), tC.to-tC.from; got != want {
+				t.Fatalf("Got %d rows, want %d", got, want)
+			}
+			for i, r := range rows {
+				idx := tC.from + uint64(i)
+				want := fmt.Appendf(nil, "entry %d", idx)
+				if r.Index != idx || !bytes.Equal(r.Data, want) || !bytes.Equal(r.LeafHash, rfc6962.DefaultHasher.HashLeaf(want)) {
+					t.Errorf("row %d: got %+v, want entry %d", i, r, idx)
+				}
+			}
+		})
+	}
+}
+
+// readParquet returns the rows in a Parquet file written by ParquetWriter, and the number of row groups
+// they were written in, reading them as directed by the file's metadata.
+func readParquet(f []byte) ([]Row, int, error) {
+	if len(f) < 12 || string(f[:4]) != parquetMagic || string(f[len(f)-4:]) != parquetMagic {
+		return nil, 0, fmt.Errorf("missing magic bytes")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(f[len(f)-8:]))
+	if footerLen > len(f)-12 {
+		return nil, 0, fmt.Errorf("footer length %d is too large", footerLen)
+	}
+	r := &thriftReader{b: f[len(f)-8-footerLen : len(f)-8]}
+	md := r.readStruct()
+	if r.err != nil {
+		return nil, 0, fmt.Errorf("invalid footer: %v", r.err)
+	}
+	if len(r.b) != 0 {
+		return nil, 0, fmt.Errorf("%d bytes after footer", len(r.b))
+	}
+
+	schema := md[2].([]any)
+	if len(schema) != 1+len(parquetColumns) || schema[0].(map[int16]any)[5] != int64(len(parquetColumns)) {
+		return nil, 0, fmt.Errorf("unexpected schema %v", schema)
+	}
+	for i, c := range parquetColumns {
+		s := schema[i+1].(map[int16]any)
+		if string(s[4].([]byte)) != c.name || s[1] != int64(c.typ) || s[3] != int64(parquetRepetitionRequired) {
+			return nil, 0, fmt.Errorf("unexpected schema for column %d: %v", i, s)
+		}
+	}
+
+	var rows []Row
+	rowGroups := md[4].([]any)
+	for _, rg := range rowGroups {
+		rg := rg.(map[int16]any)
+		n := int(rg[3].(int64))
+		cols := rg[1].([]any)
+		if len(cols) != len(parquetColumns) {
+			return nil, 0, fmt.Errorf("row group has %d columns", len(cols))
+		}
+		values := make([][]byte, 0, len(cols))
+		for i, c := range cols {
+			cmd := c.(map[int16]any)[3].(map[int16]any)
+			if cmd[5] != int64(n) || cmd[4] != int64(parquetCodecUncompressed) || string(cmd[3].([]any)[0].([]byte)) != parquetColumns[i].name {
+				return nil, 0, fmt.Errorf("unexpected metadata for column %d: %v", i, cmd)
+			}
+			off, size := cmd[9].(int64), cmd[7].(int64)
+			r := &thriftReader{b: f[off : off+size]}
+			ph := r.readStruct()
+			if r.err != nil {
+				return nil, 0, fmt.Errorf("invalid page header for column %d: %v", i, r.err)
+			}
+			if dph := ph[5].(map[int16]any); dph[1] != int64(n) || dph[2] != int64(parquetEncodingPlain) {
+				return nil, 0, fmt.Errorf("unexpected data page header for column %d: %v", i, ph)
+			}
+			if ph[3] != int64(len(r.b)) {
+				return nil, 0, fmt.Errorf("page of column %d has %d bytes, header says %d", i, len(r.b), ph[3])
+			}
+			values = append(values, r.b)
+		}
+		for range n {
+			row := Row{Index: binary.LittleEndian.Uint64(values[0])}
+			values[0] = values[0][8:]
+			for i, v := range []*[]byte{&row.LeafHash, &row.Data} {
+				l := binary.LittleEndian.Uint32(values[i+1])
+				*v = values[i+1][4 : 4+l]
+				values[i+1] = values[i+1][4+l:]
+			}
+			rows = append(rows, row)
+		}
+	}
+	if md[3] != int64(len(rows)) {
+		return nil, 0, fmt.Errorf("file has %d rows, metadata says %v", len(rows), md[3])
+	}
+	return rows, len(rowGroups), nil
+}
+
+// thriftReader decodes the subset of the Thrift compact protocol written by thriftWriter.
+type thriftReader struct {
+	b   []byte
+	err error
+}
+
+// readStruct returns the fields of a struct by ID. Integers are returned as int64, binary fields as
+// []byte, lists as []any, and structs as map[int16]any.
+func (r *thriftReader) readStruct() map[int16]any {
+	s := map[int16]any{}
+	var id int16
+	for r.err == nil {
+		h := r.byte()
+		if h == 0 {
+			break
+		}
+		if d := int16(h >> 4); d != 0 {
+			id += d
+		} else {
+			id = int16(r.zigzag())
+		}
+		s[id] = r.value(h & 0x0f)
+	}
+	return s
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := r.uvarint()
+		if n > uint64(len(r.b)) {
+			r.err = fmt.Errorf("binary of length %d overruns input", n)
+			return nil
+		}
+		v := r.b[:n]
+		r.b = r.b[n:]
+		return v
+	case thriftList:
+		h := r.byte()
+		n := uint64(h >> 4)
+		if n == 15 {
+			n = r.uvarint()
+		}
+		l := make([]any, 0, n)
+		for range n {
+			l = append(l, r.value(h&0x0f))
+		}
+		return l
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.err = fmt.Errorf("unsupported type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.b) == 0 {
+		r.err = fmt.Errorf("unexpected end of input")
+		return 0
+	}
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = fmt.Errorf("invalid varint")
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxGossipCheckpointSize is the maximum size of a checkpoint which will be accepted via gossip.
+	maxGossipCheckpointSize = 64 << 10
+)
+
+var (
+	// errGossipInvalidCheckpoint is returned when a gossiped checkpoint is not a valid checkpoint for this log.
+	errGossipInvalidCheckpoint = errors.New("invalid checkpoint")
+	// errGossipUnknownSize is returned when a gossiped checkpoint is larger than the log's published checkpoint.
+	errGossipUnknownSize = errors.New("checkpoint is larger than the published checkpoint")
+
+	gossipCheckpoints metric.Int64Counter
+)
+
+func init() {
+	var err error
+
+	gossipCheckpoints, err = meter.Int64Counter(
+		"tessera.gossip.checkpoints",
+		metric.WithDescription("Number of checkpoints received via gossip"),
+		metric.WithUnit("{checkpoint}"))
+	if err != nil {
+		klog.Exitf("Failed to create gossipCheckpoints metric: %v", err)
+	}
+}
+
+// GossipOptions holds optional configuration for a GossipHandler.
+type GossipOptions struct {
+	// OnSplitView is called with an error wrapping ErrDiscrepancy when a gossiped checkpoint is found
+	// to be inconsistent with the log's own history. This is intended to allow personalities to hook
+	// in alerting.
+	OnSplitView func(ctx context.Context, err error)
+	// Origin is the log's checkpoint origin. Defaults to the verifier's name, and must be set if the
+	// log was configured using WithCheckpointOrigin.
+	Origin string
+}
+
+// GossipHandler allows peers and monitors to submit checkpoints they have seen for this log, so that
+// they can be verified against the log's own history.
+//
+// A correctly signed checkpoint which is not consistent with the log's own history is evidence of
+// a split view, i.e. that different parties have been shown different versions of the log.
+//
+// GossipHandler implements http.Handler, accepting checkpoints POSTed as the request body. It responds
+// with:
+//   - 200 if the checkpoint is consistent with the log's history,
+//   - 400 if the checkpoint is not a valid checkpoint for this log,
+//   - 409 if the checkpoint is evidence of a split view,
+//   - 422 if the checkpoint is larger than the log's published checkpoint, and so cannot yet be verified.
+//
+// Clients can submit checkpoints using client.GossipCheckpoint.
+type GossipHandler struct {
+	lr   LogReader
+	v    note.Verifier
+	opts GossipOptions
+}
+
+// NewGossipHandler creates a new GossipHandler which verifies gossiped checkpoints signed by v against
+// the log state available via lr.
+func NewGossipHandler(lr LogReader, v note.Verifier, opts GossipOptions) *GossipHandler {
+	if opts.Origin == "" {
+		opts.Origin = v.Name()
+	}
+	return &GossipHandler{
+		lr:   lr,
+		v:    v,
+		opts: opts,
+	}
+}
+
+// Check verifies the provided checkpoint against the log's own history.
+//
+// Errors wrapping ErrDiscrepancy indicate that the checkpoint is evidence of a split view, and are
+// also passed to the OnSplitView hook, if set.
+func (g *GossipHandler) Check(ctx context.Context, cpRaw []byte) error {
+	ctx, span := tracer.Start(ctx, "tessera.GossipHandler.Check")
+	defer span.End()
+
+	err := g.check(ctx, cpRaw)
+	result := "ok"
+	switch {
+	case errors.Is(err, ErrDiscrepancy):
+		result = "split_view"
+		if g.opts.OnSplitView != nil {
+			g.opts.OnSplitView(ctx, err)
+		}
+	case errors.Is(err, errGossipInvalidCheckpoint):
+		result = "invalid"
+	case errors.Is(err, errGossipUnknownSize):
+		result = "unknown_size"
+	case err != nil:
+		result = "error"
+	}
+	gossipCheckpoints.Add(ctx, 1, metric.WithAttributes(attribute.String("tessera.gossip.result", result)))
+	return err
+}
+
+func (g *GossipHandler) check(ctx context.Context, cpRaw []byte) error {
+	cp, _, _, err := f_log.ParseCheckpoint(cpRaw, g.opts.Origin, g.v)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errGossipInvalidCheckpoint, err)
+	}
+	ownRaw, err := g.lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	own, _, _, err := f_log.ParseCheckpoint(ownRaw, g.opts.Origin, g.v)
+	if err != nil {
+		return fmt.Errorf("failed to parse own checkpoint: %v", err)
+	}
+
+	switch {
+	case cp.Size > own.Size:
+		return fmt.Errorf("%w: gossiped size %d > published size %d", errGossipUnknownSize, cp.Size, own.Size)
+	case cp.Size == own.Size:
+		if !bytes.Equal(cp.Hash, own.Hash) {
+			return fmt.Errorf("%w: %v", ErrDiscrepancy, client.ErrInconsistency{
+				SmallerRaw: cpRaw,
+				LargerRaw:  ownRaw,
+				Wrapped:    fmt.Errorf("different root hashes for size %d", cp.Size),
+			})
+		}
+		return nil
+	case cp.Size == 0:
+		if emptyRoot := rfc6962.DefaultHasher.EmptyRoot(); !bytes.Equal(cp.Hash, emptyRoot[:]) {
+			return fmt.Errorf("%w: invalid root hash for empty tree", ErrDiscrepancy)
+		}
+		return nil
+	}
+
+	pb, err := client.NewProofBuilder(ctx, own.Size, g.lr.ReadTile)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	p, err := pb.ConsistencyProof(ctx, cp.Size, own.Size)
+	if err != nil {
+		return fmt.Errorf("failed to build consistency proof from %d to %d: %v", cp.Size, own.Size, err)
+	}
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, cp.Size, own.Size, p, cp.Hash, own.Hash); err != nil {
+		return fmt.Errorf("%w: %v", ErrDiscrepancy, client.ErrInconsistency{
+			SmallerRaw: cpRaw,
+			LargerRaw:  ownRaw,
+			Proof:      p,
+			Wrapped:    err,
+		})
+	}
+	return nil
+}
+
+// ServeHTTP verifies a checkpoint POSTed as the request body.
+func (g *GossipHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cpRaw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGossipCheckpointSize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = g.Check(r.Context(), cpRaw)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+		return
+	case errors.Is(err, ErrDiscrepancy):
+		klog.Errorf("GossipHandler: %v", err)
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, errGossipInvalidCheckpoint):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, errGossipUnknownSize):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		klog.Warningf("GossipHandler: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_, _ = w.Write([]byte(err.Error()))
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/testonly"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestGossipHandler(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+	addEntries := func(n int) []byte {
+		t.Helper()
+		futures := make([]tessera.IndexFuture, 0, n)
+		for i := range n {
+			futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d %d", time.Now().UnixNano(), i))))
+		}
+		var cp []byte
+		for _, f := range futures {
+			_, c, err := awaiter.Await(ctx, f)
+			if err != nil {
+				t.Fatalf("Await: %v", err)
+			}
+			cp = c
+		}
+		return cp
+	}
+	forge := func(size uint64) []byte {
+		t.Helper()
+		h := sha256.Sum256([]byte("forged"))
+		cp, err := note.Sign(&note.Note{Text: string(f_log.Checkpoint{Origin: tl.SigSigner.Name(), Size: size, Hash: h[:]}.Marshal())}, tl.SigSigner)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return cp
+	}
+
+	oldCP := addEntries(3)
+	curCP := addEntries(5)
+
+	var splitViews []error
+	h := tessera.NewGossipHandler(tl.LogReader, tl.SigVerifier, tessera.GossipOptions{
+		OnSplitView: func(_ context.Context, err error) {
+			splitViews = append(splitViews, err)
+		},
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+
+	for _, test := range []struct {
+		name          string
+		cp            []byte
+		wantErr       bool
+		wantSplitView bool
+	}{
+		{name: "old checkpoint", cp: oldCP},
+		{name: "current checkpoint", cp: curCP},
+		{name: "forked old checkpoint", cp: forge(3), wantErr: true, wantSplitView: true},
+		{name: "forked current checkpoint", cp: forge(8), wantErr: true, wantSplitView: true},
+		{name: "future checkpoint", cp: forge(100), wantErr: true},
+		{name: "bad signature", cp: []byte("test\n1\nAAAA\n\n— test AAAAAAAA\n"), wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			splitViews = nil
+			err := client.GossipCheckpoint(ctx, srv.Client(), u, test.cp)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("GossipCheckpoint: got err %v, want err %t", err, test.wantErr)
+			}
+			if got := errors.Is(err, client.ErrSplitView); got != test.wantSplitView {
+				t.Errorf("GossipCheckpoint: got split view %t, want %t (err: %v)", got, test.wantSplitView, err)
+			}
+			if got := len(splitViews) > 0; got != test.wantSplitView {
+				t.Errorf("OnSplitView called: %t, want %t", got, test.wantSplitView)
+			}
+		})
+	}
+}
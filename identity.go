@@ -0,0 +1,110 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// ErrLogIdentityMismatch is returned when an appender is configured with a checkpoint origin or signing
+// key which doesn't match those previously used with the same storage.
+//
+// This guards against accidentally creating a split-brain log, e.g. by pointing a misconfigured
+// personality at the storage of an existing log.
+var ErrLogIdentityMismatch = errors.New("log identity mismatch")
+
+// LogIdentity describes the checkpoint origin and signing keys with which a log is configured.
+//
+// Storage drivers persist a LogIdentity on first use, and refuse to create an appender if a later
+// configuration doesn't match it.
+type LogIdentity struct {
+	// Origin is the checkpoint origin.
+	Origin string
+	// KeyIDs are the sorted IDs of the configured checkpoint signing keys, in the form "<name>+<hash>"
+	// used by note verifier keys.
+	KeyIDs []string
+}
+
+// newLogIdentity returns the identity of a log with the given origin and signers.
+func newLogIdentity(origin string, signers ...note.Signer) LogIdentity {
+	keyIDs := make([]string, 0, len(signers))
+	for _, s := range signers {
+		keyIDs = append(keyIDs, fmt.Sprintf("%s+%08x", s.Name(), s.KeyHash()))
+	}
+	return newLogIdentityFromKeyIDs(origin, keyIDs)
+}
+
+// Reconcile checks the identity against stored, the serialised identity previously persisted by the
+// storage driver, or nil if there is none.
+//
+// The identities match if they have the same origin and at least one key in common; this permits
+// keys to be rotated using WithKeyRotation, which configures both the old and new keys.
+// If they match, the returned value is the serialised union of both identities, which the driver
+// should persist if it differs from stored. Otherwise, an error wrapping ErrLogIdentityMismatch is
+// returned.
+//
+// An empty identity, e.g. from AppendOptions without a checkpoint signer, is not checked.
+func (id LogIdentity) Reconcile(stored []byte) ([]byte, error) {
+	if id.Origin == "" {
+		return stored, nil
+	}
+	if len(stored) == 0 {
+		return id.marshal(), nil
+	}
+	prev, err := parseLogIdentity(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse stored log identity: %v", err)
+	}
+	if prev.Origin != id.Origin {
+		return nil, fmt.Errorf("%w: configured origin %q does not match %q used previously with this storage", ErrLogIdentityMismatch, id.Origin, prev.Origin)
+	}
+	if !slices.ContainsFunc(id.KeyIDs, func(k string) bool { return slices.Contains(prev.KeyIDs, k) }) {
+		return nil, fmt.Errorf("%w: none of the configured checkpoint signing keys %v were used previously with this storage, which used %v", ErrLogIdentityMismatch, id.KeyIDs, prev.KeyIDs)
+	}
+	return newLogIdentityFromKeyIDs(id.Origin, append(prev.KeyIDs, id.KeyIDs...)).marshal(), nil
+}
+
+// newLogIdentityFromKeyIDs returns the identity of a log with the given origin and key IDs.
+func newLogIdentityFromKeyIDs(origin string, keyIDs []string) LogIdentity {
+	keyIDs = slices.Clone(keyIDs)
+	slices.Sort(keyIDs)
+	return LogIdentity{Origin: origin, KeyIDs: slices.Compact(keyIDs)}
+}
+
+// marshal returns the serialised form of the identity: the origin on the first line, followed by one
+// key ID per line.
+func (id LogIdentity) marshal() []byte {
+	b := &strings.Builder{}
+	b.WriteString(id.Origin)
+	b.WriteString("\n")
+	for _, k := range id.KeyIDs {
+		b.WriteString(k)
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+func parseLogIdentity(b []byte) (LogIdentity, error) {
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) < 2 || lines[0] == "" {
+		return LogIdentity{}, errors.New("expected an origin and at least one key ID")
+	}
+	return newLogIdentityFromKeyIDs(lines[0], lines[1:]), nil
+}
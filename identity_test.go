@@ -0,0 +1,145 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func mustSigner(t *testing.T, name string) note.Signer {
+	t.Helper()
+	skey, _, err := note.GenerateKey(rand.Reader, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func TestLogIdentityReconcile(t *testing.T) {
+	keyA, keyB, keyC := mustSigner(t, "example.com/log"), mustSigner(t, "example.com/log"), mustSigner(t, "example.com/log")
+	other := mustSigner(t, "example.com/other")
+	rotation := &tessera.KeyRotation{Old: keyA, New: keyB, Start: time.Now(), End: time.Now().Add(time.Hour)}
+
+	id := func(o *tessera.AppendOptions) tessera.LogIdentity { return o.LogIdentity() }
+	for _, test := range []struct {
+		desc     string
+		history  []tessera.LogIdentity
+		current  tessera.LogIdentity
+		wantErr  bool
+		wantKeys int
+	}{
+		{
+			desc:     "first use",
+			current:  id(tessera.NewAppendOptions().WithCheckpointSigner(keyA)),
+			wantKeys: 1,
+		}, {
+			desc:     "same key",
+			history:  []tessera.LogIdentity{id(tessera.NewAppendOptions().WithCheckpointSigner(keyA))},
+			current:  id(tessera.NewAppendOptions().WithCheckpointSigner(keyA)),
+			wantKeys: 1,
+		}, {
+			desc:    "different key",
+			history: []tessera.LogIdentity{id(tessera.NewAppendOptions().WithCheckpointSigner(keyA))},
+			current: id(tessera.NewAppendOptions().WithCheckpointSigner(keyB)),
+			wantErr: true,
+		}, {
+			desc:    "different origin",
+			history: []tessera.LogIdentity{id(tessera.NewAppendOptions().WithCheckpointSigner(keyA))},
+			current: id(tessera.NewAppendOptions().WithCheckpointSigner(other)),
+			wantErr: true,
+		}, {
+			desc:     "additional key",
+			history:  []tessera.LogIdentity{id(tessera.NewAppendOptions().WithCheckpointSigner(keyA))},
+			current:  id(tessera.NewAppendOptions().WithCheckpointSigner(keyA, keyC)),
+			wantKeys: 2,
+		}, {
+			desc: "rotated key",
+			history: []tessera.LogIdentity{
+				id(tessera.NewAppendOptions().WithCheckpointSigner(keyA)),
+				id(tessera.NewAppendOptions().WithKeyRotation(rotation)),
+			},
+			current:  id(tessera.NewAppendOptions().WithCheckpointSigner(keyB)),
+			wantKeys: 2,
+		}, {
+			desc:     "unsigned",
+			history:  []tessera.LogIdentity{id(tessera.NewAppendOptions().WithCheckpointSigner(keyA))},
+			current:  tessera.LogIdentity{},
+			wantKeys: 1,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			var stored []byte
+			for _, h := range test.history {
+				var err error
+				if stored, err = h.Reconcile(stored); err != nil {
+					t.Fatalf("Reconcile(history): %v", err)
+				}
+			}
+			got, err := test.current.Reconcile(stored)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Reconcile: %v, wantErr %t", err, test.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, tessera.ErrLogIdentityMismatch) {
+					t.Errorf("Reconcile: got err %v, want %v", err, tessera.ErrLogIdentityMismatch)
+				}
+				return
+			}
+			// The stored identity has the origin on the first line, followed by one line per key.
+			if n := strings.Count(string(got), "\n") - 1; n != test.wantKeys {
+				t.Errorf("Reconcile returned identity with %d keys, want %d:\n%s", n, test.wantKeys, got)
+			}
+		})
+	}
+}
+
+func TestNewAppenderRefusesDifferentIdentity(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	dir := t.TempDir()
+
+	newAppender := func(s note.Signer) error {
+		driver, err := posix.New(ctx, dir)
+		if err != nil {
+			t.Fatalf("posix.New: %v", err)
+		}
+		_, _, _, err = tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().WithCheckpointSigner(s).WithCheckpointInterval(time.Second))
+		return err
+	}
+
+	s := mustSigner(t, "example.com/log")
+	if err := newAppender(s); err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	if err := newAppender(s); err != nil {
+		t.Fatalf("NewAppender with same key: %v", err)
+	}
+	if err := newAppender(mustSigner(t, "example.com/log")); !errors.Is(err, tessera.ErrLogIdentityMismatch) {
+		t.Fatalf("NewAppender with different key: got err %v, want %v", err, tessera.ErrLogIdentityMismatch)
+	}
+}
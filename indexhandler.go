@@ -0,0 +1,63 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"k8s.io/klog/v2"
+)
+
+// NewIndexHandler returns an http.Handler which serves GET /index/{hash}, mapping the hex-encoded identity hash
+// of an entry to the index at which it appears in the log. This allows clients which didn't keep hold of the
+// index returned when they submitted an entry to find it again, and so fetch inclusion proofs for it.
+//
+// For entries created with NewEntry, the identity hash is the SHA-256 hash of the entry data.
+// The index is returned as a decimal number in a text/plain response. Hashes which aren't known result in a
+// 404, which may only be temporary: entries become visible once they have been integrated and the antispam
+// follower has caught up with them.
+//
+// The response isn't authenticated, so clients should check that the entry at the returned index is the one
+// they expect, e.g. by fetching its entry bundle and verifying its inclusion in a checkpoint.
+//
+// The handler expects to be mounted at the root of the log's URL space, alongside NewReadHandler.
+func NewIndexHandler(l IndexLookup) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /index/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		h, err := hex.DecodeString(r.PathValue("hash"))
+		if err != nil || len(h) != sha256.Size {
+			writeMalformedURL(w, fmt.Errorf("identity hash must be %d hex-encoded bytes", sha256.Size))
+			return
+		}
+		idx, err := l.LookupIndex(r.Context(), h)
+		if err != nil {
+			// Don't allow negative responses to be cached, as the entry may yet be integrated.
+			w.Header().Set("Cache-Control", "no-cache")
+			writeError(w, "/index/{hash}", err)
+			return
+		}
+		// The first entry with a given identity never moves, so positive responses are immutable.
+		w.Header().Set("Cache-Control", readLogCacheControl)
+		w.Header().Set("Content-Type", readCheckpointContType)
+		if _, err := w.Write(strconv.AppendUint(nil, idx, 10)); err != nil {
+			klog.Errorf("/index/{hash}: failed to write response: %v", err)
+		}
+	})
+	return mux
+}
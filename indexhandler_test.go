@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// indexLookupFunc adapts a function to the IndexLookup interface.
+type indexLookupFunc func(ctx context.Context, h []byte) (uint64, error)
+
+func (f indexLookupFunc) LookupIndex(ctx context.Context, h []byte) (uint64, error) {
+	return f(ctx, h)
+}
+
+func TestIndexHandler(t *testing.T) {
+	known := sha256.Sum256([]byte("known"))
+	unknown := sha256.Sum256([]byte("unknown"))
+	broken := sha256.Sum256(nil)
+	srv := httptest.NewServer(tessera.NewIndexHandler(indexLookupFunc(func(_ context.Context, h []byte) (uint64, error) {
+		switch {
+		case bytes.Equal(h, known[:]):
+			return 1234, nil
+		case bytes.Equal(h, broken[:]):
+			return 0, errors.New("storage is broken")
+		}
+		return 0, fmt.Errorf("%x: %w", h, tessera.ErrNotFound)
+	})))
+	defer srv.Close()
+
+	for _, tC := range []struct {
+		desc      string
+		path      string
+		wantCode  int
+		wantBody  string
+		wantCache string
+	}{
+		{
+			desc:      "known",
+			path:      "/index/" + hex.EncodeToString(known[:]),
+			wantCode:  http.StatusOK,
+			wantBody:  "1234",
+			wantCache: "public, max-age=31536000, immutable",
+		}, {
+			desc:      "unknown",
+			path:      "/index/" + hex.EncodeToString(unknown[:]),
+			wantCode:  http.StatusNotFound,
+			wantCache: "no-cache",
+		}, {
+			desc:      "storage error",
+			path:      "/index/" + hex.EncodeToString(broken[:]),
+			wantCode:  http.StatusInternalServerError,
+			wantCache: "no-cache",
+		}, {
+			desc:     "not hex",
+			path:     "/index/xyz",
+			wantCode: http.StatusBadRequest,
+		}, {
+			desc:     "wrong length",
+			path:     "/index/" + hex.EncodeToString(known[:16]),
+			wantCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tC.path)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if resp.StatusCode != tC.wantCode {
+				t.Fatalf("Got status %d, want %d", resp.StatusCode, tC.wantCode)
+			}
+			if tC.wantCode == http.StatusOK && string(body) != tC.wantBody {
+				t.Errorf("Got body %q, want %q", body, tC.wantBody)
+			}
+			if got := resp.Header.Get("Cache-Control"); tC.wantCache != "" && got != tC.wantCache {
+				t.Errorf("Got Cache-Control %q, want %q", got, tC.wantCache)
+			}
+		})
+	}
+}
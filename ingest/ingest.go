@@ -0,0 +1,258 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ingest provides a gRPC service for high-throughput ingestion of entries into a Tessera log.
+//
+// The service has a single bidirectional streaming Add RPC: clients stream entries to the server, and the
+// server streams back the outcome for each entry as it's sequenced - either the index assigned to it, or
+// the reason it wasn't added. This avoids the per-entry overhead of the HTTP write API for bulk ingestion
+// pipelines.
+//
+// Messages are protobuf well-known types, so no generated code is needed; the service is described in
+// ingest.proto for clients written in other languages.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/transparency-dev/tessera"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// DefaultMaxInFlight is the default number of entries per stream which may be awaiting sequencing.
+	DefaultMaxInFlight = 4096
+
+	serviceName = "tessera.ingest.v1.Ingest"
+	addMethod   = "/" + serviceName + "/Add"
+)
+
+// Options configures the service registered by Register.
+type Options struct {
+	// MaxInFlight is the maximum number of entries per stream which may be awaiting sequencing, beyond
+	// which the server stops reading from the stream until earlier entries have been sequenced.
+	// If zero, DefaultMaxInFlight is used.
+	MaxInFlight int
+}
+
+// ingestServer is the interface which implementations of the service must satisfy.
+type ingestServer interface {
+	add(stream grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ingestServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Add",
+			Handler:       func(srv any, stream grpc.ServerStream) error { return srv.(ingestServer).add(stream) },
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "ingest.proto",
+}
+
+// Register registers the ingest service with s, adding entries to the log using a.
+func Register(s grpc.ServiceRegistrar, a *tessera.Appender, opts Options) {
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = DefaultMaxInFlight
+	}
+	s.RegisterService(&serviceDesc, &server{a: a, opts: opts})
+}
+
+type server struct {
+	a    *tessera.Appender
+	opts Options
+}
+
+// add serves a single Add stream.
+//
+// Entries are read and added to the log in one goroutine, while this one resolves their futures in the
+// order the entries arrived and sends back the outcome for each. The bounded channel between the two
+// applies backpressure to the client via gRPC flow control.
+//
+// An entry which can't be added doesn't end the stream: by the time its future resolves, the entries
+// which follow it may already have been queued for sequencing, so they're reported individually too.
+func (s *server) add(stream grpc.ServerStream) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	futures := make(chan tessera.IndexFuture, s.opts.MaxInFlight)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(futures)
+		for {
+			req := &wrapperspb.BytesValue{}
+			if err := stream.RecvMsg(req); err != nil {
+				if !errors.Is(err, io.EOF) {
+					recvErr <- err
+				}
+				return
+			}
+			f := s.a.Add(ctx, tessera.NewEntry(req.GetValue()))
+			select {
+			case futures <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for f := range futures {
+		resp, err := response(f)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to marshal response: %v", err)
+		}
+		if err := stream.SendMsg(resp); err != nil {
+			return err
+		}
+	}
+	// The receiving goroutine only closes futures after reporting any error.
+	select {
+	case err := <-recvErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// response waits for f to resolve, and returns the message which reports its outcome to the client.
+func response(f tessera.IndexFuture) (*anypb.Any, error) {
+	idx, err := f()
+	if err != nil {
+		return anypb.New(status.Convert(toStatus(err)).Proto())
+	}
+	return anypb.New(wrapperspb.UInt64(idx.Index))
+}
+
+// toStatus converts an error returned by the appender into a gRPC status error.
+func toStatus(err error) error {
+	var c codes.Code
+	switch tessera.HTTPStatusCode(err) {
+	case http.StatusServiceUnavailable:
+		c = codes.Unavailable
+	case http.StatusTooManyRequests:
+		c = codes.ResourceExhausted
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		c = codes.InvalidArgument
+	default:
+		c = codes.Internal
+	}
+	return status.Error(c, err.Error())
+}
+
+// fromStatus converts a gRPC status error into one which wraps the corresponding Tessera error, where
+// there is one.
+func fromStatus(err error) error {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %v", tessera.ErrPushback, err)
+	case codes.ResourceExhausted:
+		return fmt.Errorf("%w: %v", tessera.ErrQuotaExceeded, err)
+	}
+	return err
+}
+
+// EntryError is returned by AddStream.Recv when a single entry wasn't added to the log.
+// The stream remains usable, and Recv may be called again for the next entry.
+type EntryError struct {
+	// Err is the reason the entry wasn't added.
+	Err error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("entry not added: %v", e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// Client is a client for the ingest service.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient returns a client for the ingest service served over cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+// Add opens a new Add stream.
+func (c *Client) Add(ctx context.Context, opts ...grpc.CallOption) (*AddStream, error) {
+	s, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], addMethod, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &AddStream{s: s}, nil
+}
+
+// AddStream is a client's view of an Add stream.
+//
+// As with any gRPC stream, Send and Recv may be called concurrently with each other, but not with
+// themselves.
+type AddStream struct {
+	s grpc.ClientStream
+}
+
+// Send sends an entry to be added to the log.
+func (a *AddStream) Send(data []byte) error {
+	return a.s.SendMsg(wrapperspb.Bytes(data))
+}
+
+// CloseSend indicates that no more entries will be sent. Indices for the entries already sent can
+// still be received.
+func (a *AddStream) CloseSend() error {
+	return a.s.CloseSend()
+}
+
+// Recv returns the index assigned to the next entry, in the order the entries were sent.
+//
+// If that entry wasn't added to the log, the error is an *EntryError and receiving may continue with the
+// following entry. When the log is pushing back, or a write quota has been exhausted, the EntryError wraps
+// tessera.ErrPushback or tessera.ErrQuotaExceeded respectively. The entries for which an EntryError was
+// received are exactly the ones which are safe to resend later.
+//
+// It returns io.EOF once the outcomes of all entries have been received after CloseSend. Any other error
+// means the stream itself has failed: entries whose outcome wasn't received may or may not have been
+// added, so resending them can add duplicates unless the log deduplicates entries.
+func (a *AddStream) Recv() (uint64, error) {
+	resp := &anypb.Any{}
+	if err := a.s.RecvMsg(resp); err != nil {
+		return 0, fromStatus(err)
+	}
+	m, err := resp.UnmarshalNew()
+	if err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	switch m := m.(type) {
+	case *wrapperspb.UInt64Value:
+		return m.GetValue(), nil
+	case *spb.Status:
+		return 0, &EntryError{Err: fromStatus(status.FromProto(m).Err())}
+	default:
+		return 0, fmt.Errorf("unexpected response type %T", m)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	// The test's context is cancelled before cleanup, and shutting down the appender needs it to be live.
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	skey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithBatching(256, 10*time.Millisecond).
+		WithEntryValidator(func(e *tessera.Entry) error {
+			if bytes.Equal(e.Data(), []byte("bad")) {
+				return errors.New("bad entry")
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	Register(srv, a, Options{MaxInFlight: 16})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return NewClient(cc)
+}
+
+func TestAdd(t *testing.T) {
+	c := newTestClient(t)
+	s, err := c.Add(t.Context())
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	const n = 1000
+	errC := make(chan error, 1)
+	go func() {
+		for i := range n {
+			if err := s.Send(fmt.Appendf(nil, "entry %d", i)); err != nil {
+				errC <- err
+				return
+			}
+		}
+		errC <- s.CloseSend()
+	}()
+	for i := range uint64(n) {
+		idx, err := s.Recv()
+		if err != nil {
+			t.Fatalf("Recv %d: %v", i, err)
+		}
+		if idx != i {
+			t.Fatalf("Recv %d: got index %d", i, idx)
+		}
+	}
+	if _, err := s.Recv(); err != io.EOF {
+		t.Errorf("Recv after all indices: got %v, want io.EOF", err)
+	}
+	if err := <-errC; err != nil {
+		t.Errorf("Send: %v", err)
+	}
+}
+
+func TestAddInvalidEntry(t *testing.T) {
+	c := newTestClient(t)
+	s, err := c.Add(t.Context())
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	for _, e := range []string{"good", "bad", "also good"} {
+		if err := s.Send([]byte(e)); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if err := s.CloseSend(); err != nil {
+		t.Fatalf("CloseSend: %v", err)
+	}
+	if idx, err := s.Recv(); err != nil || idx != 0 {
+		t.Fatalf("Recv: got %d, %v, want 0", idx, err)
+	}
+	_, err = s.Recv()
+	var entryErr *EntryError
+	if !errors.As(err, &entryErr) || status.Code(entryErr.Err) != codes.InvalidArgument {
+		t.Fatalf("Recv for invalid entry: got %v, want EntryError with InvalidArgument", err)
+	}
+	// The invalid entry doesn't end the stream, so the entry sent after it is still reported.
+	if idx, err := s.Recv(); err != nil || idx != 1 {
+		t.Fatalf("Recv after invalid entry: got %d, %v, want 1", idx, err)
+	}
+	if _, err := s.Recv(); err != io.EOF {
+		t.Errorf("Recv after all entries: got %v, want io.EOF", err)
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want error
+	}{
+		{err: status.Error(codes.Unavailable, "slow down"), want: tessera.ErrPushback},
+		{err: status.Error(codes.ResourceExhausted, "quota"), want: tessera.ErrQuotaExceeded},
+	} {
+		if got := fromStatus(test.err); !errors.Is(got, test.want) {
+			t.Errorf("fromStatus(%v): got %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: tessera.ErrPushback, want: codes.Unavailable},
+		{err: tessera.ErrQuotaExceeded, want: codes.ResourceExhausted},
+		{err: fmt.Errorf("wrapped: %w", tessera.ErrEntryTooLarge), want: codes.InvalidArgument},
+		{err: tessera.ErrInvalidEntry, want: codes.InvalidArgument},
+		{err: errors.New("boom"), want: codes.Internal},
+	} {
+		if got := status.Code(toStatus(test.err)); got != test.want {
+			t.Errorf("toStatus(%v): got %v, want %v", test.err, got, test.want)
+		}
+	}
+}
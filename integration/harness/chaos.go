@@ -0,0 +1,338 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/sync/errgroup"
+)
+
+// RestartFunc stops the log under test without giving it a chance to shut down cleanly, as though
+// it had crashed, and then starts it again, returning once it is ready to serve requests.
+type RestartFunc func(ctx context.Context) error
+
+// ChaosOptions configures the Chaos check performed by Run.
+type ChaosOptions struct {
+	// Restarts is the number of times to restart the log while entries are being added.
+	// The Chaos check only runs if this is positive, in which case Log.Restart must be set.
+	Restarts int
+	// MaxInterval is the longest time to wait between restarts. The actual wait is chosen at random
+	// up to this value. Defaults to 3 seconds.
+	MaxInterval time.Duration
+	// Writers is the number of concurrent writers adding entries. Defaults to 16.
+	Writers int
+}
+
+// chaosPoint identifies the moment at which the Chaos check restarts the log.
+type chaosPoint int
+
+const (
+	// betweenAdds waits for all in-flight adds to complete, and holds off new ones, before restarting.
+	betweenAdds chaosPoint = iota
+	// afterAccept restarts as soon as an entry has been accepted, while the rest of the batch it was
+	// sequenced in is likely still being integrated.
+	afterAccept
+	// anytime restarts without coordinating with the writers at all. With entries constantly being
+	// added, this will usually catch the log part way through integrating or publishing a checkpoint.
+	anytime
+
+	numChaosPoints
+)
+
+func (p chaosPoint) String() string {
+	switch p {
+	case betweenAdds:
+		return "between adds"
+	case afterAccept:
+		return "after accept"
+	case anytime:
+		return "anytime"
+	}
+	return fmt.Sprintf("chaosPoint(%d)", int(p))
+}
+
+// testChaos adds entries to the log while repeatedly restarting it, and then checks that every entry
+// whose add succeeded is present in the log at the index returned, and that every checkpoint
+// observed along the way is consistent with those which came before it.
+//
+// Adds which fail, e.g. because the log was down, say nothing about whether the entry made it into the
+// log, so they're ignored.
+func testChaos(ctx context.Context, t *testing.T, l Log, opts Options) {
+	if l.Restart == nil {
+		t.Fatal("Chaos check requires Log.Restart to be set")
+	}
+	co := opts.Chaos
+	if co.MaxInterval <= 0 {
+		co.MaxInterval = 3 * time.Second
+	}
+	if co.Writers <= 0 {
+		co.Writers = 16
+	}
+
+	lst, err := client.NewLogStateTracker(ctx, l.ReadTile, nil, l.Verifier, l.Verifier.Name(), client.UnilateralConsensus(l.ReadCheckpoint))
+	if err != nil {
+		t.Fatalf("client.NewLogStateTracker: %v", err)
+	}
+	initial := lst.Latest()
+
+	var (
+		// gate is held for reading by writers for the duration of each add, so that the betweenAdds
+		// restart can quiesce them.
+		gate     sync.RWMutex
+		mu       sync.Mutex
+		accepted = map[uint64][]byte{}
+		maxIndex uint64
+		failed   int
+		// acks is signalled, without blocking, each time an add succeeds.
+		acks = make(chan struct{}, 1)
+		stop = make(chan struct{})
+	)
+	wctx, wcancel := context.WithCancel(ctx)
+	defer wcancel()
+	eg := errgroup.Group{}
+	for w := range co.Writers {
+		eg.Go(func() error {
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return nil
+				case <-wctx.Done():
+					return nil
+				default:
+				}
+				data := fmt.Appendf(nil, "%s/chaos/%d/%d", opts.EntryPrefix, w, i)
+				gate.RLock()
+				idx, err := l.Add(wctx, data)
+				gate.RUnlock()
+
+				mu.Lock()
+				if err != nil {
+					failed++
+					mu.Unlock()
+					// Most likely the log is restarting, give it a moment.
+					time.Sleep(50 * time.Millisecond)
+					continue
+				}
+				if prev, ok := accepted[idx]; ok {
+					mu.Unlock()
+					return fmt.Errorf("index %d assigned to both %q and %q", idx, prev, data)
+				}
+				accepted[idx] = data
+				maxIndex = max(maxIndex, idx)
+				mu.Unlock()
+				select {
+				case acks <- struct{}{}:
+				default:
+				}
+			}
+		})
+	}
+
+	// Restarts happen on the test goroutine so that RestartFuncs are free to fail the test.
+	for n := range co.Restarts {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out before restart %d: %v", n, ctx.Err())
+		case <-time.After(rand.N(co.MaxInterval)):
+		}
+		p := chaosPoint(n % int(numChaosPoints))
+		t.Logf("Restart %d: %v", n, p)
+		var err error
+		switch p {
+		case betweenAdds:
+			gate.Lock()
+			err = l.Restart(ctx)
+			gate.Unlock()
+		case afterAccept:
+			// Drain any stale ack, so that the restart follows a fresh one.
+			select {
+			case <-acks:
+			default:
+			}
+			select {
+			case <-acks:
+			case <-time.After(co.MaxInterval):
+				t.Logf("No entry accepted within %v, restarting anyway", co.MaxInterval)
+			}
+			err = l.Restart(ctx)
+		default:
+			err = l.Restart(ctx)
+		}
+		if err != nil {
+			t.Fatalf("Restart %d (%v): %v", n, p, err)
+		}
+		// Checking consistency after each restart ensures that the log didn't revert to, or fork
+		// from, an earlier state while recovering.
+		if err := awaitUpdate(ctx, lst); err != nil {
+			t.Fatalf("After restart %d (%v): %v", n, p, err)
+		}
+	}
+	// Allow the log to accept some entries after the final restart before stopping the writers.
+	select {
+	case <-acks:
+	case <-time.After(co.MaxInterval):
+	}
+	close(stop)
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(accepted) == 0 {
+		t.Fatalf("No entries were accepted (%d adds failed)", failed)
+	}
+	t.Logf("%d entries accepted, %d adds failed", len(accepted), failed)
+
+	if err := awaitSize(ctx, lst, maxIndex+1); err != nil {
+		t.Fatalf("Accepted entries were not published: %v", err)
+	}
+	verifyEntries(ctx, t, l, initial, lst.Latest(), accepted)
+}
+
+// awaitUpdate retries updating the tracked log state until it succeeds, as the log may take some time
+// to become available again after restarting.
+func awaitUpdate(ctx context.Context, lst *client.LogStateTracker) error {
+	var err error
+	for {
+		if _, _, _, err = lst.Update(ctx); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("lst.Update: %v (%w)", err, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// RestartableInProcessLog is like InProcessLog, but the returned Log can be restarted by the Chaos check.
+//
+// newDriver is called to create the storage driver each time the log is started, and must return a
+// driver for the same underlying storage each time. A crash can only be approximated in-process: the
+// context passed to newDriver and tessera.NewAppender is cancelled without shutting the appender down,
+// and any adds in flight are allowed to resolve before the next appender is created.
+func RestartableInProcessLog(t testing.TB, newDriver func(ctx context.Context) (tessera.Driver, error), opts *tessera.AppendOptions, v note.Verifier) Log {
+	t.Helper()
+	type instance struct {
+		a        *tessera.Appender
+		r        tessera.LogReader
+		shutdown func(context.Context) error
+		cancel   context.CancelFunc
+		inflight sync.WaitGroup
+	}
+	start := func() (*instance, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		d, err := newDriver(ctx)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("newDriver: %v", err)
+		}
+		a, shutdown, r, err := tessera.NewAppender(ctx, d, opts)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("NewAppender: %v", err)
+		}
+		return &instance{a: a, r: r, shutdown: shutdown, cancel: cancel}, nil
+	}
+
+	var mu sync.RWMutex
+	cur, err := start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() *instance {
+		mu.RLock()
+		defer mu.RUnlock()
+		return cur
+	}
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if cur == nil {
+			return
+		}
+		defer cur.cancel()
+		sctx, scancel := context.WithTimeout(context.Background(), time.Minute)
+		defer scancel()
+		if err := cur.shutdown(sctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+
+	return Log{
+		Verifier: v,
+		Add: func(ctx context.Context, data []byte) (uint64, error) {
+			mu.RLock()
+			i := cur
+			if i == nil {
+				mu.RUnlock()
+				return 0, errors.New("log is not running")
+			}
+			i.inflight.Add(1)
+			mu.RUnlock()
+			defer i.inflight.Done()
+			idx, err := i.a.Add(ctx, tessera.NewEntry(data))()
+			return idx.Index, err
+		},
+		ReadCheckpoint: func(ctx context.Context) ([]byte, error) {
+			return get().r.ReadCheckpoint(ctx)
+		},
+		ReadTile: func(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+			return get().r.ReadTile(ctx, level, index, p)
+		},
+		ReadEntryBundle: func(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+			return get().r.ReadEntryBundle(ctx, index, p)
+		},
+		Restart: func(ctx context.Context) error {
+			mu.Lock()
+			old := cur
+			old.cancel()
+			mu.Unlock()
+			old.inflight.Wait()
+
+			i, err := start()
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			cur = i
+			mu.Unlock()
+			return nil
+		},
+	}
+}
+
+// RestartableBinary starts the personality binary as StartBinary does, and returns a RestartFunc which
+// kills the process and starts it again with the same arguments.
+//
+// Since StartBinary fails the test if the binary can't be started, the returned func must only be
+// called from the test's goroutine, which is where the Chaos check calls it.
+func RestartableBinary(t testing.TB, path string, args []string, readyURL string) RestartFunc {
+	t.Helper()
+	stop := StartBinary(t, path, args, readyURL)
+	return func(context.Context) error {
+		stop()
+		stop = StartBinary(t, path, args, readyURL)
+		return nil
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package harness contains a reusable end-to-end test suite for Tessera logs and personalities.
+//
+// The suite writes entries to a log, waits for them to be published, and then checks that they can
+// be read back from the entry bundles and that valid inclusion and consistency proofs can be built
+// for them. It can be pointed at a running personality binary via HTTP, or at a Tessera storage
+// driver running in-process, so that personality authors can exercise their own deployments in CI:
+//
+//	func TestMyPersonality(t *testing.T) {
+//		stop := harness.StartBinary(t, "./my-personality", []string{"--listen=:2024"}, "http://localhost:2024/checkpoint")
+//		defer stop()
+//		u, _ := url.Parse("http://localhost:2024")
+//		log := harness.HTTPLog(t, u, "http://localhost:2024/add", verifier)
+//		harness.Run(t, log, harness.Options{})
+//	}
+//
+// The optional Chaos check codifies the crash-safety contract which every driver must meet: it restarts
+// the log without warning while entries are being added, and then verifies that every entry whose add
+// succeeded is in the log at the index it was given, and that the log's checkpoints remain consistent.
+// Logs opt in by setting Log.Restart, e.g. using RestartableBinary or RestartableInProcessLog.
+package harness
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+// AddFunc adds data to the log under test, and returns the index it was assigned.
+type AddFunc func(ctx context.Context, data []byte) (uint64, error)
+
+// Log describes how the harness interacts with the log under test.
+type Log struct {
+	// Verifier verifies signatures on the log's checkpoints.
+	Verifier note.Verifier
+	// Add writes new entries to the log.
+	Add AddFunc
+	// ReadCheckpoint, ReadTile, and ReadEntryBundle read from the log.
+	ReadCheckpoint  client.CheckpointFetcherFunc
+	ReadTile        client.TileFetcherFunc
+	ReadEntryBundle client.EntryBundleFetcherFunc
+	// Restart, if set, crashes and restarts the log. It's only needed for the Chaos check.
+	Restart RestartFunc
+}
+
+// Options configures the checks performed by Run.
+type Options struct {
+	// NumEntries is the number of distinct entries to write. Defaults to 1024.
+	NumEntries int
+	// ExpectDedup, if set, requires that resubmitting a previously added entry returns the index
+	// originally assigned to it, i.e. that the log under test has antispam enabled.
+	ExpectDedup bool
+	// Timeout bounds the duration of each check. Defaults to 5 minutes.
+	Timeout time.Duration
+	// EntryPrefix, if set, is prepended to the data of each entry written, e.g. to keep entries
+	// distinct between runs against the same log. Defaults to the current time.
+	EntryPrefix string
+	// Chaos, if Chaos.Restarts is positive, enables the Chaos check, which restarts the log at random
+	// points while entries are being added, and verifies that no accepted entry is lost.
+	Chaos ChaosOptions
+}
+
+// Run runs the end-to-end suite against the provided log as subtests of t.
+func Run(t *testing.T, l Log, opts Options) {
+	t.Helper()
+	if opts.NumEntries <= 0 {
+		opts.NumEntries = 1024
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.EntryPrefix == "" {
+		opts.EntryPrefix = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	t.Run("WriteReadProve", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), opts.Timeout)
+		defer cancel()
+		testWriteReadProve(ctx, t, l, opts)
+	})
+	t.Run("Dedup", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), opts.Timeout)
+		defer cancel()
+		testDedup(ctx, t, l, opts)
+	})
+	if opts.Chaos.Restarts > 0 {
+		t.Run("Chaos", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), opts.Timeout)
+			defer cancel()
+			testChaos(ctx, t, l, opts)
+		})
+	}
+}
+
+func testWriteReadProve(ctx context.Context, t *testing.T, l Log, opts Options) {
+	lst, err := client.NewLogStateTracker(ctx, l.ReadTile, nil, l.Verifier, l.Verifier.Name(), client.UnilateralConsensus(l.ReadCheckpoint))
+	if err != nil {
+		t.Fatalf("client.NewLogStateTracker: %v", err)
+	}
+	initial := lst.Latest()
+
+	entryData := func(i int) []byte { return fmt.Appendf(nil, "%s/%d", opts.EntryPrefix, i) }
+	indices := make([]uint64, opts.NumEntries)
+	var maxIndex uint64
+	var mu sync.Mutex
+	eg := errgroup.Group{}
+	for i := range opts.NumEntries {
+		eg.Go(func() error {
+			idx, err := l.Add(ctx, entryData(i))
+			if err != nil {
+				return fmt.Errorf("add(%d): %v", i, err)
+			}
+			indices[i] = idx
+			mu.Lock()
+			defer mu.Unlock()
+			maxIndex = max(maxIndex, idx)
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("Failed to add entries: %v", err)
+	}
+
+	// NewLogStateTracker verifies consistency with the previous checkpoint on every update.
+	if err := awaitSize(ctx, lst, maxIndex+1); err != nil {
+		t.Fatalf("Entries were not published: %v", err)
+	}
+	cp := lst.Latest()
+	if got, want := cp.Size-initial.Size, uint64(opts.NumEntries); got < want {
+		t.Logf("Checkpoint size increased by %d, less than the %d entries added; entries may have been deduplicated", got, want)
+	}
+
+	entries := make(map[uint64][]byte, len(indices))
+	for i, idx := range indices {
+		entries[idx] = entryData(i)
+	}
+	verifyEntries(ctx, t, l, initial, cp, entries)
+}
+
+// verifyEntries checks that each of the entries is present at its index in the log committed to by cp,
+// that it has a valid inclusion proof, and that cp is consistent with initial.
+func verifyEntries(ctx context.Context, t *testing.T, l Log, initial, cp log.Checkpoint, entries map[uint64][]byte) {
+	t.Helper()
+	pb, err := client.NewProofBuilder(ctx, cp.Size, l.ReadTile)
+	if err != nil {
+		t.Fatalf("client.NewProofBuilder: %v", err)
+	}
+	for idx, data := range entries {
+		bundle, err := client.GetEntryBundle(ctx, l.ReadEntryBundle, idx/layout.EntryBundleWidth, cp.Size)
+		if err != nil {
+			t.Fatalf("client.GetEntryBundle(%d): %v", idx/layout.EntryBundleWidth, err)
+		}
+		if got, want := bundle.Entries[idx%layout.EntryBundleWidth], data; !bytes.Equal(got, want) {
+			t.Errorf("Entry at index %d: got %q, want %q", idx, got, want)
+			continue
+		}
+		ip, err := pb.InclusionProof(ctx, idx)
+		if err != nil {
+			t.Errorf("InclusionProof(%d): %v", idx, err)
+			continue
+		}
+		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, idx, cp.Size, rfc6962.DefaultHasher.HashLeaf(data), ip, cp.Hash); err != nil {
+			t.Errorf("VerifyInclusion(%d): %v", idx, err)
+		}
+	}
+
+	if initial.Size > 0 {
+		cproof, err := pb.ConsistencyProof(ctx, initial.Size, cp.Size)
+		if err != nil {
+			t.Fatalf("ConsistencyProof(%d, %d): %v", initial.Size, cp.Size, err)
+		}
+		if err := proof.VerifyConsistency(rfc6962.DefaultHasher, initial.Size, cp.Size, cproof, initial.Hash, cp.Hash); err != nil {
+			t.Errorf("VerifyConsistency(%d, %d): %v", initial.Size, cp.Size, err)
+		}
+	}
+}
+
+func testDedup(ctx context.Context, t *testing.T, l Log, opts Options) {
+	data := fmt.Appendf(nil, "%s/dup", opts.EntryPrefix)
+	first, err := l.Add(ctx, data)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if opts.ExpectDedup {
+		// Antispam implementations are generally only aware of entries once they've been integrated.
+		lst, err := client.NewLogStateTracker(ctx, l.ReadTile, nil, l.Verifier, l.Verifier.Name(), client.UnilateralConsensus(l.ReadCheckpoint))
+		if err != nil {
+			t.Fatalf("client.NewLogStateTracker: %v", err)
+		}
+		if err := awaitSize(ctx, lst, first+1); err != nil {
+			t.Fatalf("Entry was not published: %v", err)
+		}
+	}
+	second, err := l.Add(ctx, data)
+	if err != nil {
+		t.Fatalf("Add(duplicate): %v", err)
+	}
+	if opts.ExpectDedup && second != first {
+		// Followers may take a little while to index new entries, so retry once more before failing.
+		time.Sleep(time.Second)
+		if third, err := l.Add(ctx, data); err != nil || third != first {
+			t.Errorf("Duplicate entry assigned index %d (err %v), want original index %d", third, err, first)
+		}
+	}
+}
+
+// awaitSize blocks until the tracked log has a checkpoint with at least the given size.
+func awaitSize(ctx context.Context, lst *client.LogStateTracker, size uint64) error {
+	for lst.Latest().Size < size {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for checkpoint of size %d (have %d): %w", size, lst.Latest().Size, ctx.Err())
+		case <-time.After(50 * time.Millisecond):
+		}
+		if _, _, _, err := lst.Update(ctx); err != nil {
+			return fmt.Errorf("lst.Update: %v", err)
+		}
+	}
+	return nil
+}
+
+// HTTPLog returns a Log which reads from the tlog-tiles API rooted at readURL, and writes entries by
+// POSTing their data to addURL. The response to a successful POST must contain the decimal index
+// assigned to the entry.
+//
+// readURL may also be a file:// URL for logs which are stored on the local filesystem.
+func HTTPLog(t testing.TB, readURL *url.URL, addURL string, v note.Verifier) Log {
+	t.Helper()
+	hc := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:        256,
+			MaxIdleConnsPerHost: 256,
+		},
+		Timeout: 60 * time.Second,
+	}
+	l := Log{
+		Verifier: v,
+		Add:      HTTPAdd(hc, addURL),
+	}
+	switch readURL.Scheme {
+	case "http", "https":
+		hf, err := client.NewHTTPFetcher(readURL, hc)
+		if err != nil {
+			t.Fatalf("NewHTTPFetcher: %v", err)
+		}
+		l.ReadCheckpoint, l.ReadTile, l.ReadEntryBundle = hf.ReadCheckpoint, hf.ReadTile, hf.ReadEntryBundle
+	case "file":
+		ff := client.FileFetcher{Root: readURL.Path}
+		l.ReadCheckpoint, l.ReadTile, l.ReadEntryBundle = ff.ReadCheckpoint, ff.ReadTile, ff.ReadEntryBundle
+	default:
+		t.Fatalf("Unsupported URL scheme %q", readURL.Scheme)
+	}
+	return l
+}
+
+// HTTPAdd returns an AddFunc which POSTs entry data to addURL, and parses the decimal index from the response.
+func HTTPAdd(hc *http.Client, addURL string) AddFunc {
+	return func(ctx context.Context, data []byte) (uint64, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, addURL, bytes.NewReader(data))
+		if err != nil {
+			return 0, err
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				klog.Warningf("resp.Body.Close(): %v", err)
+			}
+		}()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read response from %s: %w", addURL, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("code: %s, path: %s, body: %s", resp.Status, addURL, strings.TrimSpace(string(body)))
+		}
+		return strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+	}
+}
+
+// InProcessLog returns a Log backed by an Appender created from the provided storage driver and options,
+// which must include a checkpoint signer whose signatures v verifies.
+//
+// The appender is shut down when the test completes.
+func InProcessLog(t testing.TB, d tessera.Driver, opts *tessera.AppendOptions, v note.Verifier) Log {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	a, shutdown, r, err := tessera.NewAppender(ctx, d, opts)
+	if err != nil {
+		cancel()
+		t.Fatalf("NewAppender: %v", err)
+	}
+	t.Cleanup(func() {
+		defer cancel()
+		sctx, scancel := context.WithTimeout(context.Background(), time.Minute)
+		defer scancel()
+		if err := shutdown(sctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+	return Log{
+		Verifier: v,
+		Add: func(ctx context.Context, data []byte) (uint64, error) {
+			idx, err := a.Add(ctx, tessera.NewEntry(data))()
+			return idx.Index, err
+		},
+		ReadCheckpoint:  r.ReadCheckpoint,
+		ReadTile:        r.ReadTile,
+		ReadEntryBundle: r.ReadEntryBundle,
+	}
+}
+
+// StartBinary runs the personality binary at path with the given arguments, and waits until a GET
+// request to readyURL succeeds. The binary is killed when the returned function is called, or when
+// the test completes.
+func StartBinary(t testing.TB, path string, args []string, readyURL string) func() {
+	t.Helper()
+	cmd := exec.Command(path, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start %s: %v", path, err)
+	}
+	exited := make(chan struct{})
+	var waitErr error
+	go func() {
+		waitErr = cmd.Wait()
+		close(exited)
+	}()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				t.Logf("Failed to kill %s: %v", path, err)
+			}
+			<-exited
+		})
+	}
+	t.Cleanup(stop)
+
+	deadline := time.Now().Add(time.Minute)
+	for {
+		resp, err := http.Get(readyURL)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return stop
+			}
+			err = fmt.Errorf("got status %s", resp.Status)
+		}
+		select {
+		case <-exited:
+			t.Fatalf("%s exited before becoming ready: %v", path, waitErr)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			stop()
+			t.Fatalf("%s did not become ready at %s: %v", path, readyURL, err)
+		}
+	}
+}
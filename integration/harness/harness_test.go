@@ -0,0 +1,81 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness_test
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/integration/harness"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestInProcessPOSIX(t *testing.T) {
+	sk, vk, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	d, err := posix.New(t.Context(), t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	opts := tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 100*time.Millisecond).
+		WithAntispam(256, nil)
+
+	harness.Run(t, harness.InProcessLog(t, d, opts, v), harness.Options{NumEntries: 300, ExpectDedup: true})
+}
+
+func TestInProcessPOSIXChaos(t *testing.T) {
+	sk, vk, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	dir := t.TempDir()
+	newDriver := func(ctx context.Context) (tessera.Driver, error) {
+		return posix.New(ctx, dir)
+	}
+	opts := tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 100*time.Millisecond)
+
+	harness.Run(t, harness.RestartableInProcessLog(t, newDriver, opts, v), harness.Options{
+		NumEntries: 100,
+		Chaos:      harness.ChaosOptions{Restarts: 6, MaxInterval: 1500 * time.Millisecond},
+	})
+}
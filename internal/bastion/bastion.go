@@ -0,0 +1,158 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bastion implements the backend side of the https://c2sp.org/https-bastion
+// reverse-connection protocol.
+//
+// A personality uses this to dial out to a bastion host and serve HTTP requests
+// over that connection, allowing clients which can reach the bastion (e.g. witnesses)
+// to talk to the personality without it exposing any additional public endpoints.
+package bastion
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// alpnProto is the ALPN protocol identifier used when connecting to a bastion.
+	alpnProto = "bastion/0"
+
+	// maxBackoff is the longest we'll wait between attempts to reconnect to the bastion.
+	maxBackoff = time.Minute
+)
+
+// Config describes how to connect to a bastion host.
+type Config struct {
+	// Addr is the host:port of the bastion.
+	Addr string
+	// Key is the Ed25519 key used to authenticate to the bastion.
+	// The bastion routes requests for /<BackendID>/ to this backend.
+	Key ed25519.PrivateKey
+	// RootCAs is used to verify the bastion's TLS certificate.
+	// If nil, the host's root CA set will be used.
+	RootCAs *x509.CertPool
+}
+
+// BackendID returns the identifier under which the bastion will route requests to
+// a backend using the provided key, i.e. the hex-encoded SHA-256 hash of the public key.
+func BackendID(k ed25519.PublicKey) string {
+	h := sha256.Sum256(k)
+	return hex.EncodeToString(h[:])
+}
+
+// Serve connects to the bastion described by cfg and serves requests arriving over
+// that connection with the provided handler.
+//
+// Requests arrive with the /<BackendID> path prefix already removed by the bastion.
+//
+// Serve reconnects with backoff if the connection to the bastion is lost, and only
+// returns once the provided context is done.
+func Serve(ctx context.Context, cfg Config, h http.Handler) error {
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Registering with bastion %q as backend %s", cfg.Addr, BackendID(cfg.Key.Public().(ed25519.PublicKey)))
+
+	backoff := time.Second
+	for {
+		start := time.Now()
+		if err := serveOnce(ctx, cfg.Addr, tlsCfg, h); err != nil {
+			klog.Warningf("Connection to bastion %q: %v", cfg.Addr, err)
+		}
+		if time.Since(start) > maxBackoff {
+			// The connection was healthy for a while, start backing off from scratch.
+			backoff = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// serveOnce dials the bastion and serves HTTP/2 over the resulting connection until
+// either it is closed or the context is done.
+func serveOnce(ctx context.Context, addr string, tlsCfg *tls.Config, h http.Handler) error {
+	d := tls.Dialer{Config: tlsCfg}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to dial: %v", err)
+	}
+	if p := conn.(*tls.Conn).ConnectionState().NegotiatedProtocol; p != alpnProto {
+		_ = conn.Close()
+		return fmt.Errorf("bastion negotiated unexpected protocol %q", p)
+	}
+	klog.V(1).Infof("Connected to bastion %q", addr)
+
+	// Ensure that we tear down the connection when the context is done.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	// In the bastion protocol the roles are reversed once the connection is
+	// established: the bastion is the HTTP/2 client, and we're the server.
+	(&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{
+		Context: ctx,
+		Handler: h,
+	})
+	return errors.New("connection closed")
+}
+
+// tlsConfig returns the client TLS config needed to authenticate to the bastion.
+func tlsConfig(cfg Config) (*tls.Config, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("bastion address must be set")
+	}
+	if len(cfg.Key) != ed25519.PrivateKeySize {
+		return nil, errors.New("bastion key must be a valid Ed25519 private key")
+	}
+	// The bastion only cares about the public key in the client certificate, so a
+	// minimal self-signed certificate will do.
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(100 * 365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, cfg.Key.Public(), cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client certificate: %v", err)
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		NextProtos: []string{alpnProto},
+		RootCAs:    cfg.RootCAs,
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der},
+			PrivateKey:  cfg.Key,
+		}},
+	}, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bastion
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	serverCert, roots := selfSignedServerCert(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		NextProtos:   []string{alpnProto},
+		ClientAuth:   tls.RequireAnyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from "+r.URL.Path)
+	})
+	go func() {
+		_ = Serve(ctx, Config{Addr: l.Addr().String(), Key: k, RootCAs: roots}, h)
+	}()
+
+	// Play the role of the bastion: accept the backend connection, and send it a request.
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	tc := c.(*tls.Conn)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		t.Fatalf("Handshake: %v", err)
+	}
+	peer := tc.ConnectionState().PeerCertificates
+	if len(peer) == 0 {
+		t.Fatal("Backend presented no client certificate")
+	}
+	if got, want := BackendID(peer[0].PublicKey.(ed25519.PublicKey)), BackendID(k.Public().(ed25519.PublicKey)); got != want {
+		t.Errorf("Got backend ID %q, want %q", got, want)
+	}
+
+	cc, err := (&http2.Transport{}).NewClientConn(tc)
+	if err != nil {
+		t.Fatalf("NewClientConn: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://bastion/checkpoint", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := cc.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if got, want := string(b), "hello from /checkpoint"; got != want {
+		t.Errorf("Got body %q, want %q", got, want)
+	}
+}
+
+func TestTLSConfigInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "no address",
+			cfg:  Config{Key: ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))},
+		}, {
+			name: "no key",
+			cfg:  Config{Addr: "bastion:443"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := tlsConfig(test.cfg); err == nil {
+				t.Error("tlsConfig: got nil error, want error")
+			}
+		})
+	}
+}
+
+func selfSignedServerCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, k.Public(), k)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: k}, pool
+}
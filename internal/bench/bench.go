@@ -0,0 +1,242 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench drives Tessera storage drivers directly, without any HTTP personality in front of them,
+// in order to measure their sequencing latency and integration throughput.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/sync/errgroup"
+)
+
+// Options describes the workload to run against a storage driver.
+type Options struct {
+	// NumEntries is the total number of entries to add to the log.
+	NumEntries int
+	// EntrySize is the size in bytes of each entry. Entries are at least 8 bytes long, as each one is
+	// made unique by prefixing it with its sequence number in the run.
+	EntrySize int
+	// Concurrency is the number of goroutines concurrently adding entries.
+	Concurrency int
+
+	// BatchMaxSize and BatchMaxAge are passed to tessera.AppendOptions.WithBatching.
+	BatchMaxSize uint
+	BatchMaxAge  time.Duration
+	// CheckpointInterval is passed to tessera.AppendOptions.WithCheckpointInterval.
+	CheckpointInterval time.Duration
+
+	// Signer signs the log's checkpoints. Storage remembers the identity of the first signer used with it,
+	// so repeated runs against the same driver must use the same signer.
+	// If unset, Run uses a new ephemeral key, which is only suitable for a single run against fresh storage.
+	Signer note.Signer
+}
+
+// DefaultOptions returns a modest workload suitable for a quick comparison run.
+func DefaultOptions() Options {
+	return Options{
+		NumEntries:         10_000,
+		EntrySize:          1024,
+		Concurrency:        64,
+		BatchMaxSize:       tessera.DefaultBatchMaxSize,
+		BatchMaxAge:        tessera.DefaultBatchMaxAge,
+		CheckpointInterval: tessera.DefaultCheckpointInterval,
+	}
+}
+
+// Latency summarises a set of latency observations.
+type Latency struct {
+	Mean, P50, P95, P99, Max time.Duration
+}
+
+func (l Latency) String() string {
+	return fmt.Sprintf("mean=%v p50=%v p95=%v p99=%v max=%v", l.Mean, l.P50, l.P95, l.P99, l.Max)
+}
+
+// Result holds the measurements taken during a run.
+type Result struct {
+	// Entries is the number of entries added to the log.
+	Entries int
+	// SequencingLatency summarises the time taken for each call to Add to return an index.
+	SequencingLatency Latency
+	// SequencingDuration is the time taken for all entries to be assigned an index.
+	SequencingDuration time.Duration
+	// IntegrationDuration is the time taken for all entries to be integrated into the tree.
+	IntegrationDuration time.Duration
+}
+
+// SequencingThroughput returns the rate, in entries per second, at which entries were sequenced.
+func (r Result) SequencingThroughput() float64 {
+	return float64(r.Entries) / r.SequencingDuration.Seconds()
+}
+
+// IntegrationThroughput returns the rate, in entries per second, at which entries were integrated.
+func (r Result) IntegrationThroughput() float64 {
+	return float64(r.Entries) / r.IntegrationDuration.Seconds()
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("entries=%d sequencing: %.1f/s (%v) integration: %.1f/s latency: %v",
+		r.Entries, r.SequencingThroughput(), r.SequencingDuration, r.IntegrationThroughput(), r.SequencingLatency)
+}
+
+// integrationPollInterval is how frequently the integrated size of the log is checked once all entries
+// have been sequenced.
+const integrationPollInterval = 10 * time.Millisecond
+
+// Run adds entries to a new appender on top of the provided driver as described by opts, and waits for
+// them to be integrated.
+//
+// The driver may already contain entries, in which case integration is considered complete once all of the
+// entries added by this run have been integrated.
+func Run(ctx context.Context, driver tessera.Driver, opts Options) (Result, error) {
+	if opts.NumEntries <= 0 || opts.Concurrency <= 0 {
+		return Result{}, errors.New("NumEntries and Concurrency must be positive")
+	}
+	s := opts.Signer
+	if s == nil {
+		var err error
+		if s, err = NewSigner(); err != nil {
+			return Result{}, err
+		}
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithBatching(opts.BatchMaxSize, opts.BatchMaxAge).
+		WithCheckpointInterval(opts.CheckpointInterval))
+	if err != nil {
+		return Result{}, fmt.Errorf("NewAppender: %v", err)
+	}
+	defer func() {
+		_ = shutdown(ctx)
+	}()
+
+	var (
+		next       atomic.Int64
+		mu         sync.Mutex
+		latencies  = make([]time.Duration, 0, opts.NumEntries)
+		maxIndex   uint64
+		eG, eCtx   = errgroup.WithContext(ctx)
+		entrySize  = max(opts.EntrySize, 8)
+		start      = time.Now()
+		sequenced  time.Duration
+		integrated time.Duration
+	)
+	for range opts.Concurrency {
+		eG.Go(func() error {
+			tmpl := make([]byte, entrySize)
+			if _, err := rand.Read(tmpl); err != nil {
+				return err
+			}
+			for {
+				i := next.Add(1) - 1
+				if i >= int64(opts.NumEntries) {
+					return nil
+				}
+				// Drivers may hold on to the entry data after Add has returned, so it can't be reused.
+				data := bytes.Clone(tmpl)
+				binary.BigEndian.PutUint64(data, uint64(i))
+				t := time.Now()
+				idx, err := appender.Add(eCtx, tessera.NewEntry(data))()
+				if err != nil {
+					return fmt.Errorf("Add: %v", err)
+				}
+				d := time.Since(t)
+				mu.Lock()
+				latencies = append(latencies, d)
+				maxIndex = max(maxIndex, idx.Index)
+				mu.Unlock()
+			}
+		})
+	}
+	if err := eG.Wait(); err != nil {
+		return Result{}, err
+	}
+	sequenced = time.Since(start)
+
+	for {
+		size, err := reader.IntegratedSize(ctx)
+		if err != nil {
+			return Result{}, fmt.Errorf("IntegratedSize: %v", err)
+		}
+		if size > maxIndex {
+			integrated = time.Since(start)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		case <-time.After(integrationPollInterval):
+		}
+	}
+
+	return Result{
+		Entries:             opts.NumEntries,
+		SequencingLatency:   summarise(latencies),
+		SequencingDuration:  sequenced,
+		IntegrationDuration: integrated,
+	}, nil
+}
+
+// summarise returns the summary statistics for the provided latencies, which will be sorted in place.
+func summarise(l []time.Duration) Latency {
+	if len(l) == 0 {
+		return Latency{}
+	}
+	slices.Sort(l)
+	var total time.Duration
+	for _, d := range l {
+		total += d
+	}
+	pct := func(p int) time.Duration {
+		return l[(len(l)-1)*p/100]
+	}
+	return Latency{
+		Mean: total / time.Duration(len(l)),
+		P50:  pct(50),
+		P95:  pct(95),
+		P99:  pct(99),
+		Max:  l[len(l)-1],
+	}
+}
+
+// NewSigner returns a note signer for a new ephemeral key, which can be shared by several runs.
+func NewSigner() (note.Signer, error) {
+	skey, _, err := note.GenerateKey(rand.Reader, "bench.transparency.dev")
+	if err != nil {
+		return nil, fmt.Errorf("GenerateKey: %v", err)
+	}
+	return note.NewSigner(skey)
+}
+
+// Report records the measurements in r as custom metrics on the provided benchmark.
+func Report(b *testing.B, r Result) {
+	b.ReportMetric(r.SequencingThroughput(), "seq-entries/s")
+	b.ReportMetric(r.IntegrationThroughput(), "int-entries/s")
+	b.ReportMetric(float64(r.SequencingLatency.P50.Microseconds()), "p50-µs")
+	b.ReportMetric(float64(r.SequencingLatency.P99.Microseconds()), "p99-µs")
+}
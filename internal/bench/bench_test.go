@@ -0,0 +1,104 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/bench"
+	"github.com/transparency-dev/tessera/storage/posix"
+)
+
+func TestRun(t *testing.T) {
+	ctx := t.Context()
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	opts := bench.Options{
+		NumEntries:         500,
+		EntrySize:          16,
+		Concurrency:        8,
+		BatchMaxSize:       64,
+		BatchMaxAge:        10 * time.Millisecond,
+		CheckpointInterval: time.Second,
+	}
+	r, err := bench.Run(ctx, driver, opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if r.Entries != opts.NumEntries {
+		t.Errorf("Got %d entries, want %d", r.Entries, opts.NumEntries)
+	}
+	if r.SequencingDuration <= 0 || r.IntegrationDuration < r.SequencingDuration {
+		t.Errorf("Got sequencing duration %v and integration duration %v", r.SequencingDuration, r.IntegrationDuration)
+	}
+	if l := r.SequencingLatency; l.P50 > l.P99 || l.P99 > l.Max {
+		t.Errorf("Latency percentiles out of order: %v", l)
+	}
+}
+
+func TestRunRepeated(t *testing.T) {
+	ctx := t.Context()
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	s, err := bench.NewSigner()
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	opts := bench.Options{
+		NumEntries:         100,
+		EntrySize:          16,
+		Concurrency:        4,
+		BatchMaxSize:       64,
+		BatchMaxAge:        10 * time.Millisecond,
+		CheckpointInterval: time.Second,
+		Signer:             s,
+	}
+	// The second run must pass the log identity check against the storage used by the first.
+	for i := range 2 {
+		if _, err := bench.Run(ctx, driver, opts); err != nil {
+			t.Fatalf("Run %d: %v", i, err)
+		}
+	}
+}
+
+func BenchmarkPOSIX(b *testing.B) {
+	for _, entrySize := range []int{64, 1024, 16 * 1024} {
+		for _, concurrency := range []int{1, 16, 256} {
+			b.Run(fmt.Sprintf("size=%d/concurrency=%d", entrySize, concurrency), func(b *testing.B) {
+				driver, err := posix.New(b.Context(), b.TempDir())
+				if err != nil {
+					b.Fatalf("posix.New: %v", err)
+				}
+				opts := bench.DefaultOptions()
+				opts.EntrySize = entrySize
+				opts.Concurrency = concurrency
+				opts.NumEntries = b.N
+				opts.BatchMaxAge = 10 * time.Millisecond
+				b.ResetTimer()
+				r, err := bench.Run(b.Context(), driver, opts)
+				if err != nil {
+					b.Fatalf("Run: %v", err)
+				}
+				bench.Report(b, r)
+			})
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debug provides an admin HTTP handler which exposes profiling and runtime diagnostics
+// for use when investigating the performance of a running personality.
+//
+// The net/http/pprof package is deliberately not used, since importing it registers its handlers on
+// http.DefaultServeMux, which many personalities use to serve their public API.
+package debug
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// maxProfileDuration bounds the duration of CPU profiles and execution traces.
+const maxProfileDuration = 5 * time.Minute
+
+// NewHandler returns an http.Handler which serves:
+//   - /debug/pprof/: profiles in the format expected by `go tool pprof`, as served by net/http/pprof,
+//   - /debug/runtime: the current values of all runtime/metrics, as text,
+//   - /debug/goroutines: a dump of the stacks of all goroutines.
+//
+// The handler exposes sensitive information about the process, so it must only be served on an address
+// which is not publicly reachable.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/pprof/{$}", pprofIndex)
+	mux.HandleFunc("GET /debug/pprof/profile", cpuProfile)
+	mux.HandleFunc("GET /debug/pprof/trace", execTrace)
+	mux.HandleFunc("GET /debug/pprof/{name}", namedProfile)
+	mux.HandleFunc("GET /debug/runtime", runtimeMetrics)
+	mux.HandleFunc("GET /debug/goroutines", goroutines)
+	return mux
+}
+
+// Serve starts serving the handler returned by NewHandler on addr in the background.
+//
+// This is a no-op if addr is empty, allowing it to be wired directly to an optional flag.
+func Serve(addr string) {
+	if addr == "" {
+		return
+	}
+	go func() {
+		klog.Infof("Serving debug handlers on %s", addr)
+		if err := http.ListenAndServe(addr, NewHandler()); err != nil {
+			klog.Errorf("Debug listener: %v", err)
+		}
+	}()
+}
+
+func pprofIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprintln(w, "profile\ntrace")
+	for _, p := range pprof.Profiles() {
+		_, _ = fmt.Fprintf(w, "%s (%d)\n", p.Name(), p.Count())
+	}
+}
+
+func cpuProfile(w http.ResponseWriter, r *http.Request) {
+	d, err := duration(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := pprof.StartCPUProfile(w); err != nil {
+		http.Error(w, fmt.Sprintf("failed to start CPU profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, d)
+	pprof.StopCPUProfile()
+}
+
+func execTrace(w http.ResponseWriter, r *http.Request) {
+	d, err := duration(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := trace.Start(w); err != nil {
+		http.Error(w, fmt.Sprintf("failed to start trace: %v", err), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, d)
+	trace.Stop()
+}
+
+func namedProfile(w http.ResponseWriter, r *http.Request) {
+	p := pprof.Lookup(r.PathValue("name"))
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if p.Name() == "heap" && r.FormValue("gc") != "" {
+		runtime.GC()
+	}
+	if debug == 0 {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	if err := p.WriteTo(w, debug); err != nil {
+		klog.Errorf("Failed to write %s profile: %v", p.Name(), err)
+	}
+}
+
+func runtimeMetrics(w http.ResponseWriter, _ *http.Request) {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i, d := range descs {
+		samples[i].Name = d.Name
+	}
+	metrics.Read(samples)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			_, _ = fmt.Fprintf(w, "%s %d\n", s.Name, s.Value.Uint64())
+		case metrics.KindFloat64:
+			_, _ = fmt.Fprintf(w, "%s %g\n", s.Name, s.Value.Float64())
+		case metrics.KindFloat64Histogram:
+			h := s.Value.Float64Histogram()
+			var n uint64
+			for _, c := range h.Counts {
+				n += c
+			}
+			_, _ = fmt.Fprintf(w, "%s count=%d\n", s.Name, n)
+		}
+	}
+}
+
+func goroutines(w http.ResponseWriter, _ *http.Request) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(buf)
+}
+
+// duration returns the duration requested by the seconds parameter of r, defaulting to 30s.
+func duration(r *http.Request) (time.Duration, error) {
+	s := r.FormValue("seconds")
+	if s == "" {
+		return 30 * time.Second, nil
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid seconds %q", s)
+	}
+	d := time.Duration(n * float64(time.Second))
+	if d > maxProfileDuration {
+		return 0, fmt.Errorf("seconds must be no more than %v", maxProfileDuration.Seconds())
+	}
+	return d, nil
+}
+
+// sleep waits for d, or until the client goes away.
+func sleep(r *http.Request, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/transparency-dev/tessera/internal/debug"
+)
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(debug.NewHandler())
+	defer srv.Close()
+
+	for _, test := range []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/debug/pprof/", wantStatus: http.StatusOK, wantBody: "goroutine"},
+		{path: "/debug/pprof/goroutine?debug=1", wantStatus: http.StatusOK, wantBody: "TestHandler"},
+		{path: "/debug/pprof/heap?gc=1", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/profile?seconds=0.1", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/profile?seconds=-1", wantStatus: http.StatusBadRequest},
+		{path: "/debug/pprof/trace?seconds=0.1", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/nope", wantStatus: http.StatusNotFound},
+		{path: "/debug/runtime", wantStatus: http.StatusOK, wantBody: "/sched/goroutines:goroutines "},
+		{path: "/debug/goroutines", wantStatus: http.StatusOK, wantBody: "goroutine "},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + test.path)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if resp.StatusCode != test.wantStatus {
+				t.Errorf("Got status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+			if !strings.Contains(string(body), test.wantBody) {
+				t.Errorf("Body doesn't contain %q:\n%s", test.wantBody, body)
+			}
+		})
+	}
+
+	// The handlers must not leak onto the default mux, which personalities use for their public API.
+	rec := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("http.DefaultServeMux served /debug/pprof/ with status %d", rec.Code)
+	}
+}
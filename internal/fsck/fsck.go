@@ -190,7 +190,7 @@ func (f *fsckTree) visit(id compact.NodeID, h []byte) {
 		f.expectedResources <- resource{
 			level:   uint64(tLevel),
 			index:   tIdx,
-			partial: uint8(len(t.Nodes)),
+			partial: 0,
 			content: c,
 		}
 		delete(f.pendingTiles, k)
@@ -205,10 +205,14 @@ func (f *fsckTree) flushPartialTiles() {
 		if err != nil {
 			klog.Exitf("Failed to marshal tile: %v", err)
 		}
+		p, err := t.Partial()
+		if err != nil {
+			klog.Exitf("Invalid tile (l: %d, idx: %d): %v", k.Level, k.Index, err)
+		}
 		f.expectedResources <- resource{
 			level:   uint64(k.Level),
 			index:   k.Index,
-			partial: uint8(len(t.Nodes)),
+			partial: p,
 			content: c,
 		}
 		delete(f.pendingTiles, k)
@@ -23,6 +23,7 @@ import (
 	"math/rand/v2"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -38,17 +39,23 @@ import (
 func init() {
 	flag.Var(&logURL, "log_url", "Log storage root URL (can be specified multiple times), e.g. https://log.server/and/path/")
 	flag.Var(&writeLogURL, "write_log_url", "Root URL for writing to a log (can be specified multiple times), e.g. https://log.server/and/path/ (optional, defaults to log_url)")
+	flag.Var(&logURLWeights, "log_url_weights", "Comma separated relative weights of read traffic for each --log_url, in the same order, e.g. 1,3 (optional, defaults to an even split)")
+	flag.Var(&writeLogURLWeights, "write_log_url_weights", "Comma separated relative weights of write traffic for each --write_log_url, in the same order (optional, defaults to an even split)")
 }
 
 var (
 	logURL      multiStringFlag
 	writeLogURL multiStringFlag
 
+	logURLWeights      weightsFlag
+	writeLogURLWeights weightsFlag
+
 	logPubKey = flag.String("log_public_key", os.Getenv("TILES_LOG_PUBLIC_KEY"), "Public key for the log. This is defaulted to the environment variable TILES_LOG_PUBLIC_KEY")
 
 	maxReadOpsPerSecond = flag.Int("max_read_ops", 20, "The maximum number of read operations per second")
 	numReadersRandom    = flag.Int("num_readers_random", 4, "The number of readers looking for random leaves")
 	numReadersFull      = flag.Int("num_readers_full", 4, "The number of readers downloading the whole log")
+	validateReads       = flag.Bool("validate_reads", false, "Whether the random readers should check that the entry bundles they read match the log's tiles and checkpoint")
 
 	maxWriteOpsPerSecond = flag.Int("max_write_ops", 0, "The maximum number of write operations per second")
 	numWriters           = flag.Int("num_writers", 0, "The number of independent write tasks to run")
@@ -56,6 +63,10 @@ var (
 	leafMinSize = flag.Int("leaf_min_size", 0, "Minimum size in bytes of individual leaves")
 	dupChance   = flag.Float64("dup_chance", 0.1, "The probability of a generated leaf being a duplicate of a previous value")
 
+	seed           = flag.Uint64("seed", 0, "Seed for the random choices made by the hammer, i.e. leaf contents, duplicate injection, and the leaves read by random readers. If 0, a random seed is chosen and logged, so that it can be passed to a later run")
+	recordWorkload = flag.String("record_workload", "", "If set, the path of a file to record the leaves written, and the leaves read by random readers, to, so that the run can be repeated with --replay_workload")
+	replayWorkload = flag.String("replay_workload", "", "If set, the path of a file recorded with --record_workload, whose operations are issued instead of generating new ones. The hammer exits once they've all been issued and have completed")
+
 	leafWriteGoal = flag.Int64("leaf_write_goal", 0, "Exit after writing this number of leaves, or 0 to keep going indefinitely")
 	maxRunTime    = flag.Duration("max_runtime", 0, "Fail after this amount of time has passed, or 0 to keep going indefinitely")
 
@@ -66,6 +77,12 @@ var (
 
 	forceHTTP2 = flag.Bool("force_http2", false, "Use HTTP/2 connections *only*")
 
+	pprofURL         = flag.String("pprof_url", "", "If set, the root URL of the target's pprof endpoints to periodically scrape profiles from during the run, e.g. http://log.server:6060/debug/pprof/")
+	pprofInterval    = flag.Duration("pprof_interval", time.Minute, "The time between successive rounds of profiling when --pprof_url is set")
+	pprofCPUDuration = flag.Duration("pprof_cpu_duration", 10*time.Second, "The length of each CPU profile taken when --pprof_url is set")
+	pprofProfiles    = flag.String("pprof_profiles", "profile,heap,goroutine", "Comma separated names of the pprof endpoints to scrape in each round when --pprof_url is set")
+	pprofDir         = flag.String("pprof_dir", "hammer-profiles", "Directory in which to write the scraped profiles, along with a report relating them to the load at the time")
+
 	hc = &http.Client{
 		Transport: &http.Transport{
 			MaxIdleConns:        256,
@@ -91,6 +108,14 @@ func main() {
 		*bearerTokenWrite = *bearerToken
 	}
 
+	if *recordWorkload != "" && *replayWorkload != "" {
+		klog.Exit("--record_workload and --replay_workload are mutually exclusive")
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+	klog.Infof("Using --seed=%d", *seed)
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	logSigV, err := note.NewVerifier(*logPubKey)
@@ -98,10 +123,12 @@ func main() {
 		klog.Exitf("failed to create verifier: %v", err)
 	}
 
-	f, w, err := loadtest.NewLogClients(logURL, writeLogURL, loadtest.ClientOpts{
+	f, w, targets, err := loadtest.NewLogClients(logURL, writeLogURL, loadtest.ClientOpts{
 		Client:           hc,
 		BearerToken:      *bearerToken,
 		BearerTokenWrite: *bearerTokenWrite,
+		ReadWeights:      logURLWeights,
+		WriteWeights:     writeLogURLWeights,
 	})
 	if err != nil {
 		klog.Exit(err)
@@ -122,15 +149,53 @@ func main() {
 	ha := loadtest.NewHammerAnalyser(func() uint64 { return tracker.Latest().Size })
 	ha.Run(ctx)
 
-	gen := newLeafGenerator(tracker.Latest().Size, *leafMinSize, *dupChance)
+	gen := newLeafGenerator(tracker.Latest().Size, *leafMinSize, *dupChance, *seed)
 	opts := loadtest.HammerOpts{
 		MaxReadOpsPerSecond:  *maxReadOpsPerSecond,
 		MaxWriteOpsPerSecond: *maxWriteOpsPerSecond,
 		NumReadersRandom:     *numReadersRandom,
 		NumReadersFull:       *numReadersFull,
 		NumWriters:           *numWriters,
+		ValidateReads:        *validateReads,
+		Seed:                 *seed,
+	}
+	var recorder *loadtest.WorkloadRecorder
+	var recordFile *os.File
+	if *recordWorkload != "" {
+		recordFile, err = os.Create(*recordWorkload)
+		if err != nil {
+			klog.Exitf("Failed to create workload recording: %v", err)
+		}
+		recorder = loadtest.NewWorkloadRecorder(recordFile)
+		gen = recorder.Gen(gen)
+		opts.RandomNextLeaf = func(seed uint64) func(uint64) uint64 {
+			return recorder.NextLeaf(loadtest.RandomNextLeaf(seed))
+		}
+		klog.Infof("Recording workload to %s", *recordWorkload)
+	}
+	if *replayWorkload != "" {
+		replayer := newWorkloadReplayerOrDie(*replayWorkload)
+		if n := replayer.Writes(); n > 0 && *numWriters == 0 {
+			klog.Exitf("%s has %d writes to replay, but --num_writers=0", *replayWorkload, n)
+		}
+		if n := replayer.Reads(); n > 0 && *numReadersRandom == 0 {
+			klog.Exitf("%s has %d random reads to replay, but --num_readers_random=0", *replayWorkload, n)
+		}
+		gen = replayer.Gen
+		opts.RandomNextLeaf = func(uint64) func(uint64) uint64 {
+			return replayer.NextLeaf
+		}
+		opts.OpDone = replayer.OpDone
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-replayer.Done():
+				klog.Infof("Replayed all operations from %s; exiting", *replayWorkload)
+				cancel()
+			}
+		}()
 	}
-	hammer := loadtest.NewHammer(tracker, f.ReadEntryBundle, w, gen, ha.SeqLeafChan, ha.ErrChan, opts)
+	hammer := loadtest.NewHammer(tracker, f.ReadEntryBundle, f.ReadTile, w, gen, ha.SeqLeafChan, ha.ErrChan, opts)
 
 	exitCode := 0
 	if *leafWriteGoal > 0 {
@@ -172,15 +237,81 @@ func main() {
 	}
 	hammer.Run(ctx)
 
+	var profiler *loadtest.Profiler
+	if *pprofURL != "" {
+		// Profiles are fetched with their own client, as CPU profiles can take longer than the timeout on hc.
+		profiler, err = loadtest.NewProfiler(loadtest.ProfilerOpts{
+			URL:         *pprofURL,
+			Dir:         *pprofDir,
+			Interval:    *pprofInterval,
+			CPUDuration: *pprofCPUDuration,
+			Profiles:    strings.Split(*pprofProfiles, ","),
+		}, func() loadtest.LoadLevel {
+			l := hammer.LoadLevel()
+			l.IntegrationTimeMillis = ha.IntegrationTime.Avg()
+			return l
+		})
+		if err != nil {
+			klog.Exitf("Failed to create profiler: %v", err)
+		}
+		klog.Infof("Writing profiles of %s to %s", *pprofURL, *pprofDir)
+		go profiler.Run(ctx)
+	}
+
 	if *showUI {
-		c := loadtest.NewController(hammer, ha)
+		c := loadtest.NewController(hammer, ha, targets)
 		c.Run(ctx)
 	} else {
-		<-ctx.Done()
+		// Without the UI, periodically log the stats for each target so that they can be compared.
+		tick := time.NewTicker(30 * time.Second)
+	Loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break Loop
+			case <-tick.C:
+				klog.Infof("Per-target stats:\n%s", loadtest.TargetSummary(targets))
+			}
+		}
 	}
+	klog.Infof("Final per-target stats:\n%s", loadtest.TargetSummary(targets))
+	if profiler != nil {
+		if err := profiler.WriteReport(targets); err != nil {
+			klog.Errorf("Failed to write profiling report: %v", err)
+		}
+	}
+	if recorder != nil {
+		if err := recorder.Err(); err != nil {
+			klog.Errorf("Workload recording is incomplete: %v", err)
+			exitCode = 1
+		}
+		if err := recordFile.Close(); err != nil {
+			klog.Errorf("Failed to close workload recording: %v", err)
+			exitCode = 1
+		}
+	}
+	klog.Infof("Used --seed=%d", *seed)
 	os.Exit(exitCode)
 }
 
+// newWorkloadReplayerOrDie reads the workload recorded in the file at path.
+func newWorkloadReplayerOrDie(path string) *loadtest.WorkloadReplayer {
+	f, err := os.Open(path)
+	if err != nil {
+		klog.Exitf("Failed to open workload recording: %v", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Errorf("Failed to close workload recording: %v", err)
+		}
+	}()
+	r, err := loadtest.NewWorkloadReplayer(f)
+	if err != nil {
+		klog.Exitf("Failed to read workload recording %s: %v", path, err)
+	}
+	return r
+}
+
 // newLeafGenerator returns a function that generates values to append to a log.
 // The leaves are constructed to be at least minLeafSize bytes long.
 // The generator can be used by concurrent threads.
@@ -189,13 +320,15 @@ func main() {
 // Leaves will be unique if dupChance is 0, and if set to 1 then all values will be duplicates.
 // startSize should be set to the initial size of the log so that repeated runs of the
 // hammer can start seeding leaves to avoid duplicates with previous runs.
-func newLeafGenerator(startSize uint64, minLeafSize int, dupChance float64) func() []byte {
-	// genLeaf MUST be determinstic given n
+//
+// The sequence of leaves returned is determined by startSize and seed.
+func newLeafGenerator(startSize uint64, minLeafSize int, dupChance float64, seed uint64) func() []byte {
+	// genLeaf MUST be determinstic given n and seed
 	genLeaf := func(n uint64) []byte {
 		// Make a slice with half the number of requested bytes since we'll
 		// hex-encode them below which gets us back up to the full amount.
 		filler := make([]byte, minLeafSize/2)
-		source := rand.New(rand.NewPCG(0, n))
+		source := rand.New(rand.NewPCG(seed, n))
 		for i := range filler {
 			// This throws away a lot of the generated data. An exercise to a future
 			// coder is to fill in multiple bytes at a time.
@@ -205,13 +338,15 @@ func newLeafGenerator(startSize uint64, minLeafSize int, dupChance float64) func
 	}
 
 	sizeLocked := startSize
+	// dups is only used while holding mu, so the choice of duplicates follows the order leaves are generated in.
+	dups := rand.New(rand.NewPCG(seed, ^startSize))
 	var mu sync.Mutex
 	return func() []byte {
 		mu.Lock()
 		thisSize := sizeLocked
 
-		if thisSize > 0 && rand.Float64() <= dupChance {
-			thisSize = rand.Uint64N(thisSize)
+		if thisSize > 0 && dups.Float64() <= dupChance {
+			thisSize = dups.Uint64N(thisSize)
 		} else {
 			sizeLocked++
 		}
@@ -234,3 +369,26 @@ func (ms *multiStringFlag) Set(w string) error {
 	*ms = append(*ms, w)
 	return nil
 }
+
+// weightsFlag holds a comma separated list of non-negative integer weights.
+type weightsFlag []int
+
+func (wf *weightsFlag) String() string {
+	s := make([]string, 0, len(*wf))
+	for _, w := range *wf {
+		s = append(s, strconv.Itoa(w))
+	}
+	return strings.Join(s, ",")
+}
+
+func (wf *weightsFlag) Set(v string) error {
+	*wf = nil
+	for _, p := range strings.Split(v, ",") {
+		w, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || w < 0 {
+			return fmt.Errorf("invalid weight %q", p)
+		}
+		*wf = append(*wf, w)
+	}
+	return nil
+}
@@ -15,12 +15,13 @@
 package main
 
 import (
+	"slices"
 	"testing"
 )
 
 func TestLeafGenerator(t *testing.T) {
 	// Always generate new values
-	gN := newLeafGenerator(0, 100, 0)
+	gN := newLeafGenerator(0, 100, 0, 1)
 	vs := make(map[string]bool)
 	for range 256 {
 		v := string(gN())
@@ -28,10 +29,42 @@ func TestLeafGenerator(t *testing.T) {
 	}
 
 	// Always generate duplicate
-	gD := newLeafGenerator(256, 100, 1.0)
+	gD := newLeafGenerator(256, 100, 1.0, 1)
 	for range 256 {
 		if !vs[string(gD())] {
 			t.Error("Expected duplicate")
 		}
 	}
 }
+
+func TestLeafGeneratorSeed(t *testing.T) {
+	gen := func(seed uint64) []string {
+		g := newLeafGenerator(10, 20, 0.5, seed)
+		r := make([]string, 0, 100)
+		for range 100 {
+			r = append(r, string(g()))
+		}
+		return r
+	}
+	if a, b := gen(1), gen(1); !slices.Equal(a, b) {
+		t.Error("Leaves generated with the same seed differ")
+	}
+	if a, b := gen(1), gen(2); slices.Equal(a, b) {
+		t.Error("Leaves generated with different seeds are the same")
+	}
+}
+
+func TestWeightsFlag(t *testing.T) {
+	var w weightsFlag
+	if err := w.Set("1, 3,0"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, want := w.String(), "1,3,0"; got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+	for _, v := range []string{"", "1,x", "-1"} {
+		if err := w.Set(v); err == nil {
+			t.Errorf("Set(%q): want error", v)
+		}
+	}
+}
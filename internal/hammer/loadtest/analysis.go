@@ -17,6 +17,8 @@ package loadtest
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	movingaverage "github.com/RobinUS2/golang-moving-average"
@@ -32,6 +34,7 @@ func NewHammerAnalyser(treeSizeFn func() uint64) *HammerAnalyser {
 		ErrChan:         errChan,
 		IntegrationTime: movingaverage.Concurrent(movingaverage.New(30)),
 		QueueTime:       movingaverage.Concurrent(movingaverage.New(30)),
+		errCounts:       make(map[string]uint64),
 	}
 }
 
@@ -43,6 +46,39 @@ type HammerAnalyser struct {
 
 	QueueTime       *movingaverage.ConcurrentMovingAverage
 	IntegrationTime *movingaverage.ConcurrentMovingAverage
+
+	// errCounts holds the number of errors seen so far, keyed by errorCode.
+	errMu     sync.Mutex
+	errCounts map[string]uint64
+}
+
+// ErrorCounts returns the number of errors seen so far, keyed by a short description of their cause
+// such as "HTTP 503".
+func (a *HammerAnalyser) ErrorCounts() map[string]uint64 {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	r := make(map[string]uint64, len(a.errCounts))
+	for k, v := range a.errCounts {
+		r[k] = v
+	}
+	return r
+}
+
+// errorCode classifies err for the error breakdown.
+func errorCode(err error) string {
+	var se statusError
+	switch {
+	case errors.As(err, &se):
+		return fmt.Sprintf("HTTP %d", se.code)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, errValidateLeaf):
+		return "validation failed"
+	case errors.Is(err, errReadLeaf):
+		return "read failed"
+	default:
+		return "other"
+	}
 }
 
 func (a *HammerAnalyser) Run(ctx context.Context) {
@@ -125,6 +161,9 @@ func (a *HammerAnalyser) errorLoop(ctx context.Context) {
 				lastErrCount = 0
 			}
 		case err := <-a.ErrChan:
+			a.errMu.Lock()
+			a.errCounts[errorCode(err)]++
+			a.errMu.Unlock()
 			if errors.Is(err, ErrRetry) {
 				pbCount++
 				continue
@@ -15,6 +15,9 @@
 package loadtest
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -47,6 +50,32 @@ func TestHammerAnalyser_Stats(t *testing.T) {
 	}
 }
 
+func TestHammerAnalyser_ErrorCounts(t *testing.T) {
+	ha := NewHammerAnalyser(func() uint64 { return 0 })
+	go ha.errorLoop(t.Context())
+
+	for _, err := range []error{
+		statusError{code: 503, err: ErrRetry},
+		fmt.Errorf("failed to create request: %w", statusError{code: 503, err: ErrRetry}),
+		statusError{code: 400, err: errors.New("bad")},
+		fmt.Errorf("%w 5: boom", errReadLeaf),
+		fmt.Errorf("%w for leaf 5: bad hash", errValidateLeaf),
+		fmt.Errorf("write: %w", context.DeadlineExceeded),
+		errors.New("mystery"),
+	} {
+		ha.ErrChan <- err
+	}
+	want := map[string]uint64{"HTTP 503": 2, "HTTP 400": 1, "read failed": 1, "validation failed": 1, "timeout": 1, "other": 1}
+	for range 50 {
+		got := ha.ErrorCounts()
+		if fmt.Sprint(got) == fmt.Sprint(want) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("ErrorCounts: got %v, want %v", ha.ErrorCounts(), want)
+}
+
 type treeSizeState struct {
 	size uint64
 	mux  sync.RWMutex
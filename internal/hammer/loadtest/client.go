@@ -24,7 +24,6 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/transparency-dev/tessera/client"
@@ -33,6 +32,16 @@ import (
 
 var ErrRetry = errors.New("retry")
 
+// statusError records the HTTP status code of an unsuccessful response from the log, alongside the
+// error describing it.
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e statusError) Error() string { return e.err.Error() }
+func (e statusError) Unwrap() error { return e.err }
+
 type fetcher interface {
 	ReadCheckpoint(ctx context.Context) ([]byte, error)
 	ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error)
@@ -43,20 +52,35 @@ type ClientOpts struct {
 	BearerToken      string
 	BearerTokenWrite string
 
+	// ReadWeights and WriteWeights are the relative shares of read and write requests to send to each
+	// of the read and write URLs respectively, in the same order as the URLs.
+	// If unset, requests are split evenly.
+	ReadWeights  []int
+	WriteWeights []int
+
 	Client *http.Client
 }
 
 // NewLogClients returns a fetcher and a writer that will read
-// and write leaves to all logs in the `log_url` flag set.
-func NewLogClients(readLogURLs, writeLogURLs []string, opts ClientOpts) (LogReader, LeafWriter, error) {
+// and write leaves to all logs in the `log_url` flag set, along with
+// stats for each of the read and write targets.
+func NewLogClients(readLogURLs, writeLogURLs []string, opts ClientOpts) (LogReader, LeafWriter, []*TargetStats, error) {
 	if len(readLogURLs) == 0 {
-		return nil, nil, fmt.Errorf("URL(s) for reading log must be provided")
+		return nil, nil, nil, fmt.Errorf("URL(s) for reading log must be provided")
 	}
 
 	if len(writeLogURLs) == 0 {
 		// If no write_log_url is provided, then default it to log_url
 		writeLogURLs = readLogURLs
 	}
+	readWeights, err := targetWeights(len(readLogURLs), opts.ReadWeights)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid read weights: %v", err)
+	}
+	writeWeights, err := targetWeights(len(writeLogURLs), opts.WriteWeights)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid write weights: %v", err)
+	}
 
 	rootURLOrDie := func(s string) *url.URL {
 		// url must reference a directory, by definition
@@ -71,18 +95,24 @@ func NewLogClients(readLogURLs, writeLogURLs []string, opts ClientOpts) (LogRead
 	}
 
 	fetchers := []fetcher{}
-	for _, s := range readLogURLs {
+	readStats := []*TargetStats{}
+	for i, s := range readLogURLs {
 		fetchers = append(fetchers, newFetcher(rootURLOrDie(s), opts.BearerToken))
+		readStats = append(readStats, newTargetStats("read", s, readWeights[i]))
 	}
 	writers := []httpLeafWriter{}
-	for _, s := range writeLogURLs {
+	writeStats := []*TargetStats{}
+	for i, s := range writeLogURLs {
 		addURL, err := rootURLOrDie(s).Parse("add")
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to create add URL: %v", err)
+			return nil, nil, nil, fmt.Errorf("failed to create add URL: %v", err)
 		}
 		writers = append(writers, newHTTPLeafWriter(opts.Client, addURL, opts.BearerTokenWrite))
+		writeStats = append(writeStats, newTargetStats("write", s, writeWeights[i]))
 	}
-	return &roundRobinFetcher{f: fetchers}, (&roundRobinLeafWriter{ws: writers}).Write, nil
+	r := &weightedFetcher{f: fetchers, stats: readStats, picker: newWeightedPicker(readWeights)}
+	w := &weightedLeafWriter{ws: writers, stats: writeStats, picker: newWeightedPicker(writeWeights)}
+	return r, w.Write, append(readStats, writeStats...), nil
 }
 
 // newFetcher creates a Fetcher for the log at the given root location.
@@ -104,37 +134,33 @@ func newFetcher(root *url.URL, bearerToken string) fetcher {
 	return nil
 }
 
-// roundRobinFetcher ensures that read requests are sent to all configured fetchers
-// using a round-robin strategy.
-type roundRobinFetcher struct {
-	sync.Mutex
-	idx int
-	f   []fetcher
+// weightedFetcher sends read requests to the configured fetchers in proportion to their weights,
+// recording stats for each.
+type weightedFetcher struct {
+	f      []fetcher
+	stats  []*TargetStats
+	picker *weightedPicker
 }
 
-func (rr *roundRobinFetcher) ReadCheckpoint(ctx context.Context) ([]byte, error) {
-	f := rr.next()
-	return f.ReadCheckpoint(ctx)
+func (wf *weightedFetcher) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	i, start := wf.picker.next(), time.Now()
+	r, err := wf.f[i].ReadCheckpoint(ctx)
+	wf.stats[i].record(start, err)
+	return r, err
 }
 
-func (rr *roundRobinFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
-	f := rr.next()
-	return f.ReadTile(ctx, l, i, p)
+func (wf *weightedFetcher) ReadTile(ctx context.Context, l, idx uint64, p uint8) ([]byte, error) {
+	i, start := wf.picker.next(), time.Now()
+	r, err := wf.f[i].ReadTile(ctx, l, idx, p)
+	wf.stats[i].record(start, err)
+	return r, err
 }
 
-func (rr *roundRobinFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
-	f := rr.next()
-	return f.ReadEntryBundle(ctx, i, p)
-}
-
-func (rr *roundRobinFetcher) next() fetcher {
-	rr.Lock()
-	defer rr.Unlock()
-
-	f := rr.f[rr.idx]
-	rr.idx = (rr.idx + 1) % len(rr.f)
-
-	return f
+func (wf *weightedFetcher) ReadEntryBundle(ctx context.Context, idx uint64, p uint8) ([]byte, error) {
+	i, start := wf.picker.next(), time.Now()
+	r, err := wf.f[i].ReadEntryBundle(ctx, idx, p)
+	wf.stats[i].record(start, err)
+	return r, err
 }
 
 func newHTTPLeafWriter(hc *http.Client, u *url.URL, bearerToken string) httpLeafWriter {
@@ -178,9 +204,9 @@ func (w httpLeafWriter) Write(ctx context.Context, newLeaf []byte) (uint64, erro
 		// These status codes may indicate a delay before retrying, so handle that here:
 		time.Sleep(retryDelay(resp.Header.Get("RetryAfter"), time.Second))
 
-		return 0, fmt.Errorf("log not available. Status code: %d. Body: %q %w", resp.StatusCode, body, ErrRetry)
+		return 0, statusError{code: resp.StatusCode, err: fmt.Errorf("log not available. Status code: %d. Body: %q %w", resp.StatusCode, body, ErrRetry)}
 	default:
-		return 0, fmt.Errorf("write leaf was not OK. Status code: %d. Body: %q", resp.StatusCode, body)
+		return 0, statusError{code: resp.StatusCode, err: fmt.Errorf("write leaf was not OK. Status code: %d. Body: %q", resp.StatusCode, body)}
 	}
 	parts := bytes.Split(body, []byte("\n"))
 	index, err := strconv.ParseUint(string(parts[0]), 10, 64)
@@ -205,25 +231,17 @@ func retryDelay(retryAfter string, defaultDur time.Duration) time.Duration {
 	return defaultDur
 }
 
-// roundRobinLeafWriter ensures that write requests are sent to all configured
-// LeafWriters using a round-robin strategy.
-type roundRobinLeafWriter struct {
-	sync.Mutex
-	idx int
-	ws  []httpLeafWriter
+// weightedLeafWriter sends write requests to the configured LeafWriters in proportion to their
+// weights, recording stats for each.
+type weightedLeafWriter struct {
+	ws     []httpLeafWriter
+	stats  []*TargetStats
+	picker *weightedPicker
 }
 
-func (rr *roundRobinLeafWriter) Write(ctx context.Context, newLeaf []byte) (uint64, error) {
-	w := rr.next()
-	return w(ctx, newLeaf)
-}
-
-func (rr *roundRobinLeafWriter) next() LeafWriter {
-	rr.Lock()
-	defer rr.Unlock()
-
-	f := rr.ws[rr.idx]
-	rr.idx = (rr.idx + 1) % len(rr.ws)
-
-	return f.Write
+func (ww *weightedLeafWriter) Write(ctx context.Context, newLeaf []byte) (uint64, error) {
+	i, start := ww.picker.next(), time.Now()
+	idx, err := ww.ws[i].Write(ctx, newLeaf)
+	ww.stats[i].record(start, err)
+	return idx, err
 }
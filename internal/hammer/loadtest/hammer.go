@@ -17,6 +17,8 @@ package loadtest
 import (
 	"context"
 	"errors"
+	"math/rand/v2"
+	"sync"
 	"time"
 
 	"github.com/transparency-dev/tessera/client"
@@ -31,20 +33,53 @@ type HammerOpts struct {
 	NumReadersRandom int
 	NumReadersFull   int
 	NumWriters       int
+
+	// ValidateReads causes the random readers to check that each entry bundle they fetch is consistent
+	// with the corresponding tile, and that the leaf they read is included in the latest checkpoint.
+	ValidateReads bool
+
+	// Seed determines the leaves chosen by each random reader.
+	Seed uint64
+
+	// RandomNextLeaf creates the strategy used by each new random reader, given a seed derived from Seed.
+	// If unset, RandomNextLeaf is used.
+	RandomNextLeaf func(seed uint64) func(uint64) uint64
+
+	// OpDone, if set, is called each time a writer finishes writing a leaf, or a random reader finishes
+	// reading one, whether or not it succeeded.
+	OpDone func()
 }
 
-func NewHammer(tracker *client.LogStateTracker, f client.EntryBundleFetcherFunc, w LeafWriter, gen func() []byte, seqLeafChan chan<- LeafTime, errChan chan<- error, opts HammerOpts) *Hammer {
+func NewHammer(tracker *client.LogStateTracker, f client.EntryBundleFetcherFunc, tf client.TileFetcherFunc, w LeafWriter, gen func() []byte, seqLeafChan chan<- LeafTime, errChan chan<- error, opts HammerOpts) *Hammer {
 	readThrottle := NewThrottle(opts.MaxReadOpsPerSecond)
 	writeThrottle := NewThrottle(opts.MaxWriteOpsPerSecond)
 
+	var validateTF client.TileFetcherFunc
+	if opts.ValidateReads {
+		validateTF = tf
+	}
+	if opts.RandomNextLeaf == nil {
+		opts.RandomNextLeaf = RandomNextLeaf
+	}
+	// Random readers may be added by the UI while the hammer is running, so access to seeds is serialised.
+	var seedsMu sync.Mutex
+	seeds := rand.New(rand.NewPCG(opts.Seed, 0))
 	randomReaders := NewWorkerPool(func() Worker {
-		return NewLeafReader(tracker, f, RandomNextLeaf(), readThrottle.TokenChan, errChan)
+		seedsMu.Lock()
+		seed := seeds.Uint64()
+		seedsMu.Unlock()
+		r := NewLeafReader(tracker, f, validateTF, opts.RandomNextLeaf(seed), readThrottle.TokenChan, errChan)
+		r.opDone = opts.OpDone
+		return r
 	})
+	// Full readers are left unvalidated, so that they read the log at the rate a plain mirror would.
 	fullReaders := NewWorkerPool(func() Worker {
-		return NewLeafReader(tracker, f, MonotonicallyIncreasingNextLeaf(), readThrottle.TokenChan, errChan)
+		return NewLeafReader(tracker, f, nil, MonotonicallyIncreasingNextLeaf(), readThrottle.TokenChan, errChan)
 	})
 	writers := NewWorkerPool(func() Worker {
-		return NewLogWriter(w, gen, writeThrottle.TokenChan, errChan, seqLeafChan)
+		lw := NewLogWriter(w, gen, writeThrottle.TokenChan, errChan, seqLeafChan)
+		lw.opDone = opts.OpDone
+		return lw
 	})
 
 	return &Hammer{
@@ -89,6 +124,18 @@ func (h *Hammer) Run(ctx context.Context) {
 	go h.updateCheckpointLoop(ctx)
 }
 
+// LoadLevel returns the load currently being applied to the log.
+func (h *Hammer) LoadLevel() LoadLevel {
+	return LoadLevel{
+		TreeSize:          h.tracker.Latest().Size,
+		MaxReadOpsPerSec:  h.readThrottle.OpsPerSecond(),
+		MaxWriteOpsPerSec: h.writeThrottle.OpsPerSecond(),
+		RandomReaders:     h.randomReaders.Size(),
+		FullReaders:       h.fullReaders.Size(),
+		Writers:           h.writers.Size(),
+	}
+}
+
 func (h *Hammer) updateCheckpointLoop(ctx context.Context) {
 	tick := time.NewTicker(500 * time.Millisecond)
 	for {
@@ -0,0 +1,245 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ReportFile is the name of the file, in the profile directory, to which Profiler.WriteReport writes.
+const ReportFile = "report.json"
+
+// cpuProfile is the name of the pprof endpoint which serves CPU profiles.
+const cpuProfile = "profile"
+
+// LoadLevel describes the load being applied to the log at a point in time.
+type LoadLevel struct {
+	// TreeSize is the size of the latest checkpoint seen by the hammer.
+	TreeSize uint64
+	// MaxReadOpsPerSec and MaxWriteOpsPerSec are the current settings of the read and write throttles.
+	MaxReadOpsPerSec  int
+	MaxWriteOpsPerSec int
+	// RandomReaders, FullReaders, and Writers are the number of workers of each kind.
+	RandomReaders int
+	FullReaders   int
+	Writers       int
+	// IntegrationTimeMillis is the recent average time taken for written leaves to be integrated, if known.
+	IntegrationTimeMillis float64 `json:",omitempty"`
+}
+
+// ProfilerOpts configures a Profiler.
+type ProfilerOpts struct {
+	// Client is used to fetch profiles. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// URL is the root of the target's pprof endpoints, e.g. http://log.server:6060/debug/pprof/.
+	URL string
+	// Dir is the directory in which profiles, and the report, are written. It is created if need be.
+	Dir string
+	// Interval is the time between the start of successive rounds of profiling.
+	Interval time.Duration
+	// CPUDuration is the length of each CPU profile.
+	CPUDuration time.Duration
+	// Profiles are the names of the pprof endpoints to scrape in each round, e.g. "profile" and "heap".
+	Profiles []string
+}
+
+// ProfileCapture records a single profile scraped from the target.
+type ProfileCapture struct {
+	// Round counts the rounds of profiling, starting at 0.
+	Round int
+	// Profile is the name of the pprof endpoint the profile was fetched from.
+	Profile string
+	// Time is when the profile was requested.
+	Time time.Time
+	// File is the name of the file within the profile directory which holds the profile.
+	// It is empty if the profile couldn't be fetched.
+	File string `json:",omitempty"`
+	// Error describes why the profile couldn't be fetched, if it couldn't.
+	Error string `json:",omitempty"`
+	// Load is the load being applied when the profile was requested.
+	Load LoadLevel
+}
+
+// Report is the summary of a run of the hammer which is written alongside the profiles, so that each profile
+// can be related to the load being applied when it was taken.
+type Report struct {
+	Start time.Time
+	End   time.Time
+	// Load is the load being applied at the end of the run.
+	Load LoadLevel
+	// Targets summarises the requests sent to each target, one line per target.
+	Targets  []string
+	Profiles []ProfileCapture
+}
+
+// Profiler periodically scrapes profiles from the pprof endpoints of the personality under test.
+type Profiler struct {
+	opts  ProfilerOpts
+	load  func() LoadLevel
+	start time.Time
+
+	mu       sync.Mutex
+	captures []ProfileCapture
+}
+
+// NewProfiler returns a Profiler which calls load to find the load being applied whenever it takes a profile.
+func NewProfiler(opts ProfilerOpts, load func() LoadLevel) (*Profiler, error) {
+	if _, err := url.Parse(opts.URL); err != nil {
+		return nil, fmt.Errorf("invalid pprof URL %q: %v", opts.URL, err)
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("profiling interval must be positive, got %v", opts.Interval)
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %v", err)
+	}
+	return &Profiler{
+		opts:  opts,
+		load:  load,
+		start: time.Now(),
+	}, nil
+}
+
+// Run takes a round of profiles straight away, and then every interval until ctx is done.
+func (p *Profiler) Run(ctx context.Context) {
+	tick := time.NewTicker(p.opts.Interval)
+	defer tick.Stop()
+	for round := 0; ; round++ {
+		for _, name := range p.opts.Profiles {
+			if ctx.Err() != nil {
+				return
+			}
+			p.capture(ctx, round, name)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// Captures returns the profiles taken so far.
+func (p *Profiler) Captures() []ProfileCapture {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ProfileCapture(nil), p.captures...)
+}
+
+// WriteReport writes a Report of the run so far to ReportFile in the profile directory.
+func (p *Profiler) WriteReport(targets []*TargetStats) error {
+	r := Report{
+		Start:    p.start,
+		End:      time.Now(),
+		Load:     p.load(),
+		Targets:  make([]string, 0, len(targets)),
+		Profiles: p.Captures(),
+	}
+	for _, t := range targets {
+		r.Targets = append(r.Targets, t.String())
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %v", err)
+	}
+	f := filepath.Join(p.opts.Dir, ReportFile)
+	if err := os.WriteFile(f, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
+
+// capture fetches a single profile and records the outcome.
+func (p *Profiler) capture(ctx context.Context, round int, name string) {
+	c := ProfileCapture{
+		Round:   round,
+		Profile: name,
+		Time:    time.Now(),
+		Load:    p.load(),
+	}
+	file := fmt.Sprintf("%04d-%s.pb.gz", round, name)
+	if err := p.fetch(ctx, name, filepath.Join(p.opts.Dir, file)); err != nil {
+		if ctx.Err() != nil {
+			// Profiles cut short by the end of the run are of no use, so don't report them.
+			return
+		}
+		klog.Warningf("Failed to capture %s profile: %v", name, err)
+		c.Error = err.Error()
+	} else {
+		klog.V(1).Infof("Captured %s profile at tree size %d", name, c.Load.TreeSize)
+		c.File = file
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.captures = append(p.captures, c)
+}
+
+// fetch writes the named profile to the file at path.
+func (p *Profiler) fetch(ctx context.Context, name, path string) error {
+	u, err := url.JoinPath(p.opts.URL, name)
+	if err != nil {
+		return err
+	}
+	if name == cpuProfile {
+		u += "?seconds=" + strconv.FormatFloat(p.opts.CPUDuration.Seconds(), 'f', -1, 64)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			klog.Errorf("resp.Body.Close(): %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	// Write to a temporary file first, so that a failed fetch doesn't leave a truncated profile behind.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to read profile: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
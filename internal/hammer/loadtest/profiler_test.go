@@ -0,0 +1,116 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProfiler(t *testing.T) {
+	var cpuSeconds atomic.Value
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		cpuSeconds.Store(r.FormValue("seconds"))
+		_, _ = w.Write([]byte("cpu"))
+	})
+	mux.HandleFunc("GET /debug/pprof/heap", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("heap"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	var size atomic.Uint64
+	p, err := NewProfiler(ProfilerOpts{
+		URL:         srv.URL + "/debug/pprof/",
+		Dir:         dir,
+		Interval:    time.Hour,
+		CPUDuration: 1500 * time.Millisecond,
+		Profiles:    []string{"profile", "heap", "missing"},
+	}, func() LoadLevel {
+		return LoadLevel{TreeSize: size.Add(1), Writers: 2}
+	})
+	if err != nil {
+		t.Fatalf("NewProfiler: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	for len(p.Captures()) < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got, want := cpuSeconds.Load(), "1.5"; got != want {
+		t.Errorf("CPU profile seconds: got %q, want %q", got, want)
+	}
+	for _, c := range p.Captures() {
+		switch c.Profile {
+		case "profile", "heap":
+			if c.Error != "" {
+				t.Errorf("%s: unexpected error %q", c.Profile, c.Error)
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(dir, c.File))
+			if err != nil {
+				t.Errorf("%s: ReadFile: %v", c.Profile, err)
+			}
+			if want := map[string]string{"profile": "cpu", "heap": "heap"}[c.Profile]; string(b) != want {
+				t.Errorf("%s: got profile %q, want %q", c.Profile, b, want)
+			}
+		case "missing":
+			if c.Error == "" || c.File != "" {
+				t.Errorf("missing: got file %q, error %q, want only an error", c.File, c.Error)
+			}
+		}
+		if c.Load.TreeSize == 0 || c.Load.Writers != 2 {
+			t.Errorf("%s: load level not recorded: %+v", c.Profile, c.Load)
+		}
+	}
+
+	if err := p.WriteReport([]*TargetStats{newTargetStats("read", "http://log", 1)}); err != nil {
+		t.Fatalf("WriteReport: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, ReportFile))
+	if err != nil {
+		t.Fatalf("ReadFile(report): %v", err)
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatalf("Unmarshal(report): %v", err)
+	}
+	if got, want := len(r.Profiles), 3; got != want {
+		t.Errorf("report has %d profiles, want %d", got, want)
+	}
+	if got, want := len(r.Targets), 1; got != want {
+		t.Errorf("report has %d targets, want %d", got, want)
+	}
+	if r.End.Before(r.Start) || r.Load.TreeSize == 0 {
+		t.Errorf("report has bad start, end, or load: %+v", r)
+	}
+}
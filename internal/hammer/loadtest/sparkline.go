@@ -0,0 +1,66 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"math"
+	"strings"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// series holds the most recent values of a metric, for graphing.
+type series struct {
+	values []float64
+	max    int
+}
+
+func newSeries(max int) *series {
+	return &series{max: max}
+}
+
+// add appends v, dropping the oldest value if the series is full.
+func (s *series) add(v float64) {
+	if len(s.values) == s.max {
+		s.values = s.values[1:]
+	}
+	s.values = append(s.values, v)
+}
+
+// sparkline renders the most recent width values as a line of block characters, scaled so that the
+// largest value shown fills a whole character cell. It also returns that largest value, so that callers
+// can label the scale.
+func (s *series) sparkline(width int) (string, float64) {
+	vs := s.values
+	if width <= 0 {
+		return "", 0
+	}
+	if len(vs) > width {
+		vs = vs[len(vs)-width:]
+	}
+	top := 0.0
+	for _, v := range vs {
+		top = max(top, v)
+	}
+	b := strings.Builder{}
+	for _, v := range vs {
+		i := 0
+		if top > 0 && v > 0 {
+			i = int(math.Ceil(v/top*float64(len(sparkBlocks)))) - 1
+		}
+		b.WriteRune(sparkBlocks[min(max(i, 0), len(sparkBlocks)-1)])
+	}
+	return b.String(), top
+}
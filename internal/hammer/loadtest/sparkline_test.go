@@ -0,0 +1,42 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	s := newSeries(4)
+	if got, top := s.sparkline(10); got != "" || top != 0 {
+		t.Errorf("empty sparkline: got %q, %f", got, top)
+	}
+	for _, v := range []float64{100, 0, 8, 4, 1} {
+		s.add(v)
+	}
+	// The oldest value has been dropped, so the scale is set by the 8.
+	for _, test := range []struct {
+		width   int
+		want    string
+		wantTop float64
+	}{
+		{width: 10, want: "▁█▄▁", wantTop: 8},
+		{width: 2, want: "█▂", wantTop: 4},
+		{width: 0, want: "", wantTop: 0},
+	} {
+		got, top := s.sparkline(test.width)
+		if got != test.want || top != test.wantTop {
+			t.Errorf("sparkline(%d): got %q, %f, want %q, %f", test.width, got, top, test.want, test.wantTop)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	movingaverage "github.com/RobinUS2/golang-moving-average"
+)
+
+// TargetStats holds statistics about the requests sent to a single read or write target.
+type TargetStats struct {
+	// Kind is either "read" or "write".
+	Kind string
+	// URL is the root URL of the target.
+	URL string
+	// Weight is the relative share of requests of this kind sent to the target.
+	Weight int
+
+	requests atomic.Uint64
+	errors   atomic.Uint64
+	// latency is the moving average of the latency, in milliseconds, of recent requests.
+	latency *movingaverage.ConcurrentMovingAverage
+}
+
+func newTargetStats(kind, url string, weight int) *TargetStats {
+	return &TargetStats{
+		Kind:    kind,
+		URL:     url,
+		Weight:  weight,
+		latency: movingaverage.Concurrent(movingaverage.New(100)),
+	}
+}
+
+// record updates the stats with the outcome of a request which started at start.
+func (s *TargetStats) record(start time.Time, err error) {
+	s.requests.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+	}
+	s.latency.Add(float64(time.Since(start)) / float64(time.Millisecond))
+}
+
+// Requests returns the number of requests sent to the target.
+func (s *TargetStats) Requests() uint64 {
+	return s.requests.Load()
+}
+
+// Errors returns the number of requests sent to the target which failed.
+func (s *TargetStats) Errors() uint64 {
+	return s.errors.Load()
+}
+
+// String summarises the stats on a single line.
+func (s *TargetStats) String() string {
+	aMin, _ := s.latency.Min()
+	aMax, _ := s.latency.Max()
+	return fmt.Sprintf("%-5s w=%-3d %s: %d requests, %d errors, %.0fms/%.0fms/%.0fms (min/avg/max)",
+		s.Kind, s.Weight, s.URL, s.Requests(), s.Errors(), aMin, s.latency.Avg(), aMax)
+}
+
+// TargetSummary summarises the stats for each target, one per line.
+func TargetSummary(targets []*TargetStats) string {
+	lines := make([]string, 0, len(targets))
+	for _, t := range targets {
+		lines = append(lines, t.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// weightedPicker chooses between targets in proportion to their weights, using the smooth weighted
+// round-robin algorithm so that requests to each target are spread evenly over time rather than
+// arriving in bursts.
+type weightedPicker struct {
+	mu      sync.Mutex
+	weights []int
+	total   int
+	current []int
+}
+
+func newWeightedPicker(weights []int) *weightedPicker {
+	p := &weightedPicker{weights: weights, current: make([]int, len(weights))}
+	for _, w := range weights {
+		p.total += w
+	}
+	return p
+}
+
+// next returns the index of the target which should receive the next request.
+func (p *weightedPicker) next() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	best := 0
+	for i, w := range p.weights {
+		p.current[i] += w
+		if p.current[i] > p.current[best] {
+			best = i
+		}
+	}
+	p.current[best] -= p.total
+	return best
+}
+
+// targetWeights returns the weights for n targets, which are all 1 if none are configured.
+func targetWeights(n int, weights []int) ([]int, error) {
+	if len(weights) == 0 {
+		weights = make([]int, n)
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights, nil
+	}
+	if len(weights) != n {
+		return nil, fmt.Errorf("got %d weights for %d targets", len(weights), n)
+	}
+	total := 0
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("weights must not be negative, got %d", w)
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one weight must be positive")
+	}
+	return weights, nil
+}
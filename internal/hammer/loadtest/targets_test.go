@@ -0,0 +1,77 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWeightedPicker(t *testing.T) {
+	p := newWeightedPicker([]int{1, 3, 0})
+	var got []int
+	for range 8 {
+		got = append(got, p.next())
+	}
+	// Smooth weighted round-robin interleaves the targets rather than sending bursts to each.
+	if want := []int{1, 0, 1, 1, 1, 0, 1, 1}; !slices.Equal(got, want) {
+		t.Errorf("next: got %v, want %v", got, want)
+	}
+}
+
+func TestTargetWeights(t *testing.T) {
+	if got, err := targetWeights(3, nil); err != nil || !slices.Equal(got, []int{1, 1, 1}) {
+		t.Errorf("targetWeights(3, nil): got %v, %v, want even weights", got, err)
+	}
+	for _, w := range [][]int{{1}, {1, -1}, {0, 0}} {
+		if _, err := targetWeights(2, w); err == nil {
+			t.Errorf("targetWeights(2, %v): want error", w)
+		}
+	}
+}
+
+// fakeFetcher serves checkpoints, and fails all other requests.
+type fakeFetcher struct{}
+
+func (fakeFetcher) ReadCheckpoint(_ context.Context) ([]byte, error) { return []byte("cp"), nil }
+func (fakeFetcher) ReadTile(_ context.Context, _, _ uint64, _ uint8) ([]byte, error) {
+	return nil, errors.New("no tiles")
+}
+func (fakeFetcher) ReadEntryBundle(_ context.Context, _ uint64, _ uint8) ([]byte, error) {
+	return nil, errors.New("no bundles")
+}
+
+func TestWeightedFetcherStats(t *testing.T) {
+	stats := []*TargetStats{newTargetStats("read", "a", 1), newTargetStats("read", "b", 2)}
+	wf := &weightedFetcher{f: []fetcher{fakeFetcher{}, fakeFetcher{}}, stats: stats, picker: newWeightedPicker([]int{1, 2})}
+	for range 3 {
+		if _, err := wf.ReadCheckpoint(t.Context()); err != nil {
+			t.Fatalf("ReadCheckpoint: %v", err)
+		}
+		if _, err := wf.ReadTile(t.Context(), 0, 0, 0); err == nil {
+			t.Fatal("ReadTile: want error")
+		}
+	}
+	for i, want := range []uint64{2, 4} {
+		if got := stats[i].Requests(); got != want {
+			t.Errorf("target %d: got %d requests, want %d", i, got, want)
+		}
+	}
+	if got, want := stats[0].Errors()+stats[1].Errors(), uint64(3); got != want {
+		t.Errorf("got %d errors, want %d", got, want)
+	}
+}
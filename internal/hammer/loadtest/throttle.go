@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,6 +35,43 @@ type Throttle struct {
 	opsPerSecond int
 
 	oversupply int
+
+	// paused, if set, stops tokens from being supplied other than by Step.
+	paused atomic.Bool
+}
+
+// SetPaused pauses or resumes the supply of tokens. Pausing discards any tokens which haven't been
+// taken yet, so that work stops promptly.
+func (t *Throttle) SetPaused(p bool) {
+	t.paused.Store(p)
+	for p {
+		select {
+		case <-t.TokenChan:
+		default:
+			return
+		}
+	}
+}
+
+// Paused returns whether the supply of tokens is paused.
+func (t *Throttle) Paused() bool {
+	return t.paused.Load()
+}
+
+// Step supplies a single token, if there isn't one waiting to be taken already. This allows operations
+// to be performed one at a time while paused.
+func (t *Throttle) Step() {
+	select {
+	case t.TokenChan <- true:
+	default:
+	}
+}
+
+// OpsPerSecond returns the current maximum number of operations per second.
+func (t *Throttle) OpsPerSecond() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.opsPerSecond
 }
 
 func (t *Throttle) Increase() {
@@ -75,6 +113,11 @@ func (t *Throttle) supplyTokens(ctx context.Context) {
 	defer t.mu.Unlock()
 	tokenCount := t.opsPerSecond
 	for range t.opsPerSecond {
+		// Check on every iteration, as this can block for most of a second waiting for tokens to be taken.
+		if t.paused.Load() {
+			t.oversupply = 0
+			return
+		}
 		select {
 		case t.TokenChan <- true:
 			tokenCount--
@@ -87,5 +130,8 @@ func (t *Throttle) supplyTokens(ctx context.Context) {
 }
 
 func (t *Throttle) String() string {
+	if t.paused.Load() {
+		return fmt.Sprintf("Paused (max when resumed: %d/s)", t.opsPerSecond)
+	}
 	return fmt.Sprintf("Current max: %d/s. Oversupply in last second: %d", t.opsPerSecond, t.oversupply)
 }
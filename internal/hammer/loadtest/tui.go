@@ -15,9 +15,11 @@
 package loadtest
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,37 +32,57 @@ import (
 type tuiController struct {
 	hammer     *Hammer
 	analyser   *HammerAnalyser
+	targets    []*TargetStats
 	app        *tview.Application
 	statusView *tview.TextView
+	graphView  *tview.TextView
+	targetView *tview.TextView
+	errorView  *tview.TextView
 	logView    *tview.TextView
 	helpView   *tview.TextView
 }
 
-func NewController(h *Hammer, a *HammerAnalyser) *tuiController {
+// NewController returns a controller for the TUI, which also displays the stats for each of the
+// given targets.
+func NewController(h *Hammer, a *HammerAnalyser, targets []*TargetStats) *tuiController {
 	c := tuiController{
 		hammer:   h,
 		analyser: a,
+		targets:  targets,
 		app:      tview.NewApplication(),
 	}
 	grid := tview.NewGrid()
-	grid.SetRows(5, 0, 10).SetColumns(0).SetBorders(true)
+	grid.SetRows(5, 2, len(targets), 0, 5).SetColumns(0, 30).SetBorders(true)
 
 	// Top: status box
 	statusView := tview.NewTextView()
-	grid.AddItem(statusView, 0, 0, 1, 1, 0, 0, false)
+	grid.AddItem(statusView, 0, 0, 1, 2, 0, 0, false)
 	c.statusView = statusView
 
-	// Middle: log view box
+	// Below the status: sparkline graphs of recent throughput and latency
+	graphView := tview.NewTextView()
+	grid.AddItem(graphView, 1, 0, 1, 2, 0, 0, false)
+	c.graphView = graphView
+
+	// Then the stats for each read and write target
+	targetView := tview.NewTextView()
+	grid.AddItem(targetView, 2, 0, 1, 2, 0, 0, false)
+	c.targetView = targetView
+
+	// Middle: log view box, with the error breakdown alongside it
 	logView := tview.NewTextView()
 	logView.ScrollToEnd()
 	logView.SetMaxLines(10000)
-	grid.AddItem(logView, 1, 0, 1, 1, 0, 0, false)
+	grid.AddItem(logView, 3, 0, 1, 1, 0, 0, false)
 	c.logView = logView
+	errorView := tview.NewTextView()
+	grid.AddItem(errorView, 3, 1, 1, 1, 0, 0, false)
+	c.errorView = errorView
 
 	// Bottom: help text
 	helpView := tview.NewTextView()
-	helpView.SetText("+/- to increase/decrease read load\n>/< to increase/decrease write load\nw/W to increase/decrease workers")
-	grid.AddItem(helpView, 2, 0, 1, 1, 0, 0, false)
+	helpView.SetText("+/- to increase/decrease read load\n>/< to increase/decrease write load\nw/W to increase/decrease workers\np to pause/resume all load\ns to perform a single read and write while paused")
+	grid.AddItem(helpView, 4, 0, 1, 2, 0, 0, false)
 	c.helpView = helpView
 
 	c.app.SetRoot(grid, true)
@@ -103,6 +125,23 @@ func (c *tuiController) Run(ctx context.Context) {
 			c.hammer.randomReaders.Shrink(ctx)
 			c.hammer.fullReaders.Shrink(ctx)
 			c.hammer.writers.Shrink(ctx)
+		case 'p':
+			paused := !c.hammer.writeThrottle.Paused()
+			if paused {
+				klog.Info("Pausing all load")
+			} else {
+				klog.Info("Resuming load")
+			}
+			c.hammer.readThrottle.SetPaused(paused)
+			c.hammer.writeThrottle.SetPaused(paused)
+		case 's':
+			if !c.hammer.writeThrottle.Paused() {
+				klog.Info("Stepping is only possible while paused")
+				break
+			}
+			klog.Info("Performing a single read and write")
+			c.hammer.readThrottle.Step()
+			c.hammer.writeThrottle.Step()
 		}
 		return event
 	})
@@ -123,13 +162,16 @@ func (c *tuiController) updateStatsLoop(ctx context.Context, interval time.Durat
 	lastSize := c.hammer.tracker.Latest().Size
 	maSlots := int((30 * time.Second) / interval)
 	growth := movingaverage.New(maSlots)
+	// Keep enough history to fill a wide terminal.
+	qpsHistory, latencyHistory := newSeries(500), newSeries(500)
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			s := c.hammer.tracker.Latest().Size
-			growth.Add(float64(s - lastSize))
+			delta := float64(s - lastSize)
+			growth.Add(delta)
 			lastSize = s
 			qps := growth.Avg() * float64(time.Second/interval)
 			readWorkersLine := fmt.Sprintf("Read (%d workers): %s",
@@ -148,7 +190,45 @@ func (c *tuiController) updateStatsLoop(ctx context.Context, interval time.Durat
 				formatMovingAverage(c.analyser.IntegrationTime))
 			text := strings.Join([]string{readWorkersLine, writeWorkersLine, treeSizeLine, queueLine, integrateLine}, "\n")
 			c.statusView.SetText(text)
+
+			// Unlike the status line, the graph shows the growth in each interval rather than an average.
+			qpsHistory.add(delta * float64(time.Second/interval))
+			latencyHistory.add(c.analyser.IntegrationTime.Avg())
+			c.graphView.SetText(c.graphText(qpsHistory, latencyHistory))
+			c.targetView.SetText(TargetSummary(c.targets))
+			c.errorView.SetText(errorBreakdown(c.analyser.ErrorCounts()))
 			c.app.Draw()
 		}
 	}
 }
+
+// graphText renders the QPS and latency histories as labelled sparklines which fit the graph view.
+func (c *tuiController) graphText(qps, latency *series) string {
+	const labelWidth, scaleWidth = 20, 12
+	_, _, w, _ := c.graphView.GetInnerRect()
+	width := max(w-labelWidth-scaleWidth, 0)
+	qpsLine, qpsMax := qps.sparkline(width)
+	latLine, latMax := latency.sparkline(width)
+	return fmt.Sprintf("%-*s%s%*s\n%-*s%s%*s",
+		labelWidth, "Integrated QPS", qpsLine, scaleWidth, fmt.Sprintf(" max %.0f", qpsMax),
+		labelWidth, "Time-to-integrate", latLine, scaleWidth, fmt.Sprintf(" max %.0fms", latMax))
+}
+
+// errorBreakdown renders the number of errors seen for each cause, most frequent first.
+func errorBreakdown(counts map[string]uint64) string {
+	if len(counts) == 0 {
+		return "Errors: none"
+	}
+	codes := make([]string, 0, len(counts))
+	for k := range counts {
+		codes = append(codes, k)
+	}
+	slices.SortFunc(codes, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	lines := []string{"Errors:"}
+	for _, k := range codes {
+		lines = append(lines, fmt.Sprintf("%-16s %8d", k, counts[k]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -15,6 +15,7 @@
 package loadtest
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
@@ -22,6 +23,10 @@ import (
 	"math/rand/v2"
 	"time"
 
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/client"
 	"k8s.io/klog/v2"
@@ -32,6 +37,13 @@ import (
 // number at which this data will be found in the log, or an error.
 type LeafWriter func(ctx context.Context, data []byte) (uint64, error)
 
+// errReadLeaf is wrapped by the errors reported by LeafReaders.
+var errReadLeaf = errors.New("failed to get leaf")
+
+// errValidateLeaf is wrapped by the errors reported by LeafReaders when the data they read is inconsistent
+// with the log's checkpoint, which suggests that the log's serving layer is corrupting or mixing up resources.
+var errValidateLeaf = errors.New("read validation failed")
+
 type LogReader interface {
 	ReadCheckpoint(ctx context.Context) ([]byte, error)
 
@@ -43,10 +55,14 @@ type LogReader interface {
 // NewLeafReader creates a LeafReader.
 // The next function provides a strategy for which leaves will be read.
 // Custom implementations can be passed, or use RandomNextLeaf or MonotonicallyIncreasingNextLeaf.
-func NewLeafReader(tracker *client.LogStateTracker, f client.EntryBundleFetcherFunc, next func(uint64) uint64, throttle <-chan bool, errChan chan<- error) *LeafReader {
+//
+// If tf is not nil, each entry bundle read is validated against the level 0 tile which commits to it,
+// and the leaf being read is proven to be included under the root hash of the current checkpoint.
+func NewLeafReader(tracker *client.LogStateTracker, f client.EntryBundleFetcherFunc, tf client.TileFetcherFunc, next func(uint64) uint64, throttle <-chan bool, errChan chan<- error) *LeafReader {
 	return &LeafReader{
 		tracker:  tracker,
 		f:        f,
+		tf:       tf,
 		next:     next,
 		throttle: throttle,
 		errChan:  errChan,
@@ -58,11 +74,14 @@ func NewLeafReader(tracker *client.LogStateTracker, f client.EntryBundleFetcherF
 type LeafReader struct {
 	tracker  *client.LogStateTracker
 	f        client.EntryBundleFetcherFunc
+	tf       client.TileFetcherFunc
 	next     func(uint64) uint64
 	throttle <-chan bool
 	errChan  chan<- error
 	cancel   func()
 	c        leafBundleCache
+	// opDone, if set, is called after each leaf which is read has been fetched and validated.
+	opDone func()
 }
 
 // Run runs the log reader. This should be called in a goroutine.
@@ -77,7 +96,8 @@ func (r *LeafReader) Run(ctx context.Context) {
 			return
 		case <-r.throttle:
 		}
-		size := r.tracker.Latest().Size
+		cp := r.tracker.Latest()
+		size := cp.Size
 		if size == 0 {
 			continue
 		}
@@ -86,33 +106,91 @@ func (r *LeafReader) Run(ctx context.Context) {
 			continue
 		}
 		klog.V(2).Infof("LeafReader getting %d", i)
-		_, err := r.getLeaf(ctx, i, size)
-		if err != nil {
-			r.errChan <- fmt.Errorf("failed to get leaf %d: %v", i, err)
+		r.read(ctx, i, cp)
+		if r.opDone != nil {
+			r.opDone()
+		}
+	}
+}
+
+// read fetches leaf i, and validates it against cp if needed, reporting any failure to r.errChan.
+func (r *LeafReader) read(ctx context.Context, i uint64, cp log.Checkpoint) {
+	_, fetched, err := r.getLeaf(ctx, i, cp.Size)
+	if err != nil {
+		r.errChan <- fmt.Errorf("%w %d: %v", errReadLeaf, i, err)
+		return
+	}
+	// Only freshly fetched bundles need validating; cached ones were checked when they were fetched.
+	if r.tf != nil && fetched {
+		if err := r.validate(ctx, i, cp); err != nil {
+			r.errChan <- fmt.Errorf("%w for leaf %d at size %d: %v", errValidateLeaf, i, cp.Size, err)
+		}
+	}
+}
+
+// validate checks that the cached entry bundle, which holds leaf i, hashes to the level 0 tile which
+// covers it, and that the hash of leaf i is committed to by the checkpoint cp.
+func (r *LeafReader) validate(ctx context.Context, i uint64, cp log.Checkpoint) error {
+	tileIdx := i / layout.EntryBundleWidth
+	// Logs may serve larger partial tiles and bundles than were asked for, so only the entries covered by
+	// cp are compared.
+	n := min(cp.Size-tileIdx*layout.EntryBundleWidth, layout.EntryBundleWidth)
+	if got := uint64(len(r.c.leaves)); got < n {
+		return fmt.Errorf("entry bundle %d has %d entries, want at least %d", tileIdx, got, n)
+	}
+	raw, err := r.tf(ctx, 0, tileIdx, layout.PartialTileSize(0, tileIdx, cp.Size))
+	if err != nil {
+		return fmt.Errorf("failed to fetch tile 0/%d: %v", tileIdx, err)
+	}
+	var tile api.HashTile
+	if err := tile.UnmarshalText(raw); err != nil {
+		return fmt.Errorf("failed to parse tile 0/%d: %v", tileIdx, err)
+	}
+	if got := uint64(len(tile.Nodes)); got < n {
+		return fmt.Errorf("tile 0/%d has %d hashes, want at least %d", tileIdx, got, n)
+	}
+	for j := range n {
+		if h := rfc6962.DefaultHasher.HashLeaf(r.c.leaves[j]); !bytes.Equal(h, tile.Nodes[j]) {
+			return fmt.Errorf("leaf %d in entry bundle %d hashes to %x, but tile 0/%d has %x", j, tileIdx, h, tileIdx, tile.Nodes[j])
 		}
 	}
+
+	pb, err := client.NewProofBuilder(ctx, cp.Size, r.tf)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	p, err := pb.InclusionProof(ctx, i)
+	if err != nil {
+		return fmt.Errorf("failed to build inclusion proof: %v", err)
+	}
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, i, cp.Size, tile.Nodes[i%layout.EntryBundleWidth], p, cp.Hash); err != nil {
+		return fmt.Errorf("leaf is not included under checkpoint root %x: %v", cp.Hash, err)
+	}
+	return nil
 }
 
 // getLeaf fetches the raw contents committed to at a given leaf index.
-func (r *LeafReader) getLeaf(ctx context.Context, i uint64, logSize uint64) ([]byte, error) {
+// If the entry bundle holding the leaf had to be fetched, rather than being found in the cache, then
+// fetched is true.
+func (r *LeafReader) getLeaf(ctx context.Context, i uint64, logSize uint64) (leaf []byte, fetched bool, err error) {
 	if i >= logSize {
-		return nil, fmt.Errorf("requested leaf %d >= log size %d", i, logSize)
+		return nil, false, fmt.Errorf("requested leaf %d >= log size %d", i, logSize)
 	}
 	if cached, _ := r.c.get(i); cached != nil {
 		klog.V(2).Infof("Using cached result for index %d", i)
-		return cached, nil
+		return cached, false, nil
 	}
 
 	bundle, err := client.GetEntryBundle(ctx, r.f, i/layout.EntryBundleWidth, logSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get entry bundle: %v", err)
+		return nil, false, fmt.Errorf("failed to get entry bundle: %v", err)
 	}
 	ti := i % layout.EntryBundleWidth
 	r.c = leafBundleCache{
 		start:  i - ti,
 		leaves: bundle.Entries,
 	}
-	return r.c.leaves[ti], nil
+	return r.c.leaves[ti], true, nil
 }
 
 // Kill kills this leaf reader at the next opportune moment.
@@ -141,9 +219,11 @@ func (tc leafBundleCache) get(i uint64) ([]byte, error) {
 }
 
 // RandomNextLeaf returns a function that fetches a random leaf available in the tree.
-func RandomNextLeaf() func(uint64) uint64 {
+// The leaves chosen are determined by seed, and the sizes of the tree passed to the function.
+func RandomNextLeaf(seed uint64) func(uint64) uint64 {
+	r := rand.New(rand.NewPCG(seed, 0))
 	return func(size uint64) uint64 {
-		return rand.Uint64N(size)
+		return r.Uint64N(size)
 	}
 }
 
@@ -173,7 +253,7 @@ type LeafTime struct {
 
 // NewLogWriter creates a LogWriter.
 // u is the URL of the write endpoint for the log.
-// gen is a function that generates new leaves to add.
+// gen is a function that generates new leaves to add, and returns nil when there are no more to add.
 func NewLogWriter(writer LeafWriter, gen func() []byte, throttle <-chan bool, errChan chan<- error, leafSampleChan chan<- LeafTime) *LogWriter {
 	return &LogWriter{
 		writer:   writer,
@@ -192,6 +272,8 @@ type LogWriter struct {
 	errChan  chan<- error
 	leafChan chan<- LeafTime
 	cancel   func()
+	// opDone, if set, is called after each leaf generated has been written, or failed to be.
+	opDone func()
 }
 
 // Run runs the log writer. This should be called in a goroutine.
@@ -207,23 +289,35 @@ func (w *LogWriter) Run(ctx context.Context) {
 		case <-w.throttle:
 		}
 		newLeaf := w.gen()
-		lt := LeafTime{QueuedAt: time.Now()}
-		index, err := w.writer(ctx, newLeaf)
-		if err != nil {
-			w.errChan <- fmt.Errorf("failed to create request: %w", err)
-			continue
+		if newLeaf == nil {
+			klog.V(1).Info("LogWriter has no more leaves to write")
+			return
 		}
-		lt.Index, lt.AssignedAt = index, time.Now()
-		// See if we can send a leaf sample
-		select {
-		// TODO: we might want to count dropped samples, and/or make sampling a bit more statistical.
-		case w.leafChan <- lt:
-		default:
+		w.write(ctx, newLeaf)
+		if w.opDone != nil {
+			w.opDone()
 		}
-		klog.V(2).Infof("Wrote leaf at index %d", index)
 	}
 }
 
+// write writes leaf to the log, reporting any failure to w.errChan.
+func (w *LogWriter) write(ctx context.Context, leaf []byte) {
+	lt := LeafTime{QueuedAt: time.Now()}
+	index, err := w.writer(ctx, leaf)
+	if err != nil {
+		w.errChan <- fmt.Errorf("failed to create request: %w", err)
+		return
+	}
+	lt.Index, lt.AssignedAt = index, time.Now()
+	// See if we can send a leaf sample
+	select {
+	// TODO: we might want to count dropped samples, and/or make sampling a bit more statistical.
+	case w.leafChan <- lt:
+	default:
+	}
+	klog.V(2).Infof("Wrote leaf at index %d", index)
+}
+
 // Kill kills this writer at the next opportune moment.
 // This function may return before the writer is dead.
 func (w *LogWriter) Kill() {
@@ -0,0 +1,124 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestLeafReaderValidate(t *testing.T) {
+	ctx := t.Context()
+	sk, vk, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, shutdown, lr, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	defer func() { _ = shutdown(context.Background()) }()
+
+	// Enough entries for a full bundle, and a partial one.
+	var f tessera.IndexFuture
+	for i := range 300 {
+		f = a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "leaf %d", i)))
+	}
+	_, cpRaw, err := tessera.NewPublicationAwaiter(ctx, lr.ReadCheckpoint, 10*time.Millisecond).Await(ctx, f)
+	if err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+	cp, _, _, err := client.OpenCheckpoint(cpRaw, v.Name(), v)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+
+	// wrongTile serves tile 0/1 whenever tile 0/0 is requested, as a misconfigured cache might.
+	wrongTile := func(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+		if l == 0 && i == 0 {
+			return lr.ReadTile(ctx, 0, 1, uint8(cp.Size%256))
+		}
+		return lr.ReadTile(ctx, l, i, p)
+	}
+	// badBundle serves bundles with one of their entries altered.
+	badBundle := func(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+		raw, err := lr.ReadEntryBundle(ctx, i, p)
+		if err != nil {
+			return nil, err
+		}
+		var b api.EntryBundle
+		if err := b.UnmarshalText(raw); err != nil {
+			return nil, err
+		}
+		b.Entries[3] = []byte("tampered")
+		bb, err := api.NewEntryBundleBuilder(i*256, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range b.Entries {
+			if err := bb.Append(e); err != nil {
+				return nil, err
+			}
+		}
+		return bb.Bytes(), nil
+	}
+
+	for _, test := range []struct {
+		name    string
+		f       client.EntryBundleFetcherFunc
+		tf      client.TileFetcherFunc
+		leaf    uint64
+		wantErr bool
+	}{
+		{name: "full bundle", f: lr.ReadEntryBundle, tf: lr.ReadTile, leaf: 10},
+		{name: "partial bundle", f: lr.ReadEntryBundle, tf: lr.ReadTile, leaf: 299},
+		{name: "wrong tile", f: lr.ReadEntryBundle, tf: wrongTile, leaf: 10, wantErr: true},
+		{name: "tampered bundle", f: badBundle, tf: lr.ReadTile, leaf: 10, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewLeafReader(nil, test.f, test.tf, RandomNextLeaf(0), nil, nil)
+			if _, fetched, err := r.getLeaf(ctx, test.leaf, cp.Size); err != nil || !fetched {
+				t.Fatalf("getLeaf: got (fetched %t, %v), want fetched", fetched, err)
+			}
+			if err := r.validate(ctx, test.leaf, *cp); (err != nil) != test.wantErr {
+				t.Errorf("validate: got err %v, want err %t", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// WorkloadOp is a single operation issued by the hammer, as recorded by a WorkloadRecorder.
+// Exactly one of its fields is set.
+type WorkloadOp struct {
+	// Write is the leaf which was written to the log.
+	Write []byte `json:"write,omitempty"`
+	// Read is the index of the leaf which was read by a random reader.
+	Read *uint64 `json:"read,omitempty"`
+}
+
+// NewWorkloadRecorder creates a WorkloadRecorder which writes each operation to w as a line of JSON.
+//
+// Operations are written to w as they're issued, rather than buffered, so that the workload leading up
+// to a crash of the hammer is preserved.
+func NewWorkloadRecorder(w io.Writer) *WorkloadRecorder {
+	return &WorkloadRecorder{enc: json.NewEncoder(w)}
+}
+
+// WorkloadRecorder records the leaves written, and the leaves read by random readers, during a run so
+// that it can be repeated exactly with a WorkloadReplayer.
+//
+// Full readers are not recorded, as they always read the log in order.
+type WorkloadRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// Gen returns a leaf generator which records each leaf returned by gen.
+func (r *WorkloadRecorder) Gen(gen func() []byte) func() []byte {
+	return func() []byte {
+		// The lock is held while generating the leaf so that leaves are recorded in the order they're generated.
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		l := gen()
+		r.record(WorkloadOp{Write: l})
+		return l
+	}
+}
+
+// NextLeaf returns a strategy for a LeafReader which records each leaf chosen by next which is
+// within the tree, and so will be read.
+func (r *WorkloadRecorder) NextLeaf(next func(uint64) uint64) func(uint64) uint64 {
+	return func(size uint64) uint64 {
+		i := next(size)
+		if i < size {
+			r.mu.Lock()
+			r.record(WorkloadOp{Read: &i})
+			r.mu.Unlock()
+		}
+		return i
+	}
+}
+
+// Err returns the first error encountered while recording, after which no more operations are recorded.
+func (r *WorkloadRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// record writes op to the recording. r.mu must be held.
+func (r *WorkloadRecorder) record(op WorkloadOp) {
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(op); err != nil {
+		r.err = fmt.Errorf("failed to record operation: %v", err)
+	}
+}
+
+// NewWorkloadReplayer creates a WorkloadReplayer from a workload recorded by a WorkloadRecorder.
+func NewWorkloadReplayer(r io.Reader) (*WorkloadReplayer, error) {
+	p := &WorkloadReplayer{done: make(chan struct{})}
+	dec := json.NewDecoder(r)
+	for {
+		var op WorkloadOp
+		if err := dec.Decode(&op); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read operation %d: %v", len(p.writes)+len(p.reads), err)
+		}
+		switch {
+		case op.Write != nil && op.Read == nil:
+			p.writes = append(p.writes, op.Write)
+		case op.Read != nil && op.Write == nil:
+			p.reads = append(p.reads, *op.Read)
+		default:
+			return nil, fmt.Errorf("operation %d must have exactly one of write or read set", len(p.writes)+len(p.reads))
+		}
+	}
+	p.maybeDone()
+	return p, nil
+}
+
+// WorkloadReplayer issues the operations recorded by a WorkloadRecorder.
+//
+// The writes, and the random reads, are each issued in the order in which they were recorded, though the
+// interleaving of reads with writes depends on the throttles and the log, as it did when recording.
+//
+// Each operation issued must be reported with OpDone once it has completed.
+type WorkloadReplayer struct {
+	mu     sync.Mutex
+	writes [][]byte
+	reads  []uint64
+	// inflight is the number of operations which have been issued, but not yet reported with OpDone.
+	inflight int
+	done     chan struct{}
+	closed   bool
+}
+
+// Writes returns the number of recorded writes which are yet to be issued.
+func (p *WorkloadReplayer) Writes() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.writes)
+}
+
+// Reads returns the number of recorded random reads which are yet to be issued.
+func (p *WorkloadReplayer) Reads() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.reads)
+}
+
+// Gen returns the next recorded leaf to write, or nil once all of them have been returned.
+func (p *WorkloadReplayer) Gen() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.writes) == 0 {
+		return nil
+	}
+	l := p.writes[0]
+	p.writes = p.writes[1:]
+	p.inflight++
+	return l
+}
+
+// NextLeaf is a strategy for LeafReaders which returns the next recorded leaf to read.
+//
+// Leaves which aren't yet in the tree of the given size are left to be returned by a later call, so that
+// they're still read when replaying against a log which is integrating more slowly than the one recorded.
+// Once all of the recorded reads have been returned, the size is returned so that nothing more is read.
+func (p *WorkloadReplayer) NextLeaf(size uint64) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.reads) == 0 || p.reads[0] >= size {
+		return size
+	}
+	i := p.reads[0]
+	p.reads = p.reads[1:]
+	p.inflight++
+	return i
+}
+
+// OpDone records that an operation issued by Gen or NextLeaf has completed, whether or not it succeeded.
+func (p *WorkloadReplayer) OpDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inflight == 0 {
+		panic("OpDone called more times than operations were issued")
+	}
+	p.inflight--
+	p.maybeDone()
+}
+
+// Done returns a channel which is closed once every recorded operation has been issued and has completed.
+func (p *WorkloadReplayer) Done() <-chan struct{} {
+	return p.done
+}
+
+// maybeDone closes the done channel if there are no more operations to issue or wait for. p.mu must be held.
+func (p *WorkloadReplayer) maybeDone() {
+	if !p.closed && len(p.writes) == 0 && len(p.reads) == 0 && p.inflight == 0 {
+		p.closed = true
+		close(p.done)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkloadRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewWorkloadRecorder(&buf)
+	n := 0
+	gen := rec.Gen(func() []byte {
+		n++
+		return fmt.Appendf(nil, "leaf %d", n)
+	})
+	next := rec.NextLeaf(RandomNextLeaf(1))
+
+	var wantWrites []string
+	var wantReads []uint64
+	for range 5 {
+		wantWrites = append(wantWrites, string(gen()))
+		wantReads = append(wantReads, next(100))
+	}
+	// Leaves beyond the tree aren't read, so aren't recorded.
+	rec.NextLeaf(func(size uint64) uint64 { return size })(100)
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+
+	rep, err := NewWorkloadReplayer(&buf)
+	if err != nil {
+		t.Fatalf("NewWorkloadReplayer: %v", err)
+	}
+	if got, want := rep.Writes(), len(wantWrites); got != want {
+		t.Errorf("Writes: got %d, want %d", got, want)
+	}
+	if got, want := rep.Reads(), len(wantReads); got != want {
+		t.Errorf("Reads: got %d, want %d", got, want)
+	}
+	for i, want := range wantWrites {
+		if got := string(rep.Gen()); got != want {
+			t.Errorf("Gen %d: got %q, want %q", i, got, want)
+		}
+	}
+	if got := rep.Gen(); got != nil {
+		t.Errorf("Gen after replaying all writes: got %q, want nil", got)
+	}
+	for i, want := range wantReads {
+		// Reads of leaves which aren't in the tree yet are deferred until they are.
+		if got := rep.NextLeaf(want); got != want {
+			t.Errorf("NextLeaf %d with size %d: got %d, want %d", i, want, got, want)
+		}
+		select {
+		case <-rep.Done():
+			t.Fatalf("Done after deferring read %d", i)
+		default:
+		}
+		if got := rep.NextLeaf(want + 1); got != want {
+			t.Errorf("NextLeaf %d: got %d, want %d", i, got, want)
+		}
+	}
+	// Every operation has been issued, but none has completed yet.
+	for range len(wantWrites) + len(wantReads) {
+		select {
+		case <-rep.Done():
+			t.Fatal("Done before all operations completed")
+		default:
+		}
+		rep.OpDone()
+	}
+	select {
+	case <-rep.Done():
+	default:
+		t.Error("Not done after replaying all operations")
+	}
+	if got := rep.NextLeaf(100); got != 100 {
+		t.Errorf("NextLeaf after replaying all reads: got %d, want 100", got)
+	}
+}
+
+func TestNewWorkloadReplayerInvalid(t *testing.T) {
+	for _, in := range []string{
+		`{}`,
+		`{"write":"bGVhZg==","read":1}`,
+		`not json`,
+	} {
+		if _, err := NewWorkloadReplayer(strings.NewReader(in)); err == nil {
+			t.Errorf("NewWorkloadReplayer(%q): want error", in)
+		}
+	}
+}
+
+func TestWorkloadReplayerWaitsForWrites(t *testing.T) {
+	rep, err := NewWorkloadReplayer(strings.NewReader(`{"write":"bGVhZg=="}`))
+	if err != nil {
+		t.Fatalf("NewWorkloadReplayer: %v", err)
+	}
+	written := make(chan struct{})
+	release := make(chan struct{})
+	writer := func(context.Context, []byte) (uint64, error) {
+		close(written)
+		<-release
+		return 0, nil
+	}
+	throttle := make(chan bool, 2)
+	throttle <- true
+	throttle <- true
+	w := NewLogWriter(writer, rep.Gen, throttle, make(chan error, 1), make(chan LeafTime, 1))
+	w.opDone = rep.OpDone
+	go w.Run(t.Context())
+
+	<-written
+	select {
+	case <-rep.Done():
+		t.Fatal("Done while the last write is still in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-rep.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Not done after the last write completed")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpserve provides the HTTP serving code shared by personality binaries, which protects the
+// write path against oversized requests and slow or stalled clients.
+//
+// Requests which are too large are rejected with 413 Request Entity Too Large, and requests whose bodies
+// aren't received in time with 408 Request Timeout, before any entry reaches the appender.
+package httpserve
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Options configures the limits applied by NewServer.
+type Options struct {
+	// MaxBodySize is the largest request body, in bytes, which will be accepted.
+	MaxBodySize int64
+	// ReadHeaderTimeout bounds the time taken by clients to send request headers.
+	ReadHeaderTimeout time.Duration
+	// BodyTimeout bounds the time taken by clients to send request bodies, measured from when the
+	// headers have been read.
+	BodyTimeout time.Duration
+	// WriteTimeout bounds the time from the end of reading the request headers to the end of writing
+	// the response. This includes the time taken to sequence entries, so must be comfortably longer
+	// than the appender's batching and pushback behaviour allows for.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds the time for which idle keep-alive connections are kept open.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the largest size, in bytes, of request headers which will be accepted.
+	MaxHeaderBytes int
+}
+
+// DefaultOptions returns the Options used by personalities unless overridden by flags.
+func DefaultOptions() Options {
+	return Options{
+		MaxBodySize:       1 << 20,
+		ReadHeaderTimeout: 10 * time.Second,
+		BodyTimeout:       30 * time.Second,
+		WriteTimeout:      time.Minute,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    64 << 10,
+	}
+}
+
+// RegisterFlags registers flags which override the fields of the returned Options, which are
+// initialised to DefaultOptions.
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	*o = DefaultOptions()
+	fs.Int64Var(&o.MaxBodySize, "http_max_body_size", o.MaxBodySize, "Maximum size in bytes of HTTP request bodies, larger requests are rejected with 413")
+	fs.DurationVar(&o.ReadHeaderTimeout, "http_read_header_timeout", o.ReadHeaderTimeout, "Maximum time for clients to send HTTP request headers")
+	fs.DurationVar(&o.BodyTimeout, "http_body_timeout", o.BodyTimeout, "Maximum time for clients to send HTTP request bodies, slower requests are rejected with 408")
+	fs.DurationVar(&o.WriteTimeout, "http_write_timeout", o.WriteTimeout, "Maximum time from reading HTTP request headers to finishing writing the response")
+	fs.DurationVar(&o.IdleTimeout, "http_idle_timeout", o.IdleTimeout, "Maximum time to keep idle HTTP connections open")
+	fs.IntVar(&o.MaxHeaderBytes, "http_max_header_bytes", o.MaxHeaderBytes, "Maximum size in bytes of HTTP request headers")
+	return o
+}
+
+// NewServer returns an http.Server which serves h on addr, applying the limits in opts.
+//
+// The request bodies passed to h are limited in size and in the time allowed to read them. Handlers
+// should read them with ReadBody, or report errors reading them with WriteBodyError, so that clients
+// receive the appropriate status code.
+func NewServer(addr string, h http.Handler, opts Options) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           Limit(h, opts),
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+}
+
+// Limit returns a handler which applies the body size and timeout limits in opts to requests before
+// passing them to h. This is useful where the http.Server can't be created with NewServer.
+func Limit(h http.Handler, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.MaxBodySize > 0 {
+			// Reject requests which declare their size up front without waiting to read them.
+			if r.ContentLength > opts.MaxBodySize {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", opts.MaxBodySize))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, opts.MaxBodySize)
+		}
+		if opts.BodyTimeout > 0 {
+			// Not all ResponseWriters support deadlines, in which case only the server's timeouts apply.
+			if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(opts.BodyTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				klog.Warningf("Failed to set read deadline: %v", err)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ReadBody reads the whole of the request body. If this fails, the appropriate error response is
+// written, and ok is false.
+func ReadBody(w http.ResponseWriter, r *http.Request) (body []byte, ok bool) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		WriteBodyError(w, err)
+		return nil, false
+	}
+	return b, true
+}
+
+// WriteBodyError writes the response for an error encountered while reading a request body:
+// 413 if it was too large, 408 if it wasn't received in time, and 400 otherwise.
+func WriteBodyError(w http.ResponseWriter, err error) {
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.As(err, &mbe):
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", mbe.Limit))
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, "timed out reading request body")
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+	}
+}
+
+// writeError writes an error response, closing the connection since the rest of the request body
+// can't be relied upon to be read.
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Connection", "close")
+	http.Error(w, msg, code)
+}
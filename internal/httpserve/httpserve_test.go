@@ -0,0 +1,118 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserve
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewServer(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxBodySize = 10
+	opts.BodyTimeout = 100 * time.Millisecond
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	srv := NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := ReadBody(w, r)
+		if !ok {
+			return
+		}
+		_, _ = w.Write(b)
+	}), opts)
+	go func() {
+		if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Serve: %v", err)
+		}
+	}()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	for _, test := range []struct {
+		desc string
+		// req is sent as-is, after which the client stalls.
+		req      string
+		wantCode int
+	}{
+		{
+			desc:     "ok",
+			req:      "POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\n\r\nhello",
+			wantCode: http.StatusOK,
+		}, {
+			desc:     "declared too large",
+			req:      "POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 11\r\n\r\n",
+			wantCode: http.StatusRequestEntityTooLarge,
+		}, {
+			desc:     "chunked too large",
+			req:      "POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\nb\r\nhello world\r\n0\r\n\r\n",
+			wantCode: http.StatusRequestEntityTooLarge,
+		}, {
+			desc:     "slow body",
+			req:      "POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\n\r\nhe",
+			wantCode: http.StatusRequestTimeout,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			c, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer func() { _ = c.Close() }()
+			if err := c.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+				t.Fatalf("SetDeadline: %v", err)
+			}
+			if _, err := io.WriteString(c, test.req); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			rsp, err := http.ReadResponse(bufio.NewReader(c), nil)
+			if err != nil {
+				t.Fatalf("ReadResponse: %v", err)
+			}
+			_ = rsp.Body.Close()
+			if rsp.StatusCode != test.wantCode {
+				t.Errorf("got status %d, want %d", rsp.StatusCode, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestWriteBodyError(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		wantCode int
+	}{
+		{err: &http.MaxBytesError{Limit: 1}, wantCode: http.StatusRequestEntityTooLarge},
+		{err: &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, wantCode: http.StatusRequestTimeout},
+		{err: errors.New("boom"), wantCode: http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		WriteBodyError(w, test.err)
+		if w.Code != test.wantCode {
+			t.Errorf("WriteBodyError(%v): got %d, want %d", test.err, w.Code, test.wantCode)
+		}
+		if got := w.Header().Get("Connection"); got != "close" {
+			t.Errorf("WriteBodyError(%v): got Connection %q, want close", test.err, got)
+		}
+	}
+}
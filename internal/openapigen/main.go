@@ -0,0 +1,330 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// openapigen generates the parts of a Go API client which are described by an OpenAPI specification:
+// the request and response types, the path of each operation, and how each response status should be
+// handled.
+//
+// Only the subset of OpenAPI used by Tessera's specifications is supported. Responses are treated as
+// successful if their status is 2xx, as asking the client to retry later if they have the
+// x-tessera-retry extension set, and as rejecting the request otherwise.
+//
+// It's run via go:generate, e.g.:
+//
+//	//go:generate go run ../../internal/openapigen --spec ../../api/openapi/write-api.json --package write --out write.gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	specPath = flag.String("spec", "", "Path to the OpenAPI specification, in JSON")
+	pkg      = flag.String("package", "", "Name of the Go package to generate")
+	outPath  = flag.String("out", "", "Path of the Go file to write")
+)
+
+// wrapWidth is the width at which generated comments are wrapped.
+const wrapWidth = 110
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	if *specPath == "" || *pkg == "" || *outPath == "" {
+		klog.Exit("--spec, --package, and --out must all be set")
+	}
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		klog.Exitf("Failed to read spec: %v", err)
+	}
+	src, err := generate(spec, filepath.Base(*specPath), *pkg)
+	if err != nil {
+		klog.Exitf("Failed to generate code: %v", err)
+	}
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		klog.Exitf("Failed to write %q: %v", *outPath, err)
+	}
+}
+
+// spec is the subset of an OpenAPI specification which is used by the generator.
+type spec struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Responses map[string]response `json:"responses"`
+		Schemas   map[string]schema   `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	OperationID string              `json:"operationId"`
+	Responses   map[string]response `json:"responses"`
+}
+
+type response struct {
+	Ref   string `json:"$ref"`
+	Retry bool   `json:"x-tessera-retry"`
+}
+
+type schema struct {
+	Ref                  string          `json:"$ref"`
+	Type                 string          `json:"type"`
+	Format               string          `json:"format"`
+	Description          string          `json:"description"`
+	Required             []string        `json:"required"`
+	Properties           json.RawMessage `json:"properties"`
+	Items                *schema         `json:"items"`
+	AdditionalProperties *schema         `json:"additionalProperties"`
+}
+
+// generate returns the formatted Go source for package pkg, generated from the specification in b, whose
+// name is used in the generated file's header.
+func generate(b []byte, name, pkg string) ([]byte, error) {
+	var s spec
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	w := &bytes.Buffer{}
+	fmt.Fprintf(w, "%s\n// Code generated by openapigen from %s. DO NOT EDIT.\n\npackage %s\n\n", license, name, pkg)
+	w.WriteString(responseKindDecl)
+
+	for _, name := range sortedKeys(s.Components.Schemas) {
+		if err := writeSchema(w, name, s.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %q: %v", name, err)
+		}
+	}
+	for _, path := range sortedKeys(s.Paths) {
+		for _, method := range sortedKeys(s.Paths[path]) {
+			op := s.Paths[path][method]
+			if err := writeOperation(w, path, op, s.Components.Responses); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", method, path, err)
+			}
+		}
+	}
+	return format.Source(w.Bytes())
+}
+
+const license = `// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+`
+
+const responseKindDecl = `// responseKind describes how the client should handle a response.
+type responseKind int
+
+const (
+	// responseReject means that the request was rejected, and shouldn't be retried.
+	responseReject responseKind = iota
+	// responseSuccess means that the request succeeded.
+	responseSuccess
+	// responseRetry means that the request should be retried later.
+	responseRetry
+)
+`
+
+// writeSchema writes a struct type for the named object schema.
+func writeSchema(w *bytes.Buffer, name string, s schema) error {
+	if s.Type != "object" {
+		return fmt.Errorf("unsupported type %q", s.Type)
+	}
+	props, err := orderedProperties(s.Properties)
+	if err != nil {
+		return err
+	}
+	w.WriteString("\n")
+	writeComment(w, "", name, s.Description)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, p := range props {
+		t, err := goType(p.schema)
+		if err != nil {
+			return fmt.Errorf("property %q: %v", p.name, err)
+		}
+		tag := p.name
+		if !slices.Contains(s.Required, p.name) {
+			tag += ",omitempty"
+		}
+		field := fieldName(p.name)
+		writeComment(w, "\t", field, p.schema.Description)
+		fmt.Fprintf(w, "\t%s %s `json:%q`\n", field, t, tag)
+	}
+	w.WriteString("}\n")
+	return nil
+}
+
+// writeOperation writes the path of op, and a map from each of its responses' status codes to how the
+// response should be handled.
+func writeOperation(w *bytes.Buffer, path string, op operation, components map[string]response) error {
+	if op.OperationID == "" {
+		return fmt.Errorf("no operationId")
+	}
+	id := op.OperationID
+	fmt.Fprintf(w, "\n// %sPath is the path of the %s operation, relative to the base URL of the API.\n", id, id)
+	fmt.Fprintf(w, "const %sPath = %q\n", id, strings.TrimPrefix(path, "/"))
+
+	fmt.Fprintf(w, "\n// %sResponses maps the status of each response to the %s operation to how it should be handled.\n", id, id)
+	fmt.Fprintf(w, "var %sResponses = map[int]responseKind{\n", id)
+	for _, code := range sortedKeys(op.Responses) {
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			return fmt.Errorf("unsupported response status %q", code)
+		}
+		r := op.Responses[code]
+		if r.Ref != "" {
+			c, ok := components[strings.TrimPrefix(r.Ref, "#/components/responses/")]
+			if !ok {
+				return fmt.Errorf("unknown response %q", r.Ref)
+			}
+			r = c
+		}
+		kind := "responseReject"
+		switch {
+		case status >= 200 && status < 300:
+			kind = "responseSuccess"
+		case r.Retry:
+			kind = "responseRetry"
+		}
+		fmt.Fprintf(w, "\t%d: %s,\n", status, kind)
+	}
+	w.WriteString("}\n")
+	return nil
+}
+
+type property struct {
+	name   string
+	schema schema
+}
+
+// orderedProperties returns the properties of an object schema, in the order in which they're defined.
+func orderedProperties(raw json.RawMessage) ([]property, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := d.Token(); err != nil {
+		return nil, err
+	}
+	var r []property
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		p := property{name: t.(string)}
+		if err := d.Decode(&p.schema); err != nil {
+			return nil, fmt.Errorf("property %q: %v", p.name, err)
+		}
+		r = append(r, p)
+	}
+	return r, nil
+}
+
+// goType returns the Go type which represents values of the schema.
+func goType(s schema) (string, error) {
+	if s.Ref != "" {
+		return strings.TrimPrefix(s.Ref, "#/components/schemas/"), nil
+	}
+	switch s.Type {
+	case "string":
+		if s.Format == "byte" {
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		switch s.Format {
+		case "uint64", "int32":
+			return s.Format, nil
+		}
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "array":
+		if s.Items == nil {
+			return "", fmt.Errorf("array has no items")
+		}
+		t, err := goType(*s.Items)
+		return "[]" + t, err
+	case "object":
+		if s.AdditionalProperties == nil {
+			return "", fmt.Errorf("inline objects are not supported")
+		}
+		t, err := goType(*s.AdditionalProperties)
+		return "map[string]" + t, err
+	}
+	return "", fmt.Errorf("unsupported type %q", s.Type)
+}
+
+// fieldName returns the exported Go name for a snake_case JSON property.
+func fieldName(p string) string {
+	var b strings.Builder
+	for part := range strings.SplitSeq(p, "_") {
+		if part == "" {
+			continue
+		}
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// writeComment writes a doc comment for the named declaration, in the form "name is description", wrapped
+// at wrapWidth.
+func writeComment(w *bytes.Buffer, indent, name, desc string) {
+	if desc == "" {
+		return
+	}
+	r := []rune(desc)
+	r[0] = unicode.ToLower(r[0])
+	line := indent + "//"
+	for _, word := range strings.Fields(name + " is " + string(r)) {
+		if len(line)+1+len(word) > wrapWidth && line != indent+"//" {
+			w.WriteString(line + "\n")
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+	w.WriteString(line + "\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedCodeIsUpToDate checks that the checked-in generated code matches its specification.
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	for _, test := range []struct {
+		spec, pkg, out string
+	}{
+		{spec: "../../api/openapi/write-api.json", pkg: "write", out: "../../client/write/write.gen.go"},
+	} {
+		t.Run(test.out, func(t *testing.T) {
+			spec, err := os.ReadFile(test.spec)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			want, err := generate(spec, "write-api.json", test.pkg)
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			got, err := os.ReadFile(test.out)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s is out of date with %s; run go generate in its directory", test.out, test.spec)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		spec     string
+		want     []string
+		wantErrs bool
+	}{
+		{
+			name: "types",
+			spec: `{"components": {"schemas": {"Req": {"type": "object", "description": "A request.", "required": ["b_value"], "properties": {
+				"b_value": {"type": "string", "format": "byte"},
+				"a_value": {"type": "array", "items": {"type": "integer", "format": "uint64"}},
+				"nested": {"$ref": "#/components/schemas/Other"}}}}}}`,
+			want: []string{
+				"// Req is a request.\ntype Req struct {",
+				"BValue []byte   `json:\"b_value\"`\n",
+				"AValue []uint64 `json:\"a_value,omitempty\"`\n",
+				"Nested Other    `json:\"nested,omitempty\"`\n",
+			},
+		},
+		{
+			name: "operation",
+			spec: `{"paths": {"/sub/op": {"post": {"operationId": "doIt", "responses": {
+				"201": {},
+				"409": {"description": "Conflict"},
+				"503": {"$ref": "#/components/responses/Busy"}}}}},
+				"components": {"responses": {"Busy": {"x-tessera-retry": true}}}}`,
+			want: []string{
+				"const doItPath = \"sub/op\"",
+				"201: responseSuccess,\n\t409: responseReject,\n\t503: responseRetry,",
+			},
+		},
+		{
+			name:     "unknown response",
+			spec:     `{"paths": {"/op": {"post": {"operationId": "op", "responses": {"503": {"$ref": "#/components/responses/Busy"}}}}}}`,
+			wantErrs: true,
+		},
+		{
+			name:     "unsupported type",
+			spec:     `{"components": {"schemas": {"Req": {"type": "object", "properties": {"v": {"type": "null"}}}}}}`,
+			wantErrs: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := generate([]byte(test.spec), "test.json", "test")
+			if gotErr := err != nil; gotErr != test.wantErrs {
+				t.Fatalf("generate: got err %v, want err %t", err, test.wantErrs)
+			}
+			for _, w := range test.want {
+				if !strings.Contains(string(got), w) {
+					t.Errorf("Generated code doesn't contain %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+type tracerProviderKey struct{}
+
+// ContextWithTracerProvider returns a copy of ctx which carries tp, so that spans started with a
+// Tracer from this package in ctx, or any context derived from it, are created by tp.
+//
+// Storage drivers start their background tasks with the context passed to their Appender, so this
+// is how spans for sequencing, integration, and publishing reach the provider configured with
+// tessera.AppendOptions.WithTracing.
+func ContextWithTracerProvider(ctx context.Context, tp trace.TracerProvider) context.Context {
+	return context.WithValue(ctx, tracerProviderKey{}, tp)
+}
+
+// TracerProvider returns the provider which should be used to start a span in ctx.
+//
+// This is the provider which created the span already in ctx, if there is one, so that child spans
+// are recorded alongside their parent. Otherwise, it's the provider set with ContextWithTracerProvider,
+// and if there isn't one, the global otel TracerProvider.
+func TracerProvider(ctx context.Context) trace.TracerProvider {
+	// Spans extracted from a remote parent aren't backed by a provider, so those are skipped.
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !sc.IsRemote() {
+		return trace.SpanFromContext(ctx).TracerProvider()
+	}
+	if tp, ok := ctx.Value(tracerProviderKey{}).(trace.TracerProvider); ok {
+		return tp
+	}
+	return otel.GetTracerProvider()
+}
+
+// Tracer returns a named trace.Tracer which starts each span with the provider returned by TracerProvider
+// for the context it's started in.
+func Tracer(name string) trace.Tracer {
+	return ctxTracer{name: name}
+}
+
+type ctxTracer struct {
+	embedded.Tracer
+
+	name string
+}
+
+func (t ctxTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return TracerProvider(ctx).Tracer(t.name).Start(ctx, spanName, opts...)
+}
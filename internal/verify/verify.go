@@ -0,0 +1,168 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verify provides helpers for checking that the static resources of a tlog-tiles log
+// are consistent with one another, and with the log's checkpoint, before they're copied elsewhere.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+)
+
+var rf = &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+
+// Root checks that the root hash of the tree of the given size, as calculated from the tiles
+// returned by f, is root.
+func Root(ctx context.Context, f client.TileFetcherFunc, size uint64, root []byte) error {
+	got := rfc6962.DefaultHasher.EmptyRoot()
+	if size > 0 {
+		hashes, err := client.FetchRangeNodes(ctx, size, f)
+		if err != nil {
+			return fmt.Errorf("failed to fetch range nodes for size %d: %v", size, err)
+		}
+		r, err := rf.NewRange(0, size, hashes)
+		if err != nil {
+			return fmt.Errorf("failed to create range: %v", err)
+		}
+		if got, err = r.GetRootHash(nil); err != nil {
+			return fmt.Errorf("failed to calculate root hash: %v", err)
+		}
+	}
+	if !bytes.Equal(got, root) {
+		return fmt.Errorf("root hash of tiles at size %d is %x, want %x", size, got, root)
+	}
+	return nil
+}
+
+// Consistency checks that the tree of size to, whose tiles are returned by f, is an extension of the
+// tree of size from.
+func Consistency(ctx context.Context, f client.TileFetcherFunc, from, to uint64, fromRoot, toRoot []byte) error {
+	if from == 0 {
+		return nil
+	}
+	pb, err := client.NewProofBuilder(ctx, to, f)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	p, err := pb.ConsistencyProof(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to build consistency proof: %v", err)
+	}
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, from, to, p, fromRoot, toRoot); err != nil {
+		return fmt.Errorf("tree of size %d is not consistent with tree of size %d: %v", to, from, err)
+	}
+	return nil
+}
+
+// Tile reads the tile at the given coordinates from f, along with its entry bundle if it's a level zero
+// tile, and checks the tile against its children.
+//
+// Level zero tiles are checked against the hashes of the entries in the bundle, and tiles at higher levels
+// against the root hashes of the full tiles one level down. Along with Root, this ensures that each tile
+// belongs to the tree committed to by the checkpoint.
+//
+// The raw tile and entry bundle are returned, the latter being nil for tiles above level zero.
+func Tile(ctx context.Context, f client.Fetcher, l, i uint64, p uint8) ([]byte, []byte, error) {
+	tileRaw, err := f.ReadTile(ctx, l, i, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tile %s: %v", layout.TilePath(l, i, p), err)
+	}
+	tile := api.HashTile{}
+	if err := tile.UnmarshalText(tileRaw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse tile %s: %v", layout.TilePath(l, i, p), err)
+	}
+	var want [][]byte
+	var bundleRaw []byte
+	if l == 0 {
+		if bundleRaw, err = f.ReadEntryBundle(ctx, i, p); err != nil {
+			return nil, nil, fmt.Errorf("failed to read entry bundle %s: %v", layout.EntriesPath(i, p), err)
+		}
+		bundle := api.EntryBundle{}
+		if err := bundle.UnmarshalText(bundleRaw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse entry bundle %s: %v", layout.EntriesPath(i, p), err)
+		}
+		for _, e := range bundle.Entries {
+			want = append(want, rfc6962.DefaultHasher.HashLeaf(e))
+		}
+	} else {
+		for j := range uint64(len(tile.Nodes)) {
+			h, err := tileRoot(ctx, f, l-1, i*layout.TileWidth+j)
+			if err != nil {
+				return nil, nil, err
+			}
+			want = append(want, h)
+		}
+	}
+	if len(want) != len(tile.Nodes) {
+		return nil, nil, fmt.Errorf("tile %s has %d hashes, but its children imply %d", layout.TilePath(l, i, p), len(tile.Nodes), len(want))
+	}
+	for j := range want {
+		if !bytes.Equal(want[j], tile.Nodes[j]) {
+			return nil, nil, fmt.Errorf("hash %d of tile %s doesn't match its children", j, layout.TilePath(l, i, p))
+		}
+	}
+	return tileRaw, bundleRaw, nil
+}
+
+// tileRoot returns the root hash of the full tile at the given coordinates.
+func tileRoot(ctx context.Context, f client.Fetcher, l, i uint64) ([]byte, error) {
+	raw, err := f.ReadTile(ctx, l, i, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tile %s: %v", layout.TilePath(l, i, 0), err)
+	}
+	t := api.HashTile{}
+	if err := t.UnmarshalText(raw); err != nil {
+		return nil, fmt.Errorf("failed to parse tile %s: %v", layout.TilePath(l, i, 0), err)
+	}
+	if len(t.Nodes) != layout.TileWidth {
+		return nil, fmt.Errorf("tile %s has %d hashes, want %d", layout.TilePath(l, i, 0), len(t.Nodes), layout.TileWidth)
+	}
+	r := rf.NewEmptyRange(0)
+	for _, h := range t.Nodes {
+		if err := r.Append(h, nil); err != nil {
+			return nil, err
+		}
+	}
+	return r.GetRootHash(nil)
+}
+
+// TileCoords identifies a tile.
+type TileCoords struct {
+	Level, Index uint64
+	Partial      uint8
+}
+
+// NewTiles returns the coordinates of the tiles which are present in the tree of size to, but not in
+// the tree of size from, starting at level zero.
+func NewTiles(from, to uint64) iter.Seq[TileCoords] {
+	return func(yield func(TileCoords) bool) {
+		for l := uint64(0); to > 0; from, to, l = from>>layout.TileHeight, to>>layout.TileHeight, l+1 {
+			for ri := range layout.Range(from, to-from, to) {
+				if !yield(TileCoords{Level: l, Index: ri.Index, Partial: ri.Partial}) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var keyRotationPhase metric.Int64Gauge
+
+func init() {
+	var err error
+
+	keyRotationPhase, err = meter.Int64Gauge(
+		"tessera.appender.key_rotation.phase",
+		metric.WithDescription("Phase of the checkpoint signing key rotation: 0 = pending, 1 = dual-signing, 2 = complete"))
+	if err != nil {
+		klog.Exitf("Failed to create keyRotationPhase metric: %v", err)
+	}
+}
+
+// KeyRotationPhase describes which key(s) are used to sign checkpoints at a given point during a key rotation.
+type KeyRotationPhase int
+
+const (
+	// KeyRotationPending means the rotation has not yet started, and only the old key is used.
+	KeyRotationPending KeyRotationPhase = iota
+	// KeyRotationDualSigning means checkpoints are being signed by both the old and new keys.
+	KeyRotationDualSigning
+	// KeyRotationComplete means the rotation is complete, and only the new key is used.
+	KeyRotationComplete
+)
+
+func (p KeyRotationPhase) String() string {
+	switch p {
+	case KeyRotationPending:
+		return "pending"
+	case KeyRotationDualSigning:
+		return "dual-signing"
+	case KeyRotationComplete:
+		return "complete"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+}
+
+// KeyRotation describes the rotation of a log's checkpoint signing key from Old to New.
+//
+// Checkpoints are signed by only the Old key before Start, by both keys in the dual-signing
+// window between Start and End, and by only the New key from End onwards. The dual-signing window
+// should be long enough for clients to learn of, and begin trusting, the New key.
+//
+// Both signers must have the same name, which is used as the checkpoint origin.
+//
+// KeyRotation implements http.Handler, serving a JSON description of the current rotation state,
+// which personalities may expose via their admin API.
+type KeyRotation struct {
+	Old   note.Signer
+	New   note.Signer
+	Start time.Time
+	End   time.Time
+}
+
+// Phase returns the phase of the rotation at the given time.
+func (r *KeyRotation) Phase(t time.Time) KeyRotationPhase {
+	switch {
+	case t.Before(r.Start):
+		return KeyRotationPending
+	case t.Before(r.End):
+		return KeyRotationDualSigning
+	default:
+		return KeyRotationComplete
+	}
+}
+
+// signersAt returns the signers which should be used to sign checkpoints at the given time.
+func (r *KeyRotation) signersAt(t time.Time) []note.Signer {
+	switch r.Phase(t) {
+	case KeyRotationPending:
+		return []note.Signer{r.Old}
+	case KeyRotationDualSigning:
+		return []note.Signer{r.New, r.Old}
+	default:
+		return []note.Signer{r.New}
+	}
+}
+
+func (r *KeyRotation) valid() error {
+	if r.Old == nil || r.New == nil {
+		return fmt.Errorf("both Old and New signers must be provided")
+	}
+	if r.Old.Name() != r.New.Name() {
+		return fmt.Errorf("new signer name (%q) does not match old signer name (%q)", r.New.Name(), r.Old.Name())
+	}
+	if r.End.Before(r.Start) {
+		return fmt.Errorf("end of dual-signing window (%v) is before its start (%v)", r.End, r.Start)
+	}
+	return nil
+}
+
+// ServeHTTP serves a JSON description of the current state of the key rotation.
+func (r *KeyRotation) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	state := struct {
+		Phase      string    `json:"phase"`
+		Name       string    `json:"name"`
+		OldKeyHash string    `json:"old_key_hash"`
+		NewKeyHash string    `json:"new_key_hash"`
+		Start      time.Time `json:"start"`
+		End        time.Time `json:"end"`
+	}{
+		Phase:      r.Phase(time.Now()).String(),
+		Name:       r.Old.Name(),
+		OldKeyHash: fmt.Sprintf("%08x", r.Old.KeyHash()),
+		NewKeyHash: fmt.Sprintf("%08x", r.New.KeyHash()),
+		Start:      r.Start,
+		End:        r.End,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		klog.Warningf("KeyRotation: failed to write state: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestKeyRotation(t *testing.T) {
+	newKey := func(name string) (note.Signer, note.Verifier) {
+		t.Helper()
+		sk, vk, err := note.GenerateKey(rand.Reader, name)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		s, err := note.NewSigner(sk)
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+		v, err := note.NewVerifier(vk)
+		if err != nil {
+			t.Fatalf("NewVerifier: %v", err)
+		}
+		return s, v
+	}
+	oldS, oldV := newKey("example.com/log")
+	newS, newV := newKey("example.com/log")
+	now := time.Now()
+
+	for _, test := range []struct {
+		name       string
+		start, end time.Time
+		wantPhase  KeyRotationPhase
+		wantOld    bool
+		wantNew    bool
+	}{
+		{
+			name:      "pending",
+			start:     now.Add(time.Hour),
+			end:       now.Add(2 * time.Hour),
+			wantPhase: KeyRotationPending,
+			wantOld:   true,
+		}, {
+			name:      "dual-signing",
+			start:     now.Add(-time.Hour),
+			end:       now.Add(time.Hour),
+			wantPhase: KeyRotationDualSigning,
+			wantOld:   true,
+			wantNew:   true,
+		}, {
+			name:      "complete",
+			start:     now.Add(-2 * time.Hour),
+			end:       now.Add(-time.Hour),
+			wantPhase: KeyRotationComplete,
+			wantNew:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := &KeyRotation{Old: oldS, New: newS, Start: test.start, End: test.end}
+			if got := r.Phase(now); got != test.wantPhase {
+				t.Errorf("Phase: got %v, want %v", got, test.wantPhase)
+			}
+
+			opts := NewAppendOptions().WithKeyRotation(r)
+			root := sha256.Sum256([]byte("root"))
+			cp, err := opts.newCP(t.Context(), 10, root[:])
+			if err != nil {
+				t.Fatalf("newCP: %v", err)
+			}
+			for _, v := range []struct {
+				v    note.Verifier
+				want bool
+			}{{oldV, test.wantOld}, {newV, test.wantNew}} {
+				_, err := note.Open(cp, note.VerifierList(v.v))
+				if got := err == nil; got != v.want {
+					t.Errorf("Signed by key %08x: got %t, want %t", v.v.KeyHash(), got, v.want)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			var state struct {
+				Phase string `json:"phase"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if state.Phase != test.wantPhase.String() {
+				t.Errorf("Served phase %q, want %q", state.Phase, test.wantPhase)
+			}
+		})
+	}
+}
+
+func TestKeyRotationValid(t *testing.T) {
+	s := func(name string) note.Signer {
+		t.Helper()
+		sk, _, err := note.GenerateKey(rand.Reader, name)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		s, err := note.NewSigner(sk)
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+		return s
+	}
+	now := time.Now()
+	for _, test := range []struct {
+		name    string
+		r       KeyRotation
+		wantErr bool
+	}{
+		{name: "ok", r: KeyRotation{Old: s("a"), New: s("a"), Start: now, End: now.Add(time.Hour)}},
+		{name: "missing new", r: KeyRotation{Old: s("a"), Start: now, End: now.Add(time.Hour)}, wantErr: true},
+		{name: "name mismatch", r: KeyRotation{Old: s("a"), New: s("b"), Start: now, End: now.Add(time.Hour)}, wantErr: true},
+		{name: "end before start", r: KeyRotation{Old: s("a"), New: s("a"), Start: now, End: now.Add(-time.Hour)}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.r.valid(); (err != nil) != test.wantErr {
+				t.Errorf("valid: got %v, want err %t", err, test.wantErr)
+			}
+		})
+	}
+}
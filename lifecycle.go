@@ -15,23 +15,31 @@
 package tessera
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"golang.org/x/sync/errgroup"
 )
 
 // NoMoreEntries is a sentinel error returned by StreamEntries when no more entries will be returned by calls to the next function.
 var ErrNoMoreEntries = errors.New("no more entries")
 
+// ErrRangeNotSatisfiable is returned by ReadEntryBundleRange when the requested range starts beyond the end of the resource.
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
 // LogReader provides read-only access to the log.
 type LogReader interface {
 	// ReadCheckpoint returns the latest checkpoint available.
-	// If no checkpoint is available then os.ErrNotExist should be returned.
+	// If no checkpoint is available then an error wrapping ErrNotFound should be returned.
 	ReadCheckpoint(ctx context.Context) ([]byte, error)
 
 	// ReadTile returns the raw marshalled tile at the given coordinates, if it exists.
@@ -50,6 +58,7 @@ type LogReader interface {
 
 	// ReadEntryBundle returns the raw marshalled leaf bundle at the given coordinates, if
 	// it exists.
+	// Resources which don't exist result in an error which wraps ErrNotFound.
 	// The expected usage and corresponding behaviours are similar to ReadTile.
 	ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error)
 
@@ -88,6 +97,249 @@ type LogReader interface {
 	StreamEntries(ctx context.Context, fromEntryIdx uint64) (next func() (layout.RangeInfo, []byte, error), cancel func())
 }
 
+// StreamingLogReader is an optional interface which LogReader implementations may also implement
+// in order to provide access to large resources without needing to buffer them fully in memory.
+//
+// Callers should generally use the ReadTileStream and ReadEntryBundleStream functions below rather than
+// asserting this interface directly, as they will fall back to the buffered methods on LogReader where
+// the implementation doesn't support streaming.
+type StreamingLogReader interface {
+	// ReadTileStream returns a reader for the raw marshalled tile at the given coordinates, if it exists.
+	// The semantics are otherwise identical to LogReader.ReadTile.
+	//
+	// Callers must close the returned reader once they're done with it.
+	ReadTileStream(ctx context.Context, level, index uint64, p uint8) (io.ReadCloser, error)
+
+	// ReadEntryBundleStream returns a reader for the raw marshalled leaf bundle at the given coordinates,
+	// if it exists.
+	// The semantics are otherwise identical to LogReader.ReadEntryBundle.
+	//
+	// Callers must close the returned reader once they're done with it.
+	ReadEntryBundleStream(ctx context.Context, index uint64, p uint8) (io.ReadCloser, error)
+}
+
+// ReadTileStream returns a reader for the raw marshalled tile at the given coordinates from the provided LogReader.
+//
+// If lr implements StreamingLogReader the tile will be streamed from storage, otherwise it will be read
+// into memory via LogReader.ReadTile.
+func ReadTileStream(ctx context.Context, lr LogReader, level, index uint64, p uint8) (io.ReadCloser, error) {
+	if s, ok := lr.(StreamingLogReader); ok {
+		return s.ReadTileStream(ctx, level, index, p)
+	}
+	t, err := lr.ReadTile(ctx, level, index, p)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(t)), nil
+}
+
+// ReadEntryBundleStream returns a reader for the raw marshalled leaf bundle at the given coordinates from the
+// provided LogReader.
+//
+// If lr implements StreamingLogReader the bundle will be streamed from storage, otherwise it will be read
+// into memory via LogReader.ReadEntryBundle.
+func ReadEntryBundleStream(ctx context.Context, lr LogReader, index uint64, p uint8) (io.ReadCloser, error) {
+	if s, ok := lr.(StreamingLogReader); ok {
+		return s.ReadEntryBundleStream(ctx, index, p)
+	}
+	b, err := lr.ReadEntryBundle(ctx, index, p)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// CheckpointModTimeReader is an optional interface which LogReader implementations may also implement in order
+// to report when the latest checkpoint was written, e.g. to serve HTTP Last-Modified headers.
+type CheckpointModTimeReader interface {
+	// ReadCheckpointModTime returns the time at which the latest checkpoint was written.
+	// If no checkpoint is available then an error wrapping ErrNotFound should be returned.
+	ReadCheckpointModTime(ctx context.Context) (time.Time, error)
+}
+
+// TreeState is a snapshot of the log's latest published checkpoint, and the tree it commits to.
+type TreeState struct {
+	// Size is the size of the tree committed to by the checkpoint.
+	Size uint64
+	// Root is the root hash of the tree committed to by the checkpoint.
+	Root []byte
+	// Checkpoint is the checkpoint as published, including any witness cosignatures.
+	Checkpoint []byte
+	// PublishedAt is the time at which the checkpoint was written, or the zero time if the storage
+	// doesn't record it.
+	PublishedAt time.Time
+}
+
+// TreeStateReader is an optional interface which LogReader implementations may also implement in order to
+// return the latest checkpoint together with the state it commits to, and its publication time, in one read.
+//
+// Callers should generally use the ReadTreeState function below rather than asserting this interface
+// directly, as it will fall back to reading and parsing the checkpoint where the implementation doesn't
+// support it.
+type TreeStateReader interface {
+	// TreeState returns the state of the log as of its latest checkpoint.
+	// If no checkpoint is available then an error wrapping ErrNotFound should be returned.
+	TreeState(ctx context.Context) (TreeState, error)
+}
+
+// ReadTreeState returns the state of the log as of its latest checkpoint from the provided LogReader.
+//
+// The checkpoint is trusted to have come from the log's own storage, so its signature is not verified. Personalities
+// which serve the state to others should use this rather than parsing the checkpoint themselves.
+//
+// If lr implements TreeStateReader its TreeState method is used, otherwise the checkpoint is read and parsed,
+// and its publication time is read if lr implements CheckpointModTimeReader.
+func ReadTreeState(ctx context.Context, lr LogReader) (TreeState, error) {
+	if r, ok := lr.(TreeStateReader); ok {
+		return r.TreeState(ctx)
+	}
+	var at time.Time
+	if r, ok := lr.(CheckpointModTimeReader); ok {
+		// Read before the checkpoint, so that a concurrent publication can only make the checkpoint seem older.
+		var err error
+		if at, err = r.ReadCheckpointModTime(ctx); err != nil {
+			return TreeState{}, err
+		}
+	}
+	cp, err := lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return TreeState{}, err
+	}
+	return NewTreeState(cp, at)
+}
+
+// NewTreeState returns the TreeState for the provided checkpoint, which was published at the given time.
+//
+// This is intended to be used by TreeStateReader implementations. The checkpoint's signature is not verified.
+func NewTreeState(cp []byte, publishedAt time.Time) (TreeState, error) {
+	_, size, root, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return TreeState{}, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	return TreeState{Size: size, Root: root, Checkpoint: cp, PublishedAt: publishedAt}, nil
+}
+
+// RangeLogReader is an optional interface which LogReader implementations may also implement in order to
+// support reading only part of an entry bundle, e.g. to serve HTTP Range requests for a single entry without
+// transferring the whole bundle.
+//
+// Callers should generally use the ReadEntryBundleRange function below rather than asserting this interface
+// directly, as it will fall back to reading the full bundle where the implementation doesn't support ranges.
+type RangeLogReader interface {
+	// ReadEntryBundleRange returns a reader for up to length bytes of the raw marshalled leaf bundle at the
+	// given coordinates starting at offset, along with the total size of the bundle.
+	//
+	// If offset is negative, the range starts -offset bytes before the end of the bundle, and length must be
+	// negative. If length is negative, the range extends to the end of the bundle.
+	//
+	// Returns ErrRangeNotSatisfiable if offset is not less than the size of the bundle.
+	// Callers must close the returned reader once they're done with it.
+	ReadEntryBundleRange(ctx context.Context, index uint64, p uint8, offset, length int64) (io.ReadCloser, int64, error)
+}
+
+// ReadEntryBundleRange returns a reader for part of the raw marshalled leaf bundle at the given coordinates from
+// the provided LogReader, along with the total size of the bundle.
+// See RangeLogReader for the semantics of offset and length.
+//
+// If lr implements RangeLogReader only the requested range will be read from storage, otherwise the full
+// bundle will be read into memory via LogReader.ReadEntryBundle.
+func ReadEntryBundleRange(ctx context.Context, lr LogReader, index uint64, p uint8, offset, length int64) (io.ReadCloser, int64, error) {
+	if r, ok := lr.(RangeLogReader); ok {
+		return r.ReadEntryBundleRange(ctx, index, p, offset, length)
+	}
+	b, err := lr.ReadEntryBundle(ctx, index, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, n, err := ResolveRange(int64(len(b)), offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(b[start : start+n])), int64(len(b)), nil
+}
+
+// ResolveRange returns the absolute start and length of the range described by offset and length within a
+// resource of the given size, as described by RangeLogReader.
+//
+// This is intended to be used by RangeLogReader implementations, and callers of ReadEntryBundleRange.
+func ResolveRange(size, offset, length int64) (int64, int64, error) {
+	if offset < 0 {
+		if length >= 0 {
+			return 0, 0, fmt.Errorf("length must be negative when offset is negative, got %d", length)
+		}
+		return max(0, size+offset), size - max(0, size+offset), nil
+	}
+	if offset >= size {
+		return 0, 0, fmt.Errorf("offset %d, size %d: %w", offset, size, ErrRangeNotSatisfiable)
+	}
+	if length < 0 || length > size-offset {
+		length = size - offset
+	}
+	return offset, length, nil
+}
+
+// BundleAddr identifies an entry bundle by its index and partial size, as used by ReadEntryBundles.
+type BundleAddr struct {
+	// Index is the index of the entry bundle.
+	Index uint64
+	// Partial is the number of entries in a partial bundle, or zero for a full bundle.
+	Partial uint8
+}
+
+// BulkLogReader is an optional interface which LogReader implementations may also implement in order to
+// read many entry bundles more efficiently than with individual calls to ReadEntryBundle, e.g. by batching
+// requests to the storage backend.
+//
+// Callers should generally use the ReadEntryBundles function below rather than asserting this interface
+// directly, as it will fall back to parallel calls to ReadEntryBundle where the implementation doesn't
+// support bulk reads.
+type BulkLogReader interface {
+	// ReadEntryBundles returns the raw marshalled leaf bundles at each of the given addresses, in the same
+	// order as they were requested.
+	//
+	// The bundle returned for each address must be the same as LogReader.ReadEntryBundle returns for that
+	// address's Index and Partial. In particular, a request for a partial bundle which has since grown
+	// returns whatever ReadEntryBundle does for it, so callers must not assume that the returned bundle holds
+	// exactly Partial entries.
+	//
+	// An error is returned if any of the bundles cannot be read. A bundle which is missing, or which holds
+	// fewer entries than requested, results in an error which wraps ErrNotFound.
+	ReadEntryBundles(ctx context.Context, addrs []BundleAddr) ([][]byte, error)
+}
+
+// readEntryBundlesConcurrency is the maximum number of concurrent ReadEntryBundle calls made by
+// ReadEntryBundles for LogReaders which don't implement BulkLogReader.
+const readEntryBundlesConcurrency = 16
+
+// ReadEntryBundles returns the raw marshalled leaf bundles at each of the given addresses from the provided
+// LogReader, in the same order as they were requested.
+//
+// If lr implements BulkLogReader, its ReadEntryBundles method is used, otherwise the bundles are fetched
+// via concurrent calls to LogReader.ReadEntryBundle. Either way, the bundle returned for each address is
+// the one which ReadEntryBundle returns for it.
+func ReadEntryBundles(ctx context.Context, lr LogReader, addrs []BundleAddr) ([][]byte, error) {
+	if b, ok := lr.(BulkLogReader); ok {
+		return b.ReadEntryBundles(ctx, addrs)
+	}
+	r := make([][]byte, len(addrs))
+	errG, ctx := errgroup.WithContext(ctx)
+	errG.SetLimit(readEntryBundlesConcurrency)
+	for i, a := range addrs {
+		errG.Go(func() error {
+			b, err := lr.ReadEntryBundle(ctx, a.Index, a.Partial)
+			if err != nil {
+				return fmt.Errorf("ReadEntryBundle(%d, %d): %w", a.Index, a.Partial, err)
+			}
+			r[i] = b
+			return nil
+		})
+	}
+	if err := errG.Wait(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 // Follower describes the contract of something which is required to track the contents of the local log.
 type Follower interface {
 	// Name returns a human readable name for this follower.
@@ -117,6 +369,17 @@ type Antispam interface {
 	Follower(func(entryBundle []byte) ([][]byte, error)) Follower
 }
 
+// IndexLookup is implemented by antispam storage which can be queried directly for the index at which an entry
+// with a given identity hash was integrated.
+//
+// Antispam storage only learns about entries by following the log, so an entry can be found only once it has
+// been integrated and the follower has caught up with it.
+type IndexLookup interface {
+	// LookupIndex returns the index of the earliest entry in the log with the provided identity hash.
+	// If there is no such entry known to the storage, an error which wraps ErrNotFound is returned.
+	LookupIndex(ctx context.Context, identityHash []byte) (uint64, error)
+}
+
 // identityHash calculates the antispam identity hash for the provided (single) leaf entry data.
 func identityHash(data []byte) []byte {
 	h := sha256.Sum256(data)
@@ -0,0 +1,146 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+// bulkLogReader is a LogReader which records whether its BulkLogReader implementation was used.
+type bulkLogReader struct {
+	tessera.LogReader
+	calls int
+}
+
+func (b *bulkLogReader) ReadEntryBundles(ctx context.Context, addrs []tessera.BundleAddr) ([][]byte, error) {
+	b.calls++
+	r := make([][]byte, 0, len(addrs))
+	for _, a := range addrs {
+		d, err := b.ReadEntryBundle(ctx, a.Index, a.Partial)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, d)
+	}
+	return r, nil
+}
+
+func TestReadEntryBundles(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	const numEntries = layout.EntryBundleWidth + 10
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, numEntries)
+	for i := range numEntries {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+
+	addrs := []tessera.BundleAddr{{Index: 1, Partial: 10}, {Index: 0}, {Index: 1, Partial: 10}}
+	bulk := &bulkLogReader{LogReader: tl.LogReader}
+	for _, tC := range []struct {
+		desc string
+		lr   tessera.LogReader
+	}{
+		{desc: "fallback", lr: tl.LogReader},
+		{desc: "bulk", lr: bulk},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			got, err := tessera.ReadEntryBundles(ctx, tC.lr, addrs)
+			if err != nil {
+				t.Fatalf("ReadEntryBundles: %v", err)
+			}
+			if len(got) != len(addrs) {
+				t.Fatalf("Got %d bundles, want %d", len(got), len(addrs))
+			}
+			for i, a := range addrs {
+				want, err := tl.LogReader.ReadEntryBundle(ctx, a.Index, a.Partial)
+				if err != nil {
+					t.Fatalf("ReadEntryBundle(%d, %d): %v", a.Index, a.Partial, err)
+				}
+				if !bytes.Equal(got[i], want) {
+					t.Errorf("ReadEntryBundles()[%d] != ReadEntryBundle(%d, %d)", i, a.Index, a.Partial)
+				}
+			}
+
+			missing := []tessera.BundleAddr{{Index: 0}, {Index: 2}}
+			if _, err := tessera.ReadEntryBundles(ctx, tC.lr, missing); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("ReadEntryBundles(%v): got err %v, want %v", missing, err, os.ErrNotExist)
+			}
+		})
+	}
+	if bulk.calls != 2 {
+		t.Errorf("BulkLogReader.ReadEntryBundles called %d times, want 2", bulk.calls)
+	}
+}
+
+func TestReadTreeState(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+	idx, cp, err := awaiter.Await(ctx, tl.Appender.Add(ctx, tessera.NewEntry([]byte("entry"))))
+	if err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+
+	for _, tC := range []struct {
+		desc string
+		lr   tessera.LogReader
+	}{
+		{desc: "driver", lr: tl.LogReader},
+		// Embedding only the LogReader interface hides the driver's TreeStateReader implementation.
+		{desc: "fallback", lr: struct{ tessera.LogReader }{tl.LogReader}},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			ts, err := tessera.ReadTreeState(ctx, tC.lr)
+			if err != nil {
+				t.Fatalf("ReadTreeState: %v", err)
+			}
+			if ts.Size <= idx.Index {
+				t.Errorf("Got size %d, want > %d", ts.Size, idx.Index)
+			}
+			if !bytes.Equal(ts.Checkpoint, cp) {
+				t.Errorf("Got checkpoint %q, want %q", ts.Checkpoint, cp)
+			}
+			if len(ts.Root) == 0 {
+				t.Error("Root hash is empty")
+			}
+		})
+	}
+}
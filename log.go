@@ -16,6 +16,9 @@ package tessera
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
+	"time"
 )
 
 // ErrPushback is returned by underlying storage implementations when a new entry cannot be accepted
@@ -26,8 +29,94 @@ import (
 // Personalities encountering this error should apply back-pressure to the source of new entries
 // in an appropriate manner (e.g. for HTTP services, return a 503 with a Retry-After header).
 //
-// Personalities should check for this error using `errors.Is(e, ErrPushback)`.
+// Personalities should check for this error using `errors.Is(e, ErrPushback)`, and can find out how long
+// the source should wait before retrying with RetryAfter.
 var ErrPushback = errors.New("pushback")
 
+// PushbackError is returned, possibly wrapped, by storage implementations which can suggest how long the
+// source of an entry should wait before trying to add it again.
+//
+// errors.Is(e, ErrPushback) is true for all PushbackErrors.
+type PushbackError struct {
+	// Reason briefly describes the overload, e.g. "too many outstanding entries". It's used as a metric
+	// attribute, so shouldn't include values which vary from one error to the next.
+	Reason string
+	// RetryAfter is the suggested delay before retrying, or zero if there's no suggestion.
+	RetryAfter time.Duration
+}
+
+func (e *PushbackError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrPushback, e.Reason)
+}
+
+// Is allows PushbackError to match ErrPushback.
+func (e *PushbackError) Is(target error) bool { return target == ErrPushback }
+
+// DefaultRetryAfter is the delay suggested by RetryAfter for errors which don't carry their own suggestion.
+const DefaultRetryAfter = time.Second
+
+// RetryAfter returns how long the source of an entry which failed to be added with err should wait
+// before trying again: the suggestion carried by a PushbackError, if err wraps one which has a suggestion,
+// and DefaultRetryAfter otherwise.
+//
+// HTTP personalities can use this to set the Retry-After header of their 503 and 429 responses.
+func RetryAfter(err error) time.Duration {
+	var pe *PushbackError
+	if errors.As(err, &pe) && pe.RetryAfter > 0 {
+		return pe.RetryAfter
+	}
+	return DefaultRetryAfter
+}
+
+// ErrNotFound is returned, wrapped, by storage implementations when the requested log resource
+// (e.g. a checkpoint, tile, or entry bundle) does not exist.
+//
+// For compatibility with code which predates it, errors.Is(ErrNotFound, fs.ErrNotExist) is also true.
+// Personalities should check for this error using `errors.Is(e, ErrNotFound)`.
+var ErrNotFound error = notFoundError{}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "not found" }
+
+// Is allows ErrNotFound to match fs.ErrNotExist.
+func (notFoundError) Is(target error) bool { return target == fs.ErrNotExist }
+
+// ErrSealed is returned by an appender which no longer accepts new entries, e.g. because it has been
+// shut down.
+//
+// Personalities should check for this error using `errors.Is(e, ErrSealed)`.
+var ErrSealed = errors.New("log is not accepting new entries")
+
+// ErrReadOnly is returned by Add on an appender created with AppendOptions.WithReadOnly.
+//
+// It wraps ErrSealed, so errors.Is(ErrReadOnly, ErrSealed) is true.
+var ErrReadOnly = fmt.Errorf("%w: log is in read-only mode", ErrSealed)
+
+// ErrEntryTooLarge is returned, wrapped, by an appender when an entry is larger than the limit set via
+// AppendOptions.WithMaxEntrySize.
+//
+// Personalities should check for this error using `errors.Is(e, ErrEntryTooLarge)`.
+var ErrEntryTooLarge = errors.New("entry too large")
+
+// ErrInvalidEntry wraps errors returned by a validator configured via AppendOptions.WithEntryValidator.
+//
+// Personalities should check for this error using `errors.Is(e, ErrInvalidEntry)`.
+var ErrInvalidEntry = errors.New("invalid entry")
+
+// ErrQuotaExceeded is returned, wrapped, by an appender when an entry can't be accepted because a quota set
+// via AppendOptions.WithWriteQuota has been used up.
+//
+// Unlike ErrPushback, this indicates that the submitter is sending entries too quickly rather than that
+// the log is overloaded, so HTTP personalities should return a 429 with a Retry-After header.
+// Personalities should check for this error using `errors.Is(e, ErrQuotaExceeded)`.
+var ErrQuotaExceeded = errors.New("write quota exceeded")
+
+// ErrIndexNotAssigned is returned via an IndexFuture when the storage implementation completed
+// sequencing of an entry without assigning it an index.
+//
+// This indicates a bug in the storage implementation, e.g. failing to call Entry.MarshalBundleData.
+var ErrIndexNotAssigned = errors.New("storage did not assign an index to entry")
+
 // Driver is the implementation-specific parts of Tessera. No methods are on here as this is not for public use.
 type Driver any
@@ -0,0 +1,73 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPushbackError(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		err        error
+		wantRetry  time.Duration
+		wantHeader string
+	}{
+		{
+			name:       "plain pushback",
+			err:        fmt.Errorf("antispam %w", ErrPushback),
+			wantRetry:  DefaultRetryAfter,
+			wantHeader: "1",
+		}, {
+			name:       "suggestion",
+			err:        fmt.Errorf("sequence: %w", &PushbackError{Reason: "busy", RetryAfter: 2500 * time.Millisecond}),
+			wantRetry:  2500 * time.Millisecond,
+			wantHeader: "3",
+		}, {
+			name:       "no suggestion",
+			err:        &PushbackError{Reason: "busy"},
+			wantRetry:  DefaultRetryAfter,
+			wantHeader: "1",
+		}, {
+			name:       "quota",
+			err:        fmt.Errorf("%w: writes", ErrQuotaExceeded),
+			wantRetry:  DefaultRetryAfter,
+			wantHeader: "1",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RetryAfter(test.err); got != test.wantRetry {
+				t.Errorf("RetryAfter: got %v, want %v", got, test.wantRetry)
+			}
+			h := http.Header{}
+			SetRetryAfter(h, test.err)
+			if got := h.Get("Retry-After"); got != test.wantHeader {
+				t.Errorf("Retry-After: got %q, want %q", got, test.wantHeader)
+			}
+		})
+	}
+
+	var pe *PushbackError
+	if err := error(&PushbackError{Reason: "busy"}); !errors.Is(err, ErrPushback) || errors.As(ErrPushback, &pe) {
+		t.Errorf("PushbackError should match ErrPushback, but not vice versa")
+	}
+	if got := HTTPStatusCode(&PushbackError{Reason: "busy"}); got != http.StatusServiceUnavailable {
+		t.Errorf("HTTPStatusCode: got %d, want %d", got, http.StatusServiceUnavailable)
+	}
+}
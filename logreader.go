@@ -0,0 +1,58 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/transparency-dev/tessera/client"
+)
+
+// LogReader satisfies client.Fetcher, so it can be passed anywhere the client package expects to
+// fetch resources from a log, e.g. client.NewProofBuilder(ctx, size, r.ReadTile).
+var _ client.Fetcher = LogReader(nil)
+
+// NewLogReader returns a LogReader which reads directly from the storage managed by the provided
+// driver, without starting an Appender.
+//
+// This allows a personality, or a tool running alongside it, to build proofs, run a SelfMonitor,
+// or serve the handlers in this package against a log's own storage without going through HTTP.
+// The driver is used in read-only mode, as with AppendOptions.WithReadOnly, so it neither creates any
+// tables or other storage structures, nor initialises or writes to the log, and the returned reader is
+// safe to use concurrently with an Appender in another process.
+//
+// opts may be nil. Otherwise, only options which affect how the log is read, such as WithCTLayout and
+// WithOperationTimeout, are used; a checkpoint signer is not required.
+func NewLogReader(ctx context.Context, d Driver, opts *AppendOptions) (LogReader, error) {
+	type appendLifecycle interface {
+		Appender(context.Context, *AppendOptions) (*Appender, LogReader, error)
+	}
+	lc, ok := d.(appendLifecycle)
+	if !ok {
+		return nil, fmt.Errorf("driver %T does not implement Appender lifecycle", d)
+	}
+	if opts == nil {
+		opts = NewAppendOptions()
+	}
+	// Take a copy so that the caller's options aren't forced into read-only mode too.
+	ro := *opts
+	ro.readOnly = true
+	_, r, err := lc.Appender(ctx, &ro)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init log reader: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/storage/posix"
+)
+
+func TestNewLogReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	dir := t.TempDir()
+
+	driver, err := posix.New(ctx, dir)
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	r, err := tessera.NewLogReader(ctx, driver, nil)
+	if err != nil {
+		t.Fatalf("NewLogReader: %v", err)
+	}
+	if _, err := r.ReadCheckpoint(ctx); !errors.Is(err, tessera.ErrNotFound) {
+		t.Errorf("ReadCheckpoint on uninitialised log: got err %v, want %v", err, tessera.ErrNotFound)
+	}
+	if ents, err := os.ReadDir(dir); err != nil || len(ents) != 0 {
+		t.Errorf("NewLogReader wrote to log directory: %v, %v", ents, err)
+	}
+
+	opts := tessera.NewAppendOptions().WithCheckpointSigner(createSigner()).WithCheckpointInterval(time.Second).WithBatching(16, 10*time.Millisecond)
+	a, _, _, err := tessera.NewAppender(ctx, driver, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	const numEntries = 20
+	var last tessera.IndexFuture
+	for i := range numEntries {
+		last = a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))
+	}
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	if _, _, err := awaiter.Await(ctx, last); err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+
+	// The reader shares nothing with the appender, so exercise it via the client package just as a
+	// remote client of the log would.
+	var f client.Fetcher = r
+	cpRaw, err := f.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	cp := log.Checkpoint{}
+	if _, err := cp.Unmarshal(cpRaw); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if cp.Size != numEntries {
+		t.Fatalf("Got checkpoint size %d, want %d", cp.Size, numEntries)
+	}
+	pb, err := client.NewProofBuilder(ctx, cp.Size, f.ReadTile)
+	if err != nil {
+		t.Fatalf("NewProofBuilder: %v", err)
+	}
+	for i := range uint64(numEntries) {
+		p, err := pb.InclusionProof(ctx, i)
+		if err != nil {
+			t.Fatalf("InclusionProof(%d): %v", i, err)
+		}
+		leaf := rfc6962.DefaultHasher.HashLeaf(fmt.Appendf(nil, "entry %d", i))
+		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, i, cp.Size, leaf, p, cp.Hash); err != nil {
+			t.Errorf("VerifyInclusion(%d): %v", i, err)
+		}
+	}
+	bundle, err := client.GetEntryBundle(ctx, f.ReadEntryBundle, 0, cp.Size)
+	if err != nil {
+		t.Fatalf("GetEntryBundle: %v", err)
+	}
+	if got := len(bundle.Entries); got != numEntries {
+		t.Errorf("Got %d entries in bundle, want %d", got, numEntries)
+	}
+}
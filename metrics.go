@@ -0,0 +1,129 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+// MetricsProvider creates the instruments with which Tessera and its storage drivers report on their
+// operation, e.g. add latency, batch sizes, integration delay, tile writes, and checkpoint publishing time.
+//
+// Implementations are provided for OpenTelemetry (NewOTelMetrics) and Prometheus (see the
+// metrics/prometheus package), and one is configured with AppendOptions.WithMetricsProvider.
+//
+// Instrument names use the Prometheus conventions: lower case words separated by underscores, with
+// a unit suffix such as _seconds or _total. Each instrument is created once, when the Appender is
+// constructed, so implementations needn't be optimised for creating them.
+type MetricsProvider interface {
+	// Counter returns a monotonically increasing counter.
+	Counter(name, help string) MetricCounter
+	// Histogram returns a histogram which places recorded values into the provided bucket upper bounds.
+	Histogram(name, help string, buckets []float64) MetricHistogram
+}
+
+// MetricCounter is a counter created by a MetricsProvider.
+type MetricCounter interface {
+	// Add increases the counter for the given labels by n.
+	Add(ctx context.Context, n int64, labels ...MetricLabel)
+}
+
+// MetricHistogram is a histogram created by a MetricsProvider.
+type MetricHistogram interface {
+	// Record adds an observation of v to the histogram for the given labels.
+	Record(ctx context.Context, v float64, labels ...MetricLabel)
+}
+
+// MetricLabel is a key/value pair which distinguishes the different series of an instrument.
+//
+// Values should come from a small, fixed set, e.g. the name of the storage driver.
+type MetricLabel struct {
+	Key   string
+	Value string
+}
+
+// LatencyBuckets are histogram bucket bounds, in seconds, suitable for the latency of operations
+// which usually take between a few milliseconds and a few seconds.
+var LatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 1.5, 2, 3, 5, 10, 30}
+
+// noopMetrics is the MetricsProvider used if none is configured.
+type noopMetrics struct{}
+
+func (noopMetrics) Counter(string, string) MetricCounter                { return noopInstrument{} }
+func (noopMetrics) Histogram(string, string, []float64) MetricHistogram { return noopInstrument{} }
+
+type noopInstrument struct{}
+
+func (noopInstrument) Add(context.Context, int64, ...MetricLabel)      {}
+func (noopInstrument) Record(context.Context, float64, ...MetricLabel) {}
+
+// NewOTelMetrics returns a MetricsProvider which creates its instruments with the provided
+// OpenTelemetry meter.
+//
+// Instruments which can't be created are logged and replaced with ones which discard their values,
+// in the same way as Tessera's own OpenTelemetry instruments.
+func NewOTelMetrics(m metric.Meter) MetricsProvider {
+	return otelMetrics{m: m}
+}
+
+type otelMetrics struct {
+	m metric.Meter
+}
+
+func (o otelMetrics) Counter(name, help string) MetricCounter {
+	c, err := o.m.Int64Counter(name, metric.WithDescription(help))
+	if err != nil {
+		klog.Warningf("Failed to create counter %q: %v", name, err)
+		return noopInstrument{}
+	}
+	return otelCounter{c: c}
+}
+
+func (o otelMetrics) Histogram(name, help string, buckets []float64) MetricHistogram {
+	h, err := o.m.Float64Histogram(name, metric.WithDescription(help), metric.WithExplicitBucketBoundaries(buckets...))
+	if err != nil {
+		klog.Warningf("Failed to create histogram %q: %v", name, err)
+		return noopInstrument{}
+	}
+	return otelHistogram{h: h}
+}
+
+type otelCounter struct {
+	c metric.Int64Counter
+}
+
+func (o otelCounter) Add(ctx context.Context, n int64, labels ...MetricLabel) {
+	o.c.Add(ctx, n, metric.WithAttributes(otelAttrs(labels)...))
+}
+
+type otelHistogram struct {
+	h metric.Float64Histogram
+}
+
+func (o otelHistogram) Record(ctx context.Context, v float64, labels ...MetricLabel) {
+	o.h.Record(ctx, v, metric.WithAttributes(otelAttrs(labels)...))
+}
+
+func otelAttrs(labels []MetricLabel) []attribute.KeyValue {
+	r := make([]attribute.KeyValue, len(labels))
+	for i, l := range labels {
+		r[i] = attribute.String(l.Key, l.Value)
+	}
+	return r
+}
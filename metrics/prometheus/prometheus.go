@@ -0,0 +1,218 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prometheus provides a tessera.MetricsProvider whose instruments are collected by a
+// registry from the Prometheus client library, and so can be exported with its HTTP handler
+// alongside a personality's own metrics.
+//
+//	import (
+//		"github.com/prometheus/client_golang/prometheus"
+//		"github.com/prometheus/client_golang/prometheus/promhttp"
+//		tessera_prometheus "github.com/transparency-dev/tessera/metrics/prometheus"
+//	)
+//
+//	reg := prometheus.NewRegistry()
+//	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+//	opts := tessera.NewAppendOptions().WithMetricsProvider(tessera_prometheus.New(reg))
+package prometheus
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/transparency-dev/tessera"
+	"k8s.io/klog/v2"
+)
+
+// Provider is a tessera.MetricsProvider which registers each instrument it creates as a collector
+// with a Prometheus registry.
+type Provider struct {
+	reg prometheus.Registerer
+
+	mu          sync.Mutex
+	instruments map[string]prometheus.Collector
+}
+
+var _ tessera.MetricsProvider = &Provider{}
+
+// New returns a Provider which registers its instruments with reg.
+func New(reg prometheus.Registerer) *Provider {
+	return &Provider{reg: reg, instruments: make(map[string]prometheus.Collector)}
+}
+
+// Counter implements tessera.MetricsProvider.
+//
+// Asking for a counter with the name of an existing counter returns that counter, so that several
+// appenders may share a Provider.
+func (p *Provider) Counter(name, help string) tessera.MetricCounter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.instruments[name].(*counter); ok {
+		return c
+	}
+	c := &counter{series: newSeries[float64](name, help)}
+	p.register(name, c)
+	return c
+}
+
+// Histogram implements tessera.MetricsProvider.
+//
+// As with Counter, asking for an existing histogram returns it, in which case buckets is ignored.
+func (p *Provider) Histogram(name, help string, buckets []float64) tessera.MetricHistogram {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if h, ok := p.instruments[name].(*histogram); ok {
+		return h
+	}
+	b := slices.Clone(buckets)
+	slices.Sort(b)
+	h := &histogram{series: newSeries[*histogramValues](name, help), buckets: b}
+	p.register(name, h)
+	return h
+}
+
+// register registers the instrument with the registry. Instruments which can't be registered, e.g.
+// because their name is already used by a different type of instrument, are logged and still
+// returned, but their values aren't exported.
+func (p *Provider) register(name string, c prometheus.Collector) {
+	if _, ok := p.instruments[name]; ok {
+		klog.Warningf("prometheus: %q is already registered as a different type of metric", name)
+		return
+	}
+	if err := p.reg.Register(c); err != nil {
+		klog.Warningf("prometheus: failed to register %q: %v", name, err)
+		return
+	}
+	p.instruments[name] = c
+}
+
+// series holds the values of an instrument for each distinct set of labels.
+//
+// Callers may use any labels with an instrument, so the label names aren't known up-front, and the
+// series are exported as constant metrics each time they're collected, rather than with the client
+// library's vectors, which require a fixed set of label names.
+type series[V any] struct {
+	name, help string
+
+	mu     sync.Mutex
+	values map[string]*labelled[V]
+}
+
+type labelled[V any] struct {
+	desc   *prometheus.Desc
+	values []string
+	v      V
+}
+
+func newSeries[V any](name, help string) *series[V] {
+	return &series[V]{name: name, help: help, values: make(map[string]*labelled[V])}
+}
+
+// get returns the series for the labels, creating it with the zero value if needed. The caller must
+// hold s.mu.
+func (s *series[V]) get(labels []tessera.MetricLabel) *labelled[V] {
+	l := slices.Clone(labels)
+	slices.SortFunc(l, func(a, b tessera.MetricLabel) int { return strings.Compare(a.Key, b.Key) })
+	keys, values := make([]string, len(l)), make([]string, len(l))
+	for i, kv := range l {
+		keys[i], values[i] = kv.Key, kv.Value
+	}
+	// Label names can't contain a NUL, so it unambiguously separates them from their values.
+	k := strings.Join(keys, "\x00") + "\x00\x00" + strings.Join(values, "\x00")
+	v, ok := s.values[k]
+	if !ok {
+		v = &labelled[V]{desc: prometheus.NewDesc(s.name, s.help, keys, nil), values: values}
+		s.values[k] = v
+	}
+	return v
+}
+
+// Describe implements prometheus.Collector. It sends no descriptors, which makes the instrument an
+// unchecked collector, as its label names aren't known up-front.
+func (s *series[V]) Describe(chan<- *prometheus.Desc) {}
+
+// counter implements tessera.MetricCounter.
+type counter struct {
+	*series[float64]
+}
+
+func (c *counter) Add(_ context.Context, n int64, labels ...tessera.MetricLabel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labels).v += float64(n)
+}
+
+// Collect implements prometheus.Collector.
+func (c *counter) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, l := range c.values {
+		m, err := prometheus.NewConstMetric(l.desc, prometheus.CounterValue, l.v, l.values...)
+		ch <- orInvalid(l.desc, m, err)
+	}
+}
+
+// histogram implements tessera.MetricHistogram.
+type histogram struct {
+	*series[*histogramValues]
+	buckets []float64
+}
+
+type histogramValues struct {
+	// counts holds the number of observations falling into each bucket, and not those below it.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) Record(_ context.Context, v float64, labels ...tessera.MetricLabel) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l := h.get(labels)
+	if l.v == nil {
+		l.v = &histogramValues{counts: make([]uint64, len(h.buckets))}
+	}
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		l.v.counts[i]++
+	}
+	l.v.count++
+	l.v.sum += v
+}
+
+// Collect implements prometheus.Collector.
+func (h *histogram) Collect(ch chan<- prometheus.Metric) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, l := range h.values {
+		cum, buckets := uint64(0), make(map[float64]uint64, len(h.buckets))
+		for i, b := range h.buckets {
+			cum += l.v.counts[i]
+			buckets[b] = cum
+		}
+		m, err := prometheus.NewConstHistogram(l.desc, l.v.count, l.v.sum, buckets, l.values...)
+		ch <- orInvalid(l.desc, m, err)
+	}
+}
+
+// orInvalid returns m, or if err is set, e.g. because a label name is invalid, a metric which reports
+// err to the registry when it's gathered.
+func orInvalid(desc *prometheus.Desc, m prometheus.Metric, err error) prometheus.Metric {
+	if err != nil {
+		return prometheus.NewInvalidMetric(desc, err)
+	}
+	return m
+}
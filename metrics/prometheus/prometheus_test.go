@@ -0,0 +1,77 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/transparency-dev/tessera"
+)
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	p := New(reg)
+	driver := tessera.MetricLabel{Key: "driver", Value: "posix"}
+
+	c := p.Counter("tile_writes_total", "Tiles\nwritten")
+	c.Add(ctx, 1, driver, tessera.MetricLabel{Key: "level", Value: "0"})
+	c.Add(ctx, 2, tessera.MetricLabel{Key: "level", Value: "0"}, driver)
+	c.Add(ctx, 1, driver, tessera.MetricLabel{Key: "level", Value: "1"})
+	// Asking for the same counter again should return the existing one.
+	p.Counter("tile_writes_total", "Tiles written").Add(ctx, 1)
+
+	h := p.Histogram("add_duration_seconds", "Add latency", []float64{1, 0.1})
+	h.Record(ctx, 0.05, driver)
+	h.Record(ctx, 0.5, driver)
+	h.Record(ctx, 5, driver)
+
+	// A histogram can't reuse the counter's name, but its values can still be recorded.
+	p.Histogram("tile_writes_total", "Tiles written", nil).Record(ctx, 1)
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	want := `# HELP add_duration_seconds Add latency
+# TYPE add_duration_seconds histogram
+add_duration_seconds_bucket{driver="posix",le="0.1"} 1
+add_duration_seconds_bucket{driver="posix",le="1"} 2
+add_duration_seconds_bucket{driver="posix",le="+Inf"} 3
+add_duration_seconds_sum{driver="posix"} 5.55
+add_duration_seconds_count{driver="posix"} 3
+# HELP tile_writes_total Tiles\nwritten
+# TYPE tile_writes_total counter
+tile_writes_total 1
+tile_writes_total{driver="posix",level="0"} 3
+tile_writes_total{driver="posix",level="1"} 1
+`
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Errorf("Unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestProviderInvalidLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	level := tessera.MetricLabel{Key: "level", Value: "0"}
+	New(reg).Counter("writes_total", "Writes").Add(context.Background(), 1, level, level)
+	if _, err := reg.Gather(); err == nil || !strings.Contains(err.Error(), "duplicate label") {
+		t.Errorf("Gather: got err %v, want error about invalid label", err)
+	}
+}
@@ -15,11 +15,14 @@
 package tessera
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 
 	"github.com/avast/retry-go/v4"
+	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/client"
 	"golang.org/x/sync/errgroup"
@@ -28,18 +31,56 @@ import (
 
 type setEntryBundleFunc func(ctx context.Context, index uint64, partial uint8, bundle []byte) error
 
-func newCopier(numWorkers uint, setEntryBundle setEntryBundleFunc, getEntryBundle client.EntryBundleFetcherFunc) *copier {
+// verifyBundleFunc checks a fetched entry bundle before it is stored in the target log.
+type verifyBundleFunc func(ctx context.Context, index uint64, partial uint8, bundle []byte) error
+
+// errBundleMismatch is returned when an entry bundle does not agree with the source log's hash tiles.
+var errBundleMismatch = errors.New("entry bundle does not match source hash tile")
+
+func newCopier(numWorkers uint, setEntryBundle setEntryBundleFunc, getEntryBundle client.EntryBundleFetcherFunc, verifyBundle verifyBundleFunc) *copier {
 	return &copier{
 		setEntryBundle: setEntryBundle,
 		getEntryBundle: getEntryBundle,
+		verifyBundle:   verifyBundle,
 		todo:           make(chan bundle, numWorkers),
 	}
 }
 
+// newTileVerifier returns a verifyBundleFunc which checks that the Merkle leaf hashes of an entry bundle
+// are identical to the corresponding level-0 hash tile fetched from the source log.
+func newTileVerifier(getTile client.TileFetcherFunc, leafHasher func([]byte) ([][]byte, error)) verifyBundleFunc {
+	return func(ctx context.Context, index uint64, partial uint8, bundle []byte) error {
+		raw, err := getTile(ctx, 0, index, partial)
+		if err != nil {
+			return fmt.Errorf("failed to fetch source tile 0/%d (p=%d): %v", index, partial, err)
+		}
+		tile := api.HashTile{}
+		if err := tile.UnmarshalText(raw); err != nil {
+			return fmt.Errorf("failed to parse source tile 0/%d (p=%d): %v", index, partial, err)
+		}
+		hashes, err := leafHasher(bundle)
+		if err != nil {
+			return fmt.Errorf("failed to hash entrybundle %d (p=%d): %v", index, partial, err)
+		}
+		hashes, nodes := partialWidth(hashes, partial), partialWidth(tile.Nodes, partial)
+		if len(hashes) != len(nodes) {
+			return fmt.Errorf("%w: entrybundle %d (p=%d) has %d entries, tile has %d hashes", errBundleMismatch, index, partial, len(hashes), len(nodes))
+		}
+		for i, h := range hashes {
+			if !bytes.Equal(h, nodes[i]) {
+				return fmt.Errorf("%w: entry %d (entrybundle %d, p=%d) has leaf hash %x, tile has %x", errBundleMismatch, index*layout.EntryBundleWidth+uint64(i), index, partial, h, nodes[i])
+			}
+		}
+		return nil
+	}
+}
+
 // copier controls the migration work.
 type copier struct {
 	setEntryBundle setEntryBundleFunc
 	getEntryBundle client.EntryBundleFetcherFunc
+	// verifyBundle, if set, is used to check each entry bundle before it's stored.
+	verifyBundle verifyBundleFunc
 
 	// todo contains work items to be completed.
 	todo chan bundle
@@ -112,6 +153,16 @@ func (m *copier) worker(ctx context.Context) error {
 				klog.Infof("%v", wErr)
 				return wErr
 			}
+			if m.verifyBundle != nil {
+				if err := m.verifyBundle(ctx, b.Index, b.Partial, d); err != nil {
+					klog.Infof("%v", err)
+					if errors.Is(err, errBundleMismatch) {
+						// Fetching the same data again won't help, so fail the migration now.
+						return retry.Unrecoverable(err)
+					}
+					return err
+				}
+			}
 			if err := m.setEntryBundle(ctx, b.Index, b.Partial, d); err != nil {
 				wErr := fmt.Errorf("failed to store entrybundle %d (p=%d): %v", b.Index, b.Partial, err)
 				klog.Infof("%v", wErr)
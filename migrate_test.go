@@ -0,0 +1,131 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+func TestCopierSourceTileCheck(t *testing.T) {
+	const size = 300
+
+	// Build the entry bundles and level-0 tiles of a source log.
+	bundles := map[string][]byte{}
+	tiles := map[string][]byte{}
+	for ri := range layout.Range(0, size, size) {
+		var b, h []byte
+		for i := range uint64(ri.N) {
+			idx := ri.Index*layout.EntryBundleWidth + i
+			e := NewEntry(fmt.Appendf(nil, "entry %d", idx))
+			b = append(b, e.MarshalBundleData(idx)...)
+			h = append(h, e.LeafHash()...)
+		}
+		k := layout.EntriesPath(ri.Index, ri.Partial)
+		bundles[k] = b
+		tiles[k] = h
+	}
+
+	for _, test := range []struct {
+		name      string
+		corrupt   func(bundles, tiles map[string][]byte)
+		wantErr   string
+		wantCalls int
+	}{
+		{
+			name: "ok",
+		}, {
+			name: "corrupt entry",
+			corrupt: func(bundles, tiles map[string][]byte) {
+				k := layout.EntriesPath(1, 44)
+				b := append([]byte{}, bundles[k]...)
+				b[len(b)-1] ^= 1
+				bundles[k] = b
+			},
+			wantErr:   "entry 299 ",
+			wantCalls: 1,
+		}, {
+			name: "missing entry",
+			corrupt: func(bundles, tiles map[string][]byte) {
+				k := layout.EntriesPath(0, 0)
+				tiles[k] = append(tiles[k], tiles[k][:32]...)
+			},
+			wantErr:   "has 256 entries, tile has 257 hashes",
+			wantCalls: 1,
+		}, {
+			// Some sources, e.g. MySQL, serve the latest version of a partial tile, which may have grown.
+			name: "grown source tile",
+			corrupt: func(bundles, tiles map[string][]byte) {
+				k := layout.EntriesPath(1, 44)
+				tiles[k] = append(tiles[k], make([]byte, 32*(layout.TileWidth-44))...)
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bundles, tiles := maps.Clone(bundles), maps.Clone(tiles)
+			if test.corrupt != nil {
+				test.corrupt(bundles, tiles)
+			}
+			var mu sync.Mutex
+			stored := map[string][]byte{}
+			calls := map[string]int{}
+			getBundle := func(_ context.Context, i uint64, p uint8) ([]byte, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls[layout.EntriesPath(i, p)]++
+				return bundles[layout.EntriesPath(i, p)], nil
+			}
+			getTile := func(_ context.Context, l, i uint64, p uint8) ([]byte, error) {
+				if l != 0 {
+					return nil, fmt.Errorf("unexpected tile level %d", l)
+				}
+				return tiles[layout.EntriesPath(i, p)], nil
+			}
+			setBundle := func(_ context.Context, i uint64, p uint8, b []byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+				stored[layout.EntriesPath(i, p)] = b
+				return nil
+			}
+
+			c := newCopier(2, setBundle, getBundle, newTileVerifier(getTile, defaultMerkleLeafHasher))
+			err := c.Copy(t.Context(), 0, size)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Copy: %v", err)
+				}
+				if got, want := len(stored), len(bundles); got != want {
+					t.Errorf("got %d bundles stored, want %d", got, want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Copy: got error %v, want one containing %q", err, test.wantErr)
+			}
+			// A mismatch must not be retried, and the bad bundle must not be stored.
+			for k, n := range calls {
+				if _, ok := stored[k]; !ok && n != test.wantCalls {
+					t.Errorf("bundle %q fetched %d times, want %d", k, n, test.wantCalls)
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package tessera
 
 import (
+	iotel "github.com/transparency-dev/tessera/internal/otel"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 )
@@ -22,10 +23,11 @@ import (
 const name = "github.com/transparency-dev/tessera"
 
 var (
-	tracer = otel.Tracer(name)
+	tracer = iotel.Tracer(name)
 	meter  = otel.Meter(name)
 )
 
 var (
-	followerNameKey = attribute.Key("tessera.follower.name")
+	followerNameKey    = attribute.Key("tessera.follower.name")
+	distributorNameKey = attribute.Key("tessera.distributor.name")
 )
@@ -0,0 +1,186 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+// DefaultProofCacheRecent is the number of recent entries whose inclusion proofs are precomputed by a
+// ProofCache if ProofCacheOptions.Recent is not set.
+const DefaultProofCacheRecent = 1024
+
+var (
+	proofCacheRequests metric.Int64Counter
+	proofCacheSize     metric.Int64Gauge
+)
+
+func init() {
+	var err error
+
+	proofCacheRequests, err = meter.Int64Counter(
+		"tessera.proofcache.requests",
+		metric.WithDescription("Number of inclusion proofs requested from the proof cache"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		klog.Exitf("Failed to create proofCacheRequests metric: %v", err)
+	}
+
+	proofCacheSize, err = meter.Int64Gauge(
+		"tessera.proofcache.size",
+		metric.WithDescription("Tree size for which the proof cache currently holds inclusion proofs"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create proofCacheSize metric: %v", err)
+	}
+}
+
+var proofCacheHitKey = attribute.Key("tessera.proofcache.hit")
+
+// ProofCacheOptions holds optional configuration for a ProofCache.
+type ProofCacheOptions struct {
+	// Recent is the number of most recently integrated entries whose inclusion proofs are precomputed
+	// each time a checkpoint is published. Defaults to DefaultProofCacheRecent.
+	Recent uint64
+}
+
+// ProofCache precomputes inclusion proofs for the most recent entries in the log each time a new checkpoint
+// is published, so that requests for them are served from memory rather than built from tiles each time.
+//
+// Monitors and submitters typically ask for proofs of recently added entries in the latest tree, e.g. via
+// the get-proof-by-hash endpoint of the RFC 6962 facade, so these are the proofs which are precomputed.
+// Requests for any other proof are built from tiles as usual.
+//
+// Pass its InclusionProof method to RFC6962HandlerOptions or AddHandlerOptions to use it.
+type ProofCache struct {
+	lr   LogReader
+	opts ProofCacheOptions
+
+	mu sync.RWMutex
+	// size is the tree size for which proofs are cached.
+	size uint64
+	// first is the index of the entry whose proof is held in proofs[0].
+	first  uint64
+	proofs [][][]byte
+}
+
+// NewProofCache creates a ProofCache which builds proofs from the tiles read from lr.
+//
+// Call Run, or Update, to precompute proofs.
+func NewProofCache(lr LogReader, opts ProofCacheOptions) *ProofCache {
+	if opts.Recent == 0 {
+		opts.Recent = DefaultProofCacheRecent
+	}
+	return &ProofCache{
+		lr:   lr,
+		opts: opts,
+	}
+}
+
+// Run precomputes proofs for each checkpoint received from published, e.g. the channel returned by
+// Appender.SubscribeCheckpoints, until it's closed or ctx is done.
+func (c *ProofCache) Run(ctx context.Context, published <-chan PublishedCheckpoint) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cp, ok := <-published:
+			if !ok {
+				return
+			}
+			if err := c.Update(ctx, cp.Size); err != nil {
+				klog.Warningf("ProofCache: %v", err)
+			}
+		}
+	}
+}
+
+// Update precomputes the inclusion proofs for the most recent entries in the tree of the given size, and
+// replaces those which were previously cached.
+//
+// This is intended for processes which don't host the log's Appender, and so must find out about new
+// checkpoints by other means, e.g. polling ReadTreeState.
+func (c *ProofCache) Update(ctx context.Context, size uint64) error {
+	ctx, span := tracer.Start(ctx, "tessera.ProofCache.Update")
+	defer span.End()
+
+	c.mu.RLock()
+	cached := c.size
+	c.mu.RUnlock()
+	if size <= cached {
+		return nil
+	}
+
+	first := size - min(size, c.opts.Recent)
+	// A single builder caches the tiles it reads, so all of the proofs are built from one read of each tile.
+	pb, err := client.NewProofBuilder(ctx, size, c.lr.ReadTile)
+	if err != nil {
+		return fmt.Errorf("NewProofBuilder(%d): %v", size, err)
+	}
+	proofs := make([][][]byte, 0, size-first)
+	for i := first; i < size; i++ {
+		p, err := pb.InclusionProof(ctx, i)
+		if err != nil {
+			return fmt.Errorf("InclusionProof(%d, %d): %v", i, size, err)
+		}
+		proofs = append(proofs, p)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Another call may have cached a larger tree while this one was building proofs.
+	if size > c.size {
+		c.size, c.first, c.proofs = size, first, proofs
+		proofCacheSize.Record(ctx, otel.Clamp64(size))
+	}
+	return nil
+}
+
+// InclusionProof returns the inclusion proof for the entry at index in the tree of the given size.
+//
+// The proof is returned from the cache if it was precomputed, and built from tiles otherwise. Callers must
+// not modify the returned proof.
+func (c *ProofCache) InclusionProof(ctx context.Context, index, size uint64) ([][]byte, error) {
+	c.mu.RLock()
+	var p [][]byte
+	if size == c.size && index >= c.first && index < c.size {
+		p = c.proofs[index-c.first]
+	}
+	c.mu.RUnlock()
+	proofCacheRequests.Add(ctx, 1, metric.WithAttributes(proofCacheHitKey.Bool(p != nil)))
+	if p != nil {
+		return p, nil
+	}
+
+	return buildInclusionProof(ctx, c.lr, index, size)
+}
+
+// buildInclusionProof builds the inclusion proof for the entry at index in the tree of the given size from
+// the tiles read from lr.
+func buildInclusionProof(ctx context.Context, lr LogReader, index, size uint64) ([][]byte, error) {
+	pb, err := client.NewProofBuilder(ctx, size, lr.ReadTile)
+	if err != nil {
+		return nil, err
+	}
+	return pb.InclusionProof(ctx, index)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+// tileCountingReader counts the number of tiles read from a log.
+type tileCountingReader struct {
+	tessera.LogReader
+	tiles atomic.Int64
+}
+
+func (r *tileCountingReader) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+	r.tiles.Add(1)
+	return r.LogReader.ReadTile(ctx, l, i, p)
+}
+
+func TestProofCache(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+
+	const numEntries, recent = 300, 10
+	leaves := make([][]byte, 0, numEntries)
+	futures := make([]tessera.IndexFuture, 0, numEntries)
+	for i := range numEntries {
+		e := fmt.Appendf(nil, "entry %d", i)
+		leaves = append(leaves, e)
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(e)))
+	}
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+	ts, err := tessera.ReadTreeState(ctx, tl.LogReader)
+	if err != nil {
+		t.Fatalf("ReadTreeState: %v", err)
+	}
+
+	lr := &tileCountingReader{LogReader: tl.LogReader}
+	c := tessera.NewProofCache(lr, tessera.ProofCacheOptions{Recent: recent})
+	if err := c.Update(ctx, ts.Size); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	verify := func(idx, size uint64, root []byte) {
+		t.Helper()
+		p, err := c.InclusionProof(ctx, idx, size)
+		if err != nil {
+			t.Fatalf("InclusionProof(%d, %d): %v", idx, size, err)
+		}
+		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, idx, size, rfc6962.DefaultHasher.HashLeaf(leaves[idx]), p, root); err != nil {
+			t.Errorf("VerifyInclusion(%d, %d): %v", idx, size, err)
+		}
+	}
+
+	lr.tiles.Store(0)
+	for idx := ts.Size - recent; idx < ts.Size; idx++ {
+		verify(idx, ts.Size, ts.Root)
+	}
+	if got := lr.tiles.Load(); got != 0 {
+		t.Errorf("Read %d tiles for cached proofs, want 0", got)
+	}
+
+	// Proofs for older entries, or other tree sizes, are built from tiles.
+	verify(0, ts.Size, ts.Root)
+	if got := lr.tiles.Load(); got == 0 {
+		t.Error("Read no tiles for uncached proof")
+	}
+
+	// Updating to a smaller tree leaves the cache as it was.
+	if err := c.Update(ctx, ts.Size-1); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	lr.tiles.Store(0)
+	verify(ts.Size-1, ts.Size, ts.Root)
+	if got := lr.tiles.Load(); got != 0 {
+		t.Errorf("Read %d tiles for cached proof after stale Update, want 0", got)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package publish provides a Follower which emits a record for each integrated entry in a log to an
+// event stream, e.g. a Kafka topic or NATS subject, for processing by downstream pipelines.
+//
+// An experimental Sink for NATS JetStream is provided by the
+// github.com/transparency-dev/tessera/cmd/experimental/publish/nats package.
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/internal/stream"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultBatchSize is the default maximum number of records passed to a Sink in one call.
+	DefaultBatchSize = 256
+	// DefaultPollInterval is the default interval at which the log is checked for newly integrated entries.
+	DefaultPollInterval = time.Second
+)
+
+// Record describes a single integrated entry.
+type Record struct {
+	// Index is the position of the entry in the log, and should be used as the record's key or
+	// deduplication ID in the event stream.
+	Index uint64
+	// LeafHash is the Merkle leaf hash of the entry.
+	LeafHash []byte
+	// Data is the entry's payload.
+	Data []byte
+}
+
+// Sink is implemented by adaptors for event streaming systems.
+type Sink interface {
+	// Publish durably emits the records, which are consecutive and in log order, returning only once
+	// they've all been accepted by the event stream.
+	//
+	// If Publish returns an error, or the process exits before the high-water mark is updated, the
+	// same records will be passed to Publish again. Sinks must therefore deduplicate by Index in order
+	// to provide exactly-once-per-index semantics, e.g. by using it as the Nats-Msg-Id header with
+	// NATS JetStream, or as the record key with an idempotent Kafka producer on a compacted topic.
+	Publish(ctx context.Context, records []Record) error
+}
+
+// HighWaterMark persists the index of the next entry to be published.
+type HighWaterMark interface {
+	// Load returns the stored index, or zero if none has been stored yet.
+	Load(ctx context.Context) (uint64, error)
+	// Store durably records the index of the next entry to be published.
+	Store(ctx context.Context, next uint64) error
+}
+
+// Options configures a Publisher.
+type Options struct {
+	// BatchSize is the maximum number of records passed to the Sink in one call.
+	// If zero, DefaultBatchSize is used.
+	BatchSize uint
+	// PollInterval is the interval at which the log is checked for newly integrated entries.
+	// If zero, DefaultPollInterval is used.
+	PollInterval time.Duration
+	// Unbundle splits a serialised entry bundle into its entries. If unset, bundles are parsed as
+	// tlog-tiles entry bundles.
+	Unbundle func(bundle []byte) ([][]byte, error)
+	// LeafHash returns the Merkle leaf hash for an entry. If unset, the RFC 6962 leaf hash of the
+	// entry's data is used.
+	LeafHash func(data []byte) []byte
+}
+
+// Publisher is a tessera.Follower which publishes a Record for each integrated entry to a Sink.
+//
+// It should be passed to tessera.AppendOptions.WithFollower, and will publish entries from the
+// stored high-water mark onwards once they've been integrated.
+type Publisher struct {
+	sink Sink
+	hwm  HighWaterMark
+	opts Options
+
+	processed atomic.Uint64
+}
+
+// New returns a Publisher which publishes records to sink, using hwm to track its progress.
+func New(sink Sink, hwm HighWaterMark, opts Options) *Publisher {
+	if opts.BatchSize == 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Unbundle == nil {
+		opts.Unbundle = func(bundle []byte) ([][]byte, error) {
+			b := api.EntryBundle{}
+			if err := b.UnmarshalText(bundle); err != nil {
+				return nil, err
+			}
+			return b.Entries, nil
+		}
+	}
+	if opts.LeafHash == nil {
+		opts.LeafHash = rfc6962.DefaultHasher.HashLeaf
+	}
+	return &Publisher{sink: sink, hwm: hwm, opts: opts}
+}
+
+// Name implements tessera.Follower.
+func (p *Publisher) Name() string {
+	return "Event stream publisher"
+}
+
+// EntriesProcessed implements tessera.Follower, returning the number of entries which have been published.
+func (p *Publisher) EntriesProcessed(_ context.Context) (uint64, error) {
+	return p.processed.Load(), nil
+}
+
+// Follow implements tessera.Follower, publishing integrated entries until ctx is done.
+func (p *Publisher) Follow(ctx context.Context, lr tessera.LogReader) {
+	for {
+		if err := p.follow(ctx, lr); err != nil && ctx.Err() == nil {
+			klog.Warningf("Publisher: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.opts.PollInterval):
+		}
+	}
+}
+
+// follow publishes entries from the high-water mark onwards, returning only on error.
+func (p *Publisher) follow(ctx context.Context, lr tessera.LogReader) error {
+	next, err := p.hwm.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load high-water mark: %v", err)
+	}
+	p.processed.Store(next)
+	nextBundle, stop := lr.StreamEntries(ctx, next)
+	defer stop()
+	r := stream.NewEntryStreamReader(nextBundle, p.opts.Unbundle)
+
+	t := time.NewTicker(p.opts.PollInterval)
+	defer t.Stop()
+	for {
+		size, err := lr.IntegratedSize(ctx)
+		if err != nil {
+			return fmt.Errorf("IntegratedSize: %v", err)
+		}
+		if size <= next {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-t.C:
+			}
+			continue
+		}
+		// Only ask for entries which have already been integrated, so that reading never blocks.
+		records := make([]Record, 0, min(uint64(p.opts.BatchSize), size-next))
+		for i := next; i < size && len(records) < cap(records); i++ {
+			idx, d, err := r.Next()
+			if err != nil {
+				return fmt.Errorf("failed to read entry %d: %v", i, err)
+			}
+			if idx != i {
+				return fmt.Errorf("read entry %d, want %d", idx, i)
+			}
+			records = append(records, Record{Index: idx, LeafHash: p.opts.LeafHash(d), Data: d})
+		}
+		if err := p.sink.Publish(ctx, records); err != nil {
+			return fmt.Errorf("failed to publish entries [%d, %d): %v", next, next+uint64(len(records)), err)
+		}
+		next += uint64(len(records))
+		if err := p.hwm.Store(ctx, next); err != nil {
+			return fmt.Errorf("failed to store high-water mark %d: %v", next, err)
+		}
+		p.processed.Store(next)
+	}
+}
+
+// FileHighWaterMark is a HighWaterMark which is stored in a local file.
+type FileHighWaterMark struct {
+	Path string
+}
+
+// Load implements HighWaterMark.
+func (f FileHighWaterMark) Load(_ context.Context) (uint64, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// Store implements HighWaterMark, atomically replacing the contents of the file.
+func (f FileHighWaterMark) Store(_ context.Context, next uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Only fails if the rename below succeeded.
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.WriteString(strconv.FormatUint(next, 10)); err != nil {
+		return errors.Join(err, tmp.Close())
+	}
+	if err := tmp.Sync(); err != nil {
+		return errors.Join(err, tmp.Close())
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
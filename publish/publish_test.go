@@ -0,0 +1,134 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// memSink records published records, after failing the configured number of calls to Publish.
+type memSink struct {
+	mu       sync.Mutex
+	failures int
+	records  []Record
+}
+
+func (s *memSink) Publish(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("injected failure")
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *memSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+func TestPublisher(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+
+	hwm := FileHighWaterMark{Path: filepath.Join(t.TempDir(), "hwm")}
+	sink := &memSink{failures: 2}
+	p := New(sink, hwm, Options{BatchSize: 100, PollInterval: 10 * time.Millisecond})
+	a, _, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 10*time.Millisecond).
+		WithFollower(p))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+
+	const numEntries = 300
+	for i := range numEntries {
+		if _, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	for sink.len() < numEntries {
+		select {
+		case <-ctx.Done():
+			t.Fatal("context done before all entries published")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	sink.mu.Lock()
+	for i, r := range sink.records {
+		want := fmt.Appendf(nil, "entry %d", i)
+		if r.Index != uint64(i) || !bytes.Equal(r.Data, want) || !bytes.Equal(r.LeafHash, rfc6962.DefaultHasher.HashLeaf(want)) {
+			t.Errorf("record %d: got %+v, want entry %d", i, r, i)
+		}
+	}
+	if len(sink.records) != numEntries {
+		t.Errorf("got %d records, want exactly %d", len(sink.records), numEntries)
+	}
+	sink.mu.Unlock()
+
+	if got, err := hwm.Load(ctx); err != nil || got != numEntries {
+		t.Errorf("high-water mark: got %d, %v, want %d", got, err, numEntries)
+	}
+	if got, err := p.EntriesProcessed(ctx); err != nil || got != numEntries {
+		t.Errorf("EntriesProcessed: got %d, %v, want %d", got, err, numEntries)
+	}
+}
+
+func TestFileHighWaterMark(t *testing.T) {
+	ctx := t.Context()
+	hwm := FileHighWaterMark{Path: filepath.Join(t.TempDir(), "hwm")}
+	if got, err := hwm.Load(ctx); err != nil || got != 0 {
+		t.Errorf("Load on missing file: got %d, %v, want 0", got, err)
+	}
+	for _, n := range []uint64{1, 1000, 12} {
+		if err := hwm.Store(ctx, n); err != nil {
+			t.Fatalf("Store(%d): %v", n, err)
+		}
+		if got, err := hwm.Load(ctx); err != nil || got != n {
+			t.Errorf("Load: got %d, %v, want %d", got, err, n)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WriteQuota limits the rate at which entries may be added, using a token bucket which holds up to
+// burst tokens and is refilled at perSecond tokens per second. Each entry consumes one token.
+//
+// A WriteQuota may be shared between the appenders of several logs hosted in the same process, e.g. to
+// apply a single quota to all of a tenant's logs, and each appender may be subject to several quotas, e.g.
+// one for the log and one for its tenant. This stops a single busy log or tenant from submitting entries
+// faster than its share. PendingLimit and ConnLimit isolate the queued entries and database connections
+// which the logs share.
+type WriteQuota struct {
+	name      string
+	perSecond float64
+	burst     float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewWriteQuota returns a WriteQuota which allows bursts of up to burst entries, and perSecond entries
+// per second on average. It starts full.
+//
+// The name identifies the quota in errors, e.g. "tenant-a".
+func NewWriteQuota(name string, perSecond float64, burst uint) *WriteQuota {
+	return &WriteQuota{
+		name:      name,
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		now:       time.Now,
+	}
+}
+
+// Name returns the name of the quota.
+func (q *WriteQuota) Name() string {
+	return q.name
+}
+
+// take consumes a token if one is available, returning whether it did.
+func (q *WriteQuota) take() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	if !q.last.IsZero() {
+		q.tokens = min(q.burst, q.tokens+now.Sub(q.last).Seconds()*q.perSecond)
+	}
+	q.last = now
+	if q.tokens < 1 {
+		return false
+	}
+	q.tokens--
+	return true
+}
+
+// refund returns a token taken by a previous call to take.
+func (q *WriteQuota) refund() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tokens = min(q.burst, q.tokens+1)
+}
+
+// WithWriteQuota configures quotas which limit the rate at which entries are accepted by Add.
+//
+// An entry is only accepted if all of the quotas have capacity for it, and otherwise Add fails with an
+// error wrapping ErrQuotaExceeded without doing any further work for the entry, including validation.
+// Quotas may be shared with other appenders; see WriteQuota.
+func (o *AppendOptions) WithWriteQuota(quotas ...*WriteQuota) *AppendOptions {
+	o.writeQuotas = append(o.writeQuotas, quotas...)
+	return o
+}
+
+// writeQuotaDecorator returns a decorator which only passes on entries for which all quotas have capacity.
+func writeQuotaDecorator(quotas []*WriteQuota) func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			for i, q := range quotas {
+				if !q.take() {
+					// Don't charge the quotas which did have capacity for an entry which wasn't accepted.
+					for _, p := range quotas[:i] {
+						p.refund()
+					}
+					return func() (Index, error) { return Index{}, fmt.Errorf("%w: %s", ErrQuotaExceeded, q.name) }
+				}
+			}
+			return delegate(ctx, entry)
+		}
+	}
+}
+
+// PendingLimit bounds the number of entries which may be pending, i.e. added but not yet assigned an index
+// or failed, at any one time.
+//
+// Like a WriteQuota, a PendingLimit may be shared between the appenders of several logs, e.g. to bound
+// the entries queued for all of a tenant's logs, so that a tenant whose logs are falling behind can't
+// fill the process's memory with queued entries at the expense of other tenants.
+type PendingLimit struct {
+	name    string
+	max     int64
+	pending atomic.Int64
+}
+
+// NewPendingLimit returns a PendingLimit which allows up to max entries to be pending.
+//
+// The name identifies the limit in errors, e.g. "tenant-a".
+func NewPendingLimit(name string, max uint) *PendingLimit {
+	return &PendingLimit{name: name, max: int64(max)}
+}
+
+// Name returns the name of the limit.
+func (l *PendingLimit) Name() string {
+	return l.name
+}
+
+// Pending returns the number of entries currently pending.
+func (l *PendingLimit) Pending() uint64 {
+	return uint64(max(l.pending.Load(), 0))
+}
+
+// reserve counts another entry as pending, returning false without doing so if the limit has been reached.
+func (l *PendingLimit) reserve() bool {
+	if l.pending.Add(1) > l.max {
+		l.pending.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (l *PendingLimit) release() {
+	l.pending.Add(-1)
+}
+
+// WithPendingLimit configures limits on the number of entries which may be pending at once.
+//
+// An entry is only accepted if none of the limits have been reached, and otherwise Add fails with a
+// PushbackError. Limits may be shared with other appenders; see PendingLimit.
+func (o *AppendOptions) WithPendingLimit(limits ...*PendingLimit) *AppendOptions {
+	o.pendingLimits = append(o.pendingLimits, limits...)
+	return o
+}
+
+// pendingLimitDecorator returns a decorator which only passes on entries while none of the limits have
+// been reached, counting each entry as pending until its future resolves.
+func pendingLimitDecorator(limits []*PendingLimit) func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			for i, l := range limits {
+				if !l.reserve() {
+					for _, p := range limits[:i] {
+						p.release()
+					}
+					err := &PushbackError{Reason: "too many pending entries for " + l.name}
+					return func() (Index, error) { return Index{}, err }
+				}
+			}
+			f := sync.OnceValues(delegate(ctx, entry))
+			// Callers needn't ever resolve the future, so wait for it here in order to know when the entry
+			// is no longer pending.
+			go func() {
+				_, _ = f()
+				for _, l := range limits {
+					l.release()
+				}
+			}()
+			return f
+		}
+	}
+}
+
+// ConnLimit bounds the number of database connections which may be open at once, across all of the
+// connection pools which use it.
+//
+// Each hosted log's storage usually has its own *sql.DB, whose own limit, set with SetMaxOpenConns,
+// only bounds that log's connections. Sharing a ConnLimit between the pools of all of a tenant's logs
+// ensures that the tenant can't exhaust the database's connections at the expense of other tenants.
+//
+// Connections count towards the limit while they're idle in a pool, so pools sharing a limit should
+// close idle connections promptly, e.g. with SetConnMaxIdleTime, so that they can be used by other pools.
+type ConnLimit struct {
+	name  string
+	slots chan struct{}
+}
+
+// NewConnLimit returns a ConnLimit which allows up to max connections to be open at once.
+//
+// The name identifies the limit in errors, e.g. "tenant-a".
+func NewConnLimit(name string, max uint) *ConnLimit {
+	return &ConnLimit{name: name, slots: make(chan struct{}, max)}
+}
+
+// Name returns the name of the limit.
+func (l *ConnLimit) Name() string {
+	return l.name
+}
+
+// Open returns the number of connections currently open under the limit.
+func (l *ConnLimit) Open() int {
+	return len(l.slots)
+}
+
+// Connector returns a connector which opens connections using c, waiting while the limit has been reached.
+//
+// Pass the result to sql.OpenDB to create a connection pool which is subject to the limit, e.g.:
+//
+//	c, err := mysql.NewConnector(cfg)
+//	...
+//	db := sql.OpenDB(tenantConns.Connector(c))
+func (l *ConnLimit) Connector(c driver.Connector) driver.Connector {
+	return &limitedConnector{Connector: c, l: l}
+}
+
+type limitedConnector struct {
+	driver.Connector
+	l *ConnLimit
+}
+
+// Connect waits for the limit to allow another connection, or for ctx to be done, before connecting.
+func (c *limitedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	select {
+	case c.l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a connection under limit %q: %w", c.l.name, ctx.Err())
+	}
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		<-c.l.slots
+		return nil, err
+	}
+	return &limitedConn{Conn: conn, release: sync.OnceFunc(func() { <-c.l.slots })}, nil
+}
+
+// limitedConn releases its slot under a ConnLimit when it's closed.
+//
+// database/sql uses the optional interfaces implemented by a driver's connections to decide how to run
+// queries, so they're all passed through to the wrapped connection, falling back to the behaviour
+// database/sql would use if the connection doesn't implement them.
+type limitedConn struct {
+	driver.Conn
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	defer c.release()
+	return c.Conn.Close()
+}
+
+func (c *limitedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("driver doesn't support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *limitedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *limitedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *limitedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *limitedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *limitedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *limitedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *limitedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
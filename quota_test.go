@@ -0,0 +1,191 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWriteQuota(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+	tenant := NewWriteQuota("tenant", 10, 3)
+	tenant.now = clock
+	logA := NewWriteQuota("log-a", 1, 2)
+	logA.now = clock
+
+	added := map[string]int{}
+	newAdd := func(name string, quotas ...*WriteQuota) AddFn {
+		return writeQuotaDecorator(quotas)(func(_ context.Context, _ *Entry) IndexFuture {
+			added[name]++
+			return func() (Index, error) { return Index{}, nil }
+		})
+	}
+	// Log A is subject to its own quota and the tenant's, log B only to the tenant's.
+	addA, addB := newAdd("a", logA, tenant), newAdd("b", tenant)
+
+	for _, step := range []struct {
+		add     AddFn
+		advance time.Duration
+		wantErr bool
+	}{
+		{add: addA},
+		{add: addA},
+		// Log A's quota is used up, and the tenant's mustn't be charged for the rejected entry.
+		{add: addA, wantErr: true},
+		{add: addB},
+		// Now the tenant's quota is used up too, so log B is affected by log A's usage.
+		{add: addB, wantErr: true},
+		// After a second, log A has one more token, and the tenant has refilled completely.
+		{add: addA, advance: time.Second},
+		{add: addA, wantErr: true},
+		{add: addB},
+	} {
+		now = now.Add(step.advance)
+		_, err := step.add(t.Context(), NewEntry([]byte("entry")))()
+		if gotErr := errors.Is(err, ErrQuotaExceeded); gotErr != step.wantErr {
+			t.Fatalf("Add at %v: got err %v, want ErrQuotaExceeded %t", now, err, step.wantErr)
+		}
+	}
+	if added["a"] != 3 || added["b"] != 2 {
+		t.Errorf("delegates called %v, want a:3 b:2", added)
+	}
+}
+
+func TestPendingLimit(t *testing.T) {
+	logA, tenant := NewPendingLimit("log-a", 1), NewPendingLimit("tenant", 2)
+
+	// Each entry passed on to a delegate stays pending until its channel is closed.
+	var added []chan struct{}
+	newAdd := func(limits ...*PendingLimit) AddFn {
+		return pendingLimitDecorator(limits)(func(_ context.Context, _ *Entry) IndexFuture {
+			c := make(chan struct{})
+			added = append(added, c)
+			return func() (Index, error) {
+				<-c
+				return Index{}, nil
+			}
+		})
+	}
+	// Log A is subject to its own limit and the tenant's, log B only to the tenant's.
+	addA, addB := newAdd(logA, tenant), newAdd(tenant)
+
+	for i, step := range []struct {
+		add         AddFn
+		resolve     int
+		wantErr     bool
+		wantPending uint64
+	}{
+		{add: addA, wantPending: 1},
+		// Log A's limit has been reached, and the rejected entry mustn't count towards the tenant's.
+		{add: addA, wantErr: true, wantPending: 1},
+		{add: addB, wantPending: 2},
+		// Now the tenant's limit has been reached too, so log B is affected by log A's pending entry.
+		{add: addB, wantErr: true, wantPending: 2},
+		// Once log A's entry is resolved, it can add another, which takes the tenant's last slot again.
+		{add: addA, resolve: 1, wantPending: 2},
+		{add: addB, wantErr: true, wantPending: 2},
+	} {
+		for _, c := range added[:step.resolve] {
+			close(c)
+		}
+		added = added[step.resolve:]
+		waitForPending(t, tenant, uint64(len(added)))
+
+		f := step.add(t.Context(), NewEntry([]byte("entry")))
+		var err error
+		if step.wantErr {
+			// Only rejected entries' futures can be resolved without blocking.
+			_, err = f()
+		}
+		if gotErr := errors.Is(err, ErrPushback); gotErr != step.wantErr {
+			t.Fatalf("Step %d: got err %v, want ErrPushback %t", i, err, step.wantErr)
+		}
+		if got := tenant.Pending(); got != step.wantPending {
+			t.Fatalf("Step %d: tenant has %d pending entries, want %d", i, got, step.wantPending)
+		}
+	}
+	for _, c := range added {
+		close(c)
+	}
+	waitForPending(t, tenant, 0)
+	waitForPending(t, logA, 0)
+}
+
+// waitForPending waits for the number of entries pending under l to reach want, which happens
+// asynchronously once their futures resolve.
+func waitForPending(t *testing.T, l *PendingLimit, want uint64) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); l.Pending() != want; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d pending entries, want %d", l.Name(), l.Pending(), want)
+		}
+	}
+}
+
+func TestConnLimit(t *testing.T) {
+	l := NewConnLimit("tenant", 2)
+	db := sql.OpenDB(l.Connector(fakeConnector{}))
+	defer func() { _ = db.Close() }()
+	// Stop the pool keeping idle connections, so that closing one frees its slot.
+	db.SetMaxIdleConns(0)
+
+	c1, err := db.Conn(t.Context())
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	if _, err := db.Conn(t.Context()); err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	if got, want := l.Open(), 2; got != want {
+		t.Fatalf("Got %d open connections, want %d", got, want)
+	}
+
+	// A second pool sharing the limit must wait for a connection to be closed.
+	db2 := sql.OpenDB(l.Connector(fakeConnector{}))
+	defer func() { _ = db2.Close() }()
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := db2.Conn(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Conn over limit: got err %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := c1.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	c3, err := db2.Conn(t.Context())
+	if err != nil {
+		t.Fatalf("Conn after Close: %v", err)
+	}
+	if err := c3.PingContext(t.Context()); err != nil {
+		t.Errorf("PingContext: %v", err)
+	}
+}
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("unimplemented") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("unimplemented") }
@@ -0,0 +1,353 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"k8s.io/klog/v2"
+)
+
+const (
+	readCheckpointContType = "text/plain; charset=utf-8"
+	readLogContType        = "application/octet-stream"
+	readLogCacheControl    = "public, max-age=31536000, immutable"
+)
+
+// NewReadHandler returns an http.Handler which serves the read API from https://c2sp.org/tlog-tiles
+// using the provided LogReader.
+//
+// The handler expects to be mounted at the root of the log's URL space; use http.StripPrefix if the
+// log should be served under a prefix.
+//
+// Tiles and entry bundles are streamed to the client if the LogReader implements StreamingLogReader,
+// so serving large resources doesn't require them to be buffered in memory.
+// Requests for entry bundles may include a single byte range in a Range header, which will be read
+// directly from storage if the LogReader implements RangeLogReader.
+//
+// Responses carry strong ETags, and conditional requests are answered with 304 Not Modified where
+// appropriate. The checkpoint response also carries a Last-Modified header if the LogReader implements
+// CheckpointModTimeReader.
+//
+// Requests for the checkpoint may be long-polled if the handler was created with WithCheckpointLongPoll.
+//
+// This is intended for use by personalities whose storage isn't directly accessible to clients, e.g.
+// MySQL, or which would like to proxy reads for debug/testing purposes.
+func NewReadHandler(lr LogReader, opts ...ReadHandlerOption) http.Handler {
+	o := &readHandlerOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /checkpoint", func(w http.ResponseWriter, r *http.Request) {
+		if o.awaiter != nil && r.URL.Query().Has("after") {
+			after, err := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+			if err != nil {
+				writeMalformedURL(w, fmt.Errorf("invalid after parameter: %v", err))
+				return
+			}
+			if !awaitCheckpoint(r.Context(), o.awaiter, after, o.maxWait) {
+				// The client went away, so there's nobody to respond to.
+				return
+			}
+		}
+		// The modification time is read before the checkpoint itself so that Last-Modified can never
+		// claim a time later than that at which the returned checkpoint was written.
+		var modTime time.Time
+		if m, ok := lr.(CheckpointModTimeReader); ok {
+			t, err := m.ReadCheckpointModTime(r.Context())
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				klog.Warningf("/checkpoint: failed to read modification time: %v", err)
+			}
+			modTime = t
+		}
+		checkpoint, err := lr.ReadCheckpoint(r.Context())
+		if err != nil {
+			writeError(w, "/checkpoint", err)
+			return
+		}
+
+		// Don't cache checkpoints as the endpoint refreshes regularly.
+		// A personality that wanted to _could_ set a small cache time here which was no higher
+		// than the checkpoint publish interval.
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Type", readCheckpointContType)
+		h := sha256.Sum256(checkpoint)
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(h[:])))
+		// ServeContent takes care of conditional requests using the ETag and modification time.
+		http.ServeContent(w, r, "", modTime, bytes.NewReader(checkpoint))
+	})
+
+	mux.HandleFunc("GET /tile/{level}/{index...}", func(w http.ResponseWriter, r *http.Request) {
+		level, index, p, err := layout.ParseTileLevelIndexPartial(r.PathValue("level"), r.PathValue("index"))
+		if err != nil {
+			writeMalformedURL(w, err)
+			return
+		}
+		etag := immutableETag(r)
+		if notModified(w, r, etag) {
+			return
+		}
+		rc, err := ReadTileStream(r.Context(), lr, level, index, p)
+		serveStream(w, "/tile/{level}/{index...}", etag, rc, err)
+	})
+
+	mux.HandleFunc("GET /tile/entries/{index...}", func(w http.ResponseWriter, r *http.Request) {
+		index, p, err := layout.ParseTileIndexPartial(r.PathValue("index"))
+		if err != nil {
+			writeMalformedURL(w, err)
+			return
+		}
+		etag := immutableETag(r)
+		if notModified(w, r, etag) {
+			return
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		if offset, length, ok := parseRange(r.Header.Get("Range")); ok {
+			serveRange(w, r, lr, etag, index, p, offset, length)
+			return
+		}
+		rc, err := ReadEntryBundleStream(r.Context(), lr, index, p)
+		serveStream(w, "/tile/entries/{index...}", etag, rc, err)
+	})
+	return mux
+}
+
+// ReadHandlerOption configures optional behaviour of the handler returned by NewReadHandler.
+type ReadHandlerOption func(*readHandlerOpts)
+
+type readHandlerOpts struct {
+	awaiter *PublicationAwaiter
+	maxWait time.Duration
+}
+
+// DefaultCheckpointLongPollMaxWait is the longest a long-polled checkpoint request is held for by default.
+const DefaultCheckpointLongPollMaxWait = 30 * time.Second
+
+// WithCheckpointLongPoll allows clients to long-poll for the checkpoint by requesting /checkpoint?after=<size>.
+// Such requests are held until a checkpoint larger than size has been published, or until maxWait has passed,
+// and are then answered with the latest checkpoint. Clients should compare the size of the returned checkpoint
+// with the one they asked for, since it will be no larger if the wait timed out, and then simply ask again.
+//
+// This lets monitors and witnesses follow the log with a request per checkpoint, rather than polling on a timer.
+// Waiting requests share the polling done by the awaiter, which should be created with the same LogReader, so the
+// cost to storage doesn't grow with the number of clients waiting.
+//
+// If maxWait is zero, DefaultCheckpointLongPollMaxWait is used. It should be comfortably shorter than any
+// timeouts applied by the HTTP server, or proxies in front of it.
+func WithCheckpointLongPoll(a *PublicationAwaiter, maxWait time.Duration) ReadHandlerOption {
+	return func(o *readHandlerOpts) {
+		o.awaiter = a
+		o.maxWait = maxWait
+		if o.maxWait <= 0 {
+			o.maxWait = DefaultCheckpointLongPollMaxWait
+		}
+	}
+}
+
+// awaitCheckpoint blocks until a checkpoint larger than size has been seen by the awaiter, or maxWait has passed.
+// It returns false if ctx was cancelled while waiting.
+//
+// Errors reading the checkpoint also end the wait, leaving the caller to read the checkpoint and report them.
+func awaitCheckpoint(ctx context.Context, a *PublicationAwaiter, size uint64, maxWait time.Duration) bool {
+	wctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+	// The awaiter waits for a checkpoint which commits to an index, which is one larger than the index itself.
+	_, _ = a.await(wctx, size)
+	return ctx.Err() == nil
+}
+
+// immutableETag returns a strong ETag for the immutable tile or entry bundle resource requested by r.
+//
+// Resources in a tlog-tiles log never change once they've been written, so the ETag is derived from the
+// request path. This allows conditional requests to be answered without reading from storage at all.
+func immutableETag(r *http.Request) string {
+	h := sha256.Sum256([]byte(r.URL.Path))
+	return fmt.Sprintf("%q", hex.EncodeToString(h[:]))
+}
+
+// notModified returns true if a 304 Not Modified response was written because the client already has the
+// resource with the given etag.
+//
+// Clients can only have the ETag from an earlier successful response, so the resource exists.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	for _, t := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		// If-None-Match uses the weak comparison function, so ignore any weakness indicator.
+		if t = strings.TrimPrefix(strings.TrimSpace(t), "W/"); t == etag {
+			setImmutableHeaders(w, etag)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// setImmutableHeaders sets the headers which allow a tile or entry bundle resource to be cached forever.
+// They must only be set on responses for resources which have been successfully read, so that errors,
+// e.g. a 404 for a tile which hasn't been written yet, aren't cached.
+func setImmutableHeaders(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", readLogCacheControl)
+}
+
+// serveRange responds to a request for part of an entry bundle with a 206 Partial Content response.
+func serveRange(w http.ResponseWriter, r *http.Request, lr LogReader, etag string, index uint64, p uint8, offset, length int64) {
+	const route = "/tile/entries/{index...}"
+	rc, size, err := ReadEntryBundleRange(r.Context(), lr, index, p, offset, length)
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			klog.Warningf("%s: failed to close reader: %v", route, err)
+		}
+	}()
+	start, n, err := ResolveRange(size, offset, length)
+	if err != nil {
+		klog.Errorf("%s: %v", route, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	setImmutableHeaders(w, etag)
+	w.Header().Set("Content-Type", readLogContType)
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+n-1, size))
+	w.Header().Set("Content-Length", strconv.FormatInt(n, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	if _, err := io.Copy(w, rc); err != nil {
+		klog.Errorf("%s: %v", route, err)
+	}
+}
+
+// parseRange parses the value of an HTTP Range header into the offset and length arguments expected
+// by ReadEntryBundleRange.
+//
+// Only a single range in bytes is supported; ok will be false if the header is absent, malformed,
+// or requests multiple ranges, in which case the header should be ignored and the full resource served.
+func parseRange(h string) (offset, length int64, ok bool) {
+	spec, found := strings.CutPrefix(h, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+	if first == "" {
+		// A suffix range, e.g. "bytes=-500" for the last 500 bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		if n == 0 {
+			// A zero-length suffix can never be satisfied, so ask for a range which starts beyond any bundle.
+			return math.MaxInt64, -1, true
+		}
+		return -n, -1, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if last == "" {
+		return start, -1, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	if end-start == math.MaxInt64 {
+		return start, -1, true
+	}
+	return start, end - start + 1, true
+}
+
+// writeMalformedURL responds to a request whose path couldn't be parsed.
+func writeMalformedURL(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	if _, werr := fmt.Fprintf(w, "Malformed URL: %s", err.Error()); werr != nil {
+		klog.Errorf("Failed to write response: %v", werr)
+	}
+}
+
+// serveStream copies an immutable tile or entry bundle resource to the response, taking care of closing
+// the reader once done.
+//
+// If err is non-nil, an appropriate error status is written instead.
+func serveStream(w http.ResponseWriter, route string, etag string, rc io.ReadCloser, err error) {
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			klog.Warningf("%s: failed to close reader: %v", route, err)
+		}
+	}()
+
+	setImmutableHeaders(w, etag)
+	w.Header().Set("Content-Type", readLogContType)
+	if _, err := io.Copy(w, rc); err != nil {
+		klog.Errorf("%s: %v", route, err)
+	}
+}
+
+// writeError writes the HTTP status code corresponding to err, logging unexpected errors.
+func writeError(w http.ResponseWriter, route string, err error) {
+	code := HTTPStatusCode(err)
+	if code == http.StatusInternalServerError {
+		klog.Errorf("%s: %v", route, err)
+	}
+	w.WriteHeader(code)
+}
+
+// HTTPStatusCode returns the HTTP status code which corresponds to err, which must be non-nil, based on
+// the sentinel errors defined by this package. This allows personalities to map errors returned by
+// storage implementations to responses without inspecting error strings.
+//
+// Unrecognised errors map to http.StatusInternalServerError.
+func HTTPStatusCode(err error) int {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// This also matches ErrNotFound.
+		return http.StatusNotFound
+	case errors.Is(err, ErrRangeNotSatisfiable):
+		return http.StatusRequestedRangeNotSatisfiable
+	case errors.Is(err, ErrEntryTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrInvalidEntry):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrPushback), errors.Is(err, ErrSealed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+func TestReadHandler(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, 10)
+	for i := range 10 {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	var cp []byte
+	for _, f := range futures {
+		_, c, err := awaiter.Await(ctx, f)
+		if err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+		cp = c
+	}
+	wantBundle, err := tl.LogReader.ReadEntryBundle(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	}
+	wantTile, err := tl.LogReader.ReadTile(ctx, 0, 0, 10)
+	if err != nil {
+		t.Fatalf("ReadTile: %v", err)
+	}
+
+	for _, tC := range []struct {
+		desc string
+		lr   tessera.LogReader
+	}{
+		{desc: "streaming", lr: tl.LogReader},
+		// Embedding the interface hides the StreamingLogReader methods, forcing the buffered fallback.
+		{desc: "buffered", lr: struct{ tessera.LogReader }{tl.LogReader}},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, ok := tC.lr.(tessera.StreamingLogReader); ok != (tC.desc == "streaming") {
+				t.Fatalf("LogReader implements StreamingLogReader = %t", ok)
+			}
+			if _, ok := tC.lr.(tessera.RangeLogReader); ok != (tC.desc == "streaming") {
+				t.Fatalf("LogReader implements RangeLogReader = %t", ok)
+			}
+			srv := httptest.NewServer(tessera.NewReadHandler(tC.lr))
+			defer srv.Close()
+
+			for _, r := range []struct {
+				path          string
+				rangeHdr      string
+				wantStatus    int
+				wantBody      []byte
+				wantContRange string
+			}{
+				{path: "/checkpoint", wantStatus: http.StatusOK, wantBody: cp},
+				{path: "/tile/entries/000.p/10", wantStatus: http.StatusOK, wantBody: wantBundle},
+				{path: "/tile/0/000.p/10", wantStatus: http.StatusOK, wantBody: wantTile},
+				{path: "/tile/entries/001", wantStatus: http.StatusNotFound},
+				{path: "/tile/0/001", wantStatus: http.StatusNotFound},
+				{path: "/tile/entries/nope", wantStatus: http.StatusBadRequest},
+				{path: "/tile/entries/000.p/10", rangeHdr: "bytes=2-5", wantStatus: http.StatusPartialContent, wantBody: wantBundle[2:6], wantContRange: fmt.Sprintf("bytes 2-5/%d", len(wantBundle))},
+				{path: "/tile/entries/000.p/10", rangeHdr: "bytes=3-", wantStatus: http.StatusPartialContent, wantBody: wantBundle[3:], wantContRange: fmt.Sprintf("bytes 3-%d/%d", len(wantBundle)-1, len(wantBundle))},
+				{path: "/tile/entries/000.p/10", rangeHdr: "bytes=-4", wantStatus: http.StatusPartialContent, wantBody: wantBundle[len(wantBundle)-4:], wantContRange: fmt.Sprintf("bytes %d-%d/%d", len(wantBundle)-4, len(wantBundle)-1, len(wantBundle))},
+				{path: "/tile/entries/000.p/10", rangeHdr: "bytes=0-100000", wantStatus: http.StatusPartialContent, wantBody: wantBundle, wantContRange: fmt.Sprintf("bytes 0-%d/%d", len(wantBundle)-1, len(wantBundle))},
+				{path: "/tile/entries/000.p/10", rangeHdr: fmt.Sprintf("bytes=%d-", len(wantBundle)), wantStatus: http.StatusRequestedRangeNotSatisfiable},
+				{path: "/tile/entries/000.p/10", rangeHdr: "bytes=-0", wantStatus: http.StatusRequestedRangeNotSatisfiable},
+				// Multiple ranges aren't supported, so the whole bundle is returned.
+				{path: "/tile/entries/000.p/10", rangeHdr: "bytes=0-1,4-5", wantStatus: http.StatusOK, wantBody: wantBundle},
+				{path: "/tile/entries/001", rangeHdr: "bytes=0-1", wantStatus: http.StatusNotFound},
+			} {
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+r.path, nil)
+				if err != nil {
+					t.Fatalf("NewRequest: %v", err)
+				}
+				if r.rangeHdr != "" {
+					req.Header.Set("Range", r.rangeHdr)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatalf("GET %s: %v", r.path, err)
+				}
+				body, err := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				if err != nil {
+					t.Fatalf("GET %s: ReadAll: %v", r.path, err)
+				}
+				if got, want := resp.StatusCode, r.wantStatus; got != want {
+					t.Errorf("GET %s (%s): got status %d, want %d", r.path, r.rangeHdr, got, want)
+				}
+				if r.wantBody != nil && !bytes.Equal(body, r.wantBody) {
+					t.Errorf("GET %s (%s): got body %q, want %q", r.path, r.rangeHdr, body, r.wantBody)
+				}
+				if got, want := resp.Header.Get("Content-Range"), r.wantContRange; got != want {
+					t.Errorf("GET %s (%s): got Content-Range %q, want %q", r.path, r.rangeHdr, got, want)
+				}
+				// Errors, e.g. a 404 for a tile which hasn't been written yet, must not be cached forever.
+				if resp.StatusCode >= 400 {
+					if cc := resp.Header.Get("Cache-Control"); strings.Contains(cc, "immutable") {
+						t.Errorf("GET %s (%s): got Cache-Control %q on %d response", r.path, r.rangeHdr, cc, resp.StatusCode)
+					}
+					if etag := resp.Header.Get("ETag"); etag != "" {
+						t.Errorf("GET %s (%s): got ETag %q on %d response", r.path, r.rangeHdr, etag, resp.StatusCode)
+					}
+				}
+			}
+
+			// Conditional requests.
+			for _, path := range []string{"/checkpoint", "/tile/entries/000.p/10", "/tile/0/000.p/10"} {
+				resp := get(t, srv.URL+path, nil)
+				etag := resp.Header.Get("ETag")
+				if etag == "" {
+					t.Fatalf("GET %s: no ETag", path)
+				}
+				if got, want := get(t, srv.URL+path, map[string]string{"If-None-Match": etag}).StatusCode, http.StatusNotModified; got != want {
+					t.Errorf("GET %s with If-None-Match %s: got status %d, want %d", path, etag, got, want)
+				}
+				if got, want := get(t, srv.URL+path, map[string]string{"If-None-Match": `"nope"`}).StatusCode, http.StatusOK; got != want {
+					t.Errorf("GET %s with mismatched If-None-Match: got status %d, want %d", path, got, want)
+				}
+			}
+			resp := get(t, srv.URL+"/checkpoint", nil)
+			lastMod := resp.Header.Get("Last-Modified")
+			if (lastMod != "") != (tC.desc == "streaming") {
+				t.Fatalf("GET /checkpoint: got Last-Modified %q", lastMod)
+			}
+			if lastMod != "" {
+				if got, want := get(t, srv.URL+"/checkpoint", map[string]string{"If-Modified-Since": lastMod}).StatusCode, http.StatusNotModified; got != want {
+					t.Errorf("GET /checkpoint with If-Modified-Since: got status %d, want %d", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadHandlerCheckpointLongPoll(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+	if _, _, err := awaiter.Await(ctx, tl.Appender.Add(ctx, tessera.NewEntry([]byte("first")))); err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+	maxWait := 500 * time.Millisecond
+	srv := httptest.NewServer(tessera.NewReadHandler(tl.LogReader, tessera.WithCheckpointLongPoll(awaiter, maxWait)))
+	defer srv.Close()
+
+	// longPoll requests the checkpoint from srv, and returns its size and how long the request took.
+	longPoll := func(srv *httptest.Server, query string) (int, uint64, time.Duration) {
+		t.Helper()
+		start := time.Now()
+		resp, err := http.Get(srv.URL + "/checkpoint" + query)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, 0, time.Since(start)
+		}
+		_, size, _, err := parse.CheckpointUnsafe(body)
+		if err != nil {
+			t.Fatalf("CheckpointUnsafe: %v", err)
+		}
+		return resp.StatusCode, size, time.Since(start)
+	}
+
+	if _, size, d := longPoll(srv, "?after=0"); size != 1 || d >= maxWait {
+		t.Errorf("Long-poll for a smaller checkpoint: got size %d after %v, want size 1 immediately", size, d)
+	}
+	if _, size, d := longPoll(srv, "?after=1"); size != 1 || d < maxWait {
+		t.Errorf("Long-poll with no new checkpoint: got size %d after %v, want size 1 after %v", size, d, maxWait)
+	}
+	if code, _, _ := longPoll(srv, "?after=one"); code != http.StatusBadRequest {
+		t.Errorf("Long-poll with invalid size: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	// A long-poll which is waiting when a new checkpoint is published should return it straight away.
+	maxWait = 10 * time.Second
+	slowSrv := httptest.NewServer(tessera.NewReadHandler(tl.LogReader, tessera.WithCheckpointLongPoll(awaiter, maxWait)))
+	defer slowSrv.Close()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := tl.Appender.Add(ctx, tessera.NewEntry([]byte("second")))(); err != nil {
+			t.Errorf("Add: %v", err)
+		}
+	}()
+	if _, size, d := longPoll(slowSrv, "?after=1"); size != 2 || d >= maxWait {
+		t.Errorf("Long-poll with new checkpoint: got size %d after %v, want size 2 before %v", size, d, maxWait)
+	}
+}
+
+// get performs a GET request for u with the provided headers, and returns the response with its body drained.
+func get(t *testing.T, u string, hdrs map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	for k, v := range hdrs {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", u, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp
+}
+
+func TestHTTPStatusCode(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want int
+	}{
+		{err: fmt.Errorf("tile/0/000: %w", tessera.ErrNotFound), want: http.StatusNotFound},
+		{err: fs.ErrNotExist, want: http.StatusNotFound},
+		{err: tessera.ErrRangeNotSatisfiable, want: http.StatusRequestedRangeNotSatisfiable},
+		{err: fmt.Errorf("antispam %w", tessera.ErrPushback), want: http.StatusServiceUnavailable},
+		{err: fmt.Errorf("%w: shut down", tessera.ErrSealed), want: http.StatusServiceUnavailable},
+		{err: fmt.Errorf("%w: tenant-a", tessera.ErrQuotaExceeded), want: http.StatusTooManyRequests},
+		{err: fmt.Errorf("%w: 70000 bytes", tessera.ErrEntryTooLarge), want: http.StatusRequestEntityTooLarge},
+		{err: fmt.Errorf("%w: bad signature", tessera.ErrInvalidEntry), want: http.StatusBadRequest},
+		{err: errors.New("boom"), want: http.StatusInternalServerError},
+	} {
+		if got := tessera.HTTPStatusCode(test.err); got != test.want {
+			t.Errorf("HTTPStatusCode(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+)
+
+func TestReadOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	s := createSigner()
+	dir := t.TempDir()
+	f := &countingFollower{}
+	newAppender := func(readOnly bool) (*tessera.Appender, tessera.LogReader) {
+		t.Helper()
+		driver, err := posix.New(ctx, dir)
+		if err != nil {
+			t.Fatalf("posix.New: %v", err)
+		}
+		opts := tessera.NewAppendOptions().WithCheckpointSigner(s).WithCheckpointInterval(time.Second)
+		if readOnly {
+			opts.WithReadOnly().WithFollower(f)
+		}
+		a, _, r, err := tessera.NewAppender(ctx, driver, opts)
+		if err != nil {
+			t.Fatalf("NewAppender: %v", err)
+		}
+		return a, r
+	}
+
+	// A read-only appender must not initialise a new log.
+	a, r := newAppender(true)
+	if _, err := a.Add(ctx, tessera.NewEntry([]byte("rejected")))(); !errors.Is(err, tessera.ErrReadOnly) || !errors.Is(err, tessera.ErrSealed) {
+		t.Errorf("Add: got err %v, want %v", err, tessera.ErrReadOnly)
+	}
+	if _, err := r.ReadCheckpoint(ctx); !errors.Is(err, tessera.ErrNotFound) {
+		t.Errorf("ReadCheckpoint on uninitialised log: got err %v, want %v", err, tessera.ErrNotFound)
+	}
+	if ents, err := os.ReadDir(dir); err != nil || len(ents) != 0 {
+		t.Errorf("read-only appender wrote to log directory: %v, %v", ents, err)
+	}
+
+	// Populate the log, then check that a read-only appender serves it.
+	a, r = newAppender(false)
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	idx, want, err := awaiter.Await(ctx, a.Add(ctx, tessera.NewEntry([]byte("accepted"))))
+	if err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+
+	a, r = newAppender(true)
+	if _, err := a.Add(ctx, tessera.NewEntry([]byte("rejected")))(); !errors.Is(err, tessera.ErrReadOnly) {
+		t.Errorf("Add: got err %v, want %v", err, tessera.ErrReadOnly)
+	}
+	got, err := r.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ReadCheckpoint: got %q, want %q", got, want)
+	}
+	if _, err := r.ReadEntryBundle(ctx, idx.Index/256, 1); err != nil {
+		t.Errorf("ReadEntryBundle: %v", err)
+	}
+	// Background tasks are never started for a read-only appender, so they can't write anywhere either.
+	if n := f.calls.Load(); n != 0 {
+		t.Errorf("Follower of read-only appender was called %d times, want 0", n)
+	}
+}
+
+// countingFollower counts the calls made to it.
+type countingFollower struct {
+	calls atomic.Int64
+}
+
+func (f *countingFollower) Name() string { return "counting" }
+
+func (f *countingFollower) Follow(context.Context, tessera.LogReader) { f.calls.Add(1) }
+
+func (f *countingFollower) EntriesProcessed(context.Context) (uint64, error) {
+	f.calls.Add(1)
+	return 0, nil
+}
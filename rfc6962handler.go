@@ -0,0 +1,307 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// DefaultRFC6962MaxGetEntries is the default maximum number of entries returned by a single get-entries request.
+const DefaultRFC6962MaxGetEntries = layout.EntryBundleWidth
+
+// RFC6962LeafEntry is a single entry as returned by the RFC 6962 get-entries endpoint.
+type RFC6962LeafEntry struct {
+	// LeafInput is the MerkleTreeLeaf structure for the entry, i.e. the preimage of its leaf hash.
+	LeafInput []byte `json:"leaf_input"`
+	// ExtraData is any auxiliary data associated with the entry.
+	ExtraData []byte `json:"extra_data"`
+}
+
+// RFC6962HandlerOptions configures the handler returned by NewRFC6962Handler.
+type RFC6962HandlerOptions struct {
+	// STHVerifier verifies the log's RFC 6962 STH signature on its checkpoints, e.g. one created with
+	// NewRFC6962Verifier from github.com/transparency-dev/formats/note. Its name must be the log's origin.
+	//
+	// This is required, and the log must sign its checkpoints with the corresponding signer, e.g. one
+	// created with NewRFC6962Signer.
+	STHVerifier note.Verifier
+
+	// LeafIndex returns the index in the log of the entry with the given RFC 6962 Merkle leaf hash,
+	// or an error wrapping os.ErrNotExist if there is no such entry.
+	//
+	// This is optional; if unset, get-proof-by-hash requests will fail with 501 Not Implemented.
+	LeafIndex func(ctx context.Context, leafHash []byte) (uint64, error)
+
+	// ParseEntryBundle converts a raw entry bundle into the entries it contains.
+	//
+	// If unset, bundles are parsed as tlog-tiles entry bundles and each entry's data is returned as its
+	// LeafInput. Logs using WithCTLayout should use ParseCTEntryBundle, or their own function which also
+	// resolves the ExtraData for each entry.
+	ParseEntryBundle func(bundle []byte) ([]RFC6962LeafEntry, error)
+
+	// MaxGetEntries is the maximum number of entries returned by a single get-entries request.
+	// If zero, DefaultRFC6962MaxGetEntries is used.
+	MaxGetEntries uint64
+
+	// InclusionProof returns the inclusion proof for the entry at index in the tree of the given size,
+	// e.g. ProofCache.InclusionProof.
+	//
+	// If unset, proofs for get-proof-by-hash requests are built from the log's tiles for each request.
+	InclusionProof func(ctx context.Context, index, size uint64) ([][]byte, error)
+}
+
+// ParseCTEntryBundle parses a Static CT API data tile into entries suitable for returning via get-entries.
+//
+// The LeafInput of each entry is its MerkleTreeLeaf. Data tiles only contain fingerprints of each
+// entry's chain, so ExtraData is left empty.
+func ParseCTEntryBundle(bundle []byte) ([]RFC6962LeafEntry, error) {
+	leaves, err := ctMerkleTreeLeaves(bundle)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]RFC6962LeafEntry, 0, len(leaves))
+	for _, l := range leaves {
+		r = append(r, RFC6962LeafEntry{LeafInput: l})
+	}
+	return r, nil
+}
+
+// parseTlogTilesEntryBundle is the default ParseEntryBundle implementation.
+func parseTlogTilesEntryBundle(bundle []byte) ([]RFC6962LeafEntry, error) {
+	b := api.EntryBundle{}
+	if err := b.UnmarshalText(bundle); err != nil {
+		return nil, err
+	}
+	r := make([]RFC6962LeafEntry, 0, len(b.Entries))
+	for _, e := range b.Entries {
+		r = append(r, RFC6962LeafEntry{LeafInput: e})
+	}
+	return r, nil
+}
+
+// NewRFC6962Handler returns an http.Handler which serves the get-sth, get-entries, get-proof-by-hash and
+// get-sth-consistency endpoints of the RFC 6962 read API, computed on the fly from the log's checkpoint,
+// tiles, and entry bundles.
+//
+// This allows existing RFC 6962 monitors to consume a Tessera-backed log during a transition to
+// tile-based APIs. The handler expects to be mounted at the log's RFC 6962 prefix, and serves paths
+// beneath /ct/v1/.
+//
+// All responses are relative to the log's latest checkpoint, so requests for tree sizes larger than
+// that are rejected even if the entries have already been integrated.
+func NewRFC6962Handler(lr LogReader, opts RFC6962HandlerOptions) (http.Handler, error) {
+	if opts.STHVerifier == nil {
+		return nil, errors.New("STHVerifier must be set")
+	}
+	if opts.ParseEntryBundle == nil {
+		opts.ParseEntryBundle = parseTlogTilesEntryBundle
+	}
+	if opts.MaxGetEntries == 0 {
+		opts.MaxGetEntries = DefaultRFC6962MaxGetEntries
+	}
+	if opts.InclusionProof == nil {
+		opts.InclusionProof = func(ctx context.Context, index, size uint64) ([][]byte, error) {
+			return buildInclusionProof(ctx, lr, index, size)
+		}
+	}
+	h := &rfc6962Handler{lr: lr, opts: opts}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ct/v1/get-sth", h.getSTH)
+	mux.HandleFunc("GET /ct/v1/get-entries", h.getEntries)
+	mux.HandleFunc("GET /ct/v1/get-proof-by-hash", h.getProofByHash)
+	mux.HandleFunc("GET /ct/v1/get-sth-consistency", h.getSTHConsistency)
+	return mux, nil
+}
+
+type rfc6962Handler struct {
+	lr   LogReader
+	opts RFC6962HandlerOptions
+}
+
+// latestSTH reads the log's latest checkpoint and returns the signed tree head it carries.
+func (h *rfc6962Handler) latestSTH(ctx context.Context) (*SignedTreeHead, error) {
+	raw, err := h.lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CheckpointToSTH(raw, h.opts.STHVerifier)
+}
+
+func (h *rfc6962Handler) getSTH(w http.ResponseWriter, r *http.Request) {
+	sth, err := h.latestSTH(r.Context())
+	if err != nil {
+		writeError(w, "/ct/v1/get-sth", err)
+		return
+	}
+	writeJSON(w, "/ct/v1/get-sth", sth)
+}
+
+func (h *rfc6962Handler) getEntries(w http.ResponseWriter, r *http.Request) {
+	const route = "/ct/v1/get-entries"
+	start, err1 := strconv.ParseUint(r.URL.Query().Get("start"), 10, 64)
+	end, err2 := strconv.ParseUint(r.URL.Query().Get("end"), 10, 64)
+	if err := errors.Join(err1, err2); err != nil || start > end {
+		http.Error(w, fmt.Sprintf("invalid start and end parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+	sth, err := h.latestSTH(r.Context())
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	if start >= sth.TreeSize {
+		http.Error(w, fmt.Sprintf("start %d is beyond tree size %d", start, sth.TreeSize), http.StatusBadRequest)
+		return
+	}
+	end = min(end, sth.TreeSize-1, start+h.opts.MaxGetEntries-1)
+
+	resp := struct {
+		Entries []RFC6962LeafEntry `json:"entries"`
+	}{Entries: make([]RFC6962LeafEntry, 0, end-start+1)}
+	for i := start; i <= end; {
+		bundleIdx := i / layout.EntryBundleWidth
+		raw, err := h.lr.ReadEntryBundle(r.Context(), bundleIdx, layout.PartialTileSize(0, bundleIdx, sth.TreeSize))
+		if err != nil {
+			writeError(w, route, fmt.Errorf("failed to read entry bundle %d: %w", bundleIdx, err))
+			return
+		}
+		entries, err := h.opts.ParseEntryBundle(raw)
+		if err != nil {
+			writeError(w, route, fmt.Errorf("failed to parse entry bundle %d: %v", bundleIdx, err))
+			return
+		}
+		for ; i <= end && i/layout.EntryBundleWidth == bundleIdx; i++ {
+			j := i % layout.EntryBundleWidth
+			if j >= uint64(len(entries)) {
+				writeError(w, route, fmt.Errorf("entry bundle %d has %d entries, want entry %d", bundleIdx, len(entries), i))
+				return
+			}
+			resp.Entries = append(resp.Entries, entries[j])
+		}
+	}
+	writeJSON(w, route, resp)
+}
+
+func (h *rfc6962Handler) getProofByHash(w http.ResponseWriter, r *http.Request) {
+	const route = "/ct/v1/get-proof-by-hash"
+	if h.opts.LeafIndex == nil {
+		http.Error(w, "get-proof-by-hash is not supported by this log", http.StatusNotImplemented)
+		return
+	}
+	hash, err := base64.StdEncoding.DecodeString(r.URL.Query().Get("hash"))
+	if err != nil || len(hash) == 0 {
+		http.Error(w, fmt.Sprintf("invalid hash parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+	size, ok := h.treeSizeParam(w, r, "tree_size")
+	if !ok {
+		return
+	}
+	idx, err := h.opts.LeafIndex(r.Context(), hash)
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	if idx >= size {
+		http.Error(w, fmt.Sprintf("leaf is not included in tree of size %d", size), http.StatusBadRequest)
+		return
+	}
+	p, err := h.opts.InclusionProof(r.Context(), idx, size)
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	writeJSON(w, route, struct {
+		LeafIndex uint64   `json:"leaf_index"`
+		AuditPath [][]byte `json:"audit_path"`
+	}{LeafIndex: idx, AuditPath: p})
+}
+
+func (h *rfc6962Handler) getSTHConsistency(w http.ResponseWriter, r *http.Request) {
+	const route = "/ct/v1/get-sth-consistency"
+	first, err := strconv.ParseUint(r.URL.Query().Get("first"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid first parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+	second, ok := h.treeSizeParam(w, r, "second")
+	if !ok {
+		return
+	}
+	if first > second {
+		http.Error(w, fmt.Sprintf("first %d is larger than second %d", first, second), http.StatusBadRequest)
+		return
+	}
+	pb, err := client.NewProofBuilder(r.Context(), second, h.lr.ReadTile)
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	p, err := pb.ConsistencyProof(r.Context(), first, second)
+	if err != nil {
+		writeError(w, route, err)
+		return
+	}
+	writeJSON(w, route, struct {
+		Consistency [][]byte `json:"consistency"`
+	}{Consistency: p})
+}
+
+// treeSizeParam parses the named query parameter as a tree size, which must be non-zero and no
+// larger than the size of the latest STH. If it isn't, an error response is written and ok is false.
+func (h *rfc6962Handler) treeSizeParam(w http.ResponseWriter, r *http.Request, name string) (size uint64, ok bool) {
+	size, err := strconv.ParseUint(r.URL.Query().Get(name), 10, 64)
+	if err != nil || size == 0 {
+		http.Error(w, fmt.Sprintf("invalid %s parameter: %v", name, err), http.StatusBadRequest)
+		return 0, false
+	}
+	sth, err := h.latestSTH(r.Context())
+	if err != nil {
+		writeError(w, r.URL.Path, err)
+		return 0, false
+	}
+	if size > sth.TreeSize {
+		http.Error(w, fmt.Sprintf("%s %d is larger than current tree size %d", name, size, sth.TreeSize), http.StatusBadRequest)
+		return 0, false
+	}
+	return size, true
+}
+
+// writeJSON writes v to the response as JSON.
+func writeJSON(w http.ResponseWriter, route string, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		klog.Errorf("%s: failed to marshal response: %v", route, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(b); err != nil {
+		klog.Errorf("%s: failed to write response: %v", route, err)
+	}
+}
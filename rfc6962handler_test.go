@@ -0,0 +1,192 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+)
+
+func TestRFC6962Handler(t *testing.T) {
+	ctx := t.Context()
+	const logURL = "https://ct.example.com/test/"
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := tessera.NewRFC6962Signer(logURL, k)
+	if err != nil {
+		t.Fatalf("NewRFC6962Signer: %v", err)
+	}
+	vkey, err := f_note.RFC6962VerifierString(logURL, k.Public())
+	if err != nil {
+		t.Fatalf("RFC6962VerifierString: %v", err)
+	}
+	v, err := f_note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, shutdown, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	const numEntries = 300
+	byHash := make(map[string]uint64)
+	var futures []tessera.IndexFuture
+	for i := range numEntries {
+		d := fmt.Appendf(nil, "entry %d", i)
+		byHash[string(rfc6962.DefaultHasher.HashLeaf(d))] = uint64(i)
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(d)))
+	}
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+
+	h, err := tessera.NewRFC6962Handler(r, tessera.RFC6962HandlerOptions{
+		STHVerifier: v,
+		LeafIndex: func(_ context.Context, hash []byte) (uint64, error) {
+			i, ok := byHash[string(hash)]
+			if !ok {
+				return 0, os.ErrNotExist
+			}
+			return i, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRFC6962Handler: %v", err)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	get := func(path string, wantCode int, resp any) {
+		t.Helper()
+		rsp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer func() { _ = rsp.Body.Close() }()
+		if rsp.StatusCode != wantCode {
+			t.Fatalf("GET %s: got status %d, want %d", path, rsp.StatusCode, wantCode)
+		}
+		if resp != nil {
+			if err := json.NewDecoder(rsp.Body).Decode(resp); err != nil {
+				t.Fatalf("GET %s: failed to decode response: %v", path, err)
+			}
+		}
+	}
+
+	var sth struct {
+		TreeSize          uint64 `json:"tree_size"`
+		Timestamp         uint64 `json:"timestamp"`
+		SHA256RootHash    []byte `json:"sha256_root_hash"`
+		TreeHeadSignature []byte `json:"tree_head_signature"`
+	}
+	get("/ct/v1/get-sth", http.StatusOK, &sth)
+	if sth.TreeSize < numEntries || sth.Timestamp == 0 || len(sth.TreeHeadSignature) == 0 {
+		t.Fatalf("get-sth: got unexpected STH %+v", sth)
+	}
+
+	var entries struct {
+		Entries []tessera.RFC6962LeafEntry `json:"entries"`
+	}
+	get("/ct/v1/get-entries?start=250&end=299", http.StatusOK, &entries)
+	if got, want := len(entries.Entries), 50; got != want {
+		t.Fatalf("get-entries: got %d entries, want %d", got, want)
+	}
+	for i, e := range entries.Entries {
+		if want := fmt.Appendf(nil, "entry %d", 250+i); !bytes.Equal(e.LeafInput, want) {
+			t.Errorf("get-entries: entry %d is %q, want %q", 250+i, e.LeafInput, want)
+		}
+	}
+
+	leaf := []byte("entry 123")
+	leafHash := rfc6962.DefaultHasher.HashLeaf(leaf)
+	var incl struct {
+		LeafIndex uint64   `json:"leaf_index"`
+		AuditPath [][]byte `json:"audit_path"`
+	}
+	get(fmt.Sprintf("/ct/v1/get-proof-by-hash?hash=%s&tree_size=%d", urlBase64(leafHash), sth.TreeSize), http.StatusOK, &incl)
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, incl.LeafIndex, sth.TreeSize, leafHash, incl.AuditPath, sth.SHA256RootHash); err != nil {
+		t.Errorf("get-proof-by-hash: VerifyInclusion: %v", err)
+	}
+	get(fmt.Sprintf("/ct/v1/get-proof-by-hash?hash=%s&tree_size=%d", urlBase64(rfc6962.DefaultHasher.HashLeaf([]byte("missing"))), sth.TreeSize), http.StatusNotFound, nil)
+	get(fmt.Sprintf("/ct/v1/get-proof-by-hash?hash=%s&tree_size=%d", urlBase64(leafHash), 100), http.StatusBadRequest, nil)
+
+	const smaller = 123
+	hashes := make([][]byte, smaller)
+	for i := range smaller {
+		hashes[i] = rfc6962.DefaultHasher.HashLeaf(fmt.Appendf(nil, "entry %d", i))
+	}
+	smallerRoot := merkleRoot(hashes)
+	var cons struct {
+		Consistency [][]byte `json:"consistency"`
+	}
+	get(fmt.Sprintf("/ct/v1/get-sth-consistency?first=%d&second=%d", smaller, sth.TreeSize), http.StatusOK, &cons)
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, smaller, sth.TreeSize, cons.Consistency, smallerRoot, sth.SHA256RootHash); err != nil {
+		t.Errorf("get-sth-consistency: VerifyConsistency: %v", err)
+	}
+	get(fmt.Sprintf("/ct/v1/get-sth-consistency?first=%d&second=%d", 10, sth.TreeSize+1), http.StatusBadRequest, nil)
+}
+
+// urlBase64 returns the standard base64 encoding of b, escaped for use in a URL query.
+func urlBase64(b []byte) string {
+	return url.QueryEscape(base64.StdEncoding.EncodeToString(b))
+}
+
+// merkleRoot computes the RFC 6962 root hash over the provided leaf hashes.
+func merkleRoot(hashes [][]byte) []byte {
+	if len(hashes) == 1 {
+		return hashes[0]
+	}
+	k := 1
+	for k*2 < len(hashes) {
+		k *= 2
+	}
+	return rfc6962.DefaultHasher.HashChildren(merkleRoot(hashes[:k]), merkleRoot(hashes[k:]))
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// ErrDiscrepancy is returned (wrapped) by the SelfMonitor when the log's published state is
+// found to be invalid, e.g. inconsistent with a previously seen state.
+var ErrDiscrepancy = errors.New("log discrepancy detected")
+
+var (
+	selfMonitorChecks       metric.Int64Counter
+	selfMonitorVerifiedSize metric.Int64Gauge
+)
+
+func init() {
+	var err error
+
+	selfMonitorChecks, err = meter.Int64Counter(
+		"tessera.selfmonitor.checks",
+		metric.WithDescription("Number of self-monitor checks of the log's published state"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		klog.Exitf("Failed to create selfMonitorChecks metric: %v", err)
+	}
+
+	selfMonitorVerifiedSize, err = meter.Int64Gauge(
+		"tessera.selfmonitor.verified.size",
+		metric.WithDescription("Size of the latest checkpoint verified by the self-monitor"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create selfMonitorVerifiedSize metric: %v", err)
+	}
+}
+
+// SelfMonitorFetcher provides access to the read path of a log.
+//
+// Both client.HTTPFetcher and LogReader implement this interface, but note that using the same
+// read path as clients of the log (i.e. HTTP) gives much stronger assurances.
+type SelfMonitorFetcher = client.Fetcher
+
+// SelfMonitorOptions holds optional configuration for a SelfMonitor.
+type SelfMonitorOptions struct {
+	// Interval is how often the published checkpoint is checked. Defaults to DefaultCheckpointInterval.
+	Interval time.Duration
+	// InclusionSamples is the number of randomly selected entries whose inclusion is verified on each
+	// check. Defaults to 1.
+	InclusionSamples uint
+	// LeafHasher returns the Merkle leaf hashes for the entries in a serialised entry bundle.
+	// Defaults to RFC6962 leaf hashes of tlog-tiles entry bundles.
+	LeafHasher func(bundle []byte) ([][]byte, error)
+	// OnDiscrepancy is called with an error wrapping ErrDiscrepancy when a discrepancy is found.
+	// This is intended to allow personalities to hook in alerting.
+	OnDiscrepancy func(ctx context.Context, err error)
+	// Origin is the log's checkpoint origin. Defaults to the verifier's name, and must be set if the
+	// log was configured using WithCheckpointOrigin.
+	Origin string
+}
+
+// SelfMonitor independently verifies that a log's published state is valid and consistent over time.
+//
+// It fetches the log's checkpoint via the read path, verifies its signature, verifies that it is
+// consistent with the previously seen checkpoint, and verifies the inclusion of a sample of entries.
+type SelfMonitor struct {
+	f    SelfMonitorFetcher
+	v    note.Verifier
+	opts SelfMonitorOptions
+
+	prev    *f_log.Checkpoint
+	prevRaw []byte
+}
+
+// NewSelfMonitor creates a new SelfMonitor for the log accessible via f, whose checkpoints are
+// signed by the provided verifier.
+//
+// Call Run to start monitoring.
+func NewSelfMonitor(f SelfMonitorFetcher, v note.Verifier, opts SelfMonitorOptions) *SelfMonitor {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultCheckpointInterval
+	}
+	if opts.InclusionSamples == 0 {
+		opts.InclusionSamples = 1
+	}
+	if opts.LeafHasher == nil {
+		opts.LeafHasher = defaultMerkleLeafHasher
+	}
+	if opts.Origin == "" {
+		opts.Origin = v.Name()
+	}
+	return &SelfMonitor{
+		f:    f,
+		v:    v,
+		opts: opts,
+	}
+}
+
+// Run periodically checks the log's published state until the provided context is done.
+func (m *SelfMonitor) Run(ctx context.Context) {
+	t := time.NewTicker(m.opts.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := m.Check(ctx); err != nil {
+			klog.Warningf("SelfMonitor: %v", err)
+		}
+	}
+}
+
+// Check performs a single round of verification of the log's published state.
+//
+// Errors wrapping ErrDiscrepancy indicate that the log has published invalid state, and are
+// also passed to the OnDiscrepancy hook, if set. Other errors are likely to be transient.
+func (m *SelfMonitor) Check(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "tessera.SelfMonitor.Check")
+	defer span.End()
+
+	err := m.check(ctx)
+	result := "ok"
+	switch {
+	case errors.Is(err, ErrDiscrepancy):
+		result = "discrepancy"
+		if m.opts.OnDiscrepancy != nil {
+			m.opts.OnDiscrepancy(ctx, err)
+		}
+	case err != nil:
+		result = "error"
+	}
+	selfMonitorChecks.Add(ctx, 1, metric.WithAttributes(attribute.String("tessera.selfmonitor.result", result)))
+	return err
+}
+
+func (m *SelfMonitor) check(ctx context.Context) error {
+	cpRaw, err := m.f.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checkpoint: %v", err)
+	}
+	cp, _, _, err := f_log.ParseCheckpoint(cpRaw, m.opts.Origin, m.v)
+	if err != nil {
+		return fmt.Errorf("%w: invalid checkpoint: %v", ErrDiscrepancy, err)
+	}
+	pb, err := client.NewProofBuilder(ctx, cp.Size, m.f.ReadTile)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder: %v", err)
+	}
+
+	if m.prev != nil {
+		switch {
+		case cp.Size < m.prev.Size:
+			return fmt.Errorf("%w: checkpoint size went backwards from %d to %d", ErrDiscrepancy, m.prev.Size, cp.Size)
+		case cp.Size == m.prev.Size:
+			if !bytes.Equal(cp.Hash, m.prev.Hash) {
+				return fmt.Errorf("%w: %v", ErrDiscrepancy, client.ErrInconsistency{
+					SmallerRaw: m.prevRaw,
+					LargerRaw:  cpRaw,
+					Wrapped:    fmt.Errorf("different root hashes for size %d", cp.Size),
+				})
+			}
+		case m.prev.Size > 0:
+			p, err := pb.ConsistencyProof(ctx, m.prev.Size, cp.Size)
+			if err != nil {
+				return fmt.Errorf("failed to build consistency proof from %d to %d: %v", m.prev.Size, cp.Size, err)
+			}
+			if err := proof.VerifyConsistency(rfc6962.DefaultHasher, m.prev.Size, cp.Size, p, m.prev.Hash, cp.Hash); err != nil {
+				return fmt.Errorf("%w: %v", ErrDiscrepancy, client.ErrInconsistency{
+					SmallerRaw: m.prevRaw,
+					LargerRaw:  cpRaw,
+					Proof:      p,
+					Wrapped:    err,
+				})
+			}
+		}
+	}
+
+	if cp.Size > 0 {
+		for range m.opts.InclusionSamples {
+			if err := m.checkInclusion(ctx, pb, cp, rand.Uint64N(cp.Size)); err != nil {
+				return err
+			}
+		}
+	}
+
+	m.prev, m.prevRaw = cp, cpRaw
+	selfMonitorVerifiedSize.Record(ctx, otel.Clamp64(cp.Size))
+	return nil
+}
+
+// checkInclusion verifies that the entry at index idx, as found in the log's entry bundles, is
+// included in the tree committed to by cp.
+func (m *SelfMonitor) checkInclusion(ctx context.Context, pb *client.ProofBuilder, cp *f_log.Checkpoint, idx uint64) error {
+	bIdx := idx / layout.EntryBundleWidth
+	bundle, err := m.f.ReadEntryBundle(ctx, bIdx, layout.PartialTileSize(0, bIdx, cp.Size))
+	if err != nil {
+		return fmt.Errorf("failed to fetch entry bundle %d: %v", bIdx, err)
+	}
+	hashes, err := m.opts.LeafHasher(bundle)
+	if err != nil {
+		return fmt.Errorf("%w: invalid entry bundle %d: %v", ErrDiscrepancy, bIdx, err)
+	}
+	i := idx % layout.EntryBundleWidth
+	if i >= uint64(len(hashes)) {
+		return fmt.Errorf("%w: entry bundle %d has %d entries, but should contain index %d", ErrDiscrepancy, bIdx, len(hashes), idx)
+	}
+	p, err := pb.InclusionProof(ctx, idx)
+	if err != nil {
+		return fmt.Errorf("failed to build inclusion proof for index %d: %v", idx, err)
+	}
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, idx, cp.Size, hashes[i], p, cp.Hash); err != nil {
+		return fmt.Errorf("%w: entry %d is not included in checkpoint of size %d: %v", ErrDiscrepancy, idx, cp.Size, err)
+	}
+	return nil
+}
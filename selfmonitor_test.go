@@ -0,0 +1,134 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+// tamperingFetcher allows tests to interfere with the data read from a log.
+type tamperingFetcher struct {
+	tessera.LogReader
+	checkpoint  []byte
+	entryBundle []byte
+}
+
+func (f *tamperingFetcher) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	if f.checkpoint != nil {
+		return f.checkpoint, nil
+	}
+	return f.LogReader.ReadCheckpoint(ctx)
+}
+
+func (f *tamperingFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	if f.entryBundle != nil {
+		return f.entryBundle, nil
+	}
+	return f.LogReader.ReadEntryBundle(ctx, i, p)
+}
+
+func TestSelfMonitor(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond)
+	addEntries := func(n int) []byte {
+		t.Helper()
+		futures := make([]tessera.IndexFuture, 0, n)
+		for i := range n {
+			futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d %d", time.Now().UnixNano(), i))))
+		}
+		var cp []byte
+		for _, f := range futures {
+			_, c, err := awaiter.Await(ctx, f)
+			if err != nil {
+				t.Fatalf("Await: %v", err)
+			}
+			cp = c
+		}
+		return cp
+	}
+
+	var discrepancies []error
+	opts := tessera.SelfMonitorOptions{
+		InclusionSamples: 5,
+		OnDiscrepancy: func(_ context.Context, err error) {
+			discrepancies = append(discrepancies, err)
+		},
+	}
+
+	oldCP := addEntries(3)
+	f := &tamperingFetcher{LogReader: tl.LogReader}
+	m := tessera.NewSelfMonitor(f, tl.SigVerifier, opts)
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	addEntries(5)
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("Check after growth: %v", err)
+	}
+	if len(discrepancies) > 0 {
+		t.Fatalf("Unexpected discrepancies: %v", discrepancies)
+	}
+
+	var badBundle []byte
+	for i := range uint64(256) {
+		badBundle = append(badBundle, tessera.NewEntry(fmt.Appendf(nil, "not entry %d", i)).MarshalBundleData(i)...)
+	}
+
+	for _, test := range []struct {
+		name   string
+		tamper func(f *tamperingFetcher)
+	}{
+		{
+			name:   "rollback",
+			tamper: func(f *tamperingFetcher) { f.checkpoint = oldCP },
+		}, {
+			name:   "bad signature",
+			tamper: func(f *tamperingFetcher) { f.checkpoint = []byte("test\n1\nAAAA\n\n— test AAAAAAAA\n") },
+		}, {
+			name:   "bad entries",
+			tamper: func(f *tamperingFetcher) { f.entryBundle = badBundle },
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			discrepancies = nil
+			f := &tamperingFetcher{LogReader: tl.LogReader}
+			m := tessera.NewSelfMonitor(f, tl.SigVerifier, opts)
+			// Prime the monitor with the real state first.
+			if err := m.Check(ctx); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			test.tamper(f)
+			if err := m.Check(ctx); !errors.Is(err, tessera.ErrDiscrepancy) {
+				t.Errorf("Check: got %v, want ErrDiscrepancy", err)
+			}
+			if len(discrepancies) != 1 {
+				t.Errorf("Got %d discrepancies reported, want 1", len(discrepancies))
+			}
+		})
+	}
+}
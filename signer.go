@@ -0,0 +1,191 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// Signature algorithm identifiers used in note verifier keys, as understood by NewVerifier in
+// github.com/transparency-dev/formats/note.
+const (
+	algECDSAWithSHA256 = 0x02
+	algRFC6962STH      = 0x05
+)
+
+// NewECDSASigner returns a note.Signer which signs checkpoints with the provided ECDSA key, using
+// the scheme supported by NewECDSAVerifier in github.com/transparency-dev/formats/note.
+//
+// The signatures are ASN.1 encoded ECDSA signatures over the SHA-256 hash of the checkpoint body.
+// The corresponding verifier key can be obtained using ECDSAVerifierKey.
+func NewECDSASigner(name string, k *ecdsa.PrivateKey) (note.Signer, error) {
+	if !isValidKeyName(name) {
+		return nil, fmt.Errorf("invalid signer name %q", name)
+	}
+	der, err := x509.MarshalPKIXPublicKey(k.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	h := sha256.Sum256(der)
+	return &signer{
+		name:    name,
+		keyHash: binary.BigEndian.Uint32(h[:]),
+		sign: func(msg []byte) ([]byte, error) {
+			dgst := sha256.Sum256(msg)
+			return ecdsa.SignASN1(rand.Reader, k, dgst[:])
+		},
+	}, nil
+}
+
+// ECDSAVerifierKey returns the note verifier key string for checkpoints signed by a signer created
+// via NewECDSASigner with the same name and the private key corresponding to pub.
+func ECDSAVerifierKey(name string, pub *ecdsa.PublicKey) (string, error) {
+	if !isValidKeyName(name) {
+		return "", fmt.Errorf("invalid verifier name %q", name)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	h := sha256.Sum256(der)
+	return fmt.Sprintf("%s+%08x+%s", name, binary.BigEndian.Uint32(h[:]), base64.StdEncoding.EncodeToString(append([]byte{algECDSAWithSHA256}, der...))), nil
+}
+
+// NewRFC6962Signer returns a note.Signer which produces RFC 6962 Signed Tree Head signatures over
+// checkpoints, as used by CT logs following the Static CT API and Sunlight, and as verified by
+// NewRFC6962Verifier in github.com/transparency-dev/formats/note.
+//
+// The signer's name, and therefore the checkpoint origin, is derived from the CT log's submission
+// prefix URL, e.g. "https://rome.ct.example.com/2024h1/" becomes "rome.ct.example.com/2024h1".
+// The corresponding verifier key can be obtained using RFC6962VerifierString in
+// github.com/transparency-dev/formats/note.
+//
+// Only ECDSA P-256 keys are supported, as required by the CT ecosystem. An STH can't commit to
+// checkpoint extension lines, so attempts to sign checkpoints which contain them will fail.
+func NewRFC6962Signer(logURL string, k *ecdsa.PrivateKey) (note.Signer, error) {
+	if k.Curve != elliptic.P256() {
+		return nil, errors.New("RFC6962 signer requires an ECDSA P-256 key")
+	}
+	name, err := rfc6962Origin(logURL)
+	if err != nil {
+		return nil, err
+	}
+	keyHash, err := rfc6962KeyHash(name, k.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &signer{
+		name:    name,
+		keyHash: keyHash,
+		sign: func(msg []byte) ([]byte, error) {
+			ts := uint64(time.Now().UnixMilli())
+			sth, err := rfc6962TreeHeadSignatureInput(name, ts, msg)
+			if err != nil {
+				return nil, err
+			}
+			dgst := sha256.Sum256(sth)
+			sig, err := ecdsa.SignASN1(rand.Reader, k, dgst[:])
+			if err != nil {
+				return nil, err
+			}
+			// The note signature is the STH timestamp followed by a TLS encoded DigitallySigned struct,
+			// see RFC 5246 s4.7: SHA-256 (4), ECDSA (3), and a 16 bit length prefixed signature.
+			r := binary.BigEndian.AppendUint64(nil, ts)
+			r = append(r, 4, 3)
+			r = binary.BigEndian.AppendUint16(r, uint16(len(sig)))
+			return append(r, sig...), nil
+		},
+	}, nil
+}
+
+// rfc6962Origin returns the checkpoint origin, and RFC6962 signer name, for a CT log with the given
+// submission prefix URL.
+func rfc6962Origin(logURL string) (string, error) {
+	name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(logURL), "http://"), "https://"), "/")
+	if !isValidKeyName(name) {
+		return "", fmt.Errorf("invalid log URL %q", logURL)
+	}
+	return name, nil
+}
+
+// rfc6962KeyHash returns the note key hash for RFC6962 STH signatures by pub on checkpoints with the given origin.
+//
+// Unlike other note key types, this is computed over the CT log ID rather than the encoded key.
+func rfc6962KeyHash(origin string, pub crypto.PublicKey) (uint32, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	logID := sha256.Sum256(der)
+	kh := sha256.New()
+	kh.Write([]byte(origin))
+	kh.Write([]byte{'\n', algRFC6962STH})
+	kh.Write(logID[:])
+	return binary.BigEndian.Uint32(kh.Sum(nil)), nil
+}
+
+// rfc6962TreeHeadSignatureInput returns the TLS encoded TreeHeadSignature struct described by RFC 6962 s3.5
+// for the provided checkpoint body.
+func rfc6962TreeHeadSignatureInput(origin string, timestamp uint64, msg []byte) ([]byte, error) {
+	lines := strings.Split(string(msg), "\n")
+	if len(lines) != 4 || lines[3] != "" {
+		return nil, errors.New("RFC6962 signatures cannot commit to checkpoint extension lines")
+	}
+	if lines[0] != origin {
+		return nil, fmt.Errorf("checkpoint origin %q does not match signer name %q", lines[0], origin)
+	}
+	size, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint size: %v", err)
+	}
+	root, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(root) != sha256.Size {
+		return nil, errors.New("invalid checkpoint root hash")
+	}
+	return SignedTreeHead{TreeSize: size, Timestamp: timestamp, SHA256RootHash: root}.SignatureInput(), nil
+}
+
+// signer is a note.Signer with a pluggable signing function.
+type signer struct {
+	name    string
+	keyHash uint32
+	sign    func([]byte) ([]byte, error)
+}
+
+func (s *signer) Name() string                    { return s.name }
+func (s *signer) KeyHash() uint32                 { return s.keyHash }
+func (s *signer) Sign(msg []byte) ([]byte, error) { return s.sign(msg) }
+
+// isValidKeyName reports whether name is valid for use in a note key, as defined by golang.org/x/mod/sumdb/note.
+func isValidKeyName(name string) bool {
+	return name != "" && utf8.ValidString(name) && strings.IndexFunc(name, unicode.IsSpace) < 0 && !strings.Contains(name, "+")
+}
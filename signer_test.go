@@ -0,0 +1,126 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestECDSASigner(t *testing.T) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := NewECDSASigner("example.com/log", k)
+	if err != nil {
+		t.Fatalf("NewECDSASigner: %v", err)
+	}
+	vkey, err := ECDSAVerifierKey("example.com/log", &k.PublicKey)
+	if err != nil {
+		t.Fatalf("ECDSAVerifierKey: %v", err)
+	}
+	v, err := f_note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier(%q): %v", vkey, err)
+	}
+
+	cp := signTestCheckpoint(t, NewAppendOptions().WithCheckpointSigner(s))
+	if _, err := note.Open(cp, note.VerifierList(v)); err != nil {
+		t.Errorf("Open: %v", err)
+	}
+
+	if _, err := NewECDSASigner("bad name", k); err == nil {
+		t.Error("NewECDSASigner: got nil error for invalid name")
+	}
+}
+
+func TestRFC6962Signer(t *testing.T) {
+	const logURL = "https://CT.example.com/2025h1/"
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := NewRFC6962Signer(logURL, k)
+	if err != nil {
+		t.Fatalf("NewRFC6962Signer: %v", err)
+	}
+	if got, want := s.Name(), "ct.example.com/2025h1"; got != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+	vkey, err := f_note.RFC6962VerifierString(logURL, k.Public())
+	if err != nil {
+		t.Fatalf("RFC6962VerifierString: %v", err)
+	}
+	v, err := f_note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier(%q): %v", vkey, err)
+	}
+	if got, want := s.KeyHash(), v.KeyHash(); got != want {
+		t.Errorf("KeyHash() = %08x, want %08x", got, want)
+	}
+
+	start := time.Now().Truncate(time.Millisecond)
+	cp := signTestCheckpoint(t, NewAppendOptions().WithCheckpointSigner(s))
+	n, err := note.Open(cp, note.VerifierList(v))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	// The signature starts with the key hash, followed by the STH timestamp in milliseconds.
+	sig, err := base64.StdEncoding.DecodeString(n.Sigs[0].Base64)
+	if err != nil || len(sig) < 12 {
+		t.Fatalf("Invalid signature %q: %v", n.Sigs[0].Base64, err)
+	}
+	ts := time.UnixMilli(int64(binary.BigEndian.Uint64(sig[4:])))
+	if ts.Before(start) || ts.After(time.Now()) {
+		t.Errorf("STH timestamp %v not between %v and now", ts, start)
+	}
+
+	// STHs can't commit to extension lines.
+	withExt := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointExtensions(func(context.Context, uint64, []byte) ([]string, error) {
+		return []string{"extension"}, nil
+	})
+	if _, err := withExt.newCP(t.Context(), 1, make([]byte, sha256.Size)); err == nil {
+		t.Error("newCP: got nil error signing checkpoint with extension lines")
+	}
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if _, err := NewRFC6962Signer(logURL, p384); err == nil {
+		t.Error("NewRFC6962Signer: got nil error for P-384 key")
+	}
+}
+
+func signTestCheckpoint(t *testing.T, opts *AppendOptions) []byte {
+	t.Helper()
+	root := sha256.Sum256([]byte("root"))
+	cp, err := opts.newCP(t.Context(), 10, root[:])
+	if err != nil {
+		t.Fatalf("newCP: %v", err)
+	}
+	return cp
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/otel"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+var appenderUnpublishedSize metric.Int64Gauge
+
+func init() {
+	var err error
+
+	appenderUnpublishedSize, err = meter.Int64Gauge(
+		"tessera.appender.unpublished.size",
+		metric.WithDescription("Number of entries which have been accepted, but are not yet covered by the published checkpoint"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderUnpublishedSize metric: %v", err)
+	}
+}
+
+// IntegrationStall describes a change in whether the log's published checkpoint is keeping up with
+// the entries it has accepted.
+type IntegrationStall struct {
+	// Stalled is true when the configured thresholds have been exceeded, and false once the published
+	// checkpoint has caught up again.
+	Stalled bool
+	// NextIndex is the next index which will be assigned to an accepted entry.
+	NextIndex uint64
+	// PublishedSize is the size of the currently published checkpoint.
+	PublishedSize uint64
+	// Age is how long the oldest entry not covered by the published checkpoint has been waiting,
+	// to within the polling period.
+	Age time.Duration
+}
+
+// integrationWatcher tracks the gap between accepted entries and the published checkpoint.
+type integrationWatcher struct {
+	readCheckpoint func(ctx context.Context) ([]byte, error)
+	nextIndex      func(ctx context.Context) (uint64, error)
+	maxAge         time.Duration
+	maxSize        uint64
+	alert          func(ctx context.Context, s IntegrationStall)
+
+	// seen holds the next index observed by each check which found it had grown, along with the time
+	// of that check, for those observations which aren't yet covered by the published checkpoint.
+	seen    []idxAt
+	stalled bool
+}
+
+// run periodically checks the gap between accepted entries and the published checkpoint, calling the
+// alert function whenever the log transitions into, or out of, a stalled state.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (w *integrationWatcher) run(ctx context.Context, pollPeriod time.Duration) {
+	t := time.NewTicker(pollPeriod)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := w.check(ctx, time.Now()); err != nil {
+			klog.Warningf("integrationWatcher: %v", err)
+		}
+	}
+}
+
+func (w *integrationWatcher) check(ctx context.Context, now time.Time) error {
+	next, err := w.nextIndex(ctx)
+	if err != nil {
+		return err
+	}
+	var published uint64
+	cp, err := w.readCheckpoint(ctx)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// No checkpoint has been published yet.
+	case err != nil:
+		return err
+	default:
+		if _, published, _, err = parse.CheckpointUnsafe(cp); err != nil {
+			return err
+		}
+	}
+	appenderUnpublishedSize.Record(ctx, otel.Clamp64(next-min(next, published)))
+
+	if next > published && (len(w.seen) == 0 || next > w.seen[len(w.seen)-1].idx) {
+		w.seen = append(w.seen, idxAt{idx: next, at: now})
+	}
+	// Observations which the published checkpoint has caught up with no longer tell us anything.
+	i := 0
+	for i < len(w.seen) && w.seen[i].idx <= published {
+		i++
+	}
+	w.seen = w.seen[i:]
+
+	s := IntegrationStall{NextIndex: next, PublishedSize: published}
+	if len(w.seen) > 0 {
+		// The oldest unpublished entry, at index published, was first noticed by the earliest check which
+		// saw the next index beyond it. We don't know exactly when it was accepted, so the age is measured
+		// from then, rather than from when the published checkpoint last grew, so that a backlog which is
+		// only slowly draining is still reported.
+		s.Age = now.Sub(w.seen[0].at)
+	}
+	s.Stalled = (w.maxAge > 0 && s.Age > w.maxAge) || (w.maxSize > 0 && next-min(next, published) > w.maxSize)
+	if s.Stalled != w.stalled {
+		w.stalled = s.Stalled
+		if s.Stalled {
+			klog.Warningf("Integration stalled: next index %d, published size %d, oldest unpublished entry waiting %s", s.NextIndex, s.PublishedSize, s.Age)
+		} else {
+			klog.Infof("Integration recovered: next index %d, published size %d", s.NextIndex, s.PublishedSize)
+		}
+		w.alert(ctx, s)
+	}
+	return nil
+}
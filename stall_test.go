@@ -0,0 +1,85 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIntegrationWatcher(t *testing.T) {
+	var next, published uint64
+	havePublished := false
+	var alerts []IntegrationStall
+	w := &integrationWatcher{
+		readCheckpoint: func(_ context.Context) ([]byte, error) {
+			if !havePublished {
+				return nil, os.ErrNotExist
+			}
+			return fmt.Appendf(nil, "example.com/log\n%d\nAAAA\n\n— example.com/log sig\n", published), nil
+		},
+		nextIndex: func(_ context.Context) (uint64, error) { return next, nil },
+		maxAge:    10 * time.Second,
+		maxSize:   100,
+		alert: func(_ context.Context, s IntegrationStall) {
+			alerts = append(alerts, s)
+		},
+	}
+
+	start := time.Now()
+	for _, step := range []struct {
+		desc          string
+		at            time.Duration
+		next          uint64
+		published     uint64
+		havePublished bool
+		wantAlert     *bool
+	}{
+		{desc: "empty log", at: 0},
+		{desc: "entries accepted", at: time.Second, next: 10},
+		{desc: "waiting, within maxAge", at: 10 * time.Second, next: 20},
+		{desc: "waiting, exceeds maxAge", at: 12 * time.Second, next: 30, wantAlert: ptr(true)},
+		{desc: "still stalled", at: 13 * time.Second, next: 30},
+		{desc: "caught up", at: 14 * time.Second, next: 30, published: 30, havePublished: true, wantAlert: ptr(false)},
+		{desc: "new entries", at: 15 * time.Second, next: 40, published: 30, havePublished: true},
+		{desc: "partially published, within maxAge", at: 24 * time.Second, next: 50, published: 40, havePublished: true},
+		{desc: "too many unpublished", at: 25 * time.Second, next: 141, published: 40, havePublished: true, wantAlert: ptr(true)},
+		{desc: "draining, within limits", at: 30 * time.Second, next: 141, published: 45, havePublished: true, wantAlert: ptr(false)},
+		// Entries accepted at 25s are still waiting, even though the published checkpoint keeps growing.
+		{desc: "draining slowly, exceeds maxAge", at: 36 * time.Second, next: 141, published: 50, havePublished: true, wantAlert: ptr(true)},
+		{desc: "caught up again", at: 37 * time.Second, next: 141, published: 141, havePublished: true, wantAlert: ptr(false)},
+	} {
+		alerts = nil
+		next, published, havePublished = step.next, step.published, step.havePublished
+		if err := w.check(t.Context(), start.Add(step.at)); err != nil {
+			t.Fatalf("%s: check: %v", step.desc, err)
+		}
+		switch {
+		case step.wantAlert == nil && len(alerts) > 0:
+			t.Errorf("%s: got unexpected alerts %+v", step.desc, alerts)
+		case step.wantAlert != nil && len(alerts) != 1:
+			t.Errorf("%s: got %d alerts, want 1", step.desc, len(alerts))
+		case step.wantAlert != nil && alerts[0].Stalled != *step.wantAlert:
+			t.Errorf("%s: got alert %+v, want Stalled=%t", step.desc, alerts[0], *step.wantAlert)
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// verifyStartupState checks that the state of the log held in storage is valid before the appender
+// accepts any writes.
+//
+// It verifies the signature on the persisted checkpoint, that the integrated tree covers at least the
+// checkpoint, and that the root hash computed from the stored tiles matches the checkpoint.
+// Additionally, the inclusion of auditSamples randomly selected entries in the checkpoint is verified.
+//
+// Errors wrapping ErrDiscrepancy indicate that the stored state is invalid; other errors indicate
+// that the state could not be read.
+func verifyStartupState(ctx context.Context, lr LogReader, origin string, v note.Verifier, auditSamples uint) error {
+	ctx, span := tracer.Start(ctx, "tessera.verifyStartupState")
+	defer span.End()
+
+	cpRaw, err := lr.ReadCheckpoint(ctx)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// A new log which hasn't yet published a checkpoint has nothing to verify.
+			return nil
+		}
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	cp, _, _, err := f_log.ParseCheckpoint(cpRaw, origin, v)
+	if err != nil {
+		return fmt.Errorf("%w: stored checkpoint cannot be verified with key %q: %v", ErrDiscrepancy, v.Name(), err)
+	}
+
+	integrated, err := lr.IntegratedSize(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read integrated size: %v", err)
+	}
+	if integrated < cp.Size {
+		return fmt.Errorf("%w: stored checkpoint has size %d, but only %d entries are integrated", ErrDiscrepancy, cp.Size, integrated)
+	}
+
+	if err := verifyRootHash(ctx, lr.ReadTile, cp); err != nil {
+		return err
+	}
+
+	if cp.Size > 0 && auditSamples > 0 {
+		pb, err := client.NewProofBuilder(ctx, cp.Size, lr.ReadTile)
+		if err != nil {
+			return fmt.Errorf("failed to create proof builder: %v", err)
+		}
+		m := NewSelfMonitor(lr, v, SelfMonitorOptions{Origin: origin})
+		for range auditSamples {
+			if err := m.checkInclusion(ctx, pb, cp, rand.Uint64N(cp.Size)); err != nil {
+				return err
+			}
+		}
+	}
+	klog.Infof("Verified stored log state at size %d", cp.Size)
+	return nil
+}
+
+// verifyRootHash checks that the root hash of the tree computed from the tiles returned by readTile
+// matches that committed to by cp.
+func verifyRootHash(ctx context.Context, readTile client.TileFetcherFunc, cp *f_log.Checkpoint) error {
+	root := rfc6962.DefaultHasher.EmptyRoot()
+	if cp.Size > 0 {
+		nodes, err := client.FetchRangeNodes(ctx, cp.Size, readTile)
+		if err != nil {
+			return fmt.Errorf("failed to fetch tree nodes for size %d: %v", cp.Size, err)
+		}
+		r, err := (&compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}).NewRange(0, cp.Size, nodes)
+		if err != nil {
+			return fmt.Errorf("%w: stored tiles are invalid for size %d: %v", ErrDiscrepancy, cp.Size, err)
+		}
+		if root, err = r.GetRootHash(nil); err != nil {
+			return fmt.Errorf("%w: failed to compute root hash from stored tiles for size %d: %v", ErrDiscrepancy, cp.Size, err)
+		}
+	}
+	if !bytes.Equal(root, cp.Hash) {
+		return fmt.Errorf("%w: root hash %x computed from stored tiles does not match stored checkpoint root hash %x at size %d", ErrDiscrepancy, root, cp.Hash, cp.Size)
+	}
+	return nil
+}
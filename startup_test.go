@@ -0,0 +1,192 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestStartupVerification(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	_, otherVKey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherV, err := note.NewVerifier(otherVKey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	dir := t.TempDir()
+	newAppender := func(v note.Verifier, auditSamples uint) (*tessera.Appender, tessera.LogReader, error) {
+		driver, err := posix.New(ctx, dir)
+		if err != nil {
+			t.Fatalf("posix.New: %v", err)
+		}
+		opts := tessera.NewAppendOptions().WithCheckpointSigner(s).WithCheckpointInterval(time.Second)
+		if v != nil {
+			opts.WithStartupVerification(v, auditSamples)
+		}
+		a, _, r, err := tessera.NewAppender(ctx, driver, opts)
+		return a, r, err
+	}
+
+	// A fresh log has no state to verify.
+	a, r, err := newAppender(v, 5)
+	if err != nil {
+		t.Fatalf("NewAppender on new log: %v", err)
+	}
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	futures := make([]tessera.IndexFuture, 0, 300)
+	for i := range cap(futures) {
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for _, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+	}
+
+	if _, _, err := newAppender(v, 5); err != nil {
+		t.Fatalf("NewAppender with valid state: %v", err)
+	}
+	if _, _, err := newAppender(otherV, 0); !errors.Is(err, tessera.ErrDiscrepancy) {
+		t.Fatalf("NewAppender with wrong verifier: got err %v, want %v", err, tessera.ErrDiscrepancy)
+	}
+
+	// Corrupting the entry bundles is only detected by the sampled audit.
+	corrupt(t, filepath.Join(dir, "tile", "entries"))
+	if _, _, err := newAppender(v, 0); err != nil {
+		t.Fatalf("NewAppender with corrupt entries and no audit: %v", err)
+	}
+	if _, _, err := newAppender(v, 5); !errors.Is(err, tessera.ErrDiscrepancy) {
+		t.Fatalf("NewAppender with corrupt entries: got err %v, want %v", err, tessera.ErrDiscrepancy)
+	}
+
+	corrupt(t, filepath.Join(dir, "tile", "0"))
+	if _, _, err := newAppender(v, 0); !errors.Is(err, tessera.ErrDiscrepancy) {
+		t.Fatalf("NewAppender with corrupt tiles: got err %v, want %v", err, tessera.ErrDiscrepancy)
+	}
+	if _, _, err := newAppender(nil, 0); err != nil {
+		t.Fatalf("NewAppender without verification: %v", err)
+	}
+}
+
+func TestStartupVerificationFailureStopsDriver(t *testing.T) {
+	ctx := t.Context()
+	skey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	_, otherVKey, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherV, err := note.NewVerifier(otherVKey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	dir := t.TempDir()
+	newAppender := func(opts *tessera.AppendOptions) (*ctxRecordingDriver, error) {
+		driver, err := posix.New(ctx, dir)
+		if err != nil {
+			t.Fatalf("posix.New: %v", err)
+		}
+		d := &ctxRecordingDriver{d: driver}
+		_, _, _, err = tessera.NewAppender(ctx, d, opts.WithCheckpointSigner(s).WithCheckpointInterval(time.Second))
+		return d, err
+	}
+	// Create the log, so that there's a checkpoint to verify.
+	d, err := newAppender(tessera.NewAppendOptions())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	if err := d.ctx.Err(); err != nil {
+		t.Fatalf("Driver context of running appender: got %v, want nil", err)
+	}
+
+	d, err = newAppender(tessera.NewAppendOptions().WithStartupVerification(otherV, 0))
+	if !errors.Is(err, tessera.ErrDiscrepancy) {
+		t.Fatalf("NewAppender with wrong verifier: got err %v, want %v", err, tessera.ErrDiscrepancy)
+	}
+	if err := d.ctx.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Driver context after failed verification: got %v, want %v", err, context.Canceled)
+	}
+}
+
+// ctxRecordingDriver wraps a driver, recording the context in which its background tasks run.
+type ctxRecordingDriver struct {
+	d   tessera.Driver
+	ctx context.Context
+}
+
+func (r *ctxRecordingDriver) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
+	r.ctx = ctx
+	return r.d.(interface {
+		Appender(context.Context, *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error)
+	}).Appender(ctx, opts)
+}
+
+// corrupt flips a bit in every byte of every file under root.
+func corrupt(t *testing.T, root string) {
+	t.Helper()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for i := range b {
+			b[i] ^= 0x01
+		}
+		return os.WriteFile(path, b, 0o644)
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt %q: %v", root, err)
+	}
+}
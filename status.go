@@ -0,0 +1,102 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AppenderStatus describes the work which is outstanding for an Appender and its log.
+//
+// It's intended to drive horizontal autoscaling and load-shedding decisions, e.g. scaling out
+// personality instances while entries are queueing faster than they can be sequenced.
+type AppenderStatus struct {
+	// Queued is the number of entries which have been added via this Appender, but not yet sequenced.
+	// This only counts entries added in this process.
+	Queued uint64 `json:"queued"`
+	// Unintegrated is the number of entries which have been sequenced, by any Appender for the log, but
+	// not yet integrated into the tree.
+	Unintegrated uint64 `json:"unintegrated"`
+	// CheckpointAge is the time since the latest checkpoint was published. It's zero if no checkpoint
+	// has been published yet, or if the storage driver doesn't record when checkpoints are published.
+	CheckpointAge time.Duration `json:"-"`
+}
+
+// Status returns the current status of the Appender and its log.
+//
+// This is only available for Appenders returned by NewAppender.
+func (a *Appender) Status(ctx context.Context) (AppenderStatus, error) {
+	if a.reader == nil {
+		return AppenderStatus{}, errors.New("status is only available for Appenders created by NewAppender")
+	}
+	var s AppenderStatus
+	if a.Queued != nil {
+		s.Queued = a.Queued()
+	}
+	// The integrated size is read first so that a concurrent integration can't make it appear to be
+	// larger than the next index.
+	size, err := a.reader.IntegratedSize(ctx)
+	if err != nil {
+		return AppenderStatus{}, fmt.Errorf("IntegratedSize: %w", err)
+	}
+	next, err := a.reader.NextIndex(ctx)
+	if err != nil {
+		return AppenderStatus{}, fmt.Errorf("NextIndex: %w", err)
+	}
+	if next > size {
+		s.Unintegrated = next - size
+	}
+	if m, ok := a.reader.(CheckpointModTimeReader); ok {
+		t, err := m.ReadCheckpointModTime(ctx)
+		switch {
+		case err == nil:
+			s.CheckpointAge = time.Since(t)
+		case !errors.Is(err, ErrNotFound):
+			return AppenderStatus{}, fmt.Errorf("ReadCheckpointModTime: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// statusResponse is the JSON representation of an AppenderStatus served by NewStatusHandler.
+type statusResponse struct {
+	AppenderStatus
+	CheckpointAgeSeconds float64 `json:"checkpoint_age_seconds"`
+}
+
+// NewStatusHandler returns an http.Handler which serves the Appender's status as a JSON object at
+// GET /status, e.g. for polling by an autoscaler:
+//
+//	{"queued": 12, "unintegrated": 256, "checkpoint_age_seconds": 1.5}
+//
+// The handler exposes operational details of the log, so personalities may prefer to serve it on
+// an internal listener.
+func NewStatusHandler(a *Appender) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		s, err := a.Status(r.Context())
+		if err != nil {
+			writeError(w, "/status", err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, "/status", statusResponse{AppenderStatus: s, CheckpointAgeSeconds: s.CheckpointAge.Seconds()})
+	})
+	return mux
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestAppenderStatus(t *testing.T) {
+	ctx := t.Context()
+	skey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	// Batches are only flushed once full, so that entries can be seen queueing.
+	a, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(3, time.Hour))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	var fs []tessera.IndexFuture
+	for i := range 2 {
+		fs = append(fs, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	st, err := a.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if st.Queued != 2 {
+		t.Errorf("Status() with partial batch: got Queued %d, want 2", st.Queued)
+	}
+
+	fs = append(fs, a.Add(ctx, tessera.NewEntry([]byte("entry 2"))))
+	for _, f := range fs {
+		if _, err := f(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	srv := httptest.NewServer(tessera.NewStatusHandler(a))
+	defer srv.Close()
+	rsp, err := http.Get(srv.URL + "/status")
+	if err != nil {
+		t.Fatalf("GET /status: %v", err)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /status: got status %d, want %d", rsp.StatusCode, http.StatusOK)
+	}
+	got := map[string]float64{}
+	if err := json.NewDecoder(rsp.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// The POSIX driver integrates entries as it sequences them, so nothing is outstanding.
+	if got["queued"] != 0 || got["unintegrated"] != 0 {
+		t.Errorf("GET /status after flush: got %v, want nothing queued or unintegrated", got)
+	}
+	if age, ok := got["checkpoint_age_seconds"]; !ok || age < 0 || age > 60 {
+		t.Errorf("GET /status: got checkpoint_age_seconds %v, want a recent checkpoint", age)
+	}
+
+	if _, err := (&tessera.Appender{}).Status(ctx); err == nil {
+		t.Error("Status() on Appender not created by NewAppender: got nil error, want error")
+	}
+}
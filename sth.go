@@ -0,0 +1,131 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// SignedTreeHead is an RFC 6962 Signed Tree Head, as returned by the get-sth endpoint described in
+// RFC 6962 s4.3.
+type SignedTreeHead struct {
+	TreeSize       uint64 `json:"tree_size"`
+	Timestamp      uint64 `json:"timestamp"`
+	SHA256RootHash []byte `json:"sha256_root_hash"`
+	// TreeHeadSignature is the TLS encoded DigitallySigned struct over the output of SignatureInput.
+	TreeHeadSignature []byte `json:"tree_head_signature"`
+}
+
+// SignatureInput returns the TLS encoded TreeHeadSignature struct from RFC 6962 s3.5 which is covered
+// by the STH's signature.
+func (s SignedTreeHead) SignatureInput() []byte {
+	// Version v1 (0), SignatureType tree_hash (1), timestamp, tree_size, sha256_root_hash.
+	b := []byte{0, 1}
+	b = binary.BigEndian.AppendUint64(b, s.Timestamp)
+	b = binary.BigEndian.AppendUint64(b, s.TreeSize)
+	return append(b, s.SHA256RootHash...)
+}
+
+// CheckpointToSTH verifies the RFC 6962 signature on a checkpoint, e.g. one signed with a signer created
+// via NewRFC6962Signer, and returns the equivalent Signed Tree Head.
+//
+// v must verify the log's STH signatures, e.g. a verifier created with NewRFC6962Verifier from
+// github.com/transparency-dev/formats/note, and its name must be the checkpoint's origin.
+func CheckpointToSTH(cp []byte, v note.Verifier) (*SignedTreeHead, error) {
+	c, _, n, err := client.OpenCheckpoint(cp, v.Name(), v)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range n.Sigs {
+		if s.Name != v.Name() || s.Hash != v.KeyHash() {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(s.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature encoding: %v", err)
+		}
+		// The note signature is the key hash, followed by the STH timestamp, followed by the TLS encoded
+		// DigitallySigned struct which is the STH signature itself.
+		if len(sig) < 4+8+1 {
+			return nil, fmt.Errorf("signature of %d bytes is too short", len(sig))
+		}
+		return &SignedTreeHead{
+			TreeSize:          c.Size,
+			Timestamp:         binary.BigEndian.Uint64(sig[4:12]),
+			SHA256RootHash:    c.Hash,
+			TreeHeadSignature: sig[12:],
+		}, nil
+	}
+	return nil, errors.New("checkpoint has no STH signature")
+}
+
+// STHToCheckpoint returns a checkpoint which commits to the same tree as sth, carrying its signature.
+//
+// logURL is the CT log's submission prefix, from which the checkpoint origin is derived as for
+// NewRFC6962Signer, and pub is the log's public key, which is used to verify sth before conversion.
+// Any cosigners, e.g. witnesses, add their own signatures to the returned checkpoint.
+//
+// Only logs with ECDSA keys are supported.
+func STHToCheckpoint(sth *SignedTreeHead, logURL string, pub crypto.PublicKey, cosigners ...note.Signer) ([]byte, error) {
+	k, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	if len(sth.SHA256RootHash) != sha256.Size {
+		return nil, fmt.Errorf("root hash is %d bytes, want %d", len(sth.SHA256RootHash), sha256.Size)
+	}
+	// DigitallySigned, see RFC 5246 s4.7: SHA-256 (4), ECDSA (3), and a 16 bit length prefixed signature.
+	ds := sth.TreeHeadSignature
+	if len(ds) < 4 || ds[0] != 4 || ds[1] != 3 || int(binary.BigEndian.Uint16(ds[2:4])) != len(ds)-4 {
+		return nil, errors.New("tree head signature is not a valid ECDSA with SHA-256 DigitallySigned struct")
+	}
+	dgst := sha256.Sum256(sth.SignatureInput())
+	if !ecdsa.VerifyASN1(k, dgst[:], ds[4:]) {
+		return nil, errors.New("invalid tree head signature")
+	}
+
+	origin, err := rfc6962Origin(logURL)
+	if err != nil {
+		return nil, err
+	}
+	keyHash, err := rfc6962KeyHash(origin, pub)
+	if err != nil {
+		return nil, err
+	}
+	body := fmt.Sprintf("%s\n%d\n%s\n", origin, sth.TreeSize, base64.StdEncoding.EncodeToString(sth.SHA256RootHash))
+	// The STH has already been signed, so the note signature is assembled from its parts rather than
+	// being created here.
+	s := &signer{
+		name:    origin,
+		keyHash: keyHash,
+		sign: func(msg []byte) ([]byte, error) {
+			if !bytes.Equal(msg, []byte(body)) {
+				return nil, errors.New("STH signature does not cover message")
+			}
+			return append(binary.BigEndian.AppendUint64(nil, sth.Timestamp), ds...), nil
+		},
+	}
+	return note.Sign(&note.Note{Text: body}, append([]note.Signer{s}, cosigners...)...)
+}
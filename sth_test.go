@@ -0,0 +1,100 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestSTHConversion(t *testing.T) {
+	const logURL = "https://ct.example.com/2025h2/"
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := NewRFC6962Signer(logURL, k)
+	if err != nil {
+		t.Fatalf("NewRFC6962Signer: %v", err)
+	}
+	vkey, err := f_note.RFC6962VerifierString(logURL, k.Public())
+	if err != nil {
+		t.Fatalf("RFC6962VerifierString: %v", err)
+	}
+	v, err := f_note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	cp := signTestCheckpoint(t, NewAppendOptions().WithCheckpointSigner(s))
+	sth, err := CheckpointToSTH(cp, v)
+	if err != nil {
+		t.Fatalf("CheckpointToSTH: %v", err)
+	}
+	root := sha256.Sum256([]byte("root"))
+	if sth.TreeSize != 10 || !bytes.Equal(sth.SHA256RootHash, root[:]) {
+		t.Errorf("CheckpointToSTH: got size %d root %x, want 10 %x", sth.TreeSize, sth.SHA256RootHash, root)
+	}
+	dgst := sha256.Sum256(sth.SignatureInput())
+	if !ecdsa.VerifyASN1(&k.PublicKey, dgst[:], sth.TreeHeadSignature[4:]) {
+		t.Error("STH signature does not verify")
+	}
+
+	// Without cosigners, the round trip must reproduce the original checkpoint exactly.
+	got, err := STHToCheckpoint(sth, logURL, &k.PublicKey)
+	if err != nil {
+		t.Fatalf("STHToCheckpoint: %v", err)
+	}
+	if !bytes.Equal(got, cp) {
+		t.Errorf("STHToCheckpoint: got %q, want %q", got, cp)
+	}
+
+	wsk, wvk, err := note.GenerateKey(rand.Reader, "witness")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	ws, err := note.NewSigner(wsk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	wv, err := note.NewVerifier(wvk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	cosigned, err := STHToCheckpoint(sth, logURL, &k.PublicKey, ws)
+	if err != nil {
+		t.Fatalf("STHToCheckpoint: %v", err)
+	}
+	n, err := note.Open(cosigned, note.VerifierList(v, wv))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(n.Sigs) != 2 {
+		t.Errorf("Open: got %d verified signatures, want 2", len(n.Sigs))
+	}
+
+	tampered := *sth
+	tampered.TreeSize++
+	if _, err := STHToCheckpoint(&tampered, logURL, &k.PublicKey); err == nil {
+		t.Error("STHToCheckpoint: got nil error for STH with invalid signature")
+	}
+}
@@ -95,13 +95,6 @@ type AntispamStorage struct {
 //
 // This functionality is experimental!
 func NewAntispam(ctx context.Context, dsn string, opts AntispamOpts) (*AntispamStorage, error) {
-	if opts.MaxBatchSize == 0 {
-		opts.MaxBatchSize = DefaultMaxBatchSize
-	}
-	if opts.PushbackThreshold == 0 {
-		opts.PushbackThreshold = DefaultPushbackThreshold
-	}
-
 	dbPool, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MySQL db: %v", err)
@@ -117,10 +110,27 @@ func NewAntispam(ctx context.Context, dsn string, opts AntispamOpts) (*AntispamS
 	if err := dbPool.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping MySQL db: %v", err)
 	}
+	return NewAntispamFromDB(ctx, dbPool, opts)
+}
+
+// NewAntispamFromDB is like NewAntispam, but uses an existing MySQL connection pool.
+//
+// This allows a log stored in MySQL, e.g. using the storage/mysql driver, to keep its antispam tables in the
+// same database, sharing the pool's connections with the log itself. The pool's size is left for the caller to
+// manage, so the MaxOpenConns and MaxIdleConns options are ignored.
+//
+// This functionality is experimental!
+func NewAntispamFromDB(ctx context.Context, db *sql.DB, opts AntispamOpts) (*AntispamStorage, error) {
+	if opts.MaxBatchSize == 0 {
+		opts.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if opts.PushbackThreshold == 0 {
+		opts.PushbackThreshold = DefaultPushbackThreshold
+	}
 
 	r := &AntispamStorage{
 		opts:   opts,
-		dbPool: dbPool,
+		dbPool: db,
 	}
 
 	if err := r.initDB(ctx); err != nil {
@@ -195,11 +205,27 @@ func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error)
 	var idx uint64
 	if err := row.Scan(&idx); err == sql.ErrNoRows {
 		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
 	d.numHits.Add(1)
 	return &idx, nil
 }
 
+// LookupIndex returns the index of the earliest entry with the provided identity hash seen by the follower.
+//
+// This implements tessera.IndexLookup.
+func (d *AntispamStorage) LookupIndex(ctx context.Context, identityHash []byte) (uint64, error) {
+	idx, err := d.index(ctx, identityHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up identity hash: %v", err)
+	}
+	if idx == nil {
+		return 0, fmt.Errorf("identity hash %x: %w", identityHash, tessera.ErrNotFound)
+	}
+	return *idx, nil
+}
+
 // Decorator returns a function which will wrap an underlying Add delegate with
 // code to dedup against the stored data.
 func (d *AntispamStorage) Decorator() func(f tessera.AddFn) tessera.AddFn {
@@ -48,8 +48,37 @@ func TestAntispam(t *testing.T) {
 		klog.Warningf("MySQL not available, skipping %s", t.Name())
 		t.Skip("MySQL not available, skipping test")
 	}
+	for _, test := range []struct {
+		name        string
+		newAntispam func(context.Context) (*aws.AntispamStorage, error)
+	}{
+		{
+			name: "DSN",
+			newAntispam: func(ctx context.Context) (*aws.AntispamStorage, error) {
+				return aws.NewAntispam(ctx, *mySQLURI, aws.AntispamOpts{})
+			},
+		}, {
+			name: "existing pool",
+			newAntispam: func(ctx context.Context) (*aws.AntispamStorage, error) {
+				db, err := sql.Open("mysql", *mySQLURI)
+				if err != nil {
+					return nil, err
+				}
+				t.Cleanup(func() { _ = db.Close() })
+				return aws.NewAntispamFromDB(ctx, db, aws.AntispamOpts{})
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testAntispam(t, test.newAntispam)
+		})
+	}
+}
+
+func testAntispam(t *testing.T, newAntispam func(context.Context) (*aws.AntispamStorage, error)) {
+	ctx := t.Context()
 	mustDropTables(t, ctx)
-	antispam, err := aws.NewAntispam(ctx, *mySQLURI, aws.AntispamOpts{})
+	antispam, err := newAntispam(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -38,9 +38,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -55,6 +55,7 @@ import (
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/otel"
 	"github.com/transparency-dev/tessera/internal/stream"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"golang.org/x/sync/errgroup"
@@ -70,8 +71,18 @@ const (
 	ckptCacheControl      = "no-cache"
 	minCheckpointInterval = time.Second
 
-	DefaultPushbackMaxOutstanding = 4096
-	DefaultIntegrationSizeLimit   = 5 * 4096
+	// defaultIntegrationTimeout is the default timeout for each integration pass, used unless
+	// overridden with tessera.AppendOptions.WithOperationTimeout.
+	defaultIntegrationTimeout = 10 * time.Second
+
+	// DefaultPushbackMaxOutstanding is the default number of outstanding entries before pushback is applied.
+	//
+	// Deprecated: Use tessera.AppendOptions.WithPushbackMaxOutstanding to configure this instead.
+	DefaultPushbackMaxOutstanding = tessera.DefaultPushbackMaxOutstanding
+	// DefaultIntegrationSizeLimit is the default maximum number of entries which will be integrated in one pass.
+	//
+	// Deprecated: Use tessera.AppendOptions.WithIntegrationBatchSize to configure this instead.
+	DefaultIntegrationSizeLimit = tessera.DefaultIntegrationBatchSize
 
 	// SchemaCompatibilityVersion represents the expected version (e.g. layout & serialisation) of stored data.
 	//
@@ -86,13 +97,30 @@ const (
 // Storage is an AWS based storage implementation for Tessera.
 type Storage struct {
 	cfg Config
+	// driverOpts holds settings common to all storage drivers.
+	driverOpts tessera.DriverOptions
+	// objStore, if set, is used in place of the S3 bucket described by cfg. This allows tests to run
+	// the driver against in-memory storage.
+	objStore objStore
+}
+
+// Option is a function which configures optional behaviour of the AWS Storage.
+type Option func(*Storage)
+
+// WithDriverOptions applies settings which are common to all storage drivers.
+func WithDriverOptions(opts ...tessera.DriverOption) Option {
+	return func(s *Storage) {
+		s.driverOpts.Apply(opts...)
+	}
 }
 
 // objStore describes a type which can store and retrieve objects.
 type objStore interface {
 	getObject(ctx context.Context, obj string) ([]byte, error)
-	setObject(ctx context.Context, obj string, data []byte, contType string, cacheControl string) error
-	setObjectIfNoneMatch(ctx context.Context, obj string, data []byte, contType string, cacheControl string) error
+	getObjectReader(ctx context.Context, obj string) (io.ReadCloser, error)
+	getObjectRangeReader(ctx context.Context, obj string, offset, length int64) (io.ReadCloser, int64, error)
+	setObject(ctx context.Context, obj string, data []byte, md tessera.ObjectMetadata) error
+	setObjectIfNoneMatch(ctx context.Context, obj string, data []byte, md tessera.ObjectMetadata) error
 	lastModified(ctx context.Context, obj string) (time.Time, error)
 }
 
@@ -141,15 +169,56 @@ type Config struct {
 	DSN string
 	// Maximum connections to the MySQL database.
 	MaxOpenConns int
+	// AdaptiveConns enables adapting the maximum number of connections to the MySQL database based on
+	// observed connection wait times and utilisation, within the range [MinOpenConns, MaxOpenConns].
+	//
+	// MaxOpenConns must be set if this is true.
+	AdaptiveConns bool
+	// MinOpenConns is the lowest maximum number of connections to the MySQL database that the pool will be
+	// adapted down to when AdaptiveConns is set.
+	MinOpenConns int
 	// Maximum idle database connections in the connection pool.
 	MaxIdleConns int
 }
 
+// validate checks that the Config is self-consistent, returning an error describing every problem found.
+func (c Config) validate() error {
+	var errs []error
+	switch {
+	case c.Bucket == "":
+		errs = append(errs, errors.New("Bucket must be set"))
+	case strings.Contains(c.Bucket, "/"):
+		errs = append(errs, fmt.Errorf("Bucket %q must be a bare bucket name without an s3:// scheme or path; use BucketPrefix to place the log under a path", c.Bucket))
+	}
+	if strings.HasPrefix(c.BucketPrefix, "/") || strings.HasSuffix(c.BucketPrefix, "/") {
+		errs = append(errs, fmt.Errorf("BucketPrefix %q must not start or end with /", c.BucketPrefix))
+	}
+	if c.DSN == "" {
+		errs = append(errs, errors.New("DSN must be set"))
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("MaxOpenConns (%d) and MaxIdleConns (%d) must not be negative", c.MaxOpenConns, c.MaxIdleConns))
+	}
+	if c.AdaptiveConns && (c.MaxOpenConns <= 0 || c.MinOpenConns < 0 || c.MinOpenConns > c.MaxOpenConns) {
+		errs = append(errs, fmt.Errorf("AdaptiveConns requires 0 <= MinOpenConns <= MaxOpenConns, and MaxOpenConns > 0, got [%d, %d]", c.MinOpenConns, c.MaxOpenConns))
+	}
+	if (c.SDKConfig == nil) != (c.S3Options == nil) {
+		errs = append(errs, errors.New("SDKConfig and S3Options must either both be set or both be nil"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid aws.Config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
 // New creates a new instance of the AWS based Storage.
 //
 // Storage instances created via this c'tor will participate in integrating newly sequenced entries into the log
 // and periodically publishing a new checkpoint which commits to the state of the tree.
-func New(ctx context.Context, cfg Config) (tessera.Driver, error) {
+func New(ctx context.Context, cfg Config, opts ...Option) (tessera.Driver, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 	if cfg.SDKConfig == nil {
 		// We're running on AWS so use the SDK's default config which will will handle credentials etc.
 		sdkConfig, err := config.LoadDefaultConfig(ctx)
@@ -164,32 +233,39 @@ func New(ctx context.Context, cfg Config) (tessera.Driver, error) {
 		printDragonsWarning()
 	}
 
-	return &Storage{
+	s := &Storage{
 		cfg: cfg,
-	}, nil
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
 }
 
 func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
 	pb := uint64(opts.PushbackMaxOutstanding())
 	if pb == 0 {
-		pb = DefaultPushbackMaxOutstanding
+		pb = tessera.DefaultPushbackMaxOutstanding
 	}
 	if opts.CheckpointInterval() < minCheckpointInterval {
 		return nil, nil, fmt.Errorf("requested CheckpointInterval (%v) is less than minimum permitted %v", opts.CheckpointInterval(), minCheckpointInterval)
 	}
 
-	seq, err := newMySQLSequencer(ctx, s.cfg.DSN, pb, s.cfg.MaxOpenConns, s.cfg.MaxIdleConns)
+	seq, err := newMySQLSequencer(ctx, s.cfg.DSN, pb, s.cfg.MaxOpenConns, s.cfg.MaxIdleConns, !opts.ReadOnly())
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create MySQL sequencer: %v", err)
 	}
+	s.monitorDBPool(ctx, seq.dbPool)
+	if !opts.ReadOnly() {
+		if err := seq.ensureIdentity(ctx, opts.LogIdentity()); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	logStore := &logResourceStore{
-		objStore: &s3Storage{
-			s3Client:     s3.NewFromConfig(*s.cfg.SDKConfig, s.cfg.S3Options),
-			bucket:       s.cfg.Bucket,
-			bucketPrefix: s.cfg.BucketPrefix,
-		},
+		objStore:    s.newObjStore(),
 		entriesPath: opts.EntriesPath(),
+		driverOpts:  s.driverOpts,
 		integratedSize: func(context.Context) (uint64, error) {
 			s, _, err := seq.currentTree(ctx)
 			return s, err
@@ -197,14 +273,29 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 		nextIndex: func(context.Context) (uint64, error) {
 			return seq.nextIndex(ctx)
 		},
+		readTimeout: opts.OperationTimeout(tessera.OperationRead),
+		metrics:     storage.NewMetrics(opts.MetricsProvider(), "aws"),
+	}
+	if opts.ReadOnly() {
+		// Skip initialisation and the integration & publishing tasks; NewAppender supplies Add in this mode.
+		return &tessera.Appender{}, logStore, nil
 	}
 	r := &Appender{
-		logStore:    logStore,
-		sequencer:   seq,
-		newCP:       opts.CheckpointPublisher(logStore, http.DefaultClient),
-		treeUpdated: make(chan struct{}),
+		logStore:        logStore,
+		sequencer:       seq,
+		newCP:           opts.CheckpointPublisher(logStore, s.driverOpts.HTTPClient()),
+		notifyPublished: opts.CheckpointNotifier(),
+		treeUpdated:     make(chan struct{}),
+
+		integrationParallelism: int(opts.IntegrationParallelism()),
+		integrationBatchSize:   uint64(opts.IntegrationBatchSize()),
+		integrationTimeout:     defaultIntegrationTimeout,
 	}
-	r.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), r.sequencer.assignEntries)
+	if d := opts.OperationTimeout(tessera.OperationIntegrate); d > 0 {
+		r.integrationTimeout = d
+	}
+	r.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.FlushWithTimeout(opts.OperationTimeout(tessera.OperationSequence), r.sequencer.assignEntries),
+		storage.WithPriority(opts.PriorityFunc()), storage.WithMetrics(logStore.metrics))
 
 	if err := r.init(ctx); err != nil {
 		return nil, nil, fmt.Errorf("failed to initialise log storage: %v", err)
@@ -217,19 +308,30 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 	go r.publishCheckpointTask(ctx, opts.CheckpointInterval())
 
 	return &tessera.Appender{
-		Add: r.Add,
+		Add:      r.Add,
+		AddBatch: r.queue.AddBatch,
+		Queued:   r.queue.Pending,
+		Flush:    r.queue.Flush,
 	}, r.logStore, nil
 }
 
 // Appender is an implementation of the Tessera appender lifecycle contract.
 type Appender struct {
-	newCP func(context.Context, uint64, []byte) ([]byte, error)
+	newCP           func(context.Context, uint64, []byte) ([]byte, error)
+	notifyPublished func(tessera.PublishedCheckpoint)
 
 	sequencer sequencer
 	logStore  *logResourceStore
 
 	queue *storage.Queue
 
+	// integrationParallelism is the maximum number of concurrent writes performed while integrating entries.
+	integrationParallelism int
+	// integrationBatchSize is the maximum number of sequenced entries which will be integrated in one pass.
+	integrationBatchSize uint64
+	// integrationTimeout bounds the duration of each integration pass.
+	integrationTimeout time.Duration
+
 	treeUpdated chan struct{}
 }
 
@@ -248,10 +350,10 @@ func (a *Appender) consumeEntriesTask(ctx context.Context) {
 
 		func() {
 			// Don't quickloop for now, it causes issues updating checkpoint too frequently.
-			cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			cctx, cancel := context.WithTimeout(ctx, a.integrationTimeout)
 			defer cancel()
 
-			if _, err := a.sequencer.consumeEntries(cctx, DefaultIntegrationSizeLimit, a.appendEntries, false); err != nil {
+			if _, err := a.sequencer.consumeEntries(cctx, a.integrationBatchSize, a.appendEntries, false); err != nil {
 				klog.Errorf("integrate: %v", err)
 				return
 			}
@@ -296,9 +398,9 @@ func (a *Appender) init(ctx context.Context) error {
 			// No checkpoint exists, do a forced (possibly empty) integration to create one in a safe
 			// way (calling updateCP directly here would not be safe as it's outside the transactional
 			// framework which prevents the tree from rolling backwards or otherwise forking).
-			cctx, c := context.WithTimeout(ctx, 10*time.Second)
+			cctx, c := context.WithTimeout(ctx, a.integrationTimeout)
 			defer c()
-			if _, err := a.sequencer.consumeEntries(cctx, DefaultIntegrationSizeLimit, a.appendEntries, true); err != nil {
+			if _, err := a.sequencer.consumeEntries(cctx, a.integrationBatchSize, a.appendEntries, true); err != nil {
 				return fmt.Errorf("forced integrate: %v", err)
 			}
 			select {
@@ -314,6 +416,9 @@ func (a *Appender) init(ctx context.Context) error {
 }
 
 func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Duration) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.publishCheckpoint")
+	defer span.End()
+
 	m, err := a.logStore.checkpointLastModified(ctx)
 	// Do not use errors.Is. Keep errors.As to compare by type and not by value.
 	var nske *types.NoSuchKey
@@ -321,9 +426,11 @@ func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 		return fmt.Errorf("checkpointLastModified(): %v", err)
 	}
 	if time.Since(m) < minStaleness {
+		span.AddEvent("Abort, too soon")
 		return nil
 	}
 
+	start := time.Now()
 	size, root, err := a.sequencer.currentTree(ctx)
 	if err != nil {
 		return fmt.Errorf("currentTree: %v", err)
@@ -336,6 +443,8 @@ func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 	if err := a.logStore.setCheckpoint(ctx, cpRaw); err != nil {
 		return fmt.Errorf("writeCheckpoint: %v", err)
 	}
+	a.logStore.metrics.CheckpointPublished(ctx, start)
+	a.notifyPublished(tessera.PublishedCheckpoint{Size: size, Root: root, Checkpoint: cpRaw})
 
 	klog.V(2).Infof("Published latest checkpoint: %d, %x", size, root)
 
@@ -346,12 +455,23 @@ func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 //
 // Returns the new root hash of the log with the entries added.
 func (a *Appender) appendEntries(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.appendEntries")
+	defer span.End()
+	span.SetAttributes(fromSizeKey.Int64(otel.Clamp64(fromSeq)), numEntriesKey.Int(len(entries)))
+
 	var newRoot []byte
 
+	// Entry bundles and tiles are written out with bounded parallelism as soon as they're ready,
+	// rather than waiting for the whole batch to be processed.
+	writes, wCtx := errgroup.WithContext(ctx)
+	if a.integrationParallelism > 0 {
+		writes.SetLimit(a.integrationParallelism)
+	}
+
 	errG := errgroup.Group{}
 
 	errG.Go(func() error {
-		if err := a.updateEntryBundles(ctx, fromSeq, entries); err != nil {
+		if err := a.updateEntryBundles(wCtx, fromSeq, entries, writes); err != nil {
 			return fmt.Errorf("updateEntryBundles: %v", err)
 		}
 		return nil
@@ -362,7 +482,7 @@ func (a *Appender) appendEntries(ctx context.Context, fromSeq uint64, entries []
 		for i, e := range entries {
 			lh[i] = e.LeafHash
 		}
-		r, err := integrate(ctx, fromSeq, lh, a.logStore)
+		r, err := integrate(wCtx, fromSeq, lh, a.logStore, writes)
 		if err != nil {
 			return fmt.Errorf("integrate: %v", err)
 		}
@@ -370,40 +490,45 @@ func (a *Appender) appendEntries(ctx context.Context, fromSeq uint64, entries []
 		return nil
 	})
 
+	// Always wait for any scheduled writes to complete, even if we've failed.
 	err := errG.Wait()
+	if wErr := writes.Wait(); err == nil {
+		err = wErr
+	}
 	return newRoot, err
 }
 
 // updateEntryBundles adds the entries being integrated into the entry bundles.
 //
 // The right-most bundle will be grown, if it's partial, and/or new bundles will be created as required.
-func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry) error {
+// Writes of the updated bundles are scheduled on the provided errgroup, and callers must Wait on it.
+func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry, writes *errgroup.Group) error {
 	if len(entries) == 0 {
 		return nil
 	}
 
-	numAdded := uint64(0)
-	bundleIndex, entriesInBundle := fromSeq/layout.EntryBundleWidth, fromSeq%layout.EntryBundleWidth
-	bundleWriter := &bytes.Buffer{}
-	if entriesInBundle > 0 {
+	var part []byte
+	if p := uint8(fromSeq % layout.EntryBundleWidth); p > 0 {
 		// If the latest bundle is partial, we need to read the data it contains in for our newer, larger, bundle.
-		part, err := a.logStore.getEntryBundle(ctx, uint64(bundleIndex), uint8(entriesInBundle))
-		if err != nil {
+		var err error
+		if part, err = a.logStore.getEntryBundle(ctx, fromSeq/layout.EntryBundleWidth, p); err != nil {
 			return err
 		}
-
-		if _, err := bundleWriter.Write(part); err != nil {
-			return fmt.Errorf("bundleWriter: %v", err)
-		}
+	}
+	// Bundle buffers are pooled to reduce allocations, and are returned to the pool once written.
+	bundleWriter := storage.GetBuffer()
+	bundle, err := api.NewEntryBundleBuilder(fromSeq, part, bundleWriter)
+	if err != nil {
+		storage.PutBuffer(bundleWriter)
+		return err
 	}
 
-	seqErr := errgroup.Group{}
-
-	// goSetEntryBundle is a function which uses seqErr to spin off a go-routine to write out an entry bundle.
+	// goSetEntryBundle is a function which uses writes to spin off a go-routine to write out an entry bundle.
 	// It's used in the for loop below.
-	goSetEntryBundle := func(ctx context.Context, bundleIndex uint64, p uint8, bundleRaw []byte) {
-		seqErr.Go(func() error {
-			if err := a.logStore.setEntryBundle(ctx, bundleIndex, p, bundleRaw); err != nil {
+	goSetEntryBundle := func(ctx context.Context, bundleIndex uint64, p uint8, bundle *bytes.Buffer) {
+		writes.Go(func() error {
+			defer storage.PutBuffer(bundle)
+			if err := a.logStore.setEntryBundle(ctx, bundleIndex, p, bundle.Bytes()); err != nil {
 				return err
 			}
 			return nil
@@ -412,47 +537,67 @@ func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entri
 
 	// Add new entries to the bundle
 	for _, e := range entries {
-		if _, err := bundleWriter.Write(e.BundleData); err != nil {
-			return fmt.Errorf("bundlewriter.Write: %v", err)
+		if err := bundle.AppendSerialised(e.BundleData); err != nil {
+			return fmt.Errorf("bundle.AppendSerialised: %v", err)
 		}
-		entriesInBundle++
-		fromSeq++
-		numAdded++
-		if entriesInBundle == layout.EntryBundleWidth {
+		if bundle.Full() {
 			//  This bundle is full, so we need to write it out...
-			klog.V(1).Infof("In-memory bundle idx %d is full, attempting write to S3", bundleIndex)
-			goSetEntryBundle(ctx, bundleIndex, 0, bundleWriter.Bytes())
-			// ... and prepare the next entry bundle for any remaining entries in the batch
-			bundleIndex++
-			entriesInBundle = 0
-			// Don't use Reset/Truncate here - the backing []bytes is still being used by goSetEntryBundle above.
-			bundleWriter = &bytes.Buffer{}
-			klog.V(1).Infof("Starting to fill in-memory bundle idx %d", bundleIndex)
+			klog.V(1).Infof("In-memory bundle idx %d is full, attempting write to S3", bundle.Index())
+			goSetEntryBundle(ctx, bundle.Index(), 0, bundleWriter)
+			// ... and prepare the next entry bundle for any remaining entries in the batch.
+			// Don't reuse the buffer here - it's still being used by goSetEntryBundle above.
+			bundleWriter = storage.GetBuffer()
+			if err := bundle.Next(bundleWriter); err != nil {
+				return err
+			}
+			klog.V(1).Infof("Starting to fill in-memory bundle idx %d", bundle.Index())
 		}
 	}
 	// If we have a partial bundle remaining once we've added all the entries from the batch,
 	// this needs writing out too.
-	if entriesInBundle > 0 {
-		klog.V(1).Infof("Attempting to write in-memory partial bundle idx %d.%d to S3", bundleIndex, entriesInBundle)
-		goSetEntryBundle(ctx, bundleIndex, uint8(entriesInBundle), bundleWriter.Bytes())
+	if p, err := bundle.Partial(); err == nil {
+		klog.V(1).Infof("Attempting to write in-memory partial bundle idx %d.%d to S3", bundle.Index(), p)
+		goSetEntryBundle(ctx, bundle.Index(), p, bundleWriter)
+	} else {
+		storage.PutBuffer(bundleWriter)
 	}
-	return seqErr.Wait()
+	return nil
+}
+
+// newObjStore returns the object store in which the log's resources are kept.
+func (s *Storage) newObjStore() objStore {
+	if s.objStore != nil {
+		return s.objStore
+	}
+	return &s3Storage{
+		s3Client:     s3.NewFromConfig(*s.cfg.SDKConfig, s.cfg.S3Options),
+		bucket:       s.cfg.Bucket,
+		bucketPrefix: s.cfg.BucketPrefix,
+	}
+}
+
+// monitorDBPool records metrics for the provided database connection pool, and adapts its size if
+// configured to do so.
+func (s *Storage) monitorDBPool(ctx context.Context, db *sql.DB) {
+	var bounds storage.DBPoolBounds
+	if s.cfg.AdaptiveConns {
+		bounds = storage.DBPoolBounds{Min: s.cfg.MinOpenConns, Max: s.cfg.MaxOpenConns}
+	}
+	storage.MonitorDBPool(ctx, db, bounds)
 }
 
 // MigrationWriter creates a new AWS storage for the MigrationWriter lifecycle mode.
 func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOptions) (tessera.MigrationWriter, tessera.LogReader, error) {
 	logStore := &logResourceStore{
-		objStore: &s3Storage{
-			s3Client:     s3.NewFromConfig(*s.cfg.SDKConfig, s.cfg.S3Options),
-			bucket:       s.cfg.Bucket,
-			bucketPrefix: s.cfg.BucketPrefix,
-		},
+		objStore:    s.newObjStore(),
 		entriesPath: opts.EntriesPath(),
+		driverOpts:  s.driverOpts,
 	}
-	seq, err := newMySQLSequencer(ctx, s.cfg.DSN, DefaultPushbackMaxOutstanding, s.cfg.MaxOpenConns, s.cfg.MaxIdleConns)
+	seq, err := newMySQLSequencer(ctx, s.cfg.DSN, tessera.DefaultPushbackMaxOutstanding, s.cfg.MaxOpenConns, s.cfg.MaxIdleConns, true)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create MySQL sequencer: %v", err)
 	}
+	s.monitorDBPool(ctx, seq.dbPool)
 	m := &MigrationStorage{
 		s:            s,
 		dbPool:       seq.dbPool,
@@ -591,7 +736,11 @@ func (m *MigrationStorage) buildTree(ctx context.Context, sourceSize uint64) (ui
 
 	added := uint64(len(lh))
 	klog.Infof("Integrate: adding %d entries to existing tree size %d", len(lh), from)
-	newRoot, err = integrate(ctx, from, lh, m.logStore)
+	writes := &errgroup.Group{}
+	newRoot, err = integrate(ctx, from, lh, m.logStore, writes)
+	if wErr := writes.Wait(); err == nil {
+		err = wErr
+	}
 	if err != nil {
 		klog.Warningf("integrate failed: %v", err)
 		return 0, nil, fmt.Errorf("integrate failed: %v", err)
@@ -616,25 +765,104 @@ type logResourceStore struct {
 	entriesPath    func(uint64, uint8) string
 	integratedSize func(context.Context) (uint64, error)
 	nextIndex      func(context.Context) (uint64, error)
+	// reads coalesces concurrent reads of the same tile or entry bundle into a single S3 request.
+	reads storage.ReadCoalescer
+	// readTimeout, if positive, bounds the duration of each non-streaming read from S3.
+	readTimeout time.Duration
+	// driverOpts holds any object metadata configured to override the defaults.
+	driverOpts tessera.DriverOptions
+	// metrics, which may be nil, records tile writes and checkpoint publishing.
+	metrics *storage.Metrics
+}
+
+// errTooManyOutstanding pushes back on entries while the SeqCoord table is too far ahead of the integrated
+// tree size.
+var errTooManyOutstanding = &tessera.PushbackError{Reason: "too many outstanding entries", RetryAfter: time.Second}
+
+// defaultObjectMetadata is what each class of resource is uploaded with, unless configured otherwise
+// using tessera.WithObjectMetadata.
+var defaultObjectMetadata = map[tessera.ResourceClass]tessera.ObjectMetadata{
+	tessera.ResourceCheckpoint:  {ContentType: ckptContType, CacheControl: ckptCacheControl},
+	tessera.ResourceTile:        {ContentType: logContType, CacheControl: logCacheControl},
+	tessera.ResourceEntryBundle: {ContentType: logContType, CacheControl: logCacheControl},
+}
+
+// metadata returns the metadata to upload resources of the given class with.
+func (lrs *logResourceStore) metadata(class tessera.ResourceClass) tessera.ObjectMetadata {
+	return lrs.driverOpts.ObjectMetadata(class, defaultObjectMetadata[class])
 }
 
 func (lr *logResourceStore) ReadCheckpoint(ctx context.Context) ([]byte, error) {
-	r, err := lr.get(ctx, layout.CheckpointPath)
+	ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+	defer cancel()
+	return lr.get(ctx, layout.CheckpointPath)
+}
+
+func (lr *logResourceStore) ReadCheckpointModTime(ctx context.Context) (time.Time, error) {
+	ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+	defer cancel()
+	t, err := lr.checkpointLastModified(ctx)
 	if err != nil {
 		var nske *types.NoSuchKey
 		if errors.As(err, &nske) {
-			return r, os.ErrNotExist
+			return t, tessera.ErrNotFound
 		}
 	}
-	return r, err
+	return t, err
+}
+
+// TreeState returns the state of the log as of its latest checkpoint.
+//
+// S3 can't return an object's contents and modification time consistently with separate requests, so the
+// time is read first, so that a concurrent publication can only make the checkpoint seem older.
+func (lr *logResourceStore) TreeState(ctx context.Context) (tessera.TreeState, error) {
+	at, err := lr.ReadCheckpointModTime(ctx)
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	cp, err := lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	return tessera.NewTreeState(cp, at)
 }
 
 func (lr *logResourceStore) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
-	return lr.get(ctx, layout.TilePath(l, i, p))
+	return lr.coalescedGet(ctx, layout.TilePath(l, i, p))
 }
 
 func (lr *logResourceStore) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
-	return lr.get(ctx, lr.entriesPath(i, p))
+	return lr.coalescedGet(ctx, lr.entriesPath(i, p))
+}
+
+// coalescedGet returns the requested immutable object, sharing a single S3 request between concurrent callers.
+func (lr *logResourceStore) coalescedGet(ctx context.Context, path string) ([]byte, error) {
+	return lr.reads.Read(ctx, path, func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+		defer cancel()
+		return lr.get(ctx, path)
+	})
+}
+
+func (lr *logResourceStore) ReadTileStream(ctx context.Context, l, i uint64, p uint8) (io.ReadCloser, error) {
+	return lr.getReader(ctx, layout.TilePath(l, i, p))
+}
+
+func (lr *logResourceStore) ReadEntryBundleStream(ctx context.Context, i uint64, p uint8) (io.ReadCloser, error) {
+	return lr.getReader(ctx, lr.entriesPath(i, p))
+}
+
+func (lr *logResourceStore) ReadEntryBundleRange(ctx context.Context, i uint64, p uint8, offset, length int64) (io.ReadCloser, int64, error) {
+	objName := lr.entriesPath(i, p)
+	r, size, err := lr.objStore.getObjectRangeReader(ctx, objName, offset, length)
+	if err != nil {
+		var nske *types.NoSuchKey
+		if errors.As(err, &nske) {
+			return nil, 0, fmt.Errorf("%v: %w", objName, tessera.ErrNotFound)
+		}
+		return nil, 0, err
+	}
+	return r, size, nil
 }
 
 func (lr *logResourceStore) IntegratedSize(ctx context.Context) (uint64, error) {
@@ -657,14 +885,37 @@ func (lr *logResourceStore) StreamEntries(ctx context.Context, fromEntry uint64)
 
 // get returns the requested object.
 //
-// This is indended to be used to proxy read requests through the personality for debug/testing purposes.
+// Returns a wrapped tessera.ErrNotFound if the object does not exist.
 func (s *logResourceStore) get(ctx context.Context, path string) ([]byte, error) {
 	d, err := s.objStore.getObject(ctx, path)
-	return d, err
+	if err != nil {
+		var nske *types.NoSuchKey
+		if errors.As(err, &nske) {
+			return nil, fmt.Errorf("%v: %w", path, tessera.ErrNotFound)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// getReader returns a reader for the requested object.
+//
+// The caller must close the returned reader.
+// Returns a wrapped tessera.ErrNotFound if the object does not exist.
+func (s *logResourceStore) getReader(ctx context.Context, path string) (io.ReadCloser, error) {
+	r, err := s.objStore.getObjectReader(ctx, path)
+	if err != nil {
+		var nske *types.NoSuchKey
+		if errors.As(err, &nske) {
+			return nil, fmt.Errorf("%v: %w", path, tessera.ErrNotFound)
+		}
+		return nil, err
+	}
+	return r, nil
 }
 
 func (lrs *logResourceStore) setCheckpoint(ctx context.Context, cpRaw []byte) error {
-	return lrs.objStore.setObject(ctx, layout.CheckpointPath, cpRaw, ckptContType, ckptCacheControl)
+	return lrs.objStore.setObject(ctx, layout.CheckpointPath, cpRaw, lrs.metadata(tessera.ResourceCheckpoint))
 }
 
 func (lrs *logResourceStore) checkpointLastModified(ctx context.Context) (time.Time, error) {
@@ -676,6 +927,10 @@ func (lrs *logResourceStore) checkpointLastModified(ctx context.Context) (time.T
 //
 // The location to which the tile is written is defined by the tile layout spec.
 func (lrs *logResourceStore) setTile(ctx context.Context, level, index, logSize uint64, tile *api.HashTile) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.setTile")
+	defer span.End()
+	span.SetAttributes(levelKey.Int64(otel.Clamp64(level)), indexKey.Int64(otel.Clamp64(index)))
+
 	data, err := tile.MarshalText()
 	if err != nil {
 		return err
@@ -683,7 +938,11 @@ func (lrs *logResourceStore) setTile(ctx context.Context, level, index, logSize
 	tPath := layout.TilePath(level, index, layout.PartialTileSize(level, index, logSize))
 	klog.V(2).Infof("StoreTile: %s (%d entries)", tPath, len(tile.Nodes))
 
-	return lrs.objStore.setObjectIfNoneMatch(ctx, tPath, data, logContType, logCacheControl)
+	if err := lrs.objStore.setObjectIfNoneMatch(ctx, tPath, data, lrs.metadata(tessera.ResourceTile)); err != nil {
+		return err
+	}
+	lrs.metrics.TileWritten(ctx, level)
+	return nil
 }
 
 // getTiles returns the tiles with the given tile-coords for the specified log size.
@@ -725,7 +984,7 @@ func (lrs *logResourceStore) getTiles(ctx context.Context, tileIDs []storage.Til
 
 // getEntryBundle returns the serialised entry bundle at the location implied by the given index and treeSize.
 //
-// Returns a wrapped os.ErrNotExist if the bundle does not exist.
+// Returns a wrapped tessera.ErrNotFound if the bundle does not exist.
 func (lrs *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint64, p uint8) ([]byte, error) {
 	objName := lrs.entriesPath(bundleIndex, p)
 	data, err := lrs.objStore.getObject(ctx, objName)
@@ -735,7 +994,7 @@ func (lrs *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uin
 		if errors.As(err, &nske) {
 			// Return the generic NotExist error so that higher levels can differentiate
 			// between this and other errors.
-			return nil, fmt.Errorf("%v: %w", objName, os.ErrNotExist)
+			return nil, fmt.Errorf("%v: %w", objName, tessera.ErrNotFound)
 		}
 		return nil, err
 	}
@@ -745,11 +1004,15 @@ func (lrs *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uin
 
 // setEntryBundle idempotently stores the serialised entry bundle at the location implied by the bundleIndex and treeSize.
 func (lrs *logResourceStore) setEntryBundle(ctx context.Context, bundleIndex uint64, p uint8, bundleRaw []byte) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.setEntryBundle")
+	defer span.End()
+	span.SetAttributes(indexKey.Int64(otel.Clamp64(bundleIndex)))
+
 	objName := lrs.entriesPath(bundleIndex, p)
 	// Note that setObject does an idempotent interpretation of IfNoneMatch - it only
 	// returns an error if the named object exists _and_ contains different data to what's
 	// passed in here.
-	if err := lrs.objStore.setObjectIfNoneMatch(ctx, objName, bundleRaw, logContType, logCacheControl); err != nil {
+	if err := lrs.objStore.setObjectIfNoneMatch(ctx, objName, bundleRaw, lrs.metadata(tessera.ResourceEntryBundle)); err != nil {
 		return fmt.Errorf("setObjectIfNoneMatch(%q): %v", objName, err)
 
 	}
@@ -757,7 +1020,11 @@ func (lrs *logResourceStore) setEntryBundle(ctx context.Context, bundleIndex uin
 }
 
 // integrate adds the provided leaf hashes to the merkle tree, starting at the provided location.
-func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, lrs *logResourceStore) ([]byte, error) {
+// Writes of the updated tiles are scheduled on the provided errgroup, and callers must Wait on it.
+func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, lrs *logResourceStore, writes *errgroup.Group) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.integrate")
+	defer span.End()
+
 	getTiles := func(ctx context.Context, tileIDs []storage.TileID, treeSize uint64) ([]*api.HashTile, error) {
 		n, err := lrs.getTiles(ctx, tileIDs, treeSize)
 		if err != nil {
@@ -770,17 +1037,13 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, lrs *logResourc
 	if err != nil {
 		return nil, fmt.Errorf("storage.Integrate: %v", err)
 	}
-	errG := errgroup.Group{}
 	for k, v := range tiles {
 		func(ctx context.Context, k storage.TileID, v *api.HashTile) {
-			errG.Go(func() error {
+			writes.Go(func() error {
 				return lrs.setTile(ctx, uint64(k.Level), k.Index, newSize, v)
 			})
 		}(ctx, k, v)
 	}
-	if err := errG.Wait(); err != nil {
-		return nil, err
-	}
 	klog.Infof("New tree: %d, %x", newSize, newRoot)
 	return newRoot, nil
 }
@@ -794,7 +1057,9 @@ type mySQLSequencer struct {
 
 // newMySQLSequencer returns a new mysqlSequencer struct which uses the provided
 // DSN for its MySQL connection.
-func newMySQLSequencer(ctx context.Context, dsn string, maxOutstanding uint64, maxOpenConns, maxIdleConns int) (*mySQLSequencer, error) {
+//
+// If create is true, any missing tables are created; otherwise the database is only read from here.
+func newMySQLSequencer(ctx context.Context, dsn string, maxOutstanding uint64, maxOpenConns, maxIdleConns int, create bool) (*mySQLSequencer, error) {
 	dbPool, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MySQL db: %v", err)
@@ -816,8 +1081,10 @@ func newMySQLSequencer(ctx context.Context, dsn string, maxOutstanding uint64, m
 		maxOutstanding: maxOutstanding,
 	}
 
-	if err := r.initDB(ctx); err != nil {
-		return nil, fmt.Errorf("failed to initDB: %v", err)
+	if create {
+		if err := r.initDB(ctx); err != nil {
+			return nil, fmt.Errorf("failed to initDB: %v", err)
+		}
 	}
 	if err := r.checkDataCompatibility(ctx); err != nil {
 		return nil, fmt.Errorf("schema is not compatible with this version of the Tessera library: %v", err)
@@ -895,6 +1162,14 @@ func (s *mySQLSequencer) initDB(ctx context.Context) error {
 		)`); err != nil {
 		return err
 	}
+	if _, err := s.dbPool.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS LogIdentity(
+			id INT UNSIGNED NOT NULL,
+			identity BLOB NOT NULL,
+			PRIMARY KEY (id)
+		)`); err != nil {
+		return err
+	}
 
 	// Set default values for a newly initialised schema - these rows being present are a precondition for
 	// sequencing and integration to occur.
@@ -915,14 +1190,73 @@ func (s *mySQLSequencer) initDB(ctx context.Context) error {
 	return nil
 }
 
+// ensureIdentity will fail if the log identity stored in the LogIdentity table doesn't match the provided one.
+// If no row exists, then it is created with the provided identity.
+func (s *mySQLSequencer) ensureIdentity(ctx context.Context, id tessera.LogIdentity) error {
+	tx, err := s.dbPool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if tx != nil {
+			if err := tx.Rollback(); err != nil {
+				klog.Errorf("Failed to rollback in ensureIdentity: %v", err)
+			}
+		}
+	}()
+
+	var stored []byte
+	found := true
+	if err := tx.QueryRowContext(ctx, "SELECT identity FROM LogIdentity WHERE id = ? FOR UPDATE", 0).Scan(&stored); errors.Is(err, sql.ErrNoRows) {
+		found = false
+	} else if err != nil {
+		return fmt.Errorf("failed to read log identity: %v", err)
+	}
+	updated, err := id.Reconcile(stored)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(stored, updated) {
+		return nil
+	}
+	q := "UPDATE LogIdentity SET identity = ? WHERE id = ?"
+	if !found {
+		q = "INSERT INTO LogIdentity (identity, id) VALUES (?, ?)"
+	}
+	if _, err := tx.ExecContext(ctx, q, updated, 0); err != nil {
+		return fmt.Errorf("failed to write log identity: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit log identity: %v", err)
+	}
+	tx = nil
+	return nil
+}
+
 // assignEntries durably assigns each of the passed-in entries an index in the log.
 //
 // Entries are allocated contiguous indices, in the order in which they appear in the entries parameter.
 // This is achieved by storing the passed-in entries in the Seq table in MySQL, keyed by the
 // index assigned to the first entry in the batch.
+//
+// The range of indices is reserved with a single compare-and-swap style UPDATE against the SeqCoord row,
+// which both checks for back-pressure and advances the next available index, and the batch is then
+// written with a single INSERT into Seq. The SeqCoord row lock is therefore only held for the duration of
+// those two statements, rather than across a locking read and a subsequent write as it was previously.
+// Concurrent flushes still serialise on the SeqCoord row, so throughput is bounded by the round-trip time
+// of the UPDATE+INSERT+COMMIT, but never by the time taken to integrate entries: integration only contends
+// with sequencing on the Seq table, and the IntCoord read used for back-pressure does not take any locks.
 func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.Entry) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.assignEntries")
+	defer span.End()
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
+
+	if len(entries) == 0 {
+		return nil
+	}
 	// First grab the treeSize in a non-locking read-only fashion (we don't want to block/collide with integration).
 	// We'll use this value to determine whether we need to apply back-pressure.
+	// The tree only grows, so using a possibly stale value here can only make us more conservative.
 	var treeSize uint64
 	row := s.dbPool.QueryRowContext(ctx, "SELECT seq FROM IntCoord WHERE id = ?", 0)
 	if err := row.Scan(&treeSize); err == sql.ErrNoRows {
@@ -944,18 +1278,26 @@ func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.E
 		}
 	}()
 
-	// First we need to grab the next available sequence number from the SeqCoord table.
-	var next, id uint64
-	r := tx.QueryRowContext(ctx, "SELECT id, next FROM SeqCoord WHERE id = ? FOR UPDATE", 0)
-	if err := r.Scan(&id, &next); err != nil {
-		return fmt.Errorf("failed to read seqcoord: %v", err)
+	// Reserve a range of indices for our entries by advancing the next available sequence number in SeqCoord,
+	// but only if doing so wouldn't leave too many outstanding entries.
+	// LAST_INSERT_ID(expr) hands us back the value of next before the update, which is the first index in
+	// our range, without needing a separate SELECT ... FOR UPDATE.
+	num := uint64(len(entries))
+	res, err := tx.ExecContext(ctx, "UPDATE SeqCoord SET next = LAST_INSERT_ID(next) + ? WHERE id = ? AND next <= ?", num, 0, treeSize+s.maxOutstanding)
+	if err != nil {
+		return fmt.Errorf("update seqcoord: %v", err)
 	}
-
-	// Check whether there are too many outstanding entries and we should apply
-	// back-pressure.
-	if outstanding := next - treeSize; outstanding > s.maxOutstanding {
-		return tessera.ErrPushback
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to read seqcoord rows affected: %v", err)
+	} else if n == 0 {
+		// There are too many outstanding entries and we should apply back-pressure.
+		return errTooManyOutstanding
 	}
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read reserved sequence number: %v", err)
+	}
+	next := uint64(lastID)
 
 	sequencedEntries := make([]storage.SequencedEntry, len(entries))
 	// Assign provisional sequence numbers to entries.
@@ -973,17 +1315,11 @@ func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.E
 	if err := e.Encode(sequencedEntries); err != nil {
 		return fmt.Errorf("failed to serialise batch: %v", err)
 	}
-	data := b.Bytes()
-	num := uint64(len(entries))
 
-	// Insert our newly sequenced batch of entries into Seq,
-	if _, err := tx.ExecContext(ctx, "INSERT INTO Seq(id, seq, v) VALUES(?, ?, ?)", 0, next, data); err != nil {
+	// Insert our newly sequenced batch of entries into Seq.
+	if _, err := tx.ExecContext(ctx, "INSERT INTO Seq(id, seq, v) VALUES(?, ?, ?)", 0, next, b.Bytes()); err != nil {
 		return fmt.Errorf("insert into seq: %v", err)
 	}
-	// and update the next-available sequence number row in SeqCoord.
-	if _, err := tx.ExecContext(ctx, "UPDATE SeqCoord SET next = ? WHERE ID = ?", next+num, 0); err != nil {
-		return fmt.Errorf("update seqcoord: %v", err)
-	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit Tx: %v", err)
@@ -1151,8 +1487,76 @@ func (s *s3Storage) getObject(ctx context.Context, obj string) ([]byte, error) {
 	return d, r.Body.Close()
 }
 
+// getObjectReader returns a reader for the data of the specified object, or an error.
+//
+// The caller must close the returned reader.
+func (s *s3Storage) getObjectReader(ctx context.Context, obj string) (io.ReadCloser, error) {
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+
+	r, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(obj),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getObjectReader: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
+	}
+	return r.Body, nil
+}
+
+// getObjectRangeReader returns a reader for part of the data of the specified object, along with the total size
+// of the object, or an error.
+//
+// The semantics of offset and length are as described by tessera.RangeLogReader.
+// The caller must close the returned reader.
+func (s *s3Storage) getObjectRangeReader(ctx context.Context, obj string, offset, length int64) (io.ReadCloser, int64, error) {
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+
+	var rng string
+	switch {
+	case offset < 0:
+		rng = fmt.Sprintf("bytes=%d", offset)
+	case length < 0:
+		rng = fmt.Sprintf("bytes=%d-", offset)
+	case length == 0:
+		// S3 would ignore the empty range below and return the whole object.
+		return nil, 0, fmt.Errorf("getObjectRangeReader: zero length range requested for %q", obj)
+	default:
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+
+	r, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(obj),
+		Range:  aws.String(rng),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+			return nil, 0, fmt.Errorf("getObjectRangeReader(%q, %s): %w", obj, rng, tessera.ErrRangeNotSatisfiable)
+		}
+		return nil, 0, fmt.Errorf("getObjectRangeReader: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
+	}
+
+	// The total size of the object is only available via the Content-Range header, e.g. "bytes 0-9/1234".
+	if r.ContentRange == nil {
+		// No Content-Range means S3 returned the whole object.
+		return r.Body, aws.ToInt64(r.ContentLength), nil
+	}
+	_, total, ok := strings.Cut(*r.ContentRange, "/")
+	size, err := strconv.ParseInt(total, 10, 64)
+	if !ok || err != nil {
+		_ = r.Body.Close()
+		return nil, 0, fmt.Errorf("getObjectRangeReader: invalid Content-Range %q for object %q", *r.ContentRange, obj)
+	}
+	return r.Body, size, nil
+}
+
 // setObject stores the provided data in the specified object.
-func (s *s3Storage) setObject(ctx context.Context, objName string, data []byte, contType string, cacheControl string) error {
+func (s *s3Storage) setObject(ctx context.Context, objName string, data []byte, md tessera.ObjectMetadata) error {
 	if s.bucketPrefix != "" {
 		objName = filepath.Join(s.bucketPrefix, objName)
 	}
@@ -1161,8 +1565,9 @@ func (s *s3Storage) setObject(ctx context.Context, objName string, data []byte,
 		Bucket:       aws.String(s.bucket),
 		Key:          aws.String(objName),
 		Body:         bytes.NewReader(data),
-		ContentType:  aws.String(contType),
-		CacheControl: aws.String(cacheControl),
+		ContentType:  aws.String(md.ContentType),
+		CacheControl: aws.String(md.CacheControl),
+		Metadata:     md.Custom,
 	}
 
 	if _, err := s.s3Client.PutObject(ctx, put); err != nil {
@@ -1177,7 +1582,7 @@ func (s *s3Storage) setObject(ctx context.Context, objName string, data []byte,
 // iff no object exists under this key already. If an object already exists under the same key,
 // an error will be returned *unless*  the currently stored data is bit-for-bit identical to the
 // data to-be-written. This is intended to provide idempotentency for writes.
-func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, data []byte, contType string, cacheControl string) error {
+func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, data []byte, md tessera.ObjectMetadata) error {
 	if s.bucketPrefix != "" {
 		objName = filepath.Join(s.bucketPrefix, objName)
 	}
@@ -1186,8 +1591,9 @@ func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, da
 		Bucket:       aws.String(s.bucket),
 		Key:          aws.String(objName),
 		Body:         bytes.NewReader(data),
-		ContentType:  aws.String(contType),
-		CacheControl: aws.String(cacheControl),
+		ContentType:  aws.String(md.ContentType),
+		CacheControl: aws.String(md.CacheControl),
+		Metadata:     md.Custom,
 		// "*" is the expected character for this condition
 		IfNoneMatch: aws.String("*"),
 	}
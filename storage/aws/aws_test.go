@@ -29,6 +29,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"reflect"
 	"sync"
@@ -36,6 +38,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
 	"github.com/google/go-cmp/cmp"
@@ -43,6 +46,7 @@ import (
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	storage "github.com/transparency-dev/tessera/storage/internal"
+	"github.com/transparency-dev/tessera/storage/storagetest"
 	"k8s.io/klog/v2"
 )
 
@@ -58,6 +62,42 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+func TestConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		cfg      Config
+		wantErrs int
+	}{
+		{desc: "ok", cfg: Config{Bucket: "b", DSN: "dsn"}},
+		{desc: "ok with adaptive conns", cfg: Config{Bucket: "b", DSN: "dsn", AdaptiveConns: true, MinOpenConns: 2, MaxOpenConns: 10}},
+		{desc: "empty", cfg: Config{}, wantErrs: 2},
+		{desc: "bucket with scheme", cfg: Config{Bucket: "s3://b", DSN: "dsn"}, wantErrs: 1},
+		{desc: "prefix with slashes", cfg: Config{Bucket: "b", BucketPrefix: "logs/", DSN: "dsn"}, wantErrs: 1},
+		{desc: "adaptive without max", cfg: Config{Bucket: "b", DSN: "dsn", AdaptiveConns: true}, wantErrs: 1},
+		{desc: "adaptive inverted", cfg: Config{Bucket: "b", DSN: "dsn", AdaptiveConns: true, MinOpenConns: 10, MaxOpenConns: 2}, wantErrs: 1},
+		{desc: "negative conns", cfg: Config{Bucket: "b", DSN: "dsn", MaxIdleConns: -1}, wantErrs: 1},
+		{desc: "s3 options without sdk config", cfg: Config{Bucket: "b", DSN: "dsn", S3Options: func(*s3.Options) {}}, wantErrs: 1},
+		{desc: "everything wrong", cfg: Config{Bucket: "s3://b", BucketPrefix: "/x", AdaptiveConns: true}, wantErrs: 4},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.cfg.validate()
+			if (err != nil) != (test.wantErrs > 0) {
+				t.Fatalf("validate() = %v, want error %t", err, test.wantErrs > 0)
+			}
+			if err == nil {
+				return
+			}
+			var joined interface{ Unwrap() []error }
+			if !errors.As(err, &joined) {
+				t.Fatalf("validate() = %v, want joined errors", err)
+			}
+			if got := len(joined.Unwrap()); got != test.wantErrs {
+				t.Errorf("validate() returned %d errors, want %d: %v", got, test.wantErrs, err)
+			}
+		})
+	}
+}
+
 // canSkipMySQLTest checks if the test MySQL db is available and, if not, if the test can be skipped.
 //
 // Use this method before every MySQL test, and if it returns true, skip the test.
@@ -65,7 +105,7 @@ func TestMain(m *testing.M) {
 // If is_mysql_test_optional is set to true and MySQL database cannot be opened or pinged,
 // the test will fail immediately. Otherwise, the test will be skipped if the test is optional
 // and the database is not available.
-func canSkipMySQLTest(t *testing.T, ctx context.Context) bool {
+func canSkipMySQLTest(t testing.TB, ctx context.Context) bool {
 	t.Helper()
 
 	db, err := sql.Open("mysql", *mySQLURI)
@@ -91,7 +131,7 @@ func canSkipMySQLTest(t *testing.T, ctx context.Context) bool {
 
 // mustDropTables drops the `Seq`, `SeqCoord` and `IntCoord` tables.
 // Call this function before every MySQL test.
-func mustDropTables(t *testing.T, ctx context.Context) {
+func mustDropTables(t testing.TB, ctx context.Context) {
 	t.Helper()
 
 	db, err := sql.Open("mysql", *mySQLURI)
@@ -104,7 +144,7 @@ func mustDropTables(t *testing.T, ctx context.Context) {
 		}
 	}()
 
-	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS `Seq`, `SeqCoord`, `IntCoord`"); err != nil {
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS `Seq`, `SeqCoord`, `IntCoord`, `LogIdentity`"); err != nil {
 		t.Fatalf("failed to drop all tables: %v", err)
 	}
 }
@@ -118,7 +158,7 @@ func TestMySQLSequencerAssignEntries(t *testing.T) {
 	// Clean tables in case there's already something in there.
 	mustDropTables(t, ctx)
 
-	seq, err := newMySQLSequencer(ctx, *mySQLURI, 1000, 0, 0)
+	seq, err := newMySQLSequencer(ctx, *mySQLURI, 1000, 0, 0, true)
 	if err != nil {
 		t.Fatalf("newMySQLSequencer: %v", err)
 	}
@@ -141,6 +181,43 @@ func TestMySQLSequencerAssignEntries(t *testing.T) {
 	}
 }
 
+// BenchmarkMySQLSequencerAssignEntries measures the cost of sequencing batches of entries with
+// varying numbers of concurrent flushers contending on the SeqCoord row.
+func BenchmarkMySQLSequencerAssignEntries(b *testing.B) {
+	ctx := context.Background()
+	if canSkipMySQLTest(b, ctx) {
+		b.Skip("MySQL not available, skipping benchmark")
+	}
+
+	for _, batchSize := range []int{1, 256, 1024} {
+		for _, flushers := range []int{1, 8, 32} {
+			b.Run(fmt.Sprintf("batch=%d/flushers=%d", batchSize, flushers), func(b *testing.B) {
+				mustDropTables(b, ctx)
+				// Never apply pushback, there's nothing integrating these entries.
+				seq, err := newMySQLSequencer(ctx, *mySQLURI, math.MaxInt64, flushers, flushers, true)
+				if err != nil {
+					b.Fatalf("newMySQLSequencer: %v", err)
+				}
+				b.SetParallelism(flushers)
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						entries := make([]*tessera.Entry, batchSize)
+						for i := range entries {
+							entries[i] = tessera.NewEntry([]byte{byte(i)})
+						}
+						if err := seq.assignEntries(ctx, entries); err != nil {
+							b.Errorf("assignEntries: %v", err)
+							return
+						}
+					}
+				})
+				b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "entries/s")
+			})
+		}
+	}
+}
+
 func TestMySQLSequencerPushback(t *testing.T) {
 	ctx := context.Background()
 	if canSkipMySQLTest(t, ctx) {
@@ -176,7 +253,7 @@ func TestMySQLSequencerPushback(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			mustDropTables(t, ctx)
 
-			seq, err := newMySQLSequencer(ctx, *mySQLURI, test.threshold, 0, 0)
+			seq, err := newMySQLSequencer(ctx, *mySQLURI, test.threshold, 0, 0, true)
 			if err != nil {
 				t.Fatalf("newMySQLSequencer: %v", err)
 			}
@@ -210,7 +287,7 @@ func TestMySQLSequencerRoundTrip(t *testing.T) {
 	// Clean tables in case there's already something in there.
 	mustDropTables(t, ctx)
 
-	s, err := newMySQLSequencer(ctx, *mySQLURI, 1000, 0, 0)
+	s, err := newMySQLSequencer(ctx, *mySQLURI, 1000, 0, 0, true)
 	if err != nil {
 		t.Fatalf("newMySQLSequencer: %v", err)
 	}
@@ -267,6 +344,30 @@ func makeTile(t *testing.T, size uint64) *api.HashTile {
 	return r
 }
 
+func TestStorageContract(t *testing.T) {
+	if canSkipMySQLTest(t, t.Context()) {
+		klog.Warningf("MySQL not available, skipping %s", t.Name())
+		t.Skip("MySQL not available, skipping test")
+	}
+	storagetest.RunAppenderTests(t, func(t *testing.T) tessera.Driver {
+		mustDropTables(t, t.Context())
+		return &Storage{cfg: Config{DSN: *mySQLURI, Bucket: "test-bucket"}, objStore: newMemObjStore()}
+	})
+}
+
+func TestChaos(t *testing.T) {
+	if canSkipMySQLTest(t, t.Context()) {
+		klog.Warningf("MySQL not available, skipping %s", t.Name())
+		t.Skip("MySQL not available, skipping test")
+	}
+	mustDropTables(t, t.Context())
+	// Each restart gets a new Storage backed by the same database and object store.
+	m := newMemObjStore()
+	storagetest.RunChaosTests(t, func(_ context.Context) (tessera.Driver, error) {
+		return &Storage{cfg: Config{DSN: *mySQLURI, Bucket: "test-bucket"}, objStore: m}, nil
+	})
+}
+
 func TestTileRoundtrip(t *testing.T) {
 	ctx := context.Background()
 	m := newMemObjStore()
@@ -378,7 +479,7 @@ func TestPublishCheckpoint(t *testing.T) {
 	// Clean tables in case there's already something in there.
 	mustDropTables(t, ctx)
 
-	s, err := newMySQLSequencer(ctx, *mySQLURI, 1000, 0, 0)
+	s, err := newMySQLSequencer(ctx, *mySQLURI, 1000, 0, 0, true)
 	if err != nil {
 		t.Fatalf("newMySQLSequencer: %v", err)
 	}
@@ -403,22 +504,26 @@ func TestPublishCheckpoint(t *testing.T) {
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			m := newMemObjStore()
+			var notified *tessera.PublishedCheckpoint
 			storage := &Appender{
 				logStore: &logResourceStore{
 					objStore:    m,
 					entriesPath: layout.EntriesPath,
 				},
-				sequencer: s,
+				sequencer:          s,
+				integrationTimeout: defaultIntegrationTimeout,
 				newCP: func(_ context.Context, size uint64, hash []byte) ([]byte, error) {
 					return fmt.Appendf(nil, "%d/%x,", size, hash), nil
 				},
+				notifyPublished: func(cp tessera.PublishedCheckpoint) { notified = &cp },
 			}
 			// Call init so we've got a zero-sized checkpoint to work with.
 			if err := storage.init(ctx); err != nil {
 				t.Fatalf("storage.init: %v", err)
 			}
+			notified = nil
 			cpOld := []byte("bananas")
-			if err := m.setObject(ctx, layout.CheckpointPath, cpOld, "", ""); err != nil {
+			if err := m.setObject(ctx, layout.CheckpointPath, cpOld, tessera.ObjectMetadata{}); err != nil {
 				t.Fatalf("setObject(bananas): %v", err)
 			}
 			m.lMod = test.cpModifiedAt
@@ -438,10 +543,49 @@ func TestPublishCheckpoint(t *testing.T) {
 			if test.wantUpdate != cpUpdated {
 				t.Fatalf("got cpUpdated=%t, want %t", cpUpdated, test.wantUpdate)
 			}
+			if gotNotified := notified != nil; gotNotified != test.wantUpdate {
+				t.Fatalf("got notified=%t, want %t", gotNotified, test.wantUpdate)
+			}
+			if notified != nil && !bytes.Equal(notified.Checkpoint, cpNew) {
+				t.Errorf("Notified of checkpoint %q, but published %q", notified.Checkpoint, cpNew)
+			}
 		})
 	}
 }
 
+func TestObjectMetadata(t *testing.T) {
+	ctx := t.Context()
+	m := newMemObjStore()
+	s := &logResourceStore{
+		objStore:    m,
+		entriesPath: layout.EntriesPath,
+	}
+	s.driverOpts.Apply(tessera.WithObjectMetadata(tessera.ResourceTile, tessera.ObjectMetadata{
+		ContentType: "application/x-tlog-tile",
+		Custom:      map[string]string{"log": "test"},
+	}))
+
+	if err := s.setCheckpoint(ctx, []byte("checkpoint")); err != nil {
+		t.Fatalf("setCheckpoint: %v", err)
+	}
+	if err := s.setTile(ctx, 0, 0, 1, &api.HashTile{Nodes: [][]byte{make([]byte, 32)}}); err != nil {
+		t.Fatalf("setTile: %v", err)
+	}
+	if err := s.setEntryBundle(ctx, 0, 1, []byte("bundle")); err != nil {
+		t.Fatalf("setEntryBundle: %v", err)
+	}
+
+	for obj, want := range map[string]tessera.ObjectMetadata{
+		layout.CheckpointPath:    {ContentType: ckptContType, CacheControl: ckptCacheControl},
+		layout.TilePath(0, 0, 1): {ContentType: "application/x-tlog-tile", CacheControl: logCacheControl, Custom: map[string]string{"log": "test"}},
+		layout.EntriesPath(0, 1): {ContentType: logContType, CacheControl: logCacheControl},
+	} {
+		if diff := cmp.Diff(want, m.md[obj]); diff != "" {
+			t.Errorf("metadata for %q: diff (-want +got):\n%s", obj, diff)
+		}
+	}
+}
+
 func TestStreamEntries(t *testing.T) {
 	ctx := context.Background()
 	m := newMemObjStore()
@@ -517,13 +661,19 @@ func TestStreamEntries(t *testing.T) {
 
 type memObjStore struct {
 	sync.RWMutex
-	mem  map[string][]byte
+	mem map[string][]byte
+	md  map[string]tessera.ObjectMetadata
+	// mod holds the time at which each object was last written.
+	mod map[string]time.Time
+	// lMod, if set, is returned as the modification time of every object.
 	lMod time.Time
 }
 
 func newMemObjStore() *memObjStore {
 	return &memObjStore{
 		mem: make(map[string][]byte),
+		md:  make(map[string]tessera.ObjectMetadata),
+		mod: make(map[string]time.Time),
 	}
 }
 
@@ -538,16 +688,37 @@ func (m *memObjStore) getObject(_ context.Context, obj string) ([]byte, error) {
 	return d, nil
 }
 
-// TODO(phboneff): add content type tests
-func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, _, _ string) error {
+func (m *memObjStore) getObjectReader(ctx context.Context, obj string) (io.ReadCloser, error) {
+	d, err := m.getObject(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(d)), nil
+}
+
+func (m *memObjStore) getObjectRangeReader(ctx context.Context, obj string, offset, length int64) (io.ReadCloser, int64, error) {
+	d, err := m.getObject(ctx, obj)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, n, err := tessera.ResolveRange(int64(len(d)), offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(d[start : start+n])), int64(len(d)), nil
+}
+
+func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, md tessera.ObjectMetadata) error {
 	m.Lock()
 	defer m.Unlock()
-	m.mem[obj] = data
+	// Take a copy, as callers may reuse the buffer once this returns.
+	m.mem[obj] = bytes.Clone(data)
+	m.mod[obj] = time.Now()
+	m.md[obj] = md
 	return nil
 }
 
-// TODO(phboneff): add content type tests
-func (m *memObjStore) setObjectIfNoneMatch(_ context.Context, obj string, data []byte, _, _ string) error {
+func (m *memObjStore) setObjectIfNoneMatch(_ context.Context, obj string, data []byte, md tessera.ObjectMetadata) error {
 	m.Lock()
 	defer m.Unlock()
 
@@ -555,10 +726,23 @@ func (m *memObjStore) setObjectIfNoneMatch(_ context.Context, obj string, data [
 	if ok && !bytes.Equal(d, data) {
 		return &smithy.GenericAPIError{Code: "PreconditionFailed"}
 	}
-	m.mem[obj] = data
+	// Take a copy, as callers may reuse the buffer once this returns.
+	m.mem[obj] = bytes.Clone(data)
+	m.mod[obj] = time.Now()
+	m.md[obj] = md
 	return nil
 }
 
 func (m *memObjStore) lastModified(_ context.Context, obj string) (time.Time, error) {
-	return m.lMod, nil
+	m.RLock()
+	defer m.RUnlock()
+
+	if !m.lMod.IsZero() {
+		return m.lMod, nil
+	}
+	t, ok := m.mod[obj]
+	if !ok {
+		return time.Time{}, fmt.Errorf("obj %q not found: %w", obj, &types.NoSuchKey{})
+	}
+	return t, nil
 }
@@ -0,0 +1,423 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cockroachdb contains a CockroachDB-based storage implementation for Tessera.
+//
+// The SQL is shared with the MySQL driver, via the storage/sqlcommon package. This package doesn't
+// register a database/sql driver itself: callers should import one which speaks the Postgres wire
+// protocol, e.g. github.com/jackc/pgx/v5/stdlib, and pass the *sql.DB it opens to New.
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"github.com/transparency-dev/tessera/storage/sqlcommon"
+	"k8s.io/klog/v2"
+)
+
+const (
+	schemaCompatibilityVersion = 1
+
+	// minCheckpointInterval is higher than for MySQL, since each publish is a serializable transaction
+	// which may have to coordinate across regions.
+	minCheckpointInterval = 2 * time.Second
+
+	// maxBundlesPerQuery is the maximum number of entry bundles which ReadEntryBundles will request in a single query.
+	maxBundlesPerQuery = 256
+)
+
+// Storage is a CockroachDB-based storage implementation for Tessera.
+type Storage struct {
+	db *sql.DB
+	// store implements the reads, writes, and transactions which are common to all SQL databases.
+	store *sqlcommon.Store
+	// reads coalesces concurrent reads of the same tile or entry bundle into a single query.
+	reads storage.ReadCoalescer
+	// driverOpts holds settings common to all storage drivers.
+	driverOpts tessera.DriverOptions
+	// poolBounds, if enabled, is the range within which the maximum number of open connections to db is adapted.
+	poolBounds storage.DBPoolBounds
+}
+
+// Option is a function which configures optional behaviour of the CockroachDB-based Storage.
+type Option func(*Storage)
+
+// WithAdaptiveConns causes the maximum number of open connections in the database connection pool to be
+// adapted, within the range [minConns, maxConns], based on observed connection wait times and utilisation.
+//
+// Without this option, the pool is used as configured by the caller.
+func WithAdaptiveConns(minConns, maxConns int) Option {
+	return func(s *Storage) {
+		s.poolBounds = storage.DBPoolBounds{Min: minConns, Max: maxConns}
+	}
+}
+
+// WithDriverOptions applies settings which are common to all storage drivers.
+func WithDriverOptions(opts ...tessera.DriverOption) Option {
+	return func(s *Storage) {
+		s.driverOpts.Apply(opts...)
+	}
+}
+
+// New creates a new instance of the CockroachDB-based Storage, which keeps the log in db.
+//
+// The schema in this package's schema.sql must already have been applied to the database.
+//
+// Metrics describing the saturation of the provided database connection pool are exported, and the pool
+// adapted if WithAdaptiveConns is set, until ctx is done.
+func New(ctx context.Context, db *sql.DB, opts ...Option) (*Storage, error) {
+	s := &Storage{
+		db: db,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	store, err := sqlcommon.NewStore(db, sqlcommon.CockroachDB)
+	if err != nil {
+		return nil, err
+	}
+	s.store = store
+	if err := s.db.PingContext(ctx); err != nil {
+		klog.Errorf("Failed to ping database: %v", err)
+		return nil, err
+	}
+	gotVersion, err := s.store.CompatibilityVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if gotVersion != schemaCompatibilityVersion {
+		return nil, fmt.Errorf("incompatible schema version: DB has Tessera compatibility version of %d, but version %d required", gotVersion, schemaCompatibilityVersion)
+	}
+	// The pool is shared by all of the appenders and migration targets constructed from s, so it's only
+	// monitored once.
+	storage.MonitorDBPool(ctx, s.db, s.poolBounds)
+	return s, nil
+}
+
+// validate checks that the Storage configuration is self-consistent, returning an error describing every problem found.
+func (s *Storage) validate() error {
+	var errs []error
+	if s.db == nil {
+		errs = append(errs, errors.New("db must not be nil"))
+	}
+	if s.poolBounds.Enabled() && (s.poolBounds.Min < 0 || s.poolBounds.Min > s.poolBounds.Max) {
+		errs = append(errs, fmt.Errorf("WithAdaptiveConns requires 0 <= minConns <= maxConns, got [%d, %d]", s.poolBounds.Min, s.poolBounds.Max))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid cockroachdb storage configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Appender creates a new appender which sequences and integrates entries in a single transaction.
+//
+// Note that `tessera.WithCheckpointSigner()` is mandatory in the `opts` argument.
+func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
+	if opts.CheckpointInterval() < minCheckpointInterval {
+		return nil, nil, fmt.Errorf("requested CheckpointInterval too low - %v < %v", opts.CheckpointInterval(), minCheckpointInterval)
+	}
+
+	reader := &logReader{Storage: s, readTimeout: opts.OperationTimeout(tessera.OperationRead)}
+	if opts.ReadOnly() {
+		return &tessera.Appender{}, reader, nil
+	}
+	metrics := storage.NewMetrics(opts.MetricsProvider(), "cockroachdb")
+	s.store.SetMetrics(metrics)
+	a := &appender{
+		s:               s,
+		newCheckpoint:   opts.CheckpointPublisher(reader, s.driverOpts.HTTPClient()),
+		notifyPublished: opts.CheckpointNotifier(),
+		cpUpdated:       make(chan struct{}, 1),
+	}
+	seqTimeout := storage.MinTimeout(opts.OperationTimeout(tessera.OperationSequence), opts.OperationTimeout(tessera.OperationIntegrate))
+	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.FlushWithTimeout(seqTimeout, a.sequenceBatch),
+		storage.WithMaxPending(uint64(opts.QueuePushbackMaxOutstanding())), storage.WithPriority(opts.PriorityFunc()),
+		storage.WithMetrics(metrics))
+
+	if err := s.store.EnsureIdentity(ctx, opts.LogIdentity()); err != nil {
+		return nil, nil, err
+	}
+	if err := s.store.InitTree(ctx); err != nil {
+		return nil, nil, fmt.Errorf("maybeInitTree: %v", err)
+	}
+	a.cpUpdated <- struct{}{}
+
+	go func(ctx context.Context, i time.Duration) {
+		t := time.NewTicker(i)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.cpUpdated:
+			case <-t.C:
+			}
+			if err := a.publishCheckpoint(ctx, i); err != nil {
+				klog.Warningf("publishCheckpoint: %v", err)
+			}
+		}
+	}(ctx, opts.CheckpointInterval())
+
+	return &tessera.Appender{
+		Add:      a.queue.Add,
+		AddBatch: a.queue.AddBatch,
+		Queued:   a.queue.Pending,
+		Flush:    a.queue.Flush,
+	}, reader, nil
+}
+
+// ReadCheckpoint returns the latest stored checkpoint.
+// If the checkpoint is not found, it returns tessera.ErrNotFound.
+func (s *Storage) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	return s.readCheckpoint(ctx, 0)
+}
+
+func (s *Storage) readCheckpoint(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := storage.WithTimeout(ctx, timeout)
+	defer cancel()
+	cp, _, err := s.store.ReadCheckpoint(ctx)
+	return cp, err
+}
+
+// ReadCheckpointModTime returns the time at which the latest checkpoint was published.
+// If the checkpoint is not found, it returns tessera.ErrNotFound.
+func (s *Storage) ReadCheckpointModTime(ctx context.Context) (time.Time, error) {
+	return s.readCheckpointModTime(ctx, 0)
+}
+
+func (s *Storage) readCheckpointModTime(ctx context.Context, timeout time.Duration) (time.Time, error) {
+	ctx, cancel := storage.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, at, err := s.store.ReadCheckpoint(ctx)
+	return at, err
+}
+
+// TreeState returns the state of the log as of its latest checkpoint.
+// If the checkpoint is not found, it returns tessera.ErrNotFound.
+func (s *Storage) TreeState(ctx context.Context) (tessera.TreeState, error) {
+	return s.treeState(ctx, 0)
+}
+
+func (s *Storage) treeState(ctx context.Context, timeout time.Duration) (tessera.TreeState, error) {
+	ctx, cancel := storage.WithTimeout(ctx, timeout)
+	defer cancel()
+	cp, at, err := s.store.ReadCheckpoint(ctx)
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	return tessera.NewTreeState(cp, at)
+}
+
+// ReadTile returns a full tile or a partial tile at the given level, index and treeSize.
+// If the tile is not found, it returns tessera.ErrNotFound.
+//
+// As with the MySQL driver, a larger tile than the one requested may be returned if one is available.
+func (s *Storage) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	return s.readTile(ctx, 0, level, index, p)
+}
+
+func (s *Storage) readTile(ctx context.Context, timeout time.Duration, level, index uint64, p uint8) ([]byte, error) {
+	return s.reads.Read(ctx, layout.TilePath(level, index, p), func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := storage.WithTimeout(ctx, timeout)
+		defer cancel()
+		return s.store.ReadTile(ctx, level, index, p)
+	})
+}
+
+// ReadEntryBundle returns the log entries at the given index.
+// If the entry bundle is not found, it returns tessera.ErrNotFound.
+func (s *Storage) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	return s.readEntryBundle(ctx, 0, index, p)
+}
+
+func (s *Storage) readEntryBundle(ctx context.Context, timeout time.Duration, index uint64, p uint8) ([]byte, error) {
+	return s.reads.Read(ctx, layout.EntriesPath(index, p), func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := storage.WithTimeout(ctx, timeout)
+		defer cancel()
+		return s.store.ReadEntryBundle(ctx, index, p)
+	})
+}
+
+// ReadEntryBundles returns the entry bundles at each of the given addresses, in the order requested.
+// As with ReadEntryBundle, a request for a partial bundle returns the bundle as it's currently stored,
+// which may hold more entries than were requested.
+// If any of the entry bundles are not found, or hold fewer entries than requested, it returns an error
+// which wraps tessera.ErrNotFound.
+//
+// This is part of the tessera BulkLogReader contract.
+func (s *Storage) ReadEntryBundles(ctx context.Context, addrs []tessera.BundleAddr) ([][]byte, error) {
+	return s.readEntryBundles(ctx, 0, addrs)
+}
+
+func (s *Storage) readEntryBundles(ctx context.Context, timeout time.Duration, addrs []tessera.BundleAddr) ([][]byte, error) {
+	r := make([][]byte, len(addrs))
+	for start := 0; start < len(addrs); start += maxBundlesPerQuery {
+		end := min(start+maxBundlesPerQuery, len(addrs))
+		if err := func() error {
+			ctx, cancel := storage.WithTimeout(ctx, timeout)
+			defer cancel()
+			return s.store.ReadEntryBundles(ctx, addrs[start:end], r[start:end])
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// IntegratedSize returns the current size of the integrated tree.
+//
+// This is part of the tessera LogReader contract.
+func (s *Storage) IntegratedSize(ctx context.Context) (uint64, error) {
+	ts, err := s.store.ReadTreeState(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("readTreeState: %v", err)
+	}
+	return ts.Size, nil
+}
+
+// NextIndex returns the next available leaf index.
+//
+// Entries are integrated as they're sequenced, so this is always the same as the integrated size.
+// This is part of the tessera LogReader contract.
+func (s *Storage) NextIndex(ctx context.Context) (uint64, error) {
+	return s.IntegratedSize(ctx)
+}
+
+// StreamEntries() returns functions `next` and `cancel` which act like a pull iterator for
+// consecutive entry bundles, starting with the entry bundle which contains the requested entry
+// index.
+//
+// This is part of the tessera LogReader contract.
+func (s *Storage) StreamEntries(ctx context.Context, fromEntry uint64) (next func() (ri layout.RangeInfo, bundle []byte, err error), cancel func()) {
+	return s.store.StreamEntries(ctx, fromEntry)
+}
+
+// logReader is the tessera.LogReader returned alongside an appender, which bounds each read query by the
+// appender's tessera.OperationRead timeout. The timeout is kept here, rather than on Storage, so that
+// appenders created from the same Storage don't change each other's timeouts.
+type logReader struct {
+	*Storage
+	readTimeout time.Duration
+}
+
+func (r *logReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	return r.readCheckpoint(ctx, r.readTimeout)
+}
+
+func (r *logReader) ReadCheckpointModTime(ctx context.Context) (time.Time, error) {
+	return r.readCheckpointModTime(ctx, r.readTimeout)
+}
+
+func (r *logReader) TreeState(ctx context.Context) (tessera.TreeState, error) {
+	return r.treeState(ctx, r.readTimeout)
+}
+
+func (r *logReader) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	return r.readTile(ctx, r.readTimeout, level, index, p)
+}
+
+func (r *logReader) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	return r.readEntryBundle(ctx, r.readTimeout, index, p)
+}
+
+func (r *logReader) ReadEntryBundles(ctx context.Context, addrs []tessera.BundleAddr) ([][]byte, error) {
+	return r.readEntryBundles(ctx, r.readTimeout, addrs)
+}
+
+// appender implements the tessera Append lifecycle.
+type appender struct {
+	s               *Storage
+	queue           *storage.Queue
+	newCheckpoint   func(context.Context, uint64, []byte) ([]byte, error)
+	notifyPublished func(tessera.PublishedCheckpoint)
+	cpUpdated       chan struct{}
+}
+
+// publishCheckpoint stores a new checkpoint for the current tree state if one hasn't been published
+// in the last interval.
+func (a *appender) publishCheckpoint(ctx context.Context, interval time.Duration) error {
+	cp, err := a.s.store.PublishCheckpoint(ctx, interval, a.newCheckpoint)
+	if err != nil {
+		return err
+	}
+	if cp != nil {
+		a.notifyPublished(*cp)
+	}
+	return nil
+}
+
+// sequenceBatch sequences and integrates the batch of entries, and then prompts the checkpoint publisher.
+func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry) error {
+	err := a.s.store.SequenceBatch(ctx, entries)
+
+	select {
+	case a.cpUpdated <- struct{}{}:
+	default:
+	}
+
+	return err
+}
+
+// MigrationWriter creates a new CockroachDB storage for the MigrationTarget lifecycle mode.
+func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOptions) (tessera.MigrationWriter, tessera.LogReader, error) {
+	if err := s.store.InitTree(ctx); err != nil {
+		return nil, nil, fmt.Errorf("maybeInitTree: %v", err)
+	}
+
+	return &MigrationStorage{
+		s:            s,
+		bundleHasher: opts.LeafHasher(),
+	}, s, nil
+}
+
+// MigrationStorage implements the tessera.MigrationTarget lifecycle contract.
+type MigrationStorage struct {
+	s            *Storage
+	bundleHasher func([]byte) ([][]byte, error)
+}
+
+var _ tessera.MigrationWriter = &MigrationStorage{}
+
+// AwaitIntegration blocks until the local integrated tree has grown to the provided size, integrating
+// the entry bundles which have been written so far as it goes.
+//
+// This implements part of the tessera MigrationTarget lifecycle contract.
+func (m *MigrationStorage) AwaitIntegration(ctx context.Context, sourceSize uint64) ([]byte, error) {
+	return m.s.store.AwaitIntegration(ctx, sourceSize, m.bundleHasher)
+}
+
+// SetEntryBundle stores the provided serialised entry bundle at the location implied by the provided
+// entry bundle index and partial size.
+//
+// Implements the tessera MigrationTarget lifecycle contract.
+func (m *MigrationStorage) SetEntryBundle(ctx context.Context, index uint64, partial uint8, bundle []byte) error {
+	return m.s.store.WriteEntryBundle(ctx, m.s.db, index, uint32(partial), bundle)
+}
+
+// IntegratedSize returns the current size of the locally integrated log.
+//
+// Implements the tessera MigrationTarget lifecycle contract.
+func (m *MigrationStorage) IntegratedSize(ctx context.Context) (uint64, error) {
+	return m.s.IntegratedSize(ctx)
+}
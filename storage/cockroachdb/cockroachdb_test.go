@@ -0,0 +1,118 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/storagetest"
+)
+
+var (
+	cockroachDBURI            = flag.String("cockroachdb_uri", "postgresql://root@localhost:26257/test_tessera?sslmode=disable", "Connection string for a CockroachDB database")
+	isCockroachDBTestOptional = flag.Bool("is_cockroachdb_test_optional", true, "Boolean value to control whether the CockroachDB test is optional")
+)
+
+// openTestDB opens the CockroachDB test database, with the tables dropped and the schema reapplied.
+// If the database isn't available, the test is skipped if is_cockroachdb_test_optional is set, and fails otherwise.
+func openTestDB(t *testing.T, ctx context.Context) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("postgres", *cockroachDBURI)
+	if err != nil {
+		if *isCockroachDBTestOptional {
+			t.Skipf("CockroachDB not available, skipping test: %v", err)
+		}
+		t.Fatalf("Failed to open CockroachDB test db: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Failed to close CockroachDB test db: %v", err)
+		}
+	})
+	if err := db.PingContext(ctx); err != nil {
+		if *isCockroachDBTestOptional {
+			t.Skipf("CockroachDB not available, skipping test: %v", err)
+		}
+		t.Fatalf("Failed to ping CockroachDB test db: %v", err)
+	}
+
+	rawSchema, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatalf("Failed to read schema.sql: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS "Tessera", "Checkpoint", "TreeState", "Subtree", "TiledLeaves", "LogIdentity"`); err != nil {
+		t.Fatalf("Failed to drop all tables: %v", err)
+	}
+	// Without arguments, the statements in the schema are sent in a single simple query.
+	if _, err := db.ExecContext(ctx, string(rawSchema)); err != nil {
+		t.Fatalf("Failed to execute init database schema: %v", err)
+	}
+	return db
+}
+
+func TestNewValidation(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		db      *sql.DB
+		opts    []Option
+		wantErr []string
+	}{
+		{
+			name:    "nil db",
+			wantErr: []string{"db must not be nil"},
+		}, {
+			name:    "all problems reported",
+			opts:    []Option{WithAdaptiveConns(10, 5)},
+			wantErr: []string{"db must not be nil", "WithAdaptiveConns"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(t.Context(), test.db, test.opts...)
+			if err == nil {
+				t.Fatal("New: want error")
+			}
+			for _, want := range test.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("New: got %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageContract(t *testing.T) {
+	storagetest.RunAppenderTests(t, func(t *testing.T) tessera.Driver {
+		s, err := New(t.Context(), openTestDB(t, t.Context()))
+		if err != nil {
+			t.Fatalf("Failed to create cockroachdb.Storage: %v", err)
+		}
+		return s
+	})
+}
+
+func TestChaos(t *testing.T) {
+	db := openTestDB(t, t.Context())
+	storagetest.RunChaosTests(t, func(ctx context.Context) (tessera.Driver, error) {
+		return New(ctx, db)
+	})
+}
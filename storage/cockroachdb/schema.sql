@@ -0,0 +1,57 @@
+-- Copyright 2025 The Tessera authors. All Rights Reserved.
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+-- CockroachDB version of the Trillian Tessera database schema.
+--
+-- The tables and columns are the same as those of the MySQL schema, which documents them in more detail.
+-- Identifiers are quoted because they're case sensitive, and some of them are keywords.
+-- CockroachDB has no unsigned integers, but none of the values stored come close to the limits of the
+-- signed types used instead.
+
+CREATE TABLE IF NOT EXISTS "Tessera" (
+  "id"                   INT2 NOT NULL PRIMARY KEY,
+  "compatibilityVersion" INT8 NOT NULL
+);
+
+INSERT INTO "Tessera" ("id", "compatibilityVersion") VALUES (0, 1) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS "Checkpoint" (
+  "id"           INT2 NOT NULL PRIMARY KEY,
+  "note"         BYTES NOT NULL,
+  "published_at" INT8 NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "TreeState" (
+  "id"   INT2 NOT NULL PRIMARY KEY,
+  "size" INT8 NOT NULL,
+  "root" BYTES NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "Subtree" (
+  "level" INT2 NOT NULL,
+  "index" INT8 NOT NULL,
+  "nodes" BYTES NOT NULL,
+  PRIMARY KEY ("level", "index")
+);
+
+CREATE TABLE IF NOT EXISTS "TiledLeaves" (
+  "tile_index" INT8 NOT NULL PRIMARY KEY,
+  "size"       INT4 NOT NULL,
+  "data"       BYTES NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "LogIdentity" (
+  "id"       INT2 NOT NULL PRIMARY KEY,
+  "identity" BYTES NOT NULL
+);
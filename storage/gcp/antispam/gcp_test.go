@@ -17,6 +17,7 @@ package gcp
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -128,6 +129,19 @@ func TestAntispamStorage(t *testing.T) {
 					t.Errorf("got index %d, want %d from looking up hash %x", gotIndex, wantIndex, e.entryHash)
 				}
 			}
+
+			for _, e := range test.lookupEntries {
+				gotIndex, err := as.LookupIndex(ctx, e.entryHash)
+				if e.wantNotFound {
+					if !errors.Is(err, tessera.ErrNotFound) {
+						t.Errorf("LookupIndex(%x): got err %v, want %v", e.entryHash, err, tessera.ErrNotFound)
+					}
+					continue
+				}
+				if wantIndex := entryIndex[string(e.entryHash)]; err != nil || gotIndex != wantIndex {
+					t.Errorf("LookupIndex(%x) = %d, %v, want %d", e.entryHash, gotIndex, err, wantIndex)
+				}
+			}
 		})
 	}
 }
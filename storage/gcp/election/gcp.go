@@ -0,0 +1,178 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcp contains a Spanner-based election.Lease implementation for Tessera.
+//
+// The lease is stored in a table in the Spanner database, which may be the same database used
+// by the GCP storage driver for coordination.
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"google.golang.org/grpc/codes"
+	"k8s.io/klog/v2"
+)
+
+// TableDDL is the statement which creates the table in which leases are stored.
+const TableDDL = "CREATE TABLE IF NOT EXISTS Lease (name STRING(MAX) NOT NULL, holder STRING(MAX) NOT NULL, expiry INT64 NOT NULL) PRIMARY KEY (name)"
+
+// ErrNotHeld is returned by Check when the lease isn't held by the given holder.
+var ErrNotHeld = errors.New("lease is held by another holder")
+
+// Lease is an election.Lease stored in a Spanner database.
+type Lease struct {
+	name   string
+	dbPool *spanner.Client
+	// ownsPool is set if dbPool was created by NewLease, and so should be closed by Close.
+	ownsPool bool
+}
+
+// NewLease returns a Lease with the given name, stored in the provided Spanner database.
+//
+// Several independent leases may be stored in the same database by using different names.
+func NewLease(ctx context.Context, spannerDB string, name string) (*Lease, error) {
+	if err := createTables(ctx, spannerDB); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %v", err)
+	}
+	db, err := spanner.NewClient(ctx, spannerDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Spanner: %v", err)
+	}
+	return &Lease{
+		name:     name,
+		dbPool:   db,
+		ownsPool: true,
+	}, nil
+}
+
+// NewLeaseWithClient returns a Lease with the given name, stored in the database which dbPool is
+// connected to.
+//
+// This allows the lease to be checked within the caller's own transactions on that database, using Check.
+// The caller must have created the table given by TableDDL, and remains responsible for closing dbPool.
+func NewLeaseWithClient(dbPool *spanner.Client, name string) *Lease {
+	return &Lease{
+		name:   name,
+		dbPool: dbPool,
+	}
+}
+
+// TryAcquire implements election.Lease.
+func (l *Lease) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	acquired := false
+	_, err := l.dbPool.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		acquired = false
+		now := time.Now()
+		row, err := txn.ReadRow(ctx, "Lease", spanner.Key{l.name}, []string{"holder", "expiry"})
+		if err != nil && spanner.ErrCode(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			var curHolder string
+			var expiry int64
+			if err := row.Columns(&curHolder, &expiry); err != nil {
+				return fmt.Errorf("failed to read lease: %v", err)
+			}
+			if curHolder != holder && now.Before(time.UnixMicro(expiry)) {
+				return nil
+			}
+		}
+		acquired = true
+		return txn.BufferWrite([]*spanner.Mutation{
+			spanner.InsertOrUpdate("Lease", []string{"name", "holder", "expiry"}, []any{l.name, holder, now.Add(ttl).UnixMicro()}),
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update lease: %v", err)
+	}
+	return acquired, nil
+}
+
+// Release implements election.Lease.
+func (l *Lease) Release(ctx context.Context, holder string) error {
+	_, err := l.dbPool.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		if err := l.Check(ctx, txn, holder); err != nil {
+			if errors.Is(err, ErrNotHeld) {
+				return nil
+			}
+			return err
+		}
+		return txn.BufferWrite([]*spanner.Mutation{spanner.Delete("Lease", spanner.Key{l.name})})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release lease: %v", err)
+	}
+	return nil
+}
+
+// Check returns ErrNotHeld unless holder is the most recent holder of the lease.
+//
+// It must be called within the transaction whose commit it is guarding, so that the transaction
+// fails to commit if another holder acquires the lease in the meantime. An expired lease which
+// nobody else has taken is still considered held, as no other holder can have done any work.
+func (l *Lease) Check(ctx context.Context, txn *spanner.ReadWriteTransaction, holder string) error {
+	row, err := txn.ReadRow(ctx, "Lease", spanner.Key{l.name}, []string{"holder"})
+	if err != nil {
+		if spanner.ErrCode(err) == codes.NotFound {
+			return ErrNotHeld
+		}
+		return fmt.Errorf("failed to read lease: %w", err)
+	}
+	var curHolder string
+	if err := row.Column(0, &curHolder); err != nil {
+		return fmt.Errorf("failed to read lease: %v", err)
+	}
+	if curHolder != holder {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Close releases the resources held by the Lease. A Lease created with NewLeaseWithClient doesn't
+// close the client it was given.
+func (l *Lease) Close() {
+	if l.ownsPool {
+		l.dbPool.Close()
+	}
+}
+
+func createTables(ctx context.Context, spannerDB string) error {
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := adminClient.Close(); err != nil {
+			klog.Warningf("adminClient.Close(): %v", err)
+		}
+	}()
+
+	op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database: spannerDB,
+		Statements: []string{
+			TableDDL,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return op.Wait(ctx)
+}
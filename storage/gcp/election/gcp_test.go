@@ -0,0 +1,135 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spannertest"
+	"github.com/transparency-dev/tessera/election"
+)
+
+var _ election.Lease = &Lease{}
+
+func TestLease(t *testing.T) {
+	ctx := t.Context()
+	srv, err := spannertest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to set up test spanner: %v", err)
+	}
+	defer srv.Close()
+	if err := os.Setenv("SPANNER_EMULATOR_HOST", srv.Addr); err != nil {
+		t.Fatalf("Setenv: %v", err)
+	}
+
+	l, err := NewLease(ctx, "projects/p/instances/i/databases/d", "log")
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	defer l.Close()
+
+	for _, step := range []struct {
+		desc    string
+		holder  string
+		ttl     time.Duration
+		release bool
+		want    bool
+	}{
+		{desc: "acquire unheld", holder: "a", ttl: time.Hour, want: true},
+		{desc: "renew", holder: "a", ttl: time.Hour, want: true},
+		{desc: "contend", holder: "b", ttl: time.Hour, want: false},
+		{desc: "release by non-holder is ignored", holder: "b", release: true},
+		{desc: "still held", holder: "b", ttl: time.Hour, want: false},
+		{desc: "release", holder: "a", release: true},
+		{desc: "acquire released", holder: "b", ttl: -time.Second, want: true},
+		{desc: "acquire expired", holder: "a", ttl: time.Hour, want: true},
+	} {
+		if step.release {
+			if err := l.Release(ctx, step.holder); err != nil {
+				t.Fatalf("%s: Release: %v", step.desc, err)
+			}
+			continue
+		}
+		got, err := l.TryAcquire(ctx, step.holder, step.ttl)
+		if err != nil {
+			t.Fatalf("%s: TryAcquire: %v", step.desc, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: TryAcquire(%q) = %t, want %t", step.desc, step.holder, got, step.want)
+		}
+	}
+}
+
+func TestLeaseCheck(t *testing.T) {
+	ctx := t.Context()
+	srv, err := spannertest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to set up test spanner: %v", err)
+	}
+	defer srv.Close()
+	if err := os.Setenv("SPANNER_EMULATOR_HOST", srv.Addr); err != nil {
+		t.Fatalf("Setenv: %v", err)
+	}
+	const db = "projects/p/instances/i/databases/d"
+	if err := createTables(ctx, db); err != nil {
+		t.Fatalf("createTables: %v", err)
+	}
+	dbPool, err := spanner.NewClient(ctx, db)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer dbPool.Close()
+
+	l := NewLeaseWithClient(dbPool, "log")
+	// Closing a lease doesn't close the client it was given.
+	defer l.Close()
+	check := func(holder string) error {
+		_, err := dbPool.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			return l.Check(ctx, txn, holder)
+		})
+		return err
+	}
+
+	if err := check("a"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Check(a) on unheld lease: got %v, want %v", err, ErrNotHeld)
+	}
+	if ok, err := l.TryAcquire(ctx, "a", time.Hour); err != nil || !ok {
+		t.Fatalf("TryAcquire(a) = %t, %v, want true", ok, err)
+	}
+	if err := check("a"); err != nil {
+		t.Errorf("Check(a) by holder: %v", err)
+	}
+	if err := check("b"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Check(b) by non-holder: got %v, want %v", err, ErrNotHeld)
+	}
+	// An expired lease is still held until somebody else takes it.
+	if ok, err := l.TryAcquire(ctx, "a", -time.Second); err != nil || !ok {
+		t.Fatalf("TryAcquire(a) = %t, %v, want true", ok, err)
+	}
+	if err := check("a"); err != nil {
+		t.Errorf("Check(a) by holder of expired lease: %v", err)
+	}
+	if ok, err := l.TryAcquire(ctx, "b", time.Hour); err != nil || !ok {
+		t.Fatalf("TryAcquire(b) = %t, %v, want true", ok, err)
+	}
+	if err := check("a"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Check(a) after takeover: got %v, want %v", err, ErrNotHeld)
+	}
+}
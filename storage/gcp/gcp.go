@@ -26,6 +26,10 @@
 //
 // A Spanner database provides a transactional mechanism to allow multiple
 // frontends to safely update the contents of the log.
+//
+// Alternatively, if Config.GCSCoordination is set, the coordination state is
+// kept in GCS instead of Spanner. This mode is intended for small, single-writer
+// deployments where the cost of a Spanner instance isn't justified.
 package gcp
 
 import (
@@ -38,6 +42,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -54,6 +60,7 @@ import (
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/internal/otel"
 	"github.com/transparency-dev/tessera/internal/stream"
+	gcp_election "github.com/transparency-dev/tessera/storage/gcp/election"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/googleapi"
@@ -69,12 +76,23 @@ const (
 	// room.
 	minCheckpointInterval = 1200 * time.Millisecond
 
+	// defaultIntegrationTimeout is the default timeout for each integration pass, used unless
+	// overridden with tessera.AppendOptions.WithOperationTimeout.
+	defaultIntegrationTimeout = 10 * time.Second
+
+	// integrationLeaseName is the name of the lease which must be held to integrate entries, when
+	// WithMultiInstance is used.
+	integrationLeaseName = "integration"
+
 	logContType      = "application/octet-stream"
 	ckptContType     = "text/plain; charset=utf-8"
 	logCacheControl  = "max-age=604800,immutable"
 	ckptCacheControl = "no-cache"
 
-	DefaultIntegrationSizeLimit = 5 * 4096
+	// DefaultIntegrationSizeLimit is the default maximum number of entries which will be integrated in one pass.
+	//
+	// Deprecated: Use tessera.AppendOptions.WithIntegrationBatchSize to configure this instead.
+	DefaultIntegrationSizeLimit = tessera.DefaultIntegrationBatchSize
 
 	// SchemaCompatibilityVersion represents the expected version (e.g. layout & serialisation) of stored data.
 	//
@@ -89,6 +107,56 @@ const (
 // Storage is a GCP based storage implementation for Tessera.
 type Storage struct {
 	cfg Config
+	// driverOpts holds settings common to all storage drivers.
+	driverOpts tessera.DriverOptions
+	// multiInstance is set if several instances may append to the log concurrently.
+	multiInstance bool
+	// instance uniquely identifies this instance when multiInstance is set.
+	instance string
+	// objStore, if set, is used in place of the GCS bucket described by cfg. This allows tests to run
+	// the driver against in-memory storage.
+	objStore objStore
+	// stateObjStore, if set, is used in place of the GCS bucket described by cfg.StateBucket.
+	stateObjStore objStore
+}
+
+// Option is a function which configures optional behaviour of the GCP Storage.
+type Option func(*Storage)
+
+// WithDriverOptions applies settings which are common to all storage drivers.
+func WithDriverOptions(opts ...tessera.DriverOption) Option {
+	return func(s *Storage) {
+		s.driverOpts.Apply(opts...)
+	}
+}
+
+// WithMultiInstance declares that several instances of the personality may append to the log at the
+// same time, for example when it's deployed as a Cloud Run service which is allowed to scale beyond a
+// single instance. Without this option, running more than one instance is not supported.
+//
+// The instance string must uniquely identify this instance amongst all those serving the log, e.g. the
+// Cloud Run instance ID from the metadata server, or the hostname.
+//
+// All instances continue to sequence entries, but they share a lease in Spanner so that only one of them
+// at a time integrates entries and publishes checkpoints. If the lease holder goes away, e.g. because it
+// was scaled down mid-integration, another instance takes over once the lease expires and repeats any
+// integration which didn't complete; since tiles and bundles are derived deterministically from the
+// sequenced entries, this is safe. The lease is released when the context passed to
+// tessera.NewAppender is cancelled, so an instance should cancel it on SIGTERM for a quick handover.
+//
+// Running in this mode requires that:
+//   - Config.Spanner is set; New returns an error if it isn't.
+//   - CPU is allocated to instances outside of request handling (on Cloud Run, instance-based billing
+//     or --no-cpu-throttling), as integration and checkpoint publishing run in the background.
+//   - All instances use the same tessera.AppendOptions, in particular the same checkpoint signer(s)
+//     and entries path.
+//   - Antispam, if used, is persistent (see the antispam package), since in-memory deduplication
+//     isn't shared between instances.
+func WithMultiInstance(instance string) Option {
+	return func(s *Storage) {
+		s.multiInstance = true
+		s.instance = instance
+	}
 }
 
 // sequencer describes a type which knows how to sequence entries.
@@ -104,6 +172,9 @@ type sequencer interface {
 	// If forceUpdate is true, then the consumeFunc should be called, with an empty slice of entries if
 	// necessary. This allows the log self-initialise in a transactionally safe manner.
 	consumeEntries(ctx context.Context, limit uint64, f consumeFunc, forceUpdate bool) (bool, error)
+	// ensureIdentity fails if the stored log identity is incompatible with id, and stores id if
+	// there isn't one yet.
+	ensureIdentity(ctx context.Context, id tessera.LogIdentity) error
 	// currentTree returns the tree state of the currently integrated tree according to the IntCoord table.
 	currentTree(ctx context.Context) (uint64, []byte, error)
 	// nextIndex returns the next available index in the log.
@@ -123,47 +194,198 @@ type Config struct {
 	// This can be used e.g. to store multiple logs in the same bucket.
 	BucketPrefix string
 	// Spanner is the GCP resource URI of the spanner database instance to use.
+	//
+	// Exactly one of Spanner and GCSCoordination must be set.
 	Spanner string
+	// GCSCoordination, if true, stores the log's coordination state in GCS under .state/, instead of in
+	// Spanner, using object generation preconditions to detect conflicting updates. This removes the need
+	// for Spanner entirely, but is only suitable for logs with a single writer and modest write rates: GCS
+	// limits updates to a single object to around one per second, and the state is updated on each
+	// sequenced batch. Use tessera.AppendOptions.WithBatching to keep batches large and infrequent in this
+	// mode. Migration targets still require Spanner.
+	//
+	// A log can't be switched from Spanner to GCS coordination: the state is only created for a log which
+	// hasn't yet published a checkpoint.
+	GCSCoordination bool
+	// StateBucket is the name of the GCS bucket in which the coordination state is stored when
+	// GCSCoordination is set. If it's empty, the state is stored in Bucket.
+	//
+	// The state includes sequenced entries which have yet to be integrated, so if Bucket is publicly
+	// readable then StateBucket should be set to a private bucket.
+	StateBucket string
+	// StateBucketPrefix is an optional prefix to prepend to the paths of the coordination state in StateBucket.
+	StateBucketPrefix string
+}
+
+// validate checks that the Config is self-consistent, returning an error describing every problem found.
+func (c Config) validate() error {
+	var errs []error
+	switch {
+	case c.Bucket == "":
+		errs = append(errs, errors.New("Bucket must be set"))
+	case strings.Contains(c.Bucket, "/"):
+		errs = append(errs, fmt.Errorf("Bucket %q must be a bare bucket name without a gs:// scheme or path; use BucketPrefix to place the log under a path", c.Bucket))
+	}
+	if strings.HasPrefix(c.BucketPrefix, "/") || strings.HasSuffix(c.BucketPrefix, "/") {
+		errs = append(errs, fmt.Errorf("BucketPrefix %q must not start or end with /", c.BucketPrefix))
+	}
+	if strings.Contains(c.StateBucket, "/") {
+		errs = append(errs, fmt.Errorf("StateBucket %q must be a bare bucket name without a gs:// scheme or path; use StateBucketPrefix to place the state under a path", c.StateBucket))
+	}
+	if strings.HasPrefix(c.StateBucketPrefix, "/") || strings.HasSuffix(c.StateBucketPrefix, "/") {
+		errs = append(errs, fmt.Errorf("StateBucketPrefix %q must not start or end with /", c.StateBucketPrefix))
+	}
+	if c.StateBucket == "" && c.StateBucketPrefix != "" {
+		errs = append(errs, errors.New("StateBucketPrefix requires StateBucket to be set"))
+	}
+	switch {
+	case c.Spanner == "" && !c.GCSCoordination:
+		errs = append(errs, errors.New("one of Spanner or GCSCoordination must be set"))
+	case c.Spanner != "" && c.GCSCoordination:
+		errs = append(errs, errors.New("only one of Spanner or GCSCoordination may be set"))
+	}
+	if !c.GCSCoordination && c.StateBucket != "" {
+		errs = append(errs, errors.New("StateBucket is only used when GCSCoordination is set"))
+	}
+	if p := strings.Split(c.Spanner, "/"); c.Spanner != "" && (len(p) != 6 || p[0] != "projects" || p[2] != "instances" || p[4] != "databases" || slices.Contains(p, "")) {
+		errs = append(errs, fmt.Errorf("Spanner %q must be of the form projects/<project>/instances/<instance>/databases/<database>", c.Spanner))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid gcp.Config:\n%w", errors.Join(errs...))
+	}
+	return nil
 }
 
 // New creates a new instance of the GCP based Storage.
-func New(ctx context.Context, cfg Config) (tessera.Driver, error) {
-	return &Storage{
+func New(ctx context.Context, cfg Config, opts ...Option) (tessera.Driver, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	s := &Storage{
 		cfg: cfg,
-	}, nil
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.multiInstance {
+		switch {
+		case cfg.Spanner == "":
+			return nil, errors.New("WithMultiInstance requires Config.Spanner to be set")
+		case s.instance == "":
+			return nil, errors.New("WithMultiInstance requires a non-empty instance name")
+		}
+	}
+	return s, nil
 }
 
 type LogReader struct {
 	lrs            logResourceStore
 	integratedSize func(context.Context) (uint64, error)
 	nextIndex      func(context.Context) (uint64, error)
+	// reads coalesces concurrent reads of the same tile or entry bundle into a single GCS request.
+	reads storage.ReadCoalescer
+	// readTimeout, if positive, bounds the duration of each non-streaming read from GCS.
+	readTimeout time.Duration
 }
 
 func (lr *LogReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadCheckpoint")
 	defer span.End()
+	ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+	defer cancel()
 
 	r, err := lr.lrs.getCheckpoint(ctx)
 	if err != nil {
 		if errors.Is(err, gcs.ErrObjectNotExist) {
-			return r, os.ErrNotExist
+			return r, tessera.ErrNotFound
 		}
 	}
 	return r, err
 }
 
+func (lr *LogReader) ReadCheckpointModTime(ctx context.Context) (time.Time, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadCheckpointModTime")
+	defer span.End()
+	ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+	defer cancel()
+
+	t, err := lr.lrs.checkpointLastModified(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return t, tessera.ErrNotFound
+		}
+	}
+	return t, err
+}
+
+// TreeState returns the state of the log as of its latest checkpoint.
+//
+// The checkpoint's modification time is read before its contents, so that a concurrent publication can only
+// make the checkpoint seem older.
+func (lr *LogReader) TreeState(ctx context.Context) (tessera.TreeState, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.TreeState")
+	defer span.End()
+
+	at, err := lr.ReadCheckpointModTime(ctx)
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	cp, err := lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	return tessera.NewTreeState(cp, at)
+}
+
 func (lr *LogReader) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadTile")
 	defer span.End()
 
-	return lr.lrs.getTile(ctx, l, i, p)
+	return lr.reads.Read(ctx, layout.TilePath(l, i, p), func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+		defer cancel()
+		return lr.lrs.getTile(ctx, l, i, p)
+	})
 }
 
 func (lr *LogReader) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadEntryBundle")
 	defer span.End()
 
-	return lr.lrs.getEntryBundle(ctx, i, p)
+	return lr.reads.Read(ctx, lr.lrs.entriesPath(i, p), func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := storage.WithTimeout(ctx, lr.readTimeout)
+		defer cancel()
+		return lr.lrs.getEntryBundle(ctx, i, p)
+	})
+}
+
+func (lr *LogReader) ReadTileStream(ctx context.Context, l, i uint64, p uint8) (io.ReadCloser, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadTileStream")
+	defer span.End()
+
+	return lr.lrs.getTileReader(ctx, l, i, p)
+}
+
+func (lr *LogReader) ReadEntryBundleStream(ctx context.Context, i uint64, p uint8) (io.ReadCloser, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadEntryBundleStream")
+	defer span.End()
+
+	return lr.lrs.getEntryBundleReader(ctx, i, p)
+}
+
+func (lr *LogReader) ReadEntryBundleRange(ctx context.Context, i uint64, p uint8, offset, length int64) (io.ReadCloser, int64, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadEntryBundleRange")
+	defer span.End()
+
+	objName := lr.lrs.entriesPath(i, p)
+	r, size, err := lr.lrs.objStore.getObjectRangeReader(ctx, objName, offset, length)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, 0, fmt.Errorf("%v: %w", objName, tessera.ErrNotFound)
+		}
+		return nil, 0, err
+	}
+	return r, size, nil
 }
 
 func (lr *LogReader) IntegratedSize(ctx context.Context) (uint64, error) {
@@ -198,30 +420,62 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 		return nil, nil, fmt.Errorf("requested CheckpointInterval (%v) is less than minimum permitted %v", opts.CheckpointInterval(), minCheckpointInterval)
 	}
 
-	c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+	objStore, err := s.newObjStore(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create GCS client: %v", err)
+		return nil, nil, err
+	}
+	integrationTimeout := defaultIntegrationTimeout
+	if d := opts.OperationTimeout(tessera.OperationIntegrate); d > 0 {
+		integrationTimeout = d
 	}
 
-	seq, err := newSpannerCoordinator(ctx, s.cfg.Spanner, uint64(opts.PushbackMaxOutstanding()))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create Spanner coordinator: %v", err)
+	var seq sequencer
+	var lease *integrationLease
+	if s.cfg.GCSCoordination {
+		stateObjStore, err := s.newStateObjStore(ctx, objStore)
+		if err != nil {
+			return nil, nil, err
+		}
+		seq, err = newGCSCoordinator(ctx, stateObjStore, objStore, uint64(opts.PushbackMaxOutstanding()), !opts.ReadOnly())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create GCS coordinator: %v", err)
+		}
+	} else {
+		sc, err := newSpannerCoordinator(ctx, s.cfg.Spanner, uint64(opts.PushbackMaxOutstanding()), !opts.ReadOnly())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Spanner coordinator: %v", err)
+		}
+		if s.multiInstance {
+			// Allow a few integration passes to fail before another instance may take over.
+			lease = &integrationLease{
+				Lease:    gcp_election.NewLeaseWithClient(sc.dbPool, integrationLeaseName),
+				instance: s.instance,
+				ttl:      3 * integrationTimeout,
+			}
+			sc.lease = lease
+		}
+		seq = sc
+	}
+	if !opts.ReadOnly() {
+		if err := seq.ensureIdentity(ctx, opts.LogIdentity()); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	a := &Appender{
 		logStore: &logResourceStore{
-			objStore: &gcsStorage{
-				gcsClient:    c,
-				bucket:       s.cfg.Bucket,
-				bucketPrefix: s.cfg.BucketPrefix,
-			},
+			objStore:    objStore,
 			entriesPath: opts.EntriesPath(),
+			driverOpts:  s.driverOpts,
+			metrics:     storage.NewMetrics(opts.MetricsProvider(), "gcp"),
 		},
-		sequencer: seq,
-		cpUpdated: make(chan struct{}),
+		sequencer:              seq,
+		cpUpdated:              make(chan struct{}),
+		integrationParallelism: int(opts.IntegrationParallelism()),
+		integrationBatchSize:   uint64(opts.IntegrationBatchSize()),
+		integrationTimeout:     integrationTimeout,
+		lease:                  lease,
 	}
-	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), a.sequencer.assignEntries)
-
 	reader := &LogReader{
 		lrs: *a.logStore,
 		integratedSize: func(context.Context) (uint64, error) {
@@ -231,8 +485,16 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 		nextIndex: func(context.Context) (uint64, error) {
 			return a.sequencer.nextIndex(ctx)
 		},
+		readTimeout: opts.OperationTimeout(tessera.OperationRead),
+	}
+	if opts.ReadOnly() {
+		// Leave the bucket and Spanner state untouched; NewAppender supplies an Add which rejects all entries.
+		return &tessera.Appender{}, reader, nil
 	}
-	a.newCP = opts.CheckpointPublisher(reader, http.DefaultClient)
+	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.FlushWithTimeout(opts.OperationTimeout(tessera.OperationSequence), a.sequencer.assignEntries),
+		storage.WithPriority(opts.PriorityFunc()), storage.WithMetrics(a.logStore.metrics))
+	a.newCP = opts.CheckpointPublisher(reader, s.driverOpts.HTTPClient())
+	a.notifyPublished = opts.CheckpointNotifier()
 
 	if err := a.init(ctx); err != nil {
 		return nil, nil, fmt.Errorf("failed to initialise log storage: %v", err)
@@ -242,19 +504,47 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 	go a.publisherJob(ctx, opts.CheckpointInterval())
 
 	return &tessera.Appender{
-		Add: a.Add,
+		Add:      a.Add,
+		AddBatch: a.queue.AddBatch,
+		Queued:   a.queue.Pending,
+		Flush:    a.queue.Flush,
 	}, reader, nil
 }
 
+// integrationLease decides which one of several instances sharing a Spanner database integrates
+// sequenced entries and publishes checkpoints.
+//
+// The lease's expiry only governs how quickly a surviving instance takes over from one which has gone
+// away; correctness doesn't rely on clocks, since consumeEntries checks the lease in the same transaction
+// as it updates IntCoord, and so an instance which has been superseded can never commit an integration.
+// Similarly, publishCheckpoint only replaces the checkpoint if it hasn't changed since before the tree
+// was read, so a superseded instance can't replace a newer checkpoint with an older one.
+type integrationLease struct {
+	*gcp_election.Lease
+	// instance is the holder name of this instance.
+	instance string
+	ttl      time.Duration
+}
+
 // Appender is an implementation of the Tessera appender lifecycle contract.
 type Appender struct {
-	newCP func(context.Context, uint64, []byte) ([]byte, error)
+	newCP           func(context.Context, uint64, []byte) ([]byte, error)
+	notifyPublished func(tessera.PublishedCheckpoint)
 
 	sequencer sequencer
 	logStore  *logResourceStore
 
 	queue *storage.Queue
 
+	// integrationParallelism is the maximum number of concurrent writes performed while integrating entries.
+	integrationParallelism int
+	// integrationBatchSize is the maximum number of sequenced entries which will be integrated in one pass.
+	integrationBatchSize uint64
+	// integrationTimeout bounds the duration of each integration pass.
+	integrationTimeout time.Duration
+	// lease, if set, must be held in order to integrate entries and publish checkpoints.
+	lease *integrationLease
+
 	cpUpdated chan struct{}
 }
 
@@ -274,6 +564,14 @@ func (a *Appender) sequencerJob(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			if a.lease != nil {
+				// Hand over to another instance straight away rather than making it wait for expiry.
+				rctx, cancel := context.WithTimeout(context.Background(), a.integrationTimeout)
+				defer cancel()
+				if err := a.lease.Release(rctx, a.lease.instance); err != nil {
+					klog.Warningf("%v", err)
+				}
+			}
 			return
 		case <-t.C:
 		}
@@ -282,11 +580,20 @@ func (a *Appender) sequencerJob(ctx context.Context) {
 			ctx, span := tracer.Start(ctx, "tessera.storage.gcp.sequenceTask")
 			defer span.End()
 
+			if !a.holdsLease(ctx) {
+				span.AddEvent("Abort, lease held elsewhere")
+				return
+			}
+
 			// Don't quickloop for now, it causes issues updating checkpoint too frequently.
-			cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			cctx, cancel := context.WithTimeout(ctx, a.integrationTimeout)
 			defer cancel()
 
-			if _, err := a.sequencer.consumeEntries(cctx, DefaultIntegrationSizeLimit, a.appendEntries, false); err != nil {
+			if _, err := a.sequencer.consumeEntries(cctx, a.integrationBatchSize, a.appendEntries, false); err != nil {
+				if errors.Is(err, gcp_election.ErrNotHeld) {
+					klog.V(1).Infof("integrate: %v", err)
+					return
+				}
 				klog.Errorf("integrate: %v", err)
 				return
 			}
@@ -314,6 +621,13 @@ func (a *Appender) publisherJob(ctx context.Context, i time.Duration) {
 			ctx, span := tracer.Start(ctx, "tessera.storage.gcp.publishTask")
 			defer span.End()
 
+			// Only the instance integrating entries publishes checkpoints, otherwise an instance
+			// with a stale view of the tree could overwrite a newer checkpoint with an older one.
+			if !a.holdsLease(ctx) {
+				span.AddEvent("Abort, lease held elsewhere")
+				return
+			}
+
 			if err := a.publishCheckpoint(ctx, i); err != nil {
 				klog.Warningf("publishCheckpoint failed: %v", err)
 			}
@@ -321,16 +635,34 @@ func (a *Appender) publisherJob(ctx context.Context, i time.Duration) {
 	}
 }
 
+// holdsLease returns true if this instance may integrate entries and publish checkpoints, acquiring
+// or renewing the integration lease if one is in use.
+func (a *Appender) holdsLease(ctx context.Context) bool {
+	if a.lease == nil {
+		return true
+	}
+	ok, err := a.lease.TryAcquire(ctx, a.lease.instance, a.lease.ttl)
+	if err != nil {
+		klog.Warningf("%v", err)
+		return false
+	}
+	return ok
+}
+
 // init ensures that the storage represents a log in a valid state.
 func (a *Appender) init(ctx context.Context) error {
 	if _, err := a.logStore.getCheckpoint(ctx); err != nil {
 		if errors.Is(err, gcs.ErrObjectNotExist) {
+			if !a.holdsLease(ctx) {
+				// Another instance is responsible for integration, and will create the checkpoint.
+				return nil
+			}
 			// No checkpoint exists, do a forced (possibly empty) integration to create one in a safe
 			// way (setting the checkpoint directly here would not be safe as it's outside the transactional
 			// framework which prevents the tree from rolling backwards or otherwise forking).
-			cctx, c := context.WithTimeout(ctx, 10*time.Second)
+			cctx, c := context.WithTimeout(ctx, a.integrationTimeout)
 			defer c()
-			if _, err := a.sequencer.consumeEntries(cctx, DefaultIntegrationSizeLimit, a.appendEntries, true); err != nil {
+			if _, err := a.sequencer.consumeEntries(cctx, a.integrationBatchSize, a.appendEntries, true); err != nil && !errors.Is(err, gcp_election.ErrNotHeld) {
 				return fmt.Errorf("forced integrate: %v", err)
 			}
 			select {
@@ -358,6 +690,16 @@ func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 		return nil
 	}
 
+	start := time.Now()
+	// The lease may be lost, and another instance publish a newer checkpoint, at any point during this
+	// pass. Noting the generation of the checkpoint before reading the tree, and only replacing it if it's
+	// unchanged, ensures that the published checkpoint never goes backwards.
+	cond := &gcs.Conditions{DoesNotExist: true}
+	if _, gen, err := a.logStore.objStore.getObject(ctx, layout.CheckpointPath); err == nil {
+		cond = &gcs.Conditions{GenerationMatch: gen}
+	} else if !errors.Is(err, gcs.ErrObjectNotExist) {
+		return fmt.Errorf("getObject(%q): %v", layout.CheckpointPath, err)
+	}
 	size, root, err := a.sequencer.currentTree(ctx)
 	if err != nil {
 		return fmt.Errorf("currentTree: %v", err)
@@ -369,9 +711,15 @@ func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 		return fmt.Errorf("newCP: %v", err)
 	}
 
-	if err := a.logStore.setCheckpoint(ctx, cpRaw); err != nil {
+	if err := a.logStore.setCheckpoint(ctx, cpRaw, cond); err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			span.AddEvent("Abort, checkpoint published elsewhere")
+			return nil
+		}
 		return fmt.Errorf("writeCheckpoint: %v", err)
 	}
+	a.logStore.metrics.CheckpointPublished(ctx, start)
+	a.notifyPublished(tessera.PublishedCheckpoint{Size: size, Root: root, Checkpoint: cpRaw})
 
 	klog.V(2).Infof("Published latest checkpoint: %d, %x", size, root)
 
@@ -382,7 +730,14 @@ func (a *Appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 // objStore describes a type which can store and retrieve objects.
 type objStore interface {
 	getObject(ctx context.Context, obj string) ([]byte, int64, error)
-	setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, contType string, cacheCtl string) error
+	getObjectReader(ctx context.Context, obj string) (io.ReadCloser, error)
+	getObjectRangeReader(ctx context.Context, obj string, offset, length int64) (io.ReadCloser, int64, error)
+	setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, md tessera.ObjectMetadata) error
+	// casObject stores data in obj only if obj's current generation is gen, or if gen is zero, only
+	// if obj doesn't exist. It returns the new generation of obj, or an error wrapping
+	// errPreconditionFailed if the generation didn't match.
+	casObject(ctx context.Context, obj string, data []byte, gen int64) (int64, error)
+	deleteObject(ctx context.Context, obj string) error
 	lastModified(ctx context.Context, obj string) (time.Time, error)
 }
 
@@ -390,10 +745,32 @@ type objStore interface {
 type logResourceStore struct {
 	objStore    objStore
 	entriesPath func(uint64, uint8) string
+	// driverOpts holds any object metadata configured to override the defaults.
+	driverOpts tessera.DriverOptions
+	// metrics, which may be nil, records tile writes and checkpoint publishing.
+	metrics *storage.Metrics
 }
 
-func (lrs *logResourceStore) setCheckpoint(ctx context.Context, cpRaw []byte) error {
-	return lrs.objStore.setObject(ctx, layout.CheckpointPath, cpRaw, nil, ckptContType, ckptCacheControl)
+// errTooManyOutstanding is returned when sequencing more entries would take the number which have been
+// sequenced but not yet integrated past the configured maximum. Submitters are asked to wait for about
+// as long as an integration pass takes to make some room.
+var errTooManyOutstanding = &tessera.PushbackError{Reason: "too many outstanding entries", RetryAfter: time.Second}
+
+// defaultObjectMetadata is attached to each class of resource unless overridden with tessera.WithObjectMetadata.
+var defaultObjectMetadata = map[tessera.ResourceClass]tessera.ObjectMetadata{
+	tessera.ResourceCheckpoint:  {ContentType: ckptContType, CacheControl: ckptCacheControl},
+	tessera.ResourceTile:        {ContentType: logContType, CacheControl: logCacheControl},
+	tessera.ResourceEntryBundle: {ContentType: logContType, CacheControl: logCacheControl},
+}
+
+// metadata returns the metadata to attach to resources of the given class.
+func (lrs *logResourceStore) metadata(class tessera.ResourceClass) tessera.ObjectMetadata {
+	return lrs.driverOpts.ObjectMetadata(class, defaultObjectMetadata[class])
+}
+
+// setCheckpoint stores the checkpoint, subject to cond if it's not nil.
+func (lrs *logResourceStore) setCheckpoint(ctx context.Context, cpRaw []byte, cond *gcs.Conditions) error {
+	return lrs.objStore.setObject(ctx, layout.CheckpointPath, cpRaw, cond, lrs.metadata(tessera.ResourceCheckpoint))
 }
 
 func (lrs *logResourceStore) checkpointLastModified(ctx context.Context) (time.Time, error) {
@@ -411,16 +788,43 @@ func (lrs *logResourceStore) getCheckpoint(ctx context.Context) ([]byte, error)
 // The location to which the tile is written is defined by the tile layout spec.
 func (s *logResourceStore) setTile(ctx context.Context, level, index uint64, partial uint8, data []byte) error {
 	tPath := layout.TilePath(level, index, partial)
-	return s.objStore.setObject(ctx, tPath, data, &gcs.Conditions{DoesNotExist: true}, logContType, logCacheControl)
+	if err := s.objStore.setObject(ctx, tPath, data, &gcs.Conditions{DoesNotExist: true}, s.metadata(tessera.ResourceTile)); err != nil {
+		return err
+	}
+	s.metrics.TileWritten(ctx, level)
+	return nil
 }
 
 // getTile retrieves the raw tile from the provided location.
 //
 // The location to which the tile is written is defined by the tile layout spec.
+// Returns a wrapped tessera.ErrNotFound if the tile does not exist.
 func (s *logResourceStore) getTile(ctx context.Context, level, index uint64, partial uint8) ([]byte, error) {
 	tPath := layout.TilePath(level, index, partial)
 	d, _, err := s.objStore.getObject(ctx, tPath)
-	return d, err
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%v: %w", tPath, tessera.ErrNotFound)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// getTileReader returns a reader for the raw tile at the provided location.
+//
+// The caller must close the returned reader.
+// Returns a wrapped tessera.ErrNotFound if the tile does not exist.
+func (s *logResourceStore) getTileReader(ctx context.Context, level, index uint64, partial uint8) (io.ReadCloser, error) {
+	tPath := layout.TilePath(level, index, partial)
+	r, err := s.objStore.getObjectReader(ctx, tPath)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%v: %w", tPath, tessera.ErrNotFound)
+		}
+		return nil, err
+	}
+	return r, nil
 }
 
 // getTiles returns the tiles with the given tile-coords for the specified log size.
@@ -463,7 +867,7 @@ func (s *logResourceStore) getTiles(ctx context.Context, tileIDs []storage.TileI
 // getEntryBundle returns the serialised entry bundle at the location described by the given index and partial size.
 // A partial size of zero implies a full tile.
 //
-// Returns a wrapped os.ErrNotExist if the bundle does not exist.
+// Returns a wrapped tessera.ErrNotFound if the bundle does not exist.
 func (s *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint64, p uint8) ([]byte, error) {
 	objName := s.entriesPath(bundleIndex, p)
 	data, _, err := s.objStore.getObject(ctx, objName)
@@ -471,7 +875,7 @@ func (s *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint6
 		if errors.Is(err, gcs.ErrObjectNotExist) {
 			// Return the generic NotExist error so that higher levels can differentiate
 			// between this and other errors.
-			return nil, fmt.Errorf("%v: %w", objName, os.ErrNotExist)
+			return nil, fmt.Errorf("%v: %w", objName, tessera.ErrNotFound)
 		}
 		return nil, err
 	}
@@ -479,13 +883,30 @@ func (s *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint6
 	return data, nil
 }
 
+// getEntryBundleReader returns a reader for the serialised entry bundle at the location described by the given
+// index and partial size.
+//
+// The caller must close the returned reader.
+// Returns a wrapped tessera.ErrNotFound if the bundle does not exist.
+func (s *logResourceStore) getEntryBundleReader(ctx context.Context, bundleIndex uint64, p uint8) (io.ReadCloser, error) {
+	objName := s.entriesPath(bundleIndex, p)
+	r, err := s.objStore.getObjectReader(ctx, objName)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%v: %w", objName, tessera.ErrNotFound)
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
 // setEntryBundle idempotently stores the serialised entry bundle at the location implied by the bundleIndex and treeSize.
 func (s *logResourceStore) setEntryBundle(ctx context.Context, bundleIndex uint64, p uint8, bundleRaw []byte) error {
 	objName := s.entriesPath(bundleIndex, p)
 	// Note that setObject does an idempotent interpretation of DoesNotExist - it only
 	// returns an error if the named object exists _and_ contains different data to what's
 	// passed in here.
-	if err := s.objStore.setObject(ctx, objName, bundleRaw, &gcs.Conditions{DoesNotExist: true}, logContType, logCacheControl); err != nil {
+	if err := s.objStore.setObject(ctx, objName, bundleRaw, &gcs.Conditions{DoesNotExist: true}, s.metadata(tessera.ResourceEntryBundle)); err != nil {
 		return fmt.Errorf("setObject(%q): %v", objName, err)
 
 	}
@@ -499,10 +920,17 @@ func (a *Appender) appendEntries(ctx context.Context, fromSeq uint64, entries []
 
 	var newRoot []byte
 
+	// Entry bundles and tiles are written out with bounded parallelism as soon as they're ready,
+	// rather than waiting for the whole batch to be processed.
+	writes, wCtx := errgroup.WithContext(ctx)
+	if a.integrationParallelism > 0 {
+		writes.SetLimit(a.integrationParallelism)
+	}
+
 	errG := errgroup.Group{}
 
 	errG.Go(func() error {
-		if err := a.updateEntryBundles(ctx, fromSeq, entries); err != nil {
+		if err := a.updateEntryBundles(wCtx, fromSeq, entries, writes); err != nil {
 			return fmt.Errorf("updateEntryBundles: %v", err)
 		}
 		return nil
@@ -513,27 +941,32 @@ func (a *Appender) appendEntries(ctx context.Context, fromSeq uint64, entries []
 		for i, e := range entries {
 			lh[i] = e.LeafHash
 		}
-		r, err := integrate(ctx, fromSeq, lh, a.logStore)
+		r, err := integrate(wCtx, fromSeq, lh, a.logStore, writes)
 		if err != nil {
 			return fmt.Errorf("integrate: %v", err)
 		}
 		newRoot = r
 		return nil
 	})
-	if err := errG.Wait(); err != nil {
+	// Always wait for any scheduled writes to complete, even if we've failed.
+	err := errG.Wait()
+	if wErr := writes.Wait(); err == nil {
+		err = wErr
+	}
+	if err != nil {
 		return nil, err
 	}
 	return newRoot, nil
 }
 
 // integrate adds the provided leaf hashes to the merkle tree, starting at the provided location.
-func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logResourceStore) ([]byte, error) {
+// Writes of the updated tiles are scheduled on the provided errgroup, and callers must Wait on it.
+func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logResourceStore, writes *errgroup.Group) ([]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.integrate")
 	defer span.End()
 
 	span.SetAttributes(fromSizeKey.Int64(otel.Clamp64(fromSeq)), numEntriesKey.Int(len(lh)))
 
-	errG := errgroup.Group{}
 	getTiles := func(ctx context.Context, tileIDs []storage.TileID, treeSize uint64) ([]*api.HashTile, error) {
 		n, err := logStore.getTiles(ctx, tileIDs, treeSize)
 		if err != nil {
@@ -548,7 +981,7 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logRe
 	}
 	for k, v := range tiles {
 		func(ctx context.Context, k storage.TileID, v *api.HashTile) {
-			errG.Go(func() error {
+			writes.Go(func() error {
 				data, err := v.MarshalText()
 				if err != nil {
 					return err
@@ -557,9 +990,6 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logRe
 			})
 		}(ctx, k, v)
 	}
-	if err := errG.Wait(); err != nil {
-		return nil, err
-	}
 	klog.Infof("New tree: %d, %x", newSize, newRoot)
 
 	return newRoot, nil
@@ -568,7 +998,8 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logRe
 // updateEntryBundles adds the entries being integrated into the entry bundles.
 //
 // The right-most bundle will be grown, if it's partial, and/or new bundles will be created as required.
-func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry) error {
+// Writes of the updated bundles are scheduled on the provided errgroup, and callers must Wait on it.
+func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry, writes *errgroup.Group) error {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.updateEntryBundles")
 	defer span.End()
 
@@ -576,28 +1007,28 @@ func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entri
 		return nil
 	}
 
-	numAdded := uint64(0)
-	bundleIndex, entriesInBundle := fromSeq/layout.EntryBundleWidth, fromSeq%layout.EntryBundleWidth
-	bundleWriter := &bytes.Buffer{}
-	if entriesInBundle > 0 {
+	var part []byte
+	if p := uint8(fromSeq % layout.EntryBundleWidth); p > 0 {
 		// If the latest bundle is partial, we need to read the data it contains in for our newer, larger, bundle.
-		part, err := a.logStore.getEntryBundle(ctx, uint64(bundleIndex), uint8(entriesInBundle))
-		if err != nil {
+		var err error
+		if part, err = a.logStore.getEntryBundle(ctx, fromSeq/layout.EntryBundleWidth, p); err != nil {
 			return err
 		}
-
-		if _, err := bundleWriter.Write(part); err != nil {
-			return fmt.Errorf("bundleWriter: %v", err)
-		}
+	}
+	// Bundle buffers are pooled to reduce allocations, and are returned to the pool once written.
+	bundleWriter := storage.GetBuffer()
+	bundle, err := api.NewEntryBundleBuilder(fromSeq, part, bundleWriter)
+	if err != nil {
+		storage.PutBuffer(bundleWriter)
+		return err
 	}
 
-	seqErr := errgroup.Group{}
-
-	// goSetEntryBundle is a function which uses seqErr to spin off a go-routine to write out an entry bundle.
+	// goSetEntryBundle is a function which uses writes to spin off a go-routine to write out an entry bundle.
 	// It's used in the for loop below.
-	goSetEntryBundle := func(ctx context.Context, bundleIndex uint64, p uint8, bundleRaw []byte) {
-		seqErr.Go(func() error {
-			if err := a.logStore.setEntryBundle(ctx, bundleIndex, p, bundleRaw); err != nil {
+	goSetEntryBundle := func(ctx context.Context, bundleIndex uint64, p uint8, bundle *bytes.Buffer) {
+		writes.Go(func() error {
+			defer storage.PutBuffer(bundle)
+			if err := a.logStore.setEntryBundle(ctx, bundleIndex, p, bundle.Bytes()); err != nil {
 				return err
 			}
 			return nil
@@ -606,31 +1037,31 @@ func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entri
 
 	// Add new entries to the bundle
 	for _, e := range entries {
-		if _, err := bundleWriter.Write(e.BundleData); err != nil {
-			return fmt.Errorf("bundleWriter.Write: %v", err)
+		if err := bundle.AppendSerialised(e.BundleData); err != nil {
+			return fmt.Errorf("bundle.AppendSerialised: %v", err)
 		}
-		entriesInBundle++
-		fromSeq++
-		numAdded++
-		if entriesInBundle == layout.EntryBundleWidth {
+		if bundle.Full() {
 			//  This bundle is full, so we need to write it out...
-			klog.V(1).Infof("In-memory bundle idx %d is full, attempting write to GCS", bundleIndex)
-			goSetEntryBundle(ctx, bundleIndex, 0, bundleWriter.Bytes())
-			// ... and prepare the next entry bundle for any remaining entries in the batch
-			bundleIndex++
-			entriesInBundle = 0
-			// Don't use Reset/Truncate here - the backing []bytes is still being used by goSetEntryBundle above.
-			bundleWriter = &bytes.Buffer{}
-			klog.V(1).Infof("Starting to fill in-memory bundle idx %d", bundleIndex)
+			klog.V(1).Infof("In-memory bundle idx %d is full, attempting write to GCS", bundle.Index())
+			goSetEntryBundle(ctx, bundle.Index(), 0, bundleWriter)
+			// ... and prepare the next entry bundle for any remaining entries in the batch.
+			// Don't reuse the buffer here - it's still being used by goSetEntryBundle above.
+			bundleWriter = storage.GetBuffer()
+			if err := bundle.Next(bundleWriter); err != nil {
+				return err
+			}
+			klog.V(1).Infof("Starting to fill in-memory bundle idx %d", bundle.Index())
 		}
 	}
 	// If we have a partial bundle remaining once we've added all the entries from the batch,
 	// this needs writing out too.
-	if entriesInBundle > 0 {
-		klog.V(1).Infof("Attempting to write in-memory partial bundle idx %d.%d to GCS", bundleIndex, entriesInBundle)
-		goSetEntryBundle(ctx, bundleIndex, uint8(entriesInBundle), bundleWriter.Bytes())
+	if p, err := bundle.Partial(); err == nil {
+		klog.V(1).Infof("Attempting to write in-memory partial bundle idx %d.%d to GCS", bundle.Index(), p)
+		goSetEntryBundle(ctx, bundle.Index(), p, bundleWriter)
+	} else {
+		storage.PutBuffer(bundleWriter)
 	}
-	return seqErr.Wait()
+	return nil
 }
 
 // spannerCoordinator uses Cloud Spanner to provide
@@ -638,11 +1069,15 @@ func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entri
 type spannerCoordinator struct {
 	dbPool         *spanner.Client
 	maxOutstanding uint64
+	// lease, if set, must be held by this instance for consumeEntries to commit.
+	lease *integrationLease
 }
 
 // newSpannerCoordinator returns a new spannerSequencer struct which uses the provided
 // spanner resource name for its spanner connection.
-func newSpannerCoordinator(ctx context.Context, spannerDB string, maxOutstanding uint64) (*spannerCoordinator, error) {
+//
+// If create is true, any missing tables are created; otherwise the database is only read from here.
+func newSpannerCoordinator(ctx context.Context, spannerDB string, maxOutstanding uint64, create bool) (*spannerCoordinator, error) {
 	dbPool, err := spanner.NewClient(ctx, spannerDB)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Spanner: %v", err)
@@ -651,8 +1086,10 @@ func newSpannerCoordinator(ctx context.Context, spannerDB string, maxOutstanding
 		dbPool:         dbPool,
 		maxOutstanding: maxOutstanding,
 	}
-	if err := r.initDB(ctx, spannerDB); err != nil {
-		return nil, fmt.Errorf("failed to initDB: %v", err)
+	if create {
+		if err := r.initDB(ctx, spannerDB); err != nil {
+			return nil, fmt.Errorf("failed to initDB: %v", err)
+		}
 	}
 	if err := r.checkDataCompatibility(ctx); err != nil {
 		return nil, fmt.Errorf("schema is not compatible with this version of the Tessera library: %v", err)
@@ -673,6 +1110,12 @@ func newSpannerCoordinator(ctx context.Context, spannerDB string, maxOutstanding
 //   - IntCoord
 //     This table coordinates integration of the batches of entries stored in
 //     Seq into the committed tree state.
+//   - LogIdentity
+//     This table only ever contains a single row which records the checkpoint
+//     origin and signing keys the log is configured with.
+//   - Lease
+//     This table holds the lease recording which instance may currently
+//     integrate entries, when WithMultiInstance is used.
 func (s *spannerCoordinator) initDB(ctx context.Context, spannerDB string) error {
 	return createAndPrepareTables(
 		ctx, spannerDB,
@@ -681,6 +1124,8 @@ func (s *spannerCoordinator) initDB(ctx context.Context, spannerDB string) error
 			"CREATE TABLE IF NOT EXISTS SeqCoord (id INT64 NOT NULL, next INT64 NOT NULL,) PRIMARY KEY (id)",
 			"CREATE TABLE IF NOT EXISTS Seq (id INT64 NOT NULL, seq INT64 NOT NULL, v BYTES(MAX),) PRIMARY KEY (id, seq)",
 			"CREATE TABLE IF NOT EXISTS IntCoord (id INT64 NOT NULL, seq INT64 NOT NULL, rootHash BYTES(32)) PRIMARY KEY (id)",
+			"CREATE TABLE IF NOT EXISTS LogIdentity (id INT64 NOT NULL, identity BYTES(MAX) NOT NULL) PRIMARY KEY (id)",
+			gcp_election.TableDDL,
 		},
 		[][]*spanner.Mutation{
 			{spanner.Insert("Tessera", []string{"id", "compatibilityVersion"}, []any{0, SchemaCompatibilityVersion})},
@@ -708,6 +1153,32 @@ func (s *spannerCoordinator) checkDataCompatibility(ctx context.Context) error {
 	return nil
 }
 
+// ensureIdentity will fail if the log identity stored in the LogIdentity table doesn't match the provided one.
+// If no row exists, then it is created with the provided identity.
+func (s *spannerCoordinator) ensureIdentity(ctx context.Context, id tessera.LogIdentity) error {
+	_, err := s.dbPool.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		var stored []byte
+		row, err := txn.ReadRow(ctx, "LogIdentity", spanner.Key{0}, []string{"identity"})
+		if err != nil && spanner.ErrCode(err) != codes.NotFound {
+			return fmt.Errorf("failed to read log identity: %v", err)
+		}
+		if row != nil {
+			if err := row.Columns(&stored); err != nil {
+				return fmt.Errorf("failed to scan log identity: %v", err)
+			}
+		}
+		updated, err := id.Reconcile(stored)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(stored, updated) {
+			return nil
+		}
+		return txn.BufferWrite([]*spanner.Mutation{spanner.InsertOrUpdate("LogIdentity", []string{"id", "identity"}, []any{0, updated})})
+	})
+	return err
+}
+
 // assignEntries durably assigns each of the passed-in entries an index in the log.
 //
 // Entries are allocated contiguous indices, in the order in which they appear in the entries parameter.
@@ -747,7 +1218,7 @@ func (s *spannerCoordinator) assignEntries(ctx context.Context, entries []*tesse
 		// Check whether there are too many outstanding entries and we should apply
 		// back-pressure.
 		if outstanding := next - treeSize; outstanding > int64(s.maxOutstanding) {
-			return tessera.ErrPushback
+			return errTooManyOutstanding
 		}
 
 		next := uint64(next) // Shadow next with a uint64 version of the same value to save on casts.
@@ -803,6 +1274,14 @@ func (s *spannerCoordinator) consumeEntries(ctx context.Context, limit uint64, f
 
 	didWork := false
 	_, err := s.dbPool.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		if s.lease != nil {
+			// Reading the lease here means this transaction will fail to commit if another instance
+			// takes over while we're integrating.
+			if err := s.lease.Check(ctx, txn, s.lease.instance); err != nil {
+				return err
+			}
+		}
+
 		// Figure out which is the starting index of sequenced entries to start consuming from.
 		row, err := txn.ReadRowWithOptions(ctx, "IntCoord", spanner.Key{0}, []string{"seq", "rootHash"}, &spanner.ReadOptions{LockHint: spannerpb.ReadRequest_LOCK_HINT_EXCLUSIVE})
 		if err != nil {
@@ -946,6 +1425,51 @@ func (s *gcsStorage) getObject(ctx context.Context, obj string) ([]byte, int64,
 	return d, r.Attrs.Generation, r.Close()
 }
 
+// getObjectReader returns a reader for the contents of the specified object, or an error.
+//
+// The caller must close the returned reader.
+func (s *gcsStorage) getObjectReader(ctx context.Context, obj string) (io.ReadCloser, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.getObjectReader")
+	defer span.End()
+
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+
+	span.SetAttributes(objectPathKey.String(obj))
+
+	r, err := s.gcsClient.Bucket(s.bucket).Object(obj).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getObjectReader: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
+	}
+	return r, nil
+}
+
+// getObjectRangeReader returns a reader for part of the contents of the specified object, along with the total
+// size of the object, or an error.
+//
+// The semantics of offset and length are as described by tessera.RangeLogReader.
+// The caller must close the returned reader.
+func (s *gcsStorage) getObjectRangeReader(ctx context.Context, obj string, offset, length int64) (io.ReadCloser, int64, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.getObjectRangeReader")
+	defer span.End()
+
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+
+	span.SetAttributes(objectPathKey.String(obj))
+
+	r, err := s.gcsClient.Bucket(s.bucket).Object(obj).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		if ee, ok := err.(*googleapi.Error); ok && ee.Code == http.StatusRequestedRangeNotSatisfiable {
+			return nil, 0, fmt.Errorf("getObjectRangeReader(%q, %d, %d): %w", obj, offset, length, tessera.ErrRangeNotSatisfiable)
+		}
+		return nil, 0, fmt.Errorf("getObjectRangeReader: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
+	}
+	return r, r.Attrs.Size, nil
+}
+
 // setObject stores the provided data in the specified object, optionally gated by a condition.
 //
 // cond can be used to specify preconditions for the write (e.g. write iff not exists, write iff
@@ -954,7 +1478,7 @@ func (s *gcsStorage) getObject(ctx context.Context, obj string) ([]byte, int64,
 // Note that when preconditions are specified and are not met, an error will be returned *unless*
 // the currently stored data is bit-for-bit identical to the data to-be-written.
 // This is intended to provide idempotentency for writes.
-func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte, cond *gcs.Conditions, contType string, cacheCtl string) error {
+func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte, cond *gcs.Conditions, md tessera.ObjectMetadata) error {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.setObject")
 	defer span.End()
 
@@ -974,8 +1498,9 @@ func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte,
 	} else {
 		w = obj.If(*cond).NewWriter(ctx)
 	}
-	w.ContentType = contType
-	w.CacheControl = cacheCtl
+	w.ContentType = md.ContentType
+	w.CacheControl = md.CacheControl
+	w.Metadata = md.Custom
 	if _, err := w.Write(data); err != nil {
 		return fmt.Errorf("failed to write object %q to bucket %q: %w", objName, s.bucket, err)
 	}
@@ -992,7 +1517,7 @@ func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte,
 			if !bytes.Equal(existing, data) {
 				span.AddEvent("Non-idempotent write")
 				klog.Errorf("Resource %q non-idempotent write:\n%s", objName, cmp.Diff(existing, data))
-				return fmt.Errorf("precondition failed: resource content for %q differs from data to-be-written", objName)
+				return fmt.Errorf("%w: resource content for %q differs from data to-be-written", errPreconditionFailed, objName)
 			}
 
 			span.AddEvent("Idempotent write")
@@ -1005,6 +1530,51 @@ func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte,
 	return nil
 }
 
+// casObject stores data in the specified object iff the object's current generation is gen, or
+// iff the object does not exist if gen is zero, and returns the generation of the newly written object.
+//
+// Unlike setObject, a precondition failure is always returned as an error, even if the stored data
+// is identical, since callers rely on the generation to detect concurrent updates.
+func (s *gcsStorage) casObject(ctx context.Context, objName string, data []byte, gen int64) (int64, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.casObject")
+	defer span.End()
+
+	if s.bucketPrefix != "" {
+		objName = filepath.Join(s.bucketPrefix, objName)
+	}
+
+	span.SetAttributes(objectPathKey.String(objName))
+
+	cond := gcs.Conditions{GenerationMatch: gen}
+	if gen == 0 {
+		cond = gcs.Conditions{DoesNotExist: true}
+	}
+	w := s.gcsClient.Bucket(s.bucket).Object(objName).If(cond).NewWriter(ctx)
+	w.ContentType = logContType
+	w.CacheControl = ckptCacheControl
+	if _, err := w.Write(data); err != nil {
+		return 0, fmt.Errorf("failed to write object %q to bucket %q: %w", objName, s.bucket, err)
+	}
+	if err := w.Close(); err != nil {
+		if ee, ok := err.(*googleapi.Error); ok && ee.Code == http.StatusPreconditionFailed {
+			return 0, fmt.Errorf("%w: %q has been modified since generation %d", errPreconditionFailed, objName, gen)
+		}
+		return 0, fmt.Errorf("failed to close write on %q: %v", objName, err)
+	}
+	return w.Attrs().Generation, nil
+}
+
+// deleteObject removes the specified object. It's not an error if the object doesn't exist.
+func (s *gcsStorage) deleteObject(ctx context.Context, obj string) error {
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+	if err := s.gcsClient.Bucket(s.bucket).Object(obj).Delete(ctx); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete object %q in bucket %q: %v", obj, s.bucket, err)
+	}
+	return nil
+}
+
 func (s *gcsStorage) lastModified(ctx context.Context, obj string) (time.Time, error) {
 	if s.bucketPrefix != "" {
 		obj = filepath.Join(s.bucketPrefix, obj)
@@ -1017,14 +1587,53 @@ func (s *gcsStorage) lastModified(ctx context.Context, obj string) (time.Time, e
 	return r.Attrs.LastModified, r.Close()
 }
 
+// newObjStore returns the object store in which the log's resources are kept.
+func (s *Storage) newObjStore(ctx context.Context) (objStore, error) {
+	if s.objStore != nil {
+		return s.objStore, nil
+	}
+	c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %v", err)
+	}
+	return &gcsStorage{
+		gcsClient:    c,
+		bucket:       s.cfg.Bucket,
+		bucketPrefix: s.cfg.BucketPrefix,
+	}, nil
+}
+
+// newStateObjStore returns the objStore in which a GCS coordinator stores its state: Config.StateBucket
+// if it's set, or otherwise the log's own objStore.
+func (s *Storage) newStateObjStore(ctx context.Context, log objStore) (objStore, error) {
+	if s.cfg.StateBucket == "" {
+		return log, nil
+	}
+	if s.stateObjStore != nil {
+		return s.stateObjStore, nil
+	}
+	c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %v", err)
+	}
+	return &gcsStorage{
+		gcsClient:    c,
+		bucket:       s.cfg.StateBucket,
+		bucketPrefix: s.cfg.StateBucketPrefix,
+	}, nil
+}
+
 // MigrationWriter creates a new GCP storage for the MigrationTarget lifecycle mode.
 func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOptions) (tessera.MigrationWriter, tessera.LogReader, error) {
-	c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+	if s.cfg.Spanner == "" {
+		return nil, nil, errors.New("migration targets require Config.Spanner to be set")
+	}
+	objStore, err := s.newObjStore(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create GCS client: %v", err)
+		return nil, nil, err
 	}
 
-	seq, err := newSpannerCoordinator(ctx, s.cfg.Spanner, 0)
+	seq, err := newSpannerCoordinator(ctx, s.cfg.Spanner, 0, true)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create Spanner sequencer: %v", err)
 	}
@@ -1034,12 +1643,9 @@ func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOp
 		bundleHasher: opts.LeafHasher(),
 		sequencer:    seq,
 		logStore: &logResourceStore{
-			objStore: &gcsStorage{
-				gcsClient:    c,
-				bucket:       s.cfg.Bucket,
-				bucketPrefix: s.cfg.BucketPrefix,
-			},
+			objStore:    objStore,
 			entriesPath: opts.EntriesPath(),
+			driverOpts:  s.driverOpts,
 		},
 	}
 
@@ -1177,7 +1783,11 @@ func (m *MigrationStorage) buildTree(ctx context.Context, sourceSize uint64) (ui
 
 		added := uint64(len(lh))
 		klog.Infof("Integrate: adding %d entries to existing tree size %d", len(lh), from)
-		newRoot, err = integrate(ctx, from, lh, m.logStore)
+		writes := &errgroup.Group{}
+		newRoot, err = integrate(ctx, from, lh, m.logStore, writes)
+		if wErr := writes.Wait(); err == nil {
+			err = wErr
+		}
 		if err != nil {
 			klog.Warningf("integrate failed: %v", err)
 			return fmt.Errorf("integrate failed: %v", err)
@@ -17,11 +17,14 @@ package gcp
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -31,10 +34,16 @@ import (
 	"cloud.google.com/go/spanner/spannertest"
 	gcs "cloud.google.com/go/storage"
 	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	gcp_election "github.com/transparency-dev/tessera/storage/gcp/election"
 	storage "github.com/transparency-dev/tessera/storage/internal"
+	"github.com/transparency-dev/tessera/storage/storagetest"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/sync/errgroup"
 )
 
 func newSpannerDB(t *testing.T) func() {
@@ -50,12 +59,138 @@ func newSpannerDB(t *testing.T) func() {
 
 }
 
+func TestConfigValidate(t *testing.T) {
+	const db = "projects/p/instances/i/databases/d"
+	for _, test := range []struct {
+		desc     string
+		cfg      Config
+		wantErrs int
+	}{
+		{desc: "ok", cfg: Config{Bucket: "b", Spanner: db}},
+		{desc: "ok with prefix", cfg: Config{Bucket: "b", BucketPrefix: "logs/one", Spanner: db}},
+		{desc: "ok with GCS coordination", cfg: Config{Bucket: "b", GCSCoordination: true}},
+		{desc: "ok with state bucket", cfg: Config{Bucket: "b", GCSCoordination: true, StateBucket: "s", StateBucketPrefix: "logs/one"}},
+		{desc: "no coordination", cfg: Config{Bucket: "b"}, wantErrs: 1},
+		{desc: "spanner and GCS coordination", cfg: Config{Bucket: "b", Spanner: db, GCSCoordination: true}, wantErrs: 1},
+		{desc: "state bucket with path", cfg: Config{Bucket: "b", GCSCoordination: true, StateBucket: "s/logs"}, wantErrs: 1},
+		{desc: "state prefix with slashes", cfg: Config{Bucket: "b", GCSCoordination: true, StateBucket: "s", StateBucketPrefix: "/logs"}, wantErrs: 1},
+		{desc: "state prefix without bucket", cfg: Config{Bucket: "b", GCSCoordination: true, StateBucketPrefix: "logs"}, wantErrs: 1},
+		{desc: "state bucket with spanner", cfg: Config{Bucket: "b", StateBucket: "s", Spanner: db}, wantErrs: 1},
+		{desc: "empty", cfg: Config{}, wantErrs: 2},
+		{desc: "bucket with scheme", cfg: Config{Bucket: "gs://b", Spanner: db}, wantErrs: 1},
+		{desc: "bucket with path", cfg: Config{Bucket: "b/logs", Spanner: db}, wantErrs: 1},
+		{desc: "prefix with slashes", cfg: Config{Bucket: "b", BucketPrefix: "/logs/", Spanner: db}, wantErrs: 1},
+		{desc: "bad spanner uri", cfg: Config{Bucket: "b", Spanner: "projects/p/databases/d"}, wantErrs: 1},
+		{desc: "everything wrong", cfg: Config{Bucket: "gs://b/x", BucketPrefix: "/x", Spanner: "d"}, wantErrs: 3},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.cfg.validate()
+			if (err != nil) != (test.wantErrs > 0) {
+				t.Fatalf("validate() = %v, want error %t", err, test.wantErrs > 0)
+			}
+			if err == nil {
+				return
+			}
+			var joined interface{ Unwrap() []error }
+			if !errors.As(err, &joined) {
+				t.Fatalf("validate() = %v, want joined errors", err)
+			}
+			if got := len(joined.Unwrap()); got != test.wantErrs {
+				t.Errorf("validate() returned %d errors, want %d: %v", got, test.wantErrs, err)
+			}
+		})
+	}
+}
+
+func TestSpannerEnsureIdentity(t *testing.T) {
+	ctx := context.Background()
+	close := newSpannerDB(t)
+	defer close()
+
+	seq, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
+	if err != nil {
+		t.Fatalf("newSpannerCoordinator: %v", err)
+	}
+	idA := tessera.NewAppendOptions().WithCheckpointSigner(mustSigner(t)).LogIdentity()
+	idB := tessera.NewAppendOptions().WithCheckpointSigner(mustSigner(t)).LogIdentity()
+	if err := seq.ensureIdentity(ctx, idA); err != nil {
+		t.Fatalf("ensureIdentity(A): %v", err)
+	}
+	if err := seq.ensureIdentity(ctx, idA); err != nil {
+		t.Fatalf("ensureIdentity(A) again: %v", err)
+	}
+	if err := seq.ensureIdentity(ctx, idB); !errors.Is(err, tessera.ErrLogIdentityMismatch) {
+		t.Fatalf("ensureIdentity(B): got err %v, want %v", err, tessera.ErrLogIdentityMismatch)
+	}
+}
+
+func mustSigner(t *testing.T) note.Signer {
+	t.Helper()
+	skey, _, err := note.GenerateKey(rand.Reader, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func TestReadOnlyDoesNotCreateTables(t *testing.T) {
+	ctx := t.Context()
+	close := newSpannerDB(t)
+	defer close()
+
+	const db = "projects/p/instances/i/databases/d"
+	s := &Storage{cfg: Config{Bucket: "b", Spanner: db}, objStore: newMemObjStore()}
+	opts := tessera.NewAppendOptions().WithReadOnly()
+	// Without creating the tables, there's no schema version to check.
+	if _, _, err := s.Appender(ctx, opts); err == nil {
+		t.Fatal("Read-only Appender on uninitialised database: want error, got none")
+	}
+
+	if _, err := newSpannerCoordinator(ctx, db, 1000, true); err != nil {
+		t.Fatalf("newSpannerCoordinator: %v", err)
+	}
+	if _, _, err := s.Appender(ctx, opts); err != nil {
+		t.Errorf("Read-only Appender on initialised database: %v", err)
+	}
+}
+
+func TestGCSCoordinatorStateBucket(t *testing.T) {
+	ctx := t.Context()
+	log, state := newMemObjStore(), newMemObjStore()
+	s := &Storage{cfg: Config{Bucket: "log", GCSCoordination: true, StateBucket: "state"}, objStore: log, stateObjStore: state}
+	opts := tessera.NewAppendOptions().WithCheckpointSigner(mustSigner(t)).WithCheckpointInterval(minCheckpointInterval)
+	a, _, err := s.Appender(ctx, opts)
+	if err != nil {
+		t.Fatalf("Appender: %v", err)
+	}
+	if _, err := a.Add(ctx, tessera.NewEntry([]byte("not yet public")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	log.RLock()
+	defer log.RUnlock()
+	for k := range log.mem {
+		if strings.HasPrefix(k, ".state/") {
+			t.Errorf("Found %q in the log's bucket, want all state in the state bucket", k)
+		}
+	}
+	state.RLock()
+	defer state.RUnlock()
+	if _, ok := state.mem[gcsCoordObject]; !ok {
+		t.Errorf("Didn't find %q in the state bucket", gcsCoordObject)
+	}
+}
+
 func TestSpannerSequencerAssignEntries(t *testing.T) {
 	ctx := context.Background()
 	close := newSpannerDB(t)
 	defer close()
 
-	seq, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000)
+	seq, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
 	if err != nil {
 		t.Fatalf("newSpannerCoordinator: %v", err)
 	}
@@ -108,7 +243,7 @@ func TestSpannerSequencerPushback(t *testing.T) {
 			close := newSpannerDB(t)
 			defer close()
 
-			seq, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", test.threshold)
+			seq, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", test.threshold, true)
 			if err != nil {
 				t.Fatalf("newSpannerCoordinator: %v", err)
 			}
@@ -138,7 +273,7 @@ func TestSpannerSequencerRoundTrip(t *testing.T) {
 	close := newSpannerDB(t)
 	defer close()
 
-	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000)
+	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
 	if err != nil {
 		t.Fatalf("newSpannerCoordinator: %v", err)
 	}
@@ -190,7 +325,7 @@ func TestCheckDataCompatibility(t *testing.T) {
 	close := newSpannerDB(t)
 	defer close()
 
-	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000)
+	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
 	if err != nil {
 		t.Fatalf("newSpannerCoordinator: %v", err)
 	}
@@ -346,6 +481,39 @@ func TestBundleRoundtrip(t *testing.T) {
 	}
 }
 
+func TestObjectMetadata(t *testing.T) {
+	ctx := t.Context()
+	m := newMemObjStore()
+	s := &logResourceStore{
+		objStore:    m,
+		entriesPath: layout.EntriesPath,
+	}
+	s.driverOpts.Apply(tessera.WithObjectMetadata(tessera.ResourceCheckpoint, tessera.ObjectMetadata{
+		CacheControl: "max-age=5",
+		Custom:       map[string]string{"log": "test"},
+	}))
+
+	if err := s.setCheckpoint(ctx, []byte("checkpoint"), nil); err != nil {
+		t.Fatalf("setCheckpoint: %v", err)
+	}
+	if err := s.setTile(ctx, 0, 0, 0, []byte("tile")); err != nil {
+		t.Fatalf("setTile: %v", err)
+	}
+	if err := s.setEntryBundle(ctx, 0, 1, []byte("bundle")); err != nil {
+		t.Fatalf("setEntryBundle: %v", err)
+	}
+
+	for obj, want := range map[string]tessera.ObjectMetadata{
+		layout.CheckpointPath:    {ContentType: ckptContType, CacheControl: "max-age=5", Custom: map[string]string{"log": "test"}},
+		layout.TilePath(0, 0, 0): {ContentType: logContType, CacheControl: logCacheControl},
+		layout.EntriesPath(0, 1): {ContentType: logContType, CacheControl: logCacheControl},
+	} {
+		if diff := cmp.Diff(want, m.md[obj]); diff != "" {
+			t.Errorf("metadata for %q: diff (-want +got):\n%s", obj, diff)
+		}
+	}
+}
+
 func TestStreamEntries(t *testing.T) {
 	ctx := context.Background()
 	m := newMemObjStore()
@@ -425,7 +593,7 @@ func TestPublishCheckpoint(t *testing.T) {
 	close := newSpannerDB(t)
 	defer close()
 
-	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000)
+	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
 	if err != nil {
 		t.Fatalf("newSpannerCoordinator: %v", err)
 	}
@@ -450,22 +618,26 @@ func TestPublishCheckpoint(t *testing.T) {
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			m := newMemObjStore()
+			var notified *tessera.PublishedCheckpoint
 			storage := &Appender{
 				logStore: &logResourceStore{
 					objStore:    m,
 					entriesPath: layout.EntriesPath,
 				},
-				sequencer: s,
+				sequencer:          s,
+				integrationTimeout: defaultIntegrationTimeout,
 				newCP: func(_ context.Context, size uint64, hash []byte) ([]byte, error) {
 					return fmt.Appendf(nil, "%d/%x,", size, hash), nil
 				},
+				notifyPublished: func(cp tessera.PublishedCheckpoint) { notified = &cp },
 			}
 			// Call init so we've got a zero-sized checkpoint to work with.
 			if err := storage.init(ctx); err != nil {
 				t.Fatalf("storage.init: %v", err)
 			}
+			notified = nil
 			cpOld := []byte("bananas")
-			if err := m.setObject(ctx, layout.CheckpointPath, cpOld, nil, "", ""); err != nil {
+			if err := m.setObject(ctx, layout.CheckpointPath, cpOld, nil, tessera.ObjectMetadata{}); err != nil {
 				t.Fatalf("setObject(bananas): %v", err)
 			}
 			m.lMod = test.cpModifiedAt
@@ -483,19 +655,96 @@ func TestPublishCheckpoint(t *testing.T) {
 			if test.wantUpdate != cpUpdated {
 				t.Fatalf("got cpUpdated=%t, want %t", cpUpdated, test.wantUpdate)
 			}
+			if gotNotified := notified != nil; gotNotified != test.wantUpdate {
+				t.Fatalf("got notified=%t, want %t", gotNotified, test.wantUpdate)
+			}
+			if notified != nil && !bytes.Equal(notified.Checkpoint, cpNew) {
+				t.Errorf("Notified of checkpoint %q, but published %q", notified.Checkpoint, cpNew)
+			}
 		})
 	}
 }
 
+func TestPublishCheckpointPublishedElsewhere(t *testing.T) {
+	ctx := context.Background()
+
+	close := newSpannerDB(t)
+	defer close()
+
+	seq, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
+	if err != nil {
+		t.Fatalf("newSpannerCoordinator: %v", err)
+	}
+	m := newMemObjStore()
+	newer := []byte("newer checkpoint")
+	notified := false
+	a := &Appender{
+		logStore: &logResourceStore{
+			objStore:    m,
+			entriesPath: layout.EntriesPath,
+		},
+		// Another instance takes over and publishes a newer checkpoint after this one has decided to publish.
+		sequencer: &currentTreeHook{sequencer: seq, hook: func() {
+			if err := m.setObject(ctx, layout.CheckpointPath, newer, nil, tessera.ObjectMetadata{}); err != nil {
+				t.Fatalf("setObject: %v", err)
+			}
+		}},
+		integrationTimeout: defaultIntegrationTimeout,
+		newCP: func(_ context.Context, size uint64, hash []byte) ([]byte, error) {
+			return fmt.Appendf(nil, "%d/%x,", size, hash), nil
+		},
+		notifyPublished: func(tessera.PublishedCheckpoint) { notified = true },
+	}
+	if err := m.setObject(ctx, layout.CheckpointPath, []byte("older checkpoint"), nil, tessera.ObjectMetadata{}); err != nil {
+		t.Fatalf("setObject: %v", err)
+	}
+	m.lMod = time.Now().Add(-time.Minute)
+
+	if err := a.publishCheckpoint(ctx, time.Second); err != nil {
+		t.Fatalf("publishCheckpoint: %v", err)
+	}
+	got, _, err := m.getObject(ctx, layout.CheckpointPath)
+	if err != nil {
+		t.Fatalf("getObject: %v", err)
+	}
+	if !bytes.Equal(got, newer) {
+		t.Errorf("Got checkpoint %q, want %q published elsewhere", got, newer)
+	}
+	if notified {
+		t.Error("Notified of a checkpoint which wasn't published")
+	}
+}
+
+// currentTreeHook is a sequencer which calls hook before returning the current tree.
+type currentTreeHook struct {
+	sequencer
+	hook func()
+}
+
+func (c *currentTreeHook) currentTree(ctx context.Context) (uint64, []byte, error) {
+	c.hook()
+	return c.sequencer.currentTree(ctx)
+}
+
 type memObjStore struct {
 	sync.RWMutex
-	mem  map[string][]byte
+	mem map[string][]byte
+	md  map[string]tessera.ObjectMetadata
+	gen map[string]int64
+	// mod holds the time at which each object was last written.
+	mod map[string]time.Time
+	// lMod, if set, is returned as the modification time of every object.
 	lMod time.Time
+	// lastGen is the most recently assigned generation, which are unique across all objects as in GCS.
+	lastGen int64
 }
 
 func newMemObjStore() *memObjStore {
 	return &memObjStore{
 		mem: make(map[string][]byte),
+		md:  make(map[string]tessera.ObjectMetadata),
+		mod: make(map[string]time.Time),
+		gen: make(map[string]int64),
 	}
 }
 
@@ -507,27 +756,278 @@ func (m *memObjStore) getObject(_ context.Context, obj string) ([]byte, int64, e
 	if !ok {
 		return nil, -1, fmt.Errorf("obj %q not found: %w", obj, gcs.ErrObjectNotExist)
 	}
-	return d, 1, nil
+	return d, m.gen[obj], nil
+}
+
+func (m *memObjStore) getObjectReader(ctx context.Context, obj string) (io.ReadCloser, error) {
+	d, _, err := m.getObject(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(d)), nil
 }
 
-// TODO(phboneff): add content type tests
-func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, cond *gcs.Conditions, _, _ string) error {
+func (m *memObjStore) getObjectRangeReader(ctx context.Context, obj string, offset, length int64) (io.ReadCloser, int64, error) {
+	d, _, err := m.getObject(ctx, obj)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, n, err := tessera.ResolveRange(int64(len(d)), offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(d[start : start+n])), int64(len(d)), nil
+}
+
+func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, cond *gcs.Conditions, md tessera.ObjectMetadata) error {
 	m.Lock()
 	defer m.Unlock()
 
 	d, ok := m.mem[obj]
 	if cond != nil {
-		if ok && cond.DoesNotExist {
+		if (ok && cond.DoesNotExist) || (cond.GenerationMatch != 0 && m.gen[obj] != cond.GenerationMatch) {
 			if !bytes.Equal(d, data) {
-				return errors.New("precondition failed and data not identical")
+				return fmt.Errorf("%w: data not identical", errPreconditionFailed)
 			}
 			return nil
 		}
 	}
-	m.mem[obj] = data
+	// Take a copy, as callers may reuse the buffer once this returns.
+	m.mem[obj] = bytes.Clone(data)
+	m.mod[obj] = time.Now()
+	m.md[obj] = md
+	m.lastGen++
+	m.gen[obj] = m.lastGen
+	return nil
+}
+
+func (m *memObjStore) casObject(_ context.Context, obj string, data []byte, gen int64) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if cur, ok := m.gen[obj]; (ok && cur != gen) || (!ok && gen != 0) {
+		return 0, fmt.Errorf("%w: %q is at generation %d, not %d", errPreconditionFailed, obj, cur, gen)
+	}
+	m.mem[obj] = bytes.Clone(data)
+	m.mod[obj] = time.Now()
+	m.lastGen++
+	m.gen[obj] = m.lastGen
+	return m.lastGen, nil
+}
+
+func (m *memObjStore) deleteObject(_ context.Context, obj string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.mem, obj)
+	delete(m.md, obj)
+	delete(m.gen, obj)
+	delete(m.mod, obj)
 	return nil
 }
 
 func (m *memObjStore) lastModified(_ context.Context, obj string) (time.Time, error) {
-	return m.lMod, nil
+	m.RLock()
+	defer m.RUnlock()
+
+	if !m.lMod.IsZero() {
+		return m.lMod, nil
+	}
+	t, ok := m.mod[obj]
+	if !ok {
+		return time.Time{}, fmt.Errorf("obj %q not found: %w", obj, gcs.ErrObjectNotExist)
+	}
+	return t, nil
+}
+
+func TestStorageContract(t *testing.T) {
+	storagetest.RunAppenderTests(t, func(t *testing.T) tessera.Driver {
+		// With GCS coordination, the driver coordinates through the object store.
+		return &Storage{cfg: Config{Bucket: "test-bucket", GCSCoordination: true}, objStore: newMemObjStore()}
+	})
+}
+
+func TestChaos(t *testing.T) {
+	// Each restart gets a new Storage backed by the same object store.
+	m := newMemObjStore()
+	storagetest.RunChaosTests(t, func(_ context.Context) (tessera.Driver, error) {
+		return &Storage{cfg: Config{Bucket: "test-bucket", GCSCoordination: true}, objStore: m}, nil
+	})
+}
+
+func TestAppendEntries(t *testing.T) {
+	ctx := context.Background()
+	for _, parallelism := range []int{1, 4, tessera.DefaultIntegrationParallelism} {
+		t.Run(fmt.Sprintf("parallelism %d", parallelism), func(t *testing.T) {
+			m := newMemObjStore()
+			a := &Appender{
+				logStore: &logResourceStore{
+					objStore:    m,
+					entriesPath: layout.EntriesPath,
+				},
+				integrationParallelism: parallelism,
+			}
+			rf := compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+			cr := rf.NewEmptyRange(0)
+			size := uint64(0)
+			// Add a few batches of entries, such that bundles and tiles are both created and grown.
+			for _, n := range []int{10, 3*layout.EntryBundleWidth + 5, 300} {
+				entries := make([]storage.SequencedEntry, 0, n)
+				for i := range n {
+					e := tessera.NewEntry(fmt.Appendf(nil, "entry %d", size+uint64(i)))
+					entries = append(entries, storage.SequencedEntry{
+						BundleData: e.MarshalBundleData(size + uint64(i)),
+						LeafHash:   e.LeafHash(),
+					})
+					if err := cr.Append(e.LeafHash(), nil); err != nil {
+						t.Fatalf("Append: %v", err)
+					}
+				}
+				gotRoot, err := a.appendEntries(ctx, size, entries)
+				if err != nil {
+					t.Fatalf("appendEntries: %v", err)
+				}
+				size += uint64(n)
+				wantRoot, err := cr.GetRootHash(nil)
+				if err != nil {
+					t.Fatalf("GetRootHash: %v", err)
+				}
+				if !bytes.Equal(gotRoot, wantRoot) {
+					t.Fatalf("Got root %x, want %x", gotRoot, wantRoot)
+				}
+				for ri := range layout.Range(0, size, size) {
+					if _, err := a.logStore.getEntryBundle(ctx, ri.Index, ri.Partial); err != nil {
+						t.Errorf("getEntryBundle(%d, %d): %v", ri.Index, ri.Partial, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAppendEntries(b *testing.B) {
+	ctx := context.Background()
+	a := newBenchmarkAppender()
+	entries := benchmarkEntries(tessera.DefaultBatchMaxSize)
+	size := uint64(0)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := a.appendEntries(ctx, size, entries); err != nil {
+			b.Fatalf("appendEntries: %v", err)
+		}
+		size += uint64(len(entries))
+	}
+	b.ReportMetric(float64(size)/b.Elapsed().Seconds(), "entries/s")
+}
+
+func BenchmarkUpdateEntryBundles(b *testing.B) {
+	ctx := context.Background()
+	a := newBenchmarkAppender()
+	entries := benchmarkEntries(tessera.DefaultBatchMaxSize)
+	size := uint64(0)
+	b.ReportAllocs()
+	for b.Loop() {
+		writes := &errgroup.Group{}
+		if err := a.updateEntryBundles(ctx, size, entries, writes); err != nil {
+			b.Fatalf("updateEntryBundles: %v", err)
+		}
+		if err := writes.Wait(); err != nil {
+			b.Fatalf("writing bundles: %v", err)
+		}
+		size += uint64(len(entries))
+	}
+}
+
+// newBenchmarkAppender returns an Appender which stores objects in memory.
+func newBenchmarkAppender() *Appender {
+	return &Appender{
+		logStore: &logResourceStore{
+			objStore:    newMemObjStore(),
+			entriesPath: layout.EntriesPath,
+		},
+		integrationParallelism: tessera.DefaultIntegrationParallelism,
+	}
+}
+
+// benchmarkEntries returns n entries to integrate. They're created up-front, so that benchmarks only
+// measure the work of integrating them, and may be integrated at any index.
+func benchmarkEntries(n int) []storage.SequencedEntry {
+	entries := make([]storage.SequencedEntry, 0, n)
+	for i := range uint64(n) {
+		e := tessera.NewEntry(fmt.Appendf(nil, "benchmark entry %d with a little bit of padding to look more realistic", i))
+		entries = append(entries, storage.SequencedEntry{
+			BundleData: e.MarshalBundleData(i),
+			LeafHash:   e.LeafHash(),
+		})
+	}
+	return entries
+}
+
+// newIntegrationLease returns a lease for the given instance, stored in s's database.
+func newIntegrationLease(s *spannerCoordinator, instance string, ttl time.Duration) *integrationLease {
+	return &integrationLease{Lease: gcp_election.NewLeaseWithClient(s.dbPool, integrationLeaseName), instance: instance, ttl: ttl}
+}
+
+func TestSpannerConsumeEntriesRequiresLease(t *testing.T) {
+	ctx := context.Background()
+	close := newSpannerDB(t)
+	defer close()
+
+	s, err := newSpannerCoordinator(ctx, "projects/p/instances/i/databases/d", 1000, true)
+	if err != nil {
+		t.Fatalf("newSpannerCoordinator: %v", err)
+	}
+	if err := s.assignEntries(ctx, []*tessera.Entry{tessera.NewEntry([]byte("one"))}); err != nil {
+		t.Fatalf("assignEntries: %v", err)
+	}
+	// Instance a believed it held the lease, but has since been superseded by b.
+	s.lease = newIntegrationLease(s, "a", time.Millisecond)
+	if _, err := s.lease.TryAcquire(ctx, s.lease.instance, s.lease.ttl); err != nil {
+		t.Fatalf("a.TryAcquire: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	b := newIntegrationLease(s, "b", time.Minute)
+	if ok, err := b.TryAcquire(ctx, b.instance, b.ttl); err != nil || !ok {
+		t.Fatalf("b.TryAcquire = %t, %v, want true", ok, err)
+	}
+
+	called := false
+	f := func(_ context.Context, _ uint64, _ []storage.SequencedEntry) ([]byte, error) {
+		called = true
+		return []byte("root"), nil
+	}
+	if _, err := s.consumeEntries(ctx, 10, f, false); !errors.Is(err, gcp_election.ErrNotHeld) {
+		t.Fatalf("consumeEntries as a: got err %v, want %v", err, gcp_election.ErrNotHeld)
+	}
+	if called {
+		t.Error("consumeFunc was called without holding the lease")
+	}
+
+	s.lease = b
+	if more, err := s.consumeEntries(ctx, 10, f, false); err != nil || !more {
+		t.Fatalf("consumeEntries as b = %t, %v, want true, nil", more, err)
+	}
+	if size, _, err := s.currentTree(ctx); err != nil || size != 1 {
+		t.Errorf("currentTree = %d, %v, want 1", size, err)
+	}
+}
+
+func TestNewMultiInstance(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc    string
+		cfg     Config
+		inst    string
+		wantErr bool
+	}{
+		{desc: "ok", cfg: Config{Bucket: "b", Spanner: "projects/p/instances/i/databases/d"}, inst: "one"},
+		{desc: "no spanner", cfg: Config{Bucket: "b", GCSCoordination: true}, inst: "one", wantErr: true},
+		{desc: "no instance", cfg: Config{Bucket: "b", Spanner: "projects/p/instances/i/databases/d"}, wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if _, err := New(ctx, test.cfg, WithMultiInstance(test.inst)); (err != nil) != test.wantErr {
+				t.Errorf("New() = %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
 }
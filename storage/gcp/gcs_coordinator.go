@@ -0,0 +1,392 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// gcsCoordObject holds the coordination state of a log which isn't using Spanner.
+	gcsCoordObject = ".state/coord"
+	// gcsIdentityObject holds the identity of a log which isn't using Spanner.
+	gcsIdentityObject = ".state/identity"
+	// gcsSeqPrefix is the prefix of the objects which hold sequenced batches of entries awaiting integration.
+	gcsSeqPrefix = ".state/seq/"
+)
+
+// errPreconditionFailed is returned by objStore writes whose preconditions were not met.
+var errPreconditionFailed = errors.New("precondition failed")
+
+// errNoChange is returned by gcsCoordinator.update mutation funcs to indicate that the state
+// already reflects the intended change, so nothing needs to be written.
+var errNoChange = errors.New("no change")
+
+// errStale is returned by gcsCoordinator.update mutation funcs when the state has moved on such
+// that the change must be recalculated from scratch.
+var errStale = errors.New("stale")
+
+// gcsCoordState is the coordination state of a log, serialised as JSON in gcsCoordObject.
+type gcsCoordState struct {
+	// Version is the SchemaCompatibilityVersion the state was created with.
+	Version int
+	// Next is the next available index in the log.
+	Next uint64
+	// Size and Root describe the integrated tree.
+	Size uint64
+	Root []byte
+	// Pending lists, in order, the batches of sequenced entries which have yet to be integrated.
+	// The first batch always starts at Size, and each subsequent batch immediately follows its predecessor.
+	Pending []gcsBatch
+}
+
+// gcsBatch refers to an object holding a batch of sequenced entries.
+type gcsBatch struct {
+	First  uint64
+	Count  uint64
+	Object string
+}
+
+// gcsCoordinator uses objects in GCS to provide a durable sequencer without needing Spanner.
+//
+// All coordination state lives in a single object, which is only ever updated with a precondition
+// that its generation hasn't changed since it was read. Batches of sequenced entries are written to
+// uniquely named objects before they're referenced from the state, so a batch only becomes part of
+// the log once the state update which sequences it succeeds, and a crash at any point either leaves
+// the batch sequenced or leaves behind an unreferenced object which is harmless.
+//
+// A second writer would not corrupt the log, but every conflicting update has to be retried, so this
+// is only intended for logs with a single writer.
+type gcsCoordinator struct {
+	objStore       objStore
+	maxOutstanding uint64
+
+	// mu serialises updates made by this coordinator, and guards the cached state below.
+	mu sync.Mutex
+	// cur is the most recent state read or written by this coordinator, and gen its generation.
+	// A zero gen means the state must be re-read before it can be updated.
+	cur gcsCoordState
+	gen int64
+}
+
+// newGCSCoordinator returns a coordinator which stores its state in objStore, for the log stored in logStore.
+//
+// If create is true, and no state exists yet, then the state of an empty log is created. This is refused
+// if the log has already published a checkpoint, e.g. because it was coordinated with Spanner until now,
+// since the empty state would cause the log's next checkpoint to roll it back to size zero.
+func newGCSCoordinator(ctx context.Context, objStore, logStore objStore, maxOutstanding uint64, create bool) (*gcsCoordinator, error) {
+	c := &gcsCoordinator{
+		objStore:       objStore,
+		maxOutstanding: maxOutstanding,
+	}
+	_, _, err := c.read(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) && create {
+		if _, _, cpErr := logStore.getObject(ctx, layout.CheckpointPath); cpErr == nil {
+			return nil, fmt.Errorf("log already has a checkpoint but no GCS coordination state; refusing to create state for an empty log, which would roll it back (was it coordinated with Spanner?)")
+		} else if !errors.Is(cpErr, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("failed to check for an existing checkpoint: %v", cpErr)
+		}
+		st := gcsCoordState{Version: SchemaCompatibilityVersion, Root: rfc6962.DefaultHasher.EmptyRoot()}
+		raw, mErr := json.Marshal(st)
+		if mErr != nil {
+			return nil, mErr
+		}
+		// Another instance may be racing to create the state too; either way there's now some state to read.
+		if _, cErr := objStore.casObject(ctx, gcsCoordObject, raw, 0); cErr != nil && !errors.Is(cErr, errPreconditionFailed) {
+			return nil, fmt.Errorf("failed to create coordination state: %v", cErr)
+		}
+		_, _, err = c.read(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coordination state: %w", err)
+	}
+	return c, nil
+}
+
+// read fetches the stored state, and checks that it's compatible with this version of the library.
+func (c *gcsCoordinator) read(ctx context.Context) (gcsCoordState, int64, error) {
+	raw, gen, err := c.objStore.getObject(ctx, gcsCoordObject)
+	if err != nil {
+		return gcsCoordState{}, 0, err
+	}
+	st := gcsCoordState{}
+	if err := json.Unmarshal(raw, &st); err != nil {
+		return gcsCoordState{}, 0, fmt.Errorf("failed to parse coordination state: %v", err)
+	}
+	if st.Version != SchemaCompatibilityVersion {
+		return gcsCoordState{}, 0, fmt.Errorf("state compatibilityVersion (%d) != library compatibilityVersion (%d)", st.Version, SchemaCompatibilityVersion)
+	}
+	return st, gen, nil
+}
+
+// state returns the latest state known to this coordinator, reading it if necessary.
+//
+// c.mu must be held.
+func (c *gcsCoordinator) state(ctx context.Context) (gcsCoordState, error) {
+	if c.gen == 0 {
+		st, gen, err := c.read(ctx)
+		if err != nil {
+			return gcsCoordState{}, err
+		}
+		c.cur, c.gen = st, gen
+	}
+	st := c.cur
+	st.Pending = slices.Clone(st.Pending)
+	return st, nil
+}
+
+// update applies f to the current state and stores the result, retrying with the latest state if
+// it was modified elsewhere in the meantime.
+//
+// f may return errNoChange if the state already reflects its change. Any other error from f is returned.
+//
+// c.mu must be held.
+func (c *gcsCoordinator) update(ctx context.Context, f func(*gcsCoordState) error) error {
+	for {
+		st, err := c.state(ctx)
+		if err != nil {
+			return err
+		}
+		if err := f(&st); err != nil {
+			if errors.Is(err, errNoChange) {
+				return nil
+			}
+			return err
+		}
+		raw, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		gen, err := c.objStore.casObject(ctx, gcsCoordObject, raw, c.gen)
+		if err != nil {
+			// Whatever happened, the stored state may no longer be what we think it is.
+			c.gen = 0
+			if errors.Is(err, errPreconditionFailed) {
+				klog.V(1).Infof("Coordination state modified concurrently, retrying: %v", err)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				continue
+			}
+			return err
+		}
+		c.cur, c.gen = st, gen
+		return nil
+	}
+}
+
+func (c *gcsCoordinator) ensureIdentity(ctx context.Context, id tessera.LogIdentity) error {
+	stored, gen, err := c.objStore.getObject(ctx, gcsIdentityObject)
+	if err != nil {
+		if !errors.Is(err, gcs.ErrObjectNotExist) {
+			return fmt.Errorf("failed to read log identity: %v", err)
+		}
+		stored, gen = nil, 0
+	}
+	updated, err := id.Reconcile(stored)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(stored, updated) {
+		return nil
+	}
+	if _, err := c.objStore.casObject(ctx, gcsIdentityObject, updated, gen); err != nil {
+		return fmt.Errorf("failed to store log identity: %v", err)
+	}
+	return nil
+}
+
+// assignEntries durably assigns each of the passed-in entries an index in the log.
+//
+// The entries are written to a new object, which is then appended to the list of pending batches
+// in the coordination state.
+func (c *gcsCoordinator) assignEntries(ctx context.Context, entries []*tessera.Entry) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.gcsAssignEntries")
+	defer span.End()
+
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		st, err := c.state(ctx)
+		if err != nil {
+			return err
+		}
+		span.SetAttributes(treeSizeKey.Int64(int64(st.Size)))
+		if st.Next-st.Size > c.maxOutstanding {
+			return errTooManyOutstanding
+		}
+
+		next := st.Next
+		sequencedEntries := make([]storage.SequencedEntry, len(entries))
+		for i, e := range entries {
+			sequencedEntries[i] = storage.SequencedEntry{
+				BundleData: e.MarshalBundleData(next + uint64(i)),
+				LeafHash:   e.LeafHash(),
+			}
+		}
+		b := &bytes.Buffer{}
+		if err := gob.NewEncoder(b).Encode(sequencedEntries); err != nil {
+			return fmt.Errorf("failed to serialise batch: %v", err)
+		}
+		batch := gcsBatch{First: next, Count: uint64(len(entries)), Object: fmt.Sprintf("%s%020d-%s", gcsSeqPrefix, next, rand.Text())}
+		if _, err := c.objStore.casObject(ctx, batch.Object, b.Bytes(), 0); err != nil {
+			return fmt.Errorf("failed to write batch: %v", err)
+		}
+
+		err = c.update(ctx, func(st *gcsCoordState) error {
+			if slices.Contains(st.Pending, batch) {
+				// Our earlier attempt to update the state actually succeeded.
+				return errNoChange
+			}
+			if st.Next != next {
+				return errStale
+			}
+			st.Next += batch.Count
+			st.Pending = append(st.Pending, batch)
+			return nil
+		})
+		if errors.Is(err, errStale) {
+			// The index the batch was written for has been taken, so it must be rewritten.
+			if err := c.objStore.deleteObject(ctx, batch.Object); err != nil {
+				klog.Warningf("Failed to delete stale batch: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to flush batch: %w", err)
+		}
+		return nil
+	}
+}
+
+// consumeEntries calls f with the entries in one or more of the pending batches, and once f returns
+// without error, records the new tree state and removes the batches.
+//
+// Returns true if some entries were consumed as a weak signal that there may be further entries waiting to be consumed.
+func (c *gcsCoordinator) consumeEntries(ctx context.Context, limit uint64, f consumeFunc, forceUpdate bool) (bool, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.gcsConsumeEntries")
+	defer span.End()
+
+	c.mu.Lock()
+	st, err := c.state(ctx)
+	c.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+
+	// Whole batches are consumed, but always at least one so that a batch larger than limit can't wedge integration.
+	var batches []gcsBatch
+	var n uint64
+	for _, b := range st.Pending {
+		if len(batches) > 0 && n+b.Count > limit {
+			break
+		}
+		batches = append(batches, b)
+		n += b.Count
+	}
+	if len(batches) == 0 && !forceUpdate {
+		klog.V(1).Info("Found no batches to integrate")
+		return false, nil
+	}
+
+	entries := make([]storage.SequencedEntry, 0, n)
+	for _, b := range batches {
+		if want := st.Size + uint64(len(entries)); b.First != want {
+			return false, fmt.Errorf("integrity fail - expected batch at %d, but found %d", want, b.First)
+		}
+		raw, _, err := c.objStore.getObject(ctx, b.Object)
+		if err != nil {
+			return false, fmt.Errorf("failed to read batch %q: %v", b.Object, err)
+		}
+		be := []storage.SequencedEntry{}
+		if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&be); err != nil {
+			return false, fmt.Errorf("failed to deserialise batch %q: %v", b.Object, err)
+		}
+		if uint64(len(be)) != b.Count {
+			return false, fmt.Errorf("integrity fail - batch %q has %d entries, expected %d", b.Object, len(be), b.Count)
+		}
+		entries = append(entries, be...)
+	}
+
+	newRoot, err := f(ctx, st.Size, entries)
+	if err != nil {
+		return false, err
+	}
+	newSize := st.Size + n
+
+	c.mu.Lock()
+	err = c.update(ctx, func(cur *gcsCoordState) error {
+		if cur.Size == newSize && bytes.Equal(cur.Root, newRoot) {
+			return errNoChange
+		}
+		if cur.Size != st.Size {
+			return fmt.Errorf("tree size changed from %d to %d during integration", st.Size, cur.Size)
+		}
+		if len(cur.Pending) < len(batches) || !slices.Equal(cur.Pending[:len(batches)], batches) {
+			return errors.New("pending batches changed during integration")
+		}
+		cur.Size, cur.Root = newSize, newRoot
+		cur.Pending = cur.Pending[len(batches):]
+		return nil
+	})
+	c.mu.Unlock()
+	if err != nil {
+		return false, fmt.Errorf("failed to update coordination state: %v", err)
+	}
+
+	// The batches are no longer referenced, so failing to delete them only wastes a little storage.
+	for _, b := range batches {
+		if err := c.objStore.deleteObject(ctx, b.Object); err != nil {
+			klog.Warningf("Failed to delete integrated batch: %v", err)
+		}
+	}
+	return len(batches) > 0, nil
+}
+
+// currentTree returns the size and root hash of the currently integrated tree.
+func (c *gcsCoordinator) currentTree(ctx context.Context) (uint64, []byte, error) {
+	st, _, err := c.read(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read coordination state: %v", err)
+	}
+	return st.Size, st.Root, nil
+}
+
+// nextIndex returns the next available index in the log.
+func (c *gcsCoordinator) nextIndex(ctx context.Context) (uint64, error) {
+	st, _, err := c.read(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read coordination state: %v", err)
+	}
+	return st.Next, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"golang.org/x/sync/errgroup"
+)
+
+// consumeChecker returns a consumeFunc which checks that it's called with contiguous entries whose
+// bundle data is as assignEntries would have created it, from the entries recorded in want.
+func consumeChecker(t *testing.T, want map[uint64][]byte) consumeFunc {
+	t.Helper()
+	return func(_ context.Context, from uint64, entries []storage.SequencedEntry) ([]byte, error) {
+		for i, e := range entries {
+			idx := from + uint64(i)
+			if w := tessera.NewEntry(want[idx]).MarshalBundleData(idx); !bytes.Equal(e.BundleData, w) {
+				t.Errorf("Entry %d: got bundle data %x, want %x", idx, e.BundleData, w)
+			}
+		}
+		r := sha256.Sum256(fmt.Appendf(nil, "%d", from+uint64(len(entries))))
+		return r[:], nil
+	}
+}
+
+func TestGCSCoordinatorRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := newMemObjStore()
+	c, err := newGCSCoordinator(ctx, m, newMemObjStore(), 1000, true)
+	if err != nil {
+		t.Fatalf("newGCSCoordinator: %v", err)
+	}
+
+	want := map[uint64][]byte{}
+	next := uint64(0)
+	for batch := range 10 {
+		entries := []*tessera.Entry{}
+		for i := range 5 + batch {
+			d := fmt.Appendf(nil, "item %d/%d", batch, i)
+			entries = append(entries, tessera.NewEntry(d))
+			want[next+uint64(i)] = d
+		}
+		if err := c.assignEntries(ctx, entries); err != nil {
+			t.Fatalf("assignEntries: %v", err)
+		}
+		for i, e := range entries {
+			if got := *e.Index(); got != next {
+				t.Errorf("Batch %d entry %d got index %d, want %d", batch, i, got, next)
+			}
+			next++
+		}
+	}
+	if got, err := c.nextIndex(ctx); err != nil || got != next {
+		t.Fatalf("nextIndex() = %d, %v, want %d", got, err, next)
+	}
+
+	for {
+		more, err := c.consumeEntries(ctx, 20, consumeChecker(t, want), false)
+		if err != nil {
+			t.Fatalf("consumeEntries: %v", err)
+		}
+		if !more {
+			break
+		}
+	}
+	size, root, err := c.currentTree(ctx)
+	if err != nil {
+		t.Fatalf("currentTree: %v", err)
+	}
+	if wantRoot := sha256.Sum256(fmt.Appendf(nil, "%d", next)); size != next || !bytes.Equal(root, wantRoot[:]) {
+		t.Errorf("currentTree() = %d, %x, want %d, %x", size, root, next, wantRoot)
+	}
+	for k := range m.mem {
+		if strings.HasPrefix(k, gcsSeqPrefix) {
+			t.Errorf("Integrated batch %q was not deleted", k)
+		}
+	}
+
+	// A coordinator for the existing state should pick up where this one left off.
+	c2, err := newGCSCoordinator(ctx, m, newMemObjStore(), 1000, false)
+	if err != nil {
+		t.Fatalf("newGCSCoordinator(existing): %v", err)
+	}
+	e := tessera.NewEntry([]byte("one more"))
+	if err := c2.assignEntries(ctx, []*tessera.Entry{e}); err != nil {
+		t.Fatalf("assignEntries: %v", err)
+	}
+	if got := *e.Index(); got != next {
+		t.Errorf("Got index %d after reopening, want %d", got, next)
+	}
+}
+
+func TestGCSCoordinatorNoState(t *testing.T) {
+	if _, err := newGCSCoordinator(context.Background(), newMemObjStore(), newMemObjStore(), 1000, false); err == nil {
+		t.Fatal("newGCSCoordinator succeeded without any state to read")
+	}
+}
+
+func TestGCSCoordinatorRefusesExistingLog(t *testing.T) {
+	ctx := t.Context()
+	// A log which was coordinated with Spanner has a checkpoint, but no GCS coordination state.
+	log, state := newMemObjStore(), newMemObjStore()
+	if err := log.setObject(ctx, layout.CheckpointPath, []byte("example.com/log\n10\n"), nil, tessera.ObjectMetadata{}); err != nil {
+		t.Fatalf("setObject: %v", err)
+	}
+	if _, err := newGCSCoordinator(ctx, state, log, 1000, true); err == nil {
+		t.Fatal("newGCSCoordinator created state for a log which already has a checkpoint")
+	}
+	state.RLock()
+	defer state.RUnlock()
+	if _, ok := state.mem[gcsCoordObject]; ok {
+		t.Errorf("Found %q after newGCSCoordinator failed", gcsCoordObject)
+	}
+}
+
+func TestGCSCoordinatorConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	m := newMemObjStore()
+	var coords []*gcsCoordinator
+	for range 2 {
+		c, err := newGCSCoordinator(ctx, m, newMemObjStore(), 10000, true)
+		if err != nil {
+			t.Fatalf("newGCSCoordinator: %v", err)
+		}
+		coords = append(coords, c)
+	}
+
+	var mu sync.Mutex
+	want := map[uint64][]byte{}
+	eg := errgroup.Group{}
+	for w := range 8 {
+		eg.Go(func() error {
+			c := coords[w%len(coords)]
+			for b := range 10 {
+				entries := []*tessera.Entry{}
+				for i := range 3 {
+					entries = append(entries, tessera.NewEntry(fmt.Appendf(nil, "%d/%d/%d", w, b, i)))
+				}
+				if err := c.assignEntries(ctx, entries); err != nil {
+					return err
+				}
+				mu.Lock()
+				for _, e := range entries {
+					if prev, ok := want[*e.Index()]; ok {
+						mu.Unlock()
+						return fmt.Errorf("index %d assigned to both %q and %q", *e.Index(), prev, e.Data())
+					}
+					want[*e.Index()] = e.Data()
+				}
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		more, err := coords[0].consumeEntries(ctx, 1000, consumeChecker(t, want), false)
+		if err != nil {
+			t.Fatalf("consumeEntries: %v", err)
+		}
+		if !more {
+			break
+		}
+	}
+	if size, _, err := coords[1].currentTree(ctx); err != nil || size != uint64(len(want)) {
+		t.Errorf("currentTree() = %d, %v, want %d", size, err, len(want))
+	}
+}
+
+func TestGCSCoordinatorPushback(t *testing.T) {
+	ctx := context.Background()
+	c, err := newGCSCoordinator(ctx, newMemObjStore(), newMemObjStore(), 10, true)
+	if err != nil {
+		t.Fatalf("newGCSCoordinator: %v", err)
+	}
+	add := func(n int) error {
+		entries := []*tessera.Entry{}
+		for i := range n {
+			entries = append(entries, tessera.NewEntry(fmt.Appendf(nil, "%d", i)))
+		}
+		return c.assignEntries(ctx, entries)
+	}
+	if err := add(11); err != nil {
+		t.Fatalf("assignEntries(11): %v", err)
+	}
+	if err := add(1); !errors.Is(err, tessera.ErrPushback) {
+		t.Fatalf("assignEntries with 11 outstanding: got err %v, want %v", err, tessera.ErrPushback)
+	}
+	if _, err := c.consumeEntries(ctx, 100, func(context.Context, uint64, []storage.SequencedEntry) ([]byte, error) {
+		return []byte("root"), nil
+	}, false); err != nil {
+		t.Fatalf("consumeEntries: %v", err)
+	}
+	if err := add(1); err != nil {
+		t.Fatalf("assignEntries after integration: %v", err)
+	}
+}
+
+func TestGCSCoordinatorEnsureIdentity(t *testing.T) {
+	ctx := context.Background()
+	c, err := newGCSCoordinator(ctx, newMemObjStore(), newMemObjStore(), 1000, true)
+	if err != nil {
+		t.Fatalf("newGCSCoordinator: %v", err)
+	}
+	idA := tessera.NewAppendOptions().WithCheckpointSigner(mustSigner(t)).LogIdentity()
+	idB := tessera.NewAppendOptions().WithCheckpointSigner(mustSigner(t)).LogIdentity()
+	if err := c.ensureIdentity(ctx, idA); err != nil {
+		t.Fatalf("ensureIdentity(A): %v", err)
+	}
+	if err := c.ensureIdentity(ctx, idA); err != nil {
+		t.Fatalf("ensureIdentity(A) again: %v", err)
+	}
+	if err := c.ensureIdentity(ctx, idB); !errors.Is(err, tessera.ErrLogIdentityMismatch) {
+		t.Fatalf("ensureIdentity(B): got err %v, want %v", err, tessera.ErrLogIdentityMismatch)
+	}
+}
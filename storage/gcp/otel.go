@@ -15,7 +15,7 @@
 package gcp
 
 import (
-	"go.opentelemetry.io/otel"
+	"github.com/transparency-dev/tessera/internal/otel"
 	"go.opentelemetry.io/otel/attribute"
 )
 
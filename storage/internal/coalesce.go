@@ -0,0 +1,58 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ReadCoalescer coalesces concurrent reads of the same resource into a single fetch from the backend.
+//
+// This is intended to protect storage backends from a thundering herd of requests for the same
+// not-yet-cached tiles or entry bundles, e.g. right after a new checkpoint is published.
+// It should only be used for immutable resources, since callers may receive the result of a fetch which
+// started before they asked.
+//
+// The zero value is ready to use.
+type ReadCoalescer struct {
+	g singleflight.Group
+}
+
+// Read returns the result of calling f, sharing the result with any concurrent calls for the same key.
+//
+// The shared call to f is not cancelled if the context of the caller which initiated it is cancelled,
+// since other callers may be waiting on it, but Read will return early with the context's error.
+// Each caller receives its own copy of the returned data, so it may be modified freely.
+func (c *ReadCoalescer) Read(ctx context.Context, key string, f func(context.Context) ([]byte, error)) ([]byte, error) {
+	ch := c.g.DoChan(key, func() (any, error) {
+		return f(context.WithoutCancel(ctx))
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		d := r.Val.([]byte)
+		if r.Shared {
+			d = bytes.Clone(d)
+		}
+		return d, nil
+	}
+}
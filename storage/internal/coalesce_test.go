@@ -0,0 +1,130 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	storage "github.com/transparency-dev/tessera/storage/internal"
+)
+
+func TestReadCoalescer(t *testing.T) {
+	ctx := t.Context()
+	c := &storage.ReadCoalescer{}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	f := func(context.Context) ([]byte, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return []byte("tile"), nil
+	}
+
+	const n = 10
+	results := make([][]byte, n)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d, err := c.Read(ctx, "key", f)
+		if err != nil {
+			t.Errorf("Read: %v", err)
+		}
+		results[0] = d
+	}()
+	<-started
+	ready := sync.WaitGroup{}
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		ready.Add(1)
+		go func() {
+			defer wg.Done()
+			ready.Done()
+			d, err := c.Read(ctx, "key", f)
+			if err != nil {
+				t.Errorf("Read: %v", err)
+			}
+			results[i] = d
+		}()
+	}
+	// There's no way to know exactly when the other readers are waiting on the in-flight call, so give them
+	// a moment once they're about to call Read. Even if some of them arrive after it's finished, there'll be
+	// fewer fetches than readers.
+	ready.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got >= n {
+		t.Errorf("Got %d fetches for %d concurrent reads, want fewer", got, n)
+	}
+	for i, r := range results {
+		if string(r) != "tile" {
+			t.Errorf("Result %d: got %q, want %q", i, r, "tile")
+		}
+	}
+	// Callers must each get their own copy of the data.
+	results[0][0] = 'X'
+	if results[1][0] == 'X' {
+		t.Error("Results share backing storage")
+	}
+}
+
+func TestReadCoalescer_CallerCancelled(t *testing.T) {
+	c := &storage.ReadCoalescer{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	cctx, cancel := context.WithCancel(t.Context())
+	errC := make(chan error, 1)
+	go func() {
+		_, err := c.Read(cctx, "key", func(ctx context.Context) ([]byte, error) {
+			close(started)
+			<-release
+			// The fetch must not be cancelled just because the caller which started it went away.
+			return []byte("tile"), ctx.Err()
+		})
+		errC <- err
+	}()
+	<-started
+	cancel()
+	if err := <-errC; !errors.Is(err, context.Canceled) {
+		t.Errorf("Read with cancelled context: got err %v, want %v", err, context.Canceled)
+	}
+
+	// A second reader joins the still in-flight fetch and gets the result.
+	resC := make(chan []byte, 1)
+	go func() {
+		d, err := c.Read(t.Context(), "key", func(context.Context) ([]byte, error) {
+			return []byte("second fetch"), nil
+		})
+		if err != nil {
+			t.Errorf("Read: %v", err)
+		}
+		resC <- d
+	}()
+	close(release)
+	if got := string(<-resC); got != "tile" && got != "second fetch" {
+		t.Errorf("Got %q", got)
+	}
+}
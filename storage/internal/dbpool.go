@@ -0,0 +1,170 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// dbPoolInterval is how often the DB connection pool stats are sampled.
+	dbPoolInterval = 5 * time.Second
+	// dbPoolWaitThreshold is the mean time spent waiting for a connection, over a sampling interval, above
+	// which an adaptive pool will grow.
+	dbPoolWaitThreshold = time.Millisecond
+)
+
+var (
+	dbPoolMaxOpen      metric.Int64Gauge
+	dbPoolOpen         metric.Int64Gauge
+	dbPoolInUse        metric.Int64Gauge
+	dbPoolIdle         metric.Int64Gauge
+	dbPoolWaits        metric.Int64Counter
+	dbPoolWaitDuration metric.Int64Counter
+)
+
+func init() {
+	var err error
+
+	dbPoolMaxOpen, err = meter.Int64Gauge(
+		"tessera.storage.db.pool.max_open",
+		metric.WithDescription("Maximum number of open connections permitted by the DB connection pool"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		klog.Exitf("Failed to create dbPoolMaxOpen metric: %v", err)
+	}
+
+	dbPoolOpen, err = meter.Int64Gauge(
+		"tessera.storage.db.pool.open",
+		metric.WithDescription("Number of open connections in the DB connection pool"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		klog.Exitf("Failed to create dbPoolOpen metric: %v", err)
+	}
+
+	dbPoolInUse, err = meter.Int64Gauge(
+		"tessera.storage.db.pool.in_use",
+		metric.WithDescription("Number of connections in the DB connection pool which are currently in use"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		klog.Exitf("Failed to create dbPoolInUse metric: %v", err)
+	}
+
+	dbPoolIdle, err = meter.Int64Gauge(
+		"tessera.storage.db.pool.idle",
+		metric.WithDescription("Number of idle connections in the DB connection pool"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		klog.Exitf("Failed to create dbPoolIdle metric: %v", err)
+	}
+
+	dbPoolWaits, err = meter.Int64Counter(
+		"tessera.storage.db.pool.waits",
+		metric.WithDescription("Number of times a caller had to wait for a connection from the DB connection pool"),
+		metric.WithUnit("{wait}"))
+	if err != nil {
+		klog.Exitf("Failed to create dbPoolWaits metric: %v", err)
+	}
+
+	dbPoolWaitDuration, err = meter.Int64Counter(
+		"tessera.storage.db.pool.wait_duration",
+		metric.WithDescription("Total time spent by callers waiting for a connection from the DB connection pool"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		klog.Exitf("Failed to create dbPoolWaitDuration metric: %v", err)
+	}
+}
+
+// DBPoolBounds describes the range within which the maximum number of open connections in a DB connection
+// pool may be adapted.
+//
+// The zero value disables adaptation.
+type DBPoolBounds struct {
+	// Min is the lowest value to which the maximum number of open connections may be reduced.
+	Min int
+	// Max is the highest value to which the maximum number of open connections may be raised.
+	Max int
+}
+
+// Enabled returns true if the bounds describe a pool which should be adapted.
+func (b DBPoolBounds) Enabled() bool {
+	return b.Max > 0
+}
+
+// MonitorDBPool periodically records saturation metrics for the provided DB connection pool until ctx is done.
+//
+// If bounds are enabled, the pool's maximum number of open connections is also adapted within them: it is
+// raised when callers are spending a significant amount of time waiting for connections, and lowered when
+// most of the permitted connections are going unused.
+func MonitorDBPool(ctx context.Context, db *sql.DB, bounds DBPoolBounds) {
+	maxOpen := db.Stats().MaxOpenConnections
+	if bounds.Enabled() {
+		maxOpen = min(max(bounds.Min, 1), bounds.Max)
+		db.SetMaxOpenConns(maxOpen)
+		klog.Infof("Adapting DB connection pool size within [%d, %d]", bounds.Min, bounds.Max)
+	}
+	go func() {
+		t := time.NewTicker(dbPoolInterval)
+		defer t.Stop()
+		prev := db.Stats()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			cur := db.Stats()
+			recordDBPoolStats(ctx, prev, cur)
+			if bounds.Enabled() {
+				if n := nextMaxOpenConns(maxOpen, bounds, prev, cur); n != maxOpen {
+					klog.V(1).Infof("Adapting DB connection pool MaxOpenConns %d -> %d (waits: %d, in use: %d)", maxOpen, n, cur.WaitCount-prev.WaitCount, cur.InUse)
+					maxOpen = n
+					db.SetMaxOpenConns(maxOpen)
+				}
+			}
+			prev = cur
+		}
+	}()
+}
+
+func recordDBPoolStats(ctx context.Context, prev, cur sql.DBStats) {
+	dbPoolMaxOpen.Record(ctx, int64(cur.MaxOpenConnections))
+	dbPoolOpen.Record(ctx, int64(cur.OpenConnections))
+	dbPoolInUse.Record(ctx, int64(cur.InUse))
+	dbPoolIdle.Record(ctx, int64(cur.Idle))
+	dbPoolWaits.Add(ctx, cur.WaitCount-prev.WaitCount)
+	dbPoolWaitDuration.Add(ctx, (cur.WaitDuration - prev.WaitDuration).Milliseconds())
+}
+
+// nextMaxOpenConns returns the maximum number of open connections the pool should permit, given its current
+// value and the pool stats at the start and end of the most recent sampling interval.
+//
+// The pool grows quickly under contention, and shrinks slowly when it's underused, in order to avoid
+// oscillating under bursty load.
+func nextMaxOpenConns(maxOpen int, bounds DBPoolBounds, prev, cur sql.DBStats) int {
+	waits := cur.WaitCount - prev.WaitCount
+	switch {
+	case waits > 0 && (cur.WaitDuration-prev.WaitDuration)/time.Duration(waits) >= dbPoolWaitThreshold:
+		maxOpen += max(1, maxOpen/4)
+	case waits == 0 && cur.InUse < maxOpen/2:
+		maxOpen -= max(1, maxOpen/8)
+	}
+	return min(max(maxOpen, bounds.Min, 1), bounds.Max)
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestNextMaxOpenConns(t *testing.T) {
+	bounds := DBPoolBounds{Min: 4, Max: 64}
+	for _, test := range []struct {
+		desc    string
+		maxOpen int
+		cur     sql.DBStats
+		want    int
+	}{
+		{
+			desc:    "contended grows",
+			maxOpen: 16,
+			cur:     sql.DBStats{InUse: 16, WaitCount: 10, WaitDuration: 100 * time.Millisecond},
+			want:    20,
+		}, {
+			desc:    "contended capped at max",
+			maxOpen: 60,
+			cur:     sql.DBStats{InUse: 60, WaitCount: 10, WaitDuration: time.Second},
+			want:    64,
+		}, {
+			desc:    "brief waits hold steady",
+			maxOpen: 16,
+			cur:     sql.DBStats{InUse: 16, WaitCount: 10, WaitDuration: time.Millisecond},
+			want:    16,
+		}, {
+			desc:    "busy without waits holds steady",
+			maxOpen: 16,
+			cur:     sql.DBStats{InUse: 12},
+			want:    16,
+		}, {
+			desc:    "underused shrinks",
+			maxOpen: 16,
+			cur:     sql.DBStats{InUse: 2},
+			want:    14,
+		}, {
+			desc:    "underused capped at min",
+			maxOpen: 4,
+			cur:     sql.DBStats{InUse: 0},
+			want:    4,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := nextMaxOpenConns(test.maxOpen, bounds, sql.DBStats{}, test.cur); got != test.want {
+				t.Errorf("nextMaxOpenConns(%d) = %d, want %d", test.maxOpen, got, test.want)
+			}
+		})
+	}
+}
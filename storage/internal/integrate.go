@@ -80,7 +80,7 @@ func (t *treeBuilder) newRange(ctx context.Context, treeSize uint64) (*compact.R
 		toFetch[TileID{Level: tLevel, Index: tIndex}] = struct{}{}
 	}
 	if err := t.readCache.Prewarm(ctx, maps.Keys(toFetch), treeSize); err != nil {
-		return nil, fmt.Errorf("Prewarm: %v", err)
+		return nil, fmt.Errorf("Prewarm: %w", err)
 	}
 
 	hashes := make([][]byte, 0, len(rangeNodes))
@@ -0,0 +1,73 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// batchSizeBuckets are the histogram bucket bounds for the number of entries in a sequenced batch.
+var batchSizeBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// Metrics holds the instruments which storage drivers report to, created with the MetricsProvider
+// from the tessera.AppendOptions they were configured with.
+//
+// Every series is labelled with the name of the driver, so that several logs backed by different
+// drivers may share a provider. A nil *Metrics discards everything, for code paths such as migration
+// which don't have a MetricsProvider.
+type Metrics struct {
+	driver            tessera.MetricLabel
+	batchSize         tessera.MetricHistogram
+	tileWrites        tessera.MetricCounter
+	checkpointPublish tessera.MetricHistogram
+}
+
+// NewMetrics creates the storage instruments for the named driver with the provided MetricsProvider.
+func NewMetrics(p tessera.MetricsProvider, driver string) *Metrics {
+	return &Metrics{
+		driver:            tessera.MetricLabel{Key: "driver", Value: driver},
+		batchSize:         p.Histogram("tessera_storage_batch_size", "Number of entries in each batch sequenced by the storage driver", batchSizeBuckets),
+		tileWrites:        p.Counter("tessera_storage_tile_writes_total", "Number of tiles written by the storage driver, by tree level"),
+		checkpointPublish: p.Histogram("tessera_storage_checkpoint_publish_duration_seconds", "Time taken to create, sign, and store each newly published checkpoint", tessera.LatencyBuckets),
+	}
+}
+
+// BatchSequenced records that a batch of n entries has been sequenced.
+func (m *Metrics) BatchSequenced(ctx context.Context, n int) {
+	if m == nil {
+		return
+	}
+	m.batchSize.Record(ctx, float64(n), m.driver)
+}
+
+// TileWritten records that a tile at the given level of the tree has been written.
+func (m *Metrics) TileWritten(ctx context.Context, level uint64) {
+	if m == nil {
+		return
+	}
+	m.tileWrites.Add(ctx, 1, m.driver, tessera.MetricLabel{Key: "level", Value: strconv.FormatUint(level, 10)})
+}
+
+// CheckpointPublished records that a new checkpoint was published, having started doing so at start.
+func (m *Metrics) CheckpointPublished(ctx context.Context, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.checkpointPublish.Record(ctx, time.Since(start).Seconds(), m.driver)
+}
@@ -15,6 +15,7 @@
 package storage
 
 import (
+	iotel "github.com/transparency-dev/tessera/internal/otel"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 )
@@ -22,7 +23,8 @@ import (
 const name = "github.com/transparency-dev/tessera/storage"
 
 var (
-	tracer = otel.Tracer(name)
+	tracer = iotel.Tracer(name)
+	meter  = otel.Meter(name)
 )
 
 var (
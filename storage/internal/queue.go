@@ -18,11 +18,14 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/globocom/go-buffer"
 	"github.com/transparency-dev/tessera"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Queue knows how to queue up a number of entries in order, taking care of deduplication as they're added.
@@ -36,9 +39,45 @@ import (
 // duplicate add calls.
 // Note that this deduplication only applies to "in-flight" entries currently in the queue; entries added
 // after a flush will not be deduped against those added before the flush.
+//
+// Entries added together via AddBatch are always passed to the same call to the FlushFunc, next to
+// each other, so that they're sequenced contiguously. A batch which wouldn't fit alongside the entries
+// already waiting causes those entries to be flushed first, so no flush holds more than the maximum
+// number of entries.
+//
+// Entries which the function set with WithPriority classifies as high priority skip the main queue, and are
+// instead placed in a small priority lane which is flushed quickly, and ahead of any batches from the main
+// queue which are waiting to be flushed.
 type Queue struct {
 	buf   *buffer.Buffer
 	flush FlushFunc
+	// prioBuf holds priority entries, and is nil if there's no priority classification function.
+	prioBuf    *buffer.Buffer
+	isPriority func(context.Context, *tessera.Entry) bool
+	maxSize    uint
+	maxAge     time.Duration
+	// maxPending, if non-zero, is the number of pending entries beyond which new entries are pushed back.
+	maxPending uint64
+	// pending is the number of entries which have been added but whose flush hasn't yet completed.
+	pending atomic.Int64
+	// metrics, if set, records the size of each flushed batch.
+	metrics *Metrics
+	// workMu and prioWorkMu are held while handing batches from the main queue and the priority lane
+	// respectively to the worker, so that the worker can be sure that no more will arrive once it has
+	// stopped. Each lane has its own lock so that a bulk batch waiting for the worker, which may take as
+	// long as a flush, can't hold up a priority batch.
+	workMu     sync.Mutex
+	prioWorkMu sync.Mutex
+
+	// pushMu serialises pushes to buf, so that the number of entries waiting in it is known when deciding
+	// whether it must be flushed.
+	pushMu sync.Mutex
+	// pushed is the total number of entries pushed to buf, and flushedMark the number which had been
+	// pushed when buf was last flushed by the queue itself. Both are guarded by pushMu.
+	pushed, flushedMark uint64
+	// flushed is the total number of entries which buf has passed on to the worker, including those from
+	// flushes which buf triggered itself.
+	flushed atomic.Uint64
 }
 
 // FlushFunc is the signature of a function which will receive the slice of queued entries.
@@ -48,14 +87,58 @@ type Queue struct {
 // See the comment on Entry.MarshalBundleData for further info.
 type FlushFunc func(ctx context.Context, entries []*tessera.Entry) error
 
+// QueueOption configures optional behaviour of a Queue.
+type QueueOption func(*Queue)
+
+// WithMaxPending causes entries to be rejected with a tessera.PushbackError, rather than being queued,
+// while n or more entries are already pending. This is for storage implementations which sequence and
+// integrate in the same operation, where a growing number of pending entries is the sign of overload.
+//
+// Zero means no limit.
+func WithMaxPending(n uint64) QueueOption {
+	return func(q *Queue) {
+		q.maxPending = n
+	}
+}
+
+// WithPriority sets the function used to decide which entries added with Add are placed in the priority lane.
+//
+// Priority entries are exempt from the limit set by WithMaxPending, as they're expected to be few and
+// important, so isPriority should be selective. Entries added with AddBatch are never prioritised.
+func WithPriority(isPriority func(context.Context, *tessera.Entry) bool) QueueOption {
+	return func(q *Queue) {
+		q.isPriority = isPriority
+	}
+}
+
+// WithMetrics causes the size of each batch which is successfully flushed to be recorded with m.
+func WithMetrics(m *Metrics) QueueOption {
+	return func(q *Queue) {
+		q.metrics = m
+	}
+}
+
+const (
+	// priorityMaxSize is the most entries which the priority lane will hold before it's flushed. It's kept
+	// small so that sequencing a priority batch is quick.
+	priorityMaxSize = 16
+	// priorityMaxAge is the longest an entry will wait in the priority lane before it's flushed.
+	priorityMaxAge = 10 * time.Millisecond
+)
+
 // NewQueue creates a new queue with the specified maximum age and size.
 //
 // The provided FlushFunc will be called with a slice containing the contents of the queue, in
 // the same order as they were added, when either the oldest entry in the queue has been there
 // for maxAge, or the size of the queue reaches maxSize.
-func NewQueue(ctx context.Context, maxAge time.Duration, maxSize uint, f FlushFunc) *Queue {
+func NewQueue(ctx context.Context, maxAge time.Duration, maxSize uint, f FlushFunc, opts ...QueueOption) *Queue {
 	q := &Queue{
-		flush: f,
+		flush:   f,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+	for _, o := range opts {
+		o(q)
 	}
 
 	// The underlying queue implementation blocks additions during a flush.
@@ -64,27 +147,78 @@ func NewQueue(ctx context.Context, maxAge time.Duration, maxSize uint, f FlushFu
 	// a worker goroutine.
 	// This same worker thread will also handle the callbacks to f.
 	work := make(chan []*queueItem, 1)
-	toWork := func(items []any) {
-		entries := make([]*queueItem, len(items))
-		for i, t := range items {
-			entries[i] = t.(*queueItem)
+	// Priority batches have their own channel, so that they don't queue up behind bulk batches.
+	prioWork := make(chan []*queueItem, 1)
+	toWork := func(work chan<- []*queueItem, mu *sync.Mutex, flushed *atomic.Uint64) buffer.FlusherFunc {
+		return func(items []any) {
+			entries := make([]*queueItem, 0, len(items))
+			for _, t := range items {
+				switch t := t.(type) {
+				case *queueItem:
+					entries = append(entries, t)
+				case []*queueItem:
+					entries = append(entries, t...)
+				}
+			}
+			if flushed != nil {
+				flushed.Add(uint64(len(entries)))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if ctx.Err() == nil {
+				select {
+				case work <- entries:
+					return
+				case <-ctx.Done():
+				}
+			}
+			q.done(entries, stoppedErr(ctx))
 		}
-		work <- entries
-
 	}
 
+	// buf counts each push, and so each batch, as one item, so push flushes it whenever the number of
+	// entries waiting would otherwise pass maxSize.
 	q.buf = buffer.New(
 		buffer.WithSize(maxSize),
 		buffer.WithFlushInterval(maxAge),
-		buffer.WithFlusher(buffer.FlusherFunc(toWork)),
+		buffer.WithFlusher(toWork(work, &q.workMu, &q.flushed)),
 	)
+	if q.isPriority != nil {
+		q.prioBuf = buffer.New(
+			buffer.WithSize(min(priorityMaxSize, maxSize)),
+			buffer.WithFlushInterval(min(priorityMaxAge, maxAge)),
+			buffer.WithFlusher(toWork(prioWork, &q.prioWorkMu, nil)),
+		)
+	}
 
 	// Spin off a worker thread to write the queue flushes to storage.
 	go func(ctx context.Context) {
 		for {
+			// Any waiting priority batch goes first, even if a bulk batch has been waiting for longer.
+			select {
+			case entries := <-prioWork:
+				q.doFlush(ctx, entries)
+				continue
+			default:
+			}
 			select {
 			case <-ctx.Done():
+				// Entries can no longer be sequenced, so fail any which are waiting rather than leaving
+				// their futures unresolved. Once the locks are held, toWork will fail any later batches itself.
+				q.prioWorkMu.Lock()
+				defer q.prioWorkMu.Unlock()
+				q.workMu.Lock()
+				defer q.workMu.Unlock()
+				for _, c := range []chan []*queueItem{prioWork, work} {
+					select {
+					case entries := <-c:
+						q.done(entries, stoppedErr(ctx))
+					default:
+					}
+				}
 				return
+			case entries := <-prioWork:
+				q.doFlush(ctx, entries)
 			case entries := <-work:
 				q.doFlush(ctx, entries)
 			}
@@ -93,32 +227,209 @@ func NewQueue(ctx context.Context, maxAge time.Duration, maxSize uint, f FlushFu
 	return q
 }
 
+// stoppedErr returns the error given to entries which couldn't be sequenced because ctx is done.
+func stoppedErr(ctx context.Context) error {
+	return fmt.Errorf("queue stopped before entry was sequenced: %w", context.Cause(ctx))
+}
+
 // Add places e into the queue, and returns a func which may be called to retrieve the assigned index.
 func (q *Queue) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFuture {
 	_, span := tracer.Start(ctx, "tessera.storage.queue.Add")
 	defer span.End()
 
 	qi := newEntry(e)
+	qi.link = trace.LinkFromContext(ctx)
+
+	if q.prioBuf != nil && q.isPriority(ctx, e) {
+		q.pending.Add(1)
+		if err := q.prioBuf.Push(qi); err != nil {
+			q.pending.Add(-1)
+			qi.notify(err)
+		}
+		return qi.f
+	}
 
-	if err := q.buf.Push(qi); err != nil {
+	// Count the entry before pushing it, as the push may trigger a flush which completes immediately.
+	if err := q.reserve(1); err != nil {
+		qi.notify(err)
+		return qi.f
+	}
+	if err := q.push(ctx, qi, 1); err != nil {
+		q.pending.Add(-1)
 		qi.notify(err)
 	}
 	return qi.f
 }
 
-// doFlush handles the queue flush, and sending notifications of assigned log indices.
-func (q *Queue) doFlush(ctx context.Context, entries []*queueItem) {
-	ctx, span := tracer.Start(ctx, "tessera.storage.queue.doFlush")
+// AddBatch places all of entries into the queue, and returns a func for each of them which may be
+// called to retrieve its assigned index.
+//
+// The entries are passed to the FlushFunc together and in order, so they are assigned contiguous indices.
+// Entries already waiting are flushed first if the batch wouldn't fit alongside them, and batches
+// holding more entries than the maximum size of the queue are rejected, so that no flush holds more
+// than that many entries.
+func (q *Queue) AddBatch(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+	_, span := tracer.Start(ctx, "tessera.storage.queue.AddBatch")
 	defer span.End()
 
+	items := make([]*queueItem, len(entries))
+	fs := make([]tessera.IndexFuture, len(entries))
+	link := trace.LinkFromContext(ctx)
+	for i, e := range entries {
+		items[i] = newEntry(e)
+		items[i].link = link
+		fs[i] = items[i].f
+	}
+	if len(items) == 0 {
+		return fs
+	}
+	if uint(len(items)) > q.maxSize {
+		err := fmt.Errorf("batch of %d entries is larger than the maximum of %d", len(items), q.maxSize)
+		for _, qi := range items {
+			qi.notify(err)
+		}
+		return fs
+	}
+
+	if err := q.reserve(len(items)); err != nil {
+		for _, qi := range items {
+			qi.notify(err)
+		}
+		return fs
+	}
+	// The batch is pushed as a single item so that no other entries can be interleaved with it.
+	if err := q.push(ctx, items, len(items)); err != nil {
+		q.done(items, err)
+	}
+	return fs
+}
+
+// push adds item, which holds n entries, to buf. If buf is already waiting to flush entries which
+// leave no room for n more, they are flushed first, and buf is flushed straight away once it holds
+// the maximum number of entries, so that no flush holds more than that.
+func (q *Queue) push(ctx context.Context, item any, n int) error {
+	q.pushMu.Lock()
+	defer q.pushMu.Unlock()
+
+	if q.buffered()+uint64(n) > uint64(q.maxSize) {
+		if err := q.flushBuf(ctx); err != nil {
+			return err
+		}
+	}
+	if err := q.buf.Push(item); err != nil {
+		return err
+	}
+	q.pushed += uint64(n)
+	if q.buffered() >= uint64(q.maxSize) {
+		// The entries have been queued, so failing to flush early only means that they'll be flushed later.
+		_ = q.flushBuf(ctx)
+	}
+	return nil
+}
+
+// buffered returns the number of entries waiting in buf. It must be called with pushMu held.
+func (q *Queue) buffered() uint64 {
+	// buf passes entries to its flusher in the order they were pushed, and once a flush has been requested
+	// it holds none of the entries pushed beforehand, even if the flusher hasn't yet counted them.
+	return q.pushed - max(q.flushedMark, q.flushed.Load())
+}
+
+// flushBuf flushes every entry waiting in buf. It must be called with pushMu held.
+func (q *Queue) flushBuf(ctx context.Context) error {
+	// The buffer may be busy handing an earlier batch to the worker, in which case the request times out.
+	for err := q.buf.Flush(); err != nil; err = q.buf.Flush() {
+		if !errors.Is(err, buffer.ErrTimeout) {
+			return fmt.Errorf("failed to flush queue: %v", err)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to flush queue: %w", ctx.Err())
+		}
+	}
+	q.flushedMark = q.pushed
+	return nil
+}
+
+// reserve counts n more entries as pending, unless doing so would take the number pending past the
+// limit set with WithMaxPending, in which case a tessera.PushbackError is returned instead.
+func (q *Queue) reserve(n int) error {
+	p := q.pending.Add(int64(n))
+	if q.maxPending == 0 || uint64(p) <= q.maxPending {
+		return nil
+	}
+	q.pending.Add(-int64(n))
+	// By the time a couple of batches have been flushed, there's a good chance that there will be room.
+	return &tessera.PushbackError{Reason: "too many pending entries", RetryAfter: 2 * q.maxAge}
+}
+
+// Flush passes any entries waiting in the queue to the FlushFunc immediately, rather than waiting for
+// the batch to fill or age out, and returns once every entry has been sequenced or has failed.
+//
+// Entries added while this is running are also waited for, so callers should stop adding entries
+// first, e.g. when shutting down.
+func (q *Queue) Flush(ctx context.Context) error {
+	if q.Pending() == 0 {
+		return nil
+	}
+	for _, b := range []*buffer.Buffer{q.prioBuf, q.buf} {
+		if b == nil {
+			continue
+		}
+		// The buffer may be busy handing an earlier batch to the worker, in which case the request times out.
+		for err := b.Flush(); err != nil; err = b.Flush() {
+			if !errors.Is(err, buffer.ErrTimeout) {
+				return fmt.Errorf("failed to flush queue: %v", err)
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("failed to flush queue: %w", ctx.Err())
+			}
+		}
+	}
+	t := time.NewTicker(10 * time.Millisecond)
+	defer t.Stop()
+	for q.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d entries still pending: %w", q.Pending(), ctx.Err())
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of entries which have been added to the queue but which haven't yet been
+// sequenced, either because they're waiting for the queue to be flushed or because the flush is in
+// progress.
+func (q *Queue) Pending() uint64 {
+	return uint64(max(q.pending.Load(), 0))
+}
+
+// doFlush handles the queue flush, and sending notifications of assigned log indices.
+func (q *Queue) doFlush(ctx context.Context, entries []*queueItem) {
+	// The batch is flushed in the queue's own context, so it's linked to the spans which added its
+	// entries to tie it into each of their traces.
+	links := make([]trace.Link, 0, len(entries))
 	entriesData := make([]*tessera.Entry, 0, len(entries))
 	for _, e := range entries {
 		entriesData = append(entriesData, e.entry)
+		if e.link.SpanContext.IsValid() {
+			links = append(links, e.link)
+		}
 	}
+	ctx, span := tracer.Start(ctx, "tessera.storage.queue.doFlush", trace.WithLinks(links...))
+	defer span.End()
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
 
 	err := q.flush(ctx, entriesData)
+	if err == nil {
+		q.metrics.BatchSequenced(ctx, len(entriesData))
+	}
+	q.done(entries, err)
+}
 
-	// Send assigned indices to all the waiting Add() requests
+// done marks the entries as no longer pending, and sends their assigned indices, or err, to the
+// waiting Add() requests.
+func (q *Queue) done(entries []*queueItem, err error) {
+	q.pending.Add(-int64(len(entries)))
 	for _, e := range entries {
 		e.notify(err)
 	}
@@ -132,6 +443,8 @@ type queueItem struct {
 	entry *tessera.Entry
 	c     chan tessera.IndexFuture
 	f     tessera.IndexFuture
+	// link refers to the span, if any, in which the entry was added.
+	link trace.Link
 }
 
 // newEntry creates a new entry for the provided data.
@@ -146,17 +459,20 @@ func newEntry(data *tessera.Entry) *queueItem {
 	return e
 }
 
-// assign sets the assigned log index (or an error) to the entry.
+// notify sets the assigned log index (or an error) to the entry.
 //
 // This func must only be called once, and will cause any current or future callers of index()
 // to be given the values provided here.
+//
+// If err is nil but the storage implementation didn't assign an index to the entry, callers will
+// be given an error wrapping tessera.ErrIndexNotAssigned.
 func (e *queueItem) notify(err error) {
 	e.c <- func() (tessera.Index, error) {
 		if err != nil {
 			return tessera.Index{}, err
 		}
 		if e.entry.Index() == nil {
-			panic(errors.New("logic error: flush complete, but entry was not assigned an index - did storage fail to call entry.MarshalBundleData?"))
+			return tessera.Index{}, fmt.Errorf("%w: flush complete, but entry was not assigned an index - did storage fail to call entry.MarshalBundleData?", tessera.ErrIndexNotAssigned)
 		}
 		return tessera.Index{Index: *e.entry.Index()}, nil
 	}
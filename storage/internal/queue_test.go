@@ -16,14 +16,23 @@ package storage_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/otel"
+	tessera_prometheus "github.com/transparency-dev/tessera/metrics/prometheus"
 	storage "github.com/transparency-dev/tessera/storage/internal"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestQueue(t *testing.T) {
@@ -95,3 +104,446 @@ func TestQueue(t *testing.T) {
 		})
 	}
 }
+
+func TestQueueIndexNotAssigned(t *testing.T) {
+	ctx := t.Context()
+	// flushFunc mimics buggy storage which forgets to assign indices to entries.
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Millisecond, 10, flushFunc)
+
+	if _, err := q.Add(ctx, tessera.NewEntry([]byte("unassigned")))(); !errors.Is(err, tessera.ErrIndexNotAssigned) {
+		t.Errorf("Add: got err %v, want %v", err, tessera.ErrIndexNotAssigned)
+	}
+}
+
+func TestQueuePending(t *testing.T) {
+	ctx := t.Context()
+	flushing, release := make(chan struct{}), make(chan struct{})
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		close(flushing)
+		<-release
+		for i, e := range entries {
+			_ = e.MarshalBundleData(uint64(i))
+		}
+		return nil
+	}
+	// The batch only fills once all of the entries have been added, so they're flushed together.
+	q := storage.NewQueue(ctx, time.Hour, 3, flushFunc)
+
+	adds := []tessera.IndexFuture{}
+	for i := range 3 {
+		adds = append(adds, q.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "item %d", i))))
+	}
+	// Entries being flushed haven't been sequenced yet, so are still pending.
+	<-flushing
+	if got, want := q.Pending(), uint64(3); got != want {
+		t.Errorf("Pending() during flush = %d, want %d", got, want)
+	}
+	close(release)
+	for _, f := range adds {
+		if _, err := f(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if got, want := q.Pending(), uint64(0); got != want {
+		t.Errorf("Pending() after flush = %d, want %d", got, want)
+	}
+}
+
+func TestQueueFlush(t *testing.T) {
+	ctx := t.Context()
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		for i, e := range entries {
+			_ = e.MarshalBundleData(uint64(i))
+		}
+		return nil
+	}
+	// Batches would never fill or age out during the test, so only Flush can sequence the entries.
+	q := storage.NewQueue(ctx, time.Hour, 10, flushFunc)
+
+	var adds []tessera.IndexFuture
+	for i := range 3 {
+		adds = append(adds, q.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "item %d", i))))
+	}
+	fctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := q.Flush(fctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := q.Pending(); got != 0 {
+		t.Errorf("Pending() after Flush = %d, want 0", got)
+	}
+	for i, f := range adds {
+		if idx, err := f(); err != nil || idx.Index != uint64(i) {
+			t.Errorf("Add %d: got (%v, %v), want index %d", i, idx, err, i)
+		}
+	}
+}
+
+func TestQueueStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		t.Error("FlushFunc called after queue was stopped")
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Millisecond, 10, flushFunc)
+	cancel()
+
+	// The entry can't be sequenced, but its future must still resolve.
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.Add(t.Context(), tessera.NewEntry([]byte("too late")))()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Add: got err %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("future for entry added to stopped queue didn't resolve")
+	}
+	if got := q.Pending(); got != 0 {
+		t.Errorf("Pending() = %d, want 0", got)
+	}
+}
+
+func TestQueueAddBatch(t *testing.T) {
+	ctx := t.Context()
+	var mu sync.Mutex
+	var next uint64
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+		}
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Millisecond, 8, flushFunc)
+
+	// Individual entries are added alongside the batches, and mustn't end up in the middle of one.
+	var wg sync.WaitGroup
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				if _, err := q.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "single %d/%d", i, j)))(); err != nil {
+					t.Errorf("Add: %v", err)
+				}
+			}
+		}()
+	}
+	for i := range 20 {
+		batch := make([]*tessera.Entry, 5)
+		for j := range batch {
+			batch[j] = tessera.NewEntry(fmt.Appendf(nil, "batch %d/%d", i, j))
+		}
+		fs := q.AddBatch(ctx, batch)
+		first, err := fs[0]()
+		if err != nil {
+			t.Fatalf("AddBatch: %v", err)
+		}
+		for j, f := range fs {
+			idx, err := f()
+			if err != nil {
+				t.Fatalf("AddBatch: %v", err)
+			}
+			if got, want := idx.Index, first.Index+uint64(j); got != want {
+				t.Errorf("batch %d entry %d: got index %d, want %d", i, j, got, want)
+			}
+		}
+	}
+	wg.Wait()
+
+	// Batches which could never fit into a flush are rejected.
+	fs := q.AddBatch(ctx, make([]*tessera.Entry, 9))
+	if got, want := len(fs), 9; got != want {
+		t.Fatalf("AddBatch returned %d futures, want %d", got, want)
+	}
+	if _, err := fs[0](); err == nil {
+		t.Error("AddBatch(oversized): want error")
+	}
+	if got := q.Pending(); got != 0 {
+		t.Errorf("Pending() = %d, want 0", got)
+	}
+}
+
+func TestQueueFlushSize(t *testing.T) {
+	ctx := t.Context()
+	const maxSize = 8
+	var mu sync.Mutex
+	var next uint64
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(entries) > maxSize {
+			t.Errorf("Flushed %d entries, want at most %d", len(entries), maxSize)
+		}
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+		}
+		return nil
+	}
+	// The queue never ages out, so only its size causes flushes until it's flushed explicitly.
+	q := storage.NewQueue(ctx, time.Hour, maxSize, flushFunc)
+
+	var wg sync.WaitGroup
+	var fs []tessera.IndexFuture
+	var fsMu sync.Mutex
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				var f []tessera.IndexFuture
+				if j%2 == 0 {
+					f = []tessera.IndexFuture{q.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "single %d/%d", i, j)))}
+				} else {
+					batch := make([]*tessera.Entry, 1+(i+j)%maxSize)
+					for k := range batch {
+						batch[k] = tessera.NewEntry(fmt.Appendf(nil, "batch %d/%d/%d", i, j, k))
+					}
+					f = q.AddBatch(ctx, batch)
+				}
+				fsMu.Lock()
+				fs = append(fs, f...)
+				fsMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	for _, f := range fs {
+		if _, err := f(); err != nil {
+			t.Errorf("Add: %v", err)
+		}
+	}
+}
+
+func TestQueueMaxPending(t *testing.T) {
+	ctx := t.Context()
+	release := make(chan struct{})
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		<-release
+		for i, e := range entries {
+			_ = e.MarshalBundleData(uint64(i))
+		}
+		return nil
+	}
+	// Nothing is flushed until the test calls Flush, and that flush is held until release is closed.
+	q := storage.NewQueue(ctx, time.Hour, 10, flushFunc, storage.WithMaxPending(3))
+
+	var adds []tessera.IndexFuture
+	for i := range 3 {
+		adds = append(adds, q.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "item %d", i))))
+	}
+	_, err := q.Add(ctx, tessera.NewEntry([]byte("one too many")))()
+	if !errors.Is(err, tessera.ErrPushback) {
+		t.Fatalf("Add past limit: got err %v, want %v", err, tessera.ErrPushback)
+	}
+	if got, want := tessera.RetryAfter(err), 2*time.Hour; got != want {
+		t.Errorf("RetryAfter: got %v, want %v", got, want)
+	}
+	if _, err := q.AddBatch(ctx, []*tessera.Entry{tessera.NewEntry([]byte("batch"))})[0](); !errors.Is(err, tessera.ErrPushback) {
+		t.Errorf("AddBatch past limit: got err %v, want %v", err, tessera.ErrPushback)
+	}
+	if got, want := q.Pending(), uint64(3); got != want {
+		t.Errorf("Pending() = %d, want %d", got, want)
+	}
+
+	close(release)
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	for i, f := range adds {
+		if _, err := f(); err != nil {
+			t.Errorf("Add %d: %v", i, err)
+		}
+	}
+	// Now the backlog has cleared, entries are accepted again.
+	q.Add(ctx, tessera.NewEntry([]byte("room now")))
+	if got, want := q.Pending(), uint64(1); got != want {
+		t.Errorf("Pending() = %d, want %d", got, want)
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	ctx := t.Context()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var batches []string
+	var next uint64
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		var b []string
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+			b = append(b, string(e.Data()))
+		}
+		batches = append(batches, strings.Join(b, ","))
+		return nil
+	}
+	isPriority := func(_ context.Context, e *tessera.Entry) bool {
+		return strings.HasPrefix(string(e.Data()), "prio")
+	}
+	q := storage.NewQueue(ctx, time.Hour, 2, flushFunc, storage.WithPriority(isPriority), storage.WithMaxPending(4))
+
+	// The first bulk batch holds up the worker, and the second waits behind it.
+	var fs []tessera.IndexFuture
+	for _, d := range []string{"a", "b", "c", "d"} {
+		fs = append(fs, q.Add(ctx, tessera.NewEntry([]byte(d))))
+	}
+	// Priority entries are neither held up by the bulk queue's maximum age, nor pushed back by its limit.
+	fs = append(fs, q.Add(ctx, tessera.NewEntry([]byte("prio"))))
+	// Give the priority lane time to age out and hand its batch to the worker.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	for i, f := range fs {
+		if _, err := f(); err != nil {
+			t.Fatalf("Add %d: %v", i, err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := batches, []string{"a,b", "prio", "c,d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got batches %q, want %q", got, want)
+	}
+}
+
+func TestQueuePrioritySaturatedBulk(t *testing.T) {
+	ctx := t.Context()
+	flushing, release := make(chan struct{}, 1), make(chan struct{})
+	var mu sync.Mutex
+	var batches []string
+	var next uint64
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		select {
+		case flushing <- struct{}{}:
+		default:
+		}
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		var b []string
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+			b = append(b, string(e.Data()))
+		}
+		batches = append(batches, strings.Join(b, ","))
+		return nil
+	}
+	isPriority := func(_ context.Context, e *tessera.Entry) bool {
+		return strings.HasPrefix(string(e.Data()), "prio")
+	}
+	q := storage.NewQueue(ctx, time.Hour, 2, flushFunc, storage.WithPriority(isPriority))
+
+	// Saturate the bulk lane: the first batch holds up the worker, the second waits to be picked up by it,
+	// and the third waits to be handed to it.
+	var fs []tessera.IndexFuture
+	for i, d := range []string{"a", "b", "c", "d", "e", "f"} {
+		fs = append(fs, q.Add(ctx, tessera.NewEntry([]byte(d))))
+		if i == 1 {
+			<-flushing
+		}
+	}
+	// A full priority batch is handed to the worker at once. The priority lane can only accept another
+	// entry once it has done so, which it mustn't be prevented from doing by the saturated bulk lane.
+	for _, d := range []string{"prio1", "prio2", "prio3"} {
+		fs = append(fs, q.Add(ctx, tessera.NewEntry([]byte(d))))
+	}
+	close(release)
+
+	for i, f := range fs {
+		if _, err := f(); err != nil {
+			t.Fatalf("Add %d: %v", i, err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := batches[:2], []string{"a,b", "prio1,prio2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got first batches %q, want %q", got, want)
+	}
+}
+
+func TestQueueMetrics(t *testing.T) {
+	ctx := t.Context()
+	reg := prometheus.NewRegistry()
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		for i, e := range entries {
+			_ = e.MarshalBundleData(uint64(i))
+		}
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Hour, 10, flushFunc, storage.WithMetrics(storage.NewMetrics(tessera_prometheus.New(reg), "test")))
+
+	for i := range 3 {
+		q.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "item %d", i)))
+	}
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	b := rec.Body
+	for _, want := range []string{
+		`tessera_storage_batch_size_count{driver="test"} 1`,
+		`tessera_storage_batch_size_sum{driver="test"} 3`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics output missing %q:\n%s", want, b)
+		}
+	}
+}
+
+func TestQueueTracing(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	ctx := otel.ContextWithTracerProvider(t.Context(), tp)
+	flushed := make(chan sdktrace.ReadOnlySpan, 1)
+	flushFunc := func(ctx context.Context, entries []*tessera.Entry) error {
+		// The span is only a ReadOnlySpan if it was created by tp.
+		s, _ := trace.SpanFromContext(ctx).(sdktrace.ReadOnlySpan)
+		flushed <- s
+		for i, e := range entries {
+			_ = e.MarshalBundleData(uint64(i))
+		}
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Hour, 10, flushFunc)
+
+	var want []trace.SpanContext
+	for i := range 2 {
+		actx, span := tp.Tracer("test").Start(t.Context(), "add")
+		q.Add(actx, tessera.NewEntry(fmt.Appendf(nil, "item %d", i)))
+		span.End()
+		want = append(want, span.SpanContext())
+	}
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	s := <-flushed
+	if s == nil {
+		t.Fatal("flush wasn't traced with the provider from the queue's context")
+	}
+	var got []trace.SpanContext
+	for _, l := range s.Links() {
+		got = append(got, l.SpanContext)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flush span links to %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// WithTimeout returns a copy of ctx which is cancelled after d, or ctx itself if d is not positive.
+func WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// FlushWithTimeout returns a FlushFunc which calls f with a context which is cancelled after d, or f
+// itself if d is not positive.
+func FlushWithTimeout(d time.Duration, f FlushFunc) FlushFunc {
+	if d <= 0 {
+		return f
+	}
+	return func(ctx context.Context, entries []*tessera.Entry) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return f(ctx, entries)
+	}
+}
+
+// MinTimeout returns the smallest positive timeout of those provided, or zero if there are none.
+//
+// This is intended for use by drivers which perform several classes of operation as a single unit of
+// work, e.g. sequencing and integration, and so must honour the tightest of their timeouts.
+func MinTimeout(ds ...time.Duration) time.Duration {
+	var r time.Duration
+	for _, d := range ds {
+		if d > 0 && (r == 0 || d < r) {
+			r = d
+		}
+	}
+	return r
+}
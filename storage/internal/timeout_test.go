@@ -0,0 +1,64 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+func TestFlushWithTimeout(t *testing.T) {
+	// wedged mimics a backend call which never returns unless its context is done.
+	wedged := func(ctx context.Context, _ []*tessera.Entry) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	f := FlushWithTimeout(10*time.Millisecond, wedged)
+	if err := f(t.Context(), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("flush: got err %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	var hasDeadline bool
+	f = FlushWithTimeout(0, func(ctx context.Context, _ []*tessera.Entry) error {
+		_, hasDeadline = ctx.Deadline()
+		return nil
+	})
+	if err := f(t.Context(), nil); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if hasDeadline {
+		t.Error("flush with zero timeout has a deadline")
+	}
+}
+
+func TestMinTimeout(t *testing.T) {
+	for _, test := range []struct {
+		ds   []time.Duration
+		want time.Duration
+	}{
+		{ds: nil, want: 0},
+		{ds: []time.Duration{0, 0}, want: 0},
+		{ds: []time.Duration{0, time.Second}, want: time.Second},
+		{ds: []time.Duration{time.Minute, time.Second}, want: time.Second},
+	} {
+		if got := MinTimeout(test.ds...); got != test.want {
+			t.Errorf("MinTimeout(%v) = %v, want %v", test.ds, got, test.want)
+		}
+	}
+}
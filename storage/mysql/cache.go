@@ -0,0 +1,61 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cachedValue holds the most recently read copy of a frequently read, single-row value, such as the
+// latest checkpoint, so that it can be served from memory for up to maxAge.
+//
+// The zero value is ready to use, and with a maxAge of zero it simply passes reads through.
+type cachedValue[T any] struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	v       T
+	expires time.Time
+}
+
+// get returns the cached value if it is fresh enough, or otherwise calls fetch to read it again.
+//
+// Callers which arrive while a fetch is in progress wait for it rather than issuing their own, so
+// the database sees at most one read per maxAge however many callers there are. Errors aren't cached.
+func (c *cachedValue[T]) get(ctx context.Context, fetch func(context.Context) (T, error)) (T, error) {
+	if c.maxAge <= 0 {
+		return fetch(ctx)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expires) {
+		return c.v, nil
+	}
+	v, err := fetch(ctx)
+	if err != nil {
+		return v, err
+	}
+	c.v, c.expires = v, time.Now().Add(c.maxAge)
+	return v, nil
+}
+
+// invalidate causes the next call to get to re-read the value.
+func (c *cachedValue[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires = time.Time{}
+}
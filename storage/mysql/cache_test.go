@@ -0,0 +1,111 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachedValue(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc      string
+		maxAge    time.Duration
+		wantReads int
+	}{
+		{desc: "disabled", maxAge: 0, wantReads: 10},
+		{desc: "enabled", maxAge: time.Hour, wantReads: 1},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			c := cachedValue[uint64]{maxAge: test.maxAge}
+			reads := 0
+			fetch := func(context.Context) (uint64, error) {
+				reads++
+				return uint64(reads), nil
+			}
+			for range 10 {
+				if _, err := c.get(ctx, fetch); err != nil {
+					t.Fatalf("get: %v", err)
+				}
+			}
+			if reads != test.wantReads {
+				t.Errorf("got %d reads, want %d", reads, test.wantReads)
+			}
+		})
+	}
+}
+
+func TestCachedValueInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := cachedValue[uint64]{maxAge: time.Hour}
+	size := uint64(1)
+	fetch := func(context.Context) (uint64, error) { return size, nil }
+
+	if got, err := c.get(ctx, fetch); err != nil || got != 1 {
+		t.Fatalf("get = %d, %v, want 1", got, err)
+	}
+	size = 2
+	if got, _ := c.get(ctx, fetch); got != 1 {
+		t.Errorf("get before invalidate = %d, want cached 1", got)
+	}
+	c.invalidate()
+	if got, _ := c.get(ctx, fetch); got != 2 {
+		t.Errorf("get after invalidate = %d, want 2", got)
+	}
+}
+
+func TestCachedValueErrorsNotCached(t *testing.T) {
+	ctx := context.Background()
+	c := cachedValue[uint64]{maxAge: time.Hour}
+	errBoom := errors.New("boom")
+	if _, err := c.get(ctx, func(context.Context) (uint64, error) { return 0, errBoom }); !errors.Is(err, errBoom) {
+		t.Fatalf("get = %v, want %v", err, errBoom)
+	}
+	if got, err := c.get(ctx, func(context.Context) (uint64, error) { return 5, nil }); err != nil || got != 5 {
+		t.Errorf("get after error = %d, %v, want 5", got, err)
+	}
+}
+
+func TestCachedValueCoalesces(t *testing.T) {
+	ctx := context.Background()
+	c := cachedValue[uint64]{maxAge: time.Hour}
+	var mu sync.Mutex
+	reads := 0
+	fetch := func(context.Context) (uint64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reads++
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	}
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.get(ctx, fetch); err != nil {
+				t.Errorf("get: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if reads != 1 {
+		t.Errorf("got %d reads from concurrent callers, want 1", reads)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mysql contains a MySQL-based election.Lease implementation for Tessera.
+//
+// This is suitable for use with both the MySQL storage driver, and the AWS storage driver whose
+// coordination database is an Aurora MySQL instance.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	createLeaseTableSQL = "CREATE TABLE IF NOT EXISTS `Lease` (`name` VARCHAR(255) NOT NULL, `holder` VARCHAR(255) NOT NULL, `expiry` BIGINT NOT NULL, PRIMARY KEY(`name`))"
+	selectLeaseSQL      = "SELECT `holder`, `expiry` FROM `Lease` WHERE `name` = ? FOR UPDATE"
+	replaceLeaseSQL     = "REPLACE INTO `Lease` (`name`, `holder`, `expiry`) VALUES (?, ?, ?)"
+	deleteLeaseSQL      = "DELETE FROM `Lease` WHERE `name` = ? AND `holder` = ?"
+)
+
+// Lease is an election.Lease stored in a MySQL database.
+type Lease struct {
+	name string
+	db   *sql.DB
+}
+
+// NewLease returns a Lease with the given name, stored in the provided database.
+//
+// Several independent leases may be stored in the same database by using different names.
+func NewLease(ctx context.Context, db *sql.DB, name string) (*Lease, error) {
+	if _, err := db.ExecContext(ctx, createLeaseTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create Lease table: %v", err)
+	}
+	return &Lease{
+		name: name,
+		db:   db,
+	}, nil
+}
+
+// TryAcquire implements election.Lease.
+func (l *Lease) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			klog.Warningf("Rollback: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	var curHolder string
+	var expiry int64
+	switch err := tx.QueryRowContext(ctx, selectLeaseSQL, l.name).Scan(&curHolder, &expiry); {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, fmt.Errorf("failed to read lease: %v", err)
+	case curHolder != holder && now.Before(time.UnixMicro(expiry)):
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, replaceLeaseSQL, l.name, holder, now.Add(ttl).UnixMicro()); err != nil {
+		return false, fmt.Errorf("failed to update lease: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit lease: %v", err)
+	}
+	return true, nil
+}
+
+// Release implements election.Lease.
+func (l *Lease) Release(ctx context.Context, holder string) error {
+	if _, err := l.db.ExecContext(ctx, deleteLeaseSQL, l.name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The tests in this file require a MySQL database, and will be skipped if one is not available.
+package mysql
+
+import (
+	"database/sql"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/transparency-dev/tessera/election"
+	"k8s.io/klog/v2"
+)
+
+var (
+	mySQLURI            = flag.String("mysql_uri", "root:root@tcp(localhost:3306)/test_tessera", "Connection string for a MySQL database")
+	isMySQLTestOptional = flag.Bool("is_mysql_test_optional", true, "Boolean value to control whether the MySQL test is optional")
+)
+
+var _ election.Lease = &Lease{}
+
+// TestMain inits flags and runs tests.
+func TestMain(m *testing.M) {
+	klog.InitFlags(nil)
+	// m.Run() will parse flags
+	os.Exit(m.Run())
+}
+
+func TestLease(t *testing.T) {
+	ctx := t.Context()
+	db, err := sql.Open("mysql", *mySQLURI)
+	if err == nil {
+		defer func() {
+			if err := db.Close(); err != nil {
+				t.Errorf("failed to close MySQL database: %v", err)
+			}
+		}()
+		err = db.PingContext(ctx)
+	}
+	if err != nil {
+		if *isMySQLTestOptional {
+			t.Skip("MySQL not available, skipping test")
+		}
+		t.Fatalf("failed to open MySQL test db: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS `Lease`"); err != nil {
+		t.Fatalf("failed to drop Lease table: %v", err)
+	}
+
+	l, err := NewLease(ctx, db, "log")
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	for _, step := range []struct {
+		desc    string
+		holder  string
+		ttl     time.Duration
+		release bool
+		want    bool
+	}{
+		{desc: "acquire unheld", holder: "a", ttl: time.Hour, want: true},
+		{desc: "renew", holder: "a", ttl: time.Hour, want: true},
+		{desc: "contend", holder: "b", ttl: time.Hour, want: false},
+		{desc: "release by non-holder is ignored", holder: "b", release: true},
+		{desc: "still held", holder: "b", ttl: time.Hour, want: false},
+		{desc: "release", holder: "a", release: true},
+		{desc: "acquire released", holder: "b", ttl: -time.Second, want: true},
+		{desc: "acquire expired", holder: "a", ttl: time.Hour, want: true},
+	} {
+		if step.release {
+			if err := l.Release(ctx, step.holder); err != nil {
+				t.Fatalf("%s: Release: %v", step.desc, err)
+			}
+			continue
+		}
+		got, err := l.TryAcquire(ctx, step.holder, step.ttl)
+		if err != nil {
+			t.Fatalf("%s: TryAcquire: %v", step.desc, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: TryAcquire(%q) = %t, want %t", step.desc, step.holder, got, step.want)
+		}
+	}
+}
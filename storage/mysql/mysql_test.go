@@ -36,6 +36,8 @@ import (
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bench"
+	"github.com/transparency-dev/tessera/storage/storagetest"
 	"golang.org/x/mod/sumdb/note"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/klog/v2"
@@ -101,7 +103,7 @@ func TestMain(m *testing.M) {
 // `multiStatements=true` in the data source name allows multiple statements in one query.
 // This is not being used in the actual MySQL storage implementation.
 func initDatabaseSchema(ctx context.Context) {
-	dropTablesSQL := "DROP TABLE IF EXISTS `Checkpoint`, `Subtree`, `TiledLeaves`, `TreeState`"
+	dropTablesSQL := "DROP TABLE IF EXISTS `Checkpoint`, `Subtree`, `TiledLeaves`, `TreeState`, `LogIdentity`"
 
 	rawSchema, err := os.ReadFile("schema.sql")
 	if err != nil {
@@ -127,6 +129,33 @@ func initDatabaseSchema(ctx context.Context) {
 	}
 }
 
+func TestAppenderReadTimeoutsAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	initDatabaseSchema(ctx)
+	s, err := New(ctx, testDB)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, fast, err := s.Appender(ctx, tessera.NewAppendOptions().WithReadOnly().WithOperationTimeout(tessera.OperationRead, time.Nanosecond))
+	if err != nil {
+		t.Fatalf("Appender: %v", err)
+	}
+	_, slow, err := s.Appender(ctx, tessera.NewAppendOptions().WithReadOnly())
+	if err != nil {
+		t.Fatalf("Appender: %v", err)
+	}
+	if _, err := fast.ReadCheckpoint(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadCheckpoint with a 1ns timeout: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	// Creating the second appender mustn't have changed the first's timeout, or vice versa.
+	if _, err := slow.ReadCheckpoint(ctx); err != nil && !errors.Is(err, tessera.ErrNotFound) {
+		t.Errorf("ReadCheckpoint without a timeout: %v", err)
+	}
+	if _, err := fast.ReadCheckpoint(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadCheckpoint with a 1ns timeout after creating another appender: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
 func TestAppend(t *testing.T) {
 	ctx := context.Background()
 
@@ -149,7 +178,7 @@ func TestAppend(t *testing.T) {
 			opts: tessera.NewAppendOptions().
 				WithCheckpointSigner(noteSigner).
 				WithBatching(1, 1*time.Second).
-				WithPushback(10),
+				WithPushbackMaxOutstanding(10),
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -166,6 +195,24 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+func TestStorageContract(t *testing.T) {
+	storagetest.RunAppenderTests(t, func(t *testing.T) tessera.Driver {
+		initDatabaseSchema(t.Context())
+		s, err := New(t.Context(), testDB)
+		if err != nil {
+			t.Fatalf("Failed to create mysql.Storage: %v", err)
+		}
+		return s
+	})
+}
+
+func TestChaos(t *testing.T) {
+	initDatabaseSchema(t.Context())
+	storagetest.RunChaosTests(t, func(ctx context.Context) (tessera.Driver, error) {
+		return New(ctx, testDB)
+	})
+}
+
 func TestGetTile(t *testing.T) {
 	ctx := context.Background()
 	addFn, r, _ := newTestMySQLStorage(t, ctx)
@@ -459,6 +506,47 @@ func TestEntryBundleRoundTrip(t *testing.T) {
 	}
 }
 
+func TestReadEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	addFn, r, s := newTestMySQLStorage(t, ctx)
+
+	const numEntries = layout.EntryBundleWidth + 10
+	eG := errgroup.Group{}
+	for i := range numEntries {
+		eG.Go(func() error {
+			_, err := addFn(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))()
+			return err
+		})
+	}
+	if err := eG.Wait(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	addrs := []tessera.BundleAddr{{Index: 1, Partial: 10}, {Index: 0}, {Index: 1, Partial: 10}}
+	got, err := s.ReadEntryBundles(ctx, addrs)
+	if err != nil {
+		t.Fatalf("ReadEntryBundles: %v", err)
+	}
+	for i, a := range addrs {
+		want, err := r.ReadEntryBundle(ctx, a.Index, a.Partial)
+		if err != nil {
+			t.Fatalf("ReadEntryBundle(%d, %d): %v", a.Index, a.Partial, err)
+		}
+		if !bytes.Equal(got[i], want) {
+			t.Errorf("ReadEntryBundles()[%d] != ReadEntryBundle(%d, %d)", i, a.Index, a.Partial)
+		}
+	}
+
+	for _, missing := range [][]tessera.BundleAddr{
+		{{Index: 0}, {Index: 2}},
+		{{Index: 1}},
+	} {
+		if _, err := s.ReadEntryBundles(ctx, missing); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("ReadEntryBundles(%v): got err %v, want %v", missing, err, os.ErrNotExist)
+		}
+	}
+}
+
 func TestStreamEntries(t *testing.T) {
 	ctx := context.Background()
 	_, _, s := newTestMySQLStorage(t, ctx)
@@ -581,11 +669,11 @@ func populateEntries(t *testing.T, size uint64, s *Storage) {
 			rem--
 		}
 		t.Logf("Write %d.%d", bundleIdx, bs)
-		if err := s.writeEntryBundle(context.Background(), tx, uint64(bundleIdx), uint32(bs), b); err != nil {
+		if err := s.store.WriteEntryBundle(context.Background(), tx, uint64(bundleIdx), uint32(bs), b); err != nil {
 			t.Fatalf("writeEntryBundle(@%d.%d): %v", bundleIdx, bs, err)
 		}
 	}
-	if err := s.writeTreeState(context.Background(), tx, size, []byte("root")); err != nil {
+	if err := s.store.WriteTreeState(context.Background(), tx, size, []byte("root")); err != nil {
 		t.Fatalf("writeTreeState: %v", err)
 	}
 }
@@ -608,3 +696,91 @@ func newTestMySQLStorage(t *testing.T, ctx context.Context) (tessera.AddFn, tess
 	}
 	return a.Add, r, s
 }
+
+func BenchmarkMySQL(b *testing.B) {
+	if testDB == nil {
+		b.Skip("MySQL not available")
+	}
+	for _, entrySize := range []int{64, 1024, 16 * 1024} {
+		for _, concurrency := range []int{1, 16, 256} {
+			b.Run(fmt.Sprintf("size=%d/concurrency=%d", entrySize, concurrency), func(b *testing.B) {
+				ctx := b.Context()
+				initDatabaseSchema(ctx)
+				s, err := New(ctx, testDB)
+				if err != nil {
+					b.Fatalf("Failed to create mysql.Storage: %v", err)
+				}
+				opts := bench.DefaultOptions()
+				opts.EntrySize = entrySize
+				opts.Concurrency = concurrency
+				opts.NumEntries = b.N
+				opts.BatchMaxAge = 10 * time.Millisecond
+				b.ResetTimer()
+				r, err := bench.Run(ctx, s, opts)
+				if err != nil {
+					b.Fatalf("Run: %v", err)
+				}
+				bench.Report(b, r)
+			})
+		}
+	}
+}
+
+// slowSigner is a note.Signer which takes a long time to sign, like a remote KMS or HSM might.
+type slowSigner struct {
+	note.Signer
+	delay time.Duration
+}
+
+func (s slowSigner) Sign(msg []byte) ([]byte, error) {
+	time.Sleep(s.delay)
+	return s.Signer.Sign(msg)
+}
+
+func TestSlowSignerDoesNotBlockIntegration(t *testing.T) {
+	ctx := context.Background()
+	initDatabaseSchema(ctx)
+
+	s, err := New(ctx, testDB)
+	if err != nil {
+		t.Fatalf("Failed to create mysql.Storage: %v", err)
+	}
+	const signDelay = 3 * time.Second
+	a, shutdown, r, err := tessera.NewAppender(ctx, s, tessera.NewAppendOptions().
+		WithCheckpointSigner(slowSigner{Signer: noteSigner, delay: signDelay}).
+		WithCheckpointInterval(time.Second).
+		WithBatching(128, 100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sctx, cancel := context.WithTimeout(ctx, 3*signDelay)
+		defer cancel()
+		if err := shutdown(sctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	// The appender publishes a checkpoint on startup, so a signing operation is now in progress.
+	start := time.Now()
+	eG := errgroup.Group{}
+	for i := range 10 {
+		eG.Go(func() error {
+			_, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))()
+			return err
+		})
+	}
+	if err := eG.Wait(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	size, err := r.IntegratedSize(ctx)
+	if err != nil {
+		t.Fatalf("IntegratedSize: %v", err)
+	}
+	if size < 10 {
+		t.Errorf("Got integrated size %d, want at least 10", size)
+	}
+	if d := time.Since(start); d >= signDelay {
+		t.Errorf("Integration took %v, which suggests it was blocked by checkpoint signing", d)
+	}
+}
@@ -71,3 +71,15 @@ CREATE TABLE IF NOT EXISTS `TiledLeaves` (
   `data`       LONGBLOB NOT NULL,
   PRIMARY KEY(`tile_index`)
 );
+
+-- "LogIdentity" table stores a single row that records the checkpoint origin and signing keys the log was first
+-- configured with. This is checked at startup to prevent a misconfigured personality from signing checkpoints for
+-- the log with a different key or origin.
+-- This table is also created by the storage implementation at startup if it doesn't exist.
+CREATE TABLE IF NOT EXISTS `LogIdentity` (
+  -- id is expected to be always 0 to maintain a maximum of a single row.
+  `id`       TINYINT UNSIGNED NOT NULL,
+  -- identity is the serialised origin and signing key IDs.
+  `identity` BLOB NOT NULL,
+  PRIMARY KEY(`id`)
+);
@@ -150,6 +150,20 @@ func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error)
 	return idx, err
 }
 
+// LookupIndex returns the index of the earliest entry with the provided identity hash seen by the follower.
+//
+// This implements tessera.IndexLookup.
+func (d *AntispamStorage) LookupIndex(ctx context.Context, identityHash []byte) (uint64, error) {
+	idx, err := d.index(ctx, identityHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up identity hash: %v", err)
+	}
+	if idx == nil {
+		return 0, fmt.Errorf("identity hash %x: %w", identityHash, tessera.ErrNotFound)
+	}
+	return *idx, nil
+}
+
 // Decorator returns a function which will wrap an underlying Add delegate with
 // code to dedup against the stored data.
 func (d *AntispamStorage) Decorator() func(f tessera.AddFn) tessera.AddFn {
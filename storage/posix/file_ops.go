@@ -15,6 +15,8 @@
 package posix
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/rand/v2"
@@ -73,7 +75,8 @@ func mkdirAll(name string, perm os.FileMode) (err error) {
 	case errors.Is(err, os.ErrNotExist):
 		// We'll see ErrNotExist if the final entry in the requested path doesn't exist,
 		// so we simply attempt to create it in here.
-		if err := os.Mkdir(name, perm); err != nil {
+		// Concurrent writers may race to create the same directory, which is fine.
+		if err := os.Mkdir(name, perm); err != nil && !errors.Is(err, os.ErrExist) {
 			return fmt.Errorf("%q: %v", name, err)
 		}
 		// And be sure to sync the parent directory.
@@ -136,6 +139,47 @@ func overwrite(name string, d []byte) error {
 	return syncDir(dir)
 }
 
+// linkObject atomically creates or replaces the file at name with a hard link to a content-addressed
+// copy of the provided data stored under objDir, and syncs the directory containing name.
+//
+// If an object with the same content already exists it is reused, so identical data written to
+// multiple locations occupies disk space only once.
+func linkObject(objDir, name string, d []byte) error {
+	h := sha256.Sum256(d)
+	hx := hex.EncodeToString(h[:])
+	obj := filepath.Join(objDir, hx[:2], hx)
+	if err := createEx(obj, d); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("failed to create object %q: %w", obj, err)
+	}
+
+	dir, _ := filepath.Split(name)
+	if err := mkdirAll(dir, dirPerm); err != nil {
+		return fmt.Errorf("failed to make entries directory structure: %w", err)
+	}
+
+	// As with overwrite, we link to a temporary name and then rename it over the target so that
+	// readers never see a missing or incomplete file.
+	tmpName := name + ".obj" + strconv.Itoa(int(rand.Int32()))
+	if err := os.Link(obj, tmpName); err != nil {
+		if errors.Is(err, syscall.EMLINK) {
+			// The object has hit the filesystem's link limit, so just store a private copy.
+			return overwrite(name, d)
+		}
+		return fmt.Errorf("failed to link object to temporary file %q: %w", tmpName, err)
+	}
+	if err := os.Rename(tmpName, name); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to rename temporary file to target %q: %w", name, err)
+	}
+	// rename(2) is a no-op which leaves tmpName in place when the target is already a link to the
+	// same object, so make sure it's gone.
+	if err := os.Remove(tmpName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		klog.Warningf("Failed to remove temporary file %q: %v", tmpName, err)
+	}
+
+	return syncDir(dir)
+}
+
 // createTemp creates a new temporary file in the directory dir, with a name based on the provided prefix,
 // and writes the provided data to it.
 //
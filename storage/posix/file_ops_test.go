@@ -0,0 +1,83 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkObject(t *testing.T) {
+	root := t.TempDir()
+	objDir := filepath.Join(root, objectsDir)
+	a := filepath.Join(root, "tile", "0", "000.p", "5")
+	b := filepath.Join(root, "mirror", "0", "000.p", "5")
+	c := filepath.Join(root, "tile", "0", "001")
+
+	for _, w := range []struct {
+		name string
+		data string
+	}{
+		{name: a, data: "hello"},
+		{name: b, data: "hello"},
+		{name: c, data: "world"},
+		// Re-writing identical content over an existing link must be harmless.
+		{name: a, data: "hello"},
+	} {
+		if err := linkObject(objDir, w.name, []byte(w.data)); err != nil {
+			t.Fatalf("linkObject(%q): %v", w.name, err)
+		}
+		got, err := os.ReadFile(w.name)
+		if err != nil {
+			t.Fatalf("ReadFile(%q): %v", w.name, err)
+		}
+		if string(got) != w.data {
+			t.Errorf("%q contains %q, want %q", w.name, got, w.data)
+		}
+	}
+
+	stat := func(p string) os.FileInfo {
+		t.Helper()
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Stat(%q): %v", p, err)
+		}
+		return fi
+	}
+	if !os.SameFile(stat(a), stat(b)) {
+		t.Errorf("identical resources %q and %q are not the same file", a, b)
+	}
+	if os.SameFile(stat(a), stat(c)) {
+		t.Errorf("different resources %q and %q are the same file", a, c)
+	}
+
+	// Overwriting a resource with new content must not affect others which shared its old object.
+	if err := linkObject(objDir, a, []byte("hello world")); err != nil {
+		t.Fatalf("linkObject(%q): %v", a, err)
+	}
+	if got, err := os.ReadFile(b); err != nil || string(got) != "hello" {
+		t.Errorf("%q = %q, %v; want %q", b, got, err, "hello")
+	}
+
+	// No temporary files should be left lying around.
+	leftovers, err := filepath.Glob(filepath.Join(root, "tile", "0", "000.p", "*.obj*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Errorf("found leftover temporary files: %v", leftovers)
+	}
+}
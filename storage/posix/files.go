@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -33,8 +32,10 @@ import (
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/otel"
 	"github.com/transparency-dev/tessera/internal/stream"
 	storage "github.com/transparency-dev/tessera/storage/internal"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/klog/v2"
 )
 
@@ -47,6 +48,8 @@ const (
 	compatibilityVersion = 1
 
 	stateDir = ".state"
+	// objectsDir holds the content-addressed copies of resources when hard-link dedup is enabled.
+	objectsDir = ".objects"
 
 	minCheckpointInterval = time.Second
 )
@@ -56,6 +59,10 @@ const (
 type Storage struct {
 	mu   sync.Mutex
 	path string
+	// driverOpts holds settings common to all storage drivers.
+	driverOpts tessera.DriverOptions
+	// dedup causes tiles and entry bundles to be written as hard links to content-addressed files.
+	dedup bool
 }
 
 // appender implements the Tessera append lifecycle.
@@ -66,6 +73,13 @@ type appender struct {
 
 	curSize uint64
 	newCP   func(context.Context, uint64, []byte) ([]byte, error) // May be nil for mirrored logs.
+	// notifyPublished passes each newly published checkpoint on to the Appender's subscribers.
+	notifyPublished func(tessera.PublishedCheckpoint)
+	// identity is the checkpoint origin and signing keys the appender is configured with.
+	identity tessera.LogIdentity
+
+	// integrationParallelism is the maximum number of concurrent writes performed while integrating entries.
+	integrationParallelism int
 
 	cpUpdated chan struct{}
 }
@@ -75,17 +89,66 @@ type appender struct {
 type logResourceStorage struct {
 	s           *Storage
 	entriesPath func(uint64, uint8) string
+	// metrics, which may be nil, records tile writes.
+	metrics *storage.Metrics
 }
 
 // NewTreeFunc is the signature of a function which receives information about newly integrated trees.
 type NewTreeFunc func(size uint64, root []byte) error
 
+// Option is a function which configures optional behaviour of the POSIX Storage.
+type Option func(*Storage)
+
+// WithDriverOptions applies settings which are common to all storage drivers.
+func WithDriverOptions(opts ...tessera.DriverOption) Option {
+	return func(s *Storage) {
+		s.driverOpts.Apply(opts...)
+	}
+}
+
+// WithHardLinkDedup causes tiles and entry bundles to be stored as hard links to content-addressed
+// files kept under the .objects directory in the log root.
+//
+// Resources with identical content, such as a partial tile which is re-written unchanged or the same
+// bundle mirrored into several locations, will then share a single copy on disk. This requires that
+// the filesystem holding the log supports hard links.
+func WithHardLinkDedup() Option {
+	return func(s *Storage) {
+		s.dedup = true
+	}
+}
+
 // New creates a new POSIX storage.
 // - path is a directory in which the log should be stored
-func New(ctx context.Context, path string) (tessera.Driver, error) {
-	return &Storage{
+func New(ctx context.Context, path string, opts ...Option) (tessera.Driver, error) {
+	if err := validatePath(path); err != nil {
+		return nil, err
+	}
+	s := &Storage{
 		path: path,
-	}, nil
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
+}
+
+// validatePath checks that path can be used as the root of a log, returning an error describing every problem found.
+//
+// The directory need not exist yet, as it will be created on first use.
+func validatePath(path string) error {
+	var errs []error
+	if path == "" {
+		errs = append(errs, errors.New("path must be set"))
+	} else if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+		errs = append(errs, fmt.Errorf("path %q exists but is not a directory", path))
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("path %q is not accessible: %v", path, err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid posix storage configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
 }
 
 func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
@@ -97,17 +160,31 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 		s:           s,
 		entriesPath: opts.EntriesPath(),
 	}
+	if opts.ReadOnly() {
+		// Don't create or lock anything under s.path, the log may be a forensic copy on read-only media.
+		return &tessera.Appender{}, logStorage, nil
+	}
 
+	logStorage.metrics = storage.NewMetrics(opts.MetricsProvider(), "posix")
 	a := &appender{
-		s:          s,
-		logStorage: logStorage,
-		cpUpdated:  make(chan struct{}),
-		newCP:      opts.CheckpointPublisher(logStorage, http.DefaultClient),
+		s:               s,
+		logStorage:      logStorage,
+		cpUpdated:       make(chan struct{}),
+		newCP:           opts.CheckpointPublisher(logStorage, s.driverOpts.HTTPClient()),
+		notifyPublished: opts.CheckpointNotifier(),
+		identity:        opts.LogIdentity(),
+
+		integrationParallelism: int(opts.IntegrationParallelism()),
 	}
 	if err := a.initialise(ctx); err != nil {
 		return nil, nil, err
 	}
-	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), a.sequenceBatch)
+	// Entries are integrated as part of sequencing, so both timeouts apply to the same operation.
+	// Reads from the local filesystem can't be cancelled, so the read timeout is not used.
+	seqTimeout := storage.MinTimeout(opts.OperationTimeout(tessera.OperationSequence), opts.OperationTimeout(tessera.OperationIntegrate))
+	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.FlushWithTimeout(seqTimeout, a.sequenceBatch),
+		storage.WithMaxPending(uint64(opts.QueuePushbackMaxOutstanding())), storage.WithPriority(opts.PriorityFunc()),
+		storage.WithMetrics(logStorage.metrics))
 
 	go func(ctx context.Context, i time.Duration) {
 		for {
@@ -124,7 +201,10 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 	}(ctx, opts.CheckpointInterval())
 
 	return &tessera.Appender{
-		Add: a.Add,
+		Add:      a.Add,
+		AddBatch: a.queue.AddBatch,
+		Queued:   a.queue.Pending,
+		Flush:    a.queue.Flush,
 	}, a.logStorage, nil
 }
 
@@ -179,19 +259,100 @@ func (a *appender) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFutur
 
 func (l *logResourceStorage) ReadCheckpoint(_ context.Context) ([]byte, error) {
 	r, err := os.ReadFile(filepath.Join(l.s.path, layout.CheckpointPath))
-	if errors.Is(err, fs.ErrNotExist) {
-		return r, os.ErrNotExist
+	return r, notFound(err)
+}
+
+func (l *logResourceStorage) ReadCheckpointModTime(_ context.Context) (time.Time, error) {
+	fi, err := os.Stat(filepath.Join(l.s.path, layout.CheckpointPath))
+	if err != nil {
+		return time.Time{}, notFound(err)
 	}
-	return r, err
+	return fi.ModTime(), nil
+}
+
+// TreeState returns the state of the log as of its latest checkpoint.
+//
+// The checkpoint's modification time is taken from the same open file as its contents, so they always match.
+func (l *logResourceStorage) TreeState(_ context.Context) (tessera.TreeState, error) {
+	f, err := os.Open(filepath.Join(l.s.path, layout.CheckpointPath))
+	if err != nil {
+		return tessera.TreeState{}, notFound(err)
+	}
+	defer func() { _ = f.Close() }()
+	fi, err := f.Stat()
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	cp, err := io.ReadAll(f)
+	if err != nil {
+		return tessera.TreeState{}, err
+	}
+	return tessera.NewTreeState(cp, fi.ModTime())
 }
 
 // ReadEntryBundle retrieves the Nth entries bundle for a log of the given size.
 func (l *logResourceStorage) ReadEntryBundle(_ context.Context, index uint64, p uint8) ([]byte, error) {
-	return os.ReadFile(filepath.Join(l.s.path, l.entriesPath(index, p)))
+	b, err := os.ReadFile(filepath.Join(l.s.path, l.entriesPath(index, p)))
+	return b, notFound(err)
 }
 
 func (l *logResourceStorage) ReadTile(_ context.Context, level, index uint64, p uint8) ([]byte, error) {
-	return os.ReadFile(filepath.Join(l.s.path, layout.TilePath(level, index, p)))
+	t, err := os.ReadFile(filepath.Join(l.s.path, layout.TilePath(level, index, p)))
+	return t, notFound(err)
+}
+
+// ReadEntryBundleStream returns a reader for the Nth entries bundle for a log of the given size.
+func (l *logResourceStorage) ReadEntryBundleStream(_ context.Context, index uint64, p uint8) (io.ReadCloser, error) {
+	return openFile(filepath.Join(l.s.path, l.entriesPath(index, p)))
+}
+
+// ReadEntryBundleRange returns a reader for part of the Nth entries bundle for a log of the given size, along
+// with the total size of the bundle.
+func (l *logResourceStorage) ReadEntryBundleRange(_ context.Context, index uint64, p uint8, offset, length int64) (io.ReadCloser, int64, error) {
+	f, err := openFile(filepath.Join(l.s.path, l.entriesPath(index, p)))
+	if err != nil {
+		return nil, 0, err
+	}
+	r, size, err := func() (io.Reader, int64, error) {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		start, n, err := tessera.ResolveRange(fi.Size(), offset, length)
+		if err != nil {
+			return nil, 0, err
+		}
+		return io.NewSectionReader(f, start, n), fi.Size(), nil
+	}()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, size, nil
+}
+
+func (l *logResourceStorage) ReadTileStream(_ context.Context, level, index uint64, p uint8) (io.ReadCloser, error) {
+	return openFile(filepath.Join(l.s.path, layout.TilePath(level, index, p)))
+}
+
+// openFile opens the named file for reading, returning a wrapped tessera.ErrNotFound if it does not exist.
+func openFile(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return f, nil
+}
+
+// notFound returns a wrapped tessera.ErrNotFound if err indicates that a file does not exist, or err otherwise.
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%v: %w", err, tessera.ErrNotFound)
+	}
+	return err
 }
 
 func (l *logResourceStorage) IntegratedSize(_ context.Context) (uint64, error) {
@@ -219,6 +380,9 @@ func (l *logResourceStorage) StreamEntries(ctx context.Context, fromEntry uint64
 // We try to minimise the number of partially complete entry bundles by writing entries in chunks rather
 // than one-by-one.
 func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.posix.sequenceBatch")
+	defer span.End()
+
 	// Double locking:
 	// - The mutex `Lock()` ensures that multiple concurrent calls to this function within a task are serialised.
 	// - The POSIX `lockFile()` ensures that distinct tasks are serialised.
@@ -243,69 +407,80 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 	}
 	a.curSize = size
 	klog.V(1).Infof("Sequencing from %d", a.curSize)
+	span.SetAttributes(fromSizeKey.Int64(otel.Clamp64(size)), numEntriesKey.Int(len(entries)))
 
 	if len(entries) == 0 {
 		return nil
 	}
-	currTile := &bytes.Buffer{}
 	seq := a.curSize
-	bundleIndex, entriesInBundle := seq/layout.EntryBundleWidth, seq%layout.EntryBundleWidth
-	if entriesInBundle > 0 {
+	var part []byte
+	if p := uint8(seq % layout.EntryBundleWidth); p > 0 {
 		// If the latest bundle is partial, we need to read the data it contains in for our newer, larger, bundle.
-		part, err := a.logStorage.ReadEntryBundle(ctx, bundleIndex, uint8(a.curSize%layout.EntryBundleWidth))
-		if err != nil {
+		var err error
+		if part, err = a.logStorage.ReadEntryBundle(ctx, seq/layout.EntryBundleWidth, p); err != nil {
 			return err
 		}
-		if _, err := currTile.Write(part); err != nil {
-			return fmt.Errorf("failed to write partial bundle into buffer: %v", err)
-		}
 	}
-	writeBundle := func(bundleIndex uint64, partialSize uint8) error {
-		return a.logStorage.writeBundle(ctx, bundleIndex, partialSize, currTile.Bytes())
+	// Bundle buffers are pooled to reduce allocations, and are returned to the pool once written.
+	currTile := storage.GetBuffer()
+	bundle, err := api.NewEntryBundleBuilder(seq, part, currTile)
+	if err != nil {
+		storage.PutBuffer(currTile)
+		return err
+	}
+	// Entry bundles and tiles are written out with bounded parallelism as soon as they're ready.
+	// All writes must be complete before the new tree state is persisted below.
+	writes, wCtx := errgroup.WithContext(ctx)
+	if a.integrationParallelism > 0 {
+		writes.SetLimit(a.integrationParallelism)
+	}
+	writeBundle := func(bundleIndex uint64, partialSize uint8) {
+		b := currTile
+		writes.Go(func() error {
+			defer storage.PutBuffer(b)
+			return a.logStorage.writeBundle(wCtx, bundleIndex, partialSize, b.Bytes())
+		})
 	}
 
 	leafHashes := make([][]byte, 0, len(entries))
 	// Add new entries to the bundle
 	for i, e := range entries {
-		bundleData := e.MarshalBundleData(seq + uint64(i))
-		if _, err := currTile.Write(bundleData); err != nil {
-			return fmt.Errorf("failed to write entry %d to currTile: %v", i, err)
+		if err := bundle.AppendSerialised(e.MarshalBundleData(seq + uint64(i))); err != nil {
+			return fmt.Errorf("failed to add entry %d to bundle: %v", i, err)
 		}
 		leafHashes = append(leafHashes, e.LeafHash())
 
-		entriesInBundle++
-		if entriesInBundle == layout.EntryBundleWidth {
+		if bundle.Full() {
 			//  This bundle is full, so we need to write it out...
 			// ... and prepare the next entry bundle for any remaining entries in the batch
-			if err := writeBundle(bundleIndex, 0); err != nil {
+			writeBundle(bundle.Index(), 0)
+			currTile = storage.GetBuffer()
+			if err := bundle.Next(currTile); err != nil {
 				return err
 			}
-			bundleIndex++
-			entriesInBundle = 0
-			currTile = &bytes.Buffer{}
 		}
 	}
 	// If we have a partial bundle remaining once we've added all the entries from the batch,
 	// this needs writing out too.
-	if entriesInBundle > 0 {
-		// This check should be redundant since this is [currently] checked above, but an overflow around the uint8 below could
-		// potentially be bad news if that check was broken/defeated as we'd be writing invalid bundle data, so do a belt-and-braces
-		// check and bail if need be.
-		if entriesInBundle > layout.EntryBundleWidth {
-			return fmt.Errorf("logic error: entriesInBundle(%d) > max bundle size %d", entriesInBundle, layout.EntryBundleWidth)
-		}
-		if err := writeBundle(bundleIndex, uint8(entriesInBundle)); err != nil {
-			return err
-		}
+	if p, err := bundle.Partial(); err == nil {
+		writeBundle(bundle.Index(), p)
+	} else {
+		storage.PutBuffer(currTile)
 	}
 
 	// For simplicity, in-line the integration of these new entries into the Merkle structure too.
 	// If this is broken out into an async process, we'll need to update the implementation of NextIndex, too.
-	newSize, newRoot, err := doIntegrate(ctx, seq, leafHashes, a.logStorage)
+	newSize, newRoot, err := doIntegrate(wCtx, seq, leafHashes, a.logStorage, writes)
+	if wErr := writes.Wait(); err == nil {
+		err = wErr
+	}
 	if err != nil {
 		klog.Errorf("Integrate failed: %v", err)
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not writing new tree state: %v", err)
+	}
 	if err := a.s.writeTreeState(newSize, newRoot); err != nil {
 		return fmt.Errorf("failed to write new tree state: %v", err)
 	}
@@ -319,7 +494,9 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 }
 
 // doIntegrate handles integrating new leaf hashes into the log, and returns the new state.
-func doIntegrate(ctx context.Context, fromSeq uint64, leafHashes [][]byte, ls *logResourceStorage) (uint64, []byte, error) {
+// Writes of the updated tiles are scheduled on the provided errgroup, and callers must Wait on it
+// before persisting the new state.
+func doIntegrate(ctx context.Context, fromSeq uint64, leafHashes [][]byte, ls *logResourceStorage, writes *errgroup.Group) (uint64, []byte, error) {
 	getTiles := func(ctx context.Context, tileIDs []storage.TileID, treeSize uint64) ([]*api.HashTile, error) {
 		n, err := ls.readTiles(ctx, tileIDs, treeSize)
 		if err != nil {
@@ -334,9 +511,12 @@ func doIntegrate(ctx context.Context, fromSeq uint64, leafHashes [][]byte, ls *l
 		return 0, nil, fmt.Errorf("error in Integrate: %v", err)
 	}
 	for k, v := range tiles {
-		if err := ls.storeTile(ctx, uint64(k.Level), k.Index, newSize, v); err != nil {
-			return 0, nil, fmt.Errorf("failed to set tile(%v): %v", k, err)
-		}
+		writes.Go(func() error {
+			if err := ls.storeTile(ctx, uint64(k.Level), k.Index, newSize, v); err != nil {
+				return fmt.Errorf("failed to set tile(%v): %v", k, err)
+			}
+			return nil
+		})
 	}
 
 	klog.Infof("New tree: %d, %x", newSize, newRoot)
@@ -381,10 +561,9 @@ func (lrs *logResourceStorage) readTile(ctx context.Context, level, index uint64
 // index parameters, partially populated (i.e. right-hand edge) tiles are
 // stored with a .xx suffix where xx is the number of "tile leaves" in hex.
 func (lrs *logResourceStorage) storeTile(ctx context.Context, level, index, logSize uint64, tile *api.HashTile) error {
-	tileSize := uint64(len(tile.Nodes))
-	klog.V(2).Infof("StoreTile: level %d index %x ts: %x", level, index, tileSize)
-	if tileSize == 0 || tileSize > layout.TileWidth {
-		return fmt.Errorf("tileSize %d must be > 0 and <= %d", tileSize, layout.TileWidth)
+	klog.V(2).Infof("StoreTile: level %d index %x ts: %x", level, index, len(tile.Nodes))
+	if _, err := tile.Partial(); err != nil {
+		return fmt.Errorf("invalid tile: %v", err)
 	}
 	t, err := tile.MarshalText()
 	if err != nil {
@@ -394,12 +573,20 @@ func (lrs *logResourceStorage) storeTile(ctx context.Context, level, index, logS
 	return lrs.writeTile(ctx, level, index, layout.PartialTileSize(level, index, logSize), t)
 }
 
-func (lrs *logResourceStorage) writeTile(_ context.Context, level, index uint64, partial uint8, t []byte) error {
+func (lrs *logResourceStorage) writeTile(ctx context.Context, level, index uint64, partial uint8, t []byte) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.posix.writeTile")
+	defer span.End()
+	span.SetAttributes(levelKey.Int64(otel.Clamp64(level)), indexKey.Int64(otel.Clamp64(index)))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	tPath := layout.TilePath(level, index, partial)
 
-	if err := lrs.s.createOverwrite(tPath, t); err != nil {
+	if err := lrs.s.writeResource(tPath, t); err != nil {
 		return err
 	}
+	lrs.metrics.TileWritten(ctx, level)
 
 	if partial == 0 {
 		partials, err := filepath.Glob(fmt.Sprintf("%s.p/*", tPath))
@@ -427,9 +614,17 @@ func (lrs *logResourceStorage) writeTile(_ context.Context, level, index uint64,
 }
 
 // writeBundle takes care of writing out the serialised entry bundle file.
-func (lrs *logResourceStorage) writeBundle(_ context.Context, index uint64, partial uint8, bundle []byte) error {
+func (lrs *logResourceStorage) writeBundle(ctx context.Context, index uint64, partial uint8, bundle []byte) error {
+	_, span := tracer.Start(ctx, "tessera.storage.posix.writeBundle")
+	defer span.End()
+	span.SetAttributes(indexKey.Int64(otel.Clamp64(index)))
+
+	// Filesystem calls can't be interrupted, so honour any deadline before starting each one.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	bf := lrs.entriesPath(index, partial)
-	if err := lrs.s.createOverwrite(bf, bundle); err != nil {
+	if err := lrs.s.writeResource(bf, bundle); err != nil {
 		if !errors.Is(err, os.ErrExist) {
 			return err
 		}
@@ -462,6 +657,9 @@ func (a *appender) initialise(ctx context.Context) error {
 	if err := a.s.ensureVersion(compatibilityVersion); err != nil {
 		return err
 	}
+	if err := a.s.ensureIdentity(a.identity); err != nil {
+		return err
+	}
 	curSize, _, err := a.s.readTreeState()
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
@@ -519,6 +717,27 @@ func (s *Storage) ensureVersion(version uint16) error {
 	return nil
 }
 
+// ensureIdentity will fail if the log identity stored in the state directory doesn't match the provided one.
+// If no file exists, then it is created with the provided identity.
+func (s *Storage) ensureIdentity(id tessera.LogIdentity) error {
+	identityFile := filepath.Join(stateDir, "identity")
+
+	stored, err := s.readAll(identityFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read identity file: %v", err)
+	}
+	updated, err := id.Reconcile(stored)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(stored, updated) {
+		if err := s.createOverwrite(identityFile, updated); err != nil {
+			return fmt.Errorf("failed to write identity file: %v", err)
+		}
+	}
+	return nil
+}
+
 // writeTreeState stores the current tree size and root hash on disk.
 func (s *Storage) writeTreeState(size uint64, root []byte) error {
 	raw, err := json.Marshal(treeState{Size: size, Root: root})
@@ -550,6 +769,9 @@ func (s *Storage) readTreeState() (uint64, []byte, error) {
 // minStaleness old, and, if so, creates and published a fresh checkpoint from the current
 // stored tree state.
 func (a *appender) publishCheckpoint(ctx context.Context, minStaleness time.Duration) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.posix.publishCheckpoint")
+	defer span.End()
+
 	// Lock the destination "published" checkpoint location:
 	lockPath := "publish.lock"
 	unlock, err := a.s.lockFile(lockPath)
@@ -573,6 +795,7 @@ func (a *appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 			return nil
 		}
 	}
+	start := time.Now()
 	size, root, err := a.s.readTreeState()
 	if err != nil {
 		return fmt.Errorf("readTreeState: %v", err)
@@ -585,6 +808,8 @@ func (a *appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 	if err := a.s.createOverwrite(layout.CheckpointPath, cpRaw); err != nil {
 		return fmt.Errorf("createOverwrite(%s): %v", layout.CheckpointPath, err)
 	}
+	a.logStorage.metrics.CheckpointPublished(ctx, start)
+	a.notifyPublished(tessera.PublishedCheckpoint{Size: size, Root: root, Checkpoint: cpRaw})
 
 	klog.V(2).Infof("Published latest checkpoint: %d, %x", size, root)
 
@@ -604,6 +829,15 @@ func (s *Storage) createOverwrite(p string, d []byte) error {
 	return overwrite(filepath.Join(s.path, p), d)
 }
 
+// writeResource atomically creates or overwrites the tile or entry bundle at the given path, relative
+// to the root of the log, with the provided data.
+func (s *Storage) writeResource(p string, d []byte) error {
+	if s.dedup {
+		return linkObject(filepath.Join(s.path, objectsDir), filepath.Join(s.path, p), d)
+	}
+	return s.createOverwrite(p, d)
+}
+
 func (s *Storage) readAll(p string) ([]byte, error) {
 	p = filepath.Join(s.path, p)
 	return os.ReadFile(p)
@@ -750,7 +984,11 @@ func (m *MigrationStorage) buildTree(ctx context.Context, targetSize uint64) err
 		return fmt.Errorf("fetchLeafHashes(%d, %d): %v", size, targetSize, err)
 	}
 
-	newSize, newRoot, err := doIntegrate(ctx, size, lh, m.logStorage)
+	writes := &errgroup.Group{}
+	newSize, newRoot, err := doIntegrate(ctx, size, lh, m.logStorage, writes)
+	if wErr := writes.Wait(); err == nil {
+		err = wErr
+	}
 	if err != nil {
 		return fmt.Errorf("doIntegrate(%d, ...): %v", size, err)
 	}
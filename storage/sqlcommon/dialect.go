@@ -0,0 +1,199 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Dialect describes the ways in which a database's flavour of SQL differs from that of the others, as far
+// as the statements issued by Store are concerned.
+type Dialect interface {
+	// QuoteIdent returns name, a table or column name, quoted so that it's never mistaken for a keyword.
+	QuoteIdent(name string) string
+	// Placeholder returns the placeholder for the nth argument of a statement, counting from 1.
+	Placeholder(n int) string
+	// Upsert returns a statement which writes a row to table, replacing any existing row which has the same
+	// values in the keys columns. The statement's arguments are the values of cols, in order, which must
+	// include the keys columns.
+	Upsert(table string, keys, cols []string) string
+	// ForUpdate returns the clause appended to SELECT statements whose rows must be locked against
+	// concurrent writers until the end of the transaction.
+	ForUpdate() string
+	// IsRetryable returns true if err shows that a transaction failed because of contention with another,
+	// e.g. a deadlock or serialisation failure, and so may succeed if tried again.
+	IsRetryable(err error) bool
+	// TxAttempts returns the number of times Store should attempt a transaction, in total, while it keeps
+	// failing with errors for which IsRetryable returns true. Databases which resolve contention by waiting
+	// on locks rarely need more than one, but those which abort one of the contending transactions instead
+	// expect their clients to retry.
+	TxAttempts() int
+}
+
+// MySQL is the Dialect for MySQL, and compatible databases such as MariaDB.
+var MySQL Dialect = mysqlDialect{}
+
+// Postgres is the Dialect for PostgreSQL.
+var Postgres Dialect = postgresDialect{}
+
+// CockroachDB is the Dialect for CockroachDB.
+//
+// CockroachDB speaks the Postgres wire protocol, but runs every transaction at SERIALIZABLE isolation and
+// resolves conflicts between them by aborting one, so transactions which fail in this way are retried.
+var CockroachDB Dialect = cockroachDialect{}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) QuoteIdent(name string) string {
+	return "`" + name + "`"
+}
+
+func (mysqlDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (d mysqlDialect) Upsert(table string, _, cols []string) string {
+	// The primary key is all MySQL needs to know which row to replace.
+	return fmt.Sprintf("REPLACE INTO %s (%s) VALUES (%s)", d.QuoteIdent(table), quoteAll(d, cols), placeholders(d, 1, len(cols)))
+}
+
+func (mysqlDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+func (mysqlDialect) IsRetryable(err error) bool {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return false
+	}
+	// ER_LOCK_DEADLOCK and ER_LOCK_WAIT_TIMEOUT respectively.
+	return me.Number == 1213 || me.Number == 1205
+}
+
+func (mysqlDialect) TxAttempts() int {
+	return 1
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) QuoteIdent(name string) string {
+	return `"` + name + `"`
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d postgresDialect) Upsert(table string, keys, cols []string) string {
+	set := make([]string, 0, len(cols))
+	for _, c := range cols {
+		set = append(set, fmt.Sprintf("%s = EXCLUDED.%[1]s", d.QuoteIdent(c)))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		d.QuoteIdent(table), quoteAll(d, cols), placeholders(d, 1, len(cols)), quoteAll(d, keys), strings.Join(set, ", "))
+}
+
+func (postgresDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+func (postgresDialect) IsRetryable(err error) bool {
+	// Both of the common drivers, pgx and lib/pq, have errors which report their SQLSTATE code in this way,
+	// so there's no need to depend on either of them.
+	var se interface{ SQLState() string }
+	if !errors.As(err, &se) {
+		return false
+	}
+	// serialization_failure and deadlock_detected respectively.
+	return se.SQLState() == "40001" || se.SQLState() == "40P01"
+}
+
+func (postgresDialect) TxAttempts() int {
+	return 1
+}
+
+// cockroachDialect shares Postgres' quoting and placeholders, but has its own upsert and error codes.
+type cockroachDialect struct {
+	postgresDialect
+}
+
+func (d cockroachDialect) Upsert(table string, _, cols []string) string {
+	// UPSERT replaces the whole row with a blind write, which is cheaper than the read which ON CONFLICT needs.
+	return fmt.Sprintf("UPSERT INTO %s (%s) VALUES (%s)", d.QuoteIdent(table), quoteAll(d, cols), placeholders(d, 1, len(cols)))
+}
+
+func (cockroachDialect) IsRetryable(err error) bool {
+	var se interface{ SQLState() string }
+	if !errors.As(err, &se) {
+		return false
+	}
+	// CockroachDB reports all of its transaction retry errors, whatever their cause, as serialization_failure.
+	// It has no deadlocks to report. Note that statement_completion_unknown (40003), which is returned when a
+	// commit may or may not have been applied, is deliberately not retried: doing so could sequence a batch twice.
+	return se.SQLState() == "40001"
+}
+
+func (cockroachDialect) TxAttempts() int {
+	return 5
+}
+
+// quoteAll returns the quoted names, separated by commas.
+func quoteAll(d Dialect, names []string) string {
+	q := make([]string, 0, len(names))
+	for _, n := range names {
+		q = append(q, d.QuoteIdent(n))
+	}
+	return strings.Join(q, ", ")
+}
+
+// placeholders returns n placeholders, separated by commas, for the arguments from the first onwards.
+func placeholders(d Dialect, first, n int) string {
+	p := make([]string, 0, n)
+	for i := range n {
+		p = append(p, d.Placeholder(first+i))
+	}
+	return strings.Join(p, ", ")
+}
+
+// rebind translates a statement written with backtick quoted identifiers and ? placeholders into d.
+// It returns an error if an identifier's quotes are unbalanced.
+func rebind(d Dialect, q string) (string, error) {
+	var b strings.Builder
+	n := 0
+	for {
+		i := strings.IndexAny(q, "`?")
+		if i < 0 {
+			b.WriteString(q)
+			return b.String(), nil
+		}
+		b.WriteString(q[:i])
+		if q[i] == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			q = q[i+1:]
+			continue
+		}
+		end := strings.IndexByte(q[i+1:], '`')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated identifier in %q", q[i:])
+		}
+		b.WriteString(d.QuoteIdent(q[i+1 : i+1+end]))
+		q = q[i+end+2:]
+	}
+}
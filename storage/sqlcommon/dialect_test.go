@@ -0,0 +1,179 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRebind(t *testing.T) {
+	const q = "SELECT `size`, `data` FROM `TiledLeaves` WHERE `tile_index` >= ? AND `size` = ?"
+	for _, test := range []struct {
+		d    Dialect
+		want string
+	}{
+		{d: MySQL, want: q},
+		{d: Postgres, want: `SELECT "size", "data" FROM "TiledLeaves" WHERE "tile_index" >= $1 AND "size" = $2`},
+		{d: CockroachDB, want: `SELECT "size", "data" FROM "TiledLeaves" WHERE "tile_index" >= $1 AND "size" = $2`},
+	} {
+		got, err := rebind(test.d, q)
+		if err != nil {
+			t.Fatalf("rebind(%T): %v", test.d, err)
+		}
+		if got != test.want {
+			t.Errorf("rebind(%T): got %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestRebindUnterminatedIdentifier(t *testing.T) {
+	for _, d := range []Dialect{MySQL, Postgres, CockroachDB} {
+		if got, err := rebind(d, "SELECT `size FROM `TiledLeaves`"); err == nil {
+			t.Errorf("rebind(%T): got %q, want error", d, got)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	keys, cols := []string{"level", "index"}, []string{"level", "index", "nodes"}
+	for _, test := range []struct {
+		d    Dialect
+		want string
+	}{
+		{
+			d:    MySQL,
+			want: "REPLACE INTO `Subtree` (`level`, `index`, `nodes`) VALUES (?, ?, ?)",
+		}, {
+			d: Postgres,
+			want: `INSERT INTO "Subtree" ("level", "index", "nodes") VALUES ($1, $2, $3) ON CONFLICT ("level", "index") DO UPDATE SET ` +
+				`"level" = EXCLUDED."level", "index" = EXCLUDED."index", "nodes" = EXCLUDED."nodes"`,
+		}, {
+			d:    CockroachDB,
+			want: `UPSERT INTO "Subtree" ("level", "index", "nodes") VALUES ($1, $2, $3)`,
+		},
+	} {
+		if got := test.d.Upsert("Subtree", keys, cols); got != test.want {
+			t.Errorf("Upsert(%T): got %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+// sqlStateError mimics the errors returned by Postgres drivers.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "postgres error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsRetryable(t *testing.T) {
+	for _, test := range []struct {
+		d    Dialect
+		err  error
+		want bool
+	}{
+		{d: MySQL, err: &mysql.MySQLError{Number: 1213}, want: true},
+		{d: MySQL, err: fmt.Errorf("commit: %w", &mysql.MySQLError{Number: 1205}), want: true},
+		{d: MySQL, err: &mysql.MySQLError{Number: 1062}},
+		{d: MySQL, err: errors.New("boom")},
+		{d: Postgres, err: sqlStateError("40001"), want: true},
+		{d: Postgres, err: fmt.Errorf("commit: %w", sqlStateError("40P01")), want: true},
+		{d: Postgres, err: sqlStateError("23505")},
+		{d: Postgres, err: errors.New("boom")},
+		{d: CockroachDB, err: sqlStateError("40001"), want: true},
+		{d: CockroachDB, err: fmt.Errorf("integrate: %w", sqlStateError("40001")), want: true},
+		{d: CockroachDB, err: sqlStateError("40003")},
+		{d: CockroachDB, err: &mysql.MySQLError{Number: 1213}},
+	} {
+		if got := test.d.IsRetryable(test.err); got != test.want {
+			t.Errorf("%T.IsRetryable(%v): got %t, want %t", test.d, test.err, got, test.want)
+		}
+	}
+}
+
+func TestRetryTx(t *testing.T) {
+	retryable := fmt.Errorf("commit: %w", sqlStateError("40001"))
+	for _, test := range []struct {
+		name      string
+		d         Dialect
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", d: CockroachDB, errs: []error{nil}, wantCalls: 1},
+		{name: "retried", d: CockroachDB, errs: []error{retryable, retryable, nil}, wantCalls: 3},
+		{name: "not retryable", d: CockroachDB, errs: []error{sqlStateError("23505")}, wantCalls: 1, wantErr: sqlStateError("23505")},
+		{
+			name:      "attempts exhausted",
+			d:         CockroachDB,
+			errs:      []error{retryable, retryable, retryable, retryable, retryable, nil},
+			wantCalls: 5,
+			wantErr:   retryable,
+		},
+		{name: "single attempt", d: Postgres, errs: []error{retryable, nil}, wantCalls: 1, wantErr: retryable},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			err := retryTx(t.Context(), test.name, test.d, func() error {
+				err := test.errs[calls]
+				calls++
+				return err
+			})
+			if calls != test.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, test.wantCalls)
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got err %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryTxCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	calls := 0
+	err := retryTx(ctx, "cancelled", CockroachDB, func() error {
+		calls++
+		return sqlStateError("40001")
+	})
+	if calls != 1 || err == nil {
+		t.Errorf("got (%d calls, %v), want a single failed call", calls, err)
+	}
+}
+
+func TestNewStoreStatements(t *testing.T) {
+	// Any MySQL-isms left in the statements would be syntax errors in Postgres and CockroachDB.
+	for _, d := range []Dialect{Postgres, CockroachDB} {
+		s, err := NewStore(nil, d)
+		if err != nil {
+			t.Fatalf("NewStore(%T): %v", d, err)
+		}
+		for _, q := range []string{
+			s.selectCompatibilityVersion, s.selectCheckpoint, s.selectCheckpointForUpdate, s.upsertCheckpoint,
+			s.selectTreeState, s.selectTreeStateForUpdate, s.upsertTreeState, s.selectSubtree, s.upsertSubtree,
+			s.selectTiledLeaves, s.streamTiledLeaves, s.upsertTiledLeaves, s.selectLogIdentityForUpdate,
+			s.insertLogIdentity, s.updateLogIdentity,
+		} {
+			if strings.ContainsAny(q, "`?") {
+				t.Errorf("%T: statement %q has not been translated", d, q)
+			}
+		}
+	}
+}
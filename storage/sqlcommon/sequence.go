@@ -0,0 +1,467 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/otel"
+	"github.com/transparency-dev/tessera/internal/parse"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"k8s.io/klog/v2"
+)
+
+// InitTree stores the state of an empty tree iff no tree state is stored yet.
+//
+// This doesn't also publish a checkpoint for the empty tree; that's left to the mechanism which publishes all
+// of the others, which in this case would be expected to do so very shortly.
+func (s *Store) InitTree(ctx context.Context) error {
+	return s.inTx(ctx, "init tree state", func(tx *sql.Tx) error {
+		ts, err := s.ReadTreeStateForUpdate(ctx, tx)
+		if err != nil && !errors.Is(err, tessera.ErrNotFound) {
+			return fmt.Errorf("failed to read tree state: %v", err)
+		}
+		if ts != nil {
+			return nil
+		}
+		klog.Infof("Initializing tree state")
+		return s.WriteTreeState(ctx, tx, 0, rfc6962.DefaultHasher.EmptyRoot())
+	})
+}
+
+// SequenceBatch assigns the next available indices in the log to entries, and integrates them into the tree,
+// in a single transaction.
+//
+// Entries are added to the end of the latest entry bundle, and the tiles and tree state are updated to match,
+// so the log is always left-hand dense. Failures caused by contention with another transaction are reported
+// as tessera.ErrPushback, since they're likely to succeed if retried.
+func (s *Store) SequenceBatch(ctx context.Context, entries []*tessera.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ctx, span := tracer.Start(ctx, "tessera.storage.sqlcommon.SequenceBatch")
+	defer span.End()
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
+
+	err := s.inTx(ctx, "sequence batch", func(tx *sql.Tx) error {
+		// The tree state row is locked for the rest of the transaction, which serialises sequencing.
+		ts, err := s.ReadTreeStateForUpdate(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to read tree state: %w", err)
+		}
+		span.SetAttributes(fromSizeKey.Int64(otel.Clamp64(ts.Size)))
+		return s.appendEntries(ctx, tx, ts.Size, entries)
+	})
+	if err != nil && s.d.IsRetryable(err) {
+		return fmt.Errorf("%w: %v", tessera.ErrPushback, err)
+	}
+	return err
+}
+
+// appendEntries incorporates the provided entries into the log starting at fromSeq.
+func (s *Store) appendEntries(ctx context.Context, tx *sql.Tx, fromSeq uint64, entries []*tessera.Entry) error {
+	// Add sequenced entries to entry bundles.
+	var partial []byte
+	if entriesInBundle := fromSeq % layout.EntryBundleWidth; entriesInBundle > 0 {
+		// If the latest bundle is partial, we need to read the data it contains in for our newer, larger, bundle.
+		var size uint32
+		if err := tx.QueryRowContext(ctx, s.selectTiledLeaves, fromSeq/layout.EntryBundleWidth).Scan(&size, &partial); err != nil {
+			return fmt.Errorf("scan partial entry bundle: %w", err)
+		}
+		if size != uint32(entriesInBundle) {
+			return fmt.Errorf("expected %d entries in storage but found %d", entriesInBundle, size)
+		}
+	}
+	bundle, err := api.NewEntryBundleBuilder(fromSeq, partial, nil)
+	if err != nil {
+		return fmt.Errorf("partial entry bundle: %w", err)
+	}
+
+	lh := make([][]byte, 0, len(entries))
+	for i, e := range entries {
+		// Entries are assigned their index here, as some serialisations include it.
+		if err := bundle.AppendSerialised(e.MarshalBundleData(fromSeq + uint64(i))); err != nil {
+			return fmt.Errorf("add bundle data: %w", err)
+		}
+		lh = append(lh, e.LeafHash())
+
+		if bundle.Full() {
+			if err := s.WriteEntryBundle(ctx, tx, bundle.Index(), uint32(bundle.Size()), bundle.Bytes()); err != nil {
+				return err
+			}
+			if err := bundle.Next(nil); err != nil {
+				return err
+			}
+		}
+	}
+	// If we have a partial bundle remaining once we've added all the entries from the batch,
+	// this needs writing out too.
+	if bundle.Size() > 0 {
+		if err := s.WriteEntryBundle(ctx, tx, bundle.Index(), uint32(bundle.Size()), bundle.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	newSize, newRoot, err := s.integrate(ctx, tx, fromSeq, lh)
+	if err != nil {
+		return fmt.Errorf("integrate: %w", err)
+	}
+	if err := s.WriteTreeState(ctx, tx, newSize, newRoot); err != nil {
+		return err
+	}
+	klog.Infof("New tree: %d, %x", newSize, newRoot)
+	return nil
+}
+
+// IntegrateBatch adds the provided leaf hashes to the tree, starting at fromSeq, and updates the tree state
+// to match, in a single transaction. This is used by migration targets, whose entry bundles are written
+// separately.
+//
+// Returns the new size of the tree and its root hash.
+func (s *Store) IntegrateBatch(ctx context.Context, fromSeq uint64, lh [][]byte) (uint64, []byte, error) {
+	var newSize uint64
+	var newRoot []byte
+	err := s.inTx(ctx, "integrate batch", func(tx *sql.Tx) error {
+		var err error
+		if newSize, newRoot, err = s.integrate(ctx, tx, fromSeq, lh); err != nil {
+			return fmt.Errorf("integrate: %w", err)
+		}
+		return s.WriteTreeState(ctx, tx, newSize, newRoot)
+	})
+	return newSize, newRoot, err
+}
+
+// integrate adds the provided leaf hashes to the merkle tree, starting at fromSeq, and writes out the
+// updated tiles.
+func (s *Store) integrate(ctx context.Context, tx *sql.Tx, fromSeq uint64, lh [][]byte) (uint64, []byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.sqlcommon.integrate")
+	defer span.End()
+
+	getTiles := func(ctx context.Context, tileIDs []storage.TileID, _ uint64) ([]*api.HashTile, error) {
+		return s.readTiles(ctx, tx, tileIDs)
+	}
+	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh)
+	if err != nil {
+		return 0, nil, fmt.Errorf("storage.Integrate: %w", err)
+	}
+	for k, v := range tiles {
+		nodes, err := v.MarshalText()
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := s.WriteTile(ctx, tx, uint64(k.Level), k.Index, nodes); err != nil {
+			return 0, nil, fmt.Errorf("failed to set tile(%v): %w", k, err)
+		}
+	}
+	return newSize, newRoot, nil
+}
+
+// PublishCheckpoint uses sign to create a checkpoint for the current tree state, and stores it, unless a
+// checkpoint has been published less than interval ago. The published checkpoint is returned, or nil if none
+// was published.
+//
+// The checkpoint is signed outside of any transaction, using a non-locking read of the tree state, so
+// that slow signers (e.g. KMS or HSM backed) never hold locks which integration needs. The transaction
+// which stores the signed checkpoint will refuse to replace a checkpoint for a larger tree, which could
+// otherwise happen if multiple appenders are publishing concurrently.
+func (s *Store) PublishCheckpoint(ctx context.Context, interval time.Duration, sign func(ctx context.Context, size uint64, root []byte) ([]byte, error)) (*tessera.PublishedCheckpoint, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.sqlcommon.PublishCheckpoint")
+	defer span.End()
+
+	// Check whether it's too soon to publish before doing the expensive work of signing.
+	if _, at, err := s.ReadCheckpoint(ctx); err != nil && !errors.Is(err, tessera.ErrNotFound) {
+		return nil, err
+	} else if time.Since(at) < interval {
+		klog.V(1).Info("skipping publish - too soon")
+		return nil, nil
+	}
+
+	start := time.Now()
+	ts, err := s.ReadTreeState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("readTreeState: %v", err)
+	}
+	cp, err := sign(ctx, ts.Size, ts.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	published := false
+	if err := s.inTx(ctx, "publish checkpoint", func(tx *sql.Tx) error {
+		// Reset, since this is run again if the transaction is retried.
+		published = false
+		// Re-check the stored checkpoint now that we hold the lock, in case another appender published
+		// while we were signing.
+		note, at, err := s.readCheckpoint(ctx, tx, s.selectCheckpointForUpdate)
+		if err != nil && !errors.Is(err, tessera.ErrNotFound) {
+			return err
+		}
+		if time.Since(at) < interval {
+			klog.V(1).Info("skipping publish - checkpoint published by another appender")
+			return nil
+		}
+		if len(note) > 0 {
+			if _, size, _, err := parse.CheckpointUnsafe(note); err != nil {
+				return fmt.Errorf("failed to parse stored checkpoint: %v", err)
+			} else if size > ts.Size {
+				klog.V(1).Infof("skipping publish - stored checkpoint size %d is larger than signed size %d", size, ts.Size)
+				return nil
+			}
+		}
+		if _, err := tx.ExecContext(ctx, s.upsertCheckpoint, checkpointID, cp, time.Now().UnixMilli()); err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+		published = true
+		return nil
+	}); err != nil || !published {
+		return nil, err
+	}
+	s.metrics.CheckpointPublished(ctx, start)
+	klog.V(2).Infof("Published latest checkpoint: %d, %x", ts.Size, ts.Root)
+	return &tessera.PublishedCheckpoint{Size: ts.Size, Root: ts.Root, Checkpoint: cp}, nil
+}
+
+// StreamEntries() returns functions `next` and `cancel` which act like a pull iterator for
+// consecutive entry bundles, starting with the entry bundle which contains the requested entry
+// index.
+//
+// This implements the StreamEntries method of the tessera LogReader contract.
+func (s *Store) StreamEntries(ctx context.Context, fromEntry uint64) (next func() (ri layout.RangeInfo, bundle []byte, err error), cancel func()) {
+	type riBundle struct {
+		ri  layout.RangeInfo
+		b   []byte
+		err error
+	}
+	// c is a channel which carries elements which ultimately will be returned via the next function.
+	// TODO(al): Figure out what a good channel capacity is here.
+	c := make(chan riBundle, 10)
+	// done signals that we should stop any background processing when it's closed.
+	// This happens when the returned cancel func is called.
+	done := make(chan struct{})
+
+	// Kick off a background goroutine which fills c.
+	go func() {
+		var rangeInfoNext func() (layout.RangeInfo, bool)
+		var rangeInfoCancel func()
+		var rows *sql.Rows
+		nextEntry := fromEntry
+
+		// reset should be called if we detect that something has gone wrong and/or we need to re-start our streaming.
+		reset := func() {
+			if rows != nil {
+				_ = rows.Close()
+				rows = nil
+			}
+			if rangeInfoCancel != nil {
+				rangeInfoCancel()
+				rangeInfoCancel = nil
+				rangeInfoNext = nil
+			}
+		}
+
+		sleep := time.Duration(0)
+	tryAgain:
+		for {
+			// We'll keep going until the context is done, but don't want to hammer the DB when we've
+			// streamed all the current entries and are waiting for the tree to grow.
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				close(c)
+				return
+			case <-time.After(sleep):
+				// We avoid pausing unnecessarily the first time we enter the loop by initialising sleep to zero, but
+				// subsequent iterations around the loop _should_ sleep to avoid hammering the DB when we've caught up with
+				// all the entries it contains.
+				sleep = time.Second
+			}
+
+			// Check if we need to (re-) setup the data stream, and do it if so.
+			if rangeInfoNext == nil {
+				// We need to know what the current local tree size is.
+				ts, err := s.ReadTreeState(ctx)
+				if err != nil {
+					klog.Warningf("Failed to read tree state: %v", err)
+					reset()
+					continue
+				}
+				klog.Infof("StreamEntries scanning %d -> %d", fromEntry, ts.Size)
+				// And we need the corresponding range info which tell us the "shape" of the entry bundles.
+				rangeInfoNext, rangeInfoCancel = iter.Pull(layout.Range(nextEntry, ts.Size, ts.Size))
+				nextBundle := nextEntry / layout.EntryBundleWidth
+				// Finally, we need the actual raw entry bundles themselves.
+				rows, err = s.streamEntryBundles(ctx, nextBundle)
+				if err != nil {
+					klog.Warningf("Failed to read entry bundle @%d: %v", nextBundle, err)
+					reset()
+					continue
+				}
+			}
+
+			// Now we can iterate over the streams we've set up above, and turn the data into the right form
+			// for sending over c, to be returned to the caller via the next func.
+			var idx, size uint64
+			var data []byte
+			for rows.Next() {
+				// Parse a bundle from the DB.
+				if err := rows.Scan(&idx, &size, &data); err != nil {
+					reset()
+					c <- riBundle{err: err}
+					continue tryAgain
+				}
+				// And grab the corresponding range info which describes it.
+				ri, ok := rangeInfoNext()
+				if !ok {
+					reset()
+					continue tryAgain
+				}
+				// The bundle data and the range info MUST refer to the same entry bundle index, so assert that they do.
+				if idx != ri.Index {
+					// Something's gone wonky - our rangeinfo and entry bundle streams are no longer lined up.
+					// Bail and set up the streams again.
+					klog.Infof("Out of sync, got entrybundle index %d, but rangeinfo for index %d", idx, ri.Index)
+					reset()
+					continue tryAgain
+				}
+				// All good, so queue up the data to be returned via calls to next.
+				klog.V(1).Infof("Sending %v", ri)
+				c <- riBundle{ri: ri, b: data}
+				nextEntry += uint64(ri.N)
+			}
+			klog.V(1).Infof("StreamEntries: no more entry bundle rows, will retry")
+			// We have no more rows coming from the entrybundle table of the DB, so go around again and re-check
+			// the tree size in case it's grown since we started the query.
+			reset()
+		}
+	}()
+
+	// This is the implementation of the next function we'll return to the caller.
+	// They'll call this repeatedly to consume entries from c.
+	next = func() (layout.RangeInfo, []byte, error) {
+		select {
+		case <-ctx.Done():
+			return layout.RangeInfo{}, nil, ctx.Err()
+		case r, ok := <-c:
+			if !ok {
+				return layout.RangeInfo{}, nil, errors.New("no more entries")
+			}
+			return r.ri, r.b, r.err
+		}
+	}
+
+	return next, func() {
+		close(done)
+	}
+}
+
+// AwaitIntegration integrates the entry bundles written by a migration target, using bundleHasher to find the
+// leaf hashes of the entries in them, and blocks until the tree has grown to sourceSize. It returns the root
+// hash of the tree at that size.
+//
+// This implements the AwaitIntegration method of the tessera MigrationWriter contract.
+func (s *Store) AwaitIntegration(ctx context.Context, sourceSize uint64, bundleHasher func([]byte) ([][]byte, error)) ([]byte, error) {
+	// fromSeq keeps track of where we need to integrate from - i.e. the current local size of the integrated tree.
+	var fromSeq uint64
+	// rows provides a stream of entry bundle rows which will be processed in the loop below.
+	var rows *sql.Rows
+
+	// The outer loop "tryAgain", will (re-) setup the streaming read of entry bundles from the DB.
+	// The inner loop will go around attempting to process each of these rows in turn. If it encounters
+	// a problem it'll break out to the outer loop to sort things out and retry.
+tryAgain:
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+
+		// Release resources if we're going around and resetting the read.
+		if rows != nil {
+			_ = rows.Close()
+		}
+		// Figure out where we should be integration from.
+		ts, err := s.ReadTreeState(ctx)
+		if err != nil {
+			klog.Warningf("AwaitIntegration: readTreeState: %v", err)
+			continue
+		}
+		fromSeq = ts.Size
+		klog.Infof("AwaitIntegration: Integrate from %d (Target %d)", fromSeq, sourceSize)
+
+		// Set up the streaming read of entry bundles from the DB.
+		nextBundle := fromSeq / layout.EntryBundleWidth
+		rows, err = s.streamEntryBundles(ctx, nextBundle)
+		if err != nil {
+			klog.Warningf("Failed to start streaming entry bundles @%d: %v", nextBundle, err)
+			continue
+		}
+
+		// This is the inner loop which processes each of the entry bundle rows from the DB read in turn.
+		for rows.Next() {
+			// Parse the row.
+			var idx, size uint64
+			var data []byte
+			if err := rows.Scan(&idx, &size, &data); err != nil {
+				klog.Warningf("AwaitIntegration: Scan: %v", err)
+				continue tryAgain
+			}
+			// Check that we're seeing contiguous bundles, and go around if we've encountered a gap.
+			// This isn't necessarily an unrecoverable error, it's probably just that we've either hit the end of all
+			// available entry bundles, or whatever process is copying them over hasn't yet written this one.
+			// We'll continue looping around in the outer loop (where we back off to avoid hammering the DB) until
+			// this entry bundle turns up.
+			if want := fromSeq / uint64(layout.EntryBundleWidth); idx != want {
+				klog.V(1).Infof("AwaitIntegration: encountered gap, want idx %d (fromSeq %d) but found %d", want, fromSeq, idx)
+				continue tryAgain
+			}
+
+			// Turn the entry bundle into leaf hashes.
+			lh, err := bundleHasher(data)
+			if err != nil {
+				klog.Warningf("AwaitIntegration: bundleHasher: %v", err)
+				continue tryAgain
+			}
+
+			// Trim the bundle if we've previously integrated some of it (e.g. because it was a [smaller] partial bundle last time
+			// we saw it.
+			f := fromSeq % layout.EntryBundleWidth
+			lh = lh[f:]
+
+			// And finally integrate the bundle into the tree.
+			newSize, newRoot, err := s.IntegrateBatch(ctx, fromSeq, lh)
+			if err != nil {
+				klog.Warningf("AwaitIntegration: integrateBatch: %v", err)
+				continue tryAgain
+			}
+			fromSeq = newSize
+
+			if newSize == sourceSize {
+				klog.Infof("AwaitIntegration: Integrated to %d with root hash %x", newSize, newRoot)
+				return newRoot, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,491 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlcommon implements the parts of a database/sql based Tessera storage driver which are common to
+// all SQL databases: reading and writing the tree state, tiles, entry bundles, and checkpoints, and the
+// transactions which sequence and integrate entries.
+//
+// The differences between databases' flavours of SQL are captured by a Dialect, so that drivers for each of
+// them share one implementation of the sequencing logic rather than forking it. Drivers remain responsible
+// for everything else, e.g. creating the schema, connection pool management, and timeouts.
+//
+// The tables used are those described by the MySQL driver's schema.sql, with column types suitably
+// translated for other databases.
+package sqlcommon
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/otel"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"k8s.io/klog/v2"
+)
+
+const (
+	selectCompatibilityVersionSQL = "SELECT `compatibilityVersion` FROM `Tessera` WHERE `id` = 0"
+	selectCheckpointByIDSQL       = "SELECT `note`, `published_at` FROM `Checkpoint` WHERE `id` = ?"
+	selectTreeStateByIDSQL        = "SELECT `size`, `root` FROM `TreeState` WHERE `id` = ?"
+	selectSubtreeSQL              = "SELECT `nodes` FROM `Subtree` WHERE `level` = ? AND `index` = ?"
+	selectTiledLeavesSQL          = "SELECT `size`, `data` FROM `TiledLeaves` WHERE `tile_index` = ?"
+	selectTiledLeavesInSQL        = "SELECT `tile_index`, `size`, `data` FROM `TiledLeaves` WHERE `tile_index` IN (%s)"
+	streamTiledLeavesSQL          = "SELECT `tile_index`, `size`, `data` FROM `TiledLeaves` WHERE `tile_index` >= ? ORDER BY `tile_index` ASC"
+	selectLogIdentitySQL          = "SELECT `identity` FROM `LogIdentity` WHERE `id` = 0"
+	insertLogIdentitySQL          = "INSERT INTO `LogIdentity` (`id`, `identity`) VALUES (0, ?)"
+	updateLogIdentitySQL          = "UPDATE `LogIdentity` SET `identity` = ? WHERE `id` = 0"
+
+	checkpointID = 0
+	treeStateID  = 0
+)
+
+// Store reads and writes the state of a log held in a SQL database.
+type Store struct {
+	db *sql.DB
+	d  Dialect
+
+	// Statements are translated into the dialect once, up front.
+	selectCompatibilityVersion string
+	selectCheckpoint           string
+	selectCheckpointForUpdate  string
+	upsertCheckpoint           string
+	selectTreeState            string
+	selectTreeStateForUpdate   string
+	upsertTreeState            string
+	selectSubtree              string
+	upsertSubtree              string
+	selectTiledLeaves          string
+	streamTiledLeaves          string
+	upsertTiledLeaves          string
+	selectLogIdentityForUpdate string
+	insertLogIdentity          string
+	updateLogIdentity          string
+
+	// metrics, which may be nil, records tile writes and checkpoint publishing.
+	metrics *storage.Metrics
+}
+
+// NewStore returns a Store which keeps the log in db, talking to it in dialect d.
+//
+// It returns an error if any of the statements can't be translated into d.
+func NewStore(db *sql.DB, d Dialect) (*Store, error) {
+	var errs []error
+	r := func(q string) string {
+		rq, err := rebind(d, q)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return rq
+	}
+	s := &Store{
+		db:                         db,
+		d:                          d,
+		selectCompatibilityVersion: r(selectCompatibilityVersionSQL),
+		selectCheckpoint:           r(selectCheckpointByIDSQL),
+		selectCheckpointForUpdate:  r(selectCheckpointByIDSQL) + d.ForUpdate(),
+		upsertCheckpoint:           d.Upsert("Checkpoint", []string{"id"}, []string{"id", "note", "published_at"}),
+		selectTreeState:            r(selectTreeStateByIDSQL),
+		selectTreeStateForUpdate:   r(selectTreeStateByIDSQL) + d.ForUpdate(),
+		upsertTreeState:            d.Upsert("TreeState", []string{"id"}, []string{"id", "size", "root"}),
+		selectSubtree:              r(selectSubtreeSQL),
+		upsertSubtree:              d.Upsert("Subtree", []string{"level", "index"}, []string{"level", "index", "nodes"}),
+		selectTiledLeaves:          r(selectTiledLeavesSQL),
+		streamTiledLeaves:          r(streamTiledLeavesSQL),
+		upsertTiledLeaves:          d.Upsert("TiledLeaves", []string{"tile_index"}, []string{"tile_index", "size", "data"}),
+		selectLogIdentityForUpdate: r(selectLogIdentitySQL) + d.ForUpdate(),
+		insertLogIdentity:          r(insertLogIdentitySQL),
+		updateLogIdentity:          r(updateLogIdentitySQL),
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to translate statements: %w", errors.Join(errs...))
+	}
+	return s, nil
+}
+
+// SetMetrics sets the instruments to which tile writes and checkpoint publishing are reported.
+func (s *Store) SetMetrics(m *storage.Metrics) {
+	s.metrics = m
+}
+
+// DB returns the database the log is stored in.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Querier is implemented by both sql.DB and sql.Tx, so that reads can be made inside or outside a transaction.
+type Querier interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+// Execer is implemented by both sql.DB and sql.Tx, so that writes can be made inside or outside a transaction.
+type Execer interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+// TreeState is the size and root hash of the integrated tree.
+type TreeState struct {
+	Size uint64
+	Root []byte
+}
+
+// CompatibilityVersion returns the version of the schema recorded in the Tessera table.
+func (s *Store) CompatibilityVersion(ctx context.Context) (uint8, error) {
+	var v uint8
+	if err := s.db.QueryRowContext(ctx, s.selectCompatibilityVersion).Scan(&v); err != nil {
+		return 0, fmt.Errorf("failed to read Tessera version from DB: %v", err)
+	}
+	return v, nil
+}
+
+// EnsureIdentity will fail if the log identity stored in the LogIdentity table doesn't match the provided one.
+// If no row exists, then it is created with the provided identity.
+func (s *Store) EnsureIdentity(ctx context.Context, id tessera.LogIdentity) error {
+	return s.inTx(ctx, "ensure identity", func(tx *sql.Tx) error {
+		var stored []byte
+		found := true
+		if err := tx.QueryRowContext(ctx, s.selectLogIdentityForUpdate).Scan(&stored); err == sql.ErrNoRows {
+			found = false
+		} else if err != nil {
+			return fmt.Errorf("failed to read log identity: %w", err)
+		}
+		updated, err := id.Reconcile(stored)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(stored, updated) {
+			return nil
+		}
+		q := s.updateLogIdentity
+		if !found {
+			q = s.insertLogIdentity
+		}
+		if _, err := tx.ExecContext(ctx, q, updated); err != nil {
+			return fmt.Errorf("failed to write log identity: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReadCheckpoint returns the latest published checkpoint, and the time at which it was published.
+// If there is no checkpoint, it returns tessera.ErrNotFound.
+func (s *Store) ReadCheckpoint(ctx context.Context) ([]byte, time.Time, error) {
+	return s.readCheckpoint(ctx, s.db, s.selectCheckpoint)
+}
+
+func (s *Store) readCheckpoint(ctx context.Context, q Querier, stmt string) ([]byte, time.Time, error) {
+	var checkpoint []byte
+	var at int64
+	if err := q.QueryRowContext(ctx, stmt, checkpointID).Scan(&checkpoint, &at); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, time.Time{}, tessera.ErrNotFound
+		}
+		return nil, time.Time{}, fmt.Errorf("scan checkpoint: %w", err)
+	}
+	return checkpoint, time.UnixMilli(at), nil
+}
+
+// ReadTreeState returns the currently stored tree state.
+// If there is no stored tree state, it returns tessera.ErrNotFound.
+func (s *Store) ReadTreeState(ctx context.Context) (*TreeState, error) {
+	return s.readTreeState(ctx, s.db, s.selectTreeState)
+}
+
+// ReadTreeStateForUpdate returns the currently stored tree state, and locks it against concurrent updates
+// until tx ends.
+// If there is no stored tree state, it returns tessera.ErrNotFound.
+func (s *Store) ReadTreeStateForUpdate(ctx context.Context, tx *sql.Tx) (*TreeState, error) {
+	return s.readTreeState(ctx, tx, s.selectTreeStateForUpdate)
+}
+
+func (s *Store) readTreeState(ctx context.Context, q Querier, stmt string) (*TreeState, error) {
+	r := &TreeState{}
+	if err := q.QueryRowContext(ctx, stmt, treeStateID).Scan(&r.Size, &r.Root); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, tessera.ErrNotFound
+		}
+		return nil, fmt.Errorf("scan tree state: %w", err)
+	}
+	return r, nil
+}
+
+// WriteTreeState replaces the stored tree state.
+func (s *Store) WriteTreeState(ctx context.Context, e Execer, size uint64, root []byte) error {
+	if _, err := e.ExecContext(ctx, s.upsertTreeState, treeStateID, size, root); err != nil {
+		return fmt.Errorf("failed to write tree state: %w", err)
+	}
+	return nil
+}
+
+// ReadTile returns a full tile or a partial tile at the given level, index and partial size.
+// If the tile is not found, it returns tessera.ErrNotFound.
+//
+// Note that if a partial tile is requested, but a larger tile is available, this
+// will return the largest tile available.
+func (s *Store) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	var tile []byte
+	if err := s.db.QueryRowContext(ctx, s.selectSubtree, level, index).Scan(&tile); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, tessera.ErrNotFound
+		}
+		return nil, fmt.Errorf("scan tile: %v", err)
+	}
+
+	numEntries := uint64(len(tile) / sha256.Size)
+	requestedEntries := uint64(p)
+	if requestedEntries == 0 {
+		requestedEntries = layout.TileWidth
+	}
+	if requestedEntries > numEntries {
+		// If the user has requested a size larger than we have, they can't have it
+		return nil, tessera.ErrNotFound
+	}
+	return tile, nil
+}
+
+// WriteTile replaces the tile nodes at the given level and index.
+func (s *Store) WriteTile(ctx context.Context, e Execer, level, index uint64, nodes []byte) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.sqlcommon.WriteTile")
+	defer span.End()
+	span.SetAttributes(levelKey.Int64(otel.Clamp64(level)), indexKey.Int64(otel.Clamp64(index)))
+
+	if _, err := e.ExecContext(ctx, s.upsertSubtree, level, index, nodes); err != nil {
+		return fmt.Errorf("failed to write tile: %w", err)
+	}
+	// This counts writes made in transactions which may later be rolled back and retried.
+	s.metrics.TileWritten(ctx, level)
+	return nil
+}
+
+// ReadEntryBundle returns the entry bundle at the given index and partial size.
+// If the entry bundle is not found, it returns an error wrapping tessera.ErrNotFound.
+//
+// Note that if a partial bundle is requested, but a larger bundle is available, this
+// will return the largest bundle available.
+func (s *Store) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	var size uint32
+	var entryBundle []byte
+	if err := s.db.QueryRowContext(ctx, s.selectTiledLeaves, index).Scan(&size, &entryBundle); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, tessera.ErrNotFound
+		}
+		return nil, fmt.Errorf("scan entry bundle: %v", err)
+	}
+
+	requestedSize := uint32(p)
+	if requestedSize == 0 {
+		requestedSize = layout.EntryBundleWidth
+	}
+	if requestedSize > size {
+		return nil, fmt.Errorf("bundle with %d entries requested, but only %d available: %w", requestedSize, size, tessera.ErrNotFound)
+	}
+	return entryBundle, nil
+}
+
+// ReadEntryBundles reads the entry bundles at addrs with a single query, and stores them in the corresponding
+// elements of r.
+// As with ReadEntryBundle, a request for a partial bundle is given the bundle as it's currently stored, which
+// may hold more entries than were requested.
+// If any of the entry bundles are not found, or hold fewer entries than requested, it returns an error which
+// wraps tessera.ErrNotFound.
+func (s *Store) ReadEntryBundles(ctx context.Context, addrs []tessera.BundleAddr, r [][]byte) error {
+	args := make([]any, 0, len(addrs))
+	seen := make(map[uint64]bool, len(addrs))
+	for _, a := range addrs {
+		if !seen[a.Index] {
+			seen[a.Index] = true
+			args = append(args, a.Index)
+		}
+	}
+	q, err := rebind(s.d, selectTiledLeavesInSQL)
+	if err != nil {
+		return err
+	}
+	q = fmt.Sprintf(q, placeholders(s.d, 1, len(args)))
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("query entry bundles: %v", err)
+	}
+	defer closeRows(rows)
+
+	type bundle struct {
+		size uint32
+		data []byte
+		used bool
+	}
+	bundles := make(map[uint64]bundle, len(args))
+	for rows.Next() {
+		var idx uint64
+		var b bundle
+		if err := rows.Scan(&idx, &b.size, &b.data); err != nil {
+			return fmt.Errorf("scan entry bundle: %v", err)
+		}
+		bundles[idx] = b
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read entry bundles: %v", err)
+	}
+
+	for i, a := range addrs {
+		b, ok := bundles[a.Index]
+		if !ok {
+			return fmt.Errorf("entry bundle %d: %w", a.Index, tessera.ErrNotFound)
+		}
+		requestedSize := uint32(a.Partial)
+		if requestedSize == 0 {
+			requestedSize = layout.EntryBundleWidth
+		}
+		if requestedSize > b.size {
+			return fmt.Errorf("entry bundle %d with %d entries requested, but only %d available: %w", a.Index, requestedSize, b.size, tessera.ErrNotFound)
+		}
+		if b.used {
+			// Don't let callers which asked for the same bundle more than once share its backing storage.
+			r[i] = bytes.Clone(b.data)
+			continue
+		}
+		r[i] = b.data
+		b.used = true
+		bundles[a.Index] = b
+	}
+	return nil
+}
+
+// WriteEntryBundle replaces the entry bundle at the given index with one holding size entries.
+func (s *Store) WriteEntryBundle(ctx context.Context, e Execer, index uint64, size uint32, entryBundle []byte) error {
+	if _, err := e.ExecContext(ctx, s.upsertTiledLeaves, index, size, entryBundle); err != nil {
+		return fmt.Errorf("failed to write entry bundle: %w", err)
+	}
+	return nil
+}
+
+// streamEntryBundles returns the rows of the TiledLeaves table, in order, starting at bundle index from.
+// Each row holds the index, size, and data of the bundle.
+func (s *Store) streamEntryBundles(ctx context.Context, from uint64) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, s.streamTiledLeaves, from)
+}
+
+// readTiles returns the hash tiles with the given IDs, in the same order, using a single query.
+func (s *Store) readTiles(ctx context.Context, q Querier, tileIDs []storage.TileID) ([]*api.HashTile, error) {
+	hashTiles := make([]*api.HashTile, len(tileIDs))
+	if len(tileIDs) == 0 {
+		return hashTiles, nil
+	}
+
+	// Build the SQL and args to fetch the hash tiles.
+	var b strings.Builder
+	args := make([]any, 0, len(tileIDs)*2)
+	for i, id := range tileIDs {
+		if i != 0 {
+			b.WriteString(" UNION ALL ")
+		}
+		b.WriteString(selectSubtreeSQL)
+		args = append(args, id.Level, id.Index)
+	}
+	stmt, err := rebind(s.d, b.String())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the hash tiles with SQL (%s): %w", stmt, err)
+	}
+	defer closeRows(rows)
+
+	i := 0
+	for rows.Next() {
+		var tile []byte
+		if err := rows.Scan(&tile); err != nil {
+			return nil, fmt.Errorf("scan subtree tile: %w", err)
+		}
+		t := &api.HashTile{}
+		if err := t.UnmarshalText(tile); err != nil {
+			return nil, fmt.Errorf("unmarshal tile: %w", err)
+		}
+		hashTiles[i] = t
+		i++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error while fetching subtrees: %w", err)
+	}
+	return hashTiles, nil
+}
+
+// inTx runs f in a transaction, which is committed if f succeeds and rolled back otherwise.
+//
+// Transactions which fail with errors that the dialect considers retryable are run again, from scratch, up
+// to the number of attempts it allows, so f must not leave behind any state which would be wrong if it
+// were called more than once.
+func (s *Store) inTx(ctx context.Context, name string, f func(tx *sql.Tx) error) error {
+	return retryTx(ctx, name, s.d, func() error {
+		return s.inTxOnce(ctx, name, f)
+	})
+}
+
+// txRetryBackoff is the base delay between attempts at a transaction; it grows with each attempt.
+const txRetryBackoff = 10 * time.Millisecond
+
+// retryTx calls f until it succeeds, fails with an error which d doesn't consider retryable, or has been
+// called d.TxAttempts() times. The error from the final call is returned.
+func retryTx(ctx context.Context, name string, d Dialect, f func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= d.TxAttempts() || !d.IsRetryable(err) {
+			return err
+		}
+		// Jitter stops the contending transactions from retrying in lockstep, and colliding again.
+		backoff := time.Duration(attempt)*txRetryBackoff + rand.N(txRetryBackoff)
+		klog.V(1).Infof("Retrying %s in %v after attempt %d failed: %v", name, backoff, attempt, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// inTxOnce makes a single attempt at running f in a transaction.
+func (s *Store) inTxOnce(ctx context.Context, name string, f func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx %s: %v", name, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			klog.Errorf("Failed to rollback in %s: %v", name, err)
+		}
+	}()
+	if err := f(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit %s: %w", name, err)
+	}
+	return nil
+}
+
+func closeRows(rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		klog.Warningf("Failed to close rows: %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storagetest provides tests which check that a storage implementation meets the contract
+// which Tessera expects of it.
+//
+// Storage implementations should call RunAppenderTests and RunChaosTests from their own tests.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/integration/harness"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// numEntries is the number of entries added by each test, chosen to span more than one entry bundle.
+const numEntries = 300
+
+// checkpointInterval is the interval between published checkpoints, chosen to be no shorter than the
+// minimum permitted by any of the drivers.
+const checkpointInterval = 2 * time.Second
+
+// NewDriverFunc returns a storage driver backed by new, empty, storage.
+type NewDriverFunc func(t *testing.T) tessera.Driver
+
+// RunAppenderTests checks that drivers returned by newDriver behave correctly in Appender mode.
+func RunAppenderTests(t *testing.T, newDriver NewDriverFunc) {
+	t.Helper()
+	for _, test := range []struct {
+		name string
+		fn   func(t *testing.T, a *tessera.Appender, r tessera.LogReader)
+	}{
+		{name: "AssignsIndices", fn: testAssignsIndices},
+		{name: "IntegratesEntries", fn: testIntegratesEntries},
+		{name: "ReturnsErrNotFound", fn: testReturnsErrNotFound},
+		{name: "ReadsEntryBundles", fn: testReadsEntryBundles},
+		{name: "ReportsTreeState", fn: testReportsTreeState},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a, r := newAppender(t, newDriver(t))
+			test.fn(t, a, r)
+		})
+	}
+}
+
+// ReopenDriverFunc returns a storage driver for the same underlying storage each time it's called, as a
+// restarted process would. The driver should stop any background work once ctx is done.
+type ReopenDriverFunc func(ctx context.Context) (tessera.Driver, error)
+
+// RunChaosTests runs the integration harness, including its Chaos check, against a log kept in the storage
+// opened by reopen, which must be empty. The Chaos check restarts the log without warning while entries are
+// being added, and then checks that no entry whose add succeeded was lost, and that the log's checkpoints
+// remained consistent throughout.
+func RunChaosTests(t *testing.T, reopen ReopenDriverFunc) {
+	t.Helper()
+	skey, vkey, err := note.GenerateKey(rand.Reader, "storagetest")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	l := harness.RestartableInProcessLog(t, reopen, appendOptions(t, skey), v)
+	awaitCheckpoint(t, l.ReadCheckpoint)
+	harness.Run(t, l, harness.Options{
+		NumEntries: 100,
+		Chaos:      harness.ChaosOptions{Restarts: 6, MaxInterval: checkpointInterval},
+	})
+}
+
+// appendOptions returns the options used by the tests, with checkpoints signed by skey.
+func appendOptions(t *testing.T, skey string) *tessera.AppendOptions {
+	t.Helper()
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(checkpointInterval).
+		WithBatching(64, 50*time.Millisecond)
+}
+
+func newAppender(t *testing.T, d tessera.Driver) (*tessera.Appender, tessera.LogReader) {
+	t.Helper()
+	skey, _, err := note.GenerateKey(rand.Reader, "storagetest")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	// The appender's background tasks are stopped when the test's context is cancelled.
+	a, _, r, err := tessera.NewAppender(t.Context(), d, appendOptions(t, skey))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	awaitCheckpoint(t, r.ReadCheckpoint)
+	return a, r
+}
+
+// awaitCheckpoint waits for the checkpoint read by readCheckpoint to exist. Some drivers publish the
+// checkpoint for the empty tree asynchronously, so this must be done before any entries are awaited.
+func awaitCheckpoint(t *testing.T, readCheckpoint func(context.Context) ([]byte, error)) {
+	t.Helper()
+	for {
+		_, err := readCheckpoint(t.Context())
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, tessera.ErrNotFound) {
+			t.Fatalf("ReadCheckpoint: %v", err)
+		}
+		select {
+		case <-t.Context().Done():
+			t.Fatal("Timed out waiting for the initial checkpoint")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// addEntries adds numEntries distinct entries to the log, returning their data and futures.
+func addEntries(t *testing.T, a *tessera.Appender) ([][]byte, []tessera.IndexFuture) {
+	t.Helper()
+	data := make([][]byte, 0, numEntries)
+	futures := make([]tessera.IndexFuture, 0, numEntries)
+	for i := range numEntries {
+		d := fmt.Appendf(nil, "storagetest entry %d %d", time.Now().UnixNano(), i)
+		data = append(data, d)
+		futures = append(futures, a.Add(t.Context(), tessera.NewEntry(d)))
+	}
+	return data, futures
+}
+
+// testAssignsIndices checks that every added entry is assigned a distinct index.
+func testAssignsIndices(t *testing.T, a *tessera.Appender, _ tessera.LogReader) {
+	_, futures := addEntries(t, a)
+	seen := make(map[uint64]bool, len(futures))
+	for i, f := range futures {
+		idx, err := f()
+		if errors.Is(err, tessera.ErrIndexNotAssigned) {
+			t.Fatalf("Add(%d): storage did not assign an index - does it call Entry.MarshalBundleData? %v", i, err)
+		}
+		if err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+		if idx.Index >= numEntries {
+			t.Errorf("Add(%d): got index %d in a log of %d entries", i, idx.Index, numEntries)
+		}
+		if seen[idx.Index] {
+			t.Errorf("Add(%d): index %d assigned more than once", i, idx.Index)
+		}
+		seen[idx.Index] = true
+	}
+}
+
+// testIntegratesEntries checks that every added entry is published at its assigned index.
+func testIntegratesEntries(t *testing.T, a *tessera.Appender, r tessera.LogReader) {
+	ctx := t.Context()
+	data, futures := addEntries(t, a)
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 100*time.Millisecond)
+	indices := make([]uint64, len(futures))
+	var size uint64
+	for i, f := range futures {
+		idx, _, err := awaiter.Await(ctx, f)
+		if err != nil {
+			t.Fatalf("Await(%d): %v", i, err)
+		}
+		indices[i] = idx.Index
+		size = max(size, idx.Index+1)
+	}
+
+	bundles := make(map[uint64]*api.EntryBundle)
+	for i, idx := range indices {
+		bIdx := idx / layout.EntryBundleWidth
+		b, ok := bundles[bIdx]
+		if !ok {
+			raw, err := r.ReadEntryBundle(ctx, bIdx, layout.PartialTileSize(0, bIdx, size))
+			if err != nil {
+				t.Fatalf("ReadEntryBundle(%d): %v", bIdx, err)
+			}
+			b = &api.EntryBundle{}
+			if err := b.UnmarshalText(raw); err != nil {
+				t.Fatalf("Invalid entry bundle %d: %v", bIdx, err)
+			}
+			bundles[bIdx] = b
+		}
+		e := idx % layout.EntryBundleWidth
+		if e >= uint64(len(b.Entries)) {
+			t.Fatalf("Entry bundle %d has %d entries, want entry at index %d", bIdx, len(b.Entries), idx)
+		}
+		if got, want := b.Entries[e], data[i]; !bytes.Equal(got, want) {
+			t.Errorf("Entry at index %d is %q, want %q", idx, got, want)
+		}
+	}
+}
+
+// testReturnsErrNotFound checks that reads of resources which don't exist return a wrapped tessera.ErrNotFound.
+func testReturnsErrNotFound(t *testing.T, _ *tessera.Appender, r tessera.LogReader) {
+	ctx := t.Context()
+	if _, err := r.ReadTile(ctx, 0, 1<<20, 0); !errors.Is(err, tessera.ErrNotFound) {
+		t.Errorf("ReadTile: got err %v, want %v", err, tessera.ErrNotFound)
+	}
+	if _, err := r.ReadEntryBundle(ctx, 1<<20, 0); !errors.Is(err, tessera.ErrNotFound) {
+		t.Errorf("ReadEntryBundle: got err %v, want %v", err, tessera.ErrNotFound)
+	}
+}
+
+// testReadsEntryBundles checks that tessera.ReadEntryBundles returns the same bundle for each address as
+// ReadEntryBundle does, including for a partial bundle which has since grown, and that it returns a wrapped
+// tessera.ErrNotFound if any bundle is missing or has fewer entries than requested.
+func testReadsEntryBundles(t *testing.T, a *tessera.Appender, r tessera.LogReader) {
+	ctx := t.Context()
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 100*time.Millisecond)
+	awaitSize := func(futures []tessera.IndexFuture) uint64 {
+		t.Helper()
+		for i, f := range futures {
+			if _, _, err := awaiter.Await(ctx, f); err != nil {
+				t.Fatalf("Await(%d): %v", i, err)
+			}
+		}
+		ts, err := tessera.ReadTreeState(ctx, r)
+		if err != nil {
+			t.Fatalf("ReadTreeState: %v", err)
+		}
+		return ts.Size
+	}
+	var futures []tessera.IndexFuture
+	for i := range 10 {
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "storagetest bundle entry %d", i))))
+	}
+	partialSize := awaitSize(futures)
+	_, futures = addEntries(t, a)
+	size := awaitSize(futures)
+	if size/layout.EntryBundleWidth != 1 {
+		t.Fatalf("Tree size %d, want it to end in the second entry bundle", size)
+	}
+
+	addrs := []tessera.BundleAddr{
+		{Index: 1, Partial: layout.PartialTileSize(0, 1, size)},
+		{Index: 0},
+		{Index: 0, Partial: uint8(partialSize)},
+		{Index: 1, Partial: layout.PartialTileSize(0, 1, size)},
+	}
+	got, err := tessera.ReadEntryBundles(ctx, r, addrs)
+	if err != nil {
+		t.Fatalf("ReadEntryBundles: %v", err)
+	}
+	if len(got) != len(addrs) {
+		t.Fatalf("ReadEntryBundles returned %d bundles, want %d", len(got), len(addrs))
+	}
+	for i, addr := range addrs {
+		want, err := r.ReadEntryBundle(ctx, addr.Index, addr.Partial)
+		if err != nil {
+			t.Fatalf("ReadEntryBundle(%d, %d): %v", addr.Index, addr.Partial, err)
+		}
+		if !bytes.Equal(got[i], want) {
+			t.Errorf("ReadEntryBundles()[%d] != ReadEntryBundle(%d, %d)", i, addr.Index, addr.Partial)
+		}
+	}
+
+	for _, missing := range [][]tessera.BundleAddr{
+		{{Index: 0}, {Index: 1 << 20}},
+		{{Index: 1}},
+	} {
+		if _, err := tessera.ReadEntryBundles(ctx, r, missing); !errors.Is(err, tessera.ErrNotFound) {
+			t.Errorf("ReadEntryBundles(%v): got err %v, want %v", missing, err, tessera.ErrNotFound)
+		}
+	}
+}
+
+// testReportsTreeState checks that the LogReader implements tessera.TreeStateReader, and that the state it
+// returns matches the published checkpoint.
+func testReportsTreeState(t *testing.T, a *tessera.Appender, r tessera.LogReader) {
+	ctx := t.Context()
+	if _, ok := r.(tessera.TreeStateReader); !ok {
+		t.Fatalf("%T does not implement tessera.TreeStateReader", r)
+	}
+	_, futures := addEntries(t, a)
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 100*time.Millisecond)
+	for i, f := range futures {
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await(%d): %v", i, err)
+		}
+	}
+
+	ts, err := tessera.ReadTreeState(ctx, r)
+	if err != nil {
+		t.Fatalf("ReadTreeState: %v", err)
+	}
+	if ts.Size < numEntries {
+		t.Errorf("ReadTreeState: got size %d, want at least %d", ts.Size, numEntries)
+	}
+	want, err := tessera.NewTreeState(ts.Checkpoint, ts.PublishedAt)
+	if err != nil {
+		t.Fatalf("ReadTreeState returned an invalid checkpoint: %v", err)
+	}
+	if ts.Size != want.Size || !bytes.Equal(ts.Root, want.Root) {
+		t.Errorf("ReadTreeState: got size %d root %x, but its checkpoint commits to size %d root %x", ts.Size, ts.Root, want.Size, want.Root)
+	}
+	if ts.PublishedAt.IsZero() {
+		t.Error("ReadTreeState: publication time is not set")
+	}
+}
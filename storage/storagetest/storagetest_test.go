@@ -0,0 +1,41 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"github.com/transparency-dev/tessera/storage/storagetest"
+)
+
+func TestPOSIX(t *testing.T) {
+	storagetest.RunAppenderTests(t, func(t *testing.T) tessera.Driver {
+		d, err := posix.New(t.Context(), t.TempDir())
+		if err != nil {
+			t.Fatalf("posix.New: %v", err)
+		}
+		return d
+	})
+}
+
+func TestPOSIXChaos(t *testing.T) {
+	dir := t.TempDir()
+	storagetest.RunChaosTests(t, func(ctx context.Context) (tessera.Driver, error) {
+		return posix.New(ctx, dir)
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"sync"
+)
+
+// PublishedCheckpoint describes a checkpoint which has been published by a storage implementation.
+type PublishedCheckpoint struct {
+	// Size is the size of the tree committed to by the checkpoint.
+	Size uint64
+	// Root is the root hash of the tree committed to by the checkpoint.
+	Root []byte
+	// Checkpoint is the checkpoint as published, including any witness cosignatures.
+	Checkpoint []byte
+}
+
+// SubscribeCheckpoints returns a channel which receives each checkpoint published by this Appender's storage
+// implementation, until ctx is done, at which point the channel is closed.
+//
+// This allows components running in the same process as the log, e.g. to feed witnesses or monitor the log, to
+// react to new checkpoints without polling storage for them. Only checkpoints published by this process are
+// delivered; where several processes share a log, the others' checkpoints must still be read from storage.
+//
+// If a checkpoint has already been published, the most recent one is delivered straight away.
+// Publishing never waits for subscribers, so a subscriber which falls behind skips to the most recent checkpoint,
+// and will not necessarily see every one.
+func (a *Appender) SubscribeCheckpoints(ctx context.Context) <-chan PublishedCheckpoint {
+	return a.published.subscribe(ctx)
+}
+
+// checkpointBroadcaster passes published checkpoints on to subscribers.
+type checkpointBroadcaster struct {
+	mu     sync.Mutex
+	latest *PublishedCheckpoint
+	subs   map[chan PublishedCheckpoint]struct{}
+}
+
+// subscribe returns a channel which receives the latest checkpoint, and each one published after it, until
+// ctx is done. A nil broadcaster returns a channel which is already closed.
+func (b *checkpointBroadcaster) subscribe(ctx context.Context) <-chan PublishedCheckpoint {
+	c := make(chan PublishedCheckpoint, 1)
+	if b == nil {
+		close(c)
+		return c
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[chan PublishedCheckpoint]struct{})
+	}
+	if b.latest != nil {
+		c <- *b.latest
+	}
+	b.subs[c] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, c)
+		close(c)
+	}()
+	return c
+}
+
+// publish delivers cp to all subscribers, replacing any checkpoint they haven't yet received.
+func (b *checkpointBroadcaster) publish(cp PublishedCheckpoint) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest = &cp
+	for c := range b.subs {
+		// Channels are only sent to with the lock held, so once any undelivered checkpoint has been
+		// discarded, there's guaranteed to be room for this one.
+		select {
+		case <-c:
+		default:
+		}
+		c <- cp
+	}
+}
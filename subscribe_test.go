@@ -0,0 +1,87 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSubscribeCheckpoints(t *testing.T) {
+	opts := NewAppendOptions()
+	notify := opts.CheckpointNotifier()
+	a := &Appender{published: opts.published}
+	cp := func(size uint64) PublishedCheckpoint {
+		return PublishedCheckpoint{Size: size, Root: fmt.Appendf(nil, "root %d", size), Checkpoint: fmt.Appendf(nil, "cp %d", size)}
+	}
+	recv := func(c <-chan PublishedCheckpoint) (PublishedCheckpoint, bool) {
+		t.Helper()
+		select {
+		case got, ok := <-c:
+			return got, ok
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for checkpoint")
+		}
+		return PublishedCheckpoint{}, false
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	early := a.SubscribeCheckpoints(ctx)
+	notify(cp(1))
+	if got, _ := recv(early); got.Size != 1 || string(got.Checkpoint) != "cp 1" {
+		t.Errorf("Got %+v, want checkpoint 1", got)
+	}
+
+	// A subscriber which isn't keeping up only sees the latest checkpoint.
+	notify(cp(2))
+	notify(cp(3))
+	if got, _ := recv(early); got.Size != 3 {
+		t.Errorf("Got size %d, want 3", got.Size)
+	}
+	select {
+	case got := <-early:
+		t.Errorf("Got unexpected checkpoint %+v", got)
+	default:
+	}
+
+	// Late subscribers start with the latest checkpoint.
+	late := a.SubscribeCheckpoints(t.Context())
+	if got, _ := recv(late); got.Size != 3 {
+		t.Errorf("Late subscriber got size %d, want 3", got.Size)
+	}
+
+	cancel()
+	for {
+		// The cancelled subscription may still deliver a checkpoint published before it was closed.
+		if _, ok := recv(early); !ok {
+			break
+		}
+	}
+	notify(cp(4))
+	if got, _ := recv(late); got.Size != 4 {
+		t.Errorf("Late subscriber got size %d, want 4", got.Size)
+	}
+}
+
+func TestSubscribeCheckpointsWithoutNotifier(t *testing.T) {
+	// An Appender which wasn't created by NewAppender never receives any checkpoints.
+	if _, ok := <-(&Appender{}).SubscribeCheckpoints(t.Context()); ok {
+		t.Error("Got checkpoint from Appender without a notifier")
+	}
+	// Nor does publishing via options which weren't created with NewAppendOptions fail.
+	AppendOptions{}.CheckpointNotifier()(PublishedCheckpoint{Size: 1})
+}
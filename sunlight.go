@@ -0,0 +1,147 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// SunlightSource reads the tiles of a log created by Sunlight, or any other log serving the Static CT API,
+// for use as the source of a migration via MigrationTarget.Migrate, or by a mirror.
+//
+// The target must be configured with MigrationOptions.WithCTLayout, since Sunlight logs contain
+// Static CT API data tiles rather than tlog-tiles entry bundles.
+type SunlightSource struct {
+	f *client.HTTPFetcher
+	v note.Verifier
+}
+
+// NewSunlightSource returns a SunlightSource for the log whose monitoring prefix is monitoringURL.
+//
+// v must verify the log's checkpoint signatures, e.g. a verifier created with NewRFC6962Verifier from
+// github.com/transparency-dev/formats/note using the log's submission prefix and public key.
+// c may be nil, in which case http.DefaultClient will be used.
+func NewSunlightSource(monitoringURL *url.URL, c *http.Client, v note.Verifier) (*SunlightSource, error) {
+	if v == nil {
+		return nil, errors.New("verifier must be set")
+	}
+	f, err := client.NewHTTPFetcher(monitoringURL, c)
+	if err != nil {
+		return nil, err
+	}
+	f.SetEntriesPath(ctEntriesPath)
+	return &SunlightSource{f: f, v: v}, nil
+}
+
+// Checkpoint fetches the log's latest checkpoint and verifies its signature, returning the raw
+// checkpoint along with the size and root hash it commits to.
+//
+// These are suitable for passing to MigrationTarget.Migrate, which will verify that the locally
+// integrated tree has the same root hash once the migration is complete.
+func (s *SunlightSource) Checkpoint(ctx context.Context) ([]byte, uint64, []byte, error) {
+	raw, err := s.f.ReadCheckpoint(ctx)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to fetch checkpoint: %w", err)
+	}
+	cp, _, _, err := client.OpenCheckpoint(raw, s.v.Name(), s.v)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return raw, cp.Size, cp.Hash, nil
+}
+
+// ReadCheckpoint returns the log's latest checkpoint, without verifying it.
+func (s *SunlightSource) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	return s.f.ReadCheckpoint(ctx)
+}
+
+// ReadTile returns the raw hash tile at the given coordinates.
+func (s *SunlightSource) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+	return s.f.ReadTile(ctx, l, i, p)
+}
+
+// ReadEntryBundle returns the raw data tile at the given index, containing p entries if it's partial.
+//
+// Logs are permitted to delete partial tiles once the corresponding full tile has been published, which
+// Sunlight does. If a partial tile no longer exists, the full tile is fetched and truncated to p entries
+// so that the returned bundle always matches the requested tree size.
+func (s *SunlightSource) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	b, err := s.f.ReadEntryBundle(ctx, i, p)
+	if p == 0 || !errors.Is(err, os.ErrNotExist) {
+		return b, err
+	}
+	klog.V(1).Infof("Partial data tile %d.p/%d not found, falling back to full tile", i, p)
+	full, err := s.f.ReadEntryBundle(ctx, i, 0)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ctDataTileEntries(full)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data tile %d: %v", i, err)
+	}
+	if len(entries) < int(p) {
+		return nil, fmt.Errorf("data tile %d has %d entries, want at least %d", i, len(entries), p)
+	}
+	return bytes.Join(entries[:p], nil), nil
+}
+
+// ctDataTileEntries splits a Static CT API data tile into the serialised TileLeaf structures it contains.
+func ctDataTileEntries(bundle []byte) ([][]byte, error) {
+	r := [][]byte{}
+	b := cryptobyte.String(bundle)
+	for i := 0; !b.Empty(); i++ {
+		start := len(bundle) - len(b)
+		var entryType uint16
+		var ignore cryptobyte.String
+		if !b.Skip(8) || !b.ReadUint16(&entryType) {
+			return nil, fmt.Errorf("failed to read timestamp and entry type of entry index %d of bundle", i)
+		}
+		switch entryType {
+		case 0: // X509 entry
+			if !b.ReadUint24LengthPrefixed(&ignore) {
+				return nil, fmt.Errorf("failed to read certificate at entry index %d of bundle", i)
+			}
+		case 1: // Precert entry
+			if !b.Skip(sha256.Size) || !b.ReadUint24LengthPrefixed(&ignore) {
+				return nil, fmt.Errorf("failed to read precert tbs at entry index %d of bundle", i)
+			}
+		default:
+			return nil, fmt.Errorf("unknown entry type 0x%x at entry index %d of bundle", entryType, i)
+		}
+		if !b.ReadUint16LengthPrefixed(&ignore) {
+			return nil, fmt.Errorf("failed to read SCT extensions at entry index %d of bundle", i)
+		}
+		if entryType == 1 && !b.ReadUint24LengthPrefixed(&ignore) {
+			return nil, fmt.Errorf("failed to read precert at entry index %d of bundle", i)
+		}
+		if !b.ReadUint16LengthPrefixed(&ignore) {
+			return nil, fmt.Errorf("failed to read chain fingerprints at entry index %d of bundle", i)
+		}
+		r = append(r, bundle[start:len(bundle)-len(b)])
+	}
+	return r, nil
+}
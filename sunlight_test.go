@@ -0,0 +1,165 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/ctonly"
+	"github.com/transparency-dev/tessera/storage/posix"
+)
+
+func TestSunlightSource(t *testing.T) {
+	ctx := t.Context()
+	const logURL = "https://sunlight.example.com/2025h2/"
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := tessera.NewRFC6962Signer(logURL, k)
+	if err != nil {
+		t.Fatalf("NewRFC6962Signer: %v", err)
+	}
+	vkey, err := f_note.RFC6962VerifierString(logURL, k.Public())
+	if err != nil {
+		t.Fatalf("RFC6962VerifierString: %v", err)
+	}
+	v, err := f_note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	// Create a Static CT API log to act as the Sunlight log being imported.
+	srcDir := t.TempDir()
+	driver, err := posix.New(ctx, srcDir)
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, shutdown, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(64, 50*time.Millisecond).
+		WithCTLayout())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	add := tessera.NewCertificateTransparencyAppender(a)
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	addEntries := func(from, to int) {
+		t.Helper()
+		var futures []tessera.IndexFuture
+		for i := from; i < to; i++ {
+			e := &ctonly.Entry{
+				Timestamp:         uint64(i),
+				Certificate:       fmt.Appendf(nil, "certificate %d", i),
+				FingerprintsChain: [][32]byte{sha256.Sum256([]byte("issuer"))},
+			}
+			if i%2 == 1 {
+				e.IsPrecert = true
+				e.Precertificate = fmt.Appendf(nil, "precertificate %d", i)
+				e.IssuerKeyHash = make([]byte, sha256.Size)
+			}
+			futures = append(futures, add(ctx, e))
+		}
+		for _, f := range futures {
+			if _, _, err := awaiter.Await(ctx, f); err != nil {
+				t.Fatalf("Await: %v", err)
+			}
+		}
+	}
+
+	// Sunlight deletes partial data tiles once they're full, so keep a copy of one to compare against.
+	addEntries(0, 300)
+	partialPath := filepath.Join(srcDir, "tile", "data", "001.p", "44")
+	wantPartial, err := os.ReadFile(partialPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	addEntries(300, 600)
+	if err := os.RemoveAll(filepath.Dir(partialPath)); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(srcDir)))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	src, err := tessera.NewSunlightSource(u, nil, v)
+	if err != nil {
+		t.Fatalf("NewSunlightSource: %v", err)
+	}
+	got, err := src.ReadEntryBundle(ctx, 1, 44)
+	if err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	}
+	if !bytes.Equal(got, wantPartial) {
+		t.Errorf("ReadEntryBundle(1, 44): truncated full tile does not match deleted partial tile")
+	}
+
+	_, size, root, err := src.Checkpoint(ctx)
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	dstDriver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	m, err := tessera.NewMigrationTarget(ctx, dstDriver, tessera.NewMigrationOptions().WithCTLayout())
+	if err != nil {
+		t.Fatalf("NewMigrationTarget: %v", err)
+	}
+	if err := m.Migrate(ctx, 4, size, root, src.ReadEntryBundle); err != nil {
+		t.Errorf("Migrate: %v", err)
+	}
+
+	// A checkpoint which doesn't verify must be rejected.
+	other, err := f_note.RFC6962VerifierString("https://other.example.com/", k.Public())
+	if err != nil {
+		t.Fatalf("RFC6962VerifierString: %v", err)
+	}
+	ov, err := f_note.NewVerifier(other)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	bad, err := tessera.NewSunlightSource(u, nil, ov)
+	if err != nil {
+		t.Fatalf("NewSunlightSource: %v", err)
+	}
+	if _, _, _, err := bad.Checkpoint(ctx); err == nil {
+		t.Error("Checkpoint: got nil error with wrong verifier")
+	}
+}
@@ -60,6 +60,7 @@ func NewTestLog(t *testing.T, opts *tessera.AppendOptions) (*TestLog, func(conte
 	r := &TestLog{
 		Root:        root,
 		SigVerifier: v,
+		SigSigner:   s,
 		LogReader:   lr,
 		Appender:    a,
 	}
@@ -73,6 +74,8 @@ type TestLog struct {
 	Root string
 	// SigVerifier can verify log signatures on its checkpoints.
 	SigVerifier note.Verifier
+	// SigSigner can sign checkpoints as the log, e.g. to simulate a misbehaving log in tests.
+	SigSigner note.Signer
 	// LogReader reads from the log storage directly.
 	LogReader tessera.LogReader
 	// Appender provides access to the Appender lifecycle mode for this log.
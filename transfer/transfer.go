@@ -0,0 +1,407 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transfer supports replicating a log across an air gap using self-verifying bundles.
+//
+// A transfer bundle is a tar archive containing the tiles and entry bundles which are new in a log's
+// latest checkpoint relative to some earlier tree size, the checkpoint itself, and a manifest signed
+// by the creator of the bundle which lists the SHA-256 hash of every other file in the archive.
+//
+// Apply only writes a bundle's contents into the replica once the manifest signature, the checkpoint
+// signature, the consistency of the checkpoint with the replica's current checkpoint, and every tile
+// and entry bundle have been verified.
+package transfer
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/internal/verify"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// manifestPath is the name of the signed manifest in a bundle, which is always the last file.
+	manifestPath = "manifest"
+	// manifestHeader is the first line of the manifest, and identifies the version of the bundle format.
+	manifestHeader = "tessera transfer bundle v1"
+	// maxManifestSize bounds the memory used to read a bundle's manifest.
+	maxManifestSize = 1 << 30
+)
+
+// Create writes a transfer bundle to w containing the resources of the log accessible via f which are
+// committed to by its latest checkpoint but not by the tree of size from, and signs its manifest with s.
+//
+// The resources are checked against each other and the checkpoint before being added to the bundle.
+// from would usually be the size of the replica's current checkpoint, or zero to transfer the entire log.
+func Create(ctx context.Context, w io.Writer, f client.Fetcher, from uint64, s note.Signer) error {
+	cpRaw, err := f.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	_, size, hash, err := parse.CheckpointUnsafe(cpRaw)
+	if err != nil {
+		return fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	if from > size {
+		return fmt.Errorf("from size %d is larger than checkpoint size %d", from, size)
+	}
+	if err := verify.Root(ctx, f.ReadTile, size, hash); err != nil {
+		return fmt.Errorf("checkpoint doesn't match tiles: %v", err)
+	}
+
+	tw := tar.NewWriter(w)
+	manifest := &strings.Builder{}
+	fmt.Fprintf(manifest, "%s\n%d\n", manifestHeader, from)
+	add := func(p string, data []byte) error {
+		h := sha256.Sum256(data)
+		fmt.Fprintf(manifest, "%s %s\n", hex.EncodeToString(h[:]), p)
+		return writeFile(tw, p, data)
+	}
+	for c := range verify.NewTiles(from, size) {
+		tile, bundle, err := verify.Tile(ctx, f, c.Level, c.Index, c.Partial)
+		if err != nil {
+			return err
+		}
+		if err := add(layout.TilePath(c.Level, c.Index, c.Partial), tile); err != nil {
+			return err
+		}
+		if bundle != nil {
+			if err := add(layout.EntriesPath(c.Index, c.Partial), bundle); err != nil {
+				return err
+			}
+		}
+	}
+	if err := add(layout.CheckpointPath, cpRaw); err != nil {
+		return err
+	}
+	signed, err := note.Sign(&note.Note{Text: manifest.String()}, s)
+	if err != nil {
+		return fmt.Errorf("failed to sign manifest: %v", err)
+	}
+	if err := writeFile(tw, manifestPath, signed); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func writeFile(tw *tar.Writer, p string, data []byte) error {
+	// Use a fixed modification time so that bundles of the same resources are identical.
+	if err := tw.WriteHeader(&tar.Header{Name: p, Mode: 0o644, Size: int64(len(data)), ModTime: time.Unix(0, 0), Format: tar.FormatPAX}); err != nil {
+		return fmt.Errorf("failed to write header for %q: %v", p, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %q: %v", p, err)
+	}
+	return nil
+}
+
+// Target is a replica of a log which transfer bundles can be applied to.
+type Target interface {
+	// ReadCheckpoint returns the replica's current checkpoint, or an error wrapping os.ErrNotExist if
+	// the replica is empty.
+	ReadCheckpoint(ctx context.Context) ([]byte, error)
+	ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error)
+	WriteCheckpoint(ctx context.Context, data []byte) error
+	WriteTile(ctx context.Context, l, i uint64, p uint8, data []byte) error
+	WriteEntryBundle(ctx context.Context, i uint64, p uint8, data []byte) error
+}
+
+// ApplyOptions holds the keys used to verify a transfer bundle.
+type ApplyOptions struct {
+	// BundleVerifier verifies the signature on the bundle's manifest.
+	BundleVerifier note.Verifier
+	// LogVerifier verifies the signature on the log's checkpoint.
+	LogVerifier note.Verifier
+	// Origin is the expected origin of the log's checkpoint. If unset, the name of LogVerifier is used.
+	Origin string
+}
+
+// Apply verifies the transfer bundle of the given size read from r, and writes its contents to t.
+//
+// Nothing is written to t unless the whole bundle verifies, and the checkpoint is written last, so
+// an interrupted Apply can safely be retried.
+func Apply(ctx context.Context, r io.ReaderAt, size int64, t Target, opts ApplyOptions) error {
+	b, err := openBundle(r, size, opts.BundleVerifier)
+	if err != nil {
+		return err
+	}
+	origin := opts.Origin
+	if origin == "" {
+		origin = opts.LogVerifier.Name()
+	}
+	cpRaw, err := b.read(layout.CheckpointPath)
+	if err != nil {
+		return err
+	}
+	cp, _, _, err := client.OpenCheckpoint(cpRaw, origin, opts.LogVerifier)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint in bundle: %v", err)
+	}
+	cur := &log.Checkpoint{}
+	curRaw, err := t.ReadCheckpoint(ctx)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read target checkpoint: %v", err)
+	default:
+		if cur, _, _, err = client.OpenCheckpoint(curRaw, origin, opts.LogVerifier); err != nil {
+			return fmt.Errorf("invalid target checkpoint: %v", err)
+		}
+	}
+
+	switch {
+	case cur.Size < b.from:
+		return fmt.Errorf("bundle starts at size %d, but target is only at size %d", b.from, cur.Size)
+	case cur.Size > cp.Size:
+		return fmt.Errorf("target size %d is larger than bundle checkpoint size %d", cur.Size, cp.Size)
+	case cur.Size == cp.Size && cur.Size > 0:
+		if !bytes.Equal(cur.Hash, cp.Hash) {
+			return fmt.Errorf("target and bundle checkpoints have the same size %d but different root hashes", cp.Size)
+		}
+		if bytes.Equal(curRaw, cpRaw) {
+			return nil
+		}
+		return t.WriteCheckpoint(ctx, cpRaw)
+	}
+
+	f := overlay{b: b, t: t}
+	if err := verify.Root(ctx, f.ReadTile, cp.Size, cp.Hash); err != nil {
+		return fmt.Errorf("bundle checkpoint doesn't match tiles: %v", err)
+	}
+	if err := verify.Consistency(ctx, f.ReadTile, cur.Size, cp.Size, cur.Hash, cp.Hash); err != nil {
+		return fmt.Errorf("bundle checkpoint is not consistent with target checkpoint: %v", err)
+	}
+	for c := range verify.NewTiles(cur.Size, cp.Size) {
+		if _, _, err := verify.Tile(ctx, f, c.Level, c.Index, c.Partial); err != nil {
+			return err
+		}
+	}
+
+	// Everything checks out, so copy the resources into the target. They're read from the bundle again
+	// rather than being held in memory since bundles can be large, which is safe because every read is
+	// checked against the hashes in the manifest.
+	for c := range verify.NewTiles(cur.Size, cp.Size) {
+		tile, err := f.ReadTile(ctx, c.Level, c.Index, c.Partial)
+		if err != nil {
+			return err
+		}
+		if err := t.WriteTile(ctx, c.Level, c.Index, c.Partial, tile); err != nil {
+			return fmt.Errorf("failed to write tile: %v", err)
+		}
+		if c.Level > 0 {
+			continue
+		}
+		bundle, err := f.ReadEntryBundle(ctx, c.Index, c.Partial)
+		if err != nil {
+			return err
+		}
+		if err := t.WriteEntryBundle(ctx, c.Index, c.Partial, bundle); err != nil {
+			return fmt.Errorf("failed to write entry bundle: %v", err)
+		}
+	}
+	if err := t.WriteCheckpoint(ctx, cpRaw); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	klog.V(1).Infof("Applied transfer bundle: size %d -> %d", cur.Size, cp.Size)
+	return nil
+}
+
+// section locates a file within a bundle.
+type section struct {
+	off, size int64
+	hash      [sha256.Size]byte
+}
+
+// bundle provides access to the verified contents of a transfer bundle.
+type bundle struct {
+	r     io.ReaderAt
+	from  uint64
+	files map[string]section
+}
+
+// openBundle indexes the files in the bundle read from r, and checks them against the signed manifest.
+func openBundle(r io.ReaderAt, size int64, v note.Verifier) (*bundle, error) {
+	cr := &countingReader{r: io.NewSectionReader(r, 0, size)}
+	tr := tar.NewReader(cr)
+	b := &bundle{r: r, files: make(map[string]section)}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %v", err)
+		}
+		if _, ok := b.files[manifestPath]; ok {
+			return nil, fmt.Errorf("bundle contains %q after the manifest", h.Name)
+		}
+		if h.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("bundle contains %q which isn't a regular file", h.Name)
+		}
+		if _, ok := b.files[h.Name]; ok {
+			return nil, fmt.Errorf("bundle contains %q more than once", h.Name)
+		}
+		s := section{off: cr.n, size: h.Size}
+		hsh := sha256.New()
+		if _, err := io.Copy(hsh, tr); err != nil {
+			return nil, fmt.Errorf("failed to read %q from bundle: %v", h.Name, err)
+		}
+		copy(s.hash[:], hsh.Sum(nil))
+		b.files[h.Name] = s
+	}
+
+	ms, ok := b.files[manifestPath]
+	if !ok {
+		return nil, errors.New("bundle has no manifest")
+	}
+	if ms.size > maxManifestSize {
+		return nil, fmt.Errorf("manifest of %d bytes is too large", ms.size)
+	}
+	raw, err := b.read(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	n, err := note.Open(raw, note.VerifierList(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify manifest: %v", err)
+	}
+	sc := bufio.NewScanner(strings.NewReader(n.Text))
+	if !sc.Scan() || sc.Text() != manifestHeader {
+		return nil, errors.New("manifest has an unknown format")
+	}
+	if !sc.Scan() {
+		return nil, errors.New("manifest is missing from size")
+	}
+	if b.from, err = strconv.ParseUint(sc.Text(), 10, 64); err != nil {
+		return nil, fmt.Errorf("manifest has invalid from size: %v", err)
+	}
+	listed := 0
+	for sc.Scan() {
+		h, p, ok := strings.Cut(sc.Text(), " ")
+		s, found := b.files[p]
+		if !ok || !found || p == manifestPath || h != hex.EncodeToString(s.hash[:]) {
+			return nil, fmt.Errorf("manifest line %q doesn't match bundle contents", sc.Text())
+		}
+		listed++
+	}
+	if listed != len(b.files)-1 {
+		return nil, fmt.Errorf("bundle contains %d files which aren't in the manifest", len(b.files)-1-listed)
+	}
+	return b, nil
+}
+
+// read returns the contents of the file at p, checking that they haven't changed since the bundle was opened.
+func (b *bundle) read(p string) ([]byte, error) {
+	s, ok := b.files[p]
+	if !ok {
+		return nil, fmt.Errorf("%q not in bundle: %w", p, os.ErrNotExist)
+	}
+	data := make([]byte, s.size)
+	if _, err := b.r.ReadAt(data, s.off); err != nil {
+		return nil, fmt.Errorf("failed to read %q from bundle: %v", p, err)
+	}
+	if sha256.Sum256(data) != s.hash {
+		return nil, fmt.Errorf("%q changed while bundle was being applied", p)
+	}
+	return data, nil
+}
+
+// overlay reads resources from a bundle, falling back to a target for tiles which aren't in it.
+type overlay struct {
+	b *bundle
+	t Target
+}
+
+func (o overlay) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return o.b.read(layout.CheckpointPath)
+}
+
+func (o overlay) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+	if _, ok := o.b.files[layout.TilePath(l, i, p)]; !ok {
+		return o.t.ReadTile(ctx, l, i, p)
+	}
+	return o.b.read(layout.TilePath(l, i, p))
+}
+
+func (o overlay) ReadEntryBundle(_ context.Context, i uint64, p uint8) ([]byte, error) {
+	return o.b.read(layout.EntriesPath(i, p))
+}
+
+// countingReader counts the bytes read from r, which allows the offsets of files in a tar archive to be found.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// DirTarget is a Target which stores a replica of a log in a directory, laid out as per tlog-tiles.
+type DirTarget struct {
+	Root string
+}
+
+func (d DirTarget) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.Root, layout.CheckpointPath))
+}
+
+func (d DirTarget) ReadTile(_ context.Context, l, i uint64, p uint8) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.Root, layout.TilePath(l, i, p)))
+}
+
+func (d DirTarget) WriteCheckpoint(_ context.Context, data []byte) error {
+	return d.write(layout.CheckpointPath, data)
+}
+
+func (d DirTarget) WriteTile(_ context.Context, l, i uint64, p uint8, data []byte) error {
+	return d.write(layout.TilePath(l, i, p), data)
+}
+
+func (d DirTarget) WriteEntryBundle(_ context.Context, i uint64, p uint8, data []byte) error {
+	return d.write(layout.EntriesPath(i, p), data)
+}
+
+// write atomically replaces the file at p with data.
+func (d DirTarget) write(p string, data []byte) error {
+	fp := filepath.Join(d.Root, p)
+	if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+		return err
+	}
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
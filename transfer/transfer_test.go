@@ -0,0 +1,140 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func newKeys(t *testing.T, name string) (note.Signer, note.Verifier) {
+	t.Helper()
+	sk, vk, err := note.GenerateKey(rand.Reader, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return s, v
+}
+
+func TestCreateApply(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	logS, logV := newKeys(t, "example.com/log")
+	bundleS, bundleV := newKeys(t, "example.com/transfer")
+	_, otherV := newKeys(t, "example.com/transfer")
+
+	driver, err := posix.New(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(logS).
+		WithCheckpointInterval(time.Second).
+		WithBatching(1024, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	awaiter := tessera.NewPublicationAwaiter(ctx, r.ReadCheckpoint, 50*time.Millisecond)
+	// addEntries grows the log to size n, and returns a transfer bundle covering the new entries.
+	size := uint64(0)
+	addEntries := func(n uint64) []byte {
+		t.Helper()
+		var f tessera.IndexFuture
+		for i := size; i < n; i++ {
+			f = a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))
+		}
+		if _, _, err := awaiter.Await(ctx, f); err != nil {
+			t.Fatalf("Await: %v", err)
+		}
+		b := &bytes.Buffer{}
+		if err := Create(ctx, b, r, size, bundleS); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		size = n
+		return b.Bytes()
+	}
+	first, second := addEntries(300), addEntries(70000)
+
+	replica := DirTarget{Root: t.TempDir()}
+	opts := ApplyOptions{BundleVerifier: bundleV, LogVerifier: logV}
+	apply := func(b []byte, opts ApplyOptions) error {
+		return Apply(ctx, bytes.NewReader(b), int64(len(b)), replica, opts)
+	}
+
+	// The second bundle can't be applied before the first.
+	if err := apply(second, opts); err == nil {
+		t.Error("Apply of second bundle to empty replica: want error")
+	}
+	if err := apply(first, ApplyOptions{BundleVerifier: otherV, LogVerifier: logV}); err == nil {
+		t.Error("Apply with wrong bundle verifier: want error")
+	}
+	if err := apply(first, opts); err != nil {
+		t.Fatalf("Apply of first bundle: %v", err)
+	}
+	// Applying a bundle again is a no-op.
+	if err := apply(first, opts); err != nil {
+		t.Errorf("Apply of first bundle again: %v", err)
+	}
+
+	// Tampering with any entry must be detected, and nothing written.
+	tampered := bytes.Clone(second)
+	i := bytes.Index(tampered, []byte("entry 12345"))
+	tampered[i] = 'E'
+	if err := apply(tampered, opts); err == nil {
+		t.Error("Apply of tampered bundle: want error")
+	}
+	if _, err := replica.ReadTile(ctx, 0, 2, 0); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Tampered bundle was partially applied: %v", err)
+	}
+
+	if err := apply(second, opts); err != nil {
+		t.Fatalf("Apply of second bundle: %v", err)
+	}
+	want, err := r.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	if got, err := replica.ReadCheckpoint(ctx); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Replica checkpoint: got %q, %v, want %q", got, err, want)
+	}
+	for _, c := range [][3]uint64{{0, 0, 0}, {0, 273, 112}, {1, 1, 17}, {1, 0, 0}, {2, 0, 1}} {
+		got, err := replica.ReadTile(ctx, c[0], c[1], uint8(c[2]))
+		if err != nil {
+			t.Errorf("ReadTile(%v): %v", c, err)
+			continue
+		}
+		if want, err := r.ReadTile(ctx, c[0], c[1], uint8(c[2])); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("Tile %v differs from source log: %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/transparency-dev/tessera/internal/parse"
+)
+
+// WebhookSignatureHeader is the HTTP header which carries the HMAC-SHA256 signature of a webhook
+// notification's body, in the form "sha256=<hex>", when WebhookOptions.Secret is set.
+const WebhookSignatureHeader = "X-Tessera-Signature"
+
+// WebhookOptions configures webhook notifications created with NewWebhookDistributors.
+type WebhookOptions struct {
+	// Client is used to make requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// Secret, if set, is used to sign the body of each notification with HMAC-SHA256, so that
+	// receivers can authenticate them. The signature is sent in the WebhookSignatureHeader header.
+	Secret []byte
+	// Retries is the number of times a failed notification is retried, with exponential backoff,
+	// before giving up until the next checkpoint poll.
+	Retries uint
+	// RetryDelay is the delay before the first retry. If zero, one second is used.
+	RetryDelay time.Duration
+}
+
+// WebhookNotification is the JSON body POSTed to webhooks when a new checkpoint is published.
+type WebhookNotification struct {
+	Origin   string `json:"origin"`
+	Size     uint64 `json:"size"`
+	RootHash []byte `json:"root_hash"`
+	// Checkpoint is the full published checkpoint, including any cosignatures.
+	Checkpoint []byte `json:"checkpoint"`
+	// Timestamp is the time, in Unix seconds, at which the notification was sent. Since it's covered by
+	// the signature, receivers may use it to reject replayed notifications.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// NewWebhookDistributors returns a CheckpointDistributor for each of the provided URLs, which notifies
+// it of each newly published checkpoint by POSTing a JSON encoded WebhookNotification.
+//
+// This allows external systems, e.g. for cache invalidation or witness feeding, to react to log growth
+// without polling. The returned distributors should be passed to AppendOptions.WithCheckpointDistribution,
+// which notifies each URL independently so that a slow or failing receiver doesn't delay the others.
+func NewWebhookDistributors(urls []*url.URL, opts WebhookOptions) []CheckpointDistributor {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = time.Second
+	}
+	r := make([]CheckpointDistributor, 0, len(urls))
+	for _, u := range urls {
+		r = append(r, webhook{url: u, opts: opts})
+	}
+	return r
+}
+
+type webhook struct {
+	url  *url.URL
+	opts WebhookOptions
+}
+
+func (w webhook) Name() string {
+	return "webhook " + w.url.Redacted()
+}
+
+func (w webhook) Distribute(ctx context.Context, cp []byte) error {
+	origin, size, hash, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(WebhookNotification{
+		Origin:     origin,
+		Size:       size,
+		RootHash:   hash,
+		Checkpoint: cp,
+		Timestamp:  time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %v", err)
+	}
+	return retry.Do(func() error {
+		return w.post(ctx, body)
+	},
+		retry.Context(ctx),
+		retry.Attempts(w.opts.Retries+1),
+		retry.Delay(w.opts.RetryDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true))
+}
+
+func (w webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url.String(), bytes.NewReader(body))
+	if err != nil {
+		return retry.Unrecoverable(fmt.Errorf("failed to create request: %v", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.opts.Secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, WebhookSignature(w.opts.Secret, body))
+	}
+	resp, err := w.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %v", err)
+	}
+	rb, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read body: %v", err)
+	}
+	switch c := resp.StatusCode; {
+	case c >= 200 && c < 300:
+		return nil
+	case c >= 400 && c < 500 && c != http.StatusTooManyRequests && c != http.StatusRequestTimeout:
+		// The receiver doesn't want this notification, retrying won't help.
+		return retry.Unrecoverable(fmt.Errorf("got status %d: %s", c, rb))
+	default:
+		return fmt.Errorf("got status %d: %s", c, rb)
+	}
+}
+
+// WebhookSignature returns the value of the WebhookSignatureHeader header for a notification with the
+// given body, signed with secret. Receivers should compare it to the received header using hmac.Equal.
+func WebhookSignature(secret, body []byte) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWebhookDistributor(t *testing.T) {
+	const cp = "example.com/log\n42\nKgEdHVFsyqx8WXDcCnk6Cq6xdqT1ENV7BPoHUvDlJNk=\n\n— example.com/log AAAA\n"
+	secret := []byte("s3cr3t")
+	for _, test := range []struct {
+		name      string
+		statuses  []int
+		retries   uint
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "ok", statuses: []int{http.StatusNoContent}, wantCalls: 1},
+		{name: "retried", statuses: []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}, retries: 2, wantCalls: 3},
+		{name: "retries exhausted", statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, retries: 1, wantCalls: 2, wantErr: true},
+		{name: "rejected", statuses: []int{http.StatusForbidden, http.StatusOK}, retries: 3, wantCalls: 1, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if got, want := r.Header.Get(WebhookSignatureHeader), WebhookSignature(secret, body); !hmac.Equal([]byte(got), []byte(want)) {
+					t.Errorf("Got signature %q, want %q", got, want)
+				}
+				n := WebhookNotification{}
+				if err := json.Unmarshal(body, &n); err != nil {
+					t.Errorf("Unmarshal: %v", err)
+				}
+				if n.Origin != "example.com/log" || n.Size != 42 || len(n.RootHash) != 32 || !bytes.Equal(n.Checkpoint, []byte(cp)) {
+					t.Errorf("Got unexpected notification %+v", n)
+				}
+				w.WriteHeader(test.statuses[calls])
+				calls++
+			}))
+			defer s.Close()
+			u, err := url.Parse(s.URL)
+			if err != nil {
+				t.Fatalf("url.Parse: %v", err)
+			}
+
+			d := NewWebhookDistributors([]*url.URL{u}, WebhookOptions{Client: s.Client(), Secret: secret, Retries: test.retries, RetryDelay: time.Millisecond})
+			err = d[0].Distribute(t.Context(), []byte(cp))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Distribute: got err %v, want err %t", err, test.wantErr)
+			}
+			if calls != test.wantCalls {
+				t.Errorf("Got %d calls, want %d", calls, test.wantCalls)
+			}
+		})
+	}
+}
@@ -15,14 +15,18 @@
 package tessera
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"maps"
 
+	f_note "github.com/transparency-dev/formats/note"
 	"golang.org/x/mod/sumdb/note"
 )
 
@@ -47,21 +51,30 @@ func NewWitness(vkey string, witnessRoot *url.URL) (Witness, error) {
 	if err != nil {
 		return Witness{}, err
 	}
+	u, err := witnessURL(vkey, witnessRoot)
+	if err != nil {
+		return Witness{}, err
+	}
+
+	return Witness{
+		Key: v,
+		URL: u,
+	}, err
+}
+
+// witnessURL returns the URL to which checkpoints should be submitted for the witness with the
+// given verifier key, reachable at witnessRoot.
+func witnessURL(vkey string, witnessRoot *url.URL) (string, error) {
 	// "key hash" MUST be a lowercase hex-encoded SHA-256 hash of a 32-byte Ed25519 public key.
 	// This expression cuts off the identity name and hash.
 	key64 := strings.SplitAfterN(vkey, "+", 3)[2]
 	key, err := base64.StdEncoding.DecodeString(key64)
 	if err != nil {
-		return Witness{}, err
+		return "", err
 	}
 	h := sha256.Sum256(key)
 
-	u := witnessRoot.JoinPath(fmt.Sprintf("/%x/add-checkpoint", h))
-
-	return Witness{
-		Key: v,
-		URL: u.String(),
-	}, err
+	return witnessRoot.JoinPath(fmt.Sprintf("/%x/add-checkpoint", h)).String(), nil
 }
 
 // Witness represents a single witness that can be reached in order to perform a witnessing operation.
@@ -88,7 +101,13 @@ func (w Witness) Satisfied(cp []byte) bool {
 // response. The returned result is a map from the URL that should be used to update
 // the witness with a new checkpoint, to the value which is the verifier to check
 // the response is well formed.
+//
+// Witnesses without a URL, e.g. those parsed from a policy which is only used for verification,
+// have no endpoints.
 func (w Witness) Endpoints() map[string]note.Verifier {
+	if w.URL == "" {
+		return map[string]note.Verifier{}
+	}
 	return map[string]note.Verifier{w.URL: w.Key}
 }
 
@@ -156,3 +175,118 @@ func (wg WitnessGroup) Endpoints() map[string]note.Verifier {
 	}
 	return endpoints
 }
+
+// NewWitnessGroupFromPolicy creates a WitnessGroup from a witness policy, in the text format used by
+// Sigsum and the C2SP witness ecosystem.
+//
+// The policy is made up of lines of the following forms:
+//
+//	witness <name> <verifier key> [<URL>]
+//	group <name> <all|any|threshold> <member name>...
+//	quorum <member name|none>
+//
+// Blank lines, lines starting with #, and log lines are ignored. Witness and group names must be
+// unique, and groups may only refer to previously defined members. Exactly one quorum line must be
+// present, and the returned WitnessGroup represents the named member.
+//
+// Witnesses without a URL may only be used for verifying checkpoints with Satisfied, and will not be
+// contacted when witnessing.
+func NewWitnessGroupFromPolicy(p []byte) (WitnessGroup, error) {
+	components := make(map[string]policyComponent)
+	var quorum *WitnessGroup
+
+	sc := bufio.NewScanner(bytes.NewReader(p))
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "log":
+			continue
+		case "witness":
+			if len(fields) < 3 || len(fields) > 4 {
+				return WitnessGroup{}, fmt.Errorf("line %d: witness line must have the form: witness <name> <verifier key> [<URL>]", lineNo)
+			}
+			name, vkey := fields[1], fields[2]
+			if _, ok := components[name]; ok {
+				return WitnessGroup{}, fmt.Errorf("line %d: duplicate name %q", lineNo, name)
+			}
+			v, err := f_note.NewVerifier(vkey)
+			if err != nil {
+				return WitnessGroup{}, fmt.Errorf("line %d: invalid verifier key: %v", lineNo, err)
+			}
+			w := Witness{Key: v}
+			if len(fields) == 4 {
+				root, err := url.Parse(fields[3])
+				if err != nil {
+					return WitnessGroup{}, fmt.Errorf("line %d: invalid URL: %v", lineNo, err)
+				}
+				if w.URL, err = witnessURL(vkey, root); err != nil {
+					return WitnessGroup{}, fmt.Errorf("line %d: invalid verifier key: %v", lineNo, err)
+				}
+			}
+			components[name] = w
+		case "group":
+			if len(fields) < 4 {
+				return WitnessGroup{}, fmt.Errorf("line %d: group line must have the form: group <name> <all|any|threshold> <member name>...", lineNo)
+			}
+			name, members := fields[1], fields[3:]
+			if _, ok := components[name]; ok {
+				return WitnessGroup{}, fmt.Errorf("line %d: duplicate name %q", lineNo, name)
+			}
+			children := make([]policyComponent, 0, len(members))
+			for _, m := range members {
+				c, ok := components[m]
+				if !ok {
+					return WitnessGroup{}, fmt.Errorf("line %d: unknown member %q", lineNo, m)
+				}
+				children = append(children, c)
+			}
+			var n int
+			switch fields[2] {
+			case "all":
+				n = len(children)
+			case "any":
+				n = 1
+			default:
+				var err error
+				n, err = strconv.Atoi(fields[2])
+				if err != nil || n < 1 || n > len(children) {
+					return WitnessGroup{}, fmt.Errorf("line %d: invalid threshold %q for %d members", lineNo, fields[2], len(children))
+				}
+			}
+			components[name] = NewWitnessGroup(n, children...)
+		case "quorum":
+			if len(fields) != 2 {
+				return WitnessGroup{}, fmt.Errorf("line %d: quorum line must have the form: quorum <member name|none>", lineNo)
+			}
+			if quorum != nil {
+				return WitnessGroup{}, fmt.Errorf("line %d: duplicate quorum line", lineNo)
+			}
+			if fields[1] == "none" {
+				quorum = &WitnessGroup{}
+				continue
+			}
+			c, ok := components[fields[1]]
+			if !ok {
+				return WitnessGroup{}, fmt.Errorf("line %d: unknown quorum member %q", lineNo, fields[1])
+			}
+			g, ok := c.(WitnessGroup)
+			if !ok {
+				g = NewWitnessGroup(1, c)
+			}
+			quorum = &g
+		default:
+			return WitnessGroup{}, fmt.Errorf("line %d: unknown keyword %q", lineNo, fields[0])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return WitnessGroup{}, err
+	}
+	if quorum == nil {
+		return WitnessGroup{}, fmt.Errorf("policy has no quorum line")
+	}
+	return *quorum, nil
+}
@@ -1,6 +1,7 @@
 package tessera_test
 
 import (
+	"fmt"
 	"net/url"
 	"slices"
 	"testing"
@@ -208,3 +209,65 @@ func BenchmarkWitnessGroupSatisfaction(b *testing.B) {
 		}
 	}
 }
+
+func TestNewWitnessGroupFromPolicy(t *testing.T) {
+	policy := fmt.Sprintf(`
+# Example policy
+log example.com/log+12345678+AAAA
+witness w1 %s https://b1.example.com/
+witness w2 %s
+witness w3 %s https://b2.example.com/
+group g2 any w2 w3
+group root all w1 g2
+quorum root
+`, wit1_vkey, wit2_vkey, wit3_vkey)
+	g, err := tessera.NewWitnessGroupFromPolicy([]byte(policy))
+	if err != nil {
+		t.Fatalf("NewWitnessGroupFromPolicy: %v", err)
+	}
+	for _, tC := range []struct {
+		desc            string
+		signers         []note.Signer
+		expectSatisfied bool
+	}{
+		{desc: "all", signers: []note.Signer{wit1Sign, wit2Sign, wit3Sign}, expectSatisfied: true},
+		{desc: "min", signers: []note.Signer{wit1Sign, wit2Sign}, expectSatisfied: true},
+		{desc: "missing required", signers: []note.Signer{wit2Sign, wit3Sign}},
+		{desc: "missing group", signers: []note.Signer{wit1Sign}},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			cp, err := note.Sign(&note.Note{Text: "sign me\n"}, tC.signers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := g.Satisfied(cp), tC.expectSatisfied; got != want {
+				t.Errorf("Expected satisfied = %t but got %t", want, got)
+			}
+		})
+	}
+	// w2 has no URL, so only w1 and w3 can be contacted.
+	if got, want := len(g.Endpoints()), 2; got != want {
+		t.Errorf("Got %d endpoints, want %d", got, want)
+	}
+}
+
+func TestNewWitnessGroupFromPolicy_Invalid(t *testing.T) {
+	for _, tC := range []struct {
+		desc   string
+		policy string
+	}{
+		{desc: "no quorum", policy: fmt.Sprintf("witness w1 %s\n", wit1_vkey)},
+		{desc: "unknown quorum", policy: fmt.Sprintf("witness w1 %s\nquorum w2\n", wit1_vkey)},
+		{desc: "duplicate name", policy: fmt.Sprintf("witness w1 %s\nwitness w1 %s\nquorum w1\n", wit1_vkey, wit2_vkey)},
+		{desc: "bad key", policy: "witness w1 nope\nquorum w1\n"},
+		{desc: "unknown member", policy: fmt.Sprintf("witness w1 %s\ngroup g any w1 w2\nquorum g\n", wit1_vkey)},
+		{desc: "bad threshold", policy: fmt.Sprintf("witness w1 %s\ngroup g 2 w1\nquorum g\n", wit1_vkey)},
+		{desc: "unknown keyword", policy: "frobnicate\nquorum none\n"},
+	} {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := tessera.NewWitnessGroupFromPolicy([]byte(tC.policy)); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}